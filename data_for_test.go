@@ -335,10 +335,10 @@ var suites = []FixtureSuite{
 			Expect: Results{
 				Config:   otelcli.DefaultConfig(),
 				ExitCode: 1,
-				// strips the date off the log line before comparing to expectation
-				CliOutputRe: regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `),
-				CliOutput: "Error while loading environment variables: could not parse OTEL_CLI_VERBOSE value " +
-					"\"lmao\" as an bool: strconv.ParseBool: parsing \"lmao\": invalid syntax\n",
+				// strips the time and level off the log line before comparing to expectation
+				CliOutputRe: regexp.MustCompile(`^time=\S+ level=ERROR `),
+				CliOutput: `msg="Error while loading environment variables: could not parse OTEL_CLI_VERBOSE value ` +
+					`\"lmao\" as an bool: strconv.ParseBool: parsing \"lmao\": invalid syntax"` + "\n",
 			},
 		},
 		{
@@ -434,6 +434,46 @@ var suites = []FixtureSuite{
 				},
 			},
 		},
+		{
+			Name: "OTEL_EXPORTER_OTLP_TRACES_TIMEOUT is in milliseconds",
+			Config: FixtureConfig{
+				CliArgs: []string{"status"},
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT":       "{{endpoint}}",
+					"OTEL_EXPORTER_OTLP_TRACES_TIMEOUT": "2000",
+				},
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}").WithTimeout("2000ms"),
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT":       "{{endpoint}}",
+					"OTEL_EXPORTER_OTLP_TRACES_TIMEOUT": "2000",
+				},
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           1,
+					ParsedTimeoutMs:   2000,
+					Endpoint:          "*",
+					EndpointSource:    "*",
+				},
+			},
+		},
+		{
+			Name: "OTEL_TRACES_EXPORTER=none disables exports",
+			Config: FixtureConfig{
+				CliArgs: []string{"span"},
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+					"OTEL_TRACES_EXPORTER":        "none",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 0,
+			},
+		},
 		{
 			Name: "#200 custom trace path in general endpoint gets signal path appended",
 			Config: FixtureConfig{
@@ -658,6 +698,66 @@ var suites = []FixtureSuite{
 			},
 		},
 	},
+	// unsampled parents are not exported with a parent-based sampler and
+	// the sampled flag is carried through to the printed traceparent
+	{
+		{
+			Name: "otel-cli span --sampler parentbased_always_on with unsampled parent",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "--tp-print",
+					"--endpoint", "{{endpoint}}",
+					"--sampler", "parentbased_always_on",
+					"--force-span-id", "beefcafefacedead",
+				},
+				Env: map[string]string{
+					"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 0,
+				CliOutput: "" +
+					"# trace id: f6c109f48195b451c4def6ab32f47b61\n" +
+					"#  span id: beefcafefacedead\n" +
+					"TRACEPARENT=00-f6c109f48195b451c4def6ab32f47b61-beefcafefacedead-00\n",
+			},
+		},
+	},
+	// otel-cli span --tp-print-format prints ids in alternate formats
+	{
+		{
+			Name: "otel-cli span --tp-print --tp-print-format b3 (non-recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--tp-print", "--tp-print-format", "b3"},
+				Env: map[string]string{
+					"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				CliOutput: "f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-1\n",
+			},
+		},
+	},
+	// otel-cli span --json prints a result object instead of --tp-print's output
+	{
+		{
+			Name: "otel-cli span --json --tp-print (non-recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--json", "--tp-print"},
+				Env: map[string]string{
+					"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01",
+				},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				CliOutput: `{"trace_id":"f6c109f48195b451c4def6ab32f47b61","span_id":"a5d2a35f2483004e",` +
+					`"traceparent":"00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01","endpoint":"",` +
+					`"status":"not_recording","retries":0,"exit_code":0}` + "\n",
+			},
+		},
+	},
 	// otel-cli span background, non-recording, this uses the suite functionality
 	// and background tasks, which are a little clunky but get the job done
 	{
@@ -968,6 +1068,43 @@ var suites = []FixtureSuite{
 				SpanCount: 1,
 			},
 		},
+		{
+			Name: "--protocol http/json",
+			Config: FixtureConfig{
+				ServerProtocol: httpProtocol,
+				CliArgs: []string{
+					"status",
+					"--endpoint", "http://{{endpoint}}",
+					"--protocol", "http/json",
+					"--force-trace-id", "00112233445566778899aabbccddeeff",
+					"--force-span-id", "beefcafefacedead",
+				},
+				TestTimeoutMs: 1000,
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig().WithEndpoint("http://{{endpoint}}").WithProtocol("http/json"),
+				ServerMeta: map[string]string{
+					"content-type": "application/json",
+					"host":         "{{endpoint}}",
+					"method":       "POST",
+					"proto":        "HTTP/1.1",
+					"uri":          "/v1/traces",
+				},
+				SpanData: map[string]string{
+					"trace_id": "00112233445566778899aabbccddeeff",
+					"span_id":  "beefcafefacedead",
+				},
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					NumArgs:           9,
+					DetectedLocalhost: true,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "*",
+					EndpointSource:    "*",
+				},
+				SpanCount: 1,
+			},
+		},
 		{
 			Name: "protocol: bad config",
 			Config: FixtureConfig{
@@ -975,8 +1112,8 @@ var suites = []FixtureSuite{
 				TestTimeoutMs: 1000,
 			},
 			Expect: Results{
-				CliOutputRe: regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `),
-				CliOutput:   "invalid protocol setting \"xxx\"\n",
+				CliOutputRe: regexp.MustCompile(`^time=\S+ level=ERROR `),
+				CliOutput:   `msg="invalid protocol setting \"xxx\""` + "\n",
 				Config:      otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
 				Diagnostics: otelcli.Diagnostics{
 					IsRecording:       false,
@@ -1065,8 +1202,8 @@ var suites = []FixtureSuite{
 			},
 			Expect: Results{
 				ExitCode:    1,
-				CliOutputRe: regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `),
-				CliOutput:   "invalid protocol setting \"roflcopter\"\n",
+				CliOutputRe: regexp.MustCompile(`^time=\S+ level=ERROR `),
+				CliOutput:   `msg="invalid protocol setting \"roflcopter\""` + "\n",
 				Config:      otelcli.DefaultConfig().WithEndpoint("http://{{endpoint}}"),
 				Diagnostics: otelcli.Diagnostics{
 					IsRecording:       false,
@@ -1138,6 +1275,7 @@ var suites = []FixtureSuite{
 				Headers: map[string]string{
 					":authority":                  "{{endpoint}}\n",
 					"content-type":                "application/grpc\n",
+					"grpc-accept-encoding":        "gzip\n", // gzip codec is always registered
 					"user-agent":                  "*",
 					"x-otel-cli-otlpserver-token": "abcdefgabcdefg\n",
 				},
@@ -1173,7 +1311,7 @@ var suites = []FixtureSuite{
 				Headers: map[string]string{
 					"Content-Type":                "application/x-protobuf",
 					"Accept-Encoding":             "gzip",
-					"User-Agent":                  "Go-http-client/1.1",
+					"User-Agent":                  "otel-cli/unknown",
 					"Content-Length":              "232",
 					"X-Otel-Cli-Otlpserver-Token": "abcdefgabcdefg",
 				},
@@ -1188,6 +1326,231 @@ var suites = []FixtureSuite{
 			},
 		},
 	},
+	// --user-agent and --grpc-metadata let gateways identify otel-cli
+	{
+		{
+			Name: "--user-agent overrides the http User-Agent",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "http://{{endpoint}}",
+					"--user-agent", "nightly-backup/2.0",
+				},
+				ServerProtocol: httpProtocol,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("http://{{endpoint}}").
+					WithUserAgent("nightly-backup/2.0"),
+				Headers: map[string]string{
+					"Content-Type":    "application/x-protobuf",
+					"Accept-Encoding": "gzip",
+					"User-Agent":      "nightly-backup/2.0",
+					"Content-Length":  "*",
+				},
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           5,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "http://{{endpoint}}/v1/traces",
+					EndpointSource:    "general",
+				},
+			},
+		},
+		{
+			Name: "--grpc-metadata is sent with grpc exports",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "{{endpoint}}",
+					"--grpc-metadata", "x-tenant=acme",
+				},
+				ServerProtocol: grpcProtocol,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("{{endpoint}}").
+					WithGrpcMetadata(map[string]string{"x-tenant": "acme"}),
+				Headers: map[string]string{
+					":authority":           "{{endpoint}}\n",
+					"content-type":         "application/grpc\n",
+					"grpc-accept-encoding": "gzip\n",
+					"user-agent":           "*",
+					"x-tenant":             "acme\n",
+				},
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           5,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "grpc://{{endpoint}}",
+					EndpointSource:    "general",
+				},
+			},
+		},
+	},
+	// --otlp-header-cmd output is sent as headers
+	{
+		{
+			Name: "--otlp-header-cmd headers for authentication",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "{{endpoint}}",
+					"--protocol", "grpc",
+					"--otlp-header-cmd", "echo x-otel-cli-otlpserver-token=abcdefgabcdefg",
+				},
+				ServerProtocol: grpcProtocol,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("{{endpoint}}").
+					WithProtocol("grpc").
+					WithOtlpHeaderCmd("echo x-otel-cli-otlpserver-token=abcdefgabcdefg"),
+				Headers: map[string]string{
+					":authority":                  "{{endpoint}}\n",
+					"content-type":                "application/grpc\n",
+					"grpc-accept-encoding":        "gzip\n", // gzip codec is always registered
+					"user-agent":                  "*",
+					"x-otel-cli-otlpserver-token": "abcdefgabcdefg\n",
+				},
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           7,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "grpc://{{endpoint}}",
+					EndpointSource:    "general",
+				},
+			},
+		},
+	},
+	// --compression gzip works end to end for both protocols
+	{
+		{
+			Name: "--compression gzip (grpc)",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "{{endpoint}}",
+					"--compression", "gzip",
+				},
+				ServerProtocol: grpcProtocol,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("{{endpoint}}").
+					WithCompression("gzip"),
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           5,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "*",
+					EndpointSource:    "*",
+				},
+			},
+		},
+		{
+			Name: "--compression gzip (http)",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "http://{{endpoint}}",
+					"--compression", "gzip",
+				},
+				ServerProtocol: httpProtocol,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("http://{{endpoint}}").
+					WithCompression("gzip"),
+				Headers: map[string]string{
+					"Content-Type":     "application/x-protobuf",
+					"Content-Encoding": "gzip",
+					"Accept-Encoding":  "gzip",
+					"User-Agent":       "otel-cli/unknown",
+					"Content-Length":   "*",
+				},
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           5,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "*",
+					EndpointSource:    "*",
+				},
+			},
+		},
+	},
+	// fan-out to multiple endpoints sends the same span to each of them
+	{
+		{
+			Name: "--endpoint repeated sends to every endpoint",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "{{endpoint}}",
+					"--endpoint", "http://{{endpoint}}",
+					"--fanout-policy", "any",
+				},
+				ServerProtocol: grpcProtocol,
+			},
+			Expect: Results{
+				// the http target fails against the grpc server, which is
+				// fine with --fanout-policy any
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("{{endpoint}},http://{{endpoint}}").
+					WithFanoutPolicy("any"),
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           7,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "*",
+					EndpointSource:    "*",
+				},
+			},
+		},
+	},
+	// failover sends to the first endpoint that accepts the span
+	{
+		{
+			Name: "--fanout-policy failover skips a dead endpoint",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"status",
+					"--endpoint", "127.0.0.1:1",
+					"--endpoint", "{{endpoint}}",
+					"--fanout-policy", "failover",
+					"--connect-timeout", "100ms",
+				},
+				ServerProtocol: grpcProtocol,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("127.0.0.1:1,{{endpoint}}").
+					WithFanoutPolicy("failover").
+					WithConnectTimeout("100ms"),
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					DetectedLocalhost: true,
+					NumArgs:           9,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "grpc://{{endpoint}}",
+					EndpointSource:    "*",
+				},
+			},
+		},
+	},
 	// exec signal and timeout behavior
 	{
 		{
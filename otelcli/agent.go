@@ -0,0 +1,286 @@
+package otelcli
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// agentCmd represents the agent command
+func agentCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "agent",
+		Short: "run a local daemon that batches spans from other otel-cli invocations",
+		Long: `Runs a long-lived daemon that accepts spans from other otel-cli commands
+over a unix socket and exports them in batches over a single connection.
+Commands with --agent-socket or OTEL_CLI_AGENT_SOCKET set hand their spans to
+the agent and return right away instead of connecting to the endpoint
+themselves, which saves the connect and TLS handshake on every call in scripts
+that run otel-cli hundreds of times. If the agent isn't running, they export
+directly as usual.
+
+The agent is configured with the same endpoint, TLS, and header settings as
+any other otel-cli command. It flushes on an interval, when a batch fills up,
+and on SIGINT or SIGTERM before exiting.
+
+So a runaway loop can't flood the collector through the agent, --max-rate
+drops spans beyond that many a second and --max-queue drops them while that
+many are waiting to be flushed. Drops are logged with --verbose.
+
+Example:
+	export OTEL_CLI_AGENT_SOCKET=/tmp/otel-cli-agent.sock
+	otel-cli agent --endpoint https://otlp.example.com &
+	for host in $(cat hosts.txt); do
+		otel-cli exec --name "ping $host" -- ping -c 1 $host
+	done
+`,
+		Run: doAgent,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&config.AgentFlushInterval, "flush-interval", defaults.AgentFlushInterval, "how often to export buffered spans")
+	cmd.Flags().IntVar(&config.AgentBatchSize, "batch-size", defaults.AgentBatchSize, "export as soon as this many spans are buffered")
+	cmd.Flags().IntVar(&config.IngestMaxRate, "max-rate", defaults.IngestMaxRate, "drop spans received beyond this many a second, 0 for no limit")
+	cmd.Flags().IntVar(&config.IngestMaxQueue, "max-queue", defaults.IngestMaxQueue, "drop spans received while this many are waiting to be exported, 0 for no limit")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doAgent(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if config.AgentSocket == "" {
+		config.SoftFail("a socket path is required, set --agent-socket or OTEL_CLI_AGENT_SOCKET")
+	} else if !config.WithAgentSocket("").GetIsRecording() {
+		config.SoftFail("an endpoint is required to run the agent")
+	}
+
+	interval, err := parseDuration(config.AgentFlushInterval)
+	config.SoftFailIfErr(err)
+	if interval <= 0 {
+		config.SoftFail("--flush-interval must be greater than zero")
+	}
+
+	listener := listenAgentSocket(config)
+
+	// the agent exports directly, so it must not try to send to itself
+	ctx, client := StartClient(ctx, config.WithAgentSocket(""))
+	agent := newAgentServer(config, client)
+
+	server := otlpserver.NewHttpServer(agent.callback, func(otlpserver.OtlpServer) {})
+	go server.Serve(listener)
+	config.SoftLog("agent listening on %s", config.AgentSocket)
+
+	sig := agent.run(ctx, interval, nil)
+	config.SoftLog("agent shutting down on %s", sig)
+
+	// stop accepting spans, then send everything that's left
+	server.StopWait()
+	os.Remove(config.AgentSocket)
+	agent.flush(ctx)
+	agent.limiter.logDrops(config, "spans")
+
+	_, err = client.Stop(ctx)
+	config.SoftLogIfErr(err)
+}
+
+// listenAgentSocket listens on the agent socket, cleaning up a stale socket
+// file left by an agent that didn't exit cleanly. Fails if another agent is
+// already listening.
+func listenAgentSocket(config Config) net.Listener {
+	if _, err := os.Stat(config.AgentSocket); err == nil {
+		if conn, err := net.Dial("unix", config.AgentSocket); err == nil {
+			conn.Close()
+			config.SoftFail("an agent is already listening on %s", config.AgentSocket)
+		}
+		if err := os.Remove(config.AgentSocket); err != nil {
+			config.SoftFail("failed to remove stale agent socket '%s': %s", config.AgentSocket, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", config.AgentSocket)
+	if err != nil {
+		config.SoftFail("unable to listen on agent socket '%s': %s", config.AgentSocket, err)
+	}
+
+	return listener
+}
+
+// agentServer buffers spans received from otel-cli clients until they're
+// flushed to the upstream client.
+type agentServer struct {
+	config    Config
+	client    otlpclient.OTLPClient
+	batchSize int
+	full      chan struct{}
+	limiter   *ingestLimiter
+
+	// resourceAttrs are merged into the resource of every span, see server_forward.go
+	resourceAttrs []*commonpb.KeyValue
+
+	mu     sync.Mutex
+	buffer []*tracepb.ResourceSpans
+	spans  int
+}
+
+// newAgentServer returns an agentServer that exports to the provided client.
+func newAgentServer(config Config, client otlpclient.OTLPClient) *agentServer {
+	return &agentServer{
+		config:    config,
+		client:    client,
+		batchSize: config.AgentBatchSize,
+		full:      make(chan struct{}, 1),
+		limiter:   newIngestLimiter(config),
+	}
+}
+
+// callback is the otlpserver.Callback that adds each received span to the
+// buffer with its resource and scope, signaling when the batch is full.
+// Spans over --max-rate or --max-queue are dropped.
+func (as *agentServer) callback(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+	rs := spanResourceSpans(span, rss)
+	if len(as.resourceAttrs) > 0 {
+		rs.Resource = mergeResourceAttrs(rs.Resource, as.resourceAttrs)
+	}
+
+	as.mu.Lock()
+	if err := as.limiter.allow(time.Now(), as.spans); err != nil {
+		as.mu.Unlock()
+		return false
+	}
+	as.buffer = append(as.buffer, rs)
+	as.spans++
+	full := as.batchSize > 0 && as.spans >= as.batchSize
+	as.mu.Unlock()
+
+	if full {
+		select {
+		case as.full <- struct{}{}:
+		default: // a flush is already pending
+		}
+	}
+
+	return false
+}
+
+// run flushes the buffer every interval and whenever it fills up, until
+// SIGINT or SIGTERM arrives or done is closed. Returns the signal, or nil
+// when done was closed.
+func (as *agentServer) run(ctx context.Context, interval time.Duration, done <-chan struct{}) os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			as.flush(ctx)
+		case <-as.full:
+			as.flush(ctx)
+		case sig := <-signals:
+			return sig
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// flush exports the buffered spans. On failure, the spans are spooled when
+// --spool-dir is set and dropped otherwise, so a bad endpoint can't make the
+// agent's memory grow without bound.
+func (as *agentServer) flush(ctx context.Context) {
+	as.mu.Lock()
+	rsps := as.buffer
+	as.buffer = nil
+	as.spans = 0
+	as.mu.Unlock()
+	as.limiter.logDrops(as.config, "spans")
+
+	if len(rsps) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(as.config.GetTimeout()))
+	defer cancel()
+
+	_, err := as.client.UploadTraces(ctx, rsps)
+	if err == nil {
+		return
+	}
+
+	if spoolDir := as.config.GetSpoolDir(); spoolDir != "" {
+		if _, serr := otlpclient.SpoolTraces(spoolDir, as.config.GetSpoolKey(), rsps); serr != nil {
+			as.config.SoftLog("failed to export %d spans: %s, and spooling failed: %s", len(rsps), err, serr)
+		} else {
+			as.config.SoftLog("failed to export %d spans, spooled to %s: %s", len(rsps), spoolDir, err)
+		}
+		return
+	}
+	as.config.SoftLog("failed to export %d spans, dropping them: %s", len(rsps), err)
+}
+
+// agentAvailable returns true if an agent is listening on the socket.
+func agentAvailable(socket string) bool {
+	conn, err := net.DialTimeout("unix", socket, 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// withAgent returns a copy of the config that sends spans to the agent on
+// its unix socket over OTLP/HTTP. Headers, auth, and the other export
+// settings are the agent's business, so they're cleared here.
+func (c Config) withAgent() Config {
+	c.Endpoint = "unix://" + c.AgentSocket
+	c.TracesEndpoint = ""
+	c.Protocol = "http/protobuf"
+	c.Compression = ""
+	c.Proxy = ""
+	c.Headers = map[string]string{}
+	c.TracesHeaders = map[string]string{}
+	c.OtlpHeadersFile = ""
+	c.OtlpHeaderCmd = ""
+	c.OAuth2TokenURL = ""
+	c.Targets = nil
+	c.RetryMaxAttempts = 1
+	return c
+}
+
+// WithAgentSocket returns the config with AgentSocket set to the provided value.
+func (c Config) WithAgentSocket(with string) Config {
+	c.AgentSocket = with
+	return c
+}
+
+// WithAgentFlushInterval returns the config with AgentFlushInterval set to the provided value.
+func (c Config) WithAgentFlushInterval(with string) Config {
+	c.AgentFlushInterval = with
+	return c
+}
+
+// WithAgentBatchSize returns the config with AgentBatchSize set to the provided value.
+func (c Config) WithAgentBatchSize(with int) Config {
+	c.AgentBatchSize = with
+	return c
+}
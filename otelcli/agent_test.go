@@ -0,0 +1,124 @@
+package otelcli
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// agentTestClient records the uploads the agent makes instead of exporting them.
+type agentTestClient struct {
+	mu      sync.Mutex
+	uploads [][]*tracepb.ResourceSpans
+}
+
+func (c *agentTestClient) Start(ctx context.Context) (context.Context, error) { return ctx, nil }
+func (c *agentTestClient) Stop(ctx context.Context) (context.Context, error)  { return ctx, nil }
+func (c *agentTestClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads = append(c.uploads, rsps)
+	return ctx, nil
+}
+
+func TestAgent(t *testing.T) {
+	// unix socket paths are limited to ~100 bytes, so keep it short
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+
+	config := DefaultConfig().
+		WithEndpoint("grpc://localhost:4317").
+		WithAgentSocket(socket).
+		WithAgentBatchSize(2)
+
+	if agentAvailable(socket) {
+		t.Fatal("expected the agent to be unavailable before it listens")
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %s", socket, err)
+	}
+	upstream := &agentTestClient{}
+	agent := newAgentServer(config, upstream)
+	server := otlpserver.NewHttpServer(agent.callback, func(otlpserver.OtlpServer) {})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	if !agentAvailable(socket) {
+		t.Fatal("expected the agent to be available once it listens")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, client := StartClient(ctx, config)
+	if _, ok := client.(*otlpclient.HttpClient); !ok {
+		t.Fatalf("expected an HTTP client for the agent socket but got %T", client)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		span := config.WithSpanName(name).NewProtobufSpan()
+		if _, err := otlpclient.SendSpan(ctx, client, config, span); err != nil {
+			t.Fatalf("failed to send span to agent: %s", err)
+		}
+	}
+
+	select {
+	case <-agent.full:
+	default:
+		t.Error("expected the agent to signal a full batch")
+	}
+
+	agent.flush(ctx)
+	agent.flush(ctx) // nothing buffered, must not upload
+
+	if len(upstream.uploads) != 1 {
+		t.Fatalf("expected 1 upload but got %d", len(upstream.uploads))
+	}
+	got := upstream.uploads[0]
+	if len(got) != 2 {
+		t.Fatalf("expected 2 spans in the batch but got %d", len(got))
+	}
+	for i, name := range []string{"first", "second"} {
+		ss := got[i].ScopeSpans[0]
+		if ss.Spans[0].Name != name {
+			t.Errorf("expected span %d to be %q but got %q", i, name, ss.Spans[0].Name)
+		}
+		if ss.Scope.GetName() != "github.com/equinix-labs/otel-cli" {
+			t.Errorf("expected the scope to be kept but got %q", ss.Scope.GetName())
+		}
+		if got[i].Resource == nil {
+			t.Error("expected the resource to be kept")
+		}
+	}
+}
+
+func TestWithAgentSocket(t *testing.T) {
+	if DefaultConfig().WithAgentSocket("/tmp/agent.sock").AgentSocket != "/tmp/agent.sock" {
+		t.Fail()
+	}
+}
+
+func TestWithAgentFlushInterval(t *testing.T) {
+	if DefaultConfig().WithAgentFlushInterval("5s").AgentFlushInterval != "5s" {
+		t.Fail()
+	}
+}
+
+func TestWithAgentBatchSize(t *testing.T) {
+	if DefaultConfig().WithAgentBatchSize(10).AgentBatchSize != 10 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,85 @@
+package otelcli
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// grafanaAnnotate holds the command-line configured settings for otel-cli annotate grafana.
+var grafanaAnnotate struct {
+	text string
+}
+
+func annotateCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "annotate",
+		Short: "mark dashboards with links to traces",
+		Long:  "Post annotations to dashboards linked to the current trace. See subcommands.",
+	}
+
+	cmd.AddCommand(annotateGrafanaCmd(config))
+
+	return &cmd
+}
+
+func annotateGrafanaCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "grafana",
+		Short: "post a Grafana annotation linked to the current trace",
+		Long: `Post a Grafana annotation with the text, tagged with the trace id from
+TRACEPARENT or --tp-carrier, so e.g. deploy markers on dashboards lead
+straight to the deploy's trace. Set --grafana-trace-url to a link to your
+trace view, with {trace_id} where the trace id goes, to put a link in the
+annotation's text. The annotation covers --start to --end, or is a point in
+time at now without them.
+
+exec, cron, and systemd-exec post an annotation for the command they run
+with --annotate.
+
+Example:
+	otel-cli annotate grafana --grafana-url https://grafana.example.com \
+		--grafana-trace-url 'https://grafana.example.com/explore?left={"queries":[{"query":"{trace_id}"}]}' \
+		--text "deploy v1.2"
+`,
+		Args: cobra.NoArgs,
+		Run:  doAnnotateGrafana,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&grafanaAnnotate.text, "text", "", "the annotation's text")
+	addGrafanaParams(&cmd, config)
+	addSpanStartEndParams(&cmd, config)
+	cmd.Flags().StringVarP(&config.CfgFile, "config", "c", defaults.CfgFile, "YAML or JSON configuration file, defaults to ~/.config/otel-cli/config.yaml if it exists")
+	cmd.Flags().StringVar(&config.Profile, "profile", defaults.Profile, "name of a profile in the configuration file to apply")
+	cmd.Flags().StringVar(&config.TraceparentCarrierFile, "tp-carrier", defaults.TraceparentCarrierFile, "a file to read the traceparent from")
+	cmd.Flags().BoolVar(&config.TraceparentIgnoreEnv, "tp-ignore-env", defaults.TraceparentIgnoreEnv, "ignore the TRACEPARENT envvar even if it's set")
+	cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for posting the annotation")
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(&cmd, config)
+	cmd.Flags().BoolVar(&config.Fail, "fail", defaults.Fail, "on failure, exit with a non-zero status")
+
+	return &cmd
+}
+
+func doAnnotateGrafana(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	if grafanaAnnotate.text == "" {
+		config.SoftFail("--text is required")
+	}
+
+	start, end := time.Now(), time.Time{}
+	if config.SpanStartTime != "" {
+		start = config.ParseSpanStartTime()
+	}
+	if config.SpanEndTime != "" {
+		end = config.ParseSpanEndTime()
+	}
+
+	tp := config.LoadTraceparent()
+	annotation := config.NewGrafanaAnnotation(grafanaAnnotate.text, tp.TraceId, start, end)
+	config.SoftFailIfErr(config.PostGrafanaAnnotation(cmd.Context(), annotation))
+}
@@ -0,0 +1,351 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// async sends are written to the async directory as spool files. The helper
+// renames a file to .sending while it exports, and to .failed if the export
+// fails and there is no --spool-dir to put it in.
+const (
+	asyncSendingExt = ".sending"
+	asyncFailedExt  = ".failed"
+	// asyncGrace is added to --timeout for the helper to start up and exit
+	// before a .sending file is considered abandoned
+	asyncGrace = 5 * time.Second
+)
+
+// AsyncStatus is the output of otel-cli async status.
+type AsyncStatus struct {
+	Dir     string `json:"dir"`
+	Pending int    `json:"pending"`
+	Failed  int    `json:"failed"`
+}
+
+// asyncCmd represents the async command, for checking on --async sends
+func asyncCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "async",
+		Short: "check on spans sent with --async",
+		Long: `Commands run with --async hand their spans to a detached helper process and
+return immediately. These subcommands report on and wait for those sends.
+
+Example:
+	otel-cli exec --async --name "quick" -- true
+	otel-cli async wait --timeout 5s
+`,
+	}
+
+	cmd.AddCommand(asyncStatusCmd(config))
+	cmd.AddCommand(asyncWaitCmd(config))
+	cmd.AddCommand(asyncSendCmd(config))
+
+	return &cmd
+}
+
+// asyncStatusCmd represents the async status command
+func asyncStatusCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "status",
+		Short: "print the number of pending and failed async sends",
+		Long: `Prints the number of --async sends that are still pending and the number
+that failed as JSON. Failed sends are kept until 'otel-cli async wait' reports
+them, unless --spool-dir is set, in which case they are spooled instead.`,
+		Run: doAsyncStatus,
+	}
+
+	addCommonParams(&cmd, config)
+	addAsyncParams(&cmd, config)
+
+	return &cmd
+}
+
+// asyncWaitCmd represents the async wait command
+func asyncWaitCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "wait",
+		Short: "wait for pending async sends to complete",
+		Long: `Waits up to --timeout for pending --async sends to complete. Fails if any
+are still pending or if any failed, and clears the failures so each one is
+only reported once.`,
+		Run: doAsyncWait,
+	}
+
+	addCommonParams(&cmd, config)
+	addAsyncParams(&cmd, config)
+
+	return &cmd
+}
+
+// asyncSendCmd is the hidden helper that exports a single async send. It
+// reads the config as JSON on stdin so secrets aren't written to disk or
+// visible in the process list.
+func asyncSendCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:    "send FILE",
+		Short:  "export an async send (internal)",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run:    doAsyncSend,
+	}
+
+	addCommonParams(&cmd, config)
+
+	return &cmd
+}
+
+// addAsyncParams adds the async directory flag to the command.
+func addAsyncParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.AsyncDir, "async-dir", defaults.AsyncDir, "directory for pending --async sends, defaults to the user cache directory")
+}
+
+func doAsyncStatus(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	status, err := config.GetAsyncStatus()
+	config.SoftFailIfErr(err)
+
+	js, err := json.MarshalIndent(status, "", "    ")
+	config.SoftFailIfErr(err)
+	os.Stdout.Write(js)
+	os.Stdout.WriteString("\n")
+}
+
+func doAsyncWait(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	deadline := time.Now().Add(config.GetTimeout())
+	dir := config.GetAsyncDir()
+
+	pending, failed, err := listAsync(dir)
+	config.SoftFailIfErr(err)
+	for len(pending) > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		pending, failed, err = listAsync(dir)
+		config.SoftFailIfErr(err)
+	}
+
+	for _, file := range failed {
+		os.Remove(file)
+	}
+
+	if len(pending) > 0 {
+		config.SoftFail("timed out with %d async sends still pending", len(pending))
+	} else if len(failed) > 0 {
+		config.SoftFail("%d async sends failed", len(failed))
+	}
+}
+
+func doAsyncSend(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+	file := args[0]
+
+	if err := json.NewDecoder(os.Stdin).Decode(&config); err != nil {
+		os.Rename(file, file+asyncFailedExt)
+		config.SoftFail("failed to read config for async send: %s", err)
+	}
+	config = config.WithAsync(false)
+
+	// claim the file and push its mtime out to when this helper will be done
+	// so async status can tell if it died without finishing
+	sending := file + asyncSendingExt
+	if err := os.Rename(file, sending); err != nil {
+		config.SoftFail("failed to claim async send '%s': %s", file, err)
+	}
+	until := time.Now().Add(config.GetTimeout() + asyncGrace)
+	os.Chtimes(sending, until, until)
+
+	rsps, err := otlpclient.LoadSpooled(sending, config.GetSpoolKey())
+	if err != nil {
+		os.Rename(sending, file+asyncFailedExt)
+		config.SoftFail("%s", err)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+	ctx, client := StartClient(ctx, config)
+	ctx, err = client.UploadTraces(ctx, rsps)
+	client.Stop(ctx)
+
+	if err == nil {
+		os.Remove(sending)
+		return
+	}
+
+	if spoolDir := config.GetSpoolDir(); spoolDir != "" {
+		if _, serr := otlpclient.SpoolTraces(spoolDir, config.GetSpoolKey(), rsps); serr == nil {
+			os.Remove(sending)
+			return
+		}
+	}
+	os.Rename(sending, file+asyncFailedExt)
+	config.SoftFail("async send failed: %s", err)
+}
+
+// asyncClient is an OTLPClient that writes spans to the async directory and
+// starts a detached helper to export them, so the caller doesn't wait on the
+// network. Once --async-max-inflight sends are pending, it sends
+// synchronously instead so a dead endpoint can't pile up processes.
+type asyncClient struct {
+	config Config
+	client otlpclient.OTLPClient
+}
+
+// newAsyncClient returns an asyncClient for the config.
+func newAsyncClient(config Config) *asyncClient {
+	return &asyncClient{config: config}
+}
+
+// Start does nothing, the helper starts its own client.
+func (ac *asyncClient) Start(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+// UploadTraces hands the spans to a detached helper, or sends them
+// synchronously when too many sends are in flight or the helper can't start.
+func (ac *asyncClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	status, err := ac.config.GetAsyncStatus()
+	if err == nil && status.Pending < ac.config.AsyncMaxInflight {
+		err = startAsyncSend(ac.config, status.Dir, rsps)
+		if err == nil {
+			return ctx, nil
+		}
+	} else if err == nil {
+		err = fmt.Errorf("%d async sends are pending", status.Pending)
+	}
+	ac.config.SoftLog("sending synchronously: %s", err)
+
+	ctx, ac.client = StartClient(ctx, ac.config.WithAsync(false))
+	return ac.client.UploadTraces(ctx, rsps)
+}
+
+// Stop stops the synchronous client if one was needed.
+func (ac *asyncClient) Stop(ctx context.Context) (context.Context, error) {
+	if ac.client == nil {
+		return ctx, nil
+	}
+	return ac.client.Stop(ctx)
+}
+
+// startAsyncSend writes the spans to the async directory and starts a
+// detached otel-cli async send for them.
+func startAsyncSend(config Config, dir string, rsps []*tracepb.ResourceSpans) error {
+	js, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config for async send: %w", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the otel-cli executable: %w", err)
+	}
+
+	file, err := otlpclient.SpoolTraces(dir, config.GetSpoolKey(), rsps)
+	if err != nil {
+		return err
+	}
+
+	stdin, w, err := os.Pipe()
+	if err != nil {
+		os.Remove(file)
+		return fmt.Errorf("failed to create pipe for async send: %w", err)
+	}
+	defer stdin.Close()
+
+	cmd := exec.Command(exe, "async", "send", file)
+	cmd.Stdin = stdin
+	cmd.SysProcAttr = asyncSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		os.Remove(file)
+		return fmt.Errorf("failed to start async send: %w", err)
+	}
+
+	// the config is much smaller than a pipe buffer so this won't block
+	_, err = w.Write(js)
+	w.Close()
+	if err != nil {
+		return fmt.Errorf("failed to send config to async send: %w", err)
+	}
+
+	return cmd.Process.Release()
+}
+
+// GetAsyncDir returns the directory for pending async sends. When it isn't
+// set, it's under the user's cache directory, e.g. ~/.cache/otel-cli/async.
+func (c Config) GetAsyncDir() string {
+	if c.AsyncDir != "" {
+		return c.AsyncDir
+	}
+	if userCache, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(userCache, "otel-cli", "async")
+	}
+	return filepath.Join(os.TempDir(), "otel-cli-async")
+}
+
+// GetAsyncStatus counts the pending and failed async sends.
+func (c Config) GetAsyncStatus() (AsyncStatus, error) {
+	dir := c.GetAsyncDir()
+	pending, failed, err := listAsync(dir)
+	return AsyncStatus{Dir: dir, Pending: len(pending), Failed: len(failed)}, err
+}
+
+// listAsync returns the pending and failed async sends in dir. A send whose
+// helper ran past its deadline without finishing counts as failed.
+func listAsync(dir string) (pending, failed []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to read async directory '%s': %w", dir, err)
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+		switch {
+		case strings.HasSuffix(file, asyncFailedExt):
+			failed = append(failed, file)
+		case strings.HasSuffix(file, asyncSendingExt):
+			if info, err := entry.Info(); err == nil && info.ModTime().Before(now) {
+				failed = append(failed, file)
+			} else {
+				pending = append(pending, file)
+			}
+		case filepath.Ext(file) == ".otlp":
+			pending = append(pending, file)
+		}
+	}
+
+	return pending, failed, nil
+}
+
+// WithAsync returns the config with Async set to the provided value.
+func (c Config) WithAsync(with bool) Config {
+	c.Async = with
+	return c
+}
+
+// WithAsyncDir returns the config with AsyncDir set to the provided value.
+func (c Config) WithAsyncDir(with string) Config {
+	c.AsyncDir = with
+	return c
+}
+
+// WithAsyncMaxInflight returns the config with AsyncMaxInflight set to the provided value.
+func (c Config) WithAsyncMaxInflight(with int) Config {
+	c.AsyncMaxInflight = with
+	return c
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package otelcli
+
+import "syscall"
+
+// asyncSysProcAttr starts the async send helper in its own session so it
+// isn't killed by job control or a hangup when the calling shell exits.
+func asyncSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
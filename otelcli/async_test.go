@@ -0,0 +1,65 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetAsyncStatus(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig().WithAsyncDir(dir)
+
+	for _, name := range []string{
+		"1-queued.otlp",
+		"2-sending.otlp.sending",
+		"3-abandoned.otlp.sending",
+		"4-broken.otlp.failed",
+		"5-partial.otlp.tmp",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0600); err != nil {
+			t.Fatalf("failed to write test file: %s", err)
+		}
+	}
+	// helpers set the mtime of a .sending file to their deadline
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "2-sending.otlp.sending"), future, future)
+	past := time.Now().Add(-time.Minute)
+	os.Chtimes(filepath.Join(dir, "3-abandoned.otlp.sending"), past, past)
+
+	status, err := config.GetAsyncStatus()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := AsyncStatus{Dir: dir, Pending: 2, Failed: 2}
+	if diff := cmp.Diff(want, status); diff != "" {
+		t.Errorf("async status did not match (-want +got):\n%s", diff)
+	}
+
+	// a missing directory just means nothing was ever sent
+	status, err = config.WithAsyncDir(filepath.Join(dir, "missing")).GetAsyncStatus()
+	if err != nil || status.Pending != 0 || status.Failed != 0 {
+		t.Errorf("expected an empty status for a missing directory but got %+v, %v", status, err)
+	}
+}
+
+func TestWithAsync(t *testing.T) {
+	if !DefaultConfig().WithAsync(true).Async {
+		t.Fail()
+	}
+}
+
+func TestWithAsyncDir(t *testing.T) {
+	if DefaultConfig().WithAsyncDir("/tmp/async").AsyncDir != "/tmp/async" {
+		t.Fail()
+	}
+}
+
+func TestWithAsyncMaxInflight(t *testing.T) {
+	if DefaultConfig().WithAsyncMaxInflight(4).AsyncMaxInflight != 4 {
+		t.Fail()
+	}
+}
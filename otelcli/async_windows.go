@@ -0,0 +1,12 @@
+package otelcli
+
+import "syscall"
+
+// asyncSysProcAttr detaches the async send helper from the console so it
+// isn't killed when the calling shell exits.
+func asyncSysProcAttr() *syscall.SysProcAttr {
+	const detachedProcess = 0x00000008 // DETACHED_PROCESS
+	return &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
@@ -3,6 +3,8 @@ package otelcli
 import (
 	"log"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -81,3 +83,57 @@ PowerShell:
 
 	return &cmd
 }
+
+// completeAttrs completes --attrs and --resource-attrs with the keys in the
+// bundled semantic conventions, then the well-known values of the key once
+// there's an =. Only the last pair of the comma-separated list is completed.
+func completeAttrs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix, pair := splitLastComma(toComplete)
+	comps := []string{}
+
+	if key, value, ok := strings.Cut(pair, "="); ok {
+		for _, known := range bundledSemconv.Values[key] {
+			if strings.HasPrefix(known, value) {
+				comps = append(comps, prefix+key+"="+known)
+			}
+		}
+		return comps, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	}
+
+	keys := []string{}
+	for key := range bundledSemconv.Attributes {
+		if strings.HasPrefix(key, pair) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		comps = append(comps, prefix+key+"=\t"+bundledSemconv.Attributes[key])
+	}
+	return comps, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEndpoint completes the URI schemes otel-cli supports for the last
+// endpoint in a comma-separated list.
+func completeEndpoint(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix, endpoint := splitLastComma(toComplete)
+	comps := []string{}
+	if !strings.Contains(endpoint, "://") {
+		for _, scheme := range endpointSchemes {
+			if strings.HasPrefix(scheme+"://", endpoint) {
+				comps = append(comps, prefix+scheme+"://")
+			}
+		}
+	}
+	return comps, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeKind completes --kind with the span kinds.
+var completeKind = cobra.FixedCompletions([]string{"internal", "server", "client", "producer", "consumer"}, cobra.ShellCompDirectiveNoFileComp)
+
+// splitLastComma splits a comma-separated list into everything up to and
+// including the last comma, and the item after it that's being completed.
+func splitLastComma(in string) (string, string) {
+	i := strings.LastIndex(in, ",")
+	return in[:i+1], in[i+1:]
+}
@@ -0,0 +1,92 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteAttrs(t *testing.T) {
+	for _, tc := range []struct {
+		toComplete string
+		want       []string
+	}{
+		{
+			toComplete: "http.response.",
+			want:       []string{"http.response.body.size=\tint", "http.response.status_code=\tint"},
+		},
+		// only the last pair is completed
+		{
+			toComplete: "team=infra,url.sch",
+			want:       []string{"team=infra,url.scheme=\tstring"},
+		},
+		{
+			toComplete: "network.transport=u",
+			want:       []string{"network.transport=udp", "network.transport=unix"},
+		},
+		// keys without well-known values can have anything
+		{
+			toComplete: "service.name=",
+			want:       []string{},
+		},
+		{
+			toComplete: "nope",
+			want:       []string{},
+		},
+	} {
+		got, directive := completeAttrs(nil, nil, tc.toComplete)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("completions for %q mismatch (-want +got):\n%s", tc.toComplete, diff)
+		}
+		if directive != cobra.ShellCompDirectiveNoSpace|cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("expected no space or file completion for %q but got %d", tc.toComplete, directive)
+		}
+	}
+}
+
+func TestCompleteEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		toComplete string
+		want       []string
+	}{
+		{toComplete: "ht", want: []string{"http://", "https://"}},
+		{toComplete: "localhost:4317,ka", want: []string{"localhost:4317,kafka://", "localhost:4317,kafkas://"}},
+		// the rest of the URL is up to the user
+		{toComplete: "grpc://", want: []string{}},
+	} {
+		got, _ := completeEndpoint(nil, nil, tc.toComplete)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("completions for %q mismatch (-want +got):\n%s", tc.toComplete, diff)
+		}
+	}
+}
+
+func TestFlagCompletionRegistered(t *testing.T) {
+	for _, args := range [][]string{
+		{"span", "--kind", "cl"},
+		{"span", "--attrs", "os.type=li"},
+		{"exec", "--resource-attrs", "os.type=li"},
+		{"exec", "--endpoint", "ht"},
+		{"cron", "--attrs", "os.type=li"},
+	} {
+		config := DefaultConfig()
+		root := createRootCmd(&config)
+		out := bytes.Buffer{}
+		root.SetOut(&out)
+		root.SetErr(io.Discard)
+		root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+		ctx := context.WithValue(context.Background(), configContextKey(), &config)
+		if err := root.ExecuteContext(ctx); err != nil {
+			t.Fatalf("completion of %q failed: %s", args, err)
+		}
+		// the completions are followed by :directive
+		if strings.HasPrefix(out.String(), ":") {
+			t.Errorf("expected completions for %q but got %q", args, out.String())
+		}
+	}
+}
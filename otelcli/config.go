@@ -1,10 +1,9 @@
 package otelcli
 
 import (
+	"crypto/sha256"
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/url"
 	"os"
 	"path"
@@ -29,58 +28,247 @@ func init() {
 // DefaultConfig returns a Config with all defaults set.
 func DefaultConfig() Config {
 	return Config{
-		Endpoint:                     "",
-		Protocol:                     "",
-		Timeout:                      "1s",
-		Headers:                      map[string]string{},
-		Insecure:                     false,
-		Blocking:                     false,
-		TlsNoVerify:                  false,
-		TlsCACert:                    "",
-		TlsClientKey:                 "",
-		TlsClientCert:                "",
-		ServiceName:                  "otel-cli",
-		SpanName:                     "todo-generate-default-span-names",
-		Kind:                         "client",
-		ForceTraceId:                 "",
-		ForceSpanId:                  "",
-		ForceParentSpanId:            "",
-		Attributes:                   map[string]string{},
-		TraceparentCarrierFile:       "",
-		TraceparentIgnoreEnv:         false,
-		TraceparentPrint:             false,
-		TraceparentPrintExport:       false,
-		TraceparentRequired:          false,
-		BackgroundParentPollMs:       10,
-		BackgroundSockdir:            "",
-		BackgroundWait:               false,
-		BackgroundSkipParentPidCheck: false,
-		ExecCommandTimeout:           "",
-		StatusCanaryCount:            1,
-		StatusCanaryInterval:         "",
-		SpanStartTime:                "now",
-		SpanEndTime:                  "now",
-		EventName:                    "todo-generate-default-event-names",
-		EventTime:                    "now",
-		CfgFile:                      "",
-		Verbose:                      false,
-		Fail:                         false,
-		StatusCode:                   "unset",
-		StatusDescription:            "",
-		Version:                      "unset",
+		Endpoint:                      "",
+		Protocol:                      "",
+		Exporter:                      "",
+		Timeout:                       "1s",
+		ConnectTimeout:                "",
+		SendTimeout:                   "",
+		Compression:                   "",
+		Proxy:                         "",
+		RetryMaxAttempts:              0,
+		RetryInitialInterval:          "100ms",
+		RetryMaxInterval:              "5s",
+		SpoolDir:                      "",
+		SpoolKey:                      "",
+		Sampler:                       "",
+		SamplerArg:                    "",
+		SpanAttributeValueLengthLimit: -1,
+		SpanAttributeCountLimit:       128,
+		SpanEventCountLimit:           128,
+		SpanLinkCountLimit:            128,
+		OAuth2TokenURL:                "",
+		OAuth2ClientID:                "",
+		OAuth2ClientSecret:            "",
+		OAuth2Scopes:                  "",
+		OAuth2CacheDir:                "",
+		GrafanaUrl:                    "",
+		GrafanaToken:                  "",
+		GrafanaDashboardUid:           "",
+		GrafanaTags:                   "",
+		GrafanaTraceUrl:               "",
+		PushgatewayUrl:                "",
+		PushgatewayJob:                "",
+		PushgatewayLabels:             map[string]string{},
+		Statsd:                        "",
+		StatsdPrefix:                  "otel_cli",
+		StatsdDogstatsd:               false,
+		Targets:                       []TargetConfig{},
+		FanoutPolicy:                  "any",
+		FileFormat:                    "json",
+		FileMaxMegabytes:              0,
+		FileMaxDays:                   0,
+		FileMaxBackups:                0,
+		FileMaxAge:                    "",
+		KafkaBrokers:                  "",
+		KafkaEncoding:                 "otlp_proto",
+		KafkaSASLMechanism:            "",
+		KafkaSASLUsername:             "",
+		KafkaSASLPassword:             "",
+		AgentSocket:                   "",
+		AgentFlushInterval:            "1s",
+		AgentBatchSize:                512,
+		IngestMaxRate:                 0,
+		IngestMaxQueue:                0,
+		Async:                         false,
+		AsyncDir:                      "",
+		AsyncMaxInflight:              16,
+		Headers:                       map[string]string{},
+		OtlpHeadersFile:               "",
+		OtlpHeaderCmd:                 "",
+		TracesHeaders:                 map[string]string{},
+		MetricsHeaders:                map[string]string{},
+		UserAgent:                     "",
+		GrpcMetadata:                  map[string]string{},
+		LogsHeaders:                   map[string]string{},
+		Insecure:                      false,
+		Blocking:                      false,
+		TlsNoVerify:                   false,
+		TlsCACert:                     "",
+		TlsClientKey:                  "",
+		TlsClientCert:                 "",
+		ServiceName:                   "otel-cli",
+		ResourceAttributes:            map[string]string{},
+		ServiceVersion:                "",
+		Environment:                   "",
+		ResourceDetectors:             "",
+		SpanName:                      "todo-generate-default-span-names",
+		Kind:                          "client",
+		ForceTraceId:                  "",
+		ForceSpanId:                   "",
+		ForceParentSpanId:             "",
+		Attributes:                    map[string]string{},
+		AttributesFile:                "",
+		AttributeCommands:             []string{},
+		StrictSemconv:                 false,
+		CiDetect:                      "auto",
+		GitAttrs:                      false,
+		TraceparentCarrierFile:        "",
+		TraceparentCarrierFormat:      "text",
+		TraceparentIgnoreEnv:          false,
+		TraceparentPrint:              false,
+		TraceparentPrintExport:        false,
+		TraceparentPrintFormat:        "w3c",
+		TraceparentRequired:           false,
+		TraceparentRegistryDir:        "",
+		TraceparentRegistryName:       "",
+		TraceparentRegistryWait:       false,
+		BackgroundParentPollMs:        10,
+		BackgroundSockdir:             "",
+		BackgroundWait:                false,
+		BackgroundSkipParentPidCheck:  false,
+		ExecCommandTimeout:            "",
+		ExecAnnotate:                  false,
+		ClockSkew:                     "",
+		ClockSkewNtpServer:            "pool.ntp.org",
+		StatusCanaryCount:             1,
+		StatusCanaryInterval:          "",
+		StatusProbe:                   false,
+		StatusCheck:                   false,
+		ServerHttpEndpoint:            "",
+		ServerForwardListen:           "",
+		ServerMetricsEndpoint:         "",
+		ServerTlsCert:                 "",
+		ServerTlsKey:                  "",
+		ServerTlsClientCA:             "",
+		ServerAuthToken:               "",
+		ServerAuthBasic:               "",
+		ServerAllowIps:                "",
+		ServerKeep:                    []string{},
+		ServerDrop:                    []string{},
+		ServerSample:                  "",
+		ServerKeepWait:                "",
+		ReplayShiftToNow:              false,
+		SpanStartTime:                 "now",
+		SpanEndTime:                   "now",
+		EventName:                     "todo-generate-default-event-names",
+		EventTime:                     "now",
+		MetricUnit:                    "",
+		MetricDescription:             "",
+		MetricIncrement:               "1",
+		MetricBuckets:                 "",
+		LogSeverity:                   "info",
+		LogBody:                       "",
+		LogStdin:                      false,
+		CfgFile:                       "",
+		Profile:                       "",
+		Verbose:                       false,
+		LogLevel:                      "",
+		LogFormat:                     "logfmt",
+		DebugWire:                     "",
+		Fail:                          false,
+		Json:                          false,
+		StatusCode:                    "unset",
+		StatusDescription:             "",
+		Version:                       "unset",
 	}
 }
 
 // Config stores the runtime configuration for otel-cli.
 // Data structure is public so that it can serialize to json easily.
 type Config struct {
-	Endpoint       string            `json:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
-	TracesEndpoint string            `json:"traces_endpoint" env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"`
-	Protocol       string            `json:"protocol" env:"OTEL_EXPORTER_OTLP_PROTOCOL,OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"`
-	Timeout        string            `json:"timeout" env:"OTEL_EXPORTER_OTLP_TIMEOUT,OTEL_EXPORTER_OTLP_TRACES_TIMEOUT"`
-	Headers        map[string]string `json:"otlp_headers" env:"OTEL_EXPORTER_OTLP_HEADERS"` // TODO: needs json marshaler hook to mask tokens
-	Insecure       bool              `json:"insecure" env:"OTEL_EXPORTER_OTLP_INSECURE"`
-	Blocking       bool              `json:"otlp_blocking" env:"OTEL_EXPORTER_OTLP_BLOCKING"`
+	Endpoint        string `json:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	TracesEndpoint  string `json:"traces_endpoint" env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"`
+	MetricsEndpoint string `json:"metrics_endpoint" env:"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"`
+	LogsEndpoint    string `json:"logs_endpoint" env:"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"`
+	Protocol        string `json:"protocol" env:"OTEL_EXPORTER_OTLP_PROTOCOL,OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"`
+	Exporter        string `json:"exporter" env:"OTEL_TRACES_EXPORTER,OTEL_CLI_EXPORTER"`
+	Timeout         string `json:"timeout" env:"OTEL_EXPORTER_OTLP_TIMEOUT,OTEL_EXPORTER_OTLP_TRACES_TIMEOUT"`
+	ConnectTimeout  string `json:"connect_timeout" env:"OTEL_CLI_CONNECT_TIMEOUT"`
+	SendTimeout     string `json:"send_timeout" env:"OTEL_CLI_SEND_TIMEOUT"`
+	Compression     string `json:"compression" env:"OTEL_EXPORTER_OTLP_COMPRESSION,OTEL_EXPORTER_OTLP_TRACES_COMPRESSION"`
+	Proxy           string `json:"proxy" env:"OTEL_CLI_PROXY"`
+
+	Headers         map[string]string `json:"otlp_headers" env:"OTEL_EXPORTER_OTLP_HEADERS"` // TODO: needs json marshaler hook to mask tokens
+	OtlpHeadersFile string            `json:"otlp_headers_file" env:"OTEL_CLI_OTLP_HEADERS_FILE"`
+	OtlpHeaderCmd   string            `json:"otlp_header_cmd" env:"OTEL_CLI_OTLP_HEADER_CMD"`
+	TracesHeaders   map[string]string `json:"traces_headers" env:"OTEL_EXPORTER_OTLP_TRACES_HEADERS"`
+	MetricsHeaders  map[string]string `json:"metrics_headers" env:"OTEL_EXPORTER_OTLP_METRICS_HEADERS"`
+	LogsHeaders     map[string]string `json:"logs_headers" env:"OTEL_EXPORTER_OTLP_LOGS_HEADERS"`
+	UserAgent       string            `json:"user_agent" env:"OTEL_CLI_USER_AGENT"`
+	GrpcMetadata    map[string]string `json:"grpc_metadata" env:"OTEL_CLI_GRPC_METADATA"`
+	Insecure        bool              `json:"insecure" env:"OTEL_EXPORTER_OTLP_INSECURE,OTEL_EXPORTER_OTLP_TRACES_INSECURE"`
+	Blocking        bool              `json:"otlp_blocking" env:"OTEL_EXPORTER_OTLP_BLOCKING"`
+
+	RetryMaxAttempts     int    `json:"retry_max_attempts" env:"OTEL_CLI_RETRY_MAX_ATTEMPTS"`
+	RetryInitialInterval string `json:"retry_initial_interval" env:"OTEL_CLI_RETRY_INITIAL_INTERVAL"`
+	RetryMaxInterval     string `json:"retry_max_interval" env:"OTEL_CLI_RETRY_MAX_INTERVAL"`
+
+	SpoolDir string `json:"spool_dir" env:"OTEL_CLI_SPOOL_DIR"`
+	SpoolKey string `json:"spool_key" env:"OTEL_CLI_SPOOL_KEY"`
+
+	Sampler    string `json:"sampler" env:"OTEL_TRACES_SAMPLER,OTEL_CLI_SAMPLER"`
+	SamplerArg string `json:"sampler_arg" env:"OTEL_TRACES_SAMPLER_ARG"`
+
+	// the span-specific envvars are last so they override the general ones
+	SpanAttributeValueLengthLimit int `json:"span_attribute_value_length_limit" env:"OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT,OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT"`
+	SpanAttributeCountLimit       int `json:"span_attribute_count_limit" env:"OTEL_ATTRIBUTE_COUNT_LIMIT,OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT"`
+	SpanEventCountLimit           int `json:"span_event_count_limit" env:"OTEL_SPAN_EVENT_COUNT_LIMIT"`
+	SpanLinkCountLimit            int `json:"span_link_count_limit" env:"OTEL_SPAN_LINK_COUNT_LIMIT"`
+
+	OAuth2TokenURL     string `json:"oauth2_token_url" env:"OTEL_CLI_OAUTH2_TOKEN_URL"`
+	OAuth2ClientID     string `json:"oauth2_client_id" env:"OTEL_CLI_OAUTH2_CLIENT_ID"`
+	OAuth2ClientSecret string `json:"oauth2_client_secret" env:"OTEL_CLI_OAUTH2_CLIENT_SECRET"`
+	OAuth2Scopes       string `json:"oauth2_scopes" env:"OTEL_CLI_OAUTH2_SCOPES"`
+	OAuth2CacheDir     string `json:"oauth2_cache_dir" env:"OTEL_CLI_OAUTH2_CACHE_DIR"`
+
+	// Grafana annotations, see config_grafana.go
+	GrafanaUrl          string `json:"grafana_url" env:"OTEL_CLI_GRAFANA_URL"`
+	GrafanaToken        string `json:"grafana_token" env:"OTEL_CLI_GRAFANA_TOKEN"`
+	GrafanaDashboardUid string `json:"grafana_dashboard_uid" env:"OTEL_CLI_GRAFANA_DASHBOARD_UID"`
+	GrafanaTags         string `json:"grafana_tags" env:"OTEL_CLI_GRAFANA_TAGS"`
+	GrafanaTraceUrl     string `json:"grafana_trace_url" env:"OTEL_CLI_GRAFANA_TRACE_URL"`
+
+	// Prometheus Pushgateway metrics for exec, see config_pushgateway.go
+	PushgatewayUrl    string            `json:"pushgateway_url" env:"OTEL_CLI_PUSHGATEWAY_URL"`
+	PushgatewayJob    string            `json:"pushgateway_job" env:"OTEL_CLI_PUSHGATEWAY_JOB"`
+	PushgatewayLabels map[string]string `json:"pushgateway_labels" env:"OTEL_CLI_PUSHGATEWAY_LABELS"`
+
+	// StatsD metrics for spans, see config_statsd.go
+	Statsd          string `json:"statsd" env:"OTEL_CLI_STATSD"`
+	StatsdPrefix    string `json:"statsd_prefix" env:"OTEL_CLI_STATSD_PREFIX"`
+	StatsdDogstatsd bool   `json:"statsd_dogstatsd" env:"OTEL_CLI_STATSD_DOGSTATSD"`
+
+	// Targets and multiple endpoints send each span to several places, see config_fanout.go
+	Targets      []TargetConfig `json:"targets"`
+	FanoutPolicy string         `json:"fanout_policy" env:"OTEL_CLI_FANOUT_POLICY"`
+
+	FileFormat       string `json:"file_format" env:"OTEL_CLI_FILE_FORMAT"`
+	FileMaxMegabytes int    `json:"file_max_megabytes" env:"OTEL_CLI_FILE_MAX_MEGABYTES"`
+	FileMaxDays      int    `json:"file_max_days" env:"OTEL_CLI_FILE_MAX_DAYS"`
+	FileMaxBackups   int    `json:"file_max_backups" env:"OTEL_CLI_FILE_MAX_BACKUPS"`
+	FileMaxAge       string `json:"file_max_age" env:"OTEL_CLI_FILE_MAX_AGE"`
+
+	KafkaBrokers       string `json:"kafka_brokers" env:"OTEL_CLI_KAFKA_BROKERS"`
+	KafkaEncoding      string `json:"kafka_encoding" env:"OTEL_CLI_KAFKA_ENCODING"`
+	KafkaSASLMechanism string `json:"kafka_sasl_mechanism" env:"OTEL_CLI_KAFKA_SASL_MECHANISM"`
+	KafkaSASLUsername  string `json:"kafka_sasl_username" env:"OTEL_CLI_KAFKA_SASL_USERNAME"`
+	KafkaSASLPassword  string `json:"kafka_sasl_password" env:"OTEL_CLI_KAFKA_SASL_PASSWORD"`
+
+	// AgentSocket hands spans to a running 'otel-cli agent', see agent.go
+	AgentSocket        string `json:"agent_socket" env:"OTEL_CLI_AGENT_SOCKET"`
+	AgentFlushInterval string `json:"agent_flush_interval" env:"OTEL_CLI_AGENT_FLUSH_INTERVAL"`
+	AgentBatchSize     int    `json:"agent_batch_size" env:"OTEL_CLI_AGENT_BATCH_SIZE"`
+
+	// IngestMaxRate and IngestMaxQueue limit what the agent and span
+	// background take in, see ingest_limit.go
+	IngestMaxRate  int `json:"ingest_max_rate" env:"OTEL_CLI_INGEST_MAX_RATE"`
+	IngestMaxQueue int `json:"ingest_max_queue" env:"OTEL_CLI_INGEST_MAX_QUEUE"`
+
+	// Async hands spans to a detached helper process, see async.go
+	Async            bool   `json:"async" env:"OTEL_CLI_ASYNC"`
+	AsyncDir         string `json:"async_dir" env:"OTEL_CLI_ASYNC_DIR"`
+	AsyncMaxInflight int    `json:"async_max_inflight" env:"OTEL_CLI_ASYNC_MAX_INFLIGHT"`
 
 	TlsCACert     string `json:"tls_ca_cert" env:"OTEL_EXPORTER_OTLP_CERTIFICATE,OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE"`
 	TlsClientKey  string `json:"tls_client_key" env:"OTEL_EXPORTER_OTLP_CLIENT_KEY,OTEL_EXPORTER_OTLP_TRACES_CLIENT_KEY"`
@@ -88,21 +276,37 @@ type Config struct {
 	// OTEL_CLI_NO_TLS_VERIFY is deprecated and will be removed for 1.0
 	TlsNoVerify bool `json:"tls_no_verify" env:"OTEL_CLI_TLS_NO_VERIFY,OTEL_CLI_NO_TLS_VERIFY"`
 
-	ServiceName       string            `json:"service_name" env:"OTEL_CLI_SERVICE_NAME,OTEL_SERVICE_NAME"`
-	SpanName          string            `json:"span_name" env:"OTEL_CLI_SPAN_NAME"`
-	Kind              string            `json:"span_kind" env:"OTEL_CLI_TRACE_KIND"`
-	Attributes        map[string]string `json:"span_attributes" env:"OTEL_CLI_ATTRIBUTES"`
-	StatusCode        string            `json:"span_status_code" env:"OTEL_CLI_STATUS_CODE"`
-	StatusDescription string            `json:"span_status_description" env:"OTEL_CLI_STATUS_DESCRIPTION"`
-	ForceSpanId       string            `json:"force_span_id" env:"OTEL_CLI_FORCE_SPAN_ID"`
-	ForceParentSpanId string            `json:"force_parent_span_id" env:"OTEL_CLI_FORCE_PARENT_SPAN_ID"`
-	ForceTraceId      string            `json:"force_trace_id" env:"OTEL_CLI_FORCE_TRACE_ID"`
-
-	TraceparentCarrierFile string `json:"traceparent_carrier_file" env:"OTEL_CLI_CARRIER_FILE"`
-	TraceparentIgnoreEnv   bool   `json:"traceparent_ignore_env" env:"OTEL_CLI_IGNORE_ENV"`
-	TraceparentPrint       bool   `json:"traceparent_print" env:"OTEL_CLI_PRINT_TRACEPARENT"`
-	TraceparentPrintExport bool   `json:"traceparent_print_export" env:"OTEL_CLI_EXPORT_TRACEPARENT"`
-	TraceparentRequired    bool   `json:"traceparent_required" env:"OTEL_CLI_TRACEPARENT_REQUIRED"`
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES are merged by loadResourceEnv
+	ServiceName        string            `json:"service_name" env:"OTEL_CLI_SERVICE_NAME"`
+	ResourceAttributes map[string]string `json:"resource_attributes" env:""`
+	ServiceVersion     string            `json:"service_version" env:"OTEL_CLI_SERVICE_VERSION"`
+	Environment        string            `json:"environment" env:"OTEL_CLI_ENVIRONMENT"`
+	ResourceDetectors  string            `json:"resource_detectors" env:"OTEL_CLI_RESOURCE_DETECTORS"`
+	SpanName           string            `json:"span_name" env:"OTEL_CLI_SPAN_NAME"`
+	Kind               string            `json:"span_kind" env:"OTEL_CLI_TRACE_KIND"`
+	Attributes         map[string]string `json:"span_attributes" env:"OTEL_CLI_ATTRIBUTES" env_merge:"true"`
+	AttributesFile     string            `json:"span_attributes_file" env:"OTEL_CLI_ATTRIBUTES_FILE"`
+	AttributeCommands  []string          `json:"span_attribute_commands" env:""`
+	StrictSemconv      bool              `json:"strict_semconv" env:"OTEL_CLI_STRICT_SEMCONV"`
+	CiDetect           string            `json:"ci_detect" env:"OTEL_CLI_CI_DETECT"`
+	GitAttrs           bool              `json:"git_attrs" env:"OTEL_CLI_GIT_ATTRS"`
+	StatusCode         string            `json:"span_status_code" env:"OTEL_CLI_STATUS_CODE"`
+	StatusDescription  string            `json:"span_status_description" env:"OTEL_CLI_STATUS_DESCRIPTION"`
+	ForceSpanId        string            `json:"force_span_id" env:"OTEL_CLI_FORCE_SPAN_ID"`
+	ForceParentSpanId  string            `json:"force_parent_span_id" env:"OTEL_CLI_FORCE_PARENT_SPAN_ID"`
+	ForceTraceId       string            `json:"force_trace_id" env:"OTEL_CLI_FORCE_TRACE_ID"`
+
+	TraceparentCarrierFile   string `json:"traceparent_carrier_file" env:"OTEL_CLI_CARRIER_FILE"`
+	TraceparentCarrierFormat string `json:"traceparent_carrier_format" env:"OTEL_CLI_CARRIER_FORMAT"`
+	TraceparentIgnoreEnv     bool   `json:"traceparent_ignore_env" env:"OTEL_CLI_IGNORE_ENV"`
+	TraceparentPrint         bool   `json:"traceparent_print" env:"OTEL_CLI_PRINT_TRACEPARENT"`
+	TraceparentPrintExport   bool   `json:"traceparent_print_export" env:"OTEL_CLI_EXPORT_TRACEPARENT"`
+	TraceparentPrintFormat   string `json:"traceparent_print_format" env:"OTEL_CLI_PRINT_TRACEPARENT_FORMAT"`
+	TraceparentRequired      bool   `json:"traceparent_required" env:"OTEL_CLI_TRACEPARENT_REQUIRED"`
+
+	TraceparentRegistryDir  string `json:"traceparent_registry_dir" env:"OTEL_CLI_TP_REGISTRY_DIR"`
+	TraceparentRegistryName string `json:"traceparent_registry_name" env:""`
+	TraceparentRegistryWait bool   `json:"traceparent_registry_wait" env:""`
 
 	BackgroundParentPollMs       int    `json:"background_parent_poll_ms" env:""`
 	BackgroundSockdir            string `json:"background_socket_directory" env:""`
@@ -110,44 +314,92 @@ type Config struct {
 	BackgroundSkipParentPidCheck bool   `json:"background_skip_parent_pid_check"`
 
 	ExecCommandTimeout string `json:"exec_command_timeout" env:"OTEL_CLI_EXEC_CMD_TIMEOUT"`
+	ExecAnnotate       bool   `json:"exec_annotate" env:"OTEL_CLI_EXEC_ANNOTATE"`
+
+	ClockSkew          string `json:"clock_skew" env:"OTEL_CLI_CLOCK_SKEW"`
+	ClockSkewNtpServer string `json:"clock_skew_ntp_server" env:"OTEL_CLI_CLOCK_SKEW_NTP_SERVER"`
 
 	StatusCanaryCount    int    `json:"status_canary_count"`
 	StatusCanaryInterval string `json:"status_canary_interval"`
+	StatusProbe          bool   `json:"status_probe"`
+	StatusCheck          bool   `json:"status_check"`
+
+	ServerHttpEndpoint    string   `json:"server_http_endpoint" env:"OTEL_CLI_SERVER_HTTP_ENDPOINT"`
+	ServerForwardListen   string   `json:"server_forward_listen" env:"OTEL_CLI_SERVER_FORWARD_LISTEN"`
+	ServerMetricsEndpoint string   `json:"server_metrics_endpoint" env:"OTEL_CLI_SERVER_METRICS_ENDPOINT"`
+	ServerTlsCert         string   `json:"server_tls_cert" env:"OTEL_CLI_SERVER_TLS_CERT"`
+	ServerTlsKey          string   `json:"server_tls_key" env:"OTEL_CLI_SERVER_TLS_KEY"`
+	ServerTlsClientCA     string   `json:"server_tls_client_ca" env:"OTEL_CLI_SERVER_TLS_CLIENT_CA"`
+	ServerAuthToken       string   `json:"server_auth_token" env:"OTEL_CLI_SERVER_AUTH_TOKEN"`
+	ServerAuthBasic       string   `json:"server_auth_basic" env:"OTEL_CLI_SERVER_AUTH_BASIC"`
+	ServerAllowIps        string   `json:"server_allow_ips" env:"OTEL_CLI_SERVER_ALLOW_IPS"`
+	ServerKeep            []string `json:"server_keep" env:""`
+	ServerDrop            []string `json:"server_drop" env:""`
+	ServerSample          string   `json:"server_sample" env:"OTEL_CLI_SERVER_SAMPLE"`
+	ServerKeepWait        string   `json:"server_keep_wait" env:"OTEL_CLI_SERVER_KEEP_WAIT"`
+
+	ReplayShiftToNow bool `json:"replay_shift_to_now" env:""`
 
 	SpanStartTime string `json:"span_start_time" env:""`
 	SpanEndTime   string `json:"span_end_time" env:""`
 	EventName     string `json:"event_name" env:""`
 	EventTime     string `json:"event_time" env:""`
 
+	MetricUnit        string `json:"metric_unit" env:""`
+	MetricDescription string `json:"metric_description" env:""`
+	MetricIncrement   string `json:"metric_increment" env:""`
+	MetricBuckets     string `json:"metric_buckets" env:""`
+
+	LogSeverity string `json:"log_severity" env:""`
+	LogBody     string `json:"log_body" env:""`
+	LogStdin    bool   `json:"log_stdin" env:""`
+
 	CfgFile string `json:"config_file" env:"OTEL_CLI_CONFIG_FILE"`
+	Profile string `json:"profile" env:"OTEL_CLI_PROFILE"`
 	Verbose bool   `json:"verbose" env:"OTEL_CLI_VERBOSE"`
 	Fail    bool   `json:"fail" env:"OTEL_CLI_FAIL"`
+	Json    bool   `json:"json" env:"OTEL_CLI_JSON"`
+
+	LogLevel  string `json:"log_level" env:"OTEL_CLI_LOG_LEVEL"`
+	LogFormat string `json:"log_format" env:"OTEL_CLI_LOG_FORMAT"`
+	DebugWire string `json:"debug_wire" env:"OTEL_CLI_DEBUG_WIRE"`
 
 	// not exported, used to get data from cobra to otlpclient internals
 	Version string `json:"-"`
+
+	// the OTLP signal being sent, traces unless set with withSignal
+	signal string
 }
 
-// LoadFile reads the file specified by -c/--config and overwrites the
-// current config values with any found in the file.
+// LoadFile reads the file specified by -c/--config, or the default config
+// file from defaultCfgFile if it exists, and fills in any settings that are
+// still at their defaults with the values in the file and in --profile.
 func (c *Config) LoadFile() error {
-	if c.CfgFile == "" {
+	path := c.GetCfgFile()
+	if path == "" {
+		if c.Profile != "" {
+			return errors.Errorf("--profile %q requires a config file, but there is no file at '%s'", c.Profile, defaultCfgFile())
+		}
 		return nil
 	}
 
-	js, err := os.ReadFile(c.CfgFile)
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return errors.Wrapf(err, "failed to read file '%s'", c.CfgFile)
+		return errors.Wrapf(err, "failed to read file '%s'", path)
 	}
 
-	if err := json.Unmarshal(js, c); err != nil {
-		return errors.Wrapf(err, "failed to parse json data in file '%s'", c.CfgFile)
+	file, err := parseConfigFile(data, c.Profile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse config file '%s'", path)
 	}
+	c.fillUnset(file)
 
 	return nil
 }
 
 // LoadEnv loads environment variables into the config, overwriting current
-// values. Environment variable to config key mapping is tagged on the
+// values, except for maps tagged env_merge, which only add missing keys.
+// Environment variable to config key mapping is tagged on the
 // Config struct. Multiple names for envvars is supported, comma-separated.
 // Takes a func(string)string that's usually os.Getenv, and is swappable to
 // make testing easier.
@@ -175,6 +427,13 @@ func (c *Config) LoadEnv(getenv func(string) string) error {
 			target := cValue.Field(i)
 			switch target.Interface().(type) {
 			case string:
+				// the spec defines OTLP timeouts as integer milliseconds, while
+				// bare numbers elsewhere in otel-cli are seconds
+				if strings.HasPrefix(envVar, "OTEL_EXPORTER_OTLP_") && strings.HasSuffix(envVar, "_TIMEOUT") {
+					if _, err := strconv.ParseInt(envVal, 10, 64); err == nil {
+						envVal += "ms"
+					}
+				}
 				target.SetString(envVal)
 			case int:
 				intVal, err := strconv.ParseInt(envVal, 10, 64)
@@ -193,58 +452,176 @@ func (c *Config) LoadEnv(getenv func(string) string) error {
 				if err != nil {
 					return errors.Wrapf(err, "could not parse %s value %q as a map", envVar, envVal)
 				}
+				// env_merge maps are defaults for every command in the shell, so
+				// the envvar's pairs go under the ones already set, e.g. by --attrs
+				if field.Tag.Get("env_merge") == "true" {
+					for k, v := range target.Interface().(map[string]string) {
+						mapVal[k] = v
+					}
+				}
 				mapValVal := reflect.ValueOf(mapVal)
 				target.Set(mapValVal)
 			}
 		}
 	}
 
-	return nil
+	return c.loadResourceEnv(getenv)
 }
 
 // ToStringMap flattens the configuration into a stringmap that is easy to work
 // with in tests especially with cmp.Diff. See test_main.go.
 func (c Config) ToStringMap() map[string]string {
 	return map[string]string{
-		"endpoint":                    c.Endpoint,
-		"protocol":                    c.Protocol,
-		"timeout":                     c.Timeout,
-		"headers":                     flattenStringMap(c.Headers, "{}"),
-		"insecure":                    strconv.FormatBool(c.Insecure),
-		"blocking":                    strconv.FormatBool(c.Blocking),
-		"tls_no_verify":               strconv.FormatBool(c.TlsNoVerify),
-		"tls_ca_cert":                 c.TlsCACert,
-		"tls_client_key":              c.TlsClientKey,
-		"tls_client_cert":             c.TlsClientCert,
-		"service_name":                c.ServiceName,
-		"span_name":                   c.SpanName,
-		"span_kind":                   c.Kind,
-		"span_attributes":             flattenStringMap(c.Attributes, "{}"),
-		"span_status_code":            c.StatusCode,
-		"span_status_description":     c.StatusDescription,
-		"traceparent_carrier_file":    c.TraceparentCarrierFile,
-		"traceparent_ignore_env":      strconv.FormatBool(c.TraceparentIgnoreEnv),
-		"traceparent_print":           strconv.FormatBool(c.TraceparentPrint),
-		"traceparent_print_export":    strconv.FormatBool(c.TraceparentPrintExport),
-		"traceparent_required":        strconv.FormatBool(c.TraceparentRequired),
-		"background_parent_poll_ms":   strconv.Itoa(c.BackgroundParentPollMs),
-		"background_socket_directory": c.BackgroundSockdir,
-		"background_wait":             strconv.FormatBool(c.BackgroundWait),
-		"background_skip_pid_check":   strconv.FormatBool(c.BackgroundSkipParentPidCheck),
-		"exec_command_timeout":        c.ExecCommandTimeout,
-		"span_start_time":             c.SpanStartTime,
-		"span_end_time":               c.SpanEndTime,
-		"event_name":                  c.EventName,
-		"event_time":                  c.EventTime,
-		"config_file":                 c.CfgFile,
-		"verbose":                     strconv.FormatBool(c.Verbose),
-	}
-}
-
-// GetIsRecording returns true if an endpoint is set and otel-cli expects to send real
-// spans. Returns false if unconfigured and going to run inert.
+		"endpoint":                          c.Endpoint,
+		"protocol":                          c.Protocol,
+		"exporter":                          c.Exporter,
+		"timeout":                           c.Timeout,
+		"connect_timeout":                   c.ConnectTimeout,
+		"send_timeout":                      c.SendTimeout,
+		"compression":                       c.Compression,
+		"proxy":                             c.Proxy,
+		"retry_max_attempts":                strconv.Itoa(c.RetryMaxAttempts),
+		"retry_initial_interval":            c.RetryInitialInterval,
+		"retry_max_interval":                c.RetryMaxInterval,
+		"spool_dir":                         c.SpoolDir,
+		"spool_key":                         c.SpoolKey,
+		"sampler":                           c.Sampler,
+		"sampler_arg":                       c.SamplerArg,
+		"span_attribute_value_length_limit": strconv.Itoa(c.SpanAttributeValueLengthLimit),
+		"span_attribute_count_limit":        strconv.Itoa(c.SpanAttributeCountLimit),
+		"span_event_count_limit":            strconv.Itoa(c.SpanEventCountLimit),
+		"span_link_count_limit":             strconv.Itoa(c.SpanLinkCountLimit),
+		"oauth2_token_url":                  c.OAuth2TokenURL,
+		"oauth2_client_id":                  c.OAuth2ClientID,
+		"oauth2_client_secret":              c.OAuth2ClientSecret,
+		"oauth2_scopes":                     c.OAuth2Scopes,
+		"oauth2_cache_dir":                  c.OAuth2CacheDir,
+		"grafana_url":                       c.GrafanaUrl,
+		"grafana_token":                     c.GrafanaToken,
+		"grafana_dashboard_uid":             c.GrafanaDashboardUid,
+		"grafana_tags":                      c.GrafanaTags,
+		"grafana_trace_url":                 c.GrafanaTraceUrl,
+		"pushgateway_url":                   c.PushgatewayUrl,
+		"pushgateway_job":                   c.PushgatewayJob,
+		"pushgateway_labels":                flattenStringMap(c.PushgatewayLabels, "{}"),
+		"statsd":                            c.Statsd,
+		"statsd_prefix":                     c.StatsdPrefix,
+		"statsd_dogstatsd":                  strconv.FormatBool(c.StatsdDogstatsd),
+		"fanout_policy":                     c.FanoutPolicy,
+		"file_format":                       c.FileFormat,
+		"file_max_megabytes":                strconv.Itoa(c.FileMaxMegabytes),
+		"file_max_days":                     strconv.Itoa(c.FileMaxDays),
+		"file_max_backups":                  strconv.Itoa(c.FileMaxBackups),
+		"file_max_age":                      c.FileMaxAge,
+		"kafka_brokers":                     c.KafkaBrokers,
+		"kafka_encoding":                    c.KafkaEncoding,
+		"kafka_sasl_mechanism":              c.KafkaSASLMechanism,
+		"kafka_sasl_username":               c.KafkaSASLUsername,
+		"kafka_sasl_password":               c.KafkaSASLPassword,
+		"agent_socket":                      c.AgentSocket,
+		"agent_flush_interval":              c.AgentFlushInterval,
+		"agent_batch_size":                  strconv.Itoa(c.AgentBatchSize),
+		"ingest_max_rate":                   strconv.Itoa(c.IngestMaxRate),
+		"ingest_max_queue":                  strconv.Itoa(c.IngestMaxQueue),
+		"async":                             strconv.FormatBool(c.Async),
+		"async_dir":                         c.AsyncDir,
+		"async_max_inflight":                strconv.Itoa(c.AsyncMaxInflight),
+		"headers":                           flattenStringMap(c.Headers, "{}"),
+		"otlp_headers_file":                 c.OtlpHeadersFile,
+		"otlp_header_cmd":                   c.OtlpHeaderCmd,
+		"traces_headers":                    flattenStringMap(c.TracesHeaders, "{}"),
+		"metrics_headers":                   flattenStringMap(c.MetricsHeaders, "{}"),
+		"user_agent":                        c.UserAgent,
+		"grpc_metadata":                     flattenStringMap(c.GrpcMetadata, "{}"),
+		"logs_headers":                      flattenStringMap(c.LogsHeaders, "{}"),
+		"insecure":                          strconv.FormatBool(c.Insecure),
+		"blocking":                          strconv.FormatBool(c.Blocking),
+		"tls_no_verify":                     strconv.FormatBool(c.TlsNoVerify),
+		"tls_ca_cert":                       c.TlsCACert,
+		"tls_client_key":                    c.TlsClientKey,
+		"tls_client_cert":                   c.TlsClientCert,
+		"service_name":                      c.ServiceName,
+		"resource_attributes":               flattenStringMap(c.ResourceAttributes, "{}"),
+		"service_version":                   c.ServiceVersion,
+		"environment":                       c.Environment,
+		"resource_detectors":                c.ResourceDetectors,
+		"span_name":                         c.SpanName,
+		"span_kind":                         c.Kind,
+		"span_attributes":                   flattenStringMap(c.Attributes, "{}"),
+		"span_attributes_file":              c.AttributesFile,
+		"span_attribute_commands":           strings.Join(c.AttributeCommands, "; "),
+		"strict_semconv":                    strconv.FormatBool(c.StrictSemconv),
+		"ci_detect":                         c.CiDetect,
+		"git_attrs":                         strconv.FormatBool(c.GitAttrs),
+		"span_status_code":                  c.StatusCode,
+		"span_status_description":           c.StatusDescription,
+		"traceparent_carrier_file":          c.TraceparentCarrierFile,
+		"traceparent_carrier_format":        c.TraceparentCarrierFormat,
+		"traceparent_ignore_env":            strconv.FormatBool(c.TraceparentIgnoreEnv),
+		"traceparent_print":                 strconv.FormatBool(c.TraceparentPrint),
+		"traceparent_print_export":          strconv.FormatBool(c.TraceparentPrintExport),
+		"traceparent_print_format":          c.TraceparentPrintFormat,
+		"traceparent_required":              strconv.FormatBool(c.TraceparentRequired),
+		"background_parent_poll_ms":         strconv.Itoa(c.BackgroundParentPollMs),
+		"background_socket_directory":       c.BackgroundSockdir,
+		"background_wait":                   strconv.FormatBool(c.BackgroundWait),
+		"background_skip_pid_check":         strconv.FormatBool(c.BackgroundSkipParentPidCheck),
+		"exec_command_timeout":              c.ExecCommandTimeout,
+		"exec_annotate":                     strconv.FormatBool(c.ExecAnnotate),
+		"clock_skew":                        c.ClockSkew,
+		"clock_skew_ntp_server":             c.ClockSkewNtpServer,
+		"status_probe":                      strconv.FormatBool(c.StatusProbe),
+		"status_check":                      strconv.FormatBool(c.StatusCheck),
+		"server_http_endpoint":              c.ServerHttpEndpoint,
+		"server_forward_listen":             c.ServerForwardListen,
+		"server_metrics_endpoint":           c.ServerMetricsEndpoint,
+		"server_tls_cert":                   c.ServerTlsCert,
+		"server_tls_key":                    c.ServerTlsKey,
+		"server_tls_client_ca":              c.ServerTlsClientCA,
+		"server_auth_token":                 c.ServerAuthToken,
+		"server_auth_basic":                 c.ServerAuthBasic,
+		"server_allow_ips":                  c.ServerAllowIps,
+		"server_keep":                       strings.Join(c.ServerKeep, "; "),
+		"server_drop":                       strings.Join(c.ServerDrop, "; "),
+		"server_sample":                     c.ServerSample,
+		"server_keep_wait":                  c.ServerKeepWait,
+		"replay_shift_to_now":               strconv.FormatBool(c.ReplayShiftToNow),
+		"span_start_time":                   c.SpanStartTime,
+		"span_end_time":                     c.SpanEndTime,
+		"event_name":                        c.EventName,
+		"event_time":                        c.EventTime,
+		"metric_unit":                       c.MetricUnit,
+		"metric_description":                c.MetricDescription,
+		"metric_increment":                  c.MetricIncrement,
+		"metric_buckets":                    c.MetricBuckets,
+		"log_severity":                      c.LogSeverity,
+		"log_body":                          c.LogBody,
+		"log_stdin":                         strconv.FormatBool(c.LogStdin),
+		"config_file":                       c.CfgFile,
+		"profile":                           c.Profile,
+		"verbose":                           strconv.FormatBool(c.Verbose),
+		"log_level":                         c.LogLevel,
+		"log_format":                        c.LogFormat,
+		"debug_wire":                        c.DebugWire,
+		"json":                              strconv.FormatBool(c.Json),
+	}
+}
+
+// GetIsRecording returns true if an endpoint or agent socket is set and otel-cli expects
+// to send real spans. Returns false if unconfigured or the exporter is 'none' and going
+// to run inert.
 func (c Config) GetIsRecording() bool {
-	if c.Endpoint == "" && c.TracesEndpoint == "" {
+	if c.Exporter == "none" {
+		Diag.IsRecording = false
+		return false
+	}
+
+	if c.Exporter == "console" || len(c.Targets) > 0 || c.AgentSocket != "" {
+		Diag.IsRecording = true
+		return true
+	}
+
+	if c.Endpoint == "" && c.getSignalEndpoint(c.getSignal()) == "" {
 		Diag.IsRecording = false
 		return false
 	}
@@ -297,17 +674,24 @@ func parseDuration(d string) (time.Duration, error) {
 	return out, nil
 }
 
-// ParseEndpoint takes the endpoint or signal endpoint, augments as needed
+// ParseEndpoint takes the endpoint or traces endpoint, augments as needed
 // (e.g. bare host:port for gRPC) and then parses as a URL.
-// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md#endpoint-urls-for-otlphttp
 func (config Config) ParseEndpoint() (*url.URL, string) {
+	return config.ParseSignalEndpoint(config.getSignal())
+}
+
+// ParseSignalEndpoint takes the signal endpoint or general endpoint for the
+// signal (traces, metrics, or logs), augments as needed (e.g. bare host:port
+// for gRPC) and then parses as a URL.
+// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md#endpoint-urls-for-otlphttp
+func (config Config) ParseSignalEndpoint(signal string) (*url.URL, string) {
 	var endpoint, source string
 	var epUrl *url.URL
 	var err error
 
 	// signal-specific configs get precedence over general endpoint per OTel spec
-	if config.TracesEndpoint != "" {
-		endpoint = config.TracesEndpoint
+	if signalEndpoint := config.getSignalEndpoint(signal); signalEndpoint != "" {
+		endpoint = signalEndpoint
 		source = "signal"
 	} else if config.Endpoint != "" {
 		endpoint = config.Endpoint
@@ -326,7 +710,12 @@ func (config Config) ParseEndpoint() (*url.URL, string) {
 	} else if len(parts) > 1 { // could be URI or host:port
 		// actual URIs
 		// grpc:// is only an otel-cli thing, maybe should drop it?
-		if parts[0] == "grpc" || parts[0] == "http" || parts[0] == "https" {
+		// unix:// connects to a unix domain socket with either protocol
+		// stdout://, stderr://, and file:// write to local files instead of sending
+		// zipkin:// and zipkins:// send Zipkin v2 JSON instead of OTLP
+		// kafka:// and kafkas:// publish OTLP to a Kafka topic
+		// srv:// is resolved with a DNS SRV lookup at send time
+		if isEndpointScheme(parts[0]) {
 			epUrl, err = url.Parse(endpoint)
 			if err != nil {
 				config.SoftFail("error parsing provided %s URI '%s': %s", source, endpoint, err)
@@ -340,41 +729,63 @@ func (config Config) ParseEndpoint() (*url.URL, string) {
 		}
 	}
 
-	// Per spec, /v1/traces is the default, appended to any url passed
+	// Per spec, /v1/{signal} is the default, appended to any url passed
 	// to the general endpoint
-	if strings.HasPrefix(epUrl.Scheme, "http") && source != "signal" && !strings.HasSuffix(epUrl.Path, "/v1/traces") {
-		epUrl.Path = path.Join(epUrl.Path, "/v1/traces")
+	signalPath := "/v1/" + signal
+	if strings.HasPrefix(epUrl.Scheme, "http") && source != "signal" && !strings.HasSuffix(epUrl.Path, signalPath) {
+		epUrl.Path = path.Join(epUrl.Path, signalPath)
 	}
 
 	Diag.EndpointSource = source
-	Diag.Endpoint = epUrl.String()
+	Diag.Endpoint = endpointString(epUrl)
 	return epUrl, source
 }
 
-// SoftLog only calls through to log if otel-cli was run with the --verbose flag.
+// endpointSchemes are the URI schemes ParseSignalEndpoint accepts. Anything
+// else with a colon is taken to be a gRPC host:port.
+var endpointSchemes = []string{"grpc", "http", "https", "unix", "stdout", "stderr", "file", "zipkin", "zipkins", "kafka", "kafkas", "srv"}
+
+// isEndpointScheme returns true if scheme is one of endpointSchemes.
+func isEndpointScheme(scheme string) bool {
+	for _, s := range endpointSchemes {
+		if scheme == s {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointString returns the endpoint URL the way it was written. url.URL
+// drops the // when there's no host or path, printing stdout:// as stdout:.
+func endpointString(u *url.URL) string {
+	if u.Host == "" && u.Path == "" && u.Opaque == "" && u.User == nil {
+		return u.Scheme + "://" + strings.TrimPrefix(u.String(), u.Scheme+":")
+	}
+	return u.String()
+}
+
+// SoftLog logs at info level, which is only seen with --verbose or a
+// --log-level of info or debug.
 // TODO: does it make any sense to support %w? probably yes, can clean up some
 // diagnostics.Error touch points.
 func (c Config) SoftLog(format string, a ...interface{}) {
-	if !c.Verbose {
-		return
-	}
-	log.Printf(format, a...)
+	c.Logger().Info(fmt.Sprintf(format, a...))
 }
 
-// SoftLogIfErr calls SoftLog only if err != nil.
+// SoftLogIfErr logs err at warn level only if err != nil.
 // Written as an interim step to pushing errors up the stack instead of calling
 // SoftLog/SoftFail directly in methods that don't need a config handle.
 func (c Config) SoftLogIfErr(err error) {
 	if err != nil {
-		c.SoftLog(err.Error())
+		c.Logger().Warn(err.Error())
 	}
 }
 
-// SoftFail calls through to softLog (which logs only if otel-cli was run with the --verbose
-// flag), then immediately exits - with status -1 by default, or 1 if --fail was
-// set (a la `curl --fail`)
+// SoftFail logs at error level (which is only seen with --verbose or
+// --log-level), then immediately exits - with status 0 by default, or 1 if
+// --fail was set (a la `curl --fail`)
 func (c Config) SoftFail(format string, a ...interface{}) {
-	c.SoftLog(format, a...)
+	c.Logger().Error(fmt.Sprintf(format, a...))
 
 	if c.Fail {
 		os.Exit(1)
@@ -545,6 +956,17 @@ func (c Config) WithProtocol(with string) Config {
 	return c
 }
 
+// WithExporter returns the config with Exporter set to the provided value.
+func (c Config) WithExporter(with string) Config {
+	c.Exporter = with
+	return c
+}
+
+// GetProtocol returns the configured OTLP protocol.
+func (c Config) GetProtocol() string {
+	return c.Protocol
+}
+
 // GetTimeout returns the parsed --timeout value as a time.Duration.
 func (c Config) GetTimeout() time.Duration {
 	return c.ParseCliTimeout()
@@ -556,9 +978,217 @@ func (c Config) WithTimeout(with string) Config {
 	return c
 }
 
-// GetHeaders returns the stringmap of configured headers.
+// GetConnectTimeout returns the parsed --connect-timeout value, the most time
+// to spend establishing a connection to the endpoint. Zero means connecting
+// is only limited by --timeout.
+func (c Config) GetConnectTimeout() time.Duration {
+	out, err := parseDuration(c.ConnectTimeout)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// WithConnectTimeout returns the config with ConnectTimeout set to the provided value.
+func (c Config) WithConnectTimeout(with string) Config {
+	c.ConnectTimeout = with
+	return c
+}
+
+// GetSendTimeout returns the parsed --send-timeout value, the most time to
+// wait on each export request. Defaults to --timeout when unset.
+func (c Config) GetSendTimeout() time.Duration {
+	if c.SendTimeout == "" {
+		return c.GetTimeout()
+	}
+	out, err := parseDuration(c.SendTimeout)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// WithSendTimeout returns the config with SendTimeout set to the provided value.
+func (c Config) WithSendTimeout(with string) Config {
+	c.SendTimeout = with
+	return c
+}
+
+// GetCompression returns the configured OTLP compression, gzip or none.
+func (c Config) GetCompression() string {
+	return c.Compression
+}
+
+// WithCompression returns the config with Compression set to the provided value.
+func (c Config) WithCompression(with string) Config {
+	c.Compression = with
+	return c
+}
+
+// GetProxy returns the proxy URL set with --proxy, or an empty string.
+func (c Config) GetProxy() string {
+	return c.Proxy
+}
+
+// WithProxy returns the config with Proxy set to the provided value.
+func (c Config) WithProxy(with string) Config {
+	c.Proxy = with
+	return c
+}
+
+// GetRetryMaxAttempts returns the maximum number of export attempts. Zero
+// means keep retrying until --timeout.
+func (c Config) GetRetryMaxAttempts() int {
+	return c.RetryMaxAttempts
+}
+
+// WithRetryMaxAttempts returns the config with RetryMaxAttempts set to the provided value.
+func (c Config) WithRetryMaxAttempts(with int) Config {
+	c.RetryMaxAttempts = with
+	return c
+}
+
+// GetRetryInitialInterval returns the parsed --retry-initial-interval value.
+func (c Config) GetRetryInitialInterval() time.Duration {
+	out, err := parseDuration(c.RetryInitialInterval)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// WithRetryInitialInterval returns the config with RetryInitialInterval set to the provided value.
+func (c Config) WithRetryInitialInterval(with string) Config {
+	c.RetryInitialInterval = with
+	return c
+}
+
+// GetRetryMaxInterval returns the parsed --retry-max-interval value.
+func (c Config) GetRetryMaxInterval() time.Duration {
+	out, err := parseDuration(c.RetryMaxInterval)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// WithRetryMaxInterval returns the config with RetryMaxInterval set to the provided value.
+func (c Config) WithRetryMaxInterval(with string) Config {
+	c.RetryMaxInterval = with
+	return c
+}
+
+// WithFanoutPolicy returns the config with FanoutPolicy set to the provided value.
+func (c Config) WithFanoutPolicy(with string) Config {
+	c.FanoutPolicy = with
+	return c
+}
+
+// GetSpoolDir returns the directory failed exports are spooled to, or an
+// empty string when spooling is disabled.
+func (c Config) GetSpoolDir() string {
+	return c.SpoolDir
+}
+
+// WithSpoolDir returns the config with SpoolDir set to the provided value.
+func (c Config) WithSpoolDir(with string) Config {
+	c.SpoolDir = with
+	return c
+}
+
+// GetSpoolKey returns the AES-256 key for encrypting spooled spans, or nil
+// when encryption is off. SpoolKey is a path to a file or env:VARNAME, never
+// the secret itself, so it doesn't show up in the process list. The secret
+// is hashed with SHA-256 so any string works, though it should be random,
+// e.g. from openssl rand -base64 32.
+func (c Config) GetSpoolKey() []byte {
+	if c.SpoolKey == "" {
+		return nil
+	}
+
+	var secret string
+	if strings.HasPrefix(c.SpoolKey, "env:") {
+		envVar := strings.TrimPrefix(c.SpoolKey, "env:")
+		secret = os.Getenv(envVar)
+		if secret == "" {
+			c.SoftFail("failed to load spool key: environment variable %q is empty or not set", envVar)
+		}
+	} else {
+		data, err := os.ReadFile(c.SpoolKey)
+		if err != nil {
+			c.SoftFail("failed to load spool key: %s", err)
+		}
+		secret = string(data)
+	}
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		c.SoftFail("spool key %q is empty", c.SpoolKey)
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	return key[:]
+}
+
+// WithSpoolKey returns the config with SpoolKey set to the provided value.
+func (c Config) WithSpoolKey(with string) Config {
+	c.SpoolKey = with
+	return c
+}
+
+// GetFileFormat returns the format for file:// endpoints, json or proto.
+func (c Config) GetFileFormat() string {
+	return c.FileFormat
+}
+
+// WithFileFormat returns the config with FileFormat set to the provided value.
+func (c Config) WithFileFormat(with string) Config {
+	c.FileFormat = with
+	return c
+}
+
+// GetFileMaxMegabytes returns the size at which file:// endpoints are rotated.
+func (c Config) GetFileMaxMegabytes() int {
+	return c.FileMaxMegabytes
+}
+
+// WithFileMaxMegabytes returns the config with FileMaxMegabytes set to the provided value.
+func (c Config) WithFileMaxMegabytes(with int) Config {
+	c.FileMaxMegabytes = with
+	return c
+}
+
+// GetFileMaxDays returns how many days rotated files are kept.
+func (c Config) GetFileMaxDays() int {
+	return c.FileMaxDays
+}
+
+// WithFileMaxDays returns the config with FileMaxDays set to the provided value.
+func (c Config) WithFileMaxDays(with int) Config {
+	c.FileMaxDays = with
+	return c
+}
+
+// GetFileMaxBackups returns how many rotated files are kept.
+func (c Config) GetFileMaxBackups() int {
+	return c.FileMaxBackups
+}
+
+// WithFileMaxBackups returns the config with FileMaxBackups set to the provided value.
+func (c Config) WithFileMaxBackups(with int) Config {
+	c.FileMaxBackups = with
+	return c
+}
+
+// GetFileMaxAge returns the parsed --file-max-age value, the age at which
+// file:// endpoints are rotated.
+func (c Config) GetFileMaxAge() time.Duration {
+	out, err := parseDuration(c.FileMaxAge)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// WithFileMaxAge returns the config with FileMaxAge set to the provided value.
+func (c Config) WithFileMaxAge(with string) Config {
+	c.FileMaxAge = with
+	return c
+}
+
+// GetHeaders returns the stringmap of configured headers for traces.
 func (c Config) GetHeaders() map[string]string {
-	return c.Headers
+	return c.GetSignalHeaders(c.getSignal())
 }
 
 // WithHeades returns the config with Heades set to the provided value.
@@ -614,6 +1244,23 @@ func (c Config) WithServiceName(with string) Config {
 	return c
 }
 
+// GetResourceDetectors returns the --resource-detectors names as a list.
+func (c Config) GetResourceDetectors() []string {
+	out := []string{}
+	for _, name := range strings.Split(c.ResourceDetectors, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// WithResourceDetectors returns the config with ResourceDetectors set to the provided value.
+func (c Config) WithResourceDetectors(with string) Config {
+	c.ResourceDetectors = with
+	return c
+}
+
 // WithSpanName returns the config with SpanName set to the provided value.
 func (c Config) WithSpanName(with string) Config {
 	c.SpanName = with
@@ -650,6 +1297,12 @@ func (c Config) WithTraceparentCarrierFile(with string) Config {
 	return c
 }
 
+// WithTraceparentCarrierFormat returns the config with TraceparentCarrierFormat set to the provided value.
+func (c Config) WithTraceparentCarrierFormat(with string) Config {
+	c.TraceparentCarrierFormat = with
+	return c
+}
+
 // WithTraceparentIgnoreEnv returns the config with TraceparentIgnoreEnv set to the provided value.
 func (c Config) WithTraceparentIgnoreEnv(with bool) Config {
 	c.TraceparentIgnoreEnv = with
@@ -668,6 +1321,12 @@ func (c Config) WithTraceparentPrintExport(with bool) Config {
 	return c
 }
 
+// WithTraceparentPrintFormat returns the config with TraceparentPrintFormat set to the provided value.
+func (c Config) WithTraceparentPrintFormat(with string) Config {
+	c.TraceparentPrintFormat = with
+	return c
+}
+
 // WithTraceparentRequired returns the config with TraceparentRequired set to the provided value.
 func (c Config) WithTraceparentRequired(with bool) Config {
 	c.TraceparentRequired = with
@@ -710,6 +1369,102 @@ func (c Config) WithStatusCanaryInterval(with string) Config {
 	return c
 }
 
+// WithStatusProbe returns the config with StatusProbe set to the provided value.
+func (c Config) WithStatusProbe(with bool) Config {
+	c.StatusProbe = with
+	return c
+}
+
+// WithStatusCheck returns the config with StatusCheck set to the provided value.
+func (c Config) WithStatusCheck(with bool) Config {
+	c.StatusCheck = with
+	return c
+}
+
+// WithServerHttpEndpoint returns the config with ServerHttpEndpoint set to the provided value.
+func (c Config) WithServerHttpEndpoint(with string) Config {
+	c.ServerHttpEndpoint = with
+	return c
+}
+
+// WithServerForwardListen returns the config with ServerForwardListen set to the provided value.
+func (c Config) WithServerForwardListen(with string) Config {
+	c.ServerForwardListen = with
+	return c
+}
+
+// WithServerMetricsEndpoint returns the config with ServerMetricsEndpoint set to the provided value.
+func (c Config) WithServerMetricsEndpoint(with string) Config {
+	c.ServerMetricsEndpoint = with
+	return c
+}
+
+// WithServerTlsCert returns the config with ServerTlsCert set to the provided value.
+func (c Config) WithServerTlsCert(with string) Config {
+	c.ServerTlsCert = with
+	return c
+}
+
+// WithServerTlsKey returns the config with ServerTlsKey set to the provided value.
+func (c Config) WithServerTlsKey(with string) Config {
+	c.ServerTlsKey = with
+	return c
+}
+
+// WithServerTlsClientCA returns the config with ServerTlsClientCA set to the provided value.
+func (c Config) WithServerTlsClientCA(with string) Config {
+	c.ServerTlsClientCA = with
+	return c
+}
+
+// WithServerAuthToken returns the config with ServerAuthToken set to the provided value.
+func (c Config) WithServerAuthToken(with string) Config {
+	c.ServerAuthToken = with
+	return c
+}
+
+// WithServerAuthBasic returns the config with ServerAuthBasic set to the provided value.
+func (c Config) WithServerAuthBasic(with string) Config {
+	c.ServerAuthBasic = with
+	return c
+}
+
+// WithServerAllowIps returns the config with ServerAllowIps set to the provided value.
+func (c Config) WithServerAllowIps(with string) Config {
+	c.ServerAllowIps = with
+	return c
+}
+
+// WithServerKeep returns the config with ServerKeep set to the provided value.
+func (c Config) WithServerKeep(with []string) Config {
+	c.ServerKeep = with
+	return c
+}
+
+// WithServerDrop returns the config with ServerDrop set to the provided value.
+func (c Config) WithServerDrop(with []string) Config {
+	c.ServerDrop = with
+	return c
+}
+
+// WithServerSample returns the config with ServerSample set to the provided value.
+func (c Config) WithServerSample(with string) Config {
+	c.ServerSample = with
+	return c
+}
+
+// WithServerKeepWait returns the config with ServerKeepWait set to the provided value.
+func (c Config) WithServerKeepWait(with string) Config {
+	c.ServerKeepWait = with
+	return c
+}
+
+// WithReplayShiftToNow returns the config with ReplayShiftToNow set to the provided value.
+func (c Config) WithReplayShiftToNow(with bool) Config {
+	c.ReplayShiftToNow = with
+	return c
+}
+
 // WithSpanStartTime returns the config with SpanStartTime set to the provided value.
 func (c Config) WithSpanStartTime(with string) Config {
 	c.SpanStartTime = with
@@ -740,6 +1495,12 @@ func (c Config) WithCfgFile(with string) Config {
 	return c
 }
 
+// WithProfile returns the config with Profile set to the provided value.
+func (c Config) WithProfile(with string) Config {
+	c.Profile = with
+	return c
+}
+
 // WithVerbose returns the config with Verbose set to the provided value.
 func (c Config) WithVerbose(with bool) Config {
 	c.Verbose = with
@@ -0,0 +1,81 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// runAttributeCommands runs each --attr-from-cmd key=command and sets the
+// attribute to the command's trimmed output. A command that fails doesn't
+// stop otel-cli, since the span is usually more important than one
+// attribute, so the error is recorded in a key.error attribute instead.
+// Only malformed flags return an error.
+func (c *Config) runAttributeCommands(ctx context.Context) error {
+	if len(c.AttributeCommands) == 0 {
+		return nil
+	}
+
+	attrs := make(map[string]string, len(c.Attributes)+len(c.AttributeCommands))
+	for k, v := range c.Attributes {
+		attrs[k] = v
+	}
+
+	for _, attrCmd := range c.AttributeCommands {
+		key, command, err := parseAttributeCommand(attrCmd)
+		if err != nil {
+			return err
+		}
+
+		value, err := c.runAttributeCommand(ctx, command)
+		if err != nil {
+			c.SoftLog("--attr-from-cmd %s: %s", key, err)
+			attrs[key+".error"] = err.Error()
+			continue
+		}
+		attrs[key] = value
+	}
+	c.Attributes = attrs
+
+	return nil
+}
+
+// parseAttributeCommand splits an --attr-from-cmd value on the first =.
+func parseAttributeCommand(in string) (string, string, error) {
+	key, command, ok := strings.Cut(in, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" || strings.TrimSpace(command) == "" {
+		return "", "", fmt.Errorf("invalid --attr-from-cmd %q, must be key=command", in)
+	}
+	return key, command, nil
+}
+
+// runAttributeCommand runs the command with the shell, /bin/sh or cmd.exe on
+// Windows, and returns its output with surrounding whitespace trimmed.
+func (c Config) runAttributeCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.GetTimeout())
+	defer cancel()
+
+	shell, flag := "/bin/sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("command %q failed: %w", command, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// WithAttributeCommands returns the config with AttributeCommands set to the provided value.
+func (c Config) WithAttributeCommands(with []string) Config {
+	c.AttributeCommands = with
+	return c
+}
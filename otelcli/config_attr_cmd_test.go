@@ -0,0 +1,38 @@
+package otelcli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunAttributeCommands(t *testing.T) {
+	c := DefaultConfig().
+		WithAttributes(map[string]string{"team": "infra", "git_sha": "stale"}).
+		WithAttributeCommands([]string{
+			"git_sha=echo '  abc123  '",
+			"branch=printf 'main\\n\\n'",
+			"broken=exit 3",
+		})
+	if err := c.runAttributeCommands(context.Background()); err != nil {
+		t.Fatalf("failed to run attribute commands: %s", err)
+	}
+
+	errMsg := c.Attributes["broken.error"]
+	if !strings.Contains(errMsg, "exit status 3") {
+		t.Errorf("expected the failure to be recorded but got %q", errMsg)
+	}
+	want := map[string]string{"team": "infra", "git_sha": "abc123", "branch": "main", "broken.error": errMsg}
+	if diff := cmp.Diff(want, c.Attributes); diff != "" {
+		t.Errorf("attributes did not match (-want +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{"git rev-parse HEAD", "=true", "key="} {
+		c := DefaultConfig().WithAttributeCommands([]string{bad})
+		if err := c.runAttributeCommands(context.Background()); err == nil {
+			t.Errorf("expected %q to fail", bad)
+		}
+	}
+}
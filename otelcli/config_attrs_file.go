@@ -0,0 +1,161 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// GetAttributes returns the git attributes, the systemd attributes, the CI
+// attributes, the attributes from --attrs-file, and --attrs, each winning
+// over the ones before it for the same key.
+// Unlike --attrs, the file's values keep the types they have in the JSON or
+// YAML.
+func (c Config) GetAttributes() []*commonpb.KeyValue {
+	fileAttrs := []*commonpb.KeyValue{}
+	if c.AttributesFile != "" {
+		var err error
+		fileAttrs, err = loadAttributesFile(c.AttributesFile)
+		c.SoftFailIfErr(err)
+	}
+
+	detected := map[string]string{}
+	for k, v := range c.gitAttributes("") {
+		detected[k] = v
+	}
+	for k, v := range c.systemdAttributes(os.Getenv) {
+		detected[k] = v
+	}
+	for k, v := range c.ciAttributes(os.Getenv) {
+		detected[k] = v
+	}
+	for _, attr := range fileAttrs {
+		delete(detected, attr.Key)
+	}
+	for key := range c.Attributes {
+		delete(detected, key)
+	}
+
+	// detected values stay strings, run ids and revisions are identifiers
+	// rather than numbers
+	out := []*commonpb.KeyValue{}
+	for key, value := range detected {
+		out = append(out, &commonpb.KeyValue{
+			Key:   key,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+
+	return append(out, mergeAttrs(fileAttrs, c.Attributes)...)
+}
+
+// mergeAttrs returns the attributes with the string attributes added,
+// replacing any with the same keys.
+func mergeAttrs(attrs []*commonpb.KeyValue, with map[string]string) []*commonpb.KeyValue {
+	out := []*commonpb.KeyValue{}
+	for _, attr := range attrs {
+		if _, ok := with[attr.Key]; !ok {
+			out = append(out, attr)
+		}
+	}
+	return append(out, otlpclient.StringMapAttrsToProtobuf(with)...)
+}
+
+// loadAttributesFile reads a JSON or YAML object and converts it to attributes.
+// Nested objects are flattened into dotted keys, so a CI metadata blob like
+// {"git": {"sha": "abc"}} becomes git.sha=abc. Nulls are skipped.
+func loadAttributesFile(path string) ([]*commonpb.KeyValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes file: %w", err)
+	}
+
+	// JSON is YAML, so one parser handles both
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse attributes file '%s': %w", path, err)
+	}
+
+	out := []*commonpb.KeyValue{}
+	if err := flattenAttrs("", obj, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse attributes file '%s': %w", path, err)
+	}
+	return out, nil
+}
+
+// flattenAttrs appends the object's values to out, sorted by key, with
+// nested objects' keys joined by dots.
+func flattenAttrs(prefix string, obj map[string]interface{}, out *[]*commonpb.KeyValue) error {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := prefix + k
+		switch v := obj[k].(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			if err := flattenAttrs(key+".", v, out); err != nil {
+				return err
+			}
+		default:
+			av, err := attrAnyValue(v)
+			if err != nil {
+				return fmt.Errorf("attribute %q: %w", key, err)
+			}
+			*out = append(*out, &commonpb.KeyValue{Key: key, Value: av})
+		}
+	}
+
+	return nil
+}
+
+// attrAnyValue converts a decoded JSON/YAML value to an OTLP value.
+func attrAnyValue(in interface{}) (*commonpb.AnyValue, error) {
+	switch v := in.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}, nil
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}, nil
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}, nil
+	case uint64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}, nil
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}, nil
+	case []interface{}:
+		array := &commonpb.ArrayValue{}
+		for _, elem := range v {
+			av, err := attrAnyValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			array.Values = append(array.Values, av)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: array}}, nil
+	case map[string]interface{}:
+		kvlist := []*commonpb.KeyValue{}
+		if err := flattenAttrs("", v, &kvlist); err != nil {
+			return nil, err
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: kvlist}}}, nil
+	case nil:
+		return &commonpb.AnyValue{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", in)
+	}
+}
+
+// WithAttributesFile returns the config with AttributesFile set to the provided value.
+func (c Config) WithAttributesFile(with string) Config {
+	c.AttributesFile = with
+	return c
+}
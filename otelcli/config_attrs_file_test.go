@@ -0,0 +1,75 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestLoadAttributesFile(t *testing.T) {
+	str := func(v string) *commonpb.AnyValue {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	}
+	want := []*commonpb.KeyValue{
+		{Key: "ci.attempt", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 2}}},
+		{Key: "ci.coverage", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: 81.5}}},
+		{Key: "ci.tags", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{
+			Values: []*commonpb.AnyValue{str("linux"), str("amd64")},
+		}}}},
+		{Key: "git.dirty", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: false}}},
+		{Key: "git.sha", Value: str("abc123")},
+		{Key: "version", Value: str("1.0")},
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"build-meta.json": `{"version": "1.0", "skipped": null, "git": {"sha": "abc123", "dirty": false},
+			"ci": {"attempt": 2, "coverage": 81.5, "tags": ["linux", "amd64"]}}`,
+		"build-meta.yaml": "version: \"1.0\"\nskipped:\ngit:\n  sha: abc123\n  dirty: false\n" +
+			"ci:\n  attempt: 2\n  coverage: 81.5\n  tags: [linux, amd64]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			os.WriteFile(path, []byte(data), 0644)
+
+			got, err := loadAttributesFile(path)
+			if err != nil {
+				t.Fatalf("failed to load attributes file: %s", err)
+			}
+			if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("attributes did not match (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`["not", "an", "object"]`), 0644)
+	if _, err := loadAttributesFile(bad); err == nil {
+		t.Error("expected a non-object file to fail")
+	}
+	if _, err := loadAttributesFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+}
+
+func TestGetAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build-meta.json")
+	os.WriteFile(path, []byte(`{"attempt": 2, "branch": "main"}`), 0644)
+
+	c := DefaultConfig().
+		WithAttributesFile(path).
+		WithAttributes(map[string]string{"branch": "release"}).
+		WithCiDetect("none")
+	got := map[string]*commonpb.AnyValue{}
+	for _, attr := range c.GetAttributes() {
+		got[attr.Key] = attr.Value
+	}
+
+	if got["attempt"].GetIntValue() != 2 || got["branch"].GetStringValue() != "release" || len(got) != 2 {
+		t.Errorf("expected the file's typed attributes with --attrs overriding but got %v", got)
+	}
+}
@@ -0,0 +1,220 @@
+package otelcli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ciProvider detects a CI system from its environment and returns the
+// attributes describing the pipeline run otel-cli is running in. Providers
+// map to the cicd and vcs semantic conventions where they can, so queries
+// work the same across CI systems, and use <name>.* for the rest.
+type ciProvider struct {
+	name   string
+	detect func(getenv func(string) string) bool
+	attrs  func(getenv func(string) string) map[string]string
+}
+
+// ciProviders are checked in order and the first one detected is used.
+var ciProviders = []ciProvider{
+	{
+		name:   "github",
+		detect: func(getenv func(string) string) bool { return getenv("GITHUB_ACTIONS") == "true" },
+		attrs:  githubActionsAttributes,
+	},
+	{
+		name:   "gitlab",
+		detect: func(getenv func(string) string) bool { return getenv("GITLAB_CI") == "true" },
+		attrs:  gitlabAttributes,
+	},
+	{
+		name:   "jenkins",
+		detect: func(getenv func(string) string) bool { return getenv("JENKINS_URL") != "" },
+		attrs:  jenkinsAttributes,
+	},
+	{
+		name:   "circleci",
+		detect: func(getenv func(string) string) bool { return getenv("CIRCLECI") == "true" },
+		attrs:  circleciAttributes,
+	},
+	{
+		name:   "buildkite",
+		detect: func(getenv func(string) string) bool { return getenv("BUILDKITE") == "true" },
+		attrs:  buildkiteAttributes,
+	},
+}
+
+// ciAttributes returns the attributes for the CI system otel-cli is
+// running in, or nil when it's not in one or --ci-detect is none.
+func (c Config) ciAttributes(getenv func(string) string) map[string]string {
+	enabled := c.GetCiDetect()
+
+	for _, provider := range ciProviders {
+		if enabled != nil && !enabled[provider.name] {
+			continue
+		}
+		if !provider.detect(getenv) {
+			continue
+		}
+
+		// skip anything the CI system didn't set
+		out := map[string]string{}
+		for k, v := range provider.attrs(getenv) {
+			if v != "" {
+				out[k] = v
+			}
+		}
+		return out
+	}
+
+	return nil
+}
+
+// GetCiDetect returns the set of CI providers --ci-detect allows, or nil
+// for auto, which allows all of them.
+func (c Config) GetCiDetect() map[string]bool {
+	if c.CiDetect == "" || c.CiDetect == "auto" {
+		return nil
+	}
+
+	out := map[string]bool{}
+	for _, name := range strings.Split(c.CiDetect, ",") {
+		if name = strings.TrimSpace(name); name != "" && name != "none" {
+			out[name] = true
+		}
+	}
+	return out
+}
+
+// ciDetectErrors returns an error for each --ci-detect name that isn't a provider.
+func ciDetectErrors(config Config) []error {
+	names := []string{}
+	for _, provider := range ciProviders {
+		names = append(names, provider.name)
+	}
+
+	errs := []error{}
+	for _, name := range strings.Split(config.CiDetect, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "auto" || name == "none" {
+			continue
+		}
+		found := false
+		for _, provider := range names {
+			found = found || name == provider
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("invalid ci detect setting %q, must be auto, none, or one or more of %s", name, strings.Join(names, ", ")))
+		}
+	}
+	return errs
+}
+
+// firstEnv returns the value of the first of the envvars that's set.
+func firstEnv(getenv func(string) string, names ...string) string {
+	for _, name := range names {
+		if value := getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// https://docs.github.com/en/actions/learn-github-actions/variables#default-environment-variables
+func githubActionsAttributes(getenv func(string) string) map[string]string {
+	attrs := map[string]string{
+		"cicd.pipeline.name":          getenv("GITHUB_WORKFLOW"),
+		"cicd.pipeline.run.id":        getenv("GITHUB_RUN_ID"),
+		"cicd.pipeline.task.name":     getenv("GITHUB_JOB"),
+		"vcs.repository.ref.name":     getenv("GITHUB_REF_NAME"),
+		"vcs.repository.ref.revision": getenv("GITHUB_SHA"),
+		"github.run_attempt":          getenv("GITHUB_RUN_ATTEMPT"),
+		"github.run_number":           getenv("GITHUB_RUN_NUMBER"),
+		"github.ref":                  getenv("GITHUB_REF"),
+		"github.actor":                getenv("GITHUB_ACTOR"),
+		"github.event_name":           getenv("GITHUB_EVENT_NAME"),
+		"github.repository":           getenv("GITHUB_REPOSITORY"),
+	}
+
+	if server, repo := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"); server != "" && repo != "" {
+		attrs["vcs.repository.url.full"] = server + "/" + repo
+		if runId := getenv("GITHUB_RUN_ID"); runId != "" {
+			attrs["cicd.pipeline.run.url.full"] = server + "/" + repo + "/actions/runs/" + runId
+		}
+	}
+
+	return attrs
+}
+
+// https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+func gitlabAttributes(getenv func(string) string) map[string]string {
+	return map[string]string{
+		// CI_PIPELINE_NAME is only set when the pipeline has a name
+		"cicd.pipeline.name":              firstEnv(getenv, "CI_PIPELINE_NAME", "CI_PROJECT_PATH"),
+		"cicd.pipeline.run.id":            getenv("CI_PIPELINE_ID"),
+		"cicd.pipeline.run.url.full":      getenv("CI_PIPELINE_URL"),
+		"cicd.pipeline.task.name":         getenv("CI_JOB_NAME"),
+		"cicd.pipeline.task.run.id":       getenv("CI_JOB_ID"),
+		"cicd.pipeline.task.run.url.full": getenv("CI_JOB_URL"),
+		"vcs.repository.url.full":         getenv("CI_PROJECT_URL"),
+		"vcs.repository.ref.name":         getenv("CI_COMMIT_REF_NAME"),
+		"vcs.repository.ref.revision":     getenv("CI_COMMIT_SHA"),
+		"gitlab.job.stage":                getenv("CI_JOB_STAGE"),
+		"gitlab.pipeline.source":          getenv("CI_PIPELINE_SOURCE"),
+		"gitlab.user.login":               getenv("GITLAB_USER_LOGIN"),
+	}
+}
+
+// https://www.jenkins.io/doc/book/pipeline/jenkinsfile/#using-environment-variables
+func jenkinsAttributes(getenv func(string) string) map[string]string {
+	return map[string]string{
+		"cicd.pipeline.name":         getenv("JOB_NAME"),
+		"cicd.pipeline.run.id":       getenv("BUILD_NUMBER"),
+		"cicd.pipeline.run.url.full": getenv("BUILD_URL"),
+		"cicd.pipeline.task.name":    getenv("STAGE_NAME"),
+		"vcs.repository.url.full":    getenv("GIT_URL"),
+		// BRANCH_NAME is set by multibranch pipelines, GIT_BRANCH by the git plugin
+		"vcs.repository.ref.name":     firstEnv(getenv, "BRANCH_NAME", "GIT_BRANCH"),
+		"vcs.repository.ref.revision": getenv("GIT_COMMIT"),
+		"jenkins.node.name":           getenv("NODE_NAME"),
+	}
+}
+
+// https://circleci.com/docs/variables/#built-in-environment-variables
+func circleciAttributes(getenv func(string) string) map[string]string {
+	return map[string]string{
+		// workflow names aren't in the environment, so this is the project
+		"cicd.pipeline.name":              getenv("CIRCLE_PROJECT_REPONAME"),
+		"cicd.pipeline.run.id":            getenv("CIRCLE_WORKFLOW_ID"),
+		"cicd.pipeline.task.name":         getenv("CIRCLE_JOB"),
+		"cicd.pipeline.task.run.id":       getenv("CIRCLE_BUILD_NUM"),
+		"cicd.pipeline.task.run.url.full": getenv("CIRCLE_BUILD_URL"),
+		"vcs.repository.url.full":         getenv("CIRCLE_REPOSITORY_URL"),
+		"vcs.repository.ref.name":         firstEnv(getenv, "CIRCLE_BRANCH", "CIRCLE_TAG"),
+		"vcs.repository.ref.revision":     getenv("CIRCLE_SHA1"),
+		"circleci.username":               getenv("CIRCLE_USERNAME"),
+	}
+}
+
+// https://buildkite.com/docs/pipelines/environment-variables
+func buildkiteAttributes(getenv func(string) string) map[string]string {
+	return map[string]string{
+		"cicd.pipeline.name":          getenv("BUILDKITE_PIPELINE_SLUG"),
+		"cicd.pipeline.run.id":        getenv("BUILDKITE_BUILD_ID"),
+		"cicd.pipeline.run.url.full":  getenv("BUILDKITE_BUILD_URL"),
+		"cicd.pipeline.task.name":     firstEnv(getenv, "BUILDKITE_LABEL", "BUILDKITE_STEP_KEY"),
+		"cicd.pipeline.task.run.id":   getenv("BUILDKITE_JOB_ID"),
+		"vcs.repository.url.full":     getenv("BUILDKITE_REPO"),
+		"vcs.repository.ref.name":     firstEnv(getenv, "BUILDKITE_BRANCH", "BUILDKITE_TAG"),
+		"vcs.repository.ref.revision": getenv("BUILDKITE_COMMIT"),
+		"buildkite.build.number":      getenv("BUILDKITE_BUILD_NUMBER"),
+		"buildkite.build.creator":     getenv("BUILDKITE_BUILD_CREATOR"),
+		"buildkite.retry_count":       getenv("BUILDKITE_RETRY_COUNT"),
+	}
+}
+
+// WithCiDetect returns the config with CiDetect set to the provided value.
+func (c Config) WithCiDetect(with string) Config {
+	c.CiDetect = with
+	return c
+}
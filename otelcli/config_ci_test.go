@@ -0,0 +1,192 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// githubActionsEnv is a subset of what a workflow run sets.
+var githubActionsEnv = map[string]string{
+	"GITHUB_ACTIONS":     "true",
+	"GITHUB_WORKFLOW":    "CI",
+	"GITHUB_RUN_ID":      "8675309",
+	"GITHUB_RUN_ATTEMPT": "2",
+	"GITHUB_RUN_NUMBER":  "42",
+	"GITHUB_JOB":         "test",
+	"GITHUB_SHA":         "ffac537e6cbbf934b08745a378932722df287a53",
+	"GITHUB_REF":         "refs/heads/main",
+	"GITHUB_REF_NAME":    "main",
+	"GITHUB_ACTOR":       "octocat",
+	"GITHUB_EVENT_NAME":  "push",
+	"GITHUB_REPOSITORY":  "octo-org/octo-repo",
+	"GITHUB_SERVER_URL":  "https://github.com",
+}
+
+func TestCiAttributes(t *testing.T) {
+	getenv := func(name string) string { return githubActionsEnv[name] }
+
+	want := map[string]string{
+		"cicd.pipeline.name":          "CI",
+		"cicd.pipeline.run.id":        "8675309",
+		"cicd.pipeline.run.url.full":  "https://github.com/octo-org/octo-repo/actions/runs/8675309",
+		"cicd.pipeline.task.name":     "test",
+		"vcs.repository.url.full":     "https://github.com/octo-org/octo-repo",
+		"vcs.repository.ref.name":     "main",
+		"vcs.repository.ref.revision": "ffac537e6cbbf934b08745a378932722df287a53",
+		"github.run_attempt":          "2",
+		"github.run_number":           "42",
+		"github.ref":                  "refs/heads/main",
+		"github.actor":                "octocat",
+		"github.event_name":           "push",
+		"github.repository":           "octo-org/octo-repo",
+	}
+	if diff := cmp.Diff(want, DefaultConfig().ciAttributes(getenv)); diff != "" {
+		t.Errorf("github attributes did not match (-want +got):\n%s", diff)
+	}
+
+	if got := DefaultConfig().WithCiDetect("none").ciAttributes(getenv); got != nil {
+		t.Errorf("expected --ci-detect none to turn off CI attributes but got %q", got)
+	}
+	if got := DefaultConfig().WithCiDetect("gitlab,jenkins").ciAttributes(getenv); got != nil {
+		t.Errorf("expected --ci-detect to skip github but got %q", got)
+	}
+	if got := DefaultConfig().ciAttributes(func(string) string { return "" }); got != nil {
+		t.Errorf("expected no CI attributes outside of CI but got %q", got)
+	}
+}
+
+func TestCiProviders(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "gitlab",
+			env: map[string]string{
+				"GITLAB_CI":          "true",
+				"CI_PROJECT_PATH":    "group/project",
+				"CI_PIPELINE_ID":     "1001",
+				"CI_PIPELINE_URL":    "https://gitlab.com/group/project/-/pipelines/1001",
+				"CI_JOB_NAME":        "test",
+				"CI_JOB_ID":          "2002",
+				"CI_COMMIT_REF_NAME": "main",
+				"CI_COMMIT_SHA":      "abc123",
+			},
+			want: map[string]string{
+				"cicd.pipeline.name":          "group/project",
+				"cicd.pipeline.run.id":        "1001",
+				"cicd.pipeline.run.url.full":  "https://gitlab.com/group/project/-/pipelines/1001",
+				"cicd.pipeline.task.name":     "test",
+				"cicd.pipeline.task.run.id":   "2002",
+				"vcs.repository.ref.name":     "main",
+				"vcs.repository.ref.revision": "abc123",
+			},
+		},
+		{
+			name: "jenkins",
+			env: map[string]string{
+				"JENKINS_URL":  "https://jenkins.example.com/",
+				"JOB_NAME":     "deploy",
+				"BUILD_NUMBER": "17",
+				"BUILD_URL":    "https://jenkins.example.com/job/deploy/17/",
+				"GIT_BRANCH":   "origin/main",
+				"GIT_COMMIT":   "abc123",
+				"NODE_NAME":    "agent-3",
+			},
+			want: map[string]string{
+				"cicd.pipeline.name":          "deploy",
+				"cicd.pipeline.run.id":        "17",
+				"cicd.pipeline.run.url.full":  "https://jenkins.example.com/job/deploy/17/",
+				"vcs.repository.ref.name":     "origin/main",
+				"vcs.repository.ref.revision": "abc123",
+				"jenkins.node.name":           "agent-3",
+			},
+		},
+		{
+			name: "circleci",
+			env: map[string]string{
+				"CIRCLECI":                "true",
+				"CIRCLE_PROJECT_REPONAME": "api",
+				"CIRCLE_WORKFLOW_ID":      "6f1f-44",
+				"CIRCLE_JOB":              "build",
+				"CIRCLE_BUILD_NUM":        "88",
+				"CIRCLE_TAG":              "v1.2.3",
+				"CIRCLE_SHA1":             "abc123",
+			},
+			want: map[string]string{
+				"cicd.pipeline.name":          "api",
+				"cicd.pipeline.run.id":        "6f1f-44",
+				"cicd.pipeline.task.name":     "build",
+				"cicd.pipeline.task.run.id":   "88",
+				"vcs.repository.ref.name":     "v1.2.3",
+				"vcs.repository.ref.revision": "abc123",
+			},
+		},
+		{
+			name: "buildkite",
+			env: map[string]string{
+				"BUILDKITE":               "true",
+				"BUILDKITE_PIPELINE_SLUG": "monorepo",
+				"BUILDKITE_BUILD_ID":      "0190-aa",
+				"BUILDKITE_STEP_KEY":      "lint",
+				"BUILDKITE_BRANCH":        "main",
+				"BUILDKITE_COMMIT":        "abc123",
+				"BUILDKITE_RETRY_COUNT":   "1",
+			},
+			want: map[string]string{
+				"cicd.pipeline.name":          "monorepo",
+				"cicd.pipeline.run.id":        "0190-aa",
+				"cicd.pipeline.task.name":     "lint",
+				"vcs.repository.ref.name":     "main",
+				"vcs.repository.ref.revision": "abc123",
+				"buildkite.retry_count":       "1",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(name string) string { return tc.env[name] }
+			if diff := cmp.Diff(tc.want, DefaultConfig().ciAttributes(getenv)); diff != "" {
+				t.Errorf("attributes did not match (-want +got):\n%s", diff)
+			}
+			if got := DefaultConfig().WithCiDetect(tc.name).ciAttributes(getenv); len(got) == 0 {
+				t.Errorf("expected --ci-detect %s to detect it", tc.name)
+			}
+		})
+	}
+}
+
+func TestCiDetectErrors(t *testing.T) {
+	for in, want := range map[string]int{"auto": 0, "none": 0, "github, gitlab": 0, "travis,gitlab,drone": 2} {
+		if errs := ciDetectErrors(DefaultConfig().WithCiDetect(in)); len(errs) != want {
+			t.Errorf("expected %d errors for %q but got %q", want, in, errs)
+		}
+	}
+}
+
+func TestGetAttributesCi(t *testing.T) {
+	for name, value := range githubActionsEnv {
+		t.Setenv(name, value)
+	}
+	path := filepath.Join(t.TempDir(), "build-meta.json")
+	os.WriteFile(path, []byte(`{"github.actor": "from-file"}`), 0644)
+
+	c := DefaultConfig().
+		WithAttributesFile(path).
+		WithAttributes(map[string]string{"cicd.pipeline.task.name": "from-flag"})
+	got := map[string]*commonpb.AnyValue{}
+	for _, attr := range c.GetAttributes() {
+		got[attr.Key] = attr.Value
+	}
+
+	if got["cicd.pipeline.run.id"].GetStringValue() != "8675309" {
+		t.Errorf("expected the run id as a string but got %v", got["cicd.pipeline.run.id"])
+	}
+	if got["github.actor"].GetStringValue() != "from-file" || got["cicd.pipeline.task.name"].GetStringValue() != "from-flag" {
+		t.Error("expected the file and --attrs to win over CI attributes")
+	}
+}
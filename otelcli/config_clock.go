@@ -0,0 +1,224 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// clockSkewAttr is the span attribute the applied clock skew is recorded in.
+const clockSkewAttr = "otel-cli.clock_skew_ms"
+
+// ntpEpochOffset is the number of seconds from 1900, where NTP time starts,
+// to the Unix epoch.
+const ntpEpochOffset = 2208988800
+
+// GetClockSkew returns how far behind the reference clock chosen with
+// --clock-skew the local clock is, so a positive skew means the local clock
+// is slow. The reference is an NTP server, the Date header of the collector's
+// response to an empty export, or a fixed duration.
+func (c Config) GetClockSkew(ctx context.Context) (time.Duration, error) {
+	switch c.ClockSkew {
+	case "":
+		return 0, nil
+	case "ntp":
+		return c.ntpClockSkew(ctx)
+	case "collector":
+		return c.collectorClockSkew(ctx)
+	}
+
+	skew, err := parseDuration(c.ClockSkew)
+	if err != nil {
+		return 0, fmt.Errorf("--clock-skew must be ntp, collector, or a duration: %w", err)
+	}
+	return skew, nil
+}
+
+// correctClockSkew moves the start, end, and event times of the spans by the
+// clock skew and records it on them in otel-cli.clock_skew_ms. When the skew
+// can't be estimated, it's logged and the spans are left as they are, since
+// a span at the wrong time is still better than no span.
+func (c Config) correctClockSkew(ctx context.Context, spans ...*tracepb.Span) {
+	if c.ClockSkew == "" {
+		return
+	}
+
+	skew, err := c.GetClockSkew(ctx)
+	if err != nil {
+		c.SoftLogIfErr(fmt.Errorf("sending timestamps uncorrected, unable to estimate the clock skew: %w", err))
+		return
+	}
+	c.SoftLog("correcting timestamps for a clock skew of %s", skew)
+
+	for _, span := range spans {
+		span.StartTimeUnixNano = shiftUnixNano(span.StartTimeUnixNano, skew)
+		span.EndTimeUnixNano = shiftUnixNano(span.EndTimeUnixNano, skew)
+		for _, event := range span.Events {
+			event.TimeUnixNano = shiftUnixNano(event.TimeUnixNano, skew)
+		}
+		span.Attributes = append(span.Attributes, otlpclient.StringMapAttrsToProtobuf(map[string]string{
+			clockSkewAttr: strconv.FormatInt(skew.Milliseconds(), 10),
+		})...)
+	}
+}
+
+// shiftUnixNano returns the timestamp moved by d, leaving unset ones at zero.
+func shiftUnixNano(ts uint64, d time.Duration) uint64 {
+	if ts == 0 {
+		return 0
+	}
+	return uint64(int64(ts) + int64(d))
+}
+
+// ntpClockSkew asks --clock-skew-ntp-server for the time with a single SNTP
+// request and returns the usual NTP offset, which is accurate to within half
+// the round trip.
+// https://datatracker.ietf.org/doc/html/rfc4330
+func (c Config) ntpClockSkew(ctx context.Context) (time.Duration, error) {
+	server := c.ClockSkewNtpServer
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	dialer := net.Dialer{Timeout: c.GetConnectTimeout()}
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else if timeout := c.GetTimeout(); timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // no leap second warning, version 4, client mode
+	sent := time.Now()
+	// the server copies the transmit time into its originate time, which
+	// tells its response apart from a stray packet
+	binary.BigEndian.PutUint64(req[40:], toNtpTime(sent))
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	received := time.Now()
+	if err != nil {
+		return 0, fmt.Errorf("no response from NTP server %s: %w", server, err)
+	}
+	switch {
+	case n < 48:
+		return 0, fmt.Errorf("NTP server %s sent a short response of %d bytes", server, n)
+	case resp[0]&0x7 != 4:
+		return 0, fmt.Errorf("NTP server %s responded in mode %d instead of server mode", server, resp[0]&0x7)
+	case resp[1] == 0:
+		return 0, fmt.Errorf("NTP server %s refused the request with kiss code %q", server, resp[12:16])
+	case !bytes.Equal(resp[24:32], req[40:48]):
+		return 0, fmt.Errorf("NTP server %s responded to a different request", server)
+	}
+
+	serverReceived := fromNtpTime(binary.BigEndian.Uint64(resp[32:40]))
+	serverSent := fromNtpTime(binary.BigEndian.Uint64(resp[40:48]))
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil
+}
+
+// toNtpTime returns t as an NTP timestamp, seconds since 1900 in the upper
+// 32 bits and the fraction of a second in the lower.
+func toNtpTime(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// fromNtpTime returns the NTP timestamp as a time.Time.
+func fromNtpTime(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nanos := int64((ts & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// collectorClockSkew sends an empty export to the traces endpoint and
+// compares its response's Date header to the middle of the round trip. Date
+// only has whole seconds, so this is good for correcting clocks that are
+// seconds off, not for fine tuning. Only OTLP/HTTP endpoints have Date
+// headers to read.
+func (c Config) collectorClockSkew(ctx context.Context) (time.Duration, error) {
+	config, err := c.resolveSRV(ctx)
+	if err != nil {
+		return 0, err
+	}
+	endpointURL := config.GetEndpoint()
+	if config.probeProtocol(endpointURL) != "http" {
+		return 0, fmt.Errorf("the collector's clock can only be read from an OTLP/HTTP endpoint, not %s", endpointURL)
+	}
+
+	dialer := net.Dialer{Timeout: config.GetConnectTimeout()}
+	transport := &http.Transport{
+		DialContext:       dialer.DialContext,
+		TLSClientConfig:   config.GetTlsConfig(),
+		ForceAttemptHTTP2: true,
+	}
+	reqURL := endpointURL.String()
+	if endpointURL.Scheme == "unix" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", otlpclient.UnixSocketPath(endpointURL))
+		}
+		reqURL = "http://localhost/v1/traces"
+	}
+	client := http.Client{Transport: transport}
+
+	contentType, body := "application/x-protobuf", ""
+	if config.GetProtocol() == "http/json" {
+		contentType, body = "application/json", "{}"
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range config.GetHeaders() {
+		req.Header.Add(k, v)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", config.GetUserAgent())
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	sent := time.Now()
+	resp, err := client.Do(req)
+	received := time.Now()
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("the collector at %s didn't send a usable Date header: %w", endpointURL, err)
+	}
+	// Date is truncated to the second, so on average it's half a second behind
+	collectorTime := date.Add(500 * time.Millisecond)
+	return collectorTime.Sub(sent.Add(received.Sub(sent) / 2).Round(0)), nil
+}
+
+// WithClockSkew returns the config with ClockSkew set to the provided value.
+func (c Config) WithClockSkew(with string) Config {
+	c.ClockSkew = with
+	return c
+}
+
+// WithClockSkewNtpServer returns the config with ClockSkewNtpServer set to the provided value.
+func (c Config) WithClockSkewNtpServer(with string) Config {
+	c.ClockSkewNtpServer = with
+	return c
+}
@@ -0,0 +1,110 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestCorrectClockSkew(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.StartTimeUnixNano = uint64(time.Second * 10)
+	span.EndTimeUnixNano = uint64(time.Second * 12)
+	span.Events = []*tracepb.Span_Event{{Name: "started", TimeUnixNano: uint64(time.Second * 11)}}
+
+	DefaultConfig().WithClockSkew("-1500ms").correctClockSkew(context.Background(), span)
+
+	if span.StartTimeUnixNano != uint64(8500*time.Millisecond) || span.EndTimeUnixNano != uint64(10500*time.Millisecond) {
+		t.Errorf("expected the span to move back 1.5s but got %d to %d", span.StartTimeUnixNano, span.EndTimeUnixNano)
+	}
+	if span.Events[0].TimeUnixNano != uint64(9500*time.Millisecond) {
+		t.Errorf("expected the event to move back 1.5s but got %d", span.Events[0].TimeUnixNano)
+	}
+	if attrs := otlpclient.SpanAttributesToStringMap(span); attrs[clockSkewAttr] != "-1500" {
+		t.Errorf("expected %s to be -1500 but got %q", clockSkewAttr, attrs[clockSkewAttr])
+	}
+}
+
+func TestCorrectClockSkewOff(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.StartTimeUnixNano = 10
+	DefaultConfig().correctClockSkew(context.Background(), span)
+	if span.StartTimeUnixNano != 10 || len(span.Attributes) != 0 {
+		t.Errorf("expected the span to be left alone but got %v", span)
+	}
+}
+
+func TestGetClockSkewInvalid(t *testing.T) {
+	if _, err := DefaultConfig().WithClockSkew("sundial").GetClockSkew(context.Background()); err == nil {
+		t.Error("expected an error for an unknown --clock-skew")
+	}
+}
+
+func TestNtpTime(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	if got := fromNtpTime(toNtpTime(now)); got.Sub(now).Abs() > time.Microsecond {
+		t.Errorf("expected %s back from NTP time but got %s", now, got)
+	}
+}
+
+func TestNtpClockSkew(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer conn.Close()
+
+	// a server with a clock running an hour ahead
+	go func() {
+		req := make([]byte, 48)
+		_, addr, err := conn.ReadFrom(req)
+		if err != nil {
+			return
+		}
+		ahead := toNtpTime(time.Now().Add(time.Hour))
+		resp := make([]byte, 48)
+		resp[0] = 0x24 // version 4, server mode
+		resp[1] = 2
+		copy(resp[24:32], req[40:48])
+		binary.BigEndian.PutUint64(resp[32:], ahead)
+		binary.BigEndian.PutUint64(resp[40:], ahead)
+		conn.WriteTo(resp, addr)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	skew, err := DefaultConfig().WithClockSkew("ntp").WithClockSkewNtpServer(conn.LocalAddr().String()).GetClockSkew(ctx)
+	if err != nil {
+		t.Fatalf("failed to get the skew from NTP: %s", err)
+	}
+	if (skew - time.Hour).Abs() > 100*time.Millisecond {
+		t.Errorf("expected a skew of about an hour but got %s", skew)
+	}
+}
+
+func TestCollectorClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig().WithClockSkew("collector").WithEndpoint(server.URL)
+	skew, err := config.GetClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get the skew from the collector: %s", err)
+	}
+	if (skew + time.Minute).Abs() > time.Second {
+		t.Errorf("expected a skew of about a minute behind but got %s", skew)
+	}
+
+	if _, err := config.WithEndpoint("grpc://" + server.Listener.Addr().String()).GetClockSkew(context.Background()); err == nil {
+		t.Error("expected an error reading the clock of a gRPC endpoint")
+	}
+}
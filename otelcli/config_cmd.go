@@ -0,0 +1,422 @@
+package otelcli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/http/httpguts"
+)
+
+// redacted replaces secrets in printed configuration.
+const redacted = "--- redacted ---"
+
+// configLoadErrors holds the errors from loading the config file and
+// environment, which the config subcommands report instead of failing.
+var configLoadErrors []error
+
+// ConfigShowOutput is the output of otel-cli config show.
+type ConfigShowOutput struct {
+	ConfigFile  string   `json:"config_file"`
+	Endpoints   []string `json:"endpoints"`
+	IsRecording bool     `json:"is_recording"`
+	Config      Config   `json:"config"`
+}
+
+// configCmd represents the config command, for debugging configuration
+func configCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "config",
+		Short: "show and validate the effective configuration",
+		Long: `Configuration comes from flags, the config file, and environment variables.
+These subcommands show the result of merging them and check it for mistakes.
+
+Example:
+	otel-cli config show --profile staging
+	OTEL_EXPORTER_OTLP_ENDPOINT=localhost:4317 otel-cli config validate
+`,
+		// replaces the root command's, so broken config can be reported
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			configLoadErrors = loadConfig(getConfigRef(cmd.Context()), os.Getenv)
+		},
+	}
+
+	cmd.AddCommand(configShowCmd(config))
+	cmd.AddCommand(configValidateCmd(config))
+
+	return &cmd
+}
+
+// configShowCmd represents the config show command
+func configShowCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "show",
+		Short: "print the effective configuration as JSON",
+		Long: `Prints the configuration otel-cli would run with as JSON, along with the
+config file that was loaded and the endpoints traces would be sent to. Secrets
+and header values are masked. Takes the same flags as span and exec.`,
+		Run: doConfigShow,
+	}
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addSpanParams(&cmd, config)
+
+	return &cmd
+}
+
+// configValidateCmd represents the config validate command
+func configValidateCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "validate",
+		Short: "check the effective configuration for mistakes",
+		Long: `Checks the configuration otel-cli would run with: the config file and
+environment variables parse, endpoints have a supported scheme, headers are
+valid HTTP headers, certificate files can be read and parsed, and durations
+and enumerated settings have valid values. Prints each problem found and exits
+non-zero if there are any. Takes the same flags as span and exec.`,
+		Run: doConfigValidate,
+	}
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addSpanParams(&cmd, config)
+
+	return &cmd
+}
+
+func doConfigShow(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	// showing config that doesn't match what would run is worse than nothing
+	if len(configLoadErrors) > 0 {
+		for _, err := range configLoadErrors {
+			log.Print(err)
+		}
+		os.Exit(1)
+	}
+
+	out := ConfigShowOutput{
+		ConfigFile:  config.GetCfgFile(),
+		Endpoints:   []string{},
+		IsRecording: config.GetIsRecording(),
+		Config:      config.redactAll(),
+	}
+	for _, target := range config.GetTargetConfigs() {
+		endpoint := target.TracesEndpoint
+		if endpoint == "" {
+			endpoint = target.Endpoint
+		}
+		// ParseEndpoint exits on bad endpoints, so show those as they are
+		if validateEndpoint(endpoint) == nil {
+			endpoint = target.GetEndpoint().String()
+		}
+		out.Endpoints = append(out.Endpoints, endpoint)
+	}
+
+	js, err := json.MarshalIndent(out, "", "    ")
+	config.SoftFailIfErr(err)
+	os.Stdout.Write(js)
+	os.Stdout.WriteString("\n")
+}
+
+func doConfigValidate(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	errs := append(configLoadErrors, validateConfig(config)...)
+	if !writeValidateReport(os.Stdout, config, errs) {
+		os.Exit(1)
+	}
+}
+
+// writeValidateReport prints the problems found by config validate and
+// returns true if there weren't any.
+func writeValidateReport(w io.Writer, config Config, errs []error) bool {
+	if len(errs) > 0 {
+		fmt.Fprintf(w, "FAIL: %d problem(s) found\n", len(errs))
+		for _, err := range errs {
+			fmt.Fprintf(w, "  %s\n", err)
+		}
+		return false
+	}
+
+	if !config.GetIsRecording() {
+		fmt.Fprintln(w, "PASS: configuration is valid, but no endpoint is set so nothing will be sent")
+	} else {
+		fmt.Fprintln(w, "PASS: configuration is valid")
+	}
+	if file := config.GetCfgFile(); file != "" {
+		fmt.Fprintf(w, "  loaded config file %s\n", file)
+	}
+	return true
+}
+
+// validateConfig checks the config for mistakes that otherwise only show
+// up when sending fails, and returns an error for each one found.
+func validateConfig(config Config) []error {
+	errs := clientConfigErrors(config)
+
+	for _, setting := range []struct{ name, value string }{
+		{"endpoint", config.Endpoint},
+		{"traces_endpoint", config.TracesEndpoint},
+		{"metrics_endpoint", config.MetricsEndpoint},
+		{"logs_endpoint", config.LogsEndpoint},
+	} {
+		for _, ep := range splitEndpoints(setting.value) {
+			if err := validateEndpoint(ep); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", setting.name, err))
+			}
+		}
+	}
+	if config.Proxy != "" {
+		if u, err := url.Parse(config.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("proxy: %q must be a URL like http://proxy:3128 or socks5://localhost:1080", config.Proxy))
+		}
+	}
+
+	for _, setting := range []struct {
+		name    string
+		headers map[string]string
+	}{
+		{"otlp_headers", config.Headers},
+		{"traces_headers", config.TracesHeaders},
+		{"metrics_headers", config.MetricsHeaders},
+		{"logs_headers", config.LogsHeaders},
+		{"grpc_metadata", config.GrpcMetadata},
+	} {
+		errs = append(errs, validateHeaders(setting.name, setting.headers)...)
+	}
+	if config.AttributesFile != "" {
+		if _, err := loadAttributesFile(config.AttributesFile); err != nil {
+			errs = append(errs, fmt.Errorf("span_attributes_file: %w", err))
+		}
+	}
+	if config.OtlpHeadersFile != "" {
+		if data, err := os.ReadFile(config.OtlpHeadersFile); err != nil {
+			errs = append(errs, fmt.Errorf("otlp_headers_file: %w", err))
+		} else if headers, err := parseHeaderLines(string(data)); err != nil {
+			errs = append(errs, fmt.Errorf("otlp_headers_file: %s: %w", config.OtlpHeadersFile, err))
+		} else {
+			errs = append(errs, validateHeaders("otlp_headers_file", headers)...)
+		}
+	}
+
+	errs = append(errs, validateTlsFiles(
+		tlsSetting{"tls_ca_cert", config.TlsCACert},
+		tlsSetting{"tls_client_cert", config.TlsClientCert},
+		tlsSetting{"tls_client_key", config.TlsClientKey})...)
+	errs = append(errs, validateTlsFiles(
+		tlsSetting{"server_tls_client_ca", config.ServerTlsClientCA},
+		tlsSetting{"server_tls_cert", config.ServerTlsCert},
+		tlsSetting{"server_tls_key", config.ServerTlsKey})...)
+
+	for i, target := range config.Targets {
+		prefix := fmt.Sprintf("targets[%d].", i)
+		if target.Endpoint == "" {
+			errs = append(errs, fmt.Errorf("%sendpoint: every target needs an endpoint", prefix))
+		} else if err := validateEndpoint(target.Endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("%sendpoint: %w", prefix, err))
+		}
+		if !isValidProtocol(target.Protocol) {
+			errs = append(errs, fmt.Errorf("%sprotocol: invalid protocol setting %q", prefix, target.Protocol))
+		}
+		errs = append(errs, validateHeaders(prefix+"otlp_headers", target.Headers)...)
+		errs = append(errs, validateTlsFiles(
+			tlsSetting{prefix + "tls_ca_cert", target.TlsCACert},
+			tlsSetting{prefix + "tls_client_cert", target.TlsClientCert},
+			tlsSetting{prefix + "tls_client_key", target.TlsClientKey})...)
+	}
+
+	for _, setting := range []struct{ name, value string }{
+		{"timeout", config.Timeout},
+		{"connect_timeout", config.ConnectTimeout},
+		{"send_timeout", config.SendTimeout},
+		{"retry_initial_interval", config.RetryInitialInterval},
+		{"retry_max_interval", config.RetryMaxInterval},
+		{"exec_command_timeout", config.ExecCommandTimeout},
+	} {
+		if _, err := parseDuration(setting.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w, use e.g. 500ms, 5s, or 1m", setting.name, err))
+		}
+	}
+
+	if _, _, err := config.ParseSampler(); err != nil {
+		errs = append(errs, fmt.Errorf("sampler: %w", err))
+	}
+
+	return errs
+}
+
+// validateEndpoint returns an error if ParseSignalEndpoint would reject the
+// endpoint or misread it.
+func validateEndpoint(endpoint string) error {
+	scheme, rest, isUri := strings.Cut(endpoint, "://")
+	if !isUri {
+		// bare host or host:port are gRPC, so the port has to be a number
+		if strings.Contains(endpoint, ":") {
+			if _, port, err := net.SplitHostPort(endpoint); err != nil {
+				return fmt.Errorf("%q is not a valid host:port: %w", endpoint, err)
+			} else if _, err := net.LookupPort("tcp", port); err != nil {
+				return fmt.Errorf("%q has an invalid port, use host:port for gRPC or a URI like https://%s", endpoint, endpoint)
+			}
+		}
+		return nil
+	}
+
+	if !isEndpointScheme(scheme) {
+		return fmt.Errorf("%q has an unsupported scheme %q, use one of %s", endpoint, scheme, strings.Join(endpointSchemes, ", "))
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid URI: %w", endpoint, err)
+	}
+	if (scheme == "http" || scheme == "https" || scheme == "grpc") && u.Host == "" {
+		return fmt.Errorf("%q has no host, e.g. %s://localhost:4318", endpoint, scheme)
+	}
+	if rest == "" {
+		return fmt.Errorf("%q is missing everything after the scheme", endpoint)
+	}
+	return nil
+}
+
+// validateHeaders returns an error for each header that isn't a valid HTTP
+// header name and value.
+func validateHeaders(name string, headers map[string]string) []error {
+	errs := []error{}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !httpguts.ValidHeaderFieldName(k) {
+			errs = append(errs, fmt.Errorf("%s: %q is not a valid header name, headers are key=value pairs separated by commas", name, k))
+		} else if !httpguts.ValidHeaderFieldValue(headers[k]) {
+			errs = append(errs, fmt.Errorf("%s: the value of %q contains characters that aren't allowed in headers, e.g. newlines", name, k))
+		}
+	}
+	return errs
+}
+
+// tlsSetting is a TLS setting's name and value, for validateTlsFiles.
+type tlsSetting struct {
+	name  string
+	value string
+}
+
+// validateTlsFiles checks that the CA, certificate, and key can be read and
+// parsed, and that the certificate and key are set together.
+func validateTlsFiles(ca, cert, key tlsSetting) []error {
+	errs := []error{}
+
+	if ca.value != "" {
+		if data, err := readTlsData(ca.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ca.name, err))
+		} else if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			errs = append(errs, fmt.Errorf("%s: no PEM certificates found in %s", ca.name, tlsDataName(ca.value)))
+		}
+	}
+
+	if (cert.value == "") != (key.value == "") {
+		errs = append(errs, fmt.Errorf("%s and %s must be set together", cert.name, key.name))
+	} else if cert.value != "" {
+		certPEM, certErr := readTlsData(cert.value)
+		if certErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", cert.name, certErr))
+		}
+		keyPEM, keyErr := readTlsData(key.value)
+		if keyErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key.name, keyErr))
+		}
+		if certErr == nil && keyErr == nil {
+			if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+				errs = append(errs, fmt.Errorf("%s and %s: %w", cert.name, key.name, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// tlsDataName describes a TLS setting for errors without printing inline PEM.
+func tlsDataName(value string) string {
+	if isInlinePem(value) {
+		return "the inline PEM data"
+	}
+	return value
+}
+
+// redactSecrets returns the config with secrets masked for printing.
+func (c Config) redactSecrets() Config {
+	// inline private keys must never be printed
+	if isInlinePem(c.TlsClientKey) {
+		c.TlsClientKey = redacted
+	}
+	if isInlinePem(c.ServerTlsKey) {
+		c.ServerTlsKey = redacted
+	}
+	if c.OAuth2ClientSecret != "" {
+		c.OAuth2ClientSecret = redacted
+	}
+	if c.KafkaSASLPassword != "" {
+		c.KafkaSASLPassword = redacted
+	}
+	if c.GrafanaToken != "" {
+		c.GrafanaToken = redacted
+	}
+	if c.ServerAuthToken != "" {
+		c.ServerAuthToken = redacted
+	}
+	if c.ServerAuthBasic != "" {
+		c.ServerAuthBasic = redacted
+	}
+	return c
+}
+
+// redactAll returns the config with secrets, header values, and proxy and
+// Pushgateway passwords masked, since any header might carry a token.
+func (c Config) redactAll() Config {
+	c = c.redactSecrets()
+	c.Headers = redactHeaders(c.Headers)
+	c.TracesHeaders = redactHeaders(c.TracesHeaders)
+	c.MetricsHeaders = redactHeaders(c.MetricsHeaders)
+	c.LogsHeaders = redactHeaders(c.LogsHeaders)
+	c.GrpcMetadata = redactHeaders(c.GrpcMetadata)
+	if u, err := url.Parse(c.Proxy); err == nil && u.User != nil {
+		c.Proxy = u.Redacted()
+	}
+	if u, err := url.Parse(c.PushgatewayUrl); err == nil && u.User != nil {
+		c.PushgatewayUrl = u.Redacted()
+	}
+
+	targets := make([]TargetConfig, len(c.Targets))
+	for i, target := range c.Targets {
+		target.Headers = redactHeaders(target.Headers)
+		if isInlinePem(target.TlsClientKey) {
+			target.TlsClientKey = redacted
+		}
+		targets[i] = target
+	}
+	c.Targets = targets
+
+	return c
+}
+
+// redactHeaders returns a copy of the headers with the values masked.
+func redactHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for k := range headers {
+		out[k] = redacted
+	}
+	return out
+}
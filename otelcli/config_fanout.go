@@ -0,0 +1,124 @@
+package otelcli
+
+import (
+	"strings"
+)
+
+// TargetConfig holds the settings for one endpoint when fanning out spans
+// to multiple endpoints. Targets are only configurable in the JSON config
+// file. Empty values inherit from the top-level configuration.
+type TargetConfig struct {
+	Endpoint      string            `json:"endpoint"`
+	Protocol      string            `json:"protocol"`
+	Headers       map[string]string `json:"otlp_headers"`
+	Insecure      bool              `json:"insecure"`
+	TlsCACert     string            `json:"tls_ca_cert"`
+	TlsClientKey  string            `json:"tls_client_key"`
+	TlsClientCert string            `json:"tls_client_cert"`
+	TlsNoVerify   bool              `json:"tls_no_verify"`
+}
+
+// commaListValue is a pflag.Value for string flags that can be given more
+// than once, joining the values with commas. e.g. --endpoint a --endpoint b
+// is the same as --endpoint a,b.
+type commaListValue struct {
+	target *string
+	set    bool
+}
+
+// newCommaListValue sets target to the default and returns a flag value for it.
+func newCommaListValue(target *string, defaultValue string) *commaListValue {
+	*target = defaultValue
+	return &commaListValue{target: target}
+}
+
+func (v *commaListValue) Set(value string) error {
+	if v.set {
+		*v.target = *v.target + "," + value
+	} else {
+		*v.target = value
+		v.set = true
+	}
+	return nil
+}
+
+func (v *commaListValue) String() string {
+	if v.target == nil {
+		return ""
+	}
+	return *v.target
+}
+
+func (v *commaListValue) Type() string {
+	return "string"
+}
+
+// splitEndpoints splits a comma-separated endpoint list, dropping empties.
+func splitEndpoints(endpoints string) []string {
+	out := []string{}
+	for _, ep := range strings.Split(endpoints, ",") {
+		if ep = strings.TrimSpace(ep); ep != "" {
+			out = append(out, ep)
+		}
+	}
+	return out
+}
+
+// IsFanout returns true when spans should be sent to more than one endpoint,
+// either from a comma-separated/repeated --endpoint or from targets in the
+// config file.
+func (c Config) IsFanout() bool {
+	return len(c.Targets) > 0 || len(splitEndpoints(c.TracesEndpoint)) > 1 || len(splitEndpoints(c.Endpoint)) > 1
+}
+
+// GetTargetConfigs returns one Config per fan-out target, each with a single
+// endpoint and with its per-target settings applied over the top-level ones.
+func (c Config) GetTargetConfigs() []Config {
+	out := []Config{}
+
+	// the signal endpoint takes precedence, same as ParseEndpoint
+	if c.TracesEndpoint != "" {
+		for _, ep := range splitEndpoints(c.TracesEndpoint) {
+			out = append(out, c.withTarget(TargetConfig{}).WithEndpoint("").WithTracesEndpoint(ep))
+		}
+	} else {
+		for _, ep := range splitEndpoints(c.Endpoint) {
+			out = append(out, c.withTarget(TargetConfig{}).WithEndpoint(ep))
+		}
+	}
+
+	for _, target := range c.Targets {
+		out = append(out, c.withTarget(target).WithEndpoint(target.Endpoint).WithTracesEndpoint(""))
+	}
+
+	return out
+}
+
+// withTarget returns a copy of the config with the target's settings applied.
+func (c Config) withTarget(target TargetConfig) Config {
+	c.Targets = nil
+
+	if target.Protocol != "" {
+		c.Protocol = target.Protocol
+	}
+	if len(target.Headers) > 0 {
+		c.Headers = target.Headers
+	}
+	if target.Insecure {
+		c.Insecure = true
+	}
+	if target.TlsCACert != "" {
+		c.TlsCACert = target.TlsCACert
+	}
+	if target.TlsClientCert != "" {
+		c.TlsClientCert = target.TlsClientCert
+	}
+	if target.TlsClientKey != "" {
+		c.TlsClientKey = target.TlsClientKey
+	}
+	if target.TlsNoVerify {
+		c.TlsNoVerify = true
+	}
+
+	return c
+}
@@ -0,0 +1,179 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultCfgFile returns the path of the config file that's loaded when
+// --config isn't set, $XDG_CONFIG_HOME/otel-cli/config.yaml, which is
+// usually ~/.config/otel-cli/config.yaml. Returns "" if there's no home.
+func defaultCfgFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "otel-cli", "config.yaml")
+}
+
+// GetCfgFile returns the config file LoadFile reads, --config or the default
+// config file if it exists, or "" if there isn't one.
+func (c Config) GetCfgFile() string {
+	if c.CfgFile != "" {
+		return c.CfgFile
+	}
+	path := defaultCfgFile()
+	if _, err := os.Stat(path); path == "" || err != nil {
+		return ""
+	}
+	return path
+}
+
+// parseConfigFile parses a YAML or JSON config file into a copy of the
+// default config. Keys are the same as the json tags on Config. A profiles
+// key can hold named sets of keys, and the named profile, or the one in the
+// file's own profile key when name is empty, is applied over the top level.
+// e.g.
+//
+//	service_name: my-scripts
+//	profile: local
+//	profiles:
+//	  local:
+//	    endpoint: localhost:4317
+//	  staging:
+//	    endpoint: https://otlp.staging.example.com
+//	    otlp_headers:
+//	      x-api-key: abc123
+func parseConfigFile(data []byte, name string) (Config, error) {
+	config := DefaultConfig()
+
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return config, err
+	}
+	if len(doc.Content) == 0 {
+		return config, nil // empty file
+	}
+
+	profiles, err := decodeConfigNode(doc.Content[0], &config)
+	if err != nil {
+		return config, err
+	}
+
+	if name == "" {
+		name = config.Profile
+	}
+	if name == "" {
+		return config, nil
+	}
+	config.Profile = name
+
+	if profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			if profiles.Content[i].Value == name {
+				if _, err := decodeConfigNode(profiles.Content[i+1], &config); err != nil {
+					return config, fmt.Errorf("in profile %q: %w", name, err)
+				}
+				return config, nil
+			}
+		}
+	}
+
+	return config, fmt.Errorf("profile %q not found, available profiles: %s", name, strings.Join(configFileProfiles(profiles), ", "))
+}
+
+// decodeConfigNode decodes a mapping of config keys into config and returns
+// the profiles node if there is one.
+func decodeConfigNode(node *yaml.Node, config *Config) (*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of config keys", node.Line)
+	}
+
+	fields := configFileFields()
+	value := reflect.ValueOf(config).Elem()
+	var profiles *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		if key.Value == "profiles" {
+			profiles = val
+			continue
+		}
+
+		idx, ok := fields[key.Value]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown config key %q", key.Line, key.Value)
+		}
+
+		field := value.Field(idx)
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct {
+			// targets use their json tags, so round trip them through json
+			var v interface{}
+			if err := val.Decode(&v); err != nil {
+				return nil, fmt.Errorf("line %d: %q: %w", key.Line, key.Value, err)
+			}
+			js, _ := json.Marshal(v)
+			if err := json.Unmarshal(js, field.Addr().Interface()); err != nil {
+				return nil, fmt.Errorf("line %d: %q: %w", key.Line, key.Value, err)
+			}
+		} else if err := val.Decode(field.Addr().Interface()); err != nil {
+			return nil, fmt.Errorf("line %d: %q: %w", key.Line, key.Value, err)
+		}
+	}
+
+	return profiles, nil
+}
+
+// configFileFields maps config file keys to Config field indexes.
+func configFileFields() map[string]int {
+	fields := map[string]int{}
+	structType := reflect.TypeOf(Config{})
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.IsExported() && key != "" && key != "-" {
+			fields[key] = i
+		}
+	}
+	return fields
+}
+
+// configFileProfiles returns the sorted names of the profiles in a
+// profiles node.
+func configFileProfiles(profiles *yaml.Node) []string {
+	names := []string{}
+	if profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i < len(profiles.Content); i += 2 {
+			names = append(names, profiles.Content[i].Value)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fillUnset copies values from the config file into settings that are
+// still at their defaults, so command line flags win over the file.
+func (c *Config) fillUnset(file Config) {
+	defaults := reflect.ValueOf(DefaultConfig())
+	fileValue := reflect.ValueOf(file)
+	value := reflect.ValueOf(c).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if reflect.DeepEqual(field.Interface(), defaults.Field(i).Interface()) {
+			field.Set(fileValue.Field(i))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testConfigFile = `
+service_name: my-scripts
+timeout: 3s
+span_attributes:
+  team: infra
+  shard: 7
+profiles:
+  local:
+    endpoint: localhost:4317
+    insecure: true
+  staging:
+    endpoint: https://otlp.staging.example.com
+    tls_ca_cert: /etc/ssl/staging.pem
+    otlp_headers:
+      x-api-key: abc123
+    span_attributes:
+      env: staging
+`
+
+func TestParseConfigFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		profile string
+		want    Config
+	}{
+		{
+			name: "top level only",
+			data: testConfigFile,
+			want: DefaultConfig().
+				WithServiceName("my-scripts").
+				WithTimeout("3s").
+				WithAttributes(map[string]string{"team": "infra", "shard": "7"}),
+		},
+		{
+			name:    "profile merges over the top level",
+			data:    testConfigFile,
+			profile: "staging",
+			want: DefaultConfig().
+				WithServiceName("my-scripts").
+				WithTimeout("3s").
+				WithAttributes(map[string]string{"team": "infra", "shard": "7", "env": "staging"}).
+				WithEndpoint("https://otlp.staging.example.com").
+				WithTlsCACert("/etc/ssl/staging.pem").
+				WithHeaders(map[string]string{"x-api-key": "abc123"}).
+				WithProfile("staging"),
+		},
+		{
+			name: "default profile from the file",
+			data: "profile: local\n" + testConfigFile,
+			want: DefaultConfig().
+				WithServiceName("my-scripts").
+				WithTimeout("3s").
+				WithAttributes(map[string]string{"team": "infra", "shard": "7"}).
+				WithEndpoint("localhost:4317").
+				WithInsecure(true).
+				WithProfile("local"),
+		},
+		{
+			name: "json works too",
+			data: "{\n\t\"endpoint\": \"localhost:4317\",\n\t\"verbose\": true\n}\n",
+			want: DefaultConfig().WithEndpoint("localhost:4317").WithVerbose(true),
+		},
+		{
+			name: "empty file",
+			data: "",
+			want: DefaultConfig(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseConfigFile([]byte(tc.data), tc.profile)
+			if err != nil {
+				t.Fatalf("failed to parse config file: %s", err)
+			}
+			if diff := cmp.Diff(tc.want.ToStringMap(), got.ToStringMap()); diff != "" {
+				t.Errorf("config did not match (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		data    string
+		profile string
+		want    string
+	}{
+		{data: testConfigFile, profile: "prod", want: "available profiles: local, staging"},
+		{data: "endpiont: localhost:4317\n", want: `line 1: unknown config key "endpiont"`},
+		{data: "profiles:\n  dev:\n    verbose: sure\n", profile: "dev", want: `in profile "dev": line 3: "verbose"`},
+		{data: "- endpoint\n", want: "expected a mapping"},
+		{data: "endpoint: [\n", want: "yaml"},
+	} {
+		_, err := parseConfigFile([]byte(tc.data), tc.profile)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected an error containing %q parsing %q but got %v", tc.want, tc.data, err)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	// no default file is fine, unless a profile was asked for
+	c := DefaultConfig()
+	if err := c.LoadFile(); err != nil {
+		t.Errorf("expected a missing default config file to be ignored but got %s", err)
+	}
+	c = DefaultConfig().WithProfile("staging")
+	if err := c.LoadFile(); err == nil {
+		t.Error("expected --profile without a config file to fail")
+	}
+
+	os.Mkdir(filepath.Join(dir, "otel-cli"), 0755)
+	os.WriteFile(filepath.Join(dir, "otel-cli", "config.yaml"), []byte(testConfigFile), 0644)
+
+	// flags win over the file, and the file fills in everything else
+	c = DefaultConfig().WithProfile("staging").WithEndpoint("localhost:9999")
+	if err := c.LoadFile(); err != nil {
+		t.Fatalf("failed to load the default config file: %s", err)
+	}
+	if c.Endpoint != "localhost:9999" || c.ServiceName != "my-scripts" || c.Headers["x-api-key"] != "abc123" {
+		t.Errorf("expected the flag endpoint and the file's service name and headers but got %q, %q, %q", c.Endpoint, c.ServiceName, c.Headers)
+	}
+
+	// --config replaces the default file
+	other := filepath.Join(dir, "other.json")
+	os.WriteFile(other, []byte(`{"service_name": "other"}`), 0644)
+	c = DefaultConfig().WithCfgFile(other)
+	if err := c.LoadFile(); err != nil {
+		t.Fatalf("failed to load --config: %s", err)
+	}
+	if c.ServiceName != "other" || c.Timeout != DefaultConfig().Timeout {
+		t.Errorf("expected only the --config file to be loaded but got service %q and timeout %q", c.ServiceName, c.Timeout)
+	}
+}
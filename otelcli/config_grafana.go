@@ -0,0 +1,132 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// grafanaAnnotation is the body of Grafana's create annotation API.
+// https://grafana.com/docs/grafana/latest/developers/http_api/annotations/
+type grafanaAnnotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	TimeEnd      int64    `json:"timeEnd,omitempty"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// NewGrafanaAnnotation returns an annotation covering start to end with the
+// text, tagged with --grafana-tags and the trace id. When --grafana-trace-url
+// is set, the text links to the trace, with {trace_id} in the URL replaced.
+// traceId is left out when it's empty or all zeroes, e.g. when not recording.
+func (c Config) NewGrafanaAnnotation(text string, traceId []byte, start, end time.Time) grafanaAnnotation {
+	annotation := grafanaAnnotation{
+		DashboardUID: c.GrafanaDashboardUid,
+		Time:         start.UnixMilli(),
+		Tags:         []string{},
+		Text:         text,
+	}
+	if end.After(start) {
+		annotation.TimeEnd = end.UnixMilli()
+	}
+
+	for _, tag := range strings.Split(c.GrafanaTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			annotation.Tags = append(annotation.Tags, tag)
+		}
+	}
+
+	if len(traceId) > 0 && !bytes.Equal(traceId, make([]byte, len(traceId))) {
+		id := hex.EncodeToString(traceId)
+		annotation.Tags = append(annotation.Tags, "trace_id:"+id)
+		if c.GrafanaTraceUrl != "" {
+			link := strings.ReplaceAll(c.GrafanaTraceUrl, "{trace_id}", id)
+			annotation.Text += fmt.Sprintf("\n<a href=\"%s\">trace %s</a>", html.EscapeString(link), id)
+		}
+	}
+
+	return annotation
+}
+
+// PostGrafanaAnnotation creates the annotation with Grafana's API at
+// --grafana-url, authenticating with --grafana-token when it's set.
+func (c Config) PostGrafanaAnnotation(ctx context.Context, annotation grafanaAnnotation) error {
+	if c.GrafanaUrl == "" {
+		return fmt.Errorf("a Grafana URL is required, set --grafana-url or OTEL_CLI_GRAFANA_URL")
+	}
+
+	body, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.GetTimeout())
+	defer cancel()
+
+	url := strings.TrimSuffix(c.GrafanaUrl, "/") + "/api/annotations"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.GrafanaToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.GrafanaToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post Grafana annotation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to post Grafana annotation: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// WithGrafanaUrl returns the config with GrafanaUrl set to the provided value.
+func (c Config) WithGrafanaUrl(with string) Config {
+	c.GrafanaUrl = with
+	return c
+}
+
+// WithGrafanaToken returns the config with GrafanaToken set to the provided value.
+func (c Config) WithGrafanaToken(with string) Config {
+	c.GrafanaToken = with
+	return c
+}
+
+// WithGrafanaDashboardUid returns the config with GrafanaDashboardUid set to the provided value.
+func (c Config) WithGrafanaDashboardUid(with string) Config {
+	c.GrafanaDashboardUid = with
+	return c
+}
+
+// WithGrafanaTags returns the config with GrafanaTags set to the provided value.
+func (c Config) WithGrafanaTags(with string) Config {
+	c.GrafanaTags = with
+	return c
+}
+
+// WithGrafanaTraceUrl returns the config with GrafanaTraceUrl set to the provided value.
+func (c Config) WithGrafanaTraceUrl(with string) Config {
+	c.GrafanaTraceUrl = with
+	return c
+}
+
+// WithExecAnnotate returns the config with ExecAnnotate set to the provided value.
+func (c Config) WithExecAnnotate(with bool) Config {
+	c.ExecAnnotate = with
+	return c
+}
@@ -0,0 +1,81 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGrafanaAnnotation(t *testing.T) {
+	var got grafanaAnnotation
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/annotations" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":1,"message":"Annotation added"}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig().
+		WithGrafanaUrl(server.URL + "/").
+		WithGrafanaToken("glsa_abc").
+		WithGrafanaDashboardUid("deploys").
+		WithGrafanaTags("deploy, prod,").
+		WithGrafanaTraceUrl("https://grafana.example.com/trace/{trace_id}")
+
+	traceId := []byte{0xf6, 0x1f, 0xc5, 0x3f, 0x92, 0x6e, 0x07, 0xa9, 0xc3, 0x89, 0x3b, 0x1a, 0x72, 0x2e, 0x1b, 0x65}
+	start := time.UnixMilli(1700000000000)
+	annotation := config.NewGrafanaAnnotation("deploy v1.2", traceId, start, start.Add(90*time.Second))
+	if err := config.PostGrafanaAnnotation(context.Background(), annotation); err != nil {
+		t.Fatalf("failed to post the annotation: %s", err)
+	}
+
+	want := grafanaAnnotation{
+		DashboardUID: "deploys",
+		Time:         1700000000000,
+		TimeEnd:      1700000090000,
+		Tags:         []string{"deploy", "prod", "trace_id:f61fc53f926e07a9c3893b1a722e1b65"},
+		Text:         "deploy v1.2\n<a href=\"https://grafana.example.com/trace/f61fc53f926e07a9c3893b1a722e1b65\">trace f61fc53f926e07a9c3893b1a722e1b65</a>",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("annotation did not match (-want +got):\n%s", diff)
+	}
+	if gotAuth != "Bearer glsa_abc" {
+		t.Errorf("expected the token to be sent as a bearer token but got %q", gotAuth)
+	}
+
+	// quotes in the trace URL are escaped for HTML so they don't end the href
+	annotation = config.WithGrafanaTraceUrl(`https://grafana.example.com/explore?left={"queries":[{"query":"{trace_id}"}]}`).
+		NewGrafanaAnnotation("deploy v1.2", traceId, start, time.Time{})
+	wantLink := `<a href="https://grafana.example.com/explore?left={&#34;queries&#34;:[{&#34;query&#34;:&#34;f61fc53f926e07a9c3893b1a722e1b65&#34;}]}">`
+	if !strings.Contains(annotation.Text, wantLink) {
+		t.Errorf("expected the link to be HTML escaped but got %q", annotation.Text)
+	}
+
+	// without a trace there's nothing to link to, and points in time have no end
+	annotation = DefaultConfig().NewGrafanaAnnotation("deploy v1.2", make([]byte, 16), start, time.Time{})
+	want = grafanaAnnotation{Time: 1700000000000, Tags: []string{}, Text: "deploy v1.2"}
+	if diff := cmp.Diff(want, annotation); diff != "" {
+		t.Errorf("annotation without a trace did not match (-want +got):\n%s", diff)
+	}
+
+	if err := config.WithGrafanaUrl(server.URL+"/nope").PostGrafanaAnnotation(context.Background(), annotation); err == nil {
+		t.Error("expected an error when Grafana doesn't accept the annotation")
+	}
+	if err := DefaultConfig().PostGrafanaAnnotation(context.Background(), annotation); err == nil {
+		t.Error("expected an error without a Grafana URL")
+	}
+}
@@ -0,0 +1,139 @@
+package otelcli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GetDynamicHeaders reads OTLP headers from --otlp-headers-from-file and the
+// output of --otlp-header-cmd. Both are resolved every time a client is
+// started so secrets don't have to live in shell history or static envvars.
+// When both are set, the command's headers take precedence.
+func (c Config) GetDynamicHeaders(ctx context.Context) (map[string]string, error) {
+	headers := map[string]string{}
+
+	if c.OtlpHeadersFile != "" {
+		data, err := os.ReadFile(c.OtlpHeadersFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read headers file: %w", err)
+		}
+
+		fileHeaders, err := parseHeaderLines(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse headers file %q: %w", c.OtlpHeadersFile, err)
+		}
+		for k, v := range fileHeaders {
+			headers[k] = v
+		}
+	}
+
+	if c.OtlpHeaderCmd != "" {
+		ctx, cancel := context.WithTimeout(ctx, c.GetTimeout())
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.OtlpHeaderCmd)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("headers command failed: %w", err)
+		}
+
+		cmdHeaders, err := parseHeaderLines(string(out))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse headers command output: %w", err)
+		}
+		for k, v := range cmdHeaders {
+			headers[k] = v
+		}
+	}
+
+	return headers, nil
+}
+
+// parseHeaderLines parses key=value headers, one per line. Blank lines and
+// lines starting with # are skipped. Whitespace around keys and values is
+// trimmed and values may contain = signs.
+func parseHeaderLines(data string) (map[string]string, error) {
+	headers := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d is not in key=value format", lineNo)
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+
+	return headers, scanner.Err()
+}
+
+// withExtraHeaders returns a copy of the config with the provided headers
+// added to the OTLP headers, replacing any with the same key. The headers
+// map is copied so the caller's config isn't modified.
+func (c Config) withExtraHeaders(extra map[string]string) Config {
+	headers := make(map[string]string, len(c.Headers)+len(extra))
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+	c.Headers = headers
+	return c
+}
+
+// WithOtlpHeadersFile returns the config with OtlpHeadersFile set to the provided value.
+func (c Config) WithOtlpHeadersFile(with string) Config {
+	c.OtlpHeadersFile = with
+	return c
+}
+
+// WithOtlpHeaderCmd returns the config with OtlpHeaderCmd set to the provided value.
+func (c Config) WithOtlpHeaderCmd(with string) Config {
+	c.OtlpHeaderCmd = with
+	return c
+}
+
+// GetUserAgent returns the User-Agent to send with exports, otel-cli/<version>
+// unless --user-agent is set, so gateways can tell otel-cli traffic apart.
+func (c Config) GetUserAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+
+	// the version string can include the commit and date after the version
+	version := "unknown"
+	if fields := strings.Fields(c.Version); len(fields) > 0 {
+		version = fields[0]
+	}
+	return "otel-cli/" + version
+}
+
+// WithUserAgent returns the config with UserAgent set to the provided value.
+func (c Config) WithUserAgent(with string) Config {
+	c.UserAgent = with
+	return c
+}
+
+// GetGrpcMetadata returns the metadata to send with gRPC exports in addition
+// to the OTLP headers.
+func (c Config) GetGrpcMetadata() map[string]string {
+	return c.GrpcMetadata
+}
+
+// WithGrpcMetadata returns the config with GrpcMetadata set to the provided value.
+func (c Config) WithGrpcMetadata(with map[string]string) Config {
+	c.GrpcMetadata = with
+	return c
+}
@@ -0,0 +1,89 @@
+package otelcli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseHeaderLines(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		want   map[string]string
+		wantOk bool
+	}{
+		{in: "", want: map[string]string{}, wantOk: true},
+		{
+			in:     "# api key\nx-api-key = abc123\n\nAuthorization=Basic Zm9vOmJhcg==\n",
+			want:   map[string]string{"x-api-key": "abc123", "Authorization": "Basic Zm9vOmJhcg=="},
+			wantOk: true,
+		},
+		{in: "x-api-key abc123\n", wantOk: false},
+		{in: "=abc123\n", wantOk: false},
+	} {
+		got, err := parseHeaderLines(tc.in)
+		if tc.wantOk && err != nil {
+			t.Errorf("unexpected error parsing %q: %s", tc.in, err)
+		} else if !tc.wantOk && err == nil {
+			t.Errorf("expected an error parsing %q", tc.in)
+		} else if diff := cmp.Diff(tc.want, got); tc.wantOk && diff != "" {
+			t.Errorf("headers did not match (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestGetDynamicHeaders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "creds.txt")
+	if err := os.WriteFile(file, []byte("x-api-key=from-file\nx-tenant=acme\n"), 0600); err != nil {
+		t.Fatalf("failed to write headers file: %s", err)
+	}
+
+	config := DefaultConfig().
+		WithOtlpHeadersFile(file).
+		WithOtlpHeaderCmd("echo x-api-key=from-cmd")
+
+	got, err := config.GetDynamicHeaders(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get dynamic headers: %s", err)
+	}
+
+	want := map[string]string{"x-api-key": "from-cmd", "x-tenant": "acme"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("headers did not match (-want +got):\n%s", diff)
+	}
+
+	if _, err := DefaultConfig().WithOtlpHeaderCmd("exit 1").GetDynamicHeaders(context.Background()); err == nil {
+		t.Errorf("expected an error from a failing headers command")
+	}
+}
+
+func TestGetUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		config Config
+		want   string
+	}{
+		{config: DefaultConfig(), want: "otel-cli/unset"},
+		{config: DefaultConfig().WithVersion("0.5.0 abc123 2024-01-01"), want: "otel-cli/0.5.0"},
+		{config: DefaultConfig().WithVersion("0.5.0").WithUserAgent("nightly-backup/2.0"), want: "nightly-backup/2.0"},
+	} {
+		if got := tc.config.GetUserAgent(); got != tc.want {
+			t.Errorf("expected user agent %q but got %q", tc.want, got)
+		}
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	if DefaultConfig().WithUserAgent("test/1.0").UserAgent != "test/1.0" {
+		t.Fail()
+	}
+}
+
+func TestWithGrpcMetadata(t *testing.T) {
+	md := map[string]string{"x-tenant": "acme"}
+	if diff := cmp.Diff(md, DefaultConfig().WithGrpcMetadata(md).GrpcMetadata); diff != "" {
+		t.Errorf("grpc metadata did not match (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,69 @@
+package otelcli
+
+import (
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+)
+
+// GetKafkaConfig returns the Kafka settings for kafka:// and kafkas://
+// endpoints. The endpoint's host is the first broker, followed by any in
+// --kafka-brokers, and the path is the topic, defaulting to otlp_spans like
+// the collector's kafka receiver.
+func (c Config) GetKafkaConfig() otlpclient.KafkaConfig {
+	endpointURL := c.GetEndpoint()
+
+	brokers := []string{}
+	if endpointURL.Host != "" {
+		brokers = append(brokers, endpointURL.Host)
+	}
+	for _, broker := range strings.Split(c.KafkaBrokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	topic := strings.Trim(endpointURL.Path, "/")
+	if topic == "" {
+		topic = "otlp_spans"
+	}
+
+	return otlpclient.KafkaConfig{
+		Brokers:       brokers,
+		Topic:         topic,
+		Encoding:      c.KafkaEncoding,
+		SASLMechanism: c.KafkaSASLMechanism,
+		SASLUsername:  c.KafkaSASLUsername,
+		SASLPassword:  c.KafkaSASLPassword,
+	}
+}
+
+// WithKafkaBrokers returns the config with KafkaBrokers set to the provided value.
+func (c Config) WithKafkaBrokers(with string) Config {
+	c.KafkaBrokers = with
+	return c
+}
+
+// WithKafkaEncoding returns the config with KafkaEncoding set to the provided value.
+func (c Config) WithKafkaEncoding(with string) Config {
+	c.KafkaEncoding = with
+	return c
+}
+
+// WithKafkaSASLMechanism returns the config with KafkaSASLMechanism set to the provided value.
+func (c Config) WithKafkaSASLMechanism(with string) Config {
+	c.KafkaSASLMechanism = with
+	return c
+}
+
+// WithKafkaSASLUsername returns the config with KafkaSASLUsername set to the provided value.
+func (c Config) WithKafkaSASLUsername(with string) Config {
+	c.KafkaSASLUsername = with
+	return c
+}
+
+// WithKafkaSASLPassword returns the config with KafkaSASLPassword set to the provided value.
+func (c Config) WithKafkaSASLPassword(with string) Config {
+	c.KafkaSASLPassword = with
+	return c
+}
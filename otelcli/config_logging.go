@@ -0,0 +1,84 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+)
+
+// logLevels maps the --log-level settings to the levels otel-cli logs at.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Logger returns the logger for otel-cli's own diagnostics, which writes to
+// stderr. otel-cli stays silent by default, so without --log-level nothing is
+// logged, unless --verbose is set, which logs at info level.
+func (c Config) Logger() *slog.Logger {
+	return c.newLogger(os.Stderr)
+}
+
+// newLogger returns a logger writing to target in --log-format, logfmt or
+// json, at --log-level.
+func (c Config) newLogger(target io.Writer) *slog.Logger {
+	level, ok := logLevels[c.LogLevel]
+	if c.LogLevel == "" {
+		if !c.Verbose {
+			target = io.Discard
+		}
+		level = slog.LevelInfo
+	} else if !ok {
+		// clientConfigErrors reports the bad level, so it has to be seen
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(target, opts))
+	}
+	return slog.New(slog.NewTextHandler(target, opts))
+}
+
+// withWireDump returns ctx set up for the clients to dump every OTLP request
+// and response to where --debug-wire says, stderr or a file that's appended
+// to. When the file can't be opened, exports go ahead without the dump.
+func (c Config) withWireDump(ctx context.Context) context.Context {
+	switch c.DebugWire {
+	case "":
+		return ctx
+	case "stderr", "-":
+		return otlpclient.WithWireDump(ctx, os.Stderr)
+	}
+
+	file, err := os.OpenFile(c.DebugWire, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		c.SoftLogIfErr(fmt.Errorf("unable to open the --debug-wire file: %w", err))
+		return ctx
+	}
+	return otlpclient.WithWireDump(ctx, file)
+}
+
+// WithLogLevel returns the config with LogLevel set to the provided value.
+func (c Config) WithLogLevel(with string) Config {
+	c.LogLevel = with
+	return c
+}
+
+// WithLogFormat returns the config with LogFormat set to the provided value.
+func (c Config) WithLogFormat(with string) Config {
+	c.LogFormat = with
+	return c
+}
+
+// WithDebugWire returns the config with DebugWire set to the provided value.
+func (c Config) WithDebugWire(with string) Config {
+	c.DebugWire = with
+	return c
+}
@@ -0,0 +1,56 @@
+package otelcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	for _, tc := range []struct {
+		config Config
+		want   []string // the messages expected to be logged, in order
+	}{
+		// silent by default
+		{config: DefaultConfig(), want: []string{}},
+		// --verbose keeps logging everything it used to
+		{config: DefaultConfig().WithVerbose(true), want: []string{"info", "warn", "error"}},
+		{config: DefaultConfig().WithLogLevel("debug"), want: []string{"debug", "info", "warn", "error"}},
+		{config: DefaultConfig().WithLogLevel("warn"), want: []string{"warn", "error"}},
+		// --log-level wins over --verbose
+		{config: DefaultConfig().WithVerbose(true).WithLogLevel("error"), want: []string{"error"}},
+		// an invalid level still logs the error about it
+		{config: DefaultConfig().WithLogLevel("loud"), want: []string{"info", "warn", "error"}},
+	} {
+		buf := bytes.Buffer{}
+		logger := tc.config.WithLogFormat("json").newLogger(&buf)
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+
+		got := []string{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			record := map[string]string{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("log line %q isn't JSON: %s", line, err)
+			}
+			got = append(got, record["msg"])
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("expected %q to be logged at level %q but got %q", tc.want, tc.config.LogLevel, got)
+		}
+	}
+}
+
+func TestNewLoggerLogfmt(t *testing.T) {
+	buf := bytes.Buffer{}
+	DefaultConfig().WithLogLevel("info").newLogger(&buf).Info("sent span", "endpoint", "grpc://localhost:4317")
+	if got := buf.String(); !strings.Contains(got, ` level=INFO msg="sent span" endpoint=grpc://localhost:4317`) {
+		t.Errorf("expected a logfmt line but got %q", got)
+	}
+}
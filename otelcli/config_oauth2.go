@@ -0,0 +1,73 @@
+package otelcli
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+)
+
+// GetOAuth2Config returns the OAuth2 client credentials settings for
+// otlpclient.GetOAuth2Token. When no cache directory is set, tokens are
+// cached under the user's cache directory, e.g. ~/.cache/otel-cli. Tokens
+// are fetched through --proxy with the --tls-* settings, like exports.
+func (c Config) GetOAuth2Config() otlpclient.OAuth2Config {
+	scopes := strings.FieldsFunc(c.OAuth2Scopes, func(r rune) bool { return r == ',' || r == ' ' })
+
+	cacheDir := c.OAuth2CacheDir
+	if cacheDir == "" {
+		if userCache, err := os.UserCacheDir(); err == nil {
+			cacheDir = filepath.Join(userCache, "otel-cli")
+		}
+	}
+
+	// an invalid --proxy fails when the client starts, this falls back to
+	// the default transport until then
+	var transport http.RoundTripper
+	if t, err := otlpclient.NewHttpTransport(c); err == nil {
+		// the token endpoint uses TLS even when the OTLP endpoint doesn't
+		t.TLSClientConfig = c.GetTlsConfig()
+		transport = t
+	}
+
+	return otlpclient.OAuth2Config{
+		TokenURL:     c.OAuth2TokenURL,
+		ClientID:     c.OAuth2ClientID,
+		ClientSecret: c.OAuth2ClientSecret,
+		Scopes:       scopes,
+		CacheDir:     cacheDir,
+		Transport:    transport,
+	}
+}
+
+// WithOAuth2TokenURL returns the config with OAuth2TokenURL set to the provided value.
+func (c Config) WithOAuth2TokenURL(with string) Config {
+	c.OAuth2TokenURL = with
+	return c
+}
+
+// WithOAuth2ClientID returns the config with OAuth2ClientID set to the provided value.
+func (c Config) WithOAuth2ClientID(with string) Config {
+	c.OAuth2ClientID = with
+	return c
+}
+
+// WithOAuth2ClientSecret returns the config with OAuth2ClientSecret set to the provided value.
+func (c Config) WithOAuth2ClientSecret(with string) Config {
+	c.OAuth2ClientSecret = with
+	return c
+}
+
+// WithOAuth2Scopes returns the config with OAuth2Scopes set to the provided value.
+func (c Config) WithOAuth2Scopes(with string) Config {
+	c.OAuth2Scopes = with
+	return c
+}
+
+// WithOAuth2CacheDir returns the config with OAuth2CacheDir set to the provided value.
+func (c Config) WithOAuth2CacheDir(with string) Config {
+	c.OAuth2CacheDir = with
+	return c
+}
@@ -0,0 +1,114 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pushgatewayMetrics returns the metrics for a run in Prometheus' text
+// format. The last success timestamp is only included for successful runs,
+// so that pushing them with POST leaves the one from the last successful
+// run in place for alerting on jobs that haven't succeeded in a while.
+func pushgatewayMetrics(duration time.Duration, exitCode int, end time.Time) string {
+	var out strings.Builder
+	metric := func(name, help string, value float64) {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+
+	metric("job_duration_seconds", "How long the job's last run took.", duration.Seconds())
+	metric("job_exit_code", "The exit code of the job's last run.", float64(exitCode))
+	metric("job_last_run_timestamp_seconds", "When the job's last run finished.", float64(end.UnixMilli())/1000)
+	if exitCode == 0 {
+		metric("job_last_success_timestamp_seconds", "When the job last finished successfully.", float64(end.UnixMilli())/1000)
+	}
+
+	return out.String()
+}
+
+// GetPushgatewayUrl returns the URL for the job's metrics group with the
+// --pushgateway-labels as grouping labels, e.g. instance. The job defaults
+// to the provided name when --pushgateway-job isn't set.
+func (c Config) GetPushgatewayUrl(defaultJob string) string {
+	job := c.PushgatewayJob
+	if job == "" {
+		job = defaultJob
+	}
+
+	path := "/metrics/" + pushgatewayLabel("job", job)
+	names := []string{}
+	for name := range c.PushgatewayLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path += "/" + pushgatewayLabel(name, c.PushgatewayLabels[name])
+	}
+
+	return strings.TrimSuffix(c.PushgatewayUrl, "/") + path
+}
+
+// pushgatewayLabel returns the URL path segments for a grouping label.
+// Values that are empty or have a / in them are base64 encoded, which the
+// Pushgateway understands from the @base64 suffix on the name.
+func pushgatewayLabel(name, value string) string {
+	if value == "" || strings.Contains(value, "/") {
+		encoded := base64.URLEncoding.EncodeToString([]byte(value))
+		if encoded == "" {
+			encoded = "="
+		}
+		return name + "@base64/" + encoded
+	}
+	return name + "/" + url.PathEscape(value)
+}
+
+// PushJobMetrics pushes the metrics for a run to the Pushgateway at
+// --pushgateway-url. Credentials in the URL are sent with basic auth.
+func (c Config) PushJobMetrics(ctx context.Context, job string, duration time.Duration, exitCode int, end time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, c.GetTimeout())
+	defer cancel()
+
+	body := strings.NewReader(pushgatewayMetrics(duration, exitCode, end))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.GetPushgatewayUrl(job), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to the Pushgateway: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to push metrics to the Pushgateway: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// WithPushgatewayUrl returns the config with PushgatewayUrl set to the provided value.
+func (c Config) WithPushgatewayUrl(with string) Config {
+	c.PushgatewayUrl = with
+	return c
+}
+
+// WithPushgatewayJob returns the config with PushgatewayJob set to the provided value.
+func (c Config) WithPushgatewayJob(with string) Config {
+	c.PushgatewayJob = with
+	return c
+}
+
+// WithPushgatewayLabels returns the config with PushgatewayLabels set to the provided value.
+func (c Config) WithPushgatewayLabels(with map[string]string) Config {
+	c.PushgatewayLabels = with
+	return c
+}
@@ -0,0 +1,66 @@
+package otelcli
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushJobMetrics(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotPath, gotBody = r.Method, r.URL.EscapedPath(), string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig().
+		WithPushgatewayUrl(server.URL + "/").
+		WithPushgatewayLabels(map[string]string{"instance": "web1", "path": "/var/backups", "env": ""})
+
+	end := time.Unix(1700000000, 250000000)
+	if err := config.PushJobMetrics(context.Background(), "backup", 1500*time.Millisecond, 0, end); err != nil {
+		t.Fatalf("failed to push metrics: %s", err)
+	}
+
+	if gotMethod != http.MethodPost {
+		t.Errorf("expected metrics to be pushed with POST but got %s", gotMethod)
+	}
+	wantPath := "/metrics/job/backup/env@base64/=/instance/web1/path@base64/L3Zhci9iYWNrdXBz"
+	if gotPath != wantPath {
+		t.Errorf("expected path %q but got %q", wantPath, gotPath)
+	}
+	wantBody := `# HELP job_duration_seconds How long the job's last run took.
+# TYPE job_duration_seconds gauge
+job_duration_seconds 1.5
+# HELP job_exit_code The exit code of the job's last run.
+# TYPE job_exit_code gauge
+job_exit_code 0
+# HELP job_last_run_timestamp_seconds When the job's last run finished.
+# TYPE job_last_run_timestamp_seconds gauge
+job_last_run_timestamp_seconds 1700000000.25
+# HELP job_last_success_timestamp_seconds When the job last finished successfully.
+# TYPE job_last_success_timestamp_seconds gauge
+job_last_success_timestamp_seconds 1700000000.25
+`
+	if gotBody != wantBody {
+		t.Errorf("expected metrics:\n%s\nbut got:\n%s", wantBody, gotBody)
+	}
+
+	// failed runs leave the last success alone
+	config = config.WithPushgatewayJob("nightly backup").WithPushgatewayLabels(map[string]string{})
+	if err := config.PushJobMetrics(context.Background(), "backup", time.Second, 2, end); err != nil {
+		t.Fatalf("failed to push metrics: %s", err)
+	}
+	if gotPath != "/metrics/job/nightly%20backup" {
+		t.Errorf("expected --pushgateway-job to be used but got path %q", gotPath)
+	}
+	if !strings.Contains(gotBody, "\njob_exit_code 2\n") || strings.Contains(gotBody, "job_last_success_timestamp_seconds") {
+		t.Errorf("unexpected metrics for a failed run:\n%s", gotBody)
+	}
+}
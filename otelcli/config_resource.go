@@ -0,0 +1,107 @@
+package otelcli
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// loadResourceEnv merges the SDK's OTEL_RESOURCE_ATTRIBUTES and
+// OTEL_SERVICE_NAME into the resource settings. Unlike the envvars LoadEnv
+// handles, these are beneath flags and the config file, so an environment
+// set up for SDK apps can provide defaults that a command line overrides.
+// Per spec OTEL_SERVICE_NAME wins over service.name in the attributes.
+// https://opentelemetry.io/docs/specs/otel/configuration/sdk-environment-variables/#general-sdk-configuration
+func (c *Config) loadResourceEnv(getenv func(string) string) error {
+	envAttrs, err := parseResourceAttributes(getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return err
+	}
+
+	attrs := make(map[string]string, len(envAttrs)+len(c.ResourceAttributes))
+	for k, v := range envAttrs {
+		attrs[k] = v
+	}
+	if name := getenv("OTEL_SERVICE_NAME"); name != "" {
+		attrs["service.name"] = name
+	}
+	for k, v := range c.ResourceAttributes {
+		attrs[k] = v
+	}
+	c.ResourceAttributes = attrs
+
+	// --service is the usual way to set service.name, so only fall back
+	// to the attributes when it's been left at the default
+	if name, ok := attrs["service.name"]; ok && c.ServiceName == DefaultConfig().ServiceName {
+		c.ServiceName = name
+	}
+
+	return nil
+}
+
+// parseResourceAttributes parses OTEL_RESOURCE_ATTRIBUTES, comma-separated
+// key=value pairs with percent-encoded values.
+func parseResourceAttributes(in string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range strings.Split(in, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("could not parse OTEL_RESOURCE_ATTRIBUTES entry %q, must be key=value", pair)
+		}
+
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("could not decode OTEL_RESOURCE_ATTRIBUTES value for %q: %w", key, err)
+		}
+		out[key] = decoded
+	}
+
+	return out, nil
+}
+
+// GetResourceAttributes returns the attributes to put on the resource of
+// everything otel-cli sends, with --service-version and --environment
+// taking precedence over the same keys in --resource-attrs.
+// service.name comes from GetServiceName.
+func (c Config) GetResourceAttributes() map[string]string {
+	if c.ServiceVersion == "" && c.Environment == "" {
+		return c.ResourceAttributes
+	}
+
+	out := make(map[string]string, len(c.ResourceAttributes)+2)
+	for k, v := range c.ResourceAttributes {
+		out[k] = v
+	}
+	if c.ServiceVersion != "" {
+		out[string(semconv.ServiceVersionKey)] = c.ServiceVersion
+	}
+	if c.Environment != "" {
+		out[string(semconv.DeploymentEnvironmentKey)] = c.Environment
+	}
+	return out
+}
+
+// WithResourceAttributes returns the config with ResourceAttributes set to the provided value.
+func (c Config) WithResourceAttributes(with map[string]string) Config {
+	c.ResourceAttributes = with
+	return c
+}
+
+// WithServiceVersion returns the config with ServiceVersion set to the provided value.
+func (c Config) WithServiceVersion(with string) Config {
+	c.ServiceVersion = with
+	return c
+}
+
+// WithEnvironment returns the config with Environment set to the provided value.
+func (c Config) WithEnvironment(with string) Config {
+	c.Environment = with
+	return c
+}
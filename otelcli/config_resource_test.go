@@ -0,0 +1,82 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseResourceAttributes(t *testing.T) {
+	got, err := parseResourceAttributes("team=infra, build.url=https%3A%2F%2Fci.example.com%2F42,,empty=")
+	if err != nil {
+		t.Fatalf("failed to parse resource attributes: %s", err)
+	}
+	want := map[string]string{"team": "infra", "build.url": "https://ci.example.com/42", "empty": ""}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("resource attributes did not match (-want +got):\n%s", diff)
+	}
+
+	for _, in := range []string{"team", "=infra", "team=%zz"} {
+		if _, err := parseResourceAttributes(in); err == nil {
+			t.Errorf("expected %q to fail", in)
+		}
+	}
+}
+
+func TestLoadResourceEnv(t *testing.T) {
+	env := map[string]string{
+		"OTEL_RESOURCE_ATTRIBUTES": "service.name=from-attrs,team=infra,region=us-east-1",
+	}
+	getenv := func(name string) string { return env[name] }
+
+	c := DefaultConfig()
+	if err := c.LoadEnv(getenv); err != nil {
+		t.Fatalf("failed to load env: %s", err)
+	}
+	want := map[string]string{"service.name": "from-attrs", "team": "infra", "region": "us-east-1"}
+	if diff := cmp.Diff(want, c.ResourceAttributes); diff != "" {
+		t.Errorf("resource attributes did not match (-want +got):\n%s", diff)
+	}
+	if c.ServiceName != "from-attrs" {
+		t.Errorf("expected service.name from OTEL_RESOURCE_ATTRIBUTES but got %q", c.ServiceName)
+	}
+
+	// OTEL_SERVICE_NAME wins over the attributes, flags win over both
+	env["OTEL_SERVICE_NAME"] = "from-env"
+	c = DefaultConfig().WithResourceAttributes(map[string]string{"region": "eu-west-1"})
+	if err := c.LoadEnv(getenv); err != nil {
+		t.Fatalf("failed to load env: %s", err)
+	}
+	if c.ServiceName != "from-env" || c.ResourceAttributes["region"] != "eu-west-1" {
+		t.Errorf("expected OTEL_SERVICE_NAME and the flag to win but got %q and %q", c.ServiceName, c.ResourceAttributes)
+	}
+
+	c = DefaultConfig().WithServiceName("from-flag")
+	if err := c.LoadEnv(getenv); err != nil {
+		t.Fatalf("failed to load env: %s", err)
+	}
+	if c.ServiceName != "from-flag" {
+		t.Errorf("expected --service to win but got %q", c.ServiceName)
+	}
+
+	env["OTEL_RESOURCE_ATTRIBUTES"] = "oops"
+	c = DefaultConfig()
+	if err := c.LoadEnv(getenv); err == nil {
+		t.Error("expected a malformed OTEL_RESOURCE_ATTRIBUTES to fail")
+	}
+}
+
+func TestGetResourceAttributes(t *testing.T) {
+	c := DefaultConfig().
+		WithResourceAttributes(map[string]string{"team": "infra", "deployment.environment": "dev"}).
+		WithServiceVersion("1.2.3").
+		WithEnvironment("prod")
+
+	want := map[string]string{"team": "infra", "service.version": "1.2.3", "deployment.environment": "prod"}
+	if diff := cmp.Diff(want, c.GetResourceAttributes()); diff != "" {
+		t.Errorf("resource attributes did not match (-want +got):\n%s", diff)
+	}
+	if c.ResourceAttributes["deployment.environment"] != "dev" {
+		t.Error("GetResourceAttributes modified the config")
+	}
+}
@@ -0,0 +1,75 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// jsonResult is what span, exec, and status print with --json, so tools
+// wrapping otel-cli don't have to scrape its other output.
+type jsonResult struct {
+	TraceId     string `json:"trace_id"`
+	SpanId      string `json:"span_id"`
+	Traceparent string `json:"traceparent"`
+	Endpoint    string `json:"endpoint"`
+	// Status is one of sent, failed, not_sampled, or not_recording
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Retries  int    `json:"retries"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// newJsonResult returns the result for the span, which was sent with sendErr
+// as the error. When not recording, the ids are the ones passed through
+// from TRACEPARENT or --tp-carrier, the same as --tp-print.
+func (c Config) newJsonResult(ctx context.Context, span *tracepb.Span, sendErr error) jsonResult {
+	result := jsonResult{
+		Endpoint: Diag.Endpoint,
+		Status:   "sent",
+		Retries:  otlpclient.GetRetryCount(ctx),
+		ExitCode: Diag.ExecExitCode,
+	}
+
+	tp := c.LoadTraceparent()
+	if c.GetIsRecording() {
+		tp = otlpclient.TraceparentFromProtobufSpan(span, c.IsSampled(span))
+	}
+	if tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		result.TraceId = hex.EncodeToString(tp.TraceId)
+		result.SpanId = hex.EncodeToString(tp.SpanId)
+		result.Traceparent = tp.Encode()
+	}
+
+	switch {
+	case !c.GetIsRecording():
+		result.Status = "not_recording"
+		result.Endpoint = ""
+	case !c.IsSampled(span):
+		result.Status = "not_sampled"
+	case sendErr != nil:
+		result.Status = "failed"
+		result.Error = sendErr.Error()
+	}
+
+	return result
+}
+
+// PrintJsonResult writes the --json result for the span to target as one
+// line, so it can be picked out after a command's own output.
+func (c Config) PrintJsonResult(ctx context.Context, span *tracepb.Span, sendErr error, target io.Writer) {
+	js, err := json.Marshal(c.newJsonResult(ctx, span, sendErr))
+	c.SoftFailIfErr(err)
+	target.Write(append(js, '\n'))
+}
+
+// WithJson returns the config with Json set to the provided value.
+func (c Config) WithJson(with bool) Config {
+	c.Json = with
+	return c
+}
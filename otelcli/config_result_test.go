@@ -0,0 +1,51 @@
+package otelcli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewJsonResult(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = []byte{0xf6, 0xc1, 0x09, 0xf4, 0x81, 0x95, 0xb4, 0x51, 0xc4, 0xde, 0xf6, 0xab, 0x32, 0xf4, 0x7b, 0x61}
+	span.SpanId = []byte{0xa5, 0xd2, 0xa3, 0x5f, 0x24, 0x83, 0x00, 0x4e}
+
+	Diag.Endpoint = "grpc://localhost:4317"
+	Diag.ExecExitCode = 3
+	defer func() { Diag.Endpoint, Diag.ExecExitCode = "", 0 }()
+
+	config := DefaultConfig().WithEndpoint("localhost:4317").WithTraceparentIgnoreEnv(true)
+	got := config.newJsonResult(context.Background(), span, errors.New("connection refused"))
+	want := jsonResult{
+		TraceId:     "f6c109f48195b451c4def6ab32f47b61",
+		SpanId:      "a5d2a35f2483004e",
+		Traceparent: "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01",
+		Endpoint:    "grpc://localhost:4317",
+		Status:      "failed",
+		Error:       "connection refused",
+		ExitCode:    3,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	got = config.newJsonResult(context.Background(), span, nil)
+	if got.Status != "sent" || got.Error != "" {
+		t.Errorf("expected a sent result but got %+v", got)
+	}
+
+	got = config.WithSampler("always_off").newJsonResult(context.Background(), span, nil)
+	if got.Status != "not_sampled" || got.Traceparent != "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00" {
+		t.Errorf("expected an unsampled result but got %+v", got)
+	}
+
+	// without an endpoint or a traceparent there's nothing to report
+	got = DefaultConfig().WithTraceparentIgnoreEnv(true).newJsonResult(context.Background(), span, nil)
+	if got.Status != "not_recording" || got.TraceId != "" || got.Endpoint != "" {
+		t.Errorf("expected an empty non-recording result but got %+v", got)
+	}
+}
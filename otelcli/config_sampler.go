@@ -0,0 +1,103 @@
+package otelcli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// ParseSampler splits the sampler setting into the sampler name and its ratio.
+// --sampler accepts name=arg so it can be set with one flag, otherwise the
+// argument comes from OTEL_TRACES_SAMPLER_ARG. Sampler names follow the OTel
+// spec and an empty sampler is always_on, matching otel-cli's past behavior.
+// https://opentelemetry.io/docs/specs/otel/configuration/sdk-environment-variables/#general-sdk-configuration
+func (c Config) ParseSampler() (string, float64, error) {
+	name, arg, hasArg := strings.Cut(c.Sampler, "=")
+	if !hasArg {
+		arg = c.SamplerArg
+	}
+
+	switch name {
+	case "", "always_on", "always_off", "parentbased_always_on", "parentbased_always_off":
+		if name == "" {
+			name = "always_on"
+		}
+		return name, 1.0, nil
+	case "traceidratio", "parentbased_traceidratio":
+		if arg == "" {
+			return name, 1.0, nil
+		}
+		ratio, err := strconv.ParseFloat(arg, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return name, 0, fmt.Errorf("invalid sampler ratio %q, must be a number from 0 to 1", arg)
+		}
+		return name, ratio, nil
+	default:
+		return name, 0, fmt.Errorf("invalid sampler %q", name)
+	}
+}
+
+// IsSampled returns whether the span should be exported and marked sampled in
+// the propagated traceparent. Ratio sampling is decided from the trace id the
+// same way the OTel SDKs do it, so every otel-cli invocation in a trace agrees
+// with the others. Parent-based samplers follow the sampled flag of the
+// traceparent when there is one.
+func (c Config) IsSampled(span *tracepb.Span) bool {
+	if !c.GetIsRecording() {
+		return false
+	}
+
+	name, ratio, err := c.ParseSampler()
+	if err != nil {
+		c.SoftFail(err.Error())
+		return false
+	}
+
+	if strings.HasPrefix(name, "parentbased_") {
+		// LoadTraceparent returns an all-zero traceparent when there is none
+		if tp := c.LoadTraceparent(); tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+			return tp.Sampling
+		}
+		name = strings.TrimPrefix(name, "parentbased_")
+	}
+
+	switch name {
+	case "always_off":
+		return false
+	case "traceidratio":
+		return traceIdRatioSampled(span.TraceId, ratio)
+	default:
+		return true
+	}
+}
+
+// traceIdRatioSampled returns true when the trace id falls within the ratio.
+// This is the same algorithm as the Go SDK's TraceIDRatioBased sampler, using
+// the low 63 bits of the trace id's second half.
+func traceIdRatioSampled(traceId []byte, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	} else if len(traceId) != 16 {
+		return false
+	}
+
+	upperBound := uint64(ratio * (1 << 63))
+	return binary.BigEndian.Uint64(traceId[8:16])>>1 < upperBound
+}
+
+// WithSampler returns the config with Sampler set to the provided value.
+func (c Config) WithSampler(with string) Config {
+	c.Sampler = with
+	return c
+}
+
+// WithSamplerArg returns the config with SamplerArg set to the provided value.
+func (c Config) WithSamplerArg(with string) Config {
+	c.SamplerArg = with
+	return c
+}
@@ -0,0 +1,92 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+)
+
+func TestParseSampler(t *testing.T) {
+	for _, tc := range []struct {
+		config    Config
+		wantName  string
+		wantRatio float64
+		wantErr   bool
+	}{
+		{config: DefaultConfig(), wantName: "always_on", wantRatio: 1.0},
+		{config: DefaultConfig().WithSampler("always_off"), wantName: "always_off", wantRatio: 1.0},
+		{config: DefaultConfig().WithSampler("traceidratio=0.05"), wantName: "traceidratio", wantRatio: 0.05},
+		{config: DefaultConfig().WithSampler("traceidratio"), wantName: "traceidratio", wantRatio: 1.0},
+		// OTEL_TRACES_SAMPLER + OTEL_TRACES_SAMPLER_ARG
+		{config: DefaultConfig().WithSampler("parentbased_traceidratio").WithSamplerArg("0.25"), wantName: "parentbased_traceidratio", wantRatio: 0.25},
+		{config: DefaultConfig().WithSampler("traceidratio=1.5"), wantErr: true},
+		{config: DefaultConfig().WithSampler("traceidratio=lots"), wantErr: true},
+		{config: DefaultConfig().WithSampler("jaeger_remote"), wantErr: true},
+	} {
+		name, ratio, err := tc.config.ParseSampler()
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("expected an error parsing sampler %q", tc.config.Sampler)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing sampler %q: %s", tc.config.Sampler, err)
+		} else if name != tc.wantName || ratio != tc.wantRatio {
+			t.Errorf("expected %s/%f but got %s/%f", tc.wantName, tc.wantRatio, name, ratio)
+		}
+	}
+}
+
+func TestTraceIdRatioSampled(t *testing.T) {
+	var sampled int
+	for i := 0; i < 10000; i++ {
+		traceId := otlpclient.GenerateTraceId()
+		got := traceIdRatioSampled(traceId, 0.1)
+		// the decision must be the same every time for a trace id
+		if got != traceIdRatioSampled(traceId, 0.1) {
+			t.Fatalf("sampling decision for %x is not consistent", traceId)
+		}
+		if got {
+			sampled++
+		}
+	}
+
+	// 10% of 10000 with plenty of slack so this never flakes
+	if sampled < 700 || sampled > 1300 {
+		t.Errorf("expected about 1000 of 10000 trace ids to be sampled but got %d", sampled)
+	}
+
+	if traceIdRatioSampled(otlpclient.GenerateTraceId(), 0) {
+		t.Errorf("expected ratio 0 to never sample")
+	}
+}
+
+func TestIsSampled(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = otlpclient.GenerateTraceId()
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+
+	if !config.IsSampled(span) {
+		t.Errorf("expected spans to be sampled by default")
+	}
+	if config.WithSampler("always_off").IsSampled(span) {
+		t.Errorf("expected always_off to not sample")
+	}
+	if DefaultConfig().IsSampled(span) {
+		t.Errorf("expected non-recording config to not sample")
+	}
+
+	// parent-based samplers follow the traceparent's sampled flag
+	t.Setenv("TRACEPARENT", "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00")
+	if config.WithSampler("parentbased_always_on").IsSampled(span) {
+		t.Errorf("expected parentbased_always_on to follow an unsampled parent")
+	}
+	t.Setenv("TRACEPARENT", "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01")
+	if !config.WithSampler("parentbased_always_off").IsSampled(span) {
+		t.Errorf("expected parentbased_always_off to follow a sampled parent")
+	}
+	if !config.WithSampler("parentbased_always_on").WithTraceparentIgnoreEnv(true).IsSampled(span) {
+		t.Errorf("expected parentbased_always_on to sample a root span")
+	}
+}
@@ -0,0 +1,119 @@
+package otelcli
+
+import (
+	"net/url"
+)
+
+// getSignal returns the signal the config sends, traces unless the config
+// came from withSignal.
+func (c Config) getSignal() string {
+	if c.signal == "" {
+		return "traces"
+	}
+	return c.signal
+}
+
+// withSignal returns a copy of the config for sending the signal, so
+// GetEndpoint and GetHeaders return the settings for that signal.
+func (c Config) withSignal(signal string) Config {
+	c.signal = signal
+	return c
+}
+
+// withSignalEndpoint returns a copy of the config with the signal-specific
+// endpoint setting for the signal set to endpoint.
+func (c Config) withSignalEndpoint(signal, endpoint string) Config {
+	switch signal {
+	case "traces":
+		c.TracesEndpoint = endpoint
+	case "metrics":
+		c.MetricsEndpoint = endpoint
+	case "logs":
+		c.LogsEndpoint = endpoint
+	default:
+		c.SoftFail("BUG: unknown signal %q, please report an issue", signal)
+	}
+	return c
+}
+
+// getSignalEndpoint returns the raw signal-specific endpoint setting for
+// traces, metrics, or logs. Returns an empty string when it isn't set so
+// callers fall back to the general endpoint.
+func (c Config) getSignalEndpoint(signal string) string {
+	switch signal {
+	case "traces":
+		return c.TracesEndpoint
+	case "metrics":
+		return c.MetricsEndpoint
+	case "logs":
+		return c.LogsEndpoint
+	default:
+		c.SoftFail("BUG: unknown signal %q, please report an issue", signal)
+		return ""
+	}
+}
+
+// GetSignalEndpoint returns the parsed endpoint URL for the signal, using the
+// signal-specific endpoint if set and the general endpoint otherwise.
+func (c Config) GetSignalEndpoint(signal string) *url.URL {
+	ep, _ := c.ParseSignalEndpoint(signal)
+	return ep
+}
+
+// GetSignalHeaders returns the general OTLP headers merged with the headers
+// for the signal, with the signal-specific values taking precedence.
+func (c Config) GetSignalHeaders(signal string) map[string]string {
+	var signalHeaders map[string]string
+	switch signal {
+	case "traces":
+		signalHeaders = c.TracesHeaders
+	case "metrics":
+		signalHeaders = c.MetricsHeaders
+	case "logs":
+		signalHeaders = c.LogsHeaders
+	}
+
+	if len(signalHeaders) == 0 {
+		return c.Headers
+	}
+
+	out := make(map[string]string, len(c.Headers)+len(signalHeaders))
+	for k, v := range c.Headers {
+		out[k] = v
+	}
+	for k, v := range signalHeaders {
+		out[k] = v
+	}
+
+	return out
+}
+
+// WithMetricsEndpoint returns the config with MetricsEndpoint set to the provided value.
+func (c Config) WithMetricsEndpoint(with string) Config {
+	c.MetricsEndpoint = with
+	return c
+}
+
+// WithLogsEndpoint returns the config with LogsEndpoint set to the provided value.
+func (c Config) WithLogsEndpoint(with string) Config {
+	c.LogsEndpoint = with
+	return c
+}
+
+// WithTracesHeaders returns the config with TracesHeaders set to the provided value.
+func (c Config) WithTracesHeaders(with map[string]string) Config {
+	c.TracesHeaders = with
+	return c
+}
+
+// WithMetricsHeaders returns the config with MetricsHeaders set to the provided value.
+func (c Config) WithMetricsHeaders(with map[string]string) Config {
+	c.MetricsHeaders = with
+	return c
+}
+
+// WithLogsHeaders returns the config with LogsHeaders set to the provided value.
+func (c Config) WithLogsHeaders(with map[string]string) Config {
+	c.LogsHeaders = with
+	return c
+}
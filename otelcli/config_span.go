@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"github.com/equinix-labs/otel-cli/otlpclient"
@@ -21,7 +22,7 @@ func (c Config) NewProtobufSpan() *tracepb.Span {
 	}
 	span.Name = c.SpanName
 	span.Kind = otlpclient.SpanKindStringToInt(c.Kind)
-	span.Attributes = otlpclient.StringMapAttrsToProtobuf(c.Attributes)
+	span.Attributes = c.GetAttributes()
 
 	now := time.Now()
 	if c.SpanStartTime != "" {
@@ -43,6 +44,7 @@ func (c Config) NewProtobufSpan() *tracepb.Span {
 		if tp.Initialized {
 			span.TraceId = tp.TraceId
 			span.ParentSpanId = tp.SpanId
+			span.TraceState = c.LoadCarrier().Tracestate
 		}
 	} else {
 		span.TraceId = otlpclient.GetEmptyTraceId()
@@ -111,27 +113,94 @@ func (c Config) LoadTraceparent() traceparent.Traceparent {
 	return tp
 }
 
+// LoadCarrier returns the W3C tracestate and baggage that came along with the
+// traceparent, as a Carrier. Following the same rules as LoadTraceparent, the
+// TRACESTATE and BAGGAGE envvars are loaded first, then a JSON carrier file
+// will override them. Plain text carrier files have neither.
+func (c Config) LoadCarrier() traceparent.Carrier {
+	carrier := traceparent.Carrier{Version: traceparent.CarrierVersion}
+
+	if !c.TraceparentIgnoreEnv {
+		carrier.Tracestate = os.Getenv("TRACESTATE")
+		carrier.Baggage = os.Getenv("BAGGAGE")
+	}
+
+	if c.TraceparentCarrierFile != "" {
+		fileCarrier, err := traceparent.LoadCarrierFromFile(c.TraceparentCarrierFile)
+		if err != nil {
+			Diag.Error = err.Error()
+		} else if fileCarrier.Traceparent != "" {
+			carrier.Tracestate = fileCarrier.Tracestate
+			carrier.Baggage = fileCarrier.Baggage
+		}
+	}
+
+	return carrier
+}
+
 // PropagateTraceparent saves the traceparent to file if necessary, then prints
 // span info to the console according to command-line args.
 func (c Config) PropagateTraceparent(span *tracepb.Span, target io.Writer) {
 	var tp traceparent.Traceparent
 	if c.GetIsRecording() {
-		tp = otlpclient.TraceparentFromProtobufSpan(span, c.GetIsRecording())
+		tp = otlpclient.TraceparentFromProtobufSpan(span, c.IsSampled(span))
 	} else {
 		// when in non-recording mode, and there is a TP available, propagate that
 		tp = c.LoadTraceparent()
 	}
 
 	if c.TraceparentCarrierFile != "" {
-		err := tp.SaveToFile(c.TraceparentCarrierFile, c.TraceparentPrintExport)
-		c.SoftFailIfErr(err)
+		switch c.TraceparentCarrierFormat {
+		case "", "text":
+			err := tp.SaveToFile(c.TraceparentCarrierFile, c.TraceparentPrintExport)
+			c.SoftFailIfErr(err)
+		case "json":
+			carrier := c.NewCarrier(span, tp)
+			err := carrier.SaveToFile(c.TraceparentCarrierFile)
+			c.SoftFailIfErr(err)
+		default:
+			c.SoftFail("invalid --tp-carrier-format %q, must be one of text or json", c.TraceparentCarrierFormat)
+		}
 	}
 
-	if c.TraceparentPrint {
-		tp.Fprint(target, c.TraceparentPrintExport)
+	// --json prints the traceparent in its result instead
+	if c.TraceparentPrint && !c.Json {
+		c.PrintTraceparent(tp, target)
 	}
 }
 
+// PrintTraceparent writes the traceparent to target in the format set by
+// --tp-print-format.
+func (c Config) PrintTraceparent(tp traceparent.Traceparent, target io.Writer) {
+	err := tp.FprintFormat(target, c.TraceparentPrintFormat, c.TraceparentPrintExport)
+	c.SoftFailIfErr(err)
+}
+
+// NewCarrier builds a JSON carrier for the provided span and traceparent,
+// carrying through any tracestate and baggage that were loaded. Span metadata
+// is only included in recording mode, since otherwise the span isn't real.
+func (c Config) NewCarrier(span *tracepb.Span, tp traceparent.Traceparent) traceparent.Carrier {
+	carrier := c.LoadCarrier()
+	carrier.Traceparent = tp.Encode()
+	if span.TraceState != "" {
+		carrier.Tracestate = span.TraceState
+	}
+
+	if c.GetIsRecording() {
+		carrier.Span = &traceparent.CarrierSpan{
+			TraceId:     hex.EncodeToString(span.TraceId),
+			SpanId:      hex.EncodeToString(span.SpanId),
+			Name:        span.Name,
+			ServiceName: c.ServiceName,
+			Kind:        otlpclient.SpanKindIntToString(span.Kind),
+			Start:       span.StartTimeUnixNano,
+			End:         span.EndTimeUnixNano,
+		}
+	}
+
+	return carrier
+}
+
 // parseHex parses hex into a []byte of length provided. Errors if the input is
 // not valid hex or the converted hex is not the right number of bytes.
 func parseHex(in string, expectedLen int) ([]byte, error) {
@@ -144,3 +213,37 @@ func parseHex(in string, expectedLen int) ([]byte, error) {
 	}
 	return out, nil
 }
+
+// GetSpanLimits returns the span limits to apply before export.
+func (c Config) GetSpanLimits() otlpclient.SpanLimits {
+	return otlpclient.SpanLimits{
+		AttributeValueLengthLimit: c.SpanAttributeValueLengthLimit,
+		AttributeCountLimit:       c.SpanAttributeCountLimit,
+		EventCountLimit:           c.SpanEventCountLimit,
+		LinkCountLimit:            c.SpanLinkCountLimit,
+	}
+}
+
+// WithSpanAttributeValueLengthLimit returns the config with SpanAttributeValueLengthLimit set to the provided value.
+func (c Config) WithSpanAttributeValueLengthLimit(with int) Config {
+	c.SpanAttributeValueLengthLimit = with
+	return c
+}
+
+// WithSpanAttributeCountLimit returns the config with SpanAttributeCountLimit set to the provided value.
+func (c Config) WithSpanAttributeCountLimit(with int) Config {
+	c.SpanAttributeCountLimit = with
+	return c
+}
+
+// WithSpanEventCountLimit returns the config with SpanEventCountLimit set to the provided value.
+func (c Config) WithSpanEventCountLimit(with int) Config {
+	c.SpanEventCountLimit = with
+	return c
+}
+
+// WithSpanLinkCountLimit returns the config with SpanLinkCountLimit set to the provided value.
+func (c Config) WithSpanLinkCountLimit(with int) Config {
+	c.SpanLinkCountLimit = with
+	return c
+}
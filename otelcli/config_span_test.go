@@ -5,9 +5,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
 )
 
 func TestPropagateTraceparent(t *testing.T) {
@@ -52,3 +54,36 @@ func TestNewProtobufSpanWithConfig(t *testing.T) {
 		t.Error("span event attributes must not be nil")
 	}
 }
+
+func TestPropagateTraceparentJsonCarrier(t *testing.T) {
+	carrierFile := filepath.Join(t.TempDir(), "carrier.json")
+	config := DefaultConfig().
+		WithEndpoint("localhost:4317").
+		WithServiceName("test-service").
+		WithTraceparentIgnoreEnv(true).
+		WithTraceparentCarrierFile(carrierFile).
+		WithTraceparentCarrierFormat("json")
+
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId, _ = hex.DecodeString("3433d5ae39bdfee397f44be5146867b3")
+	span.SpanId, _ = hex.DecodeString("8a5518f1e5c54d0a")
+	span.Name = "test span"
+	span.TraceState = "vendor=xyz"
+
+	config.PropagateTraceparent(span, new(bytes.Buffer))
+
+	carrier, err := traceparent.LoadCarrierFromFile(carrierFile)
+	if err != nil {
+		t.Fatalf("failed to load carrier file: %s", err)
+	}
+
+	if carrier.Traceparent != "00-3433d5ae39bdfee397f44be5146867b3-8a5518f1e5c54d0a-01" {
+		t.Errorf("unexpected traceparent in carrier: %q", carrier.Traceparent)
+	}
+	if carrier.Tracestate != "vendor=xyz" {
+		t.Errorf("unexpected tracestate in carrier: %q", carrier.Tracestate)
+	}
+	if carrier.Span == nil || carrier.Span.Name != "test span" || carrier.Span.ServiceName != "test-service" {
+		t.Errorf("unexpected span metadata in carrier: %+v", carrier.Span)
+	}
+}
@@ -0,0 +1,57 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// resolveSRV returns the config with an srv:// endpoint replaced by a target
+// from its DNS SRV record. This happens at send time so collectors whose
+// ports move around, e.g. in Consul or Kubernetes DNS, are found wherever
+// they are now. Configs with any other endpoint are returned unchanged.
+func (c Config) resolveSRV(ctx context.Context) (Config, error) {
+	endpointURL, source := c.ParseEndpoint()
+	if endpointURL.Scheme != "srv" {
+		return c, nil
+	}
+
+	// Go sorts the records by priority and shuffles them by weight within
+	// a priority as RFC 2782 describes, so the first one is the pick
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", endpointURL.Hostname())
+	if err != nil {
+		return c, fmt.Errorf("failed to look up SRV record for '%s': %w", endpointURL.Hostname(), err)
+	} else if len(addrs) == 0 {
+		return c, fmt.Errorf("no SRV records found for '%s'", endpointURL.Hostname())
+	}
+
+	endpoint := c.srvEndpoint(endpointURL, addrs[0])
+	if source == "signal" {
+		return c.withSignalEndpoint(c.getSignal(), endpoint), nil
+	}
+	return c.WithEndpoint(endpoint), nil
+}
+
+// srvEndpoint returns the endpoint URL for an SRV target. The scheme follows
+// --protocol, gRPC by default, with https for HTTP unless --insecure is set.
+// Any path on the srv:// URL is kept.
+func (c Config) srvEndpoint(endpointURL *url.URL, target *net.SRV) string {
+	scheme := "grpc"
+	if strings.HasPrefix(c.Protocol, "http/") {
+		if c.Insecure {
+			scheme = "http"
+		} else {
+			scheme = "https"
+		}
+	}
+
+	u := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(strings.TrimSuffix(target.Target, "."), strconv.Itoa(int(target.Port))),
+		Path:   endpointURL.Path,
+	}
+	return u.String()
+}
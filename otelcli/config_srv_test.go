@@ -0,0 +1,53 @@
+package otelcli
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestSrvEndpoint(t *testing.T) {
+	target := &net.SRV{Target: "collector-1.example.com.", Port: 24317, Priority: 10, Weight: 5}
+
+	for _, tc := range []struct {
+		config Config
+		srvURL string
+		want   string
+	}{
+		{
+			config: DefaultConfig(),
+			srvURL: "srv://_otlp._tcp.example.com",
+			want:   "grpc://collector-1.example.com:24317",
+		},
+		{
+			config: DefaultConfig().WithProtocol("http/protobuf"),
+			srvURL: "srv://_otlp._tcp.example.com",
+			want:   "https://collector-1.example.com:24317",
+		},
+		{
+			config: DefaultConfig().WithProtocol("http/json").WithInsecure(true),
+			srvURL: "srv://_otlp._tcp.example.com/custom/v1/traces",
+			want:   "http://collector-1.example.com:24317/custom/v1/traces",
+		},
+	} {
+		u, err := url.Parse(tc.srvURL)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %s", tc.srvURL, err)
+		}
+		if got := tc.config.srvEndpoint(u, target); got != tc.want {
+			t.Errorf("expected endpoint %q but got %q", tc.want, got)
+		}
+	}
+}
+
+func TestResolveSRVPassthrough(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("grpc://localhost:4317")
+	got, err := config.resolveSRV(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got.Endpoint != config.Endpoint {
+		t.Errorf("expected endpoint %q to be unchanged but got %q", config.Endpoint, got.Endpoint)
+	}
+}
@@ -0,0 +1,87 @@
+package otelcli
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// statsdNameRe matches the characters that aren't safe in a plain StatsD
+// metric name segment.
+var statsdNameRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// statsdStatus returns "error" for spans with an error status, "ok" otherwise.
+func statsdStatus(span *tracepb.Span) string {
+	if span.Status != nil && span.Status.Code == tracepb.Status_STATUS_CODE_ERROR {
+		return "error"
+	}
+	return "ok"
+}
+
+// statsdMetrics returns a timing with the span's duration in milliseconds and
+// a counter for the span's status, one per line. With --statsd-dogstatsd the
+// span name and status are tags on fixed metric names, plain StatsD has no
+// tags so they're put into the metric names instead.
+func (c Config) statsdMetrics(span *tracepb.Span) string {
+	prefix := strings.TrimSuffix(c.StatsdPrefix, ".")
+	if prefix != "" {
+		prefix += "."
+	}
+	ms := float64(span.EndTimeUnixNano-span.StartTimeUnixNano) / 1e6
+	duration := strconv.FormatFloat(ms, 'f', -1, 64)
+	status := statsdStatus(span)
+
+	if c.StatsdDogstatsd {
+		// , and | separate tags and fields so they can't be in tag values
+		tagValue := strings.NewReplacer(",", "_", "|", "_", "\n", "_")
+		tags := fmt.Sprintf("#name:%s,status:%s", tagValue.Replace(span.Name), status)
+		if c.ServiceName != "" {
+			tags += ",service:" + tagValue.Replace(c.ServiceName)
+		}
+		return fmt.Sprintf("%sspan.duration:%s|ms|%s\n%sspan.count:1|c|%s", prefix, duration, tags, prefix, tags)
+	}
+
+	name := strings.Trim(statsdNameRe.ReplaceAllString(span.Name, "_"), "_")
+	if name == "" {
+		name = "span"
+	}
+	return fmt.Sprintf("%s%s.duration:%s|ms\n%s%s.%s:1|c", prefix, name, duration, prefix, name, status)
+}
+
+// SendStatsd sends the span's metrics to the StatsD server at --statsd over
+// UDP. Like StatsD clients do, it doesn't wait to hear back, so errors are
+// only reported when the address can't be used.
+func (c Config) SendStatsd(span *tracepb.Span) error {
+	conn, err := net.DialTimeout("udp", c.Statsd, c.GetTimeout())
+	if err != nil {
+		return fmt.Errorf("failed to send StatsD metrics: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(c.statsdMetrics(span))); err != nil {
+		return fmt.Errorf("failed to send StatsD metrics: %w", err)
+	}
+	return nil
+}
+
+// WithStatsd returns the config with Statsd set to the provided value.
+func (c Config) WithStatsd(with string) Config {
+	c.Statsd = with
+	return c
+}
+
+// WithStatsdPrefix returns the config with StatsdPrefix set to the provided value.
+func (c Config) WithStatsdPrefix(with string) Config {
+	c.StatsdPrefix = with
+	return c
+}
+
+// WithStatsdDogstatsd returns the config with StatsdDogstatsd set to the provided value.
+func (c Config) WithStatsdDogstatsd(with bool) Config {
+	c.StatsdDogstatsd = with
+	return c
+}
@@ -0,0 +1,86 @@
+package otelcli
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestStatsdMetrics(t *testing.T) {
+	span := &tracepb.Span{
+		Name:              "db backup",
+		StartTimeUnixNano: 1700000000000000000,
+		EndTimeUnixNano:   1700000001500000000,
+	}
+	errorSpan := &tracepb.Span{
+		Name:              "deploy|prod,eu",
+		StartTimeUnixNano: 1700000000000000000,
+		EndTimeUnixNano:   1700000000000250000,
+		Status:            &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config Config
+		span   *tracepb.Span
+		want   string
+	}{
+		{
+			name:   "plain",
+			config: DefaultConfig(),
+			span:   span,
+			want:   "otel_cli.db_backup.duration:1500|ms\notel_cli.db_backup.ok:1|c",
+		},
+		{
+			name:   "plain error without prefix",
+			config: DefaultConfig().WithStatsdPrefix(""),
+			span:   errorSpan,
+			want:   "deploy_prod_eu.duration:0.25|ms\ndeploy_prod_eu.error:1|c",
+		},
+		{
+			name:   "dogstatsd",
+			config: DefaultConfig().WithStatsdDogstatsd(true).WithServiceName("backups"),
+			span:   span,
+			want:   "otel_cli.span.duration:1500|ms|#name:db backup,status:ok,service:backups\notel_cli.span.count:1|c|#name:db backup,status:ok,service:backups",
+		},
+		{
+			name:   "dogstatsd error",
+			config: DefaultConfig().WithStatsdDogstatsd(true).WithStatsdPrefix("ci.").WithServiceName(""),
+			span:   errorSpan,
+			want:   "ci.span.duration:0.25|ms|#name:deploy_prod_eu,status:error\nci.span.count:1|c|#name:deploy_prod_eu,status:error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.config.statsdMetrics(tc.span); got != tc.want {
+				t.Errorf("expected metrics:\n%s\nbut got:\n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSendStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer conn.Close()
+
+	span := &tracepb.Span{Name: "backup", StartTimeUnixNano: 0, EndTimeUnixNano: 2000000}
+	config := DefaultConfig().WithStatsd(conn.LocalAddr().String())
+	if err := config.SendStatsd(span); err != nil {
+		t.Fatalf("failed to send metrics: %s", err)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %s", err)
+	}
+	want := "otel_cli.backup.duration:2|ms\notel_cli.backup.ok:1|c"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("expected metrics:\n%s\nbut got:\n%s", want, got)
+	}
+}
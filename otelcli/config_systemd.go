@@ -0,0 +1,83 @@
+package otelcli
+
+import (
+	"os"
+	"strings"
+)
+
+// systemdUnitSuffixes are the kinds of systemd units that run processes.
+var systemdUnitSuffixes = []string{".service", ".scope", ".socket", ".mount", ".swap"}
+
+// systemdAttributes returns attributes for the systemd unit otel-cli is
+// running in, so spans can be matched with the unit's journal entries. The
+// keys are named after the journal's _SYSTEMD_* fields.
+func (c Config) systemdAttributes(getenv func(string) string) map[string]string {
+	if getenv("INVOCATION_ID") == "" {
+		return nil
+	}
+	// not being able to read it, e.g. on macOS, just leaves out the unit
+	cgroup, _ := os.ReadFile("/proc/self/cgroup")
+	return systemdAttributes(getenv, string(cgroup))
+}
+
+// systemdAttributes returns the attributes for the unit in the contents of
+// /proc/self/cgroup. systemd sets INVOCATION_ID for services, but shells in
+// desktop terminals run in scopes and inherit the terminal's, so scopes are
+// skipped rather than tagging everything run from a terminal.
+func systemdAttributes(getenv func(string) string, cgroup string) map[string]string {
+	invocationId := getenv("INVOCATION_ID")
+	if invocationId == "" {
+		return nil
+	}
+
+	unit, slice := systemdUnit(cgroup)
+	if strings.HasSuffix(unit, ".scope") {
+		return nil
+	}
+
+	attrs := map[string]string{
+		"systemd.invocation_id": invocationId,
+		"systemd.unit":          unit,
+		"systemd.slice":         slice,
+	}
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+	return attrs
+}
+
+// systemdUnit returns the unit and slice from the contents of
+// /proc/self/cgroup, using the unified hierarchy or, on cgroup v1, the
+// name=systemd one. Services with Delegate= can be in a cgroup below their
+// unit's, so this is the deepest unit and slice in the path.
+func systemdUnit(cgroup string) (string, string) {
+	var path string
+	for _, line := range strings.Split(cgroup, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			path = parts[2]
+			break
+		} else if parts[1] == "name=systemd" {
+			path = parts[2]
+		}
+	}
+
+	var unit, slice string
+	for _, name := range strings.Split(path, "/") {
+		if strings.HasSuffix(name, ".slice") {
+			slice = name
+			continue
+		}
+		for _, suffix := range systemdUnitSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				unit = name
+			}
+		}
+	}
+	return unit, slice
+}
@@ -0,0 +1,96 @@
+package otelcli
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSystemdAttributes(t *testing.T) {
+	env := map[string]string{"INVOCATION_ID": "9f2c0e8d4b5a4d6e8f7a6b5c4d3e2f1a"}
+	getenv := func(name string) string { return env[name] }
+
+	for _, tc := range []struct {
+		name   string
+		cgroup string
+		want   map[string]string
+	}{
+		{
+			name:   "system service",
+			cgroup: "0::/system.slice/backup.service\n",
+			want: map[string]string{
+				"systemd.invocation_id": env["INVOCATION_ID"],
+				"systemd.unit":          "backup.service",
+				"systemd.slice":         "system.slice",
+			},
+		},
+		{
+			name:   "user service with a delegated cgroup",
+			cgroup: "0::/user.slice/user-1000.slice/user@1000.service/app.slice/sync.service/worker\n",
+			want: map[string]string{
+				"systemd.invocation_id": env["INVOCATION_ID"],
+				"systemd.unit":          "sync.service",
+				"systemd.slice":         "app.slice",
+			},
+		},
+		{
+			name:   "cgroup v1",
+			cgroup: "12:cpu,cpuacct:/system.slice/backup.service\n1:name=systemd:/system.slice/backup.service\n",
+			want: map[string]string{
+				"systemd.invocation_id": env["INVOCATION_ID"],
+				"systemd.unit":          "backup.service",
+				"systemd.slice":         "system.slice",
+			},
+		},
+		{
+			name:   "no cgroup",
+			cgroup: "",
+			want:   map[string]string{"systemd.invocation_id": env["INVOCATION_ID"]},
+		},
+		{
+			name:   "terminal scope",
+			cgroup: "0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-terminal-1234.scope\n",
+			want:   nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, systemdAttributes(getenv, tc.cgroup)); diff != "" {
+				t.Errorf("systemd attributes did not match (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if got := systemdAttributes(func(string) string { return "" }, "0::/system.slice/backup.service\n"); got != nil {
+		t.Errorf("expected no systemd attributes without INVOCATION_ID but got %q", got)
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unable to listen on a unix datagram socket: %s", err)
+	}
+	defer conn.Close()
+
+	if err := sdNotify(socket, "READY=1\nSTATUS=running"); err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read the notification: %s", err)
+	}
+	if got := string(buf[:n]); got != "READY=1\nSTATUS=running" {
+		t.Errorf("expected the state to be sent as-is but got %q", got)
+	}
+
+	if err := sdNotify("", "READY=1"); err != nil {
+		t.Errorf("expected no error without a socket but got %s", err)
+	}
+	if err := sdNotify(filepath.Join(t.TempDir(), "missing"), "READY=1"); err == nil {
+		t.Error("expected an error notifying a socket that doesn't exist")
+	}
+}
@@ -0,0 +1,76 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// templateRe matches the placeholders expandTemplates fills in. Anything
+// else in braces is left alone so existing names with braces keep working.
+var templateRe = regexp.MustCompile(`\{(env|attr):([^{}]+)\}|\{(hostname|date|time)\}`)
+
+// templateVars are the values placeholders are resolved against.
+type templateVars struct {
+	getenv   func(string) string
+	attrs    map[string]string
+	hostname string
+	now      time.Time
+}
+
+// expandTemplates fills in placeholders in the span name and attribute
+// values, e.g. --name 'deploy {env:STAGE} {attr:service}':
+//
+//	{env:NAME}   the environment variable NAME
+//	{attr:KEY}   the --attrs value for KEY, as given before expansion
+//	{hostname}   the host's name
+//	{date}       today's date, e.g. 2006-01-02
+//	{time}       the current time in RFC3339
+func (c *Config) expandTemplates(getenv func(string) string, now time.Time) error {
+	vars := templateVars{getenv: getenv, attrs: c.Attributes, now: now}
+	if hostname, err := os.Hostname(); err == nil {
+		vars.hostname = hostname
+	}
+
+	name, err := vars.expand(c.SpanName)
+	if err != nil {
+		return fmt.Errorf("span name: %w", err)
+	}
+	c.SpanName = name
+
+	attrs := make(map[string]string, len(c.Attributes))
+	for k, v := range c.Attributes {
+		if attrs[k], err = vars.expand(v); err != nil {
+			return fmt.Errorf("attribute %q: %w", k, err)
+		}
+	}
+	c.Attributes = attrs
+
+	return nil
+}
+
+// expand returns the string with its placeholders filled in.
+func (v templateVars) expand(in string) (string, error) {
+	var err error
+	out := templateRe.ReplaceAllStringFunc(in, func(match string) string {
+		m := templateRe.FindStringSubmatch(match)
+		switch {
+		case m[1] == "env":
+			return v.getenv(m[2])
+		case m[1] == "attr":
+			value, ok := v.attrs[m[2]]
+			if !ok {
+				err = fmt.Errorf("%s refers to an attribute that isn't set", match)
+			}
+			return value
+		case m[3] == "hostname":
+			return v.hostname
+		case m[3] == "date":
+			return v.now.Format("2006-01-02")
+		default: // time
+			return v.now.Format(time.RFC3339)
+		}
+	})
+	return out, err
+}
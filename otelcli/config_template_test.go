@@ -0,0 +1,54 @@
+package otelcli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateExpand(t *testing.T) {
+	vars := templateVars{
+		getenv:   func(name string) string { return map[string]string{"STAGE": "prod"}[name] },
+		attrs:    map[string]string{"service": "checkout"},
+		hostname: "build-7",
+		now:      time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC),
+	}
+
+	for in, want := range map[string]string{
+		"deploy {env:STAGE} {attr:service}": "deploy prod checkout",
+		"{hostname} at {date}":              "build-7 at 2024-03-09",
+		"started {time}":                    "started 2024-03-09T14:30:00Z",
+		"{env:UNSET}-x":                     "-x",
+		"{unknown} and {} stay put":         "{unknown} and {} stay put",
+	} {
+		got, err := vars.expand(in)
+		if err != nil {
+			t.Errorf("failed to expand %q: %s", in, err)
+		} else if got != want {
+			t.Errorf("expected %q to expand to %q but got %q", in, want, got)
+		}
+	}
+
+	if _, err := vars.expand("{attr:missing}"); err == nil {
+		t.Error("expected an unset attribute to fail")
+	}
+}
+
+func TestExpandTemplates(t *testing.T) {
+	c := DefaultConfig().
+		WithSpanName("deploy {env:STAGE} {attr:service}").
+		WithAttributes(map[string]string{"service": "checkout", "target": "{env:STAGE}-{attr:service}"})
+	getenv := func(name string) string { return map[string]string{"STAGE": "prod"}[name] }
+	if err := c.expandTemplates(getenv, time.Now()); err != nil {
+		t.Fatalf("failed to expand templates: %s", err)
+	}
+
+	if c.SpanName != "deploy prod checkout" {
+		t.Errorf("unexpected span name %q", c.SpanName)
+	}
+	want := map[string]string{"service": "checkout", "target": "prod-checkout"}
+	if diff := cmp.Diff(want, c.Attributes); diff != "" {
+		t.Errorf("attributes did not match (-want +got):\n%s", diff)
+	}
+}
@@ -1,9 +1,13 @@
 package otelcli
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/equinix-labs/otel-cli/otlpclient"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -36,6 +40,57 @@ func TestIsRecording(t *testing.T) {
 	if !c.GetIsRecording() {
 		t.Fail()
 	}
+
+	// OTEL_TRACES_EXPORTER=none turns off exports even with an endpoint
+	if c.WithExporter("none").GetIsRecording() {
+		t.Fail()
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	env := map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT":        "localhost:4317",
+		"OTEL_EXPORTER_OTLP_TIMEOUT":         "10000",
+		"OTEL_EXPORTER_OTLP_PROTOCOL":        "http/protobuf",
+		"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL": "grpc",
+		"OTEL_EXPORTER_OTLP_TRACES_INSECURE": "true",
+		"OTEL_TRACES_EXPORTER":               "console",
+	}
+
+	c := DefaultConfig()
+	if err := c.LoadEnv(func(name string) string { return env[name] }); err != nil {
+		t.Fatalf("failed to load env: %s", err)
+	}
+
+	want := DefaultConfig().
+		WithEndpoint("localhost:4317").
+		WithTimeout("10000ms"). // OTLP timeouts are milliseconds per spec
+		WithProtocol("grpc").   // signal-specific envvars take precedence
+		WithInsecure(true).
+		WithExporter("console")
+	if diff := cmp.Diff(want.ToStringMap(), c.ToStringMap()); diff != "" {
+		t.Errorf("config did not match (-want +got):\n%s", diff)
+	}
+
+	// OTEL_CLI_ envvars win over the SDK envvars
+	env["OTEL_CLI_EXPORTER"] = "otlp"
+	c = DefaultConfig()
+	if err := c.LoadEnv(func(name string) string { return env[name] }); err != nil {
+		t.Fatalf("failed to load env: %s", err)
+	} else if c.Exporter != "otlp" {
+		t.Errorf("expected OTEL_CLI_EXPORTER to take precedence but got %q", c.Exporter)
+	}
+
+	// OTEL_CLI_ATTRIBUTES are defaults under --attrs rather than replacing them
+	env["OTEL_CLI_ATTRIBUTES"] = "pipeline=deploy,team=payments"
+	c = DefaultConfig().WithAttributes(map[string]string{"team": "checkout", "step": "migrate"})
+	if err := c.LoadEnv(func(name string) string { return env[name] }); err != nil {
+		t.Fatalf("failed to load env: %s", err)
+	}
+	wantAttrs := map[string]string{"pipeline": "deploy", "team": "checkout", "step": "migrate"}
+	if diff := cmp.Diff(wantAttrs, c.Attributes); diff != "" {
+		t.Errorf("attributes did not match (-want +got):\n%s", diff)
+	}
 }
 
 func TestFlattenStringMap(t *testing.T) {
@@ -227,6 +282,36 @@ func TestParseEndpoint(t *testing.T) {
 			wantEndpoint: "http://localhost",
 			wantSource:   "signal",
 		},
+		// unix socket, should come through unmodified
+		{
+			config:       DefaultConfig().WithEndpoint("unix:///var/run/otelcol.sock"),
+			wantEndpoint: "unix:///var/run/otelcol.sock",
+			wantSource:   "general",
+		},
+		// zipkin exporter, no /v1/traces appended
+		{
+			config:       DefaultConfig().WithEndpoint("zipkin://localhost:9411"),
+			wantEndpoint: "zipkin://localhost:9411",
+			wantSource:   "general",
+		},
+		// SRV record, resolved later, no /v1/traces appended
+		{
+			config:       DefaultConfig().WithEndpoint("srv://_otlp._tcp.example.com"),
+			wantEndpoint: "srv://_otlp._tcp.example.com",
+			wantSource:   "general",
+		},
+		// file exporter, should come through unmodified
+		{
+			config:       DefaultConfig().WithEndpoint("file:///tmp/spans.json"),
+			wantEndpoint: "file:///tmp/spans.json",
+			wantSource:   "general",
+		},
+		// stdout exporter, should come through unmodified
+		{
+			config:       DefaultConfig().WithEndpoint("stdout://"),
+			wantEndpoint: "stdout:",
+			wantSource:   "general",
+		},
 	} {
 		u, src := tc.config.ParseEndpoint()
 
@@ -240,6 +325,18 @@ func TestParseEndpoint(t *testing.T) {
 	}
 }
 
+func TestEndpointString(t *testing.T) {
+	for _, endpoint := range []string{"stdout://", "stderr://", "file:///tmp/spans.json", "grpc://localhost:4317", "http://localhost:4318/v1/traces"} {
+		u, _ := DefaultConfig().WithEndpoint(endpoint).ParseEndpoint()
+		if got := endpointString(u); got != endpoint {
+			t.Errorf("expected endpoint %q but got %q", endpoint, got)
+		}
+		if Diag.Endpoint != endpoint {
+			t.Errorf("expected diagnostics endpoint %q but got %q", endpoint, Diag.Endpoint)
+		}
+	}
+}
+
 func TestWithEndpoint(t *testing.T) {
 	if DefaultConfig().WithEndpoint("foobar").Endpoint != "foobar" {
 		t.Fail()
@@ -255,6 +352,109 @@ func TestWithTimeout(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithConnectTimeout(t *testing.T) {
+	if DefaultConfig().WithConnectTimeout("250ms").ConnectTimeout != "250ms" {
+		t.Fail()
+	}
+}
+func TestWithSendTimeout(t *testing.T) {
+	if DefaultConfig().WithSendTimeout("10s").SendTimeout != "10s" {
+		t.Fail()
+	}
+}
+func TestGetSendTimeout(t *testing.T) {
+	if got := DefaultConfig().WithTimeout("3s").GetSendTimeout(); got != 3*time.Second {
+		t.Errorf("expected send timeout to default to --timeout but got %s", got)
+	}
+	if got := DefaultConfig().WithTimeout("3s").WithSendTimeout("500ms").GetSendTimeout(); got != 500*time.Millisecond {
+		t.Errorf("expected send timeout of 500ms but got %s", got)
+	}
+}
+func TestWithCompression(t *testing.T) {
+	if DefaultConfig().WithCompression("gzip").Compression != "gzip" {
+		t.Fail()
+	}
+}
+func TestWithProxy(t *testing.T) {
+	if DefaultConfig().WithProxy("socks5://localhost:1080").Proxy != "socks5://localhost:1080" {
+		t.Fail()
+	}
+}
+func TestWithRetryMaxAttempts(t *testing.T) {
+	if DefaultConfig().WithRetryMaxAttempts(3).RetryMaxAttempts != 3 {
+		t.Fail()
+	}
+}
+func TestWithRetryInitialInterval(t *testing.T) {
+	if DefaultConfig().WithRetryInitialInterval("250ms").RetryInitialInterval != "250ms" {
+		t.Fail()
+	}
+}
+func TestWithRetryMaxInterval(t *testing.T) {
+	if DefaultConfig().WithRetryMaxInterval("2s").RetryMaxInterval != "2s" {
+		t.Fail()
+	}
+}
+func TestWithSpoolDir(t *testing.T) {
+	if DefaultConfig().WithSpoolDir("/tmp/spool").SpoolDir != "/tmp/spool" {
+		t.Fail()
+	}
+}
+func TestWithSpoolKey(t *testing.T) {
+	if DefaultConfig().WithSpoolKey("env:SPOOL_KEY").SpoolKey != "env:SPOOL_KEY" {
+		t.Fail()
+	}
+}
+func TestGetSpoolKey(t *testing.T) {
+	if DefaultConfig().GetSpoolKey() != nil {
+		t.Error("expected no spool key by default")
+	}
+
+	file := filepath.Join(t.TempDir(), "spool.key")
+	if err := os.WriteFile(file, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatalf("failed to write key file: %s", err)
+	}
+	t.Setenv("OTEL_CLI_TEST_SPOOL_KEY", "hunter2")
+
+	fromFile := DefaultConfig().WithSpoolKey(file).GetSpoolKey()
+	fromEnv := DefaultConfig().WithSpoolKey("env:OTEL_CLI_TEST_SPOOL_KEY").GetSpoolKey()
+	if len(fromFile) != 32 {
+		t.Errorf("expected a 32 byte key but got %d bytes", len(fromFile))
+	}
+	if !bytes.Equal(fromFile, fromEnv) {
+		t.Error("expected the same secret from a file and the environment to give the same key")
+	}
+}
+func TestWithExporter(t *testing.T) {
+	if DefaultConfig().WithExporter("console").Exporter != "console" {
+		t.Fail()
+	}
+}
+func TestWithFileFormat(t *testing.T) {
+	if DefaultConfig().WithFileFormat("proto").FileFormat != "proto" {
+		t.Fail()
+	}
+}
+func TestWithFileMaxMegabytes(t *testing.T) {
+	if DefaultConfig().WithFileMaxMegabytes(10).FileMaxMegabytes != 10 {
+		t.Fail()
+	}
+}
+func TestWithFileMaxDays(t *testing.T) {
+	if DefaultConfig().WithFileMaxDays(7).FileMaxDays != 7 {
+		t.Fail()
+	}
+}
+func TestWithFileMaxBackups(t *testing.T) {
+	if DefaultConfig().WithFileMaxBackups(3).FileMaxBackups != 3 {
+		t.Fail()
+	}
+}
+func TestWithFileMaxAge(t *testing.T) {
+	if DefaultConfig().WithFileMaxAge("24h").FileMaxAge != "24h" {
+		t.Fail()
+	}
+}
 func TestWithHeaders(t *testing.T) {
 	attr := map[string]string{"foo": "bar"}
 	c := DefaultConfig().WithHeaders(attr)
@@ -297,6 +497,64 @@ func TestWithServiceName(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithResourceDetectors(t *testing.T) {
+	if DefaultConfig().WithResourceDetectors("foobar").ResourceDetectors != "foobar" {
+		t.Fail()
+	}
+}
+func TestGetResourceDetectors(t *testing.T) {
+	got := DefaultConfig().WithResourceDetectors(" host,os,, k8s").GetResourceDetectors()
+	if diff := cmp.Diff([]string{"host", "os", "k8s"}, got); diff != "" {
+		t.Errorf("resource detectors did not match (-want +got):\n%s", diff)
+	}
+	if len(DefaultConfig().GetResourceDetectors()) != 0 {
+		t.Error("expected no resource detectors by default")
+	}
+}
+func TestWithResourceAttributes(t *testing.T) {
+	attr := map[string]string{"foo": "bar"}
+	c := DefaultConfig().WithResourceAttributes(attr)
+	if diff := cmp.Diff(attr, c.ResourceAttributes); diff != "" {
+		t.Fatalf("resource attributes did not match (-want +got):\n%s", diff)
+	}
+}
+func TestWithServiceVersion(t *testing.T) {
+	if DefaultConfig().WithServiceVersion("1.2.3").ServiceVersion != "1.2.3" {
+		t.Fail()
+	}
+}
+func TestWithEnvironment(t *testing.T) {
+	if DefaultConfig().WithEnvironment("prod").Environment != "prod" {
+		t.Fail()
+	}
+}
+func TestWithAttributesFile(t *testing.T) {
+	if DefaultConfig().WithAttributesFile("build-meta.json").AttributesFile != "build-meta.json" {
+		t.Fail()
+	}
+}
+func TestWithAttributeCommands(t *testing.T) {
+	cmds := []string{"git_sha=git rev-parse HEAD"}
+	c := DefaultConfig().WithAttributeCommands(cmds)
+	if diff := cmp.Diff(cmds, c.AttributeCommands); diff != "" {
+		t.Errorf("AttributeCommands did not match (-want +got):\n%s", diff)
+	}
+}
+func TestWithStrictSemconv(t *testing.T) {
+	if DefaultConfig().WithStrictSemconv(true).StrictSemconv != true {
+		t.Fail()
+	}
+}
+func TestWithCiDetect(t *testing.T) {
+	if DefaultConfig().WithCiDetect("gitlab").CiDetect != "gitlab" {
+		t.Fail()
+	}
+}
+func TestWithGitAttrs(t *testing.T) {
+	if DefaultConfig().WithGitAttrs(true).GitAttrs != true {
+		t.Fail()
+	}
+}
 func TestWithSpanName(t *testing.T) {
 	if DefaultConfig().WithSpanName("foobar").SpanName != "foobar" {
 		t.Fail()
@@ -340,6 +598,11 @@ func TestWithTraceparentCarrierFile(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithTraceparentCarrierFormat(t *testing.T) {
+	if DefaultConfig().WithTraceparentCarrierFormat("json").TraceparentCarrierFormat != "json" {
+		t.Fail()
+	}
+}
 func TestWithTraceparentIgnoreEnv(t *testing.T) {
 	if DefaultConfig().WithTraceparentIgnoreEnv(true).TraceparentIgnoreEnv != true {
 		t.Fail()
@@ -355,6 +618,11 @@ func TestWithTraceparentPrintExport(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithTraceparentPrintFormat(t *testing.T) {
+	if DefaultConfig().WithTraceparentPrintFormat("b3").TraceparentPrintFormat != "b3" {
+		t.Fail()
+	}
+}
 func TestWithTraceparentRequired(t *testing.T) {
 	if DefaultConfig().WithTraceparentRequired(true).TraceparentRequired != true {
 		t.Fail()
@@ -385,6 +653,90 @@ func TestWithStatusCanaryInterval(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithStatusProbe(t *testing.T) {
+	if DefaultConfig().WithStatusProbe(true).StatusProbe != true {
+		t.Fail()
+	}
+}
+func TestWithStatusCheck(t *testing.T) {
+	if DefaultConfig().WithStatusCheck(true).StatusCheck != true {
+		t.Fail()
+	}
+}
+func TestWithServerHttpEndpoint(t *testing.T) {
+	if DefaultConfig().WithServerHttpEndpoint("localhost:4318").ServerHttpEndpoint != "localhost:4318" {
+		t.Fail()
+	}
+}
+func TestWithServerForwardListen(t *testing.T) {
+	if DefaultConfig().WithServerForwardListen("localhost:14317").ServerForwardListen != "localhost:14317" {
+		t.Fail()
+	}
+}
+func TestWithServerMetricsEndpoint(t *testing.T) {
+	if DefaultConfig().WithServerMetricsEndpoint("localhost:9464").ServerMetricsEndpoint != "localhost:9464" {
+		t.Fail()
+	}
+}
+func TestWithServerTlsCert(t *testing.T) {
+	if DefaultConfig().WithServerTlsCert("/tmp/server.pem").ServerTlsCert != "/tmp/server.pem" {
+		t.Fail()
+	}
+}
+func TestWithServerTlsKey(t *testing.T) {
+	if DefaultConfig().WithServerTlsKey("/tmp/server.key").ServerTlsKey != "/tmp/server.key" {
+		t.Fail()
+	}
+}
+func TestWithServerTlsClientCA(t *testing.T) {
+	if DefaultConfig().WithServerTlsClientCA("/tmp/ca.pem").ServerTlsClientCA != "/tmp/ca.pem" {
+		t.Fail()
+	}
+}
+func TestWithServerAuthToken(t *testing.T) {
+	if DefaultConfig().WithServerAuthToken("s3cret").ServerAuthToken != "s3cret" {
+		t.Fail()
+	}
+}
+func TestWithServerAuthBasic(t *testing.T) {
+	if DefaultConfig().WithServerAuthBasic("dev:s3cret").ServerAuthBasic != "dev:s3cret" {
+		t.Fail()
+	}
+}
+func TestWithServerAllowIps(t *testing.T) {
+	if DefaultConfig().WithServerAllowIps("10.0.0.0/8").ServerAllowIps != "10.0.0.0/8" {
+		t.Fail()
+	}
+}
+func TestWithServerKeep(t *testing.T) {
+	rules := []string{"status=error"}
+	c := DefaultConfig().WithServerKeep(rules)
+	if diff := cmp.Diff(rules, c.ServerKeep); diff != "" {
+		t.Errorf("ServerKeep did not match (-want +got):\n%s", diff)
+	}
+}
+func TestWithServerDrop(t *testing.T) {
+	rules := []string{"name~^health"}
+	c := DefaultConfig().WithServerDrop(rules)
+	if diff := cmp.Diff(rules, c.ServerDrop); diff != "" {
+		t.Errorf("ServerDrop did not match (-want +got):\n%s", diff)
+	}
+}
+func TestWithServerSample(t *testing.T) {
+	if DefaultConfig().WithServerSample("10%").ServerSample != "10%" {
+		t.Fail()
+	}
+}
+func TestWithServerKeepWait(t *testing.T) {
+	if DefaultConfig().WithServerKeepWait("30s").ServerKeepWait != "30s" {
+		t.Fail()
+	}
+}
+func TestWithReplayShiftToNow(t *testing.T) {
+	if DefaultConfig().WithReplayShiftToNow(true).ReplayShiftToNow != true {
+		t.Fail()
+	}
+}
 func TestWithSpanStartTime(t *testing.T) {
 	if DefaultConfig().WithSpanStartTime("foobar").SpanStartTime != "foobar" {
 		t.Fail()
@@ -410,8 +762,368 @@ func TestWithCfgFile(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithProfile(t *testing.T) {
+	if DefaultConfig().WithProfile("foobar").Profile != "foobar" {
+		t.Fail()
+	}
+}
 func TestWithVerbose(t *testing.T) {
 	if DefaultConfig().WithVerbose(true).Verbose != true {
 		t.Fail()
 	}
 }
+
+func TestWithJson(t *testing.T) {
+	if DefaultConfig().WithJson(true).Json != true {
+		t.Fail()
+	}
+}
+
+func TestWithLogLevel(t *testing.T) {
+	if DefaultConfig().WithLogLevel("debug").LogLevel != "debug" {
+		t.Fail()
+	}
+}
+
+func TestWithLogFormat(t *testing.T) {
+	if DefaultConfig().WithLogFormat("json").LogFormat != "json" {
+		t.Fail()
+	}
+}
+
+func TestWithDebugWire(t *testing.T) {
+	if DefaultConfig().WithDebugWire("stderr").DebugWire != "stderr" {
+		t.Fail()
+	}
+}
+
+func TestWithFanoutPolicy(t *testing.T) {
+	if DefaultConfig().WithFanoutPolicy("all").FanoutPolicy != "all" {
+		t.Fail()
+	}
+}
+
+func TestCommaListValue(t *testing.T) {
+	var target string
+	v := newCommaListValue(&target, "default")
+	if target != "default" {
+		t.Errorf("expected default value to be set but got %q", target)
+	}
+
+	for _, val := range []string{"a", "b,c", "d"} {
+		v.Set(val)
+	}
+
+	if target != "a,b,c,d" {
+		t.Errorf("expected repeated values to be joined but got %q", target)
+	}
+}
+
+func TestGetTargetConfigs(t *testing.T) {
+	config := DefaultConfig().
+		WithEndpoint("localhost:4317, https://vendor.example.com").
+		WithHeaders(map[string]string{"x-base": "1"})
+	config.Targets = []TargetConfig{
+		{
+			Endpoint: "https://other.example.com",
+			Headers:  map[string]string{"x-api-key": "secret"},
+			Protocol: "http/json",
+		},
+	}
+
+	if !config.IsFanout() {
+		t.Fatalf("expected config with multiple endpoints to fan out")
+	}
+
+	targets := config.GetTargetConfigs()
+	got := []string{}
+	for _, target := range targets {
+		got = append(got, target.Endpoint+"|"+target.Protocol+"|"+flattenStringMap(target.Headers, "{}"))
+		if len(target.Targets) != 0 {
+			t.Errorf("target configs must not have nested targets")
+		}
+	}
+
+	want := []string{
+		"localhost:4317||x-base=1",
+		"https://vendor.example.com||x-base=1",
+		"https://other.example.com|http/json|x-api-key=secret",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("target configs did not match (-want +got):\n%s", diff)
+	}
+
+	if DefaultConfig().WithEndpoint("localhost").IsFanout() {
+		t.Errorf("a single endpoint should not fan out")
+	}
+}
+
+func TestParseSignalEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		config       Config
+		signal       string
+		wantEndpoint string
+		wantSource   string
+	}{
+		{
+			config:       DefaultConfig().WithEndpoint("http://localhost:4318"),
+			signal:       "metrics",
+			wantEndpoint: "http://localhost:4318/v1/metrics",
+			wantSource:   "general",
+		},
+		{
+			config:       DefaultConfig().WithEndpoint("http://localhost:4318"),
+			signal:       "logs",
+			wantEndpoint: "http://localhost:4318/v1/logs",
+			wantSource:   "general",
+		},
+		// signal endpoints are independent of each other
+		{
+			config:       DefaultConfig().WithEndpoint("http://localhost:4318").WithTracesEndpoint("http://traces:4318/t"),
+			signal:       "metrics",
+			wantEndpoint: "http://localhost:4318/v1/metrics",
+			wantSource:   "general",
+		},
+		{
+			config:       DefaultConfig().WithEndpoint("http://localhost:4318").WithLogsEndpoint("http://logs:4318/l"),
+			signal:       "logs",
+			wantEndpoint: "http://logs:4318/l",
+			wantSource:   "signal",
+		},
+		{
+			config:       DefaultConfig().WithMetricsEndpoint("localhost"),
+			signal:       "metrics",
+			wantEndpoint: "grpc://localhost:4317",
+			wantSource:   "signal",
+		},
+	} {
+		u, src := tc.config.ParseSignalEndpoint(tc.signal)
+		if u.String() != tc.wantEndpoint {
+			t.Errorf("Expected %s endpoint %q but got %q", tc.signal, tc.wantEndpoint, u.String())
+		}
+		if src != tc.wantSource {
+			t.Errorf("Expected %s source %q but got %q", tc.signal, tc.wantSource, src)
+		}
+	}
+}
+
+func TestGetSignalHeaders(t *testing.T) {
+	config := DefaultConfig().
+		WithHeaders(map[string]string{"a": "general", "b": "general"}).
+		WithTracesHeaders(map[string]string{"b": "traces"}).
+		WithLogsHeaders(map[string]string{"c": "logs"})
+
+	for signal, want := range map[string]map[string]string{
+		"traces":  {"a": "general", "b": "traces"},
+		"metrics": {"a": "general", "b": "general"},
+		"logs":    {"a": "general", "b": "general", "c": "logs"},
+	} {
+		if diff := cmp.Diff(want, config.GetSignalHeaders(signal)); diff != "" {
+			t.Errorf("%s headers did not match (-want +got):\n%s", signal, diff)
+		}
+	}
+}
+func TestWithOAuth2TokenURL(t *testing.T) {
+	if DefaultConfig().WithOAuth2TokenURL("https://auth.example.com/token").OAuth2TokenURL != "https://auth.example.com/token" {
+		t.Fail()
+	}
+}
+func TestWithOAuth2ClientID(t *testing.T) {
+	if DefaultConfig().WithOAuth2ClientID("otel-cli").OAuth2ClientID != "otel-cli" {
+		t.Fail()
+	}
+}
+func TestWithOAuth2ClientSecret(t *testing.T) {
+	if DefaultConfig().WithOAuth2ClientSecret("s3cr3t").OAuth2ClientSecret != "s3cr3t" {
+		t.Fail()
+	}
+}
+func TestWithOAuth2Scopes(t *testing.T) {
+	if DefaultConfig().WithOAuth2Scopes("traces.write").OAuth2Scopes != "traces.write" {
+		t.Fail()
+	}
+}
+func TestWithOAuth2CacheDir(t *testing.T) {
+	if DefaultConfig().WithOAuth2CacheDir("/tmp/oauth2").OAuth2CacheDir != "/tmp/oauth2" {
+		t.Fail()
+	}
+}
+func TestWithOtlpHeadersFile(t *testing.T) {
+	if DefaultConfig().WithOtlpHeadersFile("/tmp/creds.txt").OtlpHeadersFile != "/tmp/creds.txt" {
+		t.Fail()
+	}
+}
+func TestWithOtlpHeaderCmd(t *testing.T) {
+	if DefaultConfig().WithOtlpHeaderCmd("cat /tmp/creds.txt").OtlpHeaderCmd != "cat /tmp/creds.txt" {
+		t.Fail()
+	}
+}
+func TestWithSampler(t *testing.T) {
+	if DefaultConfig().WithSampler("traceidratio=0.05").Sampler != "traceidratio=0.05" {
+		t.Fail()
+	}
+}
+func TestWithSamplerArg(t *testing.T) {
+	if DefaultConfig().WithSamplerArg("0.05").SamplerArg != "0.05" {
+		t.Fail()
+	}
+}
+func TestWithSpanAttributeValueLengthLimit(t *testing.T) {
+	if DefaultConfig().WithSpanAttributeValueLengthLimit(4096).SpanAttributeValueLengthLimit != 4096 {
+		t.Fail()
+	}
+}
+func TestWithSpanAttributeCountLimit(t *testing.T) {
+	if DefaultConfig().WithSpanAttributeCountLimit(64).SpanAttributeCountLimit != 64 {
+		t.Fail()
+	}
+}
+func TestWithSpanEventCountLimit(t *testing.T) {
+	if DefaultConfig().WithSpanEventCountLimit(64).SpanEventCountLimit != 64 {
+		t.Fail()
+	}
+}
+func TestWithSpanLinkCountLimit(t *testing.T) {
+	if DefaultConfig().WithSpanLinkCountLimit(64).SpanLinkCountLimit != 64 {
+		t.Fail()
+	}
+}
+func TestSpanLimitsEnv(t *testing.T) {
+	env := map[string]string{
+		"OTEL_ATTRIBUTE_VALUE_LENGTH_LIMIT":      "100",
+		"OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT": "4096",
+		"OTEL_ATTRIBUTE_COUNT_LIMIT":             "64",
+	}
+	config := DefaultConfig()
+	if err := config.LoadEnv(func(name string) string { return env[name] }); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := otlpclient.SpanLimits{
+		AttributeValueLengthLimit: 4096, // the span envvar wins
+		AttributeCountLimit:       64,   // the general envvar applies
+		EventCountLimit:           128,
+		LinkCountLimit:            128,
+	}
+	if diff := cmp.Diff(want, config.GetSpanLimits()); diff != "" {
+		t.Errorf("span limits did not match (-want +got):\n%s", diff)
+	}
+}
+func TestGetKafkaConfig(t *testing.T) {
+	got := DefaultConfig().
+		WithEndpoint("kafka://broker1:9092/traces").
+		WithKafkaBrokers("broker2:9092, broker3:9092").
+		GetKafkaConfig()
+	want := otlpclient.KafkaConfig{
+		Brokers:  []string{"broker1:9092", "broker2:9092", "broker3:9092"},
+		Topic:    "traces",
+		Encoding: "otlp_proto",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("kafka config did not match (-want +got):\n%s", diff)
+	}
+
+	// the topic defaults to the same as the collector's kafka receiver
+	if topic := DefaultConfig().WithEndpoint("kafka://broker1:9092").GetKafkaConfig().Topic; topic != "otlp_spans" {
+		t.Errorf("expected default topic otlp_spans but got %q", topic)
+	}
+}
+func TestWithKafkaBrokers(t *testing.T) {
+	if DefaultConfig().WithKafkaBrokers("broker1:9092").KafkaBrokers != "broker1:9092" {
+		t.Fail()
+	}
+}
+func TestWithKafkaEncoding(t *testing.T) {
+	if DefaultConfig().WithKafkaEncoding("otlp_json").KafkaEncoding != "otlp_json" {
+		t.Fail()
+	}
+}
+func TestWithKafkaSASLMechanism(t *testing.T) {
+	if DefaultConfig().WithKafkaSASLMechanism("PLAIN").KafkaSASLMechanism != "PLAIN" {
+		t.Fail()
+	}
+}
+func TestWithKafkaSASLUsername(t *testing.T) {
+	if DefaultConfig().WithKafkaSASLUsername("otel").KafkaSASLUsername != "otel" {
+		t.Fail()
+	}
+}
+func TestWithKafkaSASLPassword(t *testing.T) {
+	if DefaultConfig().WithKafkaSASLPassword("s3cr3t").KafkaSASLPassword != "s3cr3t" {
+		t.Fail()
+	}
+}
+func TestWithGrafanaUrl(t *testing.T) {
+	if DefaultConfig().WithGrafanaUrl("https://grafana.example.com").GrafanaUrl != "https://grafana.example.com" {
+		t.Fail()
+	}
+}
+func TestWithGrafanaToken(t *testing.T) {
+	if DefaultConfig().WithGrafanaToken("glsa_abc").GrafanaToken != "glsa_abc" {
+		t.Fail()
+	}
+}
+func TestWithGrafanaDashboardUid(t *testing.T) {
+	if DefaultConfig().WithGrafanaDashboardUid("deploys").GrafanaDashboardUid != "deploys" {
+		t.Fail()
+	}
+}
+func TestWithGrafanaTags(t *testing.T) {
+	if DefaultConfig().WithGrafanaTags("deploy,prod").GrafanaTags != "deploy,prod" {
+		t.Fail()
+	}
+}
+func TestWithGrafanaTraceUrl(t *testing.T) {
+	if DefaultConfig().WithGrafanaTraceUrl("https://grafana.example.com/trace/{trace_id}").GrafanaTraceUrl != "https://grafana.example.com/trace/{trace_id}" {
+		t.Fail()
+	}
+}
+func TestWithExecAnnotate(t *testing.T) {
+	if DefaultConfig().WithExecAnnotate(true).ExecAnnotate != true {
+		t.Fail()
+	}
+}
+func TestWithClockSkew(t *testing.T) {
+	if DefaultConfig().WithClockSkew("ntp").ClockSkew != "ntp" {
+		t.Fail()
+	}
+}
+func TestWithClockSkewNtpServer(t *testing.T) {
+	if DefaultConfig().WithClockSkewNtpServer("time.example.com").ClockSkewNtpServer != "time.example.com" {
+		t.Fail()
+	}
+}
+func TestWithPushgatewayUrl(t *testing.T) {
+	if DefaultConfig().WithPushgatewayUrl("http://pushgateway:9091").PushgatewayUrl != "http://pushgateway:9091" {
+		t.Fail()
+	}
+}
+func TestWithPushgatewayJob(t *testing.T) {
+	if DefaultConfig().WithPushgatewayJob("backup").PushgatewayJob != "backup" {
+		t.Fail()
+	}
+}
+func TestWithPushgatewayLabels(t *testing.T) {
+	attr := map[string]string{"instance": "web1"}
+	if diff := cmp.Diff(DefaultConfig().WithPushgatewayLabels(attr).PushgatewayLabels, attr); diff != "" {
+		t.Errorf("PushgatewayLabels mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithStatsd(t *testing.T) {
+	if DefaultConfig().WithStatsd("localhost:8125").Statsd != "localhost:8125" {
+		t.Fail()
+	}
+}
+
+func TestWithStatsdPrefix(t *testing.T) {
+	if DefaultConfig().WithStatsdPrefix("ci").StatsdPrefix != "ci" {
+		t.Fail()
+	}
+}
+
+func TestWithStatsdDogstatsd(t *testing.T) {
+	if !DefaultConfig().WithStatsdDogstatsd(true).StatsdDogstatsd {
+		t.Fail()
+	}
+}
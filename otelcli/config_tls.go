@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strings"
 )
 
 // TlsConfig evaluates otel-cli configuration and returns a tls.Config
@@ -22,7 +23,7 @@ func (config Config) GetTlsConfig() *tls.Config {
 	// puts the provided CA certificate into the root pool
 	// when not provided, Go TLS will automatically load the system CA pool
 	if config.TlsCACert != "" {
-		data, err := os.ReadFile(config.TlsCACert)
+		data, err := readTlsData(config.TlsCACert)
 		if err != nil {
 			config.SoftFail("failed to load CA certificate: %s", err)
 		}
@@ -34,13 +35,13 @@ func (config Config) GetTlsConfig() *tls.Config {
 
 	// client certificate authentication
 	if config.TlsClientCert != "" && config.TlsClientKey != "" {
-		clientPEM, err := os.ReadFile(config.TlsClientCert)
+		clientPEM, err := readTlsData(config.TlsClientCert)
 		if err != nil {
-			config.SoftFail("failed to read client certificate file %s: %s", config.TlsClientCert, err)
+			config.SoftFail("failed to read client certificate: %s", err)
 		}
-		clientKeyPEM, err := os.ReadFile(config.TlsClientKey)
+		clientKeyPEM, err := readTlsData(config.TlsClientKey)
 		if err != nil {
-			config.SoftFail("failed to read client key file %s: %s", config.TlsClientKey, err)
+			config.SoftFail("failed to read client key: %s", err)
 		}
 		certPair, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
 		if err != nil {
@@ -56,10 +57,96 @@ func (config Config) GetTlsConfig() *tls.Config {
 	return tlsConfig
 }
 
+// GetServerTlsConfig returns the tls.Config for the server's listeners from
+// --tls-cert and --tls-key, requiring and verifying client certificates
+// signed by --tls-client-ca when it's set. Returns nil when the server
+// should listen without TLS.
+func (config Config) GetServerTlsConfig() *tls.Config {
+	if config.ServerTlsCert == "" && config.ServerTlsKey == "" {
+		if config.ServerTlsClientCA != "" {
+			config.SoftFail("--tls-client-ca requires --tls-cert and --tls-key")
+		}
+		return nil
+	} else if config.ServerTlsCert == "" || config.ServerTlsKey == "" {
+		config.SoftFail("server cert and key must be specified together")
+	}
+
+	certPEM, err := readTlsData(config.ServerTlsCert)
+	if err != nil {
+		config.SoftFail("failed to read server certificate: %s", err)
+	}
+	keyPEM, err := readTlsData(config.ServerTlsKey)
+	if err != nil {
+		config.SoftFail("failed to read server key: %s", err)
+	}
+	certPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		config.SoftFail("failed to parse server cert pair: %s", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certPair},
+		// gRPC needs h2 negotiated, OTLP/HTTP clients can use either
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	if config.ServerTlsClientCA != "" {
+		data, err := readTlsData(config.ServerTlsClientCA)
+		if err != nil {
+			config.SoftFail("failed to load client CA certificate: %s", err)
+		}
+		certpool := x509.NewCertPool()
+		if !certpool.AppendCertsFromPEM(data) {
+			config.SoftFail("no certificates found in --tls-client-ca")
+		}
+		tlsConfig.ClientCAs = certpool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig
+}
+
+// readTlsData loads PEM data for the TLS settings, which can be one of:
+//
+//	a path to a file containing PEM data (the default)
+//	inline PEM data, detected by the presence of a "-----BEGIN" marker
+//	env:VARNAME to read PEM data from the named environment variable
+//
+// The latter two allow CI systems that only expose secrets as environment
+// variables to use TLS without writing key material to disk.
+func readTlsData(value string) ([]byte, error) {
+	if strings.HasPrefix(value, "env:") {
+		envVar := strings.TrimPrefix(value, "env:")
+		data := os.Getenv(envVar)
+		if data == "" {
+			return nil, fmt.Errorf("environment variable %q is empty or not set", envVar)
+		}
+		return []byte(data), nil
+	} else if isInlinePem(value) {
+		return []byte(value), nil
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", value, err)
+	}
+	return data, nil
+}
+
+// isInlinePem returns true if the value is PEM data instead of a reference.
+func isInlinePem(value string) bool {
+	return strings.Contains(value, "-----BEGIN")
+}
+
 // GetInsecure returns true if the configuration expects a non-TLS connection.
 func (c Config) GetInsecure() bool {
 	endpointURL := c.GetEndpoint()
 
+	// unix sockets are local-only and never use TLS
+	if endpointURL.Scheme == "unix" {
+		return true
+	}
+
 	isLoopback, err := isLoopbackAddr(endpointURL)
 	c.SoftFailIfErr(err)
 
@@ -68,9 +155,9 @@ func (c Config) GetInsecure() bool {
 	// have any encryption available, or setting it up raises the bar of entry too high.
 	// The compromise is to automatically flip this flag to true when endpoint contains an
 	// an obvious "localhost", "127.0.0.x", or "::1" address.
-	if c.Insecure || (isLoopback && endpointURL.Scheme != "https") {
+	if c.Insecure || (isLoopback && endpointURL.Scheme != "https" && endpointURL.Scheme != "zipkins" && endpointURL.Scheme != "kafkas") {
 		return true
-	} else if endpointURL.Scheme == "http" || endpointURL.Scheme == "unix" {
+	} else if endpointURL.Scheme == "http" || endpointURL.Scheme == "zipkin" || endpointURL.Scheme == "kafka" {
 		return true
 	}
 
@@ -0,0 +1,200 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReadTlsData(t *testing.T) {
+	pem := "-----BEGIN CERTIFICATE-----\nMIIBfake\n-----END CERTIFICATE-----\n"
+
+	pemFile := filepath.Join(t.TempDir(), "cert.pem")
+	os.WriteFile(pemFile, []byte(pem), 0600)
+	t.Setenv("OTEL_CLI_TEST_TLS_PEM", pem)
+
+	for _, tc := range []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "file path", value: pemFile},
+		{name: "inline pem", value: pem},
+		{name: "env reference", value: "env:OTEL_CLI_TEST_TLS_PEM"},
+		{name: "missing env", value: "env:OTEL_CLI_TEST_TLS_MISSING", wantErr: true},
+		{name: "missing file", value: filepath.Join(t.TempDir(), "nope.pem"), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := readTlsData(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error but got data %q", data)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(data) != pem {
+				t.Errorf("expected %q but got %q", pem, data)
+			}
+		})
+	}
+}
+
+// testPems holds PEM encoded certificates and keys for testing TLS.
+type testPems struct {
+	ca, serverCert, serverKey, clientCert, clientKey string
+}
+
+// generateTestPems creates a CA with a server certificate for 127.0.0.1 and
+// a client certificate signed by it.
+func generateTestPems(t *testing.T) testPems {
+	expire := time.Now().Add(time.Hour)
+	encode := func(typ string, der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}))
+	}
+	newKey := func() (*ecdsa.PrivateKey, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %s", err)
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %s", err)
+		}
+		return key, encode("EC PRIVATE KEY", der)
+	}
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "otel-cli test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              expire,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caKey, _ := newKey()
+	caDer, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA cert: %s", err)
+	}
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, ips []net.IP) (string, string) {
+		cert := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			IPAddresses:  ips,
+			NotBefore:    time.Now(),
+			NotAfter:     expire,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		key, keyPEM := newKey()
+		der, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to create cert: %s", err)
+		}
+		return encode("CERTIFICATE", der), keyPEM
+	}
+
+	out := testPems{ca: encode("CERTIFICATE", caDer)}
+	out.serverCert, out.serverKey = issue(2, x509.ExtKeyUsageServerAuth, []net.IP{net.IPv4(127, 0, 0, 1)})
+	out.clientCert, out.clientKey = issue(3, x509.ExtKeyUsageClientAuth, nil)
+	return out
+}
+
+func TestGetServerTlsConfig(t *testing.T) {
+	pems := generateTestPems(t)
+
+	if DefaultConfig().GetServerTlsConfig() != nil {
+		t.Error("expected no TLS without --tls-cert")
+	}
+
+	config := DefaultConfig().WithServerTlsCert(pems.serverCert).WithServerTlsKey(pems.serverKey)
+	tlsConfig := config.GetServerTlsConfig()
+	if tlsConfig == nil || len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected a TLS config with the server certificate but got %v", tlsConfig)
+	}
+	if tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Error("expected client certificates to be optional without --tls-client-ca")
+	}
+
+	tlsConfig = config.WithServerTlsClientCA(pems.ca).GetServerTlsConfig()
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert || tlsConfig.ClientCAs == nil {
+		t.Error("expected client certificates to be required with --tls-client-ca")
+	}
+}
+
+func TestServerTlsListener(t *testing.T) {
+	pems := generateTestPems(t)
+	config := DefaultConfig().
+		WithServerTlsCert(pems.serverCert).
+		WithServerTlsKey(pems.serverKey).
+		WithServerTlsClientCA(pems.ca)
+
+	listener, err := listenServer(config, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	received := 0
+	server := otlpserver.NewHttpServer(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		received++
+		return false
+	}, func(otlpserver.OtlpServer) {})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(pems.ca))
+	post := func(certs []tls.Certificate) error {
+		client := http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		body, _ := proto.Marshal(&coltracepb.ExportTraceServiceRequest{
+			ResourceSpans: []*tracepb.ResourceSpans{{
+				ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{otlpclient.NewProtobufSpan()}}},
+			}},
+		})
+		resp, err := client.Post("https://"+listener.Addr().String()+"/v1/traces", "application/x-protobuf", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := post(nil); err == nil {
+		t.Error("expected a client without a certificate to be rejected")
+	}
+
+	clientCert, err := tls.X509KeyPair([]byte(pems.clientCert), []byte(pems.clientKey))
+	if err != nil {
+		t.Fatalf("failed to load client cert: %s", err)
+	}
+	if err := post([]tls.Certificate{clientCert}); err != nil {
+		t.Fatalf("expected a client with a certificate to be accepted: %s", err)
+	}
+	if received != 1 {
+		t.Errorf("expected 1 span to be received but got %d", received)
+	}
+}
@@ -0,0 +1,226 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cronWrapper holds the command-line configured settings for otel-cli cron.
+var cronWrapper struct {
+	schedule  string
+	jobName   string
+	stateDir  string
+	lateAfter string
+}
+
+// cronJobFileRe matches the characters that aren't safe in the state file names.
+var cronJobFileRe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// cronLastRun is saved for each job so the next run can tell whether any
+// scheduled runs were missed in between.
+type cronLastRun struct {
+	Scheduled time.Time `json:"scheduled"`
+	Started   time.Time `json:"started"`
+}
+
+func cronCmd(config *Config) *cobra.Command {
+	cmd := execCmd(config)
+	cmd.Use = "cron"
+	cmd.Short = "execute a cron job in a span with its schedule"
+	cmd.Long = `Execute the command provided inside a span like exec, for use in a crontab.
+The span gets the job's schedule, the time the run was scheduled for, the
+expected interval between runs, and how late the run started. Runs that
+started more than --late-after past their scheduled time get cron.late=true,
+scheduled runs that never happened since the last one are counted in
+cron.missed, and a run that starts while the job's previous run is still
+going gets cron.overlap=true. The command always runs, otel-cli only reports.
+
+The job's last run and a lockfile are kept in --state-dir, which defaults to
+otel-cli/cron under the user's cache directory, e.g. ~/.cache/otel-cli/cron.
+
+Example:
+	*/5 * * * * otel-cli cron --schedule '*/5 * * * *' --job-name sync -- ./sync.sh
+`
+	cmd.Run = doCron
+
+	cmd.Flags().StringVar(&cronWrapper.schedule, "schedule", "", "the job's crontab schedule, e.g. '*/5 * * * *' or @hourly")
+	cmd.Flags().StringVar(&cronWrapper.jobName, "job-name", "", "the job's name, defaults to the command's file name")
+	cmd.Flags().StringVar(&cronWrapper.stateDir, "state-dir", "", "directory for the job's last run and lockfile")
+	cmd.Flags().StringVar(&cronWrapper.lateAfter, "late-after", "1m", "how long after its scheduled time a run is late")
+
+	return cmd
+}
+
+func doCron(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	started := time.Now()
+
+	job := cronWrapper.jobName
+	if job == "" {
+		job = filepath.Base(args[0])
+	}
+	if config.SpanName == DefaultConfig().SpanName {
+		config.SpanName = job
+	}
+
+	attrs := map[string]string{"cron.job.name": job}
+	dir := getCronStateDir()
+	file := filepath.Join(dir, cronJobFileRe.ReplaceAllString(job, "_"))
+
+	// problems with the schedule or state are logged rather than failing,
+	// since a cron job shouldn't stop running because of its telemetry
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		config.SoftLog("failed to create cron state directory '%s': %s", dir, err)
+	}
+
+	owned, otherPid, err := lockCronJob(file + ".lock")
+	config.SoftLogIfErr(err)
+	if owned {
+		defer os.Remove(file + ".lock")
+	}
+	attrs["cron.overlap"] = strconv.FormatBool(otherPid != 0)
+	if otherPid != 0 {
+		attrs["cron.overlap.pid"] = strconv.Itoa(otherPid)
+	}
+
+	if cronWrapper.schedule == "" {
+		config.SoftLog("--schedule is not set, the run's schedule won't be reported")
+	} else if schedule, err := parseCronSchedule(cronWrapper.schedule); err != nil {
+		config.SoftLog("%s", err)
+	} else {
+		lateAfter, err := parseDuration(cronWrapper.lateAfter)
+		config.SoftLogIfErr(err)
+
+		last, err := readCronLastRun(file + ".json")
+		config.SoftLogIfErr(err)
+
+		scheduleAttrs, scheduled, err := cronScheduleAttributes(schedule, started, last, lateAfter)
+		config.SoftLogIfErr(err)
+		for k, v := range scheduleAttrs {
+			attrs[k] = v
+		}
+		attrs["cron.schedule"] = cronWrapper.schedule
+
+		if err == nil {
+			config.SoftLogIfErr(writeCronLastRun(file+".json", cronLastRun{Scheduled: scheduled, Started: started}))
+		}
+	}
+
+	// --attrs win over these
+	for k, v := range attrs {
+		if _, ok := config.Attributes[k]; !ok {
+			config.Attributes[k] = v
+		}
+	}
+
+	runExec(cmd, args, nil)
+}
+
+// cronScheduleAttributes returns the attributes describing a run started at
+// started, with last being the job's previous run when there was one, and
+// the time the run was scheduled for.
+func cronScheduleAttributes(schedule cronSchedule, started time.Time, last *cronLastRun, lateAfter time.Duration) (map[string]string, time.Time, error) {
+	scheduled, err := schedule.prev(started)
+	if err != nil {
+		return nil, scheduled, err
+	}
+	next, err := schedule.next(scheduled)
+	if err != nil {
+		return nil, scheduled, err
+	}
+	delay := started.Sub(scheduled)
+
+	attrs := map[string]string{
+		"cron.scheduled_time": scheduled.Format(time.RFC3339),
+		"cron.interval_s":     strconv.FormatInt(int64(next.Sub(scheduled).Seconds()), 10),
+		"cron.delay_ms":       strconv.FormatInt(delay.Milliseconds(), 10),
+		"cron.late":           strconv.FormatBool(delay > lateAfter),
+	}
+
+	if last != nil {
+		missed := 0
+		for t := last.Scheduled; missed < 100000; missed++ {
+			if t, err = schedule.next(t); err != nil || !t.Before(scheduled) {
+				break
+			}
+		}
+		attrs["cron.missed"] = strconv.Itoa(missed)
+	}
+
+	return attrs, scheduled, nil
+}
+
+// lockCronJob creates the lockfile with this process's pid, returning
+// whether it was created and, when another run holds it, that run's pid.
+// Lockfiles left behind by runs that aren't running anymore, e.g. because
+// they were killed, are replaced.
+func lockCronJob(lockfile string) (bool, int, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockfile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			return true, 0, f.Close()
+		} else if !os.IsExist(err) {
+			return false, 0, fmt.Errorf("failed to create cron lockfile '%s': %w", lockfile, err)
+		}
+
+		data, err := os.ReadFile(lockfile)
+		if err != nil && !os.IsNotExist(err) {
+			return false, 0, fmt.Errorf("failed to read cron lockfile '%s': %w", lockfile, err)
+		}
+		if pid, _ := strconv.Atoi(strings.TrimSpace(string(data))); pid > 0 && pid != os.Getpid() && processRunning(pid) {
+			return false, pid, nil
+		}
+		os.Remove(lockfile)
+	}
+	return false, 0, fmt.Errorf("failed to lock cron lockfile '%s'", lockfile)
+}
+
+// readCronLastRun returns the job's last run, or nil when there isn't one.
+func readCronLastRun(path string) (*cronLastRun, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	last := cronLastRun{}
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, fmt.Errorf("failed to parse cron state '%s': %w", path, err)
+	}
+	return &last, nil
+}
+
+// writeCronLastRun saves the run, writing to a tempfile then renaming so
+// another run never sees a partial file.
+func writeCronLastRun(path string, last cronLastRun) error {
+	data, err := json.Marshal(last)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// getCronStateDir returns --state-dir, or otel-cli/cron under the user's
+// cache directory when it isn't set.
+func getCronStateDir() string {
+	if cronWrapper.stateDir != "" {
+		return cronWrapper.stateDir
+	}
+	if userCache, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(userCache, "otel-cli", "cron")
+	}
+	return filepath.Join(os.TempDir(), "otel-cli-cron")
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package otelcli
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning returns whether a process with the pid exists.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,182 @@
+package otelcli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed 5-field crontab schedule. Each field is a bitset
+// of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// like cron, when both day fields are restricted a day matching either
+	// one runs, otherwise both have to match
+	domStar, dowStar bool
+}
+
+// cronMacros are the @ shorthands crontab accepts, other than @reboot,
+// which doesn't have a schedule.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var cronDayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// cronSearchLimit is how far prev and next look for a matching time, long
+// enough for a schedule that only runs on February 29th.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// parseCronSchedule parses a schedule in crontab's minute, hour,
+// day-of-month, month, day-of-week format, or one of the @ macros.
+func parseCronSchedule(spec string) (cronSchedule, error) {
+	if macro, ok := cronMacros[strings.ToLower(strings.TrimSpace(spec))]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("invalid schedule %q, expected 5 fields or a macro like @hourly", spec)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return s, fmt.Errorf("invalid minute in schedule %q: %w", spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return s, fmt.Errorf("invalid hour in schedule %q: %w", spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return s, fmt.Errorf("invalid day of month in schedule %q: %w", spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return s, fmt.Errorf("invalid month in schedule %q: %w", spec, err)
+	}
+	// 7 is also Sunday
+	if s.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return s, fmt.Errorf("invalid day of week in schedule %q: %w", spec, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges, and steps,
+// e.g. 1,5-10,*/15, into a bitset. names, when set, are accepted in place of
+// numbers starting at min, or 1 for months.
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	value := func(in string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(in, name) {
+				if max == 12 {
+					return i + 1, nil
+				}
+				return i, nil
+			}
+		}
+		n, err := strconv.Atoi(in)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not a number from %d to %d", in, min, max)
+		}
+		return n, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(from); err != nil {
+				return 0, err
+			}
+			if isRange {
+				if hi, err = value(to); err != nil {
+					return 0, err
+				}
+			} else if !hasStep {
+				hi = lo
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// matchesDay returns whether the schedule runs on t's day.
+func (s cronSchedule) matchesDay(t time.Time) bool {
+	if s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// prev returns the latest time at or before t the schedule runs.
+func (s cronSchedule) prev(t time.Time) (time.Time, error) {
+	t = t.Truncate(time.Minute)
+	limit := t.Add(-cronSearchLimit)
+	for t.After(limit) {
+		if !s.matchesDay(t) {
+			// the last minute of the day before
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		} else if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(-time.Minute)
+		} else if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(-time.Minute)
+		} else {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("the schedule never runs")
+}
+
+// next returns the earliest time after t the schedule runs.
+func (s cronSchedule) next(t time.Time) (time.Time, error) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for t.Before(limit) {
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		} else if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		} else if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+		} else {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("the schedule never runs")
+}
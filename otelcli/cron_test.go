@@ -0,0 +1,125 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCronSchedule(t *testing.T) {
+	at := func(s string) time.Time {
+		out, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	// 2024-03-13 is a Wednesday
+	now := at("2024-03-13 10:07")
+	for _, tc := range []struct {
+		spec       string
+		prev, next string
+	}{
+		{"*/5 * * * *", "2024-03-13 10:05", "2024-03-13 10:10"},
+		{"0 * * * *", "2024-03-13 10:00", "2024-03-13 11:00"},
+		{"@daily", "2024-03-13 00:00", "2024-03-14 00:00"},
+		{"30 2 * * mon-fri", "2024-03-13 02:30", "2024-03-14 02:30"},
+		{"0 9 * * 0", "2024-03-10 09:00", "2024-03-17 09:00"},
+		{"0 9 * * 7", "2024-03-10 09:00", "2024-03-17 09:00"},
+		{"15,45 8-18/2 * * *", "2024-03-13 08:45", "2024-03-13 10:15"},
+		{"0 0 1 jan *", "2024-01-01 00:00", "2025-01-01 00:00"},
+		{"0 0 29 2 *", "2024-02-29 00:00", "2028-02-29 00:00"},
+		// restricting both days runs on either one
+		{"0 12 1 * fri", "2024-03-08 12:00", "2024-03-15 12:00"},
+	} {
+		schedule, err := parseCronSchedule(tc.spec)
+		if err != nil {
+			t.Errorf("failed to parse %q: %s", tc.spec, err)
+			continue
+		}
+		prev, err := schedule.prev(now)
+		if err != nil || !prev.Equal(at(tc.prev)) {
+			t.Errorf("%q: expected previous run at %s but got %s (%v)", tc.spec, tc.prev, prev, err)
+		}
+		next, err := schedule.next(prev)
+		if err != nil || !next.Equal(at(tc.next)) {
+			t.Errorf("%q: expected next run at %s but got %s (%v)", tc.spec, tc.next, next, err)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@reboot", "* * * foo *"} {
+		if _, err := parseCronSchedule(spec); err == nil {
+			t.Errorf("expected an error parsing %q", spec)
+		}
+	}
+
+	never, err := parseCronSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := never.prev(now); err == nil {
+		t.Error("expected an error for a schedule that never runs")
+	}
+}
+
+func TestCronScheduleAttributes(t *testing.T) {
+	schedule, err := parseCronSchedule("*/5 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Date(2024, 3, 13, 10, 7, 30, 0, time.UTC)
+	last := &cronLastRun{Scheduled: time.Date(2024, 3, 13, 9, 50, 0, 0, time.UTC)}
+
+	attrs, scheduled, err := cronScheduleAttributes(schedule, started, last, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"cron.scheduled_time": "2024-03-13T10:05:00Z",
+		"cron.interval_s":     "300",
+		"cron.delay_ms":       "150000",
+		"cron.late":           "true",
+		// 9:55 and 10:00 didn't run
+		"cron.missed": "2",
+	}
+	if diff := cmp.Diff(want, attrs); diff != "" {
+		t.Errorf("attributes did not match (-want +got):\n%s", diff)
+	}
+	if !scheduled.Equal(time.Date(2024, 3, 13, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("unexpected scheduled time %s", scheduled)
+	}
+
+	// the first run doesn't know about missed runs
+	attrs, _, _ = cronScheduleAttributes(schedule, started, nil, 5*time.Minute)
+	if _, ok := attrs["cron.missed"]; ok || attrs["cron.late"] != "false" {
+		t.Errorf("unexpected attributes for the first run: %q", attrs)
+	}
+}
+
+func TestLockCronJob(t *testing.T) {
+	lockfile := filepath.Join(t.TempDir(), "sync.lock")
+
+	owned, pid, err := lockCronJob(lockfile)
+	if err != nil || !owned || pid != 0 {
+		t.Fatalf("expected to take the lock but got %t, %d, %v", owned, pid, err)
+	}
+
+	// a lock held by a running process is an overlap, the parent is running
+	os.WriteFile(lockfile, []byte(strconv.Itoa(os.Getppid())), 0600)
+	owned, pid, err = lockCronJob(lockfile)
+	if err != nil || owned || pid != os.Getppid() {
+		t.Errorf("expected an overlap with pid %d but got %t, %d, %v", os.Getppid(), owned, pid, err)
+	}
+
+	// and one left behind by a run that's gone is taken over
+	os.WriteFile(lockfile, []byte("not a pid"), 0600)
+	owned, pid, err = lockCronJob(lockfile)
+	if err != nil || !owned || pid != 0 {
+		t.Errorf("expected to take over a stale lock but got %t, %d, %v", owned, pid, err)
+	}
+}
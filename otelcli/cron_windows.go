@@ -0,0 +1,14 @@
+package otelcli
+
+import "os"
+
+// processRunning returns whether a process with the pid exists. On Windows
+// FindProcess opens the process, which fails when it has exited.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -1,8 +1,11 @@
 package otelcli
 
 import (
+	"context"
 	"strconv"
 	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
 )
 
 // package global Diagnostics handle, written to from all over otel-cli
@@ -25,6 +28,8 @@ type Diagnostics struct {
 	Error              string   `json:"error"`
 	ExecExitCode       int      `json:"exec_exit_code"`
 	Retries            int      `json:"retries"`
+	DroppedRateLimited int      `json:"dropped_rate_limited"`
+	DroppedQueueFull   int      `json:"dropped_queue_full"`
 }
 
 // ToMap returns the Diag struct as a string map for testing.
@@ -51,6 +56,15 @@ func (d *Diagnostics) SetError(err error) error {
 	return err
 }
 
+// SetSendResult records the retries and, with failover, the endpoint that
+// took the spans, from the context returned by sending them.
+func (d *Diagnostics) SetSendResult(ctx context.Context) {
+	d.Retries = otlpclient.GetRetryCount(ctx)
+	if target := otlpclient.GetFailoverTarget(ctx); target != "" {
+		d.Endpoint = target
+	}
+}
+
 // GetExitCode() is a helper for Cobra to retrieve the exit code, mainly
 // used by exec to make otel-cli return the child program's exit code.
 func GetExitCode() int {
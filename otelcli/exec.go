@@ -8,7 +8,9 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/equinix-labs/otel-cli/otlpclient"
@@ -37,7 +39,9 @@ otel-cli exec -s "outer span" 'otel-cli exec -s "inner span" sleep 1'`,
 	addCommonParams(&cmd, config)
 	addSpanParams(&cmd, config)
 	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
 	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
 
 	defaults := DefaultConfig()
 	cmd.Flags().StringVar(
@@ -46,14 +50,30 @@ otel-cli exec -s "outer span" 'otel-cli exec -s "inner span" sleep 1'`,
 		defaults.ExecCommandTimeout,
 		"timeout for the child process, when 0 otel-cli will wait forever",
 	)
+	cmd.Flags().BoolVar(&config.StrictSemconv, "strict-semconv", defaults.StrictSemconv, "check attributes against the semantic conventions and exit 1 without running the command if there are problems")
+	cmd.Flags().BoolVar(&config.ExecAnnotate, "annotate", defaults.ExecAnnotate, "post a Grafana annotation for the command's run, linked to its trace")
+	addGrafanaParams(&cmd, config)
+	addPushgatewayParams(&cmd, config)
+	addStatsdParams(&cmd, config)
+	addJsonParams(&cmd, config)
 
 	return &cmd
 }
 
 func doExec(cmd *cobra.Command, args []string) {
+	runExec(cmd, args, nil)
+}
+
+// runExec runs the command in a span and sends it. started, when set, is
+// called with the span once the command is running. It returns the span
+// after it's been sent.
+func runExec(cmd *cobra.Command, args []string, started func(*tracev1.Span)) *tracev1.Span {
 	ctx := cmd.Context()
 	config := getConfig(ctx)
 
+	// before anything runs, and before the command attributes are added
+	config.checkStrictSemconv()
+
 	// put the command in the attributes, before creating the span so it gets picked up
 	config.Attributes["command"] = args[0]
 	config.Attributes["arguments"] = ""
@@ -98,7 +118,7 @@ func doExec(cmd *cobra.Command, args []string) {
 
 	// set the traceparent to the current span to be available to the child process
 	if config.GetIsRecording() {
-		tp := otlpclient.TraceparentFromProtobufSpan(span, config.GetIsRecording())
+		tp := otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
 		child.Env = append(child.Env, fmt.Sprintf("TRACEPARENT=%s", tp.Encode()))
 		// when not recording, and a traceparent is available, pass it through
 	} else if !config.TraceparentIgnoreEnv {
@@ -108,18 +128,55 @@ func doExec(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// ctrl-c (sigint) is forwarded to the child process
+	// forwarded signals and the timeout are recorded as span events, from
+	// the signal goroutine and exec's context watcher
+	var eventsMu sync.Mutex
+	addEvent := func(name string, attrs map[string]string) {
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = name
+		event.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+		eventsMu.Lock()
+		defer eventsMu.Unlock()
+		span.Events = append(span.Events, event)
+	}
+
+	// --command-timeout kills the child and, where the platform can, all of
+	// the processes it started
+	tree := &execProcessTree{child: child}
+	child.SysProcAttr = execSysProcAttr()
+	child.Cancel = func() error {
+		addEvent("command timeout", map[string]string{"timeout": config.ExecCommandTimeout})
+		return tree.kill()
+	}
+
+	// ctrl-c (sigint) is forwarded to the child process, signals that come
+	// in before it has started are forwarded once it has
 	signals := make(chan os.Signal, 10)
 	signalsDone := make(chan struct{})
 	signal.Notify(signals, os.Interrupt)
-	go func() {
-		sig := <-signals
-		child.Process.Signal(sig)
-		// this might not seem necessary but without it, otel-cli exits before sending the span
-		close(signalsDone)
-	}()
 
-	if err := child.Run(); err != nil {
+	err := child.Start()
+	if err == nil {
+		config.SoftLogIfErr(tree.track())
+		go func() {
+			for sig := range signals {
+				name, err := tree.interrupt(sig)
+				config.SoftLogIfErr(err)
+				addEvent("signal forwarded", map[string]string{"signal": name})
+			}
+			// this might not seem necessary but without it, otel-cli exits before sending the span
+			close(signalsDone)
+		}()
+
+		if started != nil {
+			started(span)
+		}
+		err = child.Wait()
+		tree.close()
+	} else {
+		close(signalsDone)
+	}
+	if err != nil {
 		span.Status = &tracev1.Status{
 			Message: fmt.Sprintf("exec command failed: %s", err),
 			Code:    tracev1.Status_STATUS_CODE_ERROR,
@@ -128,6 +185,7 @@ func doExec(cmd *cobra.Command, args []string) {
 	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
 
 	cancelCtxDeadline()
+	signal.Stop(signals)
 	close(signals)
 	<-signalsDone
 
@@ -136,9 +194,15 @@ func doExec(cmd *cobra.Command, args []string) {
 	defer cancelCtxDeadline()
 
 	ctx, client := StartClient(ctx, config)
-	ctx, err := otlpclient.SendSpan(ctx, client, config, span)
-	if err != nil {
-		config.SoftFail("unable to send span: %s", err)
+	var sendErr error
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, span)
+		ctx, sendErr = otlpclient.SendSpan(ctx, client, config, span)
+		Diag.SetSendResult(ctx)
+		// --json reports the error in its result before failing
+		if sendErr != nil && !config.Json {
+			config.SoftFail("unable to send span: %s", sendErr)
+		}
 	}
 
 	_, err = client.Stop(ctx)
@@ -149,5 +213,37 @@ func doExec(cmd *cobra.Command, args []string) {
 	// set the global exit code so main() can grab it and os.Exit() properly
 	Diag.ExecExitCode = child.ProcessState.ExitCode()
 
+	if config.ExecAnnotate {
+		text := span.Name
+		if Diag.ExecExitCode != 0 {
+			text += fmt.Sprintf(" (exit code %d)", Diag.ExecExitCode)
+		}
+		start, end := time.Unix(0, int64(span.StartTimeUnixNano)), time.Unix(0, int64(span.EndTimeUnixNano))
+		annotation := config.NewGrafanaAnnotation(text, span.TraceId, start, end)
+		config.SoftLogIfErr(config.PostGrafanaAnnotation(cmd.Context(), annotation))
+	}
+
+	if config.PushgatewayUrl != "" {
+		job := span.Name
+		if config.SpanName == DefaultConfig().SpanName {
+			job = filepath.Base(args[0])
+		}
+		start, end := time.Unix(0, int64(span.StartTimeUnixNano)), time.Unix(0, int64(span.EndTimeUnixNano))
+		config.SoftLogIfErr(config.PushJobMetrics(cmd.Context(), job, end.Sub(start), Diag.ExecExitCode, end))
+	}
+
+	if config.Statsd != "" {
+		config.SoftLogIfErr(config.SendStatsd(span))
+	}
+
 	config.PropagateTraceparent(span, os.Stdout)
+
+	if config.Json {
+		config.PrintJsonResult(ctx, span, sendErr, os.Stdout)
+		if sendErr != nil {
+			config.SoftFail("unable to send span: %s", sendErr)
+		}
+	}
+
+	return span
 }
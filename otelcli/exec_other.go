@@ -0,0 +1,41 @@
+//go:build !windows
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// execProcessTree is the command otel-cli exec runs and the processes it
+// starts. Only the command itself is signaled and killed here, it's in the
+// shell's process group so the terminal already delivers ctrl-c to the rest.
+type execProcessTree struct {
+	child *exec.Cmd
+}
+
+// execSysProcAttr leaves the command in otel-cli's process group and session
+// so job control works the same as when it runs on its own.
+func execSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// track starts tracking the processes the command starts, which there's no
+// need for here.
+func (t *execProcessTree) track() error {
+	return nil
+}
+
+// interrupt forwards sig to the command and returns its name for the span event.
+func (t *execProcessTree) interrupt(sig os.Signal) (string, error) {
+	return sig.String(), t.child.Process.Signal(sig)
+}
+
+// kill kills the command.
+func (t *execProcessTree) kill() error {
+	return t.child.Process.Kill()
+}
+
+// close releases what track set up.
+func (t *execProcessTree) close() {}
@@ -0,0 +1,55 @@
+//go:build !windows
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExecProcessTree(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		stop func(tree *execProcessTree) error
+		want string
+	}{
+		{
+			name: "interrupt",
+			stop: func(tree *execProcessTree) error {
+				name, err := tree.interrupt(os.Interrupt)
+				if name != "interrupt" {
+					t.Errorf("expected the signal to be named interrupt but got %q", name)
+				}
+				return err
+			},
+			want: "signal: interrupt",
+		},
+		{
+			name: "kill",
+			stop: func(tree *execProcessTree) error { return tree.kill() },
+			want: "signal: killed",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			child := exec.Command("sleep", "5")
+			child.SysProcAttr = execSysProcAttr()
+			tree := &execProcessTree{child: child}
+			if err := child.Start(); err != nil {
+				t.Fatalf("failed to start sleep: %s", err)
+			}
+			defer tree.close()
+			if err := tree.track(); err != nil {
+				t.Fatalf("failed to track the process: %s", err)
+			}
+
+			if err := tc.stop(tree); err != nil {
+				t.Fatalf("failed to stop the process: %s", err)
+			}
+			if err := child.Wait(); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected the process to exit with %q but got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// execProcessTree is the command otel-cli exec runs and the processes it
+// starts. Windows has no signals to forward and killing a process leaves its
+// children running, so the command is started in its own console process
+// group to send it CTRL_BREAK_EVENT, and put in a Job Object, which the
+// processes it starts join too, to terminate all of them at once.
+type execProcessTree struct {
+	child *exec.Cmd
+
+	mu  sync.Mutex
+	job windows.Handle
+}
+
+// execSysProcAttr starts the command as the root of a new console process
+// group so CTRL_BREAK_EVENT can be sent to it and its children.
+func execSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// track puts the started command in a new Job Object. Processes it starts
+// from then on join the job automatically, so only those started in the
+// moment before it's assigned can escape. On error, kill falls back to
+// killing the command alone.
+func (t *execProcessTree) track() error {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return err
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(t.child.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return err
+	}
+	defer windows.CloseHandle(process)
+
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.job = job
+	return nil
+}
+
+// interrupt sends CTRL_BREAK_EVENT to the command's process group, which is
+// what ctrl-c is forwarded as since CTRL_C_EVENT can't be sent to a group. It
+// returns the event's name for the span event.
+func (t *execProcessTree) interrupt(os.Signal) (string, error) {
+	return "CTRL_BREAK_EVENT", windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(t.child.Process.Pid))
+}
+
+// kill terminates every process in the job, or the command alone when it
+// couldn't be tracked.
+func (t *execProcessTree) kill() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.job == 0 {
+		return t.child.Process.Kill()
+	}
+	return windows.TerminateJobObject(t.job, 1)
+}
+
+// close releases the Job Object. Processes still running in it are left
+// alone, same as a command's background processes elsewhere.
+func (t *execProcessTree) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.job != 0 {
+		windows.CloseHandle(t.job)
+		t.job = 0
+	}
+}
@@ -0,0 +1,76 @@
+package otelcli
+
+import (
+	"context"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+)
+
+// flushCmd represents the flush command
+func flushCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "flush",
+		Short: "send spans that were spooled to disk when export failed",
+		Long: `When --spool-dir is set, spans that fail to export are written to that
+directory instead of being dropped. flush sends the spooled spans to the
+configured endpoint, oldest first, and removes each one once it's accepted.
+Flushing stops at the first failure so the rest can be tried again later.
+
+Example:
+	export OTEL_CLI_SPOOL_DIR=$HOME/.cache/otel-cli/spool
+	otel-cli exec --name "sync" -- rsync -a src/ dest/
+	# later, once the network is back
+	otel-cli flush --endpoint localhost:4317
+`,
+		Run: doFlush,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doFlush(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if config.SpoolDir == "" {
+		config.SoftFail("a spool directory is required, set --spool-dir or OTEL_CLI_SPOOL_DIR")
+	} else if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to flush spooled spans")
+	}
+
+	files, err := otlpclient.ListSpool(config.SpoolDir)
+	config.SoftFailIfErr(err)
+	if len(files) == 0 {
+		return
+	}
+
+	key := config.GetSpoolKey()
+	ctx, client := StartClient(ctx, config)
+
+	var flushed int
+	for _, file := range files {
+		// each file gets the full --timeout, same as a single span would
+		fileCtx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+		_, err = otlpclient.FlushSpoolFile(fileCtx, client, file, key)
+		cancel()
+		if err != nil {
+			break
+		}
+		flushed++
+	}
+
+	_, serr := client.Stop(ctx)
+	config.SoftLogIfErr(serr)
+
+	if err != nil {
+		config.SoftFail("flushed %d of %d spooled requests, stopped on error: %s", flushed, len(files), err)
+	}
+	config.SoftLog("flushed %d spooled requests from %s", flushed, config.SpoolDir)
+}
@@ -0,0 +1,361 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// generate holds the command-line configured settings for otel-cli generate.
+var generate struct {
+	traces        int
+	depth         int
+	spansPerTrace int
+	rate          string
+	errorRate     float64
+	concurrency   int
+	seed          int64
+}
+
+// generateSpanKind is a kind of operation generated traces are made of.
+type generateSpanKind struct {
+	kind  tracepb.Span_SpanKind
+	names []string
+	attrs func(name string) map[string]string
+}
+
+// generateRoots are the operations traces start with.
+var generateRoots = generateSpanKind{
+	kind:  tracepb.Span_SPAN_KIND_SERVER,
+	names: []string{"GET /api/users", "GET /api/orders", "POST /api/orders", "GET /api/products", "PUT /api/cart"},
+	attrs: generateHttpAttrs,
+}
+
+// generateChildren are the operations under the root, picked at random.
+var generateChildren = []generateSpanKind{
+	{
+		kind:  tracepb.Span_SPAN_KIND_INTERNAL,
+		names: []string{"validate request", "render response", "apply discounts", "check permissions"},
+		attrs: func(string) map[string]string { return map[string]string{} },
+	},
+	{
+		kind:  tracepb.Span_SPAN_KIND_CLIENT,
+		names: []string{"GET /inventory", "GET /pricing", "POST /payments"},
+		attrs: generateHttpAttrs,
+	},
+	{
+		kind:  tracepb.Span_SPAN_KIND_CLIENT,
+		names: []string{"SELECT users", "SELECT orders", "INSERT orders", "UPDATE carts"},
+		attrs: func(name string) map[string]string {
+			op, table, _ := strings.Cut(name, " ")
+			return map[string]string{
+				"db.system":          "postgresql",
+				"db.operation.name":  op,
+				"db.collection.name": table,
+			}
+		},
+	},
+	{
+		kind:  tracepb.Span_SPAN_KIND_PRODUCER,
+		names: []string{"orders publish", "emails publish"},
+		attrs: func(name string) map[string]string {
+			topic, _, _ := strings.Cut(name, " ")
+			return map[string]string{
+				"messaging.system":           "kafka",
+				"messaging.operation.type":   "publish",
+				"messaging.destination.name": topic,
+			}
+		},
+	},
+}
+
+func generateCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "generate",
+		Short: "send synthetic traces for load testing",
+		Long: `Sends randomized traces that look like a web service's, for sizing
+collectors and testing pipelines without writing a load tool. Each trace has
+a server span at the root and --spans-per-trace spans in all, nested up to
+--depth deep, made of internal work, HTTP and database client calls, and
+message publishing, with the usual semantic convention attributes. Spans fail
+with an exception event at --error-rate, and --attrs are added to all of them.
+
+Traces are sent at --rate, e.g. 50/s or 600/m, by --concurrency senders, as
+fast as they can go when --rate isn't set. A summary of what was sent is
+printed at the end. --seed makes the traces' shapes repeatable.
+
+Example:
+	otel-cli generate --traces 100 --depth 4 --spans-per-trace 20 --rate 50/s
+	otel-cli generate --traces 10000 --error-rate 0.05 --attrs load.test=true
+`,
+		Args: cobra.NoArgs,
+		Run:  doGenerate,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().IntVar(&generate.traces, "traces", 100, "the number of traces to send")
+	cmd.Flags().IntVar(&generate.depth, "depth", 4, "the most levels of spans in a trace, counting the root")
+	cmd.Flags().IntVar(&generate.spansPerTrace, "spans-per-trace", 20, "the number of spans in each trace")
+	cmd.Flags().StringVar(&generate.rate, "rate", "", "traces to send per second or other unit, e.g. 50/s or 600/m, unlimited by default")
+	cmd.Flags().Float64Var(&generate.errorRate, "error-rate", 0.01, "the fraction of spans that fail, from 0 to 1")
+	cmd.Flags().IntVar(&generate.concurrency, "concurrency", 4, "the number of traces to send at once")
+	cmd.Flags().Int64Var(&generate.seed, "seed", 0, "seed for the random trace shapes, random by default")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+
+	return &cmd
+}
+
+func doGenerate(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	interval, err := parseGenerateRate(generate.rate)
+	if err != nil {
+		config.SoftFail("invalid --rate: %s", err)
+	}
+	switch {
+	case generate.traces < 1:
+		config.SoftFail("--traces must be at least 1")
+	case generate.spansPerTrace < 1:
+		config.SoftFail("--spans-per-trace must be at least 1")
+	case generate.depth < 1:
+		config.SoftFail("--depth must be at least 1")
+	case generate.depth == 1 && generate.spansPerTrace > 1:
+		config.SoftFail("--depth must be at least 2 for more than one span per trace")
+	case generate.errorRate < 0 || generate.errorRate > 1:
+		config.SoftFail("--error-rate must be from 0 to 1")
+	case generate.concurrency < 1:
+		config.SoftFail("--concurrency must be at least 1")
+	}
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to send generated traces to")
+	}
+
+	seed := generate.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	ctx, client := StartClient(ctx, config)
+	summary := generateSummary{}
+	traces := make(chan []*tracepb.Span)
+	wg := sync.WaitGroup{}
+	for i := 0; i < generate.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for spans := range traces {
+				// each trace gets the full --timeout, same as a single span would
+				sendCtx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+				_, err := otlpclient.SendSpans(sendCtx, client, config, spans)
+				cancel()
+				config.SoftLogIfErr(err)
+				summary.add(spans, err)
+			}
+		}()
+	}
+
+	start := time.Now()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for i := 0; i < generate.traces; i++ {
+		// the first trace goes right away, the rest wait for the ticker
+		if tick != nil && i > 0 {
+			<-tick
+		}
+		traces <- config.generateTrace(rng, time.Now(), generate.spansPerTrace, generate.depth, generate.errorRate)
+	}
+	close(traces)
+	wg.Wait()
+
+	_, err = client.Stop(ctx)
+	config.SoftLogIfErr(err)
+
+	summary.write(os.Stdout, time.Since(start))
+	if summary.failed > 0 {
+		config.SoftFail("failed to send %d of %d traces", summary.failed, generate.traces)
+	}
+}
+
+// parseGenerateRate parses a --rate like 50/s, 600/m, or 50, which is per
+// second, into the time between traces. Empty means unlimited, which is 0.
+func parseGenerateRate(rate string) (time.Duration, error) {
+	if rate == "" {
+		return 0, nil
+	}
+
+	count, unit, found := strings.Cut(rate, "/")
+	per := time.Second
+	if found {
+		// a bare unit like s or m is one of it
+		if unit != "" && strings.IndexAny(unit[:1], "0123456789") == -1 {
+			unit = "1" + unit
+		}
+		var err error
+		if per, err = time.ParseDuration(unit); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a number of traces", count)
+	}
+	if n <= 0 || per <= 0 {
+		return 0, fmt.Errorf("the rate must be greater than zero")
+	}
+	return time.Duration(float64(per) / n), nil
+}
+
+// generateTrace returns a random trace of count spans, root first, nested
+// up to depth levels and starting at start. The first spans go straight
+// down so the trace reaches depth when it has enough spans, the rest are
+// hung off random spans that aren't at the bottom already.
+func (c Config) generateTrace(rng *rand.Rand, start time.Time, count, depth int, errorRate float64) []*tracepb.Span {
+	traceId := otlpclient.GenerateTraceId()
+	spans := make([]*tracepb.Span, count)
+	levels := make([]int, count)
+	parents := make([]int, count)
+
+	for i := range spans {
+		kind := generateRoots
+		if i > 0 {
+			kind = generateChildren[rng.Intn(len(generateChildren))]
+			if i < depth {
+				parents[i] = i - 1
+			} else {
+				parents[i] = rng.Intn(i)
+				for levels[parents[i]] >= depth-1 {
+					parents[i] = rng.Intn(i)
+				}
+			}
+			levels[i] = levels[parents[i]] + 1
+		}
+		spans[i] = c.generateSpan(rng, traceId, kind, errorRate)
+		if i > 0 {
+			spans[i].ParentSpanId = spans[parents[i]].SpanId
+		}
+	}
+
+	// the root takes from 10ms to 2s, log-uniformly so most are quick, and
+	// every child starts and ends within its parent
+	rootDuration := time.Duration(10e6 * math.Pow(200, rng.Float64()))
+	spans[0].StartTimeUnixNano = uint64(start.UnixNano())
+	spans[0].EndTimeUnixNano = uint64(start.Add(rootDuration).UnixNano())
+	for i := 1; i < count; i++ {
+		parent := spans[parents[i]]
+		parentDuration := float64(parent.EndTimeUnixNano - parent.StartTimeUnixNano)
+		offset := uint64(rng.Float64() * 0.5 * parentDuration)
+		duration := uint64(rng.Float64() * (parentDuration - float64(offset)))
+		spans[i].StartTimeUnixNano = parent.StartTimeUnixNano + offset
+		spans[i].EndTimeUnixNano = spans[i].StartTimeUnixNano + duration
+	}
+	for _, span := range spans {
+		for _, event := range span.Events {
+			event.TimeUnixNano = span.EndTimeUnixNano
+		}
+	}
+
+	return spans
+}
+
+// generateSpan returns a span of the kind with a random name from it, its
+// attributes plus --attrs, and failing at errorRate.
+func (c Config) generateSpan(rng *rand.Rand, traceId []byte, kind generateSpanKind, errorRate float64) *tracepb.Span {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = traceId
+	span.SpanId = otlpclient.GenerateSpanId()
+	span.Kind = kind.kind
+	span.Name = kind.names[rng.Intn(len(kind.names))]
+
+	attrs := kind.attrs(span.Name)
+	failed := rng.Float64() < errorRate
+	if failed {
+		if _, ok := attrs["http.response.status_code"]; ok {
+			attrs["http.response.status_code"] = "500"
+		}
+		attrs["error.type"] = "GeneratedError"
+		otlpclient.SetSpanStatus(span, "error", "generated failure")
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = "exception"
+		event.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{
+			"exception.type":    "GeneratedError",
+			"exception.message": fmt.Sprintf("%s failed", span.Name),
+		})
+		span.Events = []*tracepb.Span_Event{event}
+	}
+
+	span.Attributes = append(otlpclient.StringMapAttrsToProtobuf(attrs), c.GetAttributes()...)
+	return span
+}
+
+// generateHttpAttrs returns HTTP semantic convention attributes for an
+// operation named like "GET /api/users".
+func generateHttpAttrs(name string) map[string]string {
+	method, route, _ := strings.Cut(name, " ")
+	return map[string]string{
+		"http.request.method":       method,
+		"http.route":                route,
+		"http.response.status_code": "200",
+	}
+}
+
+// generateSummary counts what generate sent, from all of its senders.
+type generateSummary struct {
+	mu     sync.Mutex
+	sent   int
+	failed int
+	spans  int
+	errors int
+}
+
+// add counts a trace that was sent, or failed to send when err isn't nil.
+func (gs *generateSummary) add(spans []*tracepb.Span, err error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if err != nil {
+		gs.failed++
+		return
+	}
+	gs.sent++
+	gs.spans += len(spans)
+	for _, span := range spans {
+		if span.Status.GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+			gs.errors++
+		}
+	}
+}
+
+// write prints the summary of a run that took elapsed.
+func (gs *generateSummary) write(w io.Writer, elapsed time.Duration) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	secs := elapsed.Seconds()
+	fmt.Fprintf(w, "sent %d traces with %d spans, %d of them errors, in %s: %.1f traces/s, %.1f spans/s\n",
+		gs.sent, gs.spans, gs.errors, elapsed.Round(time.Millisecond), float64(gs.sent)/secs, float64(gs.spans)/secs)
+	if gs.failed > 0 {
+		fmt.Fprintf(w, "failed to send %d traces\n", gs.failed)
+	}
+}
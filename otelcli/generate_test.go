@@ -0,0 +1,121 @@
+package otelcli
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestParseGenerateRate(t *testing.T) {
+	for rate, want := range map[string]time.Duration{
+		"":        0,
+		"50/s":    20 * time.Millisecond,
+		"50":      20 * time.Millisecond,
+		"600/m":   100 * time.Millisecond,
+		"1/10s":   10 * time.Second,
+		"0.5/s":   2 * time.Second,
+		"2/500ms": 250 * time.Millisecond,
+	} {
+		got, err := parseGenerateRate(rate)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", rate, err)
+		} else if got != want {
+			t.Errorf("%q: expected %s but got %s", rate, want, got)
+		}
+	}
+
+	for _, rate := range []string{"fast", "0/s", "-5/s", "50/fortnight", "50/0s"} {
+		if _, err := parseGenerateRate(rate); err == nil {
+			t.Errorf("%q: expected an error", rate)
+		}
+	}
+}
+
+func TestGenerateTrace(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	config := DefaultConfig().WithAttributes(map[string]string{"load.test": "true"})
+	start := time.Now()
+
+	for n := 0; n < 100; n++ {
+		spans := config.generateTrace(rng, start, 20, 4, 0)
+		if len(spans) != 20 {
+			t.Fatalf("expected 20 spans but got %d", len(spans))
+		}
+
+		root := spans[0]
+		if len(root.ParentSpanId) != 0 || root.Kind != tracepb.Span_SPAN_KIND_SERVER {
+			t.Fatalf("expected the first span to be a server root span but got %v", root)
+		}
+		if root.StartTimeUnixNano != uint64(start.UnixNano()) {
+			t.Errorf("expected the root to start at %d but got %d", start.UnixNano(), root.StartTimeUnixNano)
+		}
+
+		byId := map[string]*tracepb.Span{}
+		levels := map[string]int{}
+		deepest := 0
+		for _, span := range spans {
+			if string(span.TraceId) != string(root.TraceId) {
+				t.Fatalf("expected all spans in one trace")
+			}
+			if span.Status.GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+				t.Errorf("expected no errors at --error-rate 0")
+			}
+			if len(span.ParentSpanId) != 0 {
+				parent, ok := byId[string(span.ParentSpanId)]
+				if !ok {
+					t.Fatalf("expected span %q's parent to come before it", span.Name)
+				}
+				if span.StartTimeUnixNano < parent.StartTimeUnixNano || span.EndTimeUnixNano > parent.EndTimeUnixNano {
+					t.Errorf("expected span %q to be within its parent %q", span.Name, parent.Name)
+				}
+				levels[string(span.SpanId)] = levels[string(span.ParentSpanId)] + 1
+			}
+			if levels[string(span.SpanId)] > deepest {
+				deepest = levels[string(span.SpanId)]
+			}
+			byId[string(span.SpanId)] = span
+
+			found := false
+			for _, attr := range span.Attributes {
+				found = found || (attr.Key == "load.test" && attr.Value.GetBoolValue())
+			}
+			if !found {
+				t.Errorf("expected --attrs on span %q", span.Name)
+			}
+		}
+		if deepest != 3 {
+			t.Errorf("expected the trace to be exactly 4 levels deep but got %d", deepest+1)
+		}
+	}
+}
+
+func TestGenerateTraceErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, span := range DefaultConfig().generateTrace(rng, time.Now(), 10, 3, 1) {
+		if span.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+			t.Errorf("expected span %q to fail at --error-rate 1", span.Name)
+		}
+		if len(span.Events) != 1 || span.Events[0].Name != "exception" || span.Events[0].TimeUnixNano != span.EndTimeUnixNano {
+			t.Errorf("expected span %q to have an exception event at its end but got %v", span.Name, span.Events)
+		}
+	}
+}
+
+func TestGenerateSummary(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gs := generateSummary{}
+	gs.add(DefaultConfig().generateTrace(rng, time.Now(), 5, 2, 0), nil)
+	gs.add(DefaultConfig().generateTrace(rng, time.Now(), 5, 2, 1), nil)
+	gs.add(DefaultConfig().generateTrace(rng, time.Now(), 5, 2, 0), errors.New("nope"))
+
+	out := bytes.Buffer{}
+	gs.write(&out, 2*time.Second)
+	want := "sent 2 traces with 10 spans, 5 of them errors, in 2s: 1.0 traces/s, 5.0 spans/s\nfailed to send 1 traces\n"
+	if out.String() != want {
+		t.Errorf("expected %q but got %q", want, out.String())
+	}
+}
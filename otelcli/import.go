@@ -0,0 +1,150 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func importCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "import",
+		Short: "convert traces from other systems into spans and send them",
+		Long:  "Build spans from data recorded by other tools and send them to the configured endpoint. See subcommands.",
+	}
+
+	cmd.AddCommand(importGithubWorkflowCmd(config))
+	cmd.AddCommand(importJunitCmd(config))
+	cmd.AddCommand(importBuildkitCmd(config))
+	cmd.AddCommand(importAccessLogCmd(config))
+	cmd.AddCommand(importZipkinCmd(config))
+	cmd.AddCommand(importJaegerCmd(config))
+
+	return &cmd
+}
+
+// importAttrs converts the attributes to protobuf, leaving out empty
+// values and keeping ids and numbers as strings.
+func importAttrs(attrs map[string]string) []*commonpb.KeyValue {
+	out := []*commonpb.KeyValue{}
+	for key, value := range attrs {
+		if value == "" {
+			continue
+		}
+		out = append(out, &commonpb.KeyValue{
+			Key:   key,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// importParent returns the trace id and parent span id for imported spans
+// from TRACEPARENT or --tp-carrier, or a new trace id and no parent. The
+// all-zero traceparent LoadTraceparent returns when there is none counts as
+// none.
+func importParent(config Config) ([]byte, []byte) {
+	if tp := config.LoadTraceparent(); tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		return tp.TraceId, tp.SpanId
+	}
+	return otlpclient.GenerateTraceId(), []byte{}
+}
+
+// addImportParentAttrs adds the tracestate and --attrs to imported spans
+// that are parented with importParent.
+func addImportParentAttrs(config Config, spans []*tracepb.Span) {
+	attrs := config.GetAttributes()
+	tracestate := config.LoadCarrier().Tracestate
+	for _, span := range spans {
+		span.TraceState = tracestate
+		span.Attributes = append(span.Attributes, attrs...)
+	}
+}
+
+// sendImportSpans sends the imported spans in one request with --timeout
+// starting now, since reading the input may have taken a while.
+func sendImportSpans(ctx context.Context, config Config, spans []*tracepb.Span) {
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	ctx, client := StartClient(ctx, config)
+	ctx, err := otlpclient.SendSpans(ctx, client, config, spans)
+	config.SoftFailIfErr(err)
+	_, err = client.Stop(ctx)
+	config.SoftFailIfErr(err)
+}
+
+// importedSpan is a span migrated from another tracing system, along with
+// the resource and instrumentation scope it belongs to.
+type importedSpan struct {
+	resource     []*commonpb.KeyValue
+	scope        string
+	scopeVersion string
+	span         *tracepb.Span
+}
+
+// importResourceSpans groups the spans by their resource and scope, keeping
+// the order they first appeared in.
+func importResourceSpans(spans []importedSpan) []*tracepb.ResourceSpans {
+	out := []*tracepb.ResourceSpans{}
+	byResource := map[string]*tracepb.ResourceSpans{}
+	byScope := map[string]*tracepb.ScopeSpans{}
+	for _, is := range spans {
+		key := fmt.Sprint(is.resource)
+		rs, ok := byResource[key]
+		if !ok {
+			rs = &tracepb.ResourceSpans{Resource: &resourcepb.Resource{Attributes: is.resource}}
+			byResource[key] = rs
+			out = append(out, rs)
+		}
+
+		scopeKey := key + "\x00" + is.scope + "\x00" + is.scopeVersion
+		ss, ok := byScope[scopeKey]
+		if !ok {
+			ss = &tracepb.ScopeSpans{Scope: &commonpb.InstrumentationScope{Name: is.scope, Version: is.scopeVersion}}
+			byScope[scopeKey] = ss
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, is.span)
+	}
+	return out
+}
+
+// uploadImportResourceSpans sends migrated spans as they are, with their own
+// resources rather than otel-cli's, with --timeout starting now.
+func uploadImportResourceSpans(ctx context.Context, config Config, rsps []*tracepb.ResourceSpans) {
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	ctx, client := StartClient(ctx, config)
+	ctx, err := client.UploadTraces(ctx, rsps)
+	config.SoftFailIfErr(err)
+	_, err = client.Stop(ctx)
+	config.SoftFailIfErr(err)
+}
+
+// importId decodes a hex trace or span id, left padding it with zeroes to
+// size bytes, since Zipkin and Jaeger have 64 bit trace ids and drop leading
+// zeroes.
+func importId(in string, size int) ([]byte, error) {
+	in = strings.TrimPrefix(strings.ToLower(in), "0x")
+	if len(in) > size*2 {
+		return nil, fmt.Errorf("id %q is longer than %d bytes", in, size)
+	}
+	id, err := hex.DecodeString(strings.Repeat("0", size*2-len(in)) + in)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %q: %w", in, err)
+	}
+	return id, nil
+}
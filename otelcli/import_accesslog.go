@@ -0,0 +1,364 @@
+package otelcli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// accessLogImport holds the command-line configured settings for otel-cli import accesslog.
+var accessLogImport struct {
+	format string
+}
+
+// accessLogBatchSize is how many spans are sent in each request, so big logs
+// don't end up in one huge request.
+const accessLogBatchSize = 1000
+
+// accessLogCommonRe matches the Common Log Format, optionally followed by the
+// referer and user agent of the Combined Log Format, e.g.
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://example.com/" "Mozilla/4.08"
+var accessLogCommonRe = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\S+)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+// accessLogRequest is what otel-cli uses from an access log line.
+type accessLogRequest struct {
+	Time      time.Time
+	Duration  time.Duration
+	Client    string
+	User      string
+	Method    string
+	Target    string
+	Protocol  string
+	Status    int
+	Bytes     int64
+	Referer   string
+	UserAgent string
+	Host      string
+}
+
+func importAccessLogCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "accesslog [file...]",
+		Short: "trace requests from web server access logs",
+		Long: `Read web server access logs and send a server span for each request with the
+HTTP semantic convention attributes, at the time it was logged, so requests
+to servers that aren't instrumented can still be looked at as traces.
+
+--format is one of:
+	common    the Common Log Format
+	combined  the Combined Log Format, which adds the referer and user agent
+	json      one JSON object per line with nginx-style fields, e.g. from
+	          log_format json escape=json, see below
+
+Servers log requests when they're done, so the time in the log is taken as
+the end of the request. The common and combined formats don't have the
+request's duration, so their spans are zero length. JSON logs can have it in
+request_time or duration, in seconds. Requests with a 5xx status get an
+error status.
+
+JSON logs are read from these fields, the first one found wins:
+	time          time_iso8601, time_local, @timestamp, timestamp, time, ts
+	duration      request_time, duration
+	method        request_method, method
+	path          request_uri, uri, path, or from request
+	status        status
+	bytes         body_bytes_sent, bytes_sent, size, bytes
+	client        remote_addr, client_ip, remote_ip
+	user agent    http_user_agent, user_agent
+	referer       http_referer, referer
+	host          host, server_name
+
+Each request is its own trace, unless TRACEPARENT or --tp-carrier is set, then
+they're all children of it. Logs are read from the files, or stdin when there
+aren't any or one is -.
+
+Example:
+	otel-cli import accesslog --format combined < /var/log/nginx/access.log
+`,
+		Run: doImportAccessLog,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&accessLogImport.format, "format", "combined", "the log format, one of common, combined, or json")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+
+	return &cmd
+}
+
+func doImportAccessLog(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to import access logs to")
+	}
+
+	var parse func(string) (accessLogRequest, error)
+	switch accessLogImport.format {
+	case "common", "combined":
+		parse = parseAccessLogLine
+	case "json":
+		parse = parseAccessLogJson
+	default:
+		config.SoftFail("invalid --format %q, must be one of common, combined, or json", accessLogImport.format)
+	}
+
+	// only one parent for all the requests when there's a traceparent to use
+	var traceId, parentId []byte
+	if tp := config.LoadTraceparent(); tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		traceId, parentId = tp.TraceId, tp.SpanId
+	}
+
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	var sent, skipped int
+	spans := []*tracepb.Span{}
+	send := func() {
+		if len(spans) == 0 {
+			return
+		}
+		addImportParentAttrs(config, spans)
+		sendImportSpans(ctx, config, spans)
+		sent += len(spans)
+		spans = []*tracepb.Span{}
+	}
+
+	for _, path := range args {
+		in := io.Reader(os.Stdin)
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				config.SoftFail("%s", err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			req, err := parse(line)
+			if err != nil {
+				config.SoftLog("skipping %s line %d: %s", path, lineNo, err)
+				skipped++
+				continue
+			}
+
+			spans = append(spans, req.span(traceId, parentId))
+			if len(spans) >= accessLogBatchSize {
+				send()
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			config.SoftFail("failed to read %s: %s", path, err)
+		}
+	}
+	send()
+
+	config.SoftLog("imported %d requests, skipped %d lines", sent, skipped)
+}
+
+// parseAccessLogLine parses a line in the Common or Combined Log Format.
+func parseAccessLogLine(line string) (accessLogRequest, error) {
+	m := accessLogCommonRe.FindStringSubmatch(line)
+	if m == nil {
+		return accessLogRequest{}, fmt.Errorf("not in the common or combined log format")
+	}
+
+	ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[3])
+	if err != nil {
+		return accessLogRequest{}, fmt.Errorf("invalid time %q", m[3])
+	}
+
+	req := accessLogRequest{
+		Time:      ts,
+		Client:    m[1],
+		User:      accessLogField(m[2]),
+		Referer:   accessLogField(unescapeAccessLog(m[7])),
+		UserAgent: accessLogField(unescapeAccessLog(m[8])),
+	}
+	req.Method, req.Target, req.Protocol = splitRequestLine(unescapeAccessLog(m[4]))
+	req.Status, _ = strconv.Atoi(m[5])
+	req.Bytes, _ = strconv.ParseInt(m[6], 10, 64)
+
+	return req, nil
+}
+
+// parseAccessLogJson parses a line of JSON with nginx-style field names,
+// accepting a few other common names for each.
+func parseAccessLogJson(line string) (accessLogRequest, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return accessLogRequest{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	get := func(names ...string) string {
+		for _, name := range names {
+			switch v := fields[name].(type) {
+			case string:
+				if v != "" && v != "-" {
+					return v
+				}
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+		return ""
+	}
+
+	ts, err := parseAccessLogTime(get("time_iso8601", "time_local", "@timestamp", "timestamp", "time", "ts"))
+	if err != nil {
+		return accessLogRequest{}, err
+	}
+
+	req := accessLogRequest{
+		Time:      ts,
+		Client:    get("remote_addr", "client_ip", "remote_ip"),
+		User:      get("remote_user", "user"),
+		Method:    get("request_method", "method"),
+		Target:    get("request_uri", "uri", "path"),
+		Protocol:  get("server_protocol", "protocol", "proto"),
+		Referer:   get("http_referer", "referer"),
+		UserAgent: get("http_user_agent", "user_agent"),
+		Host:      get("host", "server_name"),
+	}
+	if request := get("request"); request != "" {
+		method, target, protocol := splitRequestLine(request)
+		if req.Method == "" {
+			req.Method = method
+		}
+		if req.Target == "" {
+			req.Target = target
+		}
+		if req.Protocol == "" {
+			req.Protocol = protocol
+		}
+	}
+
+	if req.Status, err = strconv.Atoi(get("status")); err != nil {
+		return accessLogRequest{}, fmt.Errorf("missing or invalid status")
+	}
+	req.Bytes, _ = strconv.ParseInt(get("body_bytes_sent", "bytes_sent", "size", "bytes"), 10, 64)
+	if secs, err := strconv.ParseFloat(get("request_time", "duration"), 64); err == nil && secs > 0 {
+		req.Duration = time.Duration(secs * float64(time.Second))
+	}
+
+	return req, nil
+}
+
+// parseAccessLogTime parses RFC3339, the CLF time format, or a Unix epoch
+// in seconds.
+func parseAccessLogTime(in string) (time.Time, error) {
+	if in == "" {
+		return time.Time{}, fmt.Errorf("missing time")
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, in); err == nil {
+		return ts, nil
+	}
+	if ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", in); err == nil {
+		return ts, nil
+	}
+	if secs, err := strconv.ParseFloat(in, 64); err == nil {
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(frac*1e9)), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", in)
+}
+
+// splitRequestLine splits e.g. "GET /index.html HTTP/1.1" into its parts,
+// leaving them empty when the line doesn't look like a request, which
+// happens with junk sent to the server.
+func splitRequestLine(line string) (string, string, string) {
+	parts := strings.Fields(line)
+	switch len(parts) {
+	case 3:
+		return parts[0], parts[1], parts[2]
+	case 2:
+		return parts[0], parts[1], ""
+	}
+	return "", "", ""
+}
+
+// accessLogField returns the value, or "" for the - logs use for no value.
+func accessLogField(in string) string {
+	if in == "-" {
+		return ""
+	}
+	return in
+}
+
+// unescapeAccessLog undoes the escaping of quotes and backslashes servers
+// do in quoted fields.
+func unescapeAccessLog(in string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(in)
+}
+
+// span returns a server span for the request. Without a traceId, the span
+// is the root of its own trace.
+func (req accessLogRequest) span(traceId, parentId []byte) *tracepb.Span {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = traceId
+	span.ParentSpanId = parentId
+	if len(traceId) == 0 {
+		span.TraceId = otlpclient.GenerateTraceId()
+		span.ParentSpanId = []byte{}
+	}
+	span.SpanId = otlpclient.GenerateSpanId()
+	span.Kind = tracepb.Span_SPAN_KIND_SERVER
+	span.StartTimeUnixNano = uint64(req.Time.Add(-req.Duration).UnixNano())
+	span.EndTimeUnixNano = uint64(req.Time.UnixNano())
+
+	// the semantic conventions use HTTP when the method isn't known
+	span.Name = req.Method
+	if span.Name == "" {
+		span.Name = "HTTP"
+	}
+
+	path, query, _ := strings.Cut(req.Target, "?")
+	attrs := map[string]string{
+		"http.request.method":         req.Method,
+		"url.path":                    path,
+		"url.query":                   query,
+		"client.address":              req.Client,
+		"enduser.id":                  req.User,
+		"user_agent.original":         req.UserAgent,
+		"http.request.header.referer": req.Referer,
+		"server.address":              req.Host,
+	}
+	if version, ok := strings.CutPrefix(req.Protocol, "HTTP/"); ok {
+		attrs["network.protocol.version"] = version
+	}
+	if req.Status >= 500 {
+		attrs["error.type"] = strconv.Itoa(req.Status)
+		otlpclient.SetSpanStatus(span, "error", "")
+	}
+	span.Attributes = append(importAttrs(attrs),
+		&commonpb.KeyValue{Key: "http.response.status_code", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(req.Status)}}},
+		&commonpb.KeyValue{Key: "http.response.body.size", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: req.Bytes}}},
+	)
+
+	return span
+}
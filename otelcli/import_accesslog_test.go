@@ -0,0 +1,153 @@
+package otelcli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestParseAccessLogLine(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want accessLogRequest
+	}{
+		{
+			line: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?x=1 HTTP/1.0" 200 2326`,
+			want: accessLogRequest{
+				Time:     time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC),
+				Client:   "127.0.0.1",
+				User:     "frank",
+				Method:   "GET",
+				Target:   "/apache_pb.gif?x=1",
+				Protocol: "HTTP/1.0",
+				Status:   200,
+				Bytes:    2326,
+			},
+		},
+		{
+			line: `10.1.2.3 - - [09/Mar/2024:14:30:00 +0000] "POST /api/\"quoted\" HTTP/1.1" 502 - "-" "curl/8.0 \"beta\""`,
+			want: accessLogRequest{
+				Time:      time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC),
+				Client:    "10.1.2.3",
+				Method:    "POST",
+				Target:    `/api/"quoted"`,
+				Protocol:  "HTTP/1.1",
+				Status:    502,
+				UserAgent: `curl/8.0 "beta"`,
+			},
+		},
+		{
+			line: `10.1.2.3 - - [09/Mar/2024:14:30:00 +0000] "\x16\x03\x01" 400 157 "-" "-"`,
+			want: accessLogRequest{
+				Time:   time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC),
+				Client: "10.1.2.3",
+				Status: 400,
+				Bytes:  157,
+			},
+		},
+	} {
+		got, err := parseAccessLogLine(tc.line)
+		if err != nil {
+			t.Errorf("failed to parse %q: %s", tc.line, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+			t.Errorf("request mismatch for %q (-want +got):\n%s", tc.line, diff)
+		}
+	}
+
+	if _, err := parseAccessLogLine("not an access log"); err == nil {
+		t.Error("expected an error for a line that isn't an access log")
+	}
+}
+
+func TestParseAccessLogJson(t *testing.T) {
+	line := `{"time_iso8601":"2024-03-09T14:30:01+00:00","remote_addr":"10.1.2.3","request":"GET /health?full=1 HTTP/2.0","status":"503","body_bytes_sent":"12","request_time":"0.250","http_user_agent":"kube-probe/1.29","http_referer":"","host":"app.example.com"}`
+	got, err := parseAccessLogJson(line)
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	want := accessLogRequest{
+		Time:      time.Date(2024, 3, 9, 14, 30, 1, 0, time.UTC),
+		Duration:  250 * time.Millisecond,
+		Client:    "10.1.2.3",
+		Method:    "GET",
+		Target:    "/health?full=1",
+		Protocol:  "HTTP/2.0",
+		Status:    503,
+		Bytes:     12,
+		UserAgent: "kube-probe/1.29",
+		Host:      "app.example.com",
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+
+	// numbers, unix times, and split out methods work too
+	got, err = parseAccessLogJson(`{"ts":1709994601.5,"method":"PUT","uri":"/x","status":201,"size":3,"duration":1.5}`)
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if got.Method != "PUT" || got.Target != "/x" || got.Status != 201 || got.Bytes != 3 ||
+		got.Duration != 1500*time.Millisecond || !got.Time.Equal(time.Unix(1709994601, 500000000)) {
+		t.Errorf("unexpected request %+v", got)
+	}
+
+	if _, err := parseAccessLogJson(`{"time":"2024-03-09T14:30:01Z"}`); err == nil {
+		t.Error("expected an error for a line without a status")
+	}
+}
+
+func TestAccessLogSpan(t *testing.T) {
+	end := time.Date(2024, 3, 9, 14, 30, 1, 0, time.UTC)
+	req := accessLogRequest{
+		Time:     end,
+		Duration: 250 * time.Millisecond,
+		Client:   "10.1.2.3",
+		Method:   "GET",
+		Target:   "/health?full=1",
+		Protocol: "HTTP/2.0",
+		Status:   503,
+		Bytes:    12,
+	}
+
+	span := req.span(nil, nil)
+	if len(span.TraceId) != 16 || len(span.ParentSpanId) != 0 {
+		t.Errorf("expected the span to start its own trace")
+	}
+	if span.Name != "GET" || span.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("unexpected span name %q and kind %s", span.Name, span.Kind)
+	}
+	if span.StartTimeUnixNano != uint64(end.Add(-250*time.Millisecond).UnixNano()) || span.EndTimeUnixNano != uint64(end.UnixNano()) {
+		t.Errorf("expected the span to end at the logged time")
+	}
+	if span.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("expected a 503 to be an error but got %s", span.Status.Code)
+	}
+
+	got := map[string]string{}
+	for _, attr := range span.Attributes {
+		got[attr.Key] = attr.Value.String()
+	}
+	want := map[string]string{
+		"client.address":            `string_value:"10.1.2.3"`,
+		"error.type":                `string_value:"503"`,
+		"http.request.method":       `string_value:"GET"`,
+		"http.response.body.size":   `int_value:12`,
+		"http.response.status_code": `int_value:503`,
+		"network.protocol.version":  `string_value:"2.0"`,
+		"url.path":                  `string_value:"/health"`,
+		"url.query":                 `string_value:"full=1"`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+	}
+
+	parentId := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	span = req.span([]byte("0123456789abcdef"), parentId)
+	if string(span.TraceId) != "0123456789abcdef" || string(span.ParentSpanId) != string(parentId) {
+		t.Errorf("expected the span to be parented to the traceparent")
+	}
+}
@@ -0,0 +1,240 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// buildkitStatus is the part of a BuildKit SolveStatus otel-cli uses. With
+// --progress rawjson, buildx writes one of these each time a build step
+// changes, so a step shows up once when it starts and again when it's done.
+type buildkitStatus struct {
+	Vertexes []buildkitVertex `json:"vertexes"`
+}
+
+// buildkitVertex is a build step, e.g. a Dockerfile instruction.
+type buildkitVertex struct {
+	Digest    string     `json:"digest"`
+	Inputs    []string   `json:"inputs"`
+	Name      string     `json:"name"`
+	Started   *time.Time `json:"started"`
+	Completed *time.Time `json:"completed"`
+	Cached    bool       `json:"cached"`
+	Error     string     `json:"error"`
+}
+
+func importBuildkitCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "buildkit [file]",
+		Short: "trace a docker build from BuildKit's rawjson progress output",
+		Long: `Read the progress of a docker build written with --progress rawjson and send
+a span for the build and a child span for each step it ran, parented to the
+traceparent from TRACEPARENT or --tp-carrier. Steps that were cached are
+marked with buildkit.cached=true and steps that failed get an error status.
+Each step links to the spans of the steps it depends on.
+
+The progress is read from the file, or stdin when there isn't one or it's -.
+buildx writes progress to stderr.
+
+Example:
+	docker buildx build --progress rawjson -t app . 2> build.json
+	otel-cli import buildkit build.json --name "build app"
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  doImportBuildkit,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVarP(&config.SpanName, "name", "n", defaults.SpanName, "set the name of the build span, defaults to docker build")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+
+	return &cmd
+}
+
+func doImportBuildkit(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to import the build to")
+	}
+
+	in := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	vertexes, err := readBuildkitProgress(in)
+	if err != nil {
+		config.SoftFail("%s", err)
+	}
+	if len(vertexes) == 0 {
+		config.SoftFail("no build steps found, was the build run with --progress rawjson?")
+	}
+
+	name := config.SpanName
+	if name == DefaultConfig().SpanName {
+		name = "docker build"
+	}
+	traceId, parentId := importParent(config)
+	spans := buildkitSpans(vertexes, name, traceId, parentId)
+
+	addImportParentAttrs(config, spans)
+	sendImportSpans(ctx, config, spans)
+
+	config.SoftLog("imported %d spans", len(spans))
+}
+
+// readBuildkitProgress reads the stream of statuses and returns each step
+// with the latest of what was reported about it, in the order they appeared.
+func readBuildkitProgress(in io.Reader) ([]buildkitVertex, error) {
+	byDigest := map[string]*buildkitVertex{}
+	vertexes := []*buildkitVertex{}
+
+	dec := json.NewDecoder(in)
+	for {
+		status := buildkitStatus{}
+		if err := dec.Decode(&status); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse BuildKit progress: %w", err)
+		}
+
+		for _, update := range status.Vertexes {
+			v, ok := byDigest[update.Digest]
+			if !ok {
+				v = &buildkitVertex{Digest: update.Digest}
+				byDigest[update.Digest] = v
+				vertexes = append(vertexes, v)
+			}
+			if update.Name != "" {
+				v.Name = update.Name
+			}
+			if len(update.Inputs) > 0 {
+				v.Inputs = update.Inputs
+			}
+			if update.Started != nil {
+				v.Started = update.Started
+			}
+			if update.Completed != nil {
+				v.Completed = update.Completed
+			}
+			if update.Error != "" {
+				v.Error = update.Error
+			}
+			v.Cached = v.Cached || update.Cached
+		}
+	}
+
+	out := []buildkitVertex{}
+	for _, v := range vertexes {
+		out = append(out, *v)
+	}
+	return out, nil
+}
+
+// buildkitSpans returns the build span followed by a span for each step
+// that started. Steps still running when the progress ends, e.g. because
+// the build was interrupted, end when the last step did.
+func buildkitSpans(vertexes []buildkitVertex, name string, traceId, parentId []byte) []*tracepb.Span {
+	var start, end time.Time
+	for _, v := range vertexes {
+		if v.Started != nil && (start.IsZero() || v.Started.Before(start)) {
+			start = *v.Started
+		}
+		if v.Completed != nil && v.Completed.After(end) {
+			end = *v.Completed
+		}
+	}
+	if end.Before(start) {
+		end = start
+	}
+
+	build := otlpclient.NewProtobufSpan()
+	build.TraceId = traceId
+	build.SpanId = otlpclient.GenerateSpanId()
+	build.ParentSpanId = parentId
+	build.Name = name
+	build.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+	build.StartTimeUnixNano = uint64(start.UnixNano())
+	build.EndTimeUnixNano = uint64(end.UnixNano())
+
+	steps := []*tracepb.Span{}
+	byDigest := map[string]*tracepb.Span{}
+	var cached, failed int
+	for _, v := range vertexes {
+		if v.Started == nil {
+			continue
+		}
+
+		span := otlpclient.NewProtobufSpan()
+		span.TraceId = traceId
+		span.SpanId = otlpclient.GenerateSpanId()
+		span.ParentSpanId = build.SpanId
+		span.Name = v.Name
+		span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+		span.StartTimeUnixNano = uint64(v.Started.UnixNano())
+		span.EndTimeUnixNano = uint64(endOrNow(v.Completed, end).UnixNano())
+		span.Attributes = append(importAttrs(map[string]string{"buildkit.vertex.digest": v.Digest}),
+			&commonpb.KeyValue{Key: "buildkit.cached", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.Cached}}},
+		)
+		if v.Error != "" {
+			otlpclient.SetSpanStatus(span, "error", v.Error)
+			failed++
+		}
+		if v.Cached {
+			cached++
+		}
+
+		byDigest[v.Digest] = span
+		steps = append(steps, span)
+	}
+
+	// link each step to the steps it used, they're all known by now
+	for _, v := range vertexes {
+		span, ok := byDigest[v.Digest]
+		if !ok {
+			continue
+		}
+		for _, input := range v.Inputs {
+			if from, ok := byDigest[input]; ok {
+				span.Links = append(span.Links, &tracepb.Span_Link{TraceId: traceId, SpanId: from.SpanId})
+			}
+		}
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].StartTimeUnixNano < steps[j].StartTimeUnixNano })
+
+	build.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{
+		"buildkit.steps":        strconv.Itoa(len(steps)),
+		"buildkit.steps.cached": strconv.Itoa(cached),
+	})
+	sort.Slice(build.Attributes, func(i, j int) bool { return build.Attributes[i].Key < build.Attributes[j].Key })
+	if failed > 0 {
+		otlpclient.SetSpanStatus(build, "error", fmt.Sprintf("%d build steps failed", failed))
+	}
+
+	return append([]*tracepb.Span{build}, steps...)
+}
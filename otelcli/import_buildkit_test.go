@@ -0,0 +1,66 @@
+package otelcli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// buildkitTestProgress is trimmed down from docker buildx build --progress rawjson,
+// which repeats steps as they change and adds statuses and logs along the way.
+const buildkitTestProgress = `{"vertexes":[{"digest":"sha256:base","name":"[internal] load metadata for docker.io/library/golang:1.21"}]}
+{"vertexes":[{"digest":"sha256:base","name":"[internal] load metadata for docker.io/library/golang:1.21","started":"2024-03-09T14:30:00Z"}]}
+{"vertexes":[{"digest":"sha256:base","name":"[internal] load metadata for docker.io/library/golang:1.21","started":"2024-03-09T14:30:00Z","completed":"2024-03-09T14:30:01Z"}],"statuses":[{"id":"x","vertex":"sha256:base"}]}
+{"vertexes":[{"digest":"sha256:copy","inputs":["sha256:base"],"name":"[2/3] COPY . .","started":"2024-03-09T14:30:01Z","completed":"2024-03-09T14:30:01Z","cached":true}]}
+{"vertexes":[{"digest":"sha256:build","inputs":["sha256:copy"],"name":"[3/3] RUN go build","started":"2024-03-09T14:30:01Z"}],"logs":[{"vertex":"sha256:build","stream":2,"data":"aGk=","timestamp":"2024-03-09T14:30:02Z"}]}
+{"vertexes":[{"digest":"sha256:build","inputs":["sha256:copy"],"name":"[3/3] RUN go build","started":"2024-03-09T14:30:01Z","completed":"2024-03-09T14:30:31Z","error":"process \"/bin/sh -c go build\" did not complete successfully: exit code: 1"}]}
+{"vertexes":[{"digest":"sha256:export","inputs":["sha256:build"],"name":"exporting to image"}]}
+`
+
+func TestBuildkitImport(t *testing.T) {
+	vertexes, err := readBuildkitProgress(strings.NewReader(buildkitTestProgress))
+	if err != nil {
+		t.Fatalf("failed to read BuildKit progress: %s", err)
+	}
+	if len(vertexes) != 4 {
+		t.Fatalf("expected 4 steps but got %d", len(vertexes))
+	}
+
+	parentId := otlpclient.GenerateSpanId()
+	spans := buildkitSpans(vertexes, "docker build", otlpclient.GenerateTraceId(), parentId)
+
+	got := []string{}
+	byId := map[string]*tracepb.Span{string(parentId): {Name: "ci"}}
+	for _, span := range spans {
+		byId[string(span.SpanId)] = span
+		links := []string{}
+		for _, link := range span.Links {
+			links = append(links, byId[string(link.SpanId)].Name)
+		}
+		attrs := otlpclient.SpanAttributesToStringMap(span)
+		got = append(got, fmt.Sprintf("%s < %s %s %s cached=%s links=%q", span.Name, byId[string(span.ParentSpanId)].Name,
+			time.Duration(span.EndTimeUnixNano-span.StartTimeUnixNano), span.Status.Code, attrs["buildkit.cached"], links))
+	}
+	want := []string{
+		`docker build < ci 31s STATUS_CODE_ERROR cached= links=[]`,
+		`[internal] load metadata for docker.io/library/golang:1.21 < docker build 1s STATUS_CODE_UNSET cached=false links=[]`,
+		`[2/3] COPY . . < docker build 0s STATUS_CODE_UNSET cached=true links=["[internal] load metadata for docker.io/library/golang:1.21"]`,
+		`[3/3] RUN go build < docker build 30s STATUS_CODE_ERROR cached=false links=["[2/3] COPY . ."]`,
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("unexpected spans\nwant: %q\n got: %q", want, got)
+	}
+
+	attrs := otlpclient.SpanAttributesToStringMap(spans[0])
+	if attrs["buildkit.steps"] != "3" || attrs["buildkit.steps.cached"] != "1" {
+		t.Errorf("unexpected build attributes %q", attrs)
+	}
+
+	if _, err := readBuildkitProgress(strings.NewReader("#1 [internal] load build definition\n")); err == nil {
+		t.Error("expected an error for plain progress output")
+	}
+}
@@ -0,0 +1,309 @@
+package otelcli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// githubImport holds the command-line configured settings for
+// otel-cli import github-workflow.
+var githubImport struct {
+	apiUrl  string
+	token   string
+	attempt int
+}
+
+// githubRun is the part of the GitHub API's workflow run otel-cli uses.
+type githubRun struct {
+	Id           int64     `json:"id"`
+	Name         string    `json:"name"`
+	RunNumber    int64     `json:"run_number"`
+	RunAttempt   int64     `json:"run_attempt"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	HtmlUrl      string    `json:"html_url"`
+	HeadBranch   string    `json:"head_branch"`
+	HeadSha      string    `json:"head_sha"`
+	RunStartedAt time.Time `json:"run_started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Actor        struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		HtmlUrl  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// githubJob is a job in a workflow run along with its steps.
+type githubJob struct {
+	Id          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	HtmlUrl     string     `json:"html_url"`
+	RunnerName  string     `json:"runner_name"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Steps       []struct {
+		Name        string     `json:"name"`
+		Number      int64      `json:"number"`
+		Status      string     `json:"status"`
+		Conclusion  string     `json:"conclusion"`
+		StartedAt   *time.Time `json:"started_at"`
+		CompletedAt *time.Time `json:"completed_at"`
+	} `json:"steps"`
+}
+
+func importGithubWorkflowCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "github-workflow [owner/repo run-id]",
+		Short: "trace a GitHub Actions workflow run",
+		Long: `Fetch a GitHub Actions workflow run's jobs and steps from the GitHub API
+and send them as a trace: a span for the run, a child span for each job, and
+a grandchild span for each step, with the timestamps GitHub recorded and an
+error status for anything that failed, was cancelled, or timed out.
+
+The repo and run id default to GITHUB_REPOSITORY and GITHUB_RUN_ID, so a final
+job in a workflow can trace the run it's part of. The token defaults to
+GITHUB_TOKEN or GH_TOKEN and needs actions:read on private repos. Trace and
+span ids are derived from the run, so importing the same run twice produces
+the same trace.
+
+Example:
+	otel-cli import github-workflow equinix-labs/otel-cli 8675309 --endpoint localhost:4317
+
+	# as the last job in a workflow, after the others with needs:
+	otel-cli import github-workflow
+`,
+		Args: cobra.MatchAll(cobra.MaximumNArgs(2), func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return fmt.Errorf("both owner/repo and the run id are required")
+			}
+			return nil
+		}),
+		Run: doImportGithubWorkflow,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&githubImport.apiUrl, "github-api-url", "", "GitHub API URL, defaults to GITHUB_API_URL or https://api.github.com")
+	cmd.Flags().StringVar(&githubImport.token, "github-token", "", "GitHub token, defaults to GITHUB_TOKEN or GH_TOKEN")
+	cmd.Flags().IntVar(&githubImport.attempt, "attempt", 0, "import this attempt of the run instead of the latest")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doImportGithubWorkflow(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	repo, runId := os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if len(args) == 2 {
+		repo, runId = args[0], args[1]
+	}
+	if repo == "" || runId == "" {
+		config.SoftFail("a repo and run id are required, either as arguments or GITHUB_REPOSITORY and GITHUB_RUN_ID")
+	}
+
+	api := githubApi{url: githubImport.apiUrl, token: githubImport.token, timeout: config.GetTimeout()}
+	if api.url == "" {
+		api.url = os.Getenv("GITHUB_API_URL")
+	}
+	if api.url == "" {
+		api.url = "https://api.github.com"
+	}
+	if api.token == "" {
+		api.token = firstEnv(os.Getenv, "GITHUB_TOKEN", "GH_TOKEN")
+	}
+	run, jobs, err := api.workflowRun(ctx, repo, runId, githubImport.attempt)
+	if err != nil {
+		config.SoftFail("failed to fetch workflow run: %s", err)
+	}
+
+	spans := githubWorkflowSpans(run, jobs, time.Now())
+	sendImportSpans(ctx, config, spans)
+
+	config.SoftLog("imported %d spans from %s", len(spans), run.HtmlUrl)
+}
+
+// githubApi is a minimal client for the GitHub REST API.
+type githubApi struct {
+	url     string
+	token   string
+	timeout time.Duration
+}
+
+// workflowRun fetches the run and all of its jobs. attempt 0 is the latest.
+func (api githubApi) workflowRun(ctx context.Context, repo, runId string, attempt int) (githubRun, []githubJob, error) {
+	run := githubRun{}
+	runPath := fmt.Sprintf("/repos/%s/actions/runs/%s", repo, runId)
+	if attempt > 0 {
+		runPath = fmt.Sprintf("%s/attempts/%d", runPath, attempt)
+	}
+	if err := api.get(ctx, runPath, &run); err != nil {
+		return run, nil, err
+	}
+
+	// jobs are paged, 100 per page is the most the API allows
+	jobs := []githubJob{}
+	for page := 1; ; page++ {
+		resp := struct {
+			TotalCount int         `json:"total_count"`
+			Jobs       []githubJob `json:"jobs"`
+		}{}
+		if err := api.get(ctx, fmt.Sprintf("%s/jobs?per_page=100&page=%d", runPath, page), &resp); err != nil {
+			return run, nil, err
+		}
+		jobs = append(jobs, resp.Jobs...)
+		if len(resp.Jobs) == 0 || len(jobs) >= resp.TotalCount {
+			break
+		}
+	}
+
+	return run, jobs, nil
+}
+
+// get fetches the API path and decodes the JSON response into out.
+func (api githubApi) get(ctx context.Context, path string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, api.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(api.url, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if api.token != "" {
+		req.Header.Set("Authorization", "Bearer "+api.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// githubWorkflowSpans builds the trace for a run: the run, then its jobs,
+// then their steps. Jobs and steps that haven't started are left out and
+// ones still running end at now.
+func githubWorkflowSpans(run githubRun, jobs []githubJob, now time.Time) []*tracepb.Span {
+	idBase := fmt.Sprintf("github-workflow/%s/%d/%d", run.Repository.FullName, run.Id, run.RunAttempt)
+	traceId := githubImportId(idBase, 16)
+
+	runEnd := run.UpdatedAt
+	if run.Status != "completed" {
+		runEnd = now
+	}
+	root := githubImportSpan(traceId, githubImportId(idBase, 8), nil, run.Name, run.RunStartedAt, runEnd, run.Conclusion)
+	root.Kind = tracepb.Span_SPAN_KIND_SERVER
+	root.Attributes = importAttrs(map[string]string{
+		"cicd.pipeline.name":          run.Name,
+		"cicd.pipeline.run.id":        strconv.FormatInt(run.Id, 10),
+		"cicd.pipeline.run.url.full":  run.HtmlUrl,
+		"vcs.repository.url.full":     run.Repository.HtmlUrl,
+		"vcs.repository.ref.name":     run.HeadBranch,
+		"vcs.repository.ref.revision": run.HeadSha,
+		"github.run_attempt":          strconv.FormatInt(run.RunAttempt, 10),
+		"github.run_number":           strconv.FormatInt(run.RunNumber, 10),
+		"github.event_name":           run.Event,
+		"github.actor":                run.Actor.Login,
+		"github.repository":           run.Repository.FullName,
+		"github.conclusion":           run.Conclusion,
+	})
+	spans := []*tracepb.Span{root}
+
+	for _, job := range jobs {
+		if job.StartedAt == nil {
+			continue
+		}
+		jobIdBase := fmt.Sprintf("%s/job/%d", idBase, job.Id)
+		jobSpan := githubImportSpan(traceId, githubImportId(jobIdBase, 8), root.SpanId, job.Name, *job.StartedAt, endOrNow(job.CompletedAt, now), job.Conclusion)
+		jobSpan.Attributes = importAttrs(map[string]string{
+			"cicd.pipeline.task.name":         job.Name,
+			"cicd.pipeline.task.run.id":       strconv.FormatInt(job.Id, 10),
+			"cicd.pipeline.task.run.url.full": job.HtmlUrl,
+			"github.runner_name":              job.RunnerName,
+			"github.conclusion":               job.Conclusion,
+		})
+		spans = append(spans, jobSpan)
+
+		for _, step := range job.Steps {
+			if step.StartedAt == nil {
+				continue
+			}
+			stepId := githubImportId(fmt.Sprintf("%s/step/%d", jobIdBase, step.Number), 8)
+			stepSpan := githubImportSpan(traceId, stepId, jobSpan.SpanId, step.Name, *step.StartedAt, endOrNow(step.CompletedAt, now), step.Conclusion)
+			stepSpan.Attributes = importAttrs(map[string]string{
+				"github.step.number": strconv.FormatInt(step.Number, 10),
+				"github.conclusion":  step.Conclusion,
+			})
+			spans = append(spans, stepSpan)
+		}
+	}
+
+	return spans
+}
+
+// githubImportSpan returns a span with the status set from a GitHub conclusion.
+func githubImportSpan(traceId, spanId, parentId []byte, name string, start, end time.Time, conclusion string) *tracepb.Span {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = traceId
+	span.SpanId = spanId
+	if parentId != nil {
+		span.ParentSpanId = parentId
+	}
+	span.Name = name
+	span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+	span.StartTimeUnixNano = uint64(start.UnixNano())
+	span.EndTimeUnixNano = uint64(end.UnixNano())
+
+	switch conclusion {
+	case "success":
+		otlpclient.SetSpanStatus(span, "ok", "")
+	case "failure", "cancelled", "timed_out", "startup_failure", "action_required":
+		otlpclient.SetSpanStatus(span, "error", conclusion)
+	}
+
+	return span
+}
+
+// githubImportId returns the first size bytes of a hash of the string, so
+// the same run always gets the same ids.
+func githubImportId(in string, size int) []byte {
+	sum := sha256.Sum256([]byte(in))
+	return sum[:size]
+}
+
+// endOrNow returns the end time, or now for things still running.
+func endOrNow(end *time.Time, now time.Time) time.Time {
+	if end == nil {
+		return now
+	}
+	return *end
+}
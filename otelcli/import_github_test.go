@@ -0,0 +1,114 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const githubTestRun = `{
+	"id": 8675309, "name": "CI", "run_number": 42, "run_attempt": 1, "event": "push",
+	"status": "completed", "conclusion": "failure",
+	"html_url": "https://github.com/octo-org/octo-repo/actions/runs/8675309",
+	"head_branch": "main", "head_sha": "abc123",
+	"run_started_at": "2024-03-09T14:30:00Z", "updated_at": "2024-03-09T14:35:00Z",
+	"actor": {"login": "octocat"},
+	"repository": {"full_name": "octo-org/octo-repo", "html_url": "https://github.com/octo-org/octo-repo"}
+}`
+
+const githubTestJobsPage1 = `{"total_count": 2, "jobs": [{
+	"id": 1, "name": "build", "status": "completed", "conclusion": "success", "runner_name": "runner-1",
+	"started_at": "2024-03-09T14:30:05Z", "completed_at": "2024-03-09T14:32:00Z",
+	"steps": [
+		{"name": "Checkout", "number": 1, "status": "completed", "conclusion": "success",
+		 "started_at": "2024-03-09T14:30:06Z", "completed_at": "2024-03-09T14:30:10Z"},
+		{"name": "Build", "number": 2, "status": "completed", "conclusion": "success",
+		 "started_at": "2024-03-09T14:30:10Z", "completed_at": "2024-03-09T14:32:00Z"}
+	]
+}]}`
+
+const githubTestJobsPage2 = `{"total_count": 2, "jobs": [{
+	"id": 2, "name": "test", "status": "completed", "conclusion": "failure",
+	"started_at": "2024-03-09T14:32:05Z", "completed_at": "2024-03-09T14:35:00Z",
+	"steps": [
+		{"name": "Test", "number": 1, "status": "completed", "conclusion": "failure",
+		 "started_at": "2024-03-09T14:32:06Z", "completed_at": "2024-03-09T14:35:00Z"},
+		{"name": "Upload", "number": 2, "status": "completed", "conclusion": "skipped",
+		 "started_at": null, "completed_at": null}
+	]
+}]}`
+
+func TestGithubWorkflowImport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/repos/octo-org/octo-repo/actions/runs/8675309?":
+			fmt.Fprint(w, githubTestRun)
+		case "/repos/octo-org/octo-repo/actions/runs/8675309/jobs?per_page=100&page=1":
+			fmt.Fprint(w, githubTestJobsPage1)
+		case "/repos/octo-org/octo-repo/actions/runs/8675309/jobs?per_page=100&page=2":
+			fmt.Fprint(w, githubTestJobsPage2)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	api := githubApi{url: srv.URL, token: "s3cret", timeout: time.Second}
+	run, jobs, err := api.workflowRun(context.Background(), "octo-org/octo-repo", "8675309", 0)
+	if err != nil {
+		t.Fatalf("failed to fetch workflow run: %s", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected both pages of jobs but got %d", len(jobs))
+	}
+
+	spans := githubWorkflowSpans(run, jobs, time.Now())
+	got := []string{}
+	byId := map[string]*tracepb.Span{}
+	for _, span := range spans {
+		byId[string(span.SpanId)] = span
+		parent := "-"
+		if p, ok := byId[string(span.ParentSpanId)]; ok {
+			parent = p.Name
+		}
+		got = append(got, fmt.Sprintf("%s < %s %s %s", span.Name, parent,
+			time.Duration(span.EndTimeUnixNano-span.StartTimeUnixNano), span.Status.Code))
+	}
+	want := []string{
+		"CI < - 5m0s STATUS_CODE_ERROR",
+		"build < CI 1m55s STATUS_CODE_OK",
+		"Checkout < build 4s STATUS_CODE_OK",
+		"Build < build 1m50s STATUS_CODE_OK",
+		"test < CI 2m55s STATUS_CODE_ERROR",
+		"Test < test 2m54s STATUS_CODE_ERROR",
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("unexpected spans\nwant: %q\n got: %q", want, got)
+	}
+
+	attrs := otlpclient.SpanAttributesToStringMap(spans[0])
+	if attrs["cicd.pipeline.run.id"] != "8675309" || attrs["vcs.repository.ref.revision"] != "abc123" {
+		t.Errorf("unexpected run attributes %q", attrs)
+	}
+
+	// importing again gives the same ids
+	again := githubWorkflowSpans(run, jobs, time.Now())
+	if string(again[0].TraceId) != string(spans[0].TraceId) || string(again[5].SpanId) != string(spans[5].SpanId) {
+		t.Error("expected the same ids when importing the same run")
+	}
+
+	api.token = "nope"
+	if _, _, err := api.workflowRun(context.Background(), "octo-org/octo-repo", "8675309", 0); err == nil {
+		t.Error("expected bad credentials to fail")
+	}
+}
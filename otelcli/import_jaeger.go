@@ -0,0 +1,269 @@
+package otelcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// jaegerTrace is a trace in the JSON the Jaeger UI downloads and its
+// /api/traces API returns, which wrap a list of these in "data".
+type jaegerTrace struct {
+	TraceId   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceId       string            `json:"traceID"`
+	SpanId        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"`
+	Duration      int64             `json:"duration"`
+	Tags          []jaegerTag       `json:"tags"`
+	Logs          []jaegerLog       `json:"logs"`
+	ProcessId     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceId string `json:"traceID"`
+	SpanId  string `json:"spanID"`
+}
+
+type jaegerProcess struct {
+	ServiceName string      `json:"serviceName"`
+	Tags        []jaegerTag `json:"tags"`
+}
+
+// jaegerTag is a typed key/value, where type is one of string, bool,
+// int64, float64, or binary.
+type jaegerTag struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type jaegerLog struct {
+	Timestamp int64       `json:"timestamp"`
+	Fields    []jaegerTag `json:"fields"`
+}
+
+func importJaegerCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "jaeger file...",
+		Short: "migrate traces exported from Jaeger to OTLP",
+		Long: `Read traces in the JSON format the Jaeger UI downloads and its /api/traces
+API returns, and send them to the OTLP endpoint, so historical traces can be
+moved to an OTLP backend.
+
+Trace and span ids, names, and times are kept as they are, with 64 bit trace
+ids padded to 128 bits. Each span's process becomes its resource, with the
+service name as service.name and the process tags as resource attributes.
+Tags become attributes, logs become events named after their event field,
+the first CHILD_OF reference becomes the parent, and other references
+become links. The span.kind, error, and otel.status_code tags set the
+span's kind and status.
+
+Example:
+	curl -s 'http://jaeger:16686/api/traces?service=api&limit=100' > traces.json
+	otel-cli import jaeger traces.json --endpoint localhost:4317
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doImportJaeger,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doImportJaeger(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to import traces to")
+	}
+
+	spans := []importedSpan{}
+	for _, path := range args {
+		traces, err := readJaegerFile(path)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		for _, trace := range traces {
+			imported, err := trace.importedSpans()
+			if err != nil {
+				config.SoftFail("%s: %s", path, err)
+			}
+			spans = append(spans, imported...)
+		}
+	}
+	if len(spans) == 0 {
+		config.SoftFail("no spans found")
+	}
+
+	uploadImportResourceSpans(ctx, config, importResourceSpans(spans))
+
+	config.SoftLog("imported %d spans from %d files", len(spans), len(args))
+}
+
+// readJaegerFile reads the traces in the file, which are either wrapped in
+// "data" or a single trace.
+func readJaegerFile(path string) ([]jaegerTrace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept as they are so int64 tags don't lose precision
+	var wrapped struct {
+		Data []jaegerTrace `json:"data"`
+		jaegerTrace
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&wrapped); err != nil {
+		return nil, fmt.Errorf("failed to parse Jaeger JSON in %s: %w", path, err)
+	}
+
+	if wrapped.Data != nil {
+		return wrapped.Data, nil
+	} else if len(wrapped.Spans) > 0 {
+		return []jaegerTrace{wrapped.jaegerTrace}, nil
+	}
+	return nil, fmt.Errorf("no Jaeger traces found in %s, expected {\"data\": [...]} or a trace", path)
+}
+
+// importedSpans converts the trace's spans to OTLP. Jaeger times are in
+// microseconds.
+func (trace jaegerTrace) importedSpans() ([]importedSpan, error) {
+	out := []importedSpan{}
+	for _, js := range trace.Spans {
+		span := otlpclient.NewProtobufSpan()
+		var err error
+		if span.TraceId, err = importId(js.TraceId, 16); err != nil {
+			return nil, err
+		}
+		if span.SpanId, err = importId(js.SpanId, 8); err != nil {
+			return nil, err
+		}
+		span.Name = js.OperationName
+		span.StartTimeUnixNano = uint64(js.StartTime * 1000)
+		span.EndTimeUnixNano = uint64((js.StartTime + js.Duration) * 1000)
+
+		span.ParentSpanId = []byte{}
+		for _, ref := range js.References {
+			traceId, err := importId(ref.TraceId, 16)
+			if err != nil {
+				return nil, err
+			}
+			spanId, err := importId(ref.SpanId, 8)
+			if err != nil {
+				return nil, err
+			}
+			if ref.RefType == "CHILD_OF" && len(span.ParentSpanId) == 0 && bytes.Equal(traceId, span.TraceId) {
+				span.ParentSpanId = spanId
+			} else {
+				span.Links = append(span.Links, &tracepb.Span_Link{
+					TraceId:    traceId,
+					SpanId:     spanId,
+					Attributes: importAttrs(map[string]string{"opentracing.ref_type": strings.ToLower(ref.RefType)}),
+				})
+			}
+		}
+
+		is := importedSpan{span: span}
+		status, statusMessage, isError := "", "", false
+		span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+		for _, tag := range js.Tags {
+			value := fmt.Sprint(tag.Value)
+			switch tag.Key {
+			case "span.kind":
+				span.Kind = map[string]tracepb.Span_SpanKind{
+					"client":   tracepb.Span_SPAN_KIND_CLIENT,
+					"server":   tracepb.Span_SPAN_KIND_SERVER,
+					"producer": tracepb.Span_SPAN_KIND_PRODUCER,
+					"consumer": tracepb.Span_SPAN_KIND_CONSUMER,
+					"internal": tracepb.Span_SPAN_KIND_INTERNAL,
+				}[value]
+			case "error":
+				isError = value == "true"
+			case "otel.status_code":
+				status = strings.ToLower(value)
+			case "otel.status_description":
+				statusMessage = value
+			case "otel.scope.name", "otel.library.name":
+				is.scope = value
+			case "otel.scope.version", "otel.library.version":
+				is.scopeVersion = value
+			default:
+				span.Attributes = append(span.Attributes, tag.keyValue())
+			}
+		}
+		if span.Kind == tracepb.Span_SPAN_KIND_UNSPECIFIED {
+			span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+		}
+		if status == "" && isError {
+			status = "error"
+		}
+		otlpclient.SetSpanStatus(span, status, statusMessage)
+
+		for _, log := range js.Logs {
+			event := otlpclient.NewProtobufSpanEvent()
+			event.Name = "log"
+			event.TimeUnixNano = uint64(log.Timestamp * 1000)
+			for _, field := range log.Fields {
+				if field.Key == "event" {
+					event.Name = fmt.Sprint(field.Value)
+				} else {
+					event.Attributes = append(event.Attributes, field.keyValue())
+				}
+			}
+			span.Events = append(span.Events, event)
+		}
+
+		process := trace.Processes[js.ProcessId]
+		is.resource = importAttrs(map[string]string{"service.name": process.ServiceName})
+		for _, tag := range process.Tags {
+			is.resource = append(is.resource, tag.keyValue())
+		}
+
+		out = append(out, is)
+	}
+	return out, nil
+}
+
+// keyValue converts the tag to an attribute with the type the tag has,
+// falling back to a string when the value doesn't match its type.
+func (tag jaegerTag) keyValue() *commonpb.KeyValue {
+	kv := &commonpb.KeyValue{
+		Key:   tag.Key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(tag.Value)}},
+	}
+	switch v := tag.Value.(type) {
+	case bool:
+		kv.Value.Value = &commonpb.AnyValue_BoolValue{BoolValue: v}
+	case json.Number:
+		if tag.Type == "int64" {
+			if i, err := v.Int64(); err == nil {
+				kv.Value.Value = &commonpb.AnyValue_IntValue{IntValue: i}
+			}
+		} else if f, err := v.Float64(); err == nil {
+			kv.Value.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: f}
+		}
+	}
+	return kv
+}
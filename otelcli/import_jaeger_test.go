@@ -0,0 +1,114 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const jaegerTestTraces = `{"data": [{
+	"traceID": "463ac35c9f6413ad",
+	"spans": [
+		{
+			"traceID": "463ac35c9f6413ad",
+			"spanID": "a2fb4a1d1a96d312",
+			"operationName": "GET /api",
+			"references": [],
+			"startTime": 1709994600000000,
+			"duration": 250000,
+			"tags": [
+				{"key": "span.kind", "type": "string", "value": "server"},
+				{"key": "error", "type": "bool", "value": true},
+				{"key": "http.status_code", "type": "int64", "value": 9007199254740993},
+				{"key": "ratio", "type": "float64", "value": 0.5}
+			],
+			"logs": [{"timestamp": 1709994600100000, "fields": [
+				{"key": "event", "type": "string", "value": "retry"},
+				{"key": "attempt", "type": "int64", "value": 2}
+			]}],
+			"processID": "p1"
+		},
+		{
+			"traceID": "463ac35c9f6413ad",
+			"spanID": "b7ad6b7169203331",
+			"operationName": "publish",
+			"references": [
+				{"refType": "FOLLOWS_FROM", "traceID": "1", "spanID": "2"},
+				{"refType": "CHILD_OF", "traceID": "463ac35c9f6413ad", "spanID": "a2fb4a1d1a96d312"}
+			],
+			"startTime": 1709994600050000,
+			"duration": 1000,
+			"tags": [{"key": "otel.status_code", "type": "string", "value": "ERROR"}, {"key": "otel.status_description", "type": "string", "value": "broker down"}],
+			"processID": "p2"
+		}
+	],
+	"processes": {
+		"p1": {"serviceName": "api", "tags": [{"key": "hostname", "type": "string", "value": "web1"}]},
+		"p2": {"serviceName": "api", "tags": [{"key": "hostname", "type": "string", "value": "web2"}]}
+	}
+}]}`
+
+func TestJaegerImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	if err := os.WriteFile(path, []byte(jaegerTestTraces), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	traces, err := readJaegerFile(path)
+	if err != nil {
+		t.Fatalf("failed to read Jaeger traces: %s", err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace but got %d", len(traces))
+	}
+	spans, err := traces[0].importedSpans()
+	if err != nil {
+		t.Fatalf("failed to convert trace: %s", err)
+	}
+
+	server := spans[0].span
+	if hex.EncodeToString(server.TraceId) != "0000000000000000463ac35c9f6413ad" || len(server.ParentSpanId) != 0 {
+		t.Errorf("unexpected trace id %x and parent %x", server.TraceId, server.ParentSpanId)
+	}
+	if server.Kind != tracepb.Span_SPAN_KIND_SERVER || server.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("unexpected kind %s and status %s", server.Kind, server.Status)
+	}
+	got := map[string]string{}
+	for _, attr := range server.Attributes {
+		got[attr.Key] = attr.Value.String()
+	}
+	want := map[string]string{
+		"http.status_code": `int_value:9007199254740993`,
+		"ratio":            `double_value:0.5`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+	}
+	if len(server.Events) != 1 || server.Events[0].Name != "retry" || server.Events[0].Attributes[0].Value.GetIntValue() != 2 {
+		t.Errorf("expected the log to become a retry event but got %v", server.Events)
+	}
+
+	producer := spans[1].span
+	if hex.EncodeToString(producer.ParentSpanId) != "a2fb4a1d1a96d312" {
+		t.Errorf("expected CHILD_OF to be the parent but got %x", producer.ParentSpanId)
+	}
+	if len(producer.Links) != 1 || hex.EncodeToString(producer.Links[0].SpanId) != "0000000000000002" {
+		t.Errorf("expected FOLLOWS_FROM to be a link but got %v", producer.Links)
+	}
+	if producer.Status.Code != tracepb.Status_STATUS_CODE_ERROR || producer.Status.Message != "broker down" {
+		t.Errorf("unexpected status %s", producer.Status)
+	}
+
+	// each process is its own resource, even with the same service name
+	rsps := importResourceSpans(spans)
+	if len(rsps) != 2 {
+		t.Fatalf("expected 2 resources but got %d", len(rsps))
+	}
+	if host := rsps[1].Resource.Attributes[1]; host.Key != "hostname" || host.Value.GetStringValue() != "web2" {
+		t.Errorf("expected the process tags on the resource but got %v", rsps[1].Resource.Attributes)
+	}
+}
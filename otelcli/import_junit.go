@@ -0,0 +1,301 @@
+package otelcli
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// junitSuite is a <testsuite>, or the <testsuites> wrapping them, in the
+// loosely standardized XML written by most test runners.
+type junitSuite struct {
+	XMLName   xml.Name
+	Name      string       `xml:"name,attr"`
+	Time      string       `xml:"time,attr"`
+	Timestamp string       `xml:"timestamp,attr"`
+	Suites    []junitSuite `xml:"testsuite"`
+	Cases     []junitCase  `xml:"testcase"`
+}
+
+// junitCase is a <testcase>, which failed if it has any failures or errors.
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Time      string         `xml:"time,attr"`
+	Failures  []junitProblem `xml:"failure"`
+	Errors    []junitProblem `xml:"error"`
+	Skipped   *junitProblem  `xml:"skipped"`
+}
+
+// junitProblem is a <failure>, <error>, or <skipped>.
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func importJunitCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "junit [file...]",
+		Short: "trace test results from JUnit XML reports",
+		Long: `Read JUnit XML test reports and send a span for each test suite and test
+case, parented to the traceparent from TRACEPARENT or --tp-carrier so the
+tests show up inside the build's trace.
+
+Cases that failed or errored get an error status and an exception event for
+each failure with its message and output. Skipped cases are marked with
+test.case.result.status=skipped and left unset. JUnit only records how long
+each case took, not when it started, so cases are laid out one after
+another from the start of their suite, and suites without a timestamp are
+assumed to have ended when the report was written.
+
+Example:
+	otel-cli exec --name "make test" -- sh -c 'go test -v ./... | go-junit-report > report.xml'
+	otel-cli import junit report.xml --tp-carrier /tmp/build.tp
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doImportJunit,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+
+	return &cmd
+}
+
+func doImportJunit(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to import test results to")
+	}
+
+	traceId, parentId := importParent(config)
+
+	spans := []*tracepb.Span{}
+	for _, path := range args {
+		suites, err := readJunitFile(path)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		spans = append(spans, junitSpans(suites, traceId, parentId, fi.ModTime())...)
+	}
+
+	addImportParentAttrs(config, spans)
+	sendImportSpans(ctx, config, spans)
+
+	config.SoftLog("imported %d spans from %d reports", len(spans), len(args))
+}
+
+// readJunitFile returns the top level suites in the report, which is either
+// a single <testsuite> or a <testsuites> of them.
+func readJunitFile(path string) ([]junitSuite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root := junitSuite{}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit XML in %s: %w", path, err)
+	}
+
+	switch root.XMLName.Local {
+	case "testsuites":
+		return root.Suites, nil
+	case "testsuite":
+		return []junitSuite{root}, nil
+	default:
+		return nil, fmt.Errorf("%s is not a JUnit XML report, found <%s> instead of <testsuites> or <testsuite>", path, root.XMLName.Local)
+	}
+}
+
+// junitSpans builds the spans for the suites in a report written at end.
+// Suites without a timestamp are placed back to back so the last one ends
+// at end.
+func junitSpans(suites []junitSuite, traceId, parentId []byte, end time.Time) []*tracepb.Span {
+	var untimed time.Duration
+	for _, suite := range suites {
+		if _, ok := parseJunitTimestamp(suite.Timestamp); !ok {
+			untimed += suite.duration()
+		}
+	}
+
+	spans := []*tracepb.Span{}
+	cursor := end.Add(-untimed)
+	for _, suite := range suites {
+		spans = append(spans, suite.spans(traceId, parentId, cursor)...)
+		if _, ok := parseJunitTimestamp(suite.Timestamp); !ok {
+			cursor = cursor.Add(suite.duration())
+		}
+	}
+	return spans
+}
+
+// spans returns the suite's span followed by the spans for its cases and
+// nested suites. start is used when the suite doesn't have a timestamp.
+func (suite junitSuite) spans(traceId, parentId []byte, start time.Time) []*tracepb.Span {
+	if ts, ok := parseJunitTimestamp(suite.Timestamp); ok {
+		start = ts
+	}
+
+	span := junitSpan(traceId, parentId, suite.Name, start, start.Add(suite.duration()))
+	status := "success"
+	if suite.failed() {
+		status = "failure"
+		otlpclient.SetSpanStatus(span, "error", "test suite failed")
+	}
+	span.Attributes = importAttrs(map[string]string{
+		"test.suite.name":       suite.Name,
+		"test.suite.run.status": status,
+	})
+	spans := []*tracepb.Span{span}
+
+	cursor := start
+	for _, tc := range suite.Cases {
+		spans = append(spans, tc.span(traceId, span.SpanId, cursor))
+		cursor = cursor.Add(parseJunitDuration(tc.Time))
+	}
+	for _, nested := range suite.Suites {
+		spans = append(spans, nested.spans(traceId, span.SpanId, cursor)...)
+		cursor = cursor.Add(nested.duration())
+	}
+
+	return spans
+}
+
+// duration returns the suite's time, or the sum of its contents when the
+// report leaves it out.
+func (suite junitSuite) duration() time.Duration {
+	if suite.Time != "" {
+		return parseJunitDuration(suite.Time)
+	}
+
+	var total time.Duration
+	for _, tc := range suite.Cases {
+		total += parseJunitDuration(tc.Time)
+	}
+	for _, nested := range suite.Suites {
+		total += nested.duration()
+	}
+	return total
+}
+
+// failed returns true if any case in the suite or its nested suites failed.
+func (suite junitSuite) failed() bool {
+	for _, tc := range suite.Cases {
+		if tc.failed() {
+			return true
+		}
+	}
+	for _, nested := range suite.Suites {
+		if nested.failed() {
+			return true
+		}
+	}
+	return false
+}
+
+func (tc junitCase) failed() bool {
+	return len(tc.Failures) > 0 || len(tc.Errors) > 0
+}
+
+// span returns the case's span with an exception event for each failure.
+func (tc junitCase) span(traceId, parentId []byte, start time.Time) *tracepb.Span {
+	end := start.Add(parseJunitDuration(tc.Time))
+	span := junitSpan(traceId, parentId, tc.Name, start, end)
+
+	attrs := map[string]string{
+		"test.case.name":          tc.Name,
+		"test.case.result.status": "pass",
+		"code.namespace":          tc.Classname,
+		"code.filepath":           tc.File,
+	}
+
+	switch {
+	case tc.failed():
+		attrs["test.case.result.status"] = "fail"
+		problems := append(append([]junitProblem{}, tc.Failures...), tc.Errors...)
+		otlpclient.SetSpanStatus(span, "error", problems[0].Message)
+		for _, problem := range problems {
+			span.Events = append(span.Events, problem.event(end))
+		}
+	case tc.Skipped != nil:
+		attrs["test.case.result.status"] = "skipped"
+	default:
+		otlpclient.SetSpanStatus(span, "ok", "")
+	}
+
+	span.Attributes = importAttrs(attrs)
+
+	return span
+}
+
+// event returns an exception event for a failure or error.
+func (problem junitProblem) event(at time.Time) *tracepb.Span_Event {
+	attrs := map[string]string{
+		"exception.type":       problem.Type,
+		"exception.message":    problem.Message,
+		"exception.stacktrace": strings.TrimSpace(problem.Text),
+	}
+
+	event := otlpclient.NewProtobufSpanEvent()
+	event.Name = "exception"
+	event.TimeUnixNano = uint64(at.UnixNano())
+	event.Attributes = importAttrs(attrs)
+	return event
+}
+
+func junitSpan(traceId, parentId []byte, name string, start, end time.Time) *tracepb.Span {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = traceId
+	span.SpanId = otlpclient.GenerateSpanId()
+	span.ParentSpanId = parentId
+	span.Name = name
+	span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+	span.StartTimeUnixNano = uint64(start.UnixNano())
+	span.EndTimeUnixNano = uint64(end.UnixNano())
+	return span
+}
+
+// parseJunitDuration parses a time attribute in seconds. Some runners add
+// thousands separators, and a missing or broken time counts as zero.
+func parseJunitDuration(in string) time.Duration {
+	secs, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(in), ",", ""), 64)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// parseJunitTimestamp parses a suite timestamp, which is usually ISO 8601
+// without a time zone, meaning local time.
+func parseJunitTimestamp(in string) (time.Time, bool) {
+	if in == "" {
+		return time.Time{}, false
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, in); err == nil {
+		return ts, true
+	}
+	if ts, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", in, time.Local); err == nil {
+		return ts, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,110 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const junitTestReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+	<testsuite name="pkg/math" tests="3" failures="1" time="1.5" timestamp="2024-03-09T14:30:00Z">
+		<testcase name="TestAdd" classname="pkg/math" time="0.25"/>
+		<testcase name="TestDivide" classname="pkg/math" time="1.0">
+			<failure message="divide by zero" type="panic">math_test.go:12: oops
+			</failure>
+		</testcase>
+		<testcase name="TestBig" classname="pkg/math" time="0">
+			<skipped message="-short"/>
+		</testcase>
+	</testsuite>
+	<testsuite name="pkg/strings">
+		<testcase name="TestTrim" classname="pkg/strings" time="2"/>
+		<testsuite name="nested">
+			<testcase name="1234" time="0.5"/>
+		</testsuite>
+	</testsuite>
+</testsuites>`
+
+func TestJunitImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := os.WriteFile(path, []byte(junitTestReport), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	suites, err := readJunitFile(path)
+	if err != nil {
+		t.Fatalf("failed to read JUnit report: %s", err)
+	}
+
+	parentId := otlpclient.GenerateSpanId()
+	end := time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)
+	spans := junitSpans(suites, otlpclient.GenerateTraceId(), parentId, end)
+
+	got := []string{}
+	byId := map[string]*tracepb.Span{string(parentId): {Name: "build"}}
+	for _, span := range spans {
+		byId[string(span.SpanId)] = span
+		got = append(got, fmt.Sprintf("%s < %s %s %s %s", span.Name, byId[string(span.ParentSpanId)].Name,
+			time.Unix(0, int64(span.StartTimeUnixNano)).UTC().Format("15:04:05.00"),
+			time.Duration(span.EndTimeUnixNano-span.StartTimeUnixNano), span.Status.Code))
+	}
+	want := []string{
+		"pkg/math < build 14:30:00.00 1.5s STATUS_CODE_ERROR",
+		"TestAdd < pkg/math 14:30:00.00 250ms STATUS_CODE_OK",
+		"TestDivide < pkg/math 14:30:00.25 1s STATUS_CODE_ERROR",
+		"TestBig < pkg/math 14:30:01.25 0s STATUS_CODE_UNSET",
+		// no timestamp, so it's placed to end when the report was written
+		"pkg/strings < build 14:59:57.50 2.5s STATUS_CODE_UNSET",
+		"TestTrim < pkg/strings 14:59:57.50 2s STATUS_CODE_OK",
+		"nested < pkg/strings 14:59:59.50 500ms STATUS_CODE_UNSET",
+		"1234 < nested 14:59:59.50 500ms STATUS_CODE_OK",
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("unexpected spans\nwant: %q\n got: %q", want, got)
+	}
+
+	attrs := otlpclient.SpanAttributesToStringMap(spans[2])
+	if attrs["test.case.result.status"] != "fail" || attrs["code.namespace"] != "pkg/math" {
+		t.Errorf("unexpected failed case attributes %q", attrs)
+	}
+	if attrs := otlpclient.SpanAttributesToStringMap(spans[3]); attrs["test.case.result.status"] != "skipped" {
+		t.Errorf("unexpected skipped case attributes %q", attrs)
+	}
+	if attrs := otlpclient.SpanAttributesToStringMap(spans[0]); attrs["test.suite.run.status"] != "failure" {
+		t.Errorf("unexpected suite attributes %q", attrs)
+	}
+
+	if len(spans[2].Events) != 1 {
+		t.Fatalf("expected an exception event on the failed case but got %d events", len(spans[2].Events))
+	}
+	event := map[string]string{"name": spans[2].Events[0].Name}
+	for _, attr := range spans[2].Events[0].Attributes {
+		event[attr.Key] = otlpclient.AttrValueToString(attr)
+	}
+	wantEvent := map[string]string{
+		"name":                 "exception",
+		"exception.type":       "panic",
+		"exception.message":    "divide by zero",
+		"exception.stacktrace": "math_test.go:12: oops",
+	}
+	if fmt.Sprint(wantEvent) != fmt.Sprint(event) {
+		t.Errorf("unexpected exception event\nwant: %q\n got: %q", wantEvent, event)
+	}
+
+	// a single <testsuite> works too, and anything else is an error
+	single := filepath.Join(t.TempDir(), "single.xml")
+	os.WriteFile(single, []byte(`<testsuite name="one"><testcase name="a"/></testsuite>`), 0600)
+	if suites, err := readJunitFile(single); err != nil || len(suites) != 1 || suites[0].Name != "one" {
+		t.Errorf("expected a single suite but got %v, %v", suites, err)
+	}
+	os.WriteFile(single, []byte(`<html></html>`), 0600)
+	if _, err := readJunitFile(single); err == nil {
+		t.Error("expected an error for a file that isn't a JUnit report")
+	}
+}
@@ -0,0 +1,223 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// zipkinSpan is a span in Zipkin's v2 JSON format, as the Zipkin UI
+// downloads and the /api/v2/traces API returns.
+// https://zipkin.io/zipkin-api/#/default/post_spans
+type zipkinSpan struct {
+	TraceId        string             `json:"traceId"`
+	Id             string             `json:"id"`
+	ParentId       string             `json:"parentId"`
+	Name           string             `json:"name"`
+	Kind           string             `json:"kind"`
+	Timestamp      int64              `json:"timestamp"`
+	Duration       int64              `json:"duration"`
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	Ipv4        string `json:"ipv4"`
+	Ipv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+func importZipkinCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "zipkin file...",
+		Short: "migrate traces exported from Zipkin to OTLP",
+		Long: `Read traces in Zipkin's v2 JSON format, as downloaded from the Zipkin UI or
+returned by its /api/v2/traces API, and send them to the OTLP endpoint, so
+historical traces can be moved to an OTLP backend.
+
+Trace and span ids, names, and times are kept as they are, with 64 bit trace
+ids padded to 128 bits. Each span's local service becomes its resource's
+service.name, tags become attributes, and annotations become events. The
+error tag and otel.status_code set the span's status.
+
+Example:
+	curl -s 'http://zipkin:9411/api/v2/traces?serviceName=api&limit=100' > traces.json
+	otel-cli import zipkin traces.json --endpoint localhost:4317
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doImportZipkin,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doImportZipkin(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to import traces to")
+	}
+
+	spans := []importedSpan{}
+	for _, path := range args {
+		zspans, err := readZipkinFile(path)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		for _, zs := range zspans {
+			is, err := zs.importedSpan()
+			if err != nil {
+				config.SoftFail("%s: %s", path, err)
+			}
+			spans = append(spans, is)
+		}
+	}
+	if len(spans) == 0 {
+		config.SoftFail("no spans found")
+	}
+
+	uploadImportResourceSpans(ctx, config, importResourceSpans(spans))
+
+	config.SoftLog("imported %d spans from %d files", len(spans), len(args))
+}
+
+// readZipkinFile reads the spans in the file, which is either a list of
+// spans, e.g. one trace from the UI, or a list of traces from the API.
+func readZipkinFile(path string) ([]zipkinSpan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := []json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Zipkin JSON in %s, expected a list of spans or traces: %w", path, err)
+	}
+
+	spans := []zipkinSpan{}
+	for _, elem := range raw {
+		trace := []zipkinSpan{}
+		if err := json.Unmarshal(elem, &trace); err == nil {
+			spans = append(spans, trace...)
+			continue
+		}
+		span := zipkinSpan{}
+		if err := json.Unmarshal(elem, &span); err != nil {
+			return nil, fmt.Errorf("failed to parse Zipkin span in %s: %w", path, err)
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// importedSpan converts the span to OTLP. Zipkin times are in microseconds.
+func (zs zipkinSpan) importedSpan() (importedSpan, error) {
+	span := otlpclient.NewProtobufSpan()
+	var err error
+	if span.TraceId, err = importId(zs.TraceId, 16); err != nil {
+		return importedSpan{}, err
+	}
+	if span.SpanId, err = importId(zs.Id, 8); err != nil {
+		return importedSpan{}, err
+	}
+	span.ParentSpanId = []byte{}
+	if zs.ParentId != "" {
+		if span.ParentSpanId, err = importId(zs.ParentId, 8); err != nil {
+			return importedSpan{}, err
+		}
+	}
+
+	span.Name = zs.Name
+	span.StartTimeUnixNano = uint64(zs.Timestamp * 1000)
+	span.EndTimeUnixNano = uint64((zs.Timestamp + zs.Duration) * 1000)
+	span.Kind = map[string]tracepb.Span_SpanKind{
+		"CLIENT":   tracepb.Span_SPAN_KIND_CLIENT,
+		"SERVER":   tracepb.Span_SPAN_KIND_SERVER,
+		"PRODUCER": tracepb.Span_SPAN_KIND_PRODUCER,
+		"CONSUMER": tracepb.Span_SPAN_KIND_CONSUMER,
+	}[zs.Kind]
+	if span.Kind == tracepb.Span_SPAN_KIND_UNSPECIFIED {
+		span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+	}
+
+	tags := map[string]string{}
+	for k, v := range zs.Tags {
+		tags[k] = v
+	}
+	is := importedSpan{
+		scope:        tags["otel.scope.name"],
+		scopeVersion: tags["otel.scope.version"],
+		span:         span,
+	}
+	if is.scope == "" {
+		is.scope, is.scopeVersion = tags["otel.library.name"], tags["otel.library.version"]
+	}
+
+	// instrumentation that isn't OTel sets error to the message or just "true"
+	if code, ok := tags["otel.status_code"]; ok {
+		otlpclient.SetSpanStatus(span, strings.ToLower(code), tags["otel.status_description"])
+	} else if msg, ok := tags["error"]; ok {
+		if msg == "true" {
+			msg = ""
+		}
+		otlpclient.SetSpanStatus(span, "error", msg)
+	}
+	for _, k := range []string{"error", "otel.status_code", "otel.status_description", "otel.scope.name", "otel.scope.version", "otel.library.name", "otel.library.version"} {
+		delete(tags, k)
+	}
+
+	ports := []*commonpb.KeyValue{}
+	port := func(key string, port int) {
+		if port > 0 {
+			ports = append(ports, &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(port)}}})
+		}
+	}
+	if ep := zs.LocalEndpoint; ep != nil {
+		is.resource = importAttrs(map[string]string{"service.name": ep.ServiceName})
+		tags["network.local.address"] = ep.address()
+		port("network.local.port", ep.Port)
+	}
+	if ep := zs.RemoteEndpoint; ep != nil {
+		tags["peer.service"] = ep.ServiceName
+		tags["network.peer.address"] = ep.address()
+		port("network.peer.port", ep.Port)
+	}
+	span.Attributes = append(importAttrs(tags), ports...)
+
+	for _, a := range zs.Annotations {
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = a.Value
+		event.TimeUnixNano = uint64(a.Timestamp * 1000)
+		span.Events = append(span.Events, event)
+	}
+
+	return is, nil
+}
+
+// address returns the endpoint's IPv4 address, or its IPv6 one.
+func (ep zipkinEndpoint) address() string {
+	if ep.Ipv4 != "" {
+		return ep.Ipv4
+	}
+	return ep.Ipv6
+}
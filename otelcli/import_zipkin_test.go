@@ -0,0 +1,129 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const zipkinTestTraces = `[[
+	{
+		"traceId": "463ac35c9f6413ad",
+		"id": "a2fb4a1d1a96d312",
+		"name": "get /api",
+		"kind": "SERVER",
+		"timestamp": 1709994600000000,
+		"duration": 250000,
+		"localEndpoint": {"serviceName": "api", "ipv4": "10.0.0.1", "port": 8080},
+		"annotations": [{"timestamp": 1709994600100000, "value": "cache miss"}],
+		"tags": {"http.method": "GET", "error": "true"}
+	},
+	{
+		"traceId": "463ac35c9f6413ad",
+		"parentId": "a2fb4a1d1a96d312",
+		"id": "b7ad6b7169203331",
+		"name": "select",
+		"kind": "CLIENT",
+		"timestamp": 1709994600050000,
+		"duration": 1000,
+		"localEndpoint": {"serviceName": "api"},
+		"remoteEndpoint": {"serviceName": "postgres", "ipv6": "::1", "port": 5432},
+		"tags": {"otel.status_code": "OK", "otel.scope.name": "pgx"}
+	}
+], [
+	{
+		"traceId": "5af7183fb1d4cf5f463ac35c9f6413ad",
+		"id": "0000000000000001",
+		"name": "send",
+		"timestamp": 1709994601000000,
+		"duration": 10,
+		"localEndpoint": {"serviceName": "worker"},
+		"tags": {"error": "queue full"}
+	}
+]]`
+
+func TestZipkinImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	if err := os.WriteFile(path, []byte(zipkinTestTraces), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	zspans, err := readZipkinFile(path)
+	if err != nil {
+		t.Fatalf("failed to read Zipkin traces: %s", err)
+	}
+	spans := []importedSpan{}
+	for _, zs := range zspans {
+		is, err := zs.importedSpan()
+		if err != nil {
+			t.Fatalf("failed to convert span: %s", err)
+		}
+		spans = append(spans, is)
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans but got %d", len(spans))
+	}
+
+	server := spans[0].span
+	if hex.EncodeToString(server.TraceId) != "0000000000000000463ac35c9f6413ad" {
+		t.Errorf("expected the 64 bit trace id to be padded but got %x", server.TraceId)
+	}
+	if server.StartTimeUnixNano != 1709994600000000000 || server.EndTimeUnixNano != 1709994600250000000 {
+		t.Errorf("unexpected times %d to %d", server.StartTimeUnixNano, server.EndTimeUnixNano)
+	}
+	if server.Kind != tracepb.Span_SPAN_KIND_SERVER || server.Status.Code != tracepb.Status_STATUS_CODE_ERROR || server.Status.Message != "" {
+		t.Errorf("unexpected kind %s and status %s", server.Kind, server.Status)
+	}
+	if len(server.Events) != 1 || server.Events[0].Name != "cache miss" || server.Events[0].TimeUnixNano != 1709994600100000000 {
+		t.Errorf("expected the annotation to become an event but got %v", server.Events)
+	}
+
+	client := spans[1].span
+	if hex.EncodeToString(client.ParentSpanId) != "a2fb4a1d1a96d312" || client.Status.Code != tracepb.Status_STATUS_CODE_OK {
+		t.Errorf("unexpected parent %x and status %s", client.ParentSpanId, client.Status)
+	}
+	got := map[string]string{}
+	for _, attr := range client.Attributes {
+		got[attr.Key] = attr.Value.String()
+	}
+	want := map[string]string{
+		"network.peer.address": `string_value:"::1"`,
+		"network.peer.port":    `int_value:5432`,
+		"peer.service":         `string_value:"postgres"`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+	}
+
+	if spans[2].span.Status.Message != "queue full" {
+		t.Errorf("expected the error tag to be the status message but got %q", spans[2].span.Status.Message)
+	}
+
+	// spans are grouped by service, then by scope
+	rsps := importResourceSpans(spans)
+	if len(rsps) != 2 {
+		t.Fatalf("expected 2 resources but got %d", len(rsps))
+	}
+	if rsps[0].Resource.Attributes[0].Value.GetStringValue() != "api" || len(rsps[0].ScopeSpans) != 2 || rsps[0].ScopeSpans[1].Scope.Name != "pgx" {
+		t.Errorf("unexpected resource spans %v", rsps[0])
+	}
+	if rsps[1].Resource.Attributes[0].Value.GetStringValue() != "worker" {
+		t.Errorf("unexpected resource %v", rsps[1].Resource)
+	}
+}
+
+func TestImportId(t *testing.T) {
+	if _, err := importId("463ac35c9f6413ad463ac35c9f6413ad00", 16); err == nil {
+		t.Error("expected an error for a trace id that's too long")
+	}
+	if _, err := importId("xyz", 8); err == nil {
+		t.Error("expected an error for an id that isn't hex")
+	}
+	if id, err := importId("1", 8); err != nil || hex.EncodeToString(id) != "0000000000000001" {
+		t.Errorf("expected a padded id but got %x, %v", id, err)
+	}
+}
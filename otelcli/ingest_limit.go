@@ -0,0 +1,100 @@
+package otelcli
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errIngestRateLimited = errors.New("dropped, over the --max-rate limit")
+	errIngestQueueFull   = errors.New("dropped, the --max-queue is full")
+)
+
+// ingestLimiter keeps a runaway loop calling otel-cli from flooding the
+// agent or a span background, and through them the collector. It allows up
+// to --max-rate a second, with bursts of up to a second's worth, and nothing
+// while --max-queue are already waiting. Zero turns either limit off.
+type ingestLimiter struct {
+	maxRate  int
+	maxQueue int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	rateLimited int
+	queueFull   int
+	logged      int // rateLimited + queueFull when the drops were last logged
+}
+
+// newIngestLimiter returns an ingestLimiter with the limits from the config.
+func newIngestLimiter(config Config) *ingestLimiter {
+	return &ingestLimiter{
+		maxRate:  config.IngestMaxRate,
+		maxQueue: config.IngestMaxQueue,
+		tokens:   float64(config.IngestMaxRate),
+	}
+}
+
+// allow returns nil if one more can be taken in at now, with queued already
+// waiting, or the reason it has to be dropped. Drops are counted here and in
+// Diag.
+func (il *ingestLimiter) allow(now time.Time, queued int) error {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+
+	if il.maxQueue > 0 && queued >= il.maxQueue {
+		il.queueFull++
+		Diag.DroppedQueueFull = il.queueFull
+		return errIngestQueueFull
+	}
+
+	if il.maxRate > 0 {
+		if !il.last.IsZero() {
+			il.tokens += now.Sub(il.last).Seconds() * float64(il.maxRate)
+			if il.tokens > float64(il.maxRate) {
+				il.tokens = float64(il.maxRate)
+			}
+		}
+		il.last = now
+		if il.tokens < 1 {
+			il.rateLimited++
+			Diag.DroppedRateLimited = il.rateLimited
+			return errIngestRateLimited
+		}
+		il.tokens--
+	}
+
+	return nil
+}
+
+// dropped returns how many were dropped for each limit so far.
+func (il *ingestLimiter) dropped() (rateLimited, queueFull int) {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+	return il.rateLimited, il.queueFull
+}
+
+// logDrops logs the drop counts when there have been new drops since the
+// last time, so a flood is reported once per flush instead of once per span.
+func (il *ingestLimiter) logDrops(config Config, what string) {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+	if total := il.rateLimited + il.queueFull; total > il.logged {
+		config.SoftLog("dropped %d %s so far, %d over --max-rate and %d with --max-queue full", total, what, il.rateLimited, il.queueFull)
+		il.logged = total
+	}
+}
+
+// WithIngestMaxRate returns the config with IngestMaxRate set to the provided value.
+func (c Config) WithIngestMaxRate(with int) Config {
+	c.IngestMaxRate = with
+	return c
+}
+
+// WithIngestMaxQueue returns the config with IngestMaxQueue set to the provided value.
+func (c Config) WithIngestMaxQueue(with int) Config {
+	c.IngestMaxQueue = with
+	return c
+}
@@ -0,0 +1,105 @@
+package otelcli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestIngestLimiterRate(t *testing.T) {
+	il := newIngestLimiter(DefaultConfig().WithIngestMaxRate(2))
+	now := time.Now()
+
+	// a second's worth can come in at once, then it has to wait for tokens
+	if il.allow(now, 0) != nil || il.allow(now, 0) != nil {
+		t.Fatal("expected a burst of --max-rate to be allowed")
+	}
+	if err := il.allow(now, 0); err != errIngestRateLimited {
+		t.Errorf("expected the third to be rate limited but got %v", err)
+	}
+	if err := il.allow(now.Add(500*time.Millisecond), 0); err != nil {
+		t.Errorf("expected one more to be allowed after half a second but got %s", err)
+	}
+	if err := il.allow(now.Add(600*time.Millisecond), 0); err != errIngestRateLimited {
+		t.Errorf("expected to be rate limited again but got %v", err)
+	}
+	// tokens don't pile up past a second's worth
+	if il.allow(now.Add(time.Hour), 0) != nil || il.allow(now.Add(time.Hour), 0) != nil || il.allow(now.Add(time.Hour), 0) == nil {
+		t.Error("expected the burst to be capped at --max-rate")
+	}
+
+	if rateLimited, queueFull := il.dropped(); rateLimited != 3 || queueFull != 0 {
+		t.Errorf("expected 3 rate limited and 0 queue full drops but got %d and %d", rateLimited, queueFull)
+	}
+}
+
+func TestIngestLimiterQueue(t *testing.T) {
+	il := newIngestLimiter(DefaultConfig().WithIngestMaxQueue(10))
+	if err := il.allow(time.Now(), 9); err != nil {
+		t.Errorf("expected room for one more but got %s", err)
+	}
+	if err := il.allow(time.Now(), 10); err != errIngestQueueFull {
+		t.Errorf("expected the queue to be full but got %v", err)
+	}
+	if _, queueFull := il.dropped(); queueFull != 1 || Diag.DroppedQueueFull != 1 {
+		t.Errorf("expected 1 queue full drop counted and in Diag but got %d and %d", queueFull, Diag.DroppedQueueFull)
+	}
+}
+
+func TestIngestLimiterOff(t *testing.T) {
+	il := newIngestLimiter(DefaultConfig())
+	for i := 0; i < 10000; i++ {
+		if err := il.allow(time.Now(), i); err != nil {
+			t.Fatalf("expected no limits by default but got %s", err)
+		}
+	}
+}
+
+func TestAgentMaxQueue(t *testing.T) {
+	agent := newAgentServer(DefaultConfig().WithAgentBatchSize(0).WithIngestMaxQueue(2), &agentTestClient{})
+	for i := 0; i < 5; i++ {
+		agent.callback(context.Background(), otlpclient.NewProtobufSpan(), nil, &tracepb.ResourceSpans{}, nil, nil)
+	}
+	if len(agent.buffer) != 2 {
+		t.Errorf("expected 2 spans buffered but got %d", len(agent.buffer))
+	}
+	if _, queueFull := agent.limiter.dropped(); queueFull != 3 {
+		t.Errorf("expected 3 spans dropped but got %d", queueFull)
+	}
+}
+
+func TestBgSpanAddEventMaxRate(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	bs := BgSpan{
+		config:  DefaultConfig(),
+		span:    span,
+		limiter: newIngestLimiter(DefaultConfig().WithIngestMaxRate(1)),
+	}
+	event := BgSpanEvent{Name: "loop", Timestamp: time.Now().Format(time.RFC3339Nano)}
+
+	if err := bs.AddEvent(&event, &BgSpan{}); err != nil {
+		t.Fatalf("expected the first event to be added but got %s", err)
+	}
+	reply := BgSpan{}
+	if err := bs.AddEvent(&event, &reply); err == nil || reply.Error == "" {
+		t.Error("expected the second event to be rate limited")
+	}
+	if len(span.Events) != 1 || span.DroppedEventsCount != 1 {
+		t.Errorf("expected 1 event and 1 dropped but got %d and %d", len(span.Events), span.DroppedEventsCount)
+	}
+}
+
+func TestWithIngestMaxRate(t *testing.T) {
+	if DefaultConfig().WithIngestMaxRate(100).IngestMaxRate != 100 {
+		t.Fail()
+	}
+}
+
+func TestWithIngestMaxQueue(t *testing.T) {
+	if DefaultConfig().WithIngestMaxQueue(10000).IngestMaxQueue != 10000 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,434 @@
+package otelcli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/spf13/cobra"
+)
+
+// k8sServiceAccountDir is where Kubernetes mounts a pod's service account.
+const k8sServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// k8sAnnotationPrefix prefixes the annotations carrying the traceparent on
+// pods and jobs. ConfigMaps use the bare names as data keys so they can be
+// mounted as carrier files.
+const k8sAnnotationPrefix = "otel-cli/"
+
+// k8sTp holds the command-line configured settings for otel-cli k8s.
+var k8sTp struct {
+	namespace   string
+	apiUrl      string
+	downwardApi string
+	wait        bool
+}
+
+// k8sErrNotFound is returned when the object or its traceparent doesn't exist.
+var k8sErrNotFound = errors.New("not found")
+
+func k8sCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "k8s",
+		Short: "Kubernetes helpers",
+		Long:  "Helpers for sharing traceparents between pods through the Kubernetes API. See subcommands.",
+	}
+
+	cmd.AddCommand(k8sTpExportCmd(config))
+	cmd.AddCommand(k8sTpImportCmd(config))
+
+	return &cmd
+}
+
+func k8sTpExportCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "tp-export [pod/NAME | job/NAME | configmap/NAME]",
+		Short: "save the current traceparent on a Kubernetes object",
+		Long: `Save the current traceparent, from the TRACEPARENT envvar or --tp-carrier, on a
+pod or job as otel-cli/traceparent annotations, or in a ConfigMap under the
+traceparent key, creating the ConfigMap if it doesn't exist. Other pods can
+read it with 'otel-cli k8s tp-import', e.g. from an init container, so
+several pods in a batch workflow can share one trace. With no object, the
+traceparent is saved on the current pod, named by POD_NAME or HOSTNAME.
+
+otel-cli uses the pod's service account, which needs get and patch on the
+object, and create for ConfigMaps. --kube-api-url can point at e.g. kubectl
+proxy outside of a cluster.
+
+Example:
+	otel-cli exec --name "nightly batch" -- \
+		otel-cli k8s tp-export configmap/nightly-batch-trace
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  doK8sTpExport,
+	}
+
+	cmd.Flags().SortFlags = false
+	addK8sParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.TraceparentCarrierFile, "tp-carrier", defaults.TraceparentCarrierFile, "a file to read the traceparent from")
+	cmd.Flags().BoolVar(&config.TraceparentIgnoreEnv, "tp-ignore-env", defaults.TraceparentIgnoreEnv, "ignore the TRACEPARENT envvar even if it's set")
+
+	return &cmd
+}
+
+func k8sTpImportCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "tp-import [pod/NAME | job/NAME | configmap/NAME]",
+		Short: "print a traceparent saved with k8s tp-export",
+		Long: `Read a traceparent saved with 'otel-cli k8s tp-export' from a pod, job, or
+ConfigMap and print it. The output format follows --tp-print-format and
+--tp-export. With --wait, tp-import polls until the traceparent is there or
+--timeout is reached, which lets an init container wait for another pod.
+
+--downward-api reads the annotations from a downward API volume file
+instead, so a pod can pick up a traceparent annotated on its own pod
+template without any API access.
+
+Example:
+	eval $(otel-cli k8s tp-import configmap/nightly-batch-trace --wait --timeout 5m --tp-export)
+	eval $(otel-cli k8s tp-import --downward-api /etc/podinfo/annotations --tp-export)
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  doK8sTpImport,
+	}
+
+	cmd.Flags().SortFlags = false
+	addK8sParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&k8sTp.downwardApi, "downward-api", "", "read the annotations from this downward API volume file instead of the API")
+	cmd.Flags().BoolVar(&k8sTp.wait, "wait", false, "wait until the traceparent is saved, up to --timeout")
+	cmd.Flags().BoolVarP(&config.TraceparentPrintExport, "tp-export", "p", defaults.TraceparentPrintExport, "put an 'export ' in front of the traceparent so it's more convinenient to source in scripts")
+	cmd.Flags().StringVar(&config.TraceparentPrintFormat, "tp-print-format", defaults.TraceparentPrintFormat, "output format, one of w3c, hex, trace-id, span-id, xray, b3, or json")
+
+	return &cmd
+}
+
+// addK8sParams adds the flags shared by the k8s subcommands.
+func addK8sParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+
+	cmd.Flags().StringVarP(&k8sTp.namespace, "namespace", "N", "", "the object's namespace, defaults to POD_NAMESPACE or the pod's own namespace")
+	cmd.Flags().StringVar(&k8sTp.apiUrl, "kube-api-url", "", "Kubernetes API URL, defaults to the in-cluster API with the pod's service account")
+	cmd.Flags().StringVarP(&config.CfgFile, "config", "c", defaults.CfgFile, "YAML or JSON configuration file, defaults to ~/.config/otel-cli/config.yaml if it exists")
+	cmd.Flags().StringVar(&config.Profile, "profile", defaults.Profile, "name of a profile in the configuration file to apply")
+	cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for API requests and for --wait")
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(cmd, config)
+	cmd.Flags().BoolVar(&config.Fail, "fail", defaults.Fail, "on failure, exit with a non-zero status")
+}
+
+func doK8sTpExport(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	tp := config.LoadTraceparent()
+	if !tp.Initialized {
+		config.SoftFail("no traceparent found in TRACEPARENT or --tp-carrier to export")
+	}
+	carrier := config.LoadCarrier()
+	carrier.Traceparent = tp.Encode()
+
+	kind, name, err := k8sObject(args, os.Getenv)
+	config.SoftFailIfErr(err)
+	api, err := newK8sApi(config)
+	config.SoftFailIfErr(err)
+
+	err = api.saveCarrier(cmd.Context(), kind, name, carrier)
+	config.SoftFailIfErr(err)
+}
+
+func doK8sTpImport(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	var read func() (traceparent.Carrier, error)
+	if k8sTp.downwardApi != "" {
+		read = func() (traceparent.Carrier, error) { return readK8sDownwardApi(k8sTp.downwardApi) }
+	} else {
+		kind, name, err := k8sObject(args, os.Getenv)
+		config.SoftFailIfErr(err)
+		api, err := newK8sApi(config)
+		config.SoftFailIfErr(err)
+		read = func() (traceparent.Carrier, error) { return api.loadCarrier(cmd.Context(), kind, name) }
+	}
+
+	started := time.Now()
+	timeout := config.ParseCliTimeout()
+	for {
+		carrier, err := read()
+		if err == nil {
+			tp, err := carrier.GetTraceparent()
+			config.SoftFailIfErr(err)
+			config.PrintTraceparent(tp, os.Stdout)
+			return
+		} else if !errors.Is(err, k8sErrNotFound) || !k8sTp.wait {
+			config.SoftFail("%s", err)
+		} else if timeout > 0 && time.Since(started) > timeout {
+			config.SoftFail("timeout after %s waiting for the traceparent: %s", timeout, err)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// k8sObject returns the kind and name from a kind/name argument, or the
+// current pod when there isn't one.
+func k8sObject(args []string, getenv func(string) string) (string, string, error) {
+	if len(args) == 0 {
+		name := firstEnv(getenv, "POD_NAME", "HOSTNAME")
+		if name == "" {
+			return "", "", fmt.Errorf("no object given and POD_NAME and HOSTNAME aren't set to find the current pod")
+		}
+		return "pod", name, nil
+	}
+
+	kind, name, ok := strings.Cut(args[0], "/")
+	kind = strings.TrimSuffix(strings.ToLower(kind), "s")
+	if kind == "cm" {
+		kind = "configmap"
+	}
+	if !ok || name == "" || (kind != "pod" && kind != "job" && kind != "configmap") {
+		return "", "", fmt.Errorf("invalid object %q, must be pod/NAME, job/NAME, or configmap/NAME", args[0])
+	}
+	return kind, name, nil
+}
+
+// k8sApi is a minimal client for the Kubernetes API.
+type k8sApi struct {
+	url       string
+	token     string
+	namespace string
+	client    *http.Client
+	timeout   time.Duration
+}
+
+// newK8sApi returns a client for --kube-api-url, or the in-cluster API with
+// the pod's service account.
+func newK8sApi(config Config) (k8sApi, error) {
+	api := k8sApi{
+		url:       k8sTp.apiUrl,
+		namespace: firstEnv(os.Getenv, "POD_NAMESPACE"),
+		client:    http.DefaultClient,
+		timeout:   config.ParseCliTimeout(),
+	}
+	if k8sTp.namespace != "" {
+		api.namespace = k8sTp.namespace
+	}
+
+	if api.url == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return api, fmt.Errorf("not running in a Kubernetes pod, set --kube-api-url to use the API from outside of a cluster")
+		}
+		api.url = "https://" + net.JoinHostPort(host, port)
+
+		token, err := os.ReadFile(k8sServiceAccountDir + "/token")
+		if err != nil {
+			return api, fmt.Errorf("unable to read the service account token: %w", err)
+		}
+		api.token = strings.TrimSpace(string(token))
+
+		ca, err := os.ReadFile(k8sServiceAccountDir + "/ca.crt")
+		if err != nil {
+			return api, fmt.Errorf("unable to read the service account CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		api.client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	}
+
+	if api.namespace == "" {
+		if ns, err := os.ReadFile(k8sServiceAccountDir + "/namespace"); err == nil {
+			api.namespace = strings.TrimSpace(string(ns))
+		}
+	}
+	if api.namespace == "" {
+		api.namespace = "default"
+	}
+
+	return api, nil
+}
+
+// path returns the API path for the object.
+func (api k8sApi) path(kind, name string) string {
+	switch kind {
+	case "job":
+		return fmt.Sprintf("/apis/batch/v1/namespaces/%s/jobs/%s", api.namespace, name)
+	case "configmap":
+		return fmt.Sprintf("/api/v1/namespaces/%s/configmaps/%s", api.namespace, name)
+	default:
+		return fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", api.namespace, name)
+	}
+}
+
+// saveCarrier merge patches the carrier onto the object. Empty tracestate
+// and baggage are patched to null so stale ones from before are removed.
+func (api k8sApi) saveCarrier(ctx context.Context, kind, name string, carrier traceparent.Carrier) error {
+	values := map[string]interface{}{"traceparent": carrier.Traceparent, "tracestate": nil, "baggage": nil}
+	if carrier.Tracestate != "" {
+		values["tracestate"] = carrier.Tracestate
+	}
+	if carrier.Baggage != "" {
+		values["baggage"] = carrier.Baggage
+	}
+
+	if kind != "configmap" {
+		annotations := map[string]interface{}{}
+		for k, v := range values {
+			annotations[k8sAnnotationPrefix+k] = v
+		}
+		patch := map[string]interface{}{"metadata": map[string]interface{}{"annotations": annotations}}
+		return api.do(ctx, http.MethodPatch, api.path(kind, name), patch, nil)
+	}
+
+	err := api.do(ctx, http.MethodPatch, api.path(kind, name), map[string]interface{}{"data": values}, nil)
+	if !errors.Is(err, k8sErrNotFound) {
+		return err
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range values {
+		if v != nil {
+			data[k] = v
+		}
+	}
+	cm := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": name},
+		"data":       data,
+	}
+	return api.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/namespaces/%s/configmaps", api.namespace), cm, nil)
+}
+
+// loadCarrier reads the carrier from the object, returning k8sErrNotFound
+// when the object or its traceparent isn't there yet.
+func (api k8sApi) loadCarrier(ctx context.Context, kind, name string) (traceparent.Carrier, error) {
+	obj := struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Data map[string]string `json:"data"`
+	}{}
+	if err := api.do(ctx, http.MethodGet, api.path(kind, name), nil, &obj); err != nil {
+		return traceparent.Carrier{}, err
+	}
+
+	values := obj.Data
+	if kind != "configmap" {
+		values = map[string]string{}
+		for k, v := range obj.Metadata.Annotations {
+			values[strings.TrimPrefix(k, k8sAnnotationPrefix)] = v
+		}
+	}
+	return k8sCarrier(values, fmt.Sprintf("%s/%s", kind, name))
+}
+
+// do sends the request with body as JSON, a merge patch for PATCH, and
+// decodes the response into out when it's set.
+func (api k8sApi) do(ctx context.Context, method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, api.timeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(api.url, "/")+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	} else if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if api.token != "" {
+		req.Header.Set("Authorization", "Bearer "+api.token)
+	}
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, k8sErrNotFound)
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		status := struct {
+			Message string `json:"message"`
+		}{}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&status)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, status.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// readK8sDownwardApi reads the carrier from the annotations file of a
+// downward API volume, which has a key="value" line per annotation with the
+// value quoted like a Go string.
+func readK8sDownwardApi(path string) (traceparent.Carrier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return traceparent.Carrier{}, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, quoted, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || !strings.HasPrefix(key, k8sAnnotationPrefix) {
+			continue
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return traceparent.Carrier{}, fmt.Errorf("invalid annotation %s in %s: %w", key, path, err)
+		}
+		values[strings.TrimPrefix(key, k8sAnnotationPrefix)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return traceparent.Carrier{}, err
+	}
+
+	return k8sCarrier(values, path)
+}
+
+// k8sCarrier returns the carrier from the traceparent, tracestate, and
+// baggage values, or k8sErrNotFound if there's no traceparent.
+func k8sCarrier(values map[string]string, from string) (traceparent.Carrier, error) {
+	if values["traceparent"] == "" {
+		return traceparent.Carrier{}, fmt.Errorf("no traceparent on %s: %w", from, k8sErrNotFound)
+	}
+	return traceparent.Carrier{
+		Version:     traceparent.CarrierVersion,
+		Traceparent: values["traceparent"],
+		Tracestate:  values["tracestate"],
+		Baggage:     values["baggage"],
+	}, nil
+}
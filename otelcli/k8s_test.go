@@ -0,0 +1,210 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeK8sApi stores the JSON of each object by path and applies merge
+// patches the way the API server does for the fields otel-cli uses.
+type fakeK8sApi struct {
+	mu      sync.Mutex
+	objects map[string]map[string]interface{}
+}
+
+func (f *fakeK8sApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	obj := map[string]interface{}{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &obj); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if existing, ok := f.objects[r.URL.Path]; ok {
+			json.NewEncoder(w).Encode(existing)
+			return
+		}
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			http.Error(w, "unsupported patch type", http.StatusUnsupportedMediaType)
+			return
+		}
+		if existing, ok := f.objects[r.URL.Path]; ok {
+			mergePatch(existing, obj)
+			return
+		}
+	case http.MethodPost:
+		name := obj["metadata"].(map[string]interface{})["name"].(string)
+		f.objects[r.URL.Path+"/"+name] = obj
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func mergePatch(target, patch map[string]interface{}) {
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+		} else if p, ok := v.(map[string]interface{}); ok {
+			t, ok := target[k].(map[string]interface{})
+			if !ok {
+				t = map[string]interface{}{}
+				target[k] = t
+			}
+			mergePatch(t, p)
+		} else {
+			target[k] = v
+		}
+	}
+}
+
+func TestK8sTpExportImport(t *testing.T) {
+	podPath := "/api/v1/namespaces/batch/pods/runner-0"
+	fake := &fakeK8sApi{objects: map[string]map[string]interface{}{
+		podPath: {"metadata": map[string]interface{}{
+			"name":        "runner-0",
+			"annotations": map[string]interface{}{"otel-cli/tracestate": "stale=1", "team": "data"},
+		}},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	api := k8sApi{url: server.URL, namespace: "batch", client: server.Client(), timeout: time.Second}
+	ctx := context.Background()
+	carrier := traceparent.Carrier{
+		Version:     traceparent.CarrierVersion,
+		Traceparent: "00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01",
+		Baggage:     "run=42",
+	}
+
+	// pods get annotations, leaving the others alone and clearing stale ones
+	if err := api.saveCarrier(ctx, "pod", "runner-0", carrier); err != nil {
+		t.Fatalf("failed to export to the pod: %s", err)
+	}
+	wantAnnotations := map[string]interface{}{
+		"otel-cli/traceparent": carrier.Traceparent,
+		"otel-cli/baggage":     "run=42",
+		"team":                 "data",
+	}
+	gotAnnotations := fake.objects[podPath]["metadata"].(map[string]interface{})["annotations"]
+	if diff := cmp.Diff(wantAnnotations, gotAnnotations); diff != "" {
+		t.Errorf("pod annotations did not match (-want +got):\n%s", diff)
+	}
+	got, err := api.loadCarrier(ctx, "pod", "runner-0")
+	if err != nil {
+		t.Fatalf("failed to import from the pod: %s", err)
+	}
+	if diff := cmp.Diff(carrier, got); diff != "" {
+		t.Errorf("pod carrier did not match (-want +got):\n%s", diff)
+	}
+
+	// configmaps are created when they don't exist yet
+	if _, err := api.loadCarrier(ctx, "configmap", "trace"); !errors.Is(err, k8sErrNotFound) {
+		t.Errorf("expected not found before the configmap was created but got %v", err)
+	}
+	if err := api.saveCarrier(ctx, "configmap", "trace", carrier); err != nil {
+		t.Fatalf("failed to export to the configmap: %s", err)
+	}
+	wantData := map[string]interface{}{"traceparent": carrier.Traceparent, "baggage": "run=42"}
+	if diff := cmp.Diff(wantData, fake.objects["/api/v1/namespaces/batch/configmaps/trace"]["data"]); diff != "" {
+		t.Errorf("configmap data did not match (-want +got):\n%s", diff)
+	}
+
+	// and patched after that
+	carrier.Traceparent = "00-f61fc53f926e07a9c3893b1a722e1b65-1111111111111111-01"
+	carrier.Baggage = ""
+	if err := api.saveCarrier(ctx, "configmap", "trace", carrier); err != nil {
+		t.Fatalf("failed to export to the existing configmap: %s", err)
+	}
+	got, err = api.loadCarrier(ctx, "configmap", "trace")
+	if err != nil {
+		t.Fatalf("failed to import from the configmap: %s", err)
+	}
+	if diff := cmp.Diff(carrier, got); diff != "" {
+		t.Errorf("configmap carrier did not match (-want +got):\n%s", diff)
+	}
+
+	if err := api.saveCarrier(ctx, "job", "missing", carrier); !errors.Is(err, k8sErrNotFound) {
+		t.Errorf("expected not found exporting to a missing job but got %v", err)
+	}
+}
+
+func TestReadK8sDownwardApi(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotations")
+	annotations := `kubectl.kubernetes.io/default-container="main"
+otel-cli/traceparent="00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01"
+otel-cli/baggage="user=\"bob\""
+`
+	if err := os.WriteFile(path, []byte(annotations), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := traceparent.Carrier{
+		Version:     traceparent.CarrierVersion,
+		Traceparent: "00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01",
+		Baggage:     `user="bob"`,
+	}
+	got, err := readK8sDownwardApi(path)
+	if err != nil {
+		t.Fatalf("failed to read downward API annotations: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("carrier did not match (-want +got):\n%s", diff)
+	}
+
+	if err := os.WriteFile(path, []byte("team=\"data\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readK8sDownwardApi(path); !errors.Is(err, k8sErrNotFound) {
+		t.Errorf("expected not found without a traceparent annotation but got %v", err)
+	}
+}
+
+func TestK8sObject(t *testing.T) {
+	env := map[string]string{"HOSTNAME": "runner-0"}
+	getenv := func(name string) string { return env[name] }
+
+	for _, tc := range []struct {
+		args       []string
+		kind, name string
+		fail       bool
+	}{
+		{args: []string{}, kind: "pod", name: "runner-0"},
+		{args: []string{"pod/web"}, kind: "pod", name: "web"},
+		{args: []string{"jobs/nightly"}, kind: "job", name: "nightly"},
+		{args: []string{"ConfigMap/trace"}, kind: "configmap", name: "trace"},
+		{args: []string{"cm/trace"}, kind: "configmap", name: "trace"},
+		{args: []string{"secret/trace"}, fail: true},
+		{args: []string{"pod"}, fail: true},
+		{args: []string{"pod/"}, fail: true},
+	} {
+		kind, name, err := k8sObject(tc.args, getenv)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("expected an error for %q", tc.args)
+			}
+		} else if err != nil || kind != tc.kind || name != tc.name {
+			t.Errorf("k8sObject(%q) = %q, %q, %v, want %q, %q", tc.args, kind, name, err, tc.kind, tc.name)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package otelcli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// with --stdin, lines are sent in batches of up to logBatchSize records, or
+// whatever has been read once logFlushInterval passes
+const (
+	logBatchSize     = 100
+	logFlushInterval = time.Second
+)
+
+// logSeverities maps the severity names to the lowest severity number in
+// their range. A 2, 3, or 4 suffix selects the higher numbers in the range,
+// e.g. debug2, the same as SeverityText in the OTel logs data model.
+var logSeverities = map[string]logspb.SeverityNumber{
+	"trace": logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	"debug": logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	"info":  logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	"warn":  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	"error": logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	"fatal": logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+// logCmd represents the log command
+func logCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "log [key=value...]",
+		Short: "create an OpenTelemetry log record and send it",
+		Long: `Send a log record to the OTLP logs service, with any key=value arguments
+added to --attrs as attributes of the record. When a traceparent is picked up
+from TRACEPARENT or --tp-carrier, its trace and span ids are set on the record
+so backends can correlate it with the trace.
+
+With --stdin, every line read from stdin is sent as a record until EOF, so the
+output of a program can be forwarded as logs. --logs-endpoint and --logs-headers
+override the general settings for logs. Logs are always sent directly, without
+the agent or --async.
+
+Example:
+	otel-cli log --severity error --body "disk full" mount=/var free=0
+	./backup.sh 2>&1 | otel-cli log --stdin --severity info
+`,
+		Run: doLog,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&config.LogSeverity, "severity", defaults.LogSeverity, "severity of the record, one of trace, debug, info, warn, error, or fatal")
+	cmd.Flags().StringVar(&config.LogBody, "body", defaults.LogBody, "body of the record")
+	cmd.Flags().BoolVar(&config.LogStdin, "stdin", defaults.LogStdin, "send each line read from stdin as a record instead of --body")
+	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the log records")
+
+	addCommonParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doLog(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	attrs, err := parseLogArgs(config.Attributes, args)
+	config.SoftFailIfErr(err)
+	config = config.WithAttributes(attrs)
+
+	if _, _, err := config.GetLogSeverity(); err != nil {
+		config.SoftFail(err.Error())
+	}
+	if config.LogStdin && config.LogBody != "" {
+		config.SoftFail("--body can't be used with --stdin")
+	} else if !config.LogStdin && config.LogBody == "" {
+		config.SoftFail("--body is required unless --stdin is set")
+	}
+
+	ctx, client := StartLogsClient(ctx, config)
+	tp := config.LoadTraceparent()
+
+	if config.LogStdin {
+		dropped := forwardLogLines(ctx, config, client, tp, os.Stdin)
+		if dropped > 0 {
+			config.SoftFail("%d log records could not be sent", dropped)
+		}
+	} else {
+		err := sendLogs(ctx, config, client, []*logspb.LogRecord{config.newLogRecord(config.LogBody, tp)})
+		config.SoftFailIfErr(err)
+	}
+
+	_, err = client.Stop(ctx)
+	config.SoftFailIfErr(err)
+}
+
+// forwardLogLines sends each line read from input as a log record, batching
+// lines that arrive close together. A batch that fails to send is logged and
+// dropped so the rest of the input keeps flowing. Returns the number of
+// records dropped.
+func forwardLogLines(ctx context.Context, config Config, client otlpclient.OTLPLogsClient, tp traceparent.Traceparent, input io.Reader) int {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		if err := scanner.Err(); err != nil {
+			config.SoftLog("failed to read log lines: %s", err)
+		}
+	}()
+
+	var dropped int
+	batch := []*logspb.LogRecord{}
+	flush := func() {
+		if err := sendLogs(ctx, config, client, batch); err != nil {
+			config.SoftLog("failed to send %d log records: %s", len(batch), err)
+			dropped += len(batch)
+		}
+		batch = []*logspb.LogRecord{}
+	}
+
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return dropped
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			batch = append(batch, config.newLogRecord(line, tp))
+			if len(batch) >= logBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				flush()
+			}
+		}
+	}
+}
+
+// sendLogs sends the records with a deadline of --timeout for each send, so
+// forwarding stdin can run for as long as the input does.
+func sendLogs(ctx context.Context, config Config, client otlpclient.OTLPLogsClient, records []*logspb.LogRecord) error {
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	_, err := otlpclient.SendLogs(ctx, client, config.withSignal("logs"), records)
+	return err
+}
+
+// StartLogsClient returns a started client for the logs endpoint.
+func StartLogsClient(ctx context.Context, config Config) (context.Context, otlpclient.OTLPLogsClient) {
+	config = config.withSignal("logs")
+	client, ok := newSignalClient(ctx, config).(otlpclient.OTLPLogsClient)
+	if !ok {
+		config.SoftFail("%s:// endpoints don't support logs", config.GetEndpoint().Scheme)
+	}
+
+	ctx = otlpclient.WithLogger(ctx, config.Logger())
+	ctx = config.withWireDump(ctx)
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		Diag.Error = err.Error()
+		config.SoftFail("Failed to start OTLP client: %s", err)
+	}
+
+	return ctx, client
+}
+
+// parseLogArgs returns the attributes with the key=value arguments added,
+// the arguments taking precedence.
+func parseLogArgs(attrs map[string]string, args []string) (map[string]string, error) {
+	out := make(map[string]string, len(attrs)+len(args))
+	for k, v := range attrs {
+		out[k] = v
+	}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("argument %q must be in key=value format", arg)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// newLogRecord returns a log record with the body and the severity and
+// attributes from the config. When the traceparent has a trace id, the
+// record carries its trace and span ids and sampled flag.
+func (c Config) newLogRecord(body string, tp traceparent.Traceparent) *logspb.LogRecord {
+	severity, severityText, err := c.GetLogSeverity()
+	c.SoftFailIfErr(err)
+
+	now := uint64(time.Now().UnixNano())
+	record := &logspb.LogRecord{
+		TimeUnixNano:         now,
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       severity,
+		SeverityText:         severityText,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: body}},
+		Attributes:           c.GetAttributes(),
+	}
+
+	if tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		record.TraceId = tp.TraceId
+		record.SpanId = tp.SpanId
+		if tp.Sampling {
+			// the low byte of the flags is the W3C trace flags
+			record.Flags = 0x01
+		}
+	}
+
+	return record
+}
+
+// GetLogSeverity returns the severity number and text for --severity, e.g.
+// SEVERITY_NUMBER_WARN and "WARN" for warn or warning.
+func (c Config) GetLogSeverity() (logspb.SeverityNumber, string, error) {
+	name := strings.ToLower(strings.TrimSpace(c.LogSeverity))
+	var step int32
+	if n := len(name); n > 1 && name[n-1] >= '2' && name[n-1] <= '4' {
+		step = int32(name[n-1] - '1')
+		name = name[:n-1]
+	}
+	if name == "warning" {
+		name = "warn"
+	}
+
+	severity, ok := logSeverities[name]
+	if !ok {
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "", fmt.Errorf("invalid log severity %q, must be one of trace, debug, info, warn, error, or fatal", c.LogSeverity)
+	}
+
+	text := strings.ToUpper(name)
+	if step > 0 {
+		text += fmt.Sprint(step + 1)
+	}
+	return severity + logspb.SeverityNumber(step), text, nil
+}
+
+// WithLogSeverity returns the config with LogSeverity set to the provided value.
+func (c Config) WithLogSeverity(with string) Config {
+	c.LogSeverity = with
+	return c
+}
+
+// WithLogBody returns the config with LogBody set to the provided value.
+func (c Config) WithLogBody(with string) Config {
+	c.LogBody = with
+	return c
+}
+
+// WithLogStdin returns the config with LogStdin set to the provided value.
+func (c Config) WithLogStdin(with bool) Config {
+	c.LogStdin = with
+	return c
+}
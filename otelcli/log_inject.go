@@ -0,0 +1,180 @@
+package otelcli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/spf13/cobra"
+)
+
+// logInject holds the command-line configured settings for otel-cli log-inject.
+var logInject struct {
+	format     string
+	traceIdKey string
+	spanIdKey  string
+}
+
+func logInjectCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "log-inject",
+		Short: "add trace and span ids to log lines on stdin",
+		Long: `Reads log lines from stdin, adds the trace and span ids from TRACEPARENT or
+--tp-carrier to each, and writes them to stdout, so log shippers pick up the
+ids to correlate logs with traces without changing the app.
+
+With --format json, the ids are added as fields to lines that are JSON
+objects. With --format logfmt, they're appended to the line as key=value
+pairs. Lines that already have the keys, lines that aren't JSON objects with
+--format json, and blank lines are passed through unchanged, as is
+everything when there is no traceparent.
+
+Example:
+	some-app | otel-cli log-inject --format json | log-shipper
+	otel-cli exec --name deploy -- sh -c './deploy.sh 2>&1 | otel-cli log-inject --format logfmt'
+`,
+		Args: cobra.NoArgs,
+		Run:  doLogInject,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&logInject.format, "format", "json", "format of the log lines: json or logfmt")
+	cmd.Flags().StringVar(&logInject.traceIdKey, "trace-id-key", "trace_id", "the key to put the trace id under")
+	cmd.Flags().StringVar(&logInject.spanIdKey, "span-id-key", "span_id", "the key to put the span id under")
+	cmd.Flags().StringVarP(&config.CfgFile, "config", "c", defaults.CfgFile, "YAML or JSON configuration file, defaults to ~/.config/otel-cli/config.yaml if it exists")
+	cmd.Flags().StringVar(&config.Profile, "profile", defaults.Profile, "name of a profile in the configuration file to apply")
+	cmd.Flags().StringVar(&config.TraceparentCarrierFile, "tp-carrier", defaults.TraceparentCarrierFile, "a file to read the traceparent from")
+	cmd.Flags().BoolVar(&config.TraceparentIgnoreEnv, "tp-ignore-env", defaults.TraceparentIgnoreEnv, "ignore the TRACEPARENT envvar even if it's set")
+	cmd.Flags().BoolVar(&config.TraceparentRequired, "tp-required", defaults.TraceparentRequired, "when set to true, fail and log if a traceparent can't be picked up from TRACEPARENT ennvar or a carrier file")
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(&cmd, config)
+	cmd.Flags().BoolVar(&config.Fail, "fail", defaults.Fail, "on failure, exit with a non-zero status")
+
+	return &cmd
+}
+
+func doLogInject(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	if logInject.format != "json" && logInject.format != "logfmt" {
+		config.SoftFail("invalid --format %q, must be json or logfmt", logInject.format)
+	}
+
+	tp := config.LoadTraceparent()
+	li := newLogInjector(logInject.format, logInject.traceIdKey, logInject.spanIdKey, tp)
+	if li.fields == nil {
+		config.SoftLog("no traceparent found, passing log lines through unchanged")
+	}
+
+	config.SoftFailIfErr(li.copy(os.Stdin, os.Stdout))
+}
+
+// logInjector adds trace and span ids to log lines.
+type logInjector struct {
+	format string
+	fields [][2]string // key and value, nil when there's nothing to add
+}
+
+// newLogInjector returns a logInjector for lines in format that adds the ids
+// from tp under the keys. It adds nothing when tp has no trace id.
+func newLogInjector(format, traceIdKey, spanIdKey string, tp traceparent.Traceparent) logInjector {
+	li := logInjector{format: format}
+	if tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		li.fields = [][2]string{
+			{traceIdKey, tp.TraceIdString()},
+			{spanIdKey, tp.SpanIdString()},
+		}
+	}
+	return li
+}
+
+// copy injects the ids into every line from r and writes them to w. Lines are
+// written as soon as they're read so a slow stream of logs isn't held up.
+func (li logInjector) copy(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if _, werr := io.WriteString(w, li.inject(line)); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// inject returns the line with the ids added, keeping its line ending, or the
+// line as it was when they can't or shouldn't be added.
+func (li logInjector) inject(line string) string {
+	body := strings.TrimRightFunc(line, unicode.IsSpace)
+	if body == "" || li.fields == nil {
+		return line
+	}
+	trailing := line[len(body):]
+
+	switch li.format {
+	case "json":
+		// decoding only checks the line is an object and finds its keys, the
+		// ids are added to the original text so its field order and formatting
+		// are kept
+		obj := map[string]json.RawMessage{}
+		if !strings.HasSuffix(body, "}") || json.Unmarshal([]byte(body), &obj) != nil {
+			return line
+		}
+		out := strings.Builder{}
+		out.WriteString(body[:len(body)-1])
+		sep := ","
+		if len(obj) == 0 {
+			sep = ""
+		}
+		added := false
+		for _, field := range li.fields {
+			if _, ok := obj[field[0]]; ok {
+				continue
+			}
+			key, _ := json.Marshal(field[0])
+			value, _ := json.Marshal(field[1])
+			out.WriteString(sep)
+			out.Write(key)
+			out.WriteString(":")
+			out.Write(value)
+			sep, added = ",", true
+		}
+		if !added {
+			return line
+		}
+		out.WriteString("}")
+		out.WriteString(trailing)
+		return out.String()
+	case "logfmt":
+		out := strings.Builder{}
+		out.WriteString(body)
+		added := false
+		for _, field := range li.fields {
+			if strings.HasPrefix(body, field[0]+"=") || strings.Contains(body, " "+field[0]+"=") {
+				continue
+			}
+			out.WriteString(" " + field[0] + "=" + field[1])
+			added = true
+		}
+		if !added {
+			return line
+		}
+		out.WriteString(trailing)
+		return out.String()
+	}
+
+	return line
+}
@@ -0,0 +1,81 @@
+package otelcli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+)
+
+func logInjectTestTp(t *testing.T) traceparent.Traceparent {
+	tp, err := traceparent.Parse("00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01")
+	if err != nil {
+		t.Fatalf("failed to parse traceparent: %s", err)
+	}
+	return tp
+}
+
+func TestLogInjectJson(t *testing.T) {
+	li := newLogInjector("json", "trace_id", "span_id", logInjectTestTp(t))
+	ids := `"trace_id":"f6c109f48195b451c4def6ab32f47b61","span_id":"a5d2a35f2483004e"`
+
+	for line, want := range map[string]string{
+		`{"msg":"hello","level":"info"}` + "\n":     `{"msg":"hello","level":"info",` + ids + "}\n",
+		`{ "msg": "spaced" }` + "\r\n":              `{ "msg": "spaced" ,` + ids + "}\r\n",
+		`{}`:                                        `{` + ids + `}`,
+		`{"msg":"mine","trace_id":"abc"}` + "\n":    `{"msg":"mine","trace_id":"abc","span_id":"a5d2a35f2483004e"}` + "\n",
+		`{"trace_id":"abc","span_id":"def"}` + "\n": `{"trace_id":"abc","span_id":"def"}` + "\n",
+		"plain text\n":                              "plain text\n",
+		`["not","an object"]`:                       `["not","an object"]`,
+		`{"truncated":`:                             `{"truncated":`,
+		"\n":                                        "\n",
+	} {
+		if got := li.inject(line); got != want {
+			t.Errorf("%q: expected %q but got %q", line, want, got)
+		}
+	}
+}
+
+func TestLogInjectLogfmt(t *testing.T) {
+	li := newLogInjector("logfmt", "trace.id", "span.id", logInjectTestTp(t))
+	ids := "trace.id=f6c109f48195b451c4def6ab32f47b61 span.id=a5d2a35f2483004e"
+
+	for line, want := range map[string]string{
+		"level=info msg=hello\n":          "level=info msg=hello " + ids + "\n",
+		"level=info msg=hello  \r\n":      "level=info msg=hello " + ids + "  \r\n",
+		"trace.id=abc msg=mine\n":         "trace.id=abc msg=mine span.id=a5d2a35f2483004e\n",
+		"msg=mine trace.id=abc span.id=d": "msg=mine trace.id=abc span.id=d",
+		"   \n":                           "   \n",
+	} {
+		if got := li.inject(line); got != want {
+			t.Errorf("%q: expected %q but got %q", line, want, got)
+		}
+	}
+}
+
+func TestLogInjectNoTraceparent(t *testing.T) {
+	li := newLogInjector("json", "trace_id", "span_id", traceparent.Traceparent{})
+	if got := li.inject(`{"msg":"hello"}` + "\n"); got != `{"msg":"hello"}`+"\n" {
+		t.Errorf("expected the line unchanged without a traceparent but got %q", got)
+	}
+}
+
+func TestLogInjectCopy(t *testing.T) {
+	li := newLogInjector("logfmt", "trace_id", "span_id", logInjectTestTp(t))
+	in := "msg=one\n\nmsg=" + strings.Repeat("x", 100000) + "\nmsg=last"
+	out := bytes.Buffer{}
+	if err := li.copy(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("copy failed: %s", err)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 4 || lines[1] != "" {
+		t.Fatalf("expected 4 lines with the blank one kept but got %d", len(lines))
+	}
+	for _, i := range []int{0, 2, 3} {
+		if !strings.HasSuffix(lines[i], " trace_id=f6c109f48195b451c4def6ab32f47b61 span_id=a5d2a35f2483004e") {
+			t.Errorf("expected line %d to have the ids but got %q", i, lines[i][len(lines[i])-60:])
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/google/go-cmp/cmp"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestGetLogSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		severity string
+		number   logspb.SeverityNumber
+		text     string
+	}{
+		{"info", logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"},
+		{"ERROR", logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "ERROR"},
+		{"warning", logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "WARN"},
+		{"debug2", logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG2, "DEBUG2"},
+		{"fatal4", logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4, "FATAL4"},
+	} {
+		number, text, err := DefaultConfig().WithLogSeverity(tc.severity).GetLogSeverity()
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tc.severity, err)
+		}
+		if number != tc.number || text != tc.text {
+			t.Errorf("expected %s and %q for %q but got %s and %q", tc.number, tc.text, tc.severity, number, text)
+		}
+	}
+
+	for _, bad := range []string{"", "notice", "info5", "2"} {
+		if _, _, err := DefaultConfig().WithLogSeverity(bad).GetLogSeverity(); err == nil {
+			t.Errorf("expected an error for severity %q", bad)
+		}
+	}
+}
+
+func TestParseLogArgs(t *testing.T) {
+	got, err := parseLogArgs(map[string]string{"env": "dev", "host": "a"}, []string{"env=prod", "msg=a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := map[string]string{"env": "prod", "host": "a", "msg": "a=b"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("attributes did not match (-want +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{"novalue", "=value"} {
+		if _, err := parseLogArgs(nil, []string{bad}); err == nil {
+			t.Errorf("expected an error for argument %q", bad)
+		}
+	}
+}
+
+func TestNewLogRecord(t *testing.T) {
+	config := DefaultConfig().WithLogSeverity("error").WithAttributes(map[string]string{"mount": "/var"})
+
+	record := config.newLogRecord("disk full", traceparent.Traceparent{})
+	if record.Body.GetStringValue() != "disk full" || record.SeverityText != "ERROR" {
+		t.Errorf("expected the body and severity to be set but got %v", record)
+	}
+	if len(record.TraceId) != 0 || len(record.SpanId) != 0 {
+		t.Errorf("expected no trace context without a traceparent but got %v", record)
+	}
+
+	tp, err := traceparent.Parse("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if err != nil {
+		t.Fatalf("failed to parse traceparent: %s", err)
+	}
+	record = config.newLogRecord("disk full", tp)
+	if !bytes.Equal(record.TraceId, tp.TraceId) || !bytes.Equal(record.SpanId, tp.SpanId) || record.Flags != 1 {
+		t.Errorf("expected the trace context from the traceparent but got %v", record)
+	}
+}
+
+func TestForwardLogLines(t *testing.T) {
+	config := DefaultConfig().WithExporter("console")
+	out := bytes.Buffer{}
+	client := otlpclient.NewWriterClient(config, &out)
+
+	dropped := forwardLogLines(context.Background(), config, client, traceparent.Traceparent{}, strings.NewReader("one\n\ntwo\n"))
+	if dropped != 0 {
+		t.Errorf("expected no dropped records but got %d", dropped)
+	}
+	if !strings.Contains(out.String(), `"stringValue":"one"`) || !strings.Contains(out.String(), `"stringValue":"two"`) {
+		t.Errorf("expected both lines to be sent but got %s", out.String())
+	}
+	if n := strings.Count(out.String(), `"body"`); n != 2 {
+		t.Errorf("expected blank lines to be skipped but got %d records", n)
+	}
+}
+
+func TestWithLogSeverity(t *testing.T) {
+	if DefaultConfig().WithLogSeverity("error").LogSeverity != "error" {
+		t.Fail()
+	}
+}
+
+func TestWithLogBody(t *testing.T) {
+	if DefaultConfig().WithLogBody("disk full").LogBody != "disk full" {
+		t.Fail()
+	}
+}
+
+func TestWithLogStdin(t *testing.T) {
+	if !DefaultConfig().WithLogStdin(true).LogStdin {
+		t.Fail()
+	}
+}
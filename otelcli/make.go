@@ -0,0 +1,319 @@
+package otelcli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// makeWrapper holds the command-line configured settings for otel-cli make.
+var makeWrapper struct {
+	bin   string
+	shell string
+}
+
+// makeRecipeLine is written by the recipe shell for each line of a recipe
+// make runs, one JSON object per line in the file in OTEL_CLI_MAKE_RECORDS.
+type makeRecipeLine struct {
+	Parent   string `json:"parent"`
+	Target   string `json:"target"`
+	Line     string `json:"line"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	ExitCode int    `json:"exit_code"`
+}
+
+func makeCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "make [-- make args...]",
+		Short: "run make with a span for each target",
+		Long: `Run make inside a span like exec, with a child span for each target make
+runs a recipe for, covering from its first recipe line starting to its last
+one finishing, so slow targets stand out without editing the Makefile.
+
+otel-cli does this by setting SHELL on make's command line to a hidden
+otel-cli helper that runs each recipe line with --recipe-shell and records
+it. Since command-line variables win, a Makefile's own SHELL setting is
+ignored, so pass it with --recipe-shell instead. Recipes get a TRACEPARENT
+for their target's span, and recursive $(MAKE) calls are traced as children
+of the target that ran them. Use -- before make's own flags.
+
+Examples:
+	otel-cli make -- -j4 all
+	otel-cli make --name "build docs" --recipe-shell /bin/bash -- -C docs html
+`,
+		Run: doMake,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&makeWrapper.bin, "make", "make", "the make program to run")
+	cmd.Flags().StringVar(&makeWrapper.shell, "recipe-shell", "/bin/sh", "the shell to run recipe lines with")
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
+
+	cmd.AddCommand(makeRecipeShellCmd(config))
+
+	return &cmd
+}
+
+// makeRecipeShellCmd is the hidden helper otel-cli make sets as make's SHELL.
+// make runs it as: otel-cli make recipe-shell TARGET -c LINE
+func makeRecipeShellCmd(config *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:                "recipe-shell TARGET [shell args...]",
+		Short:              "run a make recipe line for otel-cli make (internal)",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		Args:               cobra.MinimumNArgs(2),
+		Run:                doMakeRecipeShell,
+	}
+}
+
+func doMake(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if config.SpanName == DefaultConfig().SpanName {
+		config.SpanName = strings.TrimSpace("make " + strings.Join(args, " "))
+	}
+	span := config.NewProtobufSpan()
+
+	child := exec.Command(makeWrapper.bin, args...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = []string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "TRACEPARENT=") {
+			child.Env = append(child.Env, env)
+		}
+	}
+
+	// only hook the recipes when there's somewhere to send their spans
+	var records string
+	if config.GetIsRecording() {
+		tp := otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
+		child.Env = append(child.Env, "TRACEPARENT="+tp.Encode())
+
+		exe, err := os.Executable()
+		if err != nil {
+			config.SoftFail("unable to find the otel-cli executable for the recipe shell: %s", err)
+		}
+		dir, err := os.MkdirTemp("", "otel-cli-make-")
+		if err != nil {
+			config.SoftFail("unable to create a directory for recipe records: %s", err)
+		}
+		defer os.RemoveAll(dir)
+		records = dir + "/records.jsonl"
+
+		child.Env = append(child.Env, "OTEL_CLI_MAKE_RECORDS="+records, "OTEL_CLI_MAKE_SHELL="+makeWrapper.shell)
+		// make expands $@ in .SHELLFLAGS for each recipe, which is how the
+		// helper knows which target a line belongs to
+		child.Args = append(child.Args, "SHELL="+exe, ".SHELLFLAGS=make recipe-shell $@ -c")
+	}
+
+	// ctrl-c goes to make too, since it's in the same process group
+	signal.Ignore(os.Interrupt)
+
+	if err := child.Run(); err != nil {
+		otlpclient.SetSpanStatus(span, "error", fmt.Sprintf("make failed: %s", err))
+	}
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+
+	spans := []*tracepb.Span{span}
+	if records != "" {
+		lines, err := readMakeRecords(records)
+		config.SoftLogIfErr(err)
+		spans = append(spans, makeTargetSpans(span.TraceId, lines)...)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	ctx, client := StartClient(ctx, config)
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, spans...)
+		var err error
+		ctx, err = otlpclient.SendSpans(ctx, client, config, spans)
+		if err != nil {
+			config.SoftFail("unable to send spans: %s", err)
+		}
+	}
+
+	_, err := client.Stop(ctx)
+	if err != nil {
+		config.SoftFail("client.Stop() failed: %s", err)
+	}
+
+	Diag.ExecExitCode = child.ProcessState.ExitCode()
+
+	config.PropagateTraceparent(span, os.Stdout)
+}
+
+// doMakeRecipeShell runs a recipe line with the real shell, with
+// TRACEPARENT set to its target's span, and records how it went.
+func doMakeRecipeShell(cmd *cobra.Command, args []string) {
+	target, shellArgs := args[0], args[1:]
+
+	shell := os.Getenv("OTEL_CLI_MAKE_SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	child := exec.Command(shell, shellArgs...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = os.Environ()
+
+	parent, _ := traceparent.LoadFromEnv()
+	if parent.Initialized {
+		tp := parent
+		tp.SpanId = makeTargetSpanId(parent.SpanId, target)
+		child.Env = append(child.Env, "TRACEPARENT="+tp.Encode())
+	}
+
+	line := makeRecipeLine{
+		Parent: hex.EncodeToString(parent.SpanId),
+		Target: target,
+		Line:   shellArgs[len(shellArgs)-1],
+		Start:  time.Now().UnixNano(),
+	}
+	err := child.Run()
+	line.End = time.Now().UnixNano()
+	if child.ProcessState != nil {
+		line.ExitCode = child.ProcessState.ExitCode()
+	} else {
+		// the shell couldn't start, fail the line like a shell would
+		fmt.Fprintf(os.Stderr, "otel-cli make: %s\n", err)
+		line.ExitCode = 127
+	}
+
+	if records := os.Getenv("OTEL_CLI_MAKE_RECORDS"); records != "" && parent.Initialized {
+		if err := appendMakeRecord(records, line); err != nil {
+			fmt.Fprintf(os.Stderr, "otel-cli make: unable to record recipe line: %s\n", err)
+		}
+	}
+
+	Diag.ExecExitCode = line.ExitCode
+}
+
+// appendMakeRecord appends the line to the records file. Each record is a
+// single small write to a file opened for appending, so lines from parallel
+// jobs don't interleave.
+func appendMakeRecord(path string, line makeRecipeLine) error {
+	js, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(js, '\n'))
+	return err
+}
+
+// readMakeRecords reads the recipe lines the recipe shell recorded. No file
+// means make didn't run any recipes.
+func readMakeRecords(path string) ([]makeRecipeLine, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := []makeRecipeLine{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := makeRecipeLine{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return lines, fmt.Errorf("invalid recipe record %q: %w", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// makeTargetSpans returns a span for each target in the order they started,
+// from the start of its first recipe line to the end of its last, with the
+// recipe as an attribute and an error status if any line failed.
+func makeTargetSpans(traceId []byte, lines []makeRecipeLine) []*tracepb.Span {
+	spans := []*tracepb.Span{}
+	byKey := map[string]*tracepb.Span{}
+	recipes := map[*tracepb.Span][]string{}
+
+	for _, line := range lines {
+		parentId, err := hex.DecodeString(line.Parent)
+		if err != nil {
+			continue
+		}
+
+		key := line.Parent + "/" + line.Target
+		span, ok := byKey[key]
+		if !ok {
+			span = otlpclient.NewProtobufSpan()
+			span.TraceId = traceId
+			span.SpanId = makeTargetSpanId(parentId, line.Target)
+			span.ParentSpanId = parentId
+			span.Name = line.Target
+			span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+			span.StartTimeUnixNano = uint64(line.Start)
+			span.EndTimeUnixNano = uint64(line.End)
+			byKey[key] = span
+			spans = append(spans, span)
+		}
+
+		span.StartTimeUnixNano = min(span.StartTimeUnixNano, uint64(line.Start))
+		span.EndTimeUnixNano = max(span.EndTimeUnixNano, uint64(line.End))
+		recipes[span] = append(recipes[span], line.Line)
+		if line.ExitCode != 0 && span.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+			otlpclient.SetSpanStatus(span, "error", fmt.Sprintf("recipe line exited with status %d", line.ExitCode))
+		}
+	}
+
+	// records are written as lines finish, so a recursive make's targets
+	// come before the target that ran it
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].StartTimeUnixNano < spans[j].StartTimeUnixNano })
+
+	for _, span := range spans {
+		span.Attributes = importAttrs(map[string]string{
+			"make.target": span.Name,
+			"make.recipe": strings.Join(recipes[span], "\n"),
+		})
+	}
+
+	return spans
+}
+
+// makeTargetSpanId returns the span id for a target run under the parent,
+// so every recipe line of a target agrees on it without coordinating.
+func makeTargetSpanId(parentId []byte, target string) []byte {
+	sum := sha256.Sum256(append(append([]byte{}, parentId...), target...))
+	return sum[:8]
+}
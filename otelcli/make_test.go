@@ -0,0 +1,62 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestMakeTargetSpans(t *testing.T) {
+	traceId := otlpclient.GenerateTraceId()
+	makeId := otlpclient.GenerateSpanId()
+	subId := makeTargetSpanId(makeId, "sub")
+	start := time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC)
+	at := func(secs int) int64 { return start.Add(time.Duration(secs) * time.Second).UnixNano() }
+
+	// in the order the recipe shell writes them, as lines finish
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	for _, line := range []makeRecipeLine{
+		{Parent: hex.EncodeToString(makeId), Target: "deps", Line: "go mod download", Start: at(0), End: at(3)},
+		{Parent: hex.EncodeToString(subId), Target: "docs", Line: "mkdocs build", Start: at(4), End: at(6)},
+		{Parent: hex.EncodeToString(makeId), Target: "sub", Line: "$(MAKE) -C docs", Start: at(3), End: at(7)},
+		{Parent: hex.EncodeToString(makeId), Target: "build", Line: "go vet ./...", Start: at(7), End: at(9)},
+		{Parent: hex.EncodeToString(makeId), Target: "build", Line: "go build ./...", Start: at(9), End: at(15), ExitCode: 2},
+	} {
+		if err := appendMakeRecord(path, line); err != nil {
+			t.Fatalf("failed to record recipe line: %s", err)
+		}
+	}
+
+	lines, err := readMakeRecords(path)
+	if err != nil {
+		t.Fatalf("failed to read recipe records: %s", err)
+	}
+	spans := makeTargetSpans(traceId, lines)
+
+	got := []string{}
+	byId := map[string]*tracepb.Span{string(makeId): {Name: "make"}}
+	for _, span := range spans {
+		byId[string(span.SpanId)] = span
+		got = append(got, fmt.Sprintf("%s < %s %s %s %q", span.Name, byId[string(span.ParentSpanId)].Name,
+			time.Duration(span.EndTimeUnixNano-span.StartTimeUnixNano), span.Status.Code,
+			otlpclient.SpanAttributesToStringMap(span)["make.recipe"]))
+	}
+	want := []string{
+		`deps < make 3s STATUS_CODE_UNSET "go mod download"`,
+		`sub < make 4s STATUS_CODE_UNSET "$(MAKE) -C docs"`,
+		`docs < sub 2s STATUS_CODE_UNSET "mkdocs build"`,
+		`build < make 8s STATUS_CODE_ERROR "go vet ./...\ngo build ./..."`,
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("unexpected spans\nwant: %q\n got: %q", want, got)
+	}
+
+	if lines, err := readMakeRecords(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || len(lines) != 0 {
+		t.Errorf("expected no records and no error when make ran no recipes, got %v, %v", lines, err)
+	}
+}
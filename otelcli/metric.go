@@ -0,0 +1,294 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// defaultHistogramBuckets are the OTel SDK's default explicit bucket boundaries.
+var defaultHistogramBuckets = []float64{0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000}
+
+// metricCmd represents the metric command
+func metricCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "metric",
+		Short: "create an OpenTelemetry metric and send it",
+		Long: `Send a single counter, gauge, or histogram data point to the OTLP metrics
+service, with the same resource, attributes, and endpoint settings as spans.
+--metrics-endpoint and --metrics-headers override the general settings for
+metrics. Metrics are always sent directly, without the agent or --async.
+
+Counters and histograms are sent with delta temporality, since each otel-cli
+run only knows about its own data point.
+
+Example:
+	otel-cli metric counter deploys.total --inc 1 --attrs env=prod
+	otel-cli metric gauge queue.depth 42
+	otel-cli metric histogram build.duration 93.5 --unit s
+`,
+	}
+
+	cmd.AddCommand(metricCounterCmd(config))
+	cmd.AddCommand(metricGaugeCmd(config))
+	cmd.AddCommand(metricHistogramCmd(config))
+
+	return &cmd
+}
+
+// metricCounterCmd represents the metric counter command
+func metricCounterCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "counter NAME",
+		Short: "add to a monotonic counter",
+		Args:  cobra.ExactArgs(1),
+		Run:   doMetricCounter,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&config.MetricIncrement, "inc", defaults.MetricIncrement, "amount to add to the counter, must not be negative")
+	addMetricParams(&cmd, config)
+
+	return &cmd
+}
+
+// metricGaugeCmd represents the metric gauge command
+func metricGaugeCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "gauge NAME VALUE",
+		Short: "record the current value of a gauge",
+		Args:  cobra.ExactArgs(2),
+		Run:   doMetricGauge,
+	}
+
+	cmd.Flags().SortFlags = false
+	addMetricParams(&cmd, config)
+
+	return &cmd
+}
+
+// metricHistogramCmd represents the metric histogram command
+func metricHistogramCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "histogram NAME VALUE",
+		Short: "record a value in a histogram",
+		Args:  cobra.ExactArgs(2),
+		Run:   doMetricHistogram,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+	cmd.Flags().StringVar(&config.MetricBuckets, "buckets", defaults.MetricBuckets, "comma-separated histogram bucket boundaries, defaults to the OTel SDK's")
+	addMetricParams(&cmd, config)
+
+	return &cmd
+}
+
+// addMetricParams adds the flags shared by the metric subcommands.
+func addMetricParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+
+	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the metrics")
+	cmd.Flags().StringVar(&config.MetricUnit, "unit", defaults.MetricUnit, "unit of the metric, e.g. s, By, or {requests}")
+	cmd.Flags().StringVar(&config.MetricDescription, "description", defaults.MetricDescription, "description of the metric")
+
+	addCommonParams(cmd, config)
+	addAttrParams(cmd, config)
+	addAttrsFileParams(cmd, config)
+	addClientParams(cmd, config)
+}
+
+func doMetricCounter(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	dp := config.newNumberDataPoint(config.MetricIncrement)
+	if dp.GetAsInt() < 0 || dp.GetAsDouble() < 0 {
+		config.SoftFail("--inc must not be negative for a counter")
+	}
+
+	metric := config.newMetric(args[0])
+	metric.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+		DataPoints:             []*metricspb.NumberDataPoint{dp},
+		AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+		IsMonotonic:            true,
+	}}
+
+	sendMetric(ctx, config, metric)
+}
+
+func doMetricGauge(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	dp := config.newNumberDataPoint(args[1])
+	dp.StartTimeUnixNano = 0 // gauges don't have a start time
+
+	metric := config.newMetric(args[0])
+	metric.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{dp},
+	}}
+
+	sendMetric(ctx, config, metric)
+}
+
+func doMetricHistogram(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	value, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		config.SoftFail("invalid histogram value %q", args[1])
+	}
+	buckets, err := config.GetMetricBuckets()
+	config.SoftFailIfErr(err)
+
+	metric := config.newMetric(args[0])
+	metric.Data = &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+		DataPoints:             []*metricspb.HistogramDataPoint{config.newHistogramDataPoint(value, buckets)},
+		AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+	}}
+
+	sendMetric(ctx, config, metric)
+}
+
+// sendMetric sends the metric and stops the client.
+func sendMetric(ctx context.Context, config Config, metric *metricspb.Metric) {
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	ctx, client := StartMetricsClient(ctx, config)
+	ctx, err := otlpclient.SendMetric(ctx, client, config.withSignal("metrics"), metric)
+	config.SoftFailIfErr(err)
+	_, err = client.Stop(ctx)
+	config.SoftFailIfErr(err)
+}
+
+// StartMetricsClient returns a started client for the metrics endpoint.
+func StartMetricsClient(ctx context.Context, config Config) (context.Context, otlpclient.OTLPMetricsClient) {
+	config = config.withSignal("metrics")
+	client, ok := newSignalClient(ctx, config).(otlpclient.OTLPMetricsClient)
+	if !ok {
+		config.SoftFail("%s:// endpoints don't support metrics", config.GetEndpoint().Scheme)
+	}
+
+	ctx = otlpclient.WithLogger(ctx, config.Logger())
+	ctx = config.withWireDump(ctx)
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		Diag.Error = err.Error()
+		config.SoftFail("Failed to start OTLP client: %s", err)
+	}
+
+	return ctx, client
+}
+
+// newMetric returns a metric with the name and the unit and description
+// from the config.
+func (c Config) newMetric(name string) *metricspb.Metric {
+	return &metricspb.Metric{
+		Name:        name,
+		Unit:        c.MetricUnit,
+		Description: c.MetricDescription,
+	}
+}
+
+// newNumberDataPoint returns a data point for the value, an int if it parses
+// as one and a double otherwise, with the attributes from the config.
+func (c Config) newNumberDataPoint(value string) *metricspb.NumberDataPoint {
+	now := uint64(time.Now().UnixNano())
+	dp := &metricspb.NumberDataPoint{
+		StartTimeUnixNano: now,
+		TimeUnixNano:      now,
+		Attributes:        c.GetAttributes(),
+	}
+
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: i}
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: f}
+	} else {
+		c.SoftFail("invalid metric value %q", value)
+	}
+
+	return dp
+}
+
+// newHistogramDataPoint returns a histogram data point with a single
+// observation of value, counted in the first bucket whose upper bound it
+// doesn't exceed.
+func (c Config) newHistogramDataPoint(value float64, buckets []float64) *metricspb.HistogramDataPoint {
+	now := uint64(time.Now().UnixNano())
+	counts := make([]uint64, len(buckets)+1)
+	i := 0
+	for i < len(buckets) && value > buckets[i] {
+		i++
+	}
+	counts[i] = 1
+
+	return &metricspb.HistogramDataPoint{
+		StartTimeUnixNano: now,
+		TimeUnixNano:      now,
+		Attributes:        c.GetAttributes(),
+		Count:             1,
+		Sum:               &value,
+		Min:               &value,
+		Max:               &value,
+		ExplicitBounds:    buckets,
+		BucketCounts:      counts,
+	}
+}
+
+// GetMetricBuckets parses --buckets into increasing bucket boundaries,
+// returning the OTel SDK defaults when it isn't set.
+func (c Config) GetMetricBuckets() ([]float64, error) {
+	if c.MetricBuckets == "" {
+		return defaultHistogramBuckets, nil
+	}
+
+	buckets := []float64{}
+	for _, field := range strings.Split(c.MetricBuckets, ",") {
+		b, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid histogram bucket %q: %w", field, err)
+		}
+		if len(buckets) > 0 && b <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("histogram buckets must be increasing, got %s", c.MetricBuckets)
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, nil
+}
+
+// WithMetricUnit returns the config with MetricUnit set to the provided value.
+func (c Config) WithMetricUnit(with string) Config {
+	c.MetricUnit = with
+	return c
+}
+
+// WithMetricDescription returns the config with MetricDescription set to the provided value.
+func (c Config) WithMetricDescription(with string) Config {
+	c.MetricDescription = with
+	return c
+}
+
+// WithMetricIncrement returns the config with MetricIncrement set to the provided value.
+func (c Config) WithMetricIncrement(with string) Config {
+	c.MetricIncrement = with
+	return c
+}
+
+// WithMetricBuckets returns the config with MetricBuckets set to the provided value.
+func (c Config) WithMetricBuckets(with string) Config {
+	c.MetricBuckets = with
+	return c
+}
@@ -0,0 +1,100 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetMetricBuckets(t *testing.T) {
+	got, err := DefaultConfig().GetMetricBuckets()
+	if err != nil || len(got) != len(defaultHistogramBuckets) {
+		t.Errorf("expected the default buckets but got %v, %v", got, err)
+	}
+
+	got, err = DefaultConfig().WithMetricBuckets("0.1, 1,10").GetMetricBuckets()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff([]float64{0.1, 1, 10}, got); diff != "" {
+		t.Errorf("buckets did not match (-want +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{"1,x", "10,1", "1,1"} {
+		if _, err := DefaultConfig().WithMetricBuckets(bad).GetMetricBuckets(); err == nil {
+			t.Errorf("expected an error for buckets %q", bad)
+		}
+	}
+}
+
+func TestNewHistogramDataPoint(t *testing.T) {
+	buckets := []float64{0, 10, 100}
+	for value, want := range map[float64][]uint64{
+		-1:   {1, 0, 0, 0},
+		0:    {1, 0, 0, 0},
+		10:   {0, 1, 0, 0},
+		93.5: {0, 0, 1, 0},
+		1000: {0, 0, 0, 1},
+	} {
+		dp := DefaultConfig().newHistogramDataPoint(value, buckets)
+		if diff := cmp.Diff(want, dp.BucketCounts); diff != "" {
+			t.Errorf("bucket counts for %v did not match (-want +got):\n%s", value, diff)
+		}
+		if dp.Count != 1 || dp.GetSum() != value {
+			t.Errorf("expected a count of 1 and sum of %v but got %d and %v", value, dp.Count, dp.GetSum())
+		}
+	}
+}
+
+func TestNewNumberDataPoint(t *testing.T) {
+	config := DefaultConfig().WithAttributes(map[string]string{"env": "prod"})
+
+	dp := config.newNumberDataPoint("42")
+	if dp.GetAsInt() != 42 {
+		t.Errorf("expected int value 42 but got %v", dp.Value)
+	}
+	if len(dp.Attributes) != 1 || dp.Attributes[0].Key != "env" {
+		t.Errorf("expected the attributes to be set but got %v", dp.Attributes)
+	}
+
+	dp = config.newNumberDataPoint("93.5")
+	if dp.GetAsDouble() != 93.5 {
+		t.Errorf("expected double value 93.5 but got %v", dp.Value)
+	}
+}
+
+func TestMetricsSignalEndpoint(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("http://localhost:4318").withSignal("metrics")
+	if got := config.GetEndpoint().String(); got != "http://localhost:4318/v1/metrics" {
+		t.Errorf("expected the metrics path on the general endpoint but got %q", got)
+	}
+
+	config = config.WithMetricsEndpoint("https://metrics.example.com/custom")
+	if got := config.GetEndpoint().String(); got != "https://metrics.example.com/custom" {
+		t.Errorf("expected the metrics endpoint but got %q", got)
+	}
+}
+
+func TestWithMetricUnit(t *testing.T) {
+	if DefaultConfig().WithMetricUnit("s").MetricUnit != "s" {
+		t.Fail()
+	}
+}
+
+func TestWithMetricDescription(t *testing.T) {
+	if DefaultConfig().WithMetricDescription("build time").MetricDescription != "build time" {
+		t.Fail()
+	}
+}
+
+func TestWithMetricIncrement(t *testing.T) {
+	if DefaultConfig().WithMetricIncrement("5").MetricIncrement != "5" {
+		t.Fail()
+	}
+}
+
+func TestWithMetricBuckets(t *testing.T) {
+	if DefaultConfig().WithMetricBuckets("1,2,3").MetricBuckets != "1,2,3" {
+		t.Fail()
+	}
+}
@@ -3,34 +3,98 @@ package otelcli
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/equinix-labs/otel-cli/otlpclient"
 )
 
 // StartClient uses the Config to setup and start either a gRPC or HTTP client,
-// and returns the OTLPClient interface to them.
+// and returns the OTLPClient interface to them. When more than one endpoint is
+// configured, the clients are wrapped in a FanoutClient.
 func StartClient(ctx context.Context, config Config) (context.Context, otlpclient.OTLPClient) {
 	if !config.GetIsRecording() {
 		return ctx, otlpclient.NewNullClient(config)
 	}
 
-	if config.Protocol != "" && config.Protocol != "grpc" && config.Protocol != "http/protobuf" {
-		err := fmt.Errorf("invalid protocol setting %q", config.Protocol)
-		Diag.Error = err.Error()
-		config.SoftFail(err.Error())
+	checkClientConfig(config)
+	ctx = otlpclient.WithLogger(ctx, config.Logger())
+	ctx = config.withWireDump(ctx)
+
+	// a running agent takes care of exporting, so none of the endpoint,
+	// header, or auth settings below are needed
+	if config.AgentSocket != "" {
+		if agentAvailable(config.AgentSocket) {
+			client := newClient(config.withAgent())
+			ctx, err := client.Start(ctx)
+			if err != nil {
+				Diag.Error = err.Error()
+				config.SoftFail("Failed to start agent client: %s", err)
+			}
+			return ctx, client
+		}
+		config.SoftLog("agent is not listening on %s, exporting directly", config.AgentSocket)
+		config = config.WithAgentSocket("")
+		if !config.GetIsRecording() {
+			return ctx, otlpclient.NewNullClient(config)
+		}
 	}
 
-	endpointURL := config.GetEndpoint()
+	// the async helper resolves headers and tokens itself so none of that
+	// latency lands on the caller
+	if config.Async {
+		return ctx, newAsyncClient(config)
+	}
+
+	// resolve dynamic headers and the OAuth2 token up front so every client,
+	// including fanout targets, sends the same headers
+	extraHeaders := getExtraHeaders(ctx, config)
+	if len(extraHeaders) > 0 {
+		config = config.withExtraHeaders(extraHeaders)
+	}
 
 	var client otlpclient.OTLPClient
-	if config.Protocol != "grpc" &&
-		(strings.HasPrefix(config.Protocol, "http/") ||
-			endpointURL.Scheme == "http" ||
-			endpointURL.Scheme == "https") {
-		client = otlpclient.NewHttpClient(config)
+	if config.Exporter == "console" {
+		client = otlpclient.NewWriterClient(config, os.Stdout)
+	} else if config.IsFanout() {
+		clients := []otlpclient.OTLPClient{}
+		names := []string{}
+		for _, target := range config.GetTargetConfigs() {
+			if !isValidProtocol(target.Protocol) {
+				err := fmt.Errorf("invalid protocol setting %q for target %q", target.Protocol, target.Endpoint)
+				Diag.Error = err.Error()
+				config.SoftFail(err.Error())
+			}
+			if len(extraHeaders) > 0 {
+				target = target.withExtraHeaders(extraHeaders)
+			}
+			var err error
+			target, err = target.resolveSRV(ctx)
+			if err != nil {
+				Diag.Error = err.Error()
+				config.SoftFail("Failed to resolve endpoint: %s", err)
+			}
+			clients = append(clients, newClient(target))
+			names = append(names, endpointString(target.GetEndpoint()))
+		}
+		switch config.FanoutPolicy {
+		case "failover":
+			client = otlpclient.NewFailoverClient(clients, names, 0)
+		case "round-robin":
+			// each otel-cli is its own process, so the pid spreads them out
+			client = otlpclient.NewFailoverClient(clients, names, os.Getpid())
+		default:
+			client = otlpclient.NewFanoutClient(clients, names, config.FanoutPolicy == "all")
+		}
 	} else {
-		client = otlpclient.NewGrpcClient(config)
+		var err error
+		config, err = config.resolveSRV(ctx)
+		if err != nil {
+			Diag.Error = err.Error()
+			config.SoftFail("Failed to resolve endpoint: %s", err)
+		}
+		client = newClient(config)
+		config.Logger().Debug("starting OTLP client", "endpoint", config.GetEndpoint().String())
 	}
 
 	ctx, err := client.Start(ctx)
@@ -41,3 +105,148 @@ func StartClient(ctx context.Context, config Config) (context.Context, otlpclien
 
 	return ctx, client
 }
+
+// newSignalClient returns an unstarted client for sending metrics or logs
+// with a config from withSignal. The agent, --async, and multiple endpoints
+// are only for spans, so those settings are ignored and the other signals
+// always go to a single endpoint.
+func newSignalClient(ctx context.Context, config Config) otlpclient.OTLPClient {
+	signal := config.getSignal()
+	config = config.WithAgentSocket("").WithAsync(false)
+	if config.getSignalEndpoint(signal) == "" && (len(config.Targets) > 0 || len(splitEndpoints(config.Endpoint)) > 1) {
+		config.SoftFail("%s can only be sent to one endpoint, set --%s-endpoint", signal, signal)
+	}
+	config.Targets = nil
+
+	if !config.GetIsRecording() {
+		return otlpclient.NewNullClient(config)
+	}
+
+	checkClientConfig(config)
+
+	if extraHeaders := getExtraHeaders(ctx, config); len(extraHeaders) > 0 {
+		config = config.withExtraHeaders(extraHeaders)
+	}
+
+	if config.Exporter == "console" {
+		return otlpclient.NewWriterClient(config, os.Stdout)
+	}
+
+	config, err := config.resolveSRV(ctx)
+	if err != nil {
+		Diag.Error = err.Error()
+		config.SoftFail("Failed to resolve endpoint: %s", err)
+	}
+
+	return newClient(config)
+}
+
+// getExtraHeaders returns the headers from --otlp-headers-from-file and
+// --otlp-header-cmd, plus the OAuth2 bearer token when configured.
+func getExtraHeaders(ctx context.Context, config Config) map[string]string {
+	extraHeaders, err := config.GetDynamicHeaders(ctx)
+	if err != nil {
+		Diag.Error = err.Error()
+		config.SoftFail("Failed to get OTLP headers: %s", err)
+	}
+	if config.OAuth2TokenURL != "" {
+		token, err := otlpclient.GetOAuth2Token(ctx, config.GetOAuth2Config())
+		if err != nil {
+			Diag.Error = err.Error()
+			config.SoftFail("Failed to get OAuth2 token: %s", err)
+		}
+		extraHeaders["Authorization"] = "Bearer " + token
+	}
+	return extraHeaders
+}
+
+// checkClientConfig fails if any of the protocol, compression, exporter,
+// fanout policy, or resource detector settings are invalid.
+func checkClientConfig(config Config) {
+	if errs := clientConfigErrors(config); len(errs) > 0 {
+		Diag.Error = errs[0].Error()
+		config.SoftFail(errs[0].Error())
+	}
+}
+
+// clientConfigErrors returns an error for each of the protocol, compression,
+// exporter, fanout policy, and resource detector settings that is invalid.
+func clientConfigErrors(config Config) []error {
+	errs := []error{}
+
+	if !isValidProtocol(config.Protocol) {
+		errs = append(errs, fmt.Errorf("invalid protocol setting %q", config.Protocol))
+	}
+
+	if _, ok := logLevels[config.LogLevel]; config.LogLevel != "" && !ok {
+		errs = append(errs, fmt.Errorf("invalid log level setting %q, must be one of debug, info, warn, or error", config.LogLevel))
+	}
+
+	if config.LogFormat != "" && config.LogFormat != "logfmt" && config.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("invalid log format setting %q, must be logfmt or json", config.LogFormat))
+	}
+
+	if config.Compression != "" && config.Compression != "none" && config.Compression != "gzip" {
+		errs = append(errs, fmt.Errorf("invalid compression setting %q", config.Compression))
+	}
+
+	if config.Exporter != "" && config.Exporter != "otlp" && config.Exporter != "console" && config.Exporter != "none" {
+		errs = append(errs, fmt.Errorf("invalid exporter setting %q", config.Exporter))
+	}
+
+	if config.FanoutPolicy != "" && config.FanoutPolicy != "any" && config.FanoutPolicy != "all" &&
+		config.FanoutPolicy != "failover" && config.FanoutPolicy != "round-robin" {
+		errs = append(errs, fmt.Errorf("invalid fanout policy setting %q", config.FanoutPolicy))
+	}
+
+	errs = append(errs, ciDetectErrors(config)...)
+
+	for _, name := range config.GetResourceDetectors() {
+		if !isResourceDetector(name) {
+			errs = append(errs, fmt.Errorf("invalid resource detector %q, must be one of %s", name, strings.Join(otlpclient.ResourceDetectors, ", ")))
+		}
+	}
+
+	return errs
+}
+
+// isResourceDetector returns true if name is one of the resource detectors
+// otlpclient implements.
+func isResourceDetector(name string) bool {
+	for _, detector := range otlpclient.ResourceDetectors {
+		if name == detector {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidProtocol returns true if the protocol is empty or one otel-cli supports.
+func isValidProtocol(protocol string) bool {
+	return protocol == "" || protocol == "grpc" || protocol == "grpc-web" || protocol == "http/protobuf" || protocol == "http/json"
+}
+
+// newClient returns an unstarted client for the config's endpoint.
+func newClient(config Config) otlpclient.OTLPClient {
+	endpointURL := config.GetEndpoint()
+	if endpointURL.Scheme == "stdout" {
+		return otlpclient.NewWriterClient(config, os.Stdout)
+	} else if endpointURL.Scheme == "stderr" {
+		return otlpclient.NewWriterClient(config, os.Stderr)
+	} else if endpointURL.Scheme == "file" {
+		return otlpclient.NewFileClient(config)
+	} else if endpointURL.Scheme == "zipkin" || endpointURL.Scheme == "zipkins" {
+		return otlpclient.NewZipkinClient(config)
+	} else if endpointURL.Scheme == "kafka" || endpointURL.Scheme == "kafkas" {
+		return otlpclient.NewKafkaClient(config)
+	} else if config.Protocol == "grpc-web" {
+		return otlpclient.NewGrpcWebClient(config)
+	} else if config.Protocol != "grpc" &&
+		(strings.HasPrefix(config.Protocol, "http/") ||
+			endpointURL.Scheme == "http" ||
+			endpointURL.Scheme == "https") {
+		return otlpclient.NewHttpClient(config)
+	}
+
+	return otlpclient.NewGrpcClient(config)
+}
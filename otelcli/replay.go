@@ -0,0 +1,370 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// replayBatch is a set of spans read from a capture. rsps is set for
+// OTLP/JSON, which carries the spans' resources. span is set for the
+// span.json files written by otel-cli server json --dir, which don't, so
+// those are sent with otel-cli's resource like any other span.
+type replayBatch struct {
+	rsps []*tracepb.ResourceSpans
+	span *tracepb.Span
+}
+
+func replayCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "replay [file or directory...]",
+		Short: "re-send spans captured by otel-cli server",
+		Long: `Read spans captured by otel-cli server json and send them to the configured
+endpoint, e.g. to move a repro from a laptop to a shared collector.
+
+Files can be OTLP/JSON, one export request per line or a whole file of one,
+like --ndjson, --output otlp-json-file, and the collector's file exporter
+write, or the span.json files written to --dir. Directories are searched
+for span.json files. Lines that aren't spans, like logs and metrics in an
+--ndjson stream, are skipped.
+
+With --shift-to-now, all of the spans are moved in time so the earliest one
+starts now, keeping their timing relative to each other.
+
+Example:
+	otel-cli replay spans.ndjson --endpoint grpc://collector:4317
+	otel-cli replay ./spans --endpoint grpc://collector:4317 --shift-to-now
+	otel-cli replay ./spans/*/*/span.json --endpoint localhost:4317
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doReplay,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	defaults := DefaultConfig()
+	cmd.Flags().BoolVar(&config.ReplayShiftToNow, "shift-to-now", defaults.ReplayShiftToNow, "move the spans in time so the earliest starts now")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doReplay(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to replay spans to")
+	}
+
+	batches := []replayBatch{}
+	var skipped int
+	for _, path := range replayFiles(config, args) {
+		fileBatches, fileSkipped, err := readReplayFile(path)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		batches = append(batches, fileBatches...)
+		skipped += fileSkipped
+	}
+	if skipped > 0 {
+		config.SoftLog("skipped %d lines that weren't spans", skipped)
+	}
+
+	if config.ReplayShiftToNow {
+		shiftReplaySpans(batches, time.Now())
+	}
+
+	ctx, client := StartClient(ctx, config)
+
+	var sent int
+	var err error
+	for _, batch := range batches {
+		// each batch gets the full --timeout, same as a single span would
+		batchCtx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+		if batch.span != nil {
+			_, err = otlpclient.SendSpan(batchCtx, client, config, batch.span)
+		} else {
+			_, err = client.UploadTraces(batchCtx, batch.rsps)
+		}
+		cancel()
+		if err != nil {
+			break
+		}
+		sent++
+	}
+
+	_, serr := client.Stop(ctx)
+	config.SoftLogIfErr(serr)
+
+	if err != nil {
+		config.SoftFail("replayed %d of %d requests, stopped on error: %s", sent, len(batches), err)
+	}
+	config.SoftLog("replayed %d requests", sent)
+}
+
+// replayFiles returns the files named in args, replacing directories with
+// the span.json files under them.
+func replayFiles(config Config, args []string) []string {
+	out := []string{}
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		if !fi.IsDir() {
+			out = append(out, arg)
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && d.Name() == "span.json" {
+				out = append(out, path)
+			}
+			return nil
+		})
+		if err != nil {
+			config.SoftFail("failed to search %q for span.json files: %s", arg, err)
+		}
+	}
+
+	return out
+}
+
+// readReplayFile reads the spans in the file, first trying the whole file as
+// one document, then each line. Returns the number of lines that weren't
+// spans.
+func readReplayFile(path string) ([]replayBatch, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	if batch, ok := parseReplayJson(data); ok {
+		return []replayBatch{batch}, 0, nil
+	}
+
+	out := []replayBatch{}
+	var skipped int
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if batch, ok := parseReplayJson(line); ok {
+			out = append(out, batch)
+		} else {
+			skipped++
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, 0, fmt.Errorf("no spans found in %q", path)
+	}
+	return out, skipped, nil
+}
+
+// parseReplayJson parses OTLP/JSON traces or a span.json. Returns false when
+// the json is neither, or has no spans.
+func parseReplayJson(js []byte) (replayBatch, bool) {
+	td := tracepb.TracesData{}
+	if err := otlpclient.UnmarshalOtlpJson(js, &td); err == nil && len(td.ResourceSpans) > 0 {
+		return replayBatch{rsps: td.ResourceSpans}, true
+	}
+
+	if span, err := parseServerJsonSpan(js); err == nil && len(span.SpanId) > 0 {
+		return replayBatch{span: span}, true
+	}
+
+	return replayBatch{}, false
+}
+
+// serverJsonSpan is a span.json as written by otel-cli server json, which
+// marshals spans with encoding/json rather than OTLP/JSON. That mostly
+// unmarshals straight back into a tracepb.Span, except for attribute
+// values, which are oneofs and come out as e.g. {"Value":{"IntValue":1}}.
+type serverJsonSpan struct {
+	tracepb.Span
+	Attributes []serverJsonKeyValue `json:"attributes,omitempty"`
+	Events     []struct {
+		*tracepb.Span_Event
+		Attributes []serverJsonKeyValue `json:"attributes,omitempty"`
+	} `json:"events,omitempty"`
+	Links []struct {
+		*tracepb.Span_Link
+		Attributes []serverJsonKeyValue `json:"attributes,omitempty"`
+	} `json:"links,omitempty"`
+}
+
+// serverJsonKeyValue is an attribute in a span.json.
+type serverJsonKeyValue struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// parseServerJsonSpan parses a span.json written by otel-cli server json.
+func parseServerJsonSpan(js []byte) (*tracepb.Span, error) {
+	sjs := serverJsonSpan{}
+	if err := json.Unmarshal(js, &sjs); err != nil {
+		return nil, err
+	}
+
+	span := &sjs.Span
+	var err error
+	if span.Attributes, err = serverJsonAttributes(sjs.Attributes); err != nil {
+		return nil, err
+	}
+	span.Events = nil
+	for _, e := range sjs.Events {
+		event := e.Span_Event
+		if event == nil {
+			event = &tracepb.Span_Event{}
+		}
+		if event.Attributes, err = serverJsonAttributes(e.Attributes); err != nil {
+			return nil, err
+		}
+		span.Events = append(span.Events, event)
+	}
+	span.Links = nil
+	for _, l := range sjs.Links {
+		link := l.Span_Link
+		if link == nil {
+			link = &tracepb.Span_Link{}
+		}
+		if link.Attributes, err = serverJsonAttributes(l.Attributes); err != nil {
+			return nil, err
+		}
+		span.Links = append(span.Links, link)
+	}
+
+	return span, nil
+}
+
+// serverJsonAttributes converts span.json attributes to protobuf.
+func serverJsonAttributes(kvs []serverJsonKeyValue) ([]*commonpb.KeyValue, error) {
+	out := []*commonpb.KeyValue{}
+	for _, kv := range kvs {
+		value, err := serverJsonAnyValue(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for attribute %q: %w", kv.Key, err)
+		}
+		out = append(out, &commonpb.KeyValue{Key: kv.Key, Value: value})
+	}
+	return out, nil
+}
+
+// serverJsonAnyValue converts a span.json attribute value, e.g.
+// {"Value":{"StringValue":"x"}}, to protobuf.
+func serverJsonAnyValue(js json.RawMessage) (*commonpb.AnyValue, error) {
+	wrapper := struct {
+		Value struct {
+			StringValue *string
+			BoolValue   *bool
+			IntValue    *int64
+			DoubleValue *float64
+			BytesValue  []byte
+			ArrayValue  *struct {
+				Values []json.RawMessage `json:"values"`
+			}
+			KvlistValue *struct {
+				Values []serverJsonKeyValue `json:"values"`
+			}
+		}
+	}{}
+	if len(js) == 0 || string(js) == "null" {
+		return nil, nil
+	}
+	if err := json.Unmarshal(js, &wrapper); err != nil {
+		return nil, err
+	}
+
+	v := wrapper.Value
+	switch {
+	case v.StringValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: *v.StringValue}}, nil
+	case v.BoolValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: *v.BoolValue}}, nil
+	case v.IntValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: *v.IntValue}}, nil
+	case v.DoubleValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: *v.DoubleValue}}, nil
+	case v.BytesValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v.BytesValue}}, nil
+	case v.ArrayValue != nil:
+		array := &commonpb.ArrayValue{}
+		for _, js := range v.ArrayValue.Values {
+			value, err := serverJsonAnyValue(js)
+			if err != nil {
+				return nil, err
+			}
+			array.Values = append(array.Values, value)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: array}}, nil
+	case v.KvlistValue != nil:
+		kvs, err := serverJsonAttributes(v.KvlistValue.Values)
+		if err != nil {
+			return nil, err
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: kvs}}}, nil
+	}
+
+	return &commonpb.AnyValue{}, nil
+}
+
+// shiftReplaySpans moves every span and event by the same amount so the
+// earliest span starts at now.
+func shiftReplaySpans(batches []replayBatch, now time.Time) {
+	spans := []*tracepb.Span{}
+	for _, batch := range batches {
+		if batch.span != nil {
+			spans = append(spans, batch.span)
+		}
+		for _, rs := range batch.rsps {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+
+	var earliest uint64
+	for _, span := range spans {
+		if earliest == 0 || (span.StartTimeUnixNano > 0 && span.StartTimeUnixNano < earliest) {
+			earliest = span.StartTimeUnixNano
+		}
+	}
+	if earliest == 0 {
+		return
+	}
+
+	// unsigned math wraps around, so adding the difference works whether
+	// the spans move forward or back in time
+	delta := uint64(now.UnixNano()) - earliest
+	shift := func(ts uint64) uint64 {
+		if ts == 0 {
+			return 0
+		}
+		return ts + delta
+	}
+	for _, span := range spans {
+		span.StartTimeUnixNano = shift(span.StartTimeUnixNano)
+		span.EndTimeUnixNano = shift(span.EndTimeUnixNano)
+		for _, event := range span.Events {
+			event.TimeUnixNano = shift(event.TimeUnixNano)
+		}
+	}
+}
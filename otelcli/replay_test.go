@@ -0,0 +1,153 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReadReplayFiles(t *testing.T) {
+	dir := t.TempDir()
+	spans := treeTestTrace()
+
+	// an --ndjson stream with a log record mixed in
+	ndjson := bytes.Buffer{}
+	for _, s := range spans[:2] {
+		if err := writeNdjson(&ndjson, s.span, s.rss); err != nil {
+			t.Fatalf("failed to write ndjson: %s", err)
+		}
+	}
+	logs, _ := signalsTestData()
+	writeOtlpJsonLine(&ndjson, &logspb.LogsData{ResourceLogs: logs.ResourceLogs})
+	ndjsonPath := filepath.Join(dir, "spans.ndjson")
+	os.WriteFile(ndjsonPath, ndjson.Bytes(), 0644)
+
+	batches, skipped, err := readReplayFile(ndjsonPath)
+	if err != nil {
+		t.Fatalf("failed to read ndjson: %s", err)
+	}
+	if len(batches) != 2 || skipped != 1 {
+		t.Fatalf("expected 2 batches and 1 skipped line but got %d and %d", len(batches), skipped)
+	}
+	got := batches[1].rsps[0]
+	if !proto.Equal(got.ScopeSpans[0].Spans[0], spans[1].span) {
+		t.Errorf("the second span didn't round trip")
+	}
+	if service := resourceServiceName(got.GetResource()); service != "postgres" {
+		t.Errorf("expected the resource to be kept but got service %q", service)
+	}
+
+	// the span.json tree written by server json --dir
+	jsonSvr.outDir = filepath.Join(dir, "tree")
+	os.Mkdir(jsonSvr.outDir, 0755)
+	defer func() { jsonSvr.outDir = "" }()
+	for _, s := range spans {
+		renderJson(context.Background(), s.span, s.span.Events, s.rss, nil, nil)
+	}
+	files := replayFiles(DefaultConfig(), []string{jsonSvr.outDir})
+	if len(files) != len(spans) {
+		t.Fatalf("expected %d span.json files but got %d: %q", len(spans), len(files), files)
+	}
+	batches, _, err = readReplayFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read span.json: %s", err)
+	}
+	if len(batches) != 1 || batches[0].span == nil || len(batches[0].span.SpanId) == 0 {
+		t.Errorf("expected a span from span.json but got %+v", batches)
+	}
+
+	notSpans := filepath.Join(dir, "nope.json")
+	os.WriteFile(notSpans, []byte(`{"hello": "world"}`), 0644)
+	if _, _, err := readReplayFile(notSpans); err == nil {
+		t.Error("expected a file without spans to fail")
+	}
+}
+
+func TestShiftReplaySpans(t *testing.T) {
+	early := otlpclient.NewProtobufSpan()
+	early.StartTimeUnixNano = 1000
+	early.EndTimeUnixNano = 3000
+	early.Events = []*tracepb.Span_Event{{TimeUnixNano: 2000}}
+	late := otlpclient.NewProtobufSpan()
+	late.StartTimeUnixNano = 5000
+	late.EndTimeUnixNano = 6000
+	batches := []replayBatch{
+		{span: early},
+		{rsps: []*tracepb.ResourceSpans{{
+			Resource:   &resourcepb.Resource{},
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{late}}},
+		}}},
+	}
+
+	now := time.Unix(1700000000, 0)
+	shiftReplaySpans(batches, now)
+	base := uint64(now.UnixNano())
+	for name, pair := range map[string][2]uint64{
+		"early start": {early.StartTimeUnixNano, base},
+		"early end":   {early.EndTimeUnixNano, base + 2000},
+		"event":       {early.Events[0].TimeUnixNano, base + 1000},
+		"late start":  {late.StartTimeUnixNano, base + 4000},
+		"late end":    {late.EndTimeUnixNano, base + 5000},
+	} {
+		if pair[0] != pair[1] {
+			t.Errorf("expected %s to be %d but got %d", name, pair[1], pair[0])
+		}
+	}
+
+	// spans from the future move back in time
+	shiftReplaySpans(batches, now.Add(-time.Hour))
+	if early.StartTimeUnixNano != uint64(now.Add(-time.Hour).UnixNano()) || late.EndTimeUnixNano != early.StartTimeUnixNano+5000 {
+		t.Errorf("expected the spans to move back an hour but got %d and %d", early.StartTimeUnixNano, late.EndTimeUnixNano)
+	}
+}
+
+func TestParseServerJsonSpan(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = otlpclient.GenerateTraceId()
+	span.SpanId = otlpclient.GenerateSpanId()
+	span.Name = "checkout"
+	span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "boom"}
+	span.Attributes = []*commonpb.KeyValue{
+		{Key: "string", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "x"}}},
+		{Key: "int", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 42}}},
+		{Key: "double", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: 1.5}}},
+		{Key: "bool", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: true}}},
+		{Key: "array", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{
+			Values: []*commonpb.AnyValue{{Value: &commonpb.AnyValue_IntValue{IntValue: 1}}},
+		}}}},
+	}
+	span.Events = []*tracepb.Span_Event{{
+		Name:         "retry",
+		TimeUnixNano: 1234,
+		Attributes:   otlpclient.StringMapAttrsToProtobuf(map[string]string{"attempt": "2"}),
+	}}
+	span.Links = []*tracepb.Span_Link{{
+		TraceId:    otlpclient.GenerateTraceId(),
+		SpanId:     otlpclient.GenerateSpanId(),
+		Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"link": "yes"}),
+	}}
+
+	// the same encoding server json uses for span.json
+	js, err := json.Marshal(span)
+	if err != nil {
+		t.Fatalf("failed to marshal span: %s", err)
+	}
+	got, err := parseServerJsonSpan(js)
+	if err != nil {
+		t.Fatalf("failed to parse span.json: %s", err)
+	}
+	if !proto.Equal(got, span) {
+		t.Errorf("span.json did not round trip:\n%s", js)
+	}
+}
@@ -0,0 +1,299 @@
+package otelcli
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// httpRequest holds the command-line configured settings for otel-cli request.
+var httpRequest struct {
+	headers  []string
+	data     string
+	output   string
+	location bool
+	timeout  string
+}
+
+func requestCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "request [METHOD] URL",
+		Short: "make an HTTP request in a client span",
+		Long: `Make an HTTP request, a bit like curl, inside a client span with the HTTP
+semantic convention attributes, e.g. http.request.method, url.full, and
+http.response.status_code, and events for how long DNS, connecting, the TLS
+handshake, and the first byte of the response took. The span's traceparent
+is sent in the traceparent header so the server's spans join the trace.
+
+The response body is written to stdout or --output. The method defaults to
+GET, or POST with --data. Like curl --fail, otel-cli exits with 22 when the
+response status is 400 or above, and 1 when the request fails.
+
+Examples:
+	otel-cli request https://api.example.com/health
+	otel-cli request POST https://api.example.com/deploys -H 'Content-Type: application/json' -d @deploy.json
+`,
+		Args: cobra.RangeArgs(1, 2),
+		Run:  doRequest,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringArrayVarP(&httpRequest.headers, "header", "H", []string{}, "a 'Name: value' header to send, may be repeated")
+	cmd.Flags().StringVarP(&httpRequest.data, "data", "d", "", "the request body, or @file to read it from a file, @- for stdin")
+	cmd.Flags().StringVarP(&httpRequest.output, "output", "o", "", "write the response body to this file instead of stdout")
+	cmd.Flags().BoolVarP(&httpRequest.location, "location", "L", false, "follow redirects")
+	cmd.Flags().StringVar(&httpRequest.timeout, "request-timeout", "", "timeout for the request, defaults to no timeout")
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
+
+	return &cmd
+}
+
+func doRequest(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	method, target := http.MethodGet, args[0]
+	if httpRequest.data != "" {
+		method = http.MethodPost
+	}
+	if len(args) == 2 {
+		method, target = strings.ToUpper(args[0]), args[1]
+	}
+
+	body, err := requestBody(httpRequest.data)
+	config.SoftFailIfErr(err)
+	timeout, err := parseDuration(httpRequest.timeout)
+	config.SoftFailIfErr(err)
+
+	reqCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, method, target, body)
+	config.SoftFailIfErr(err)
+	for _, header := range httpRequest.headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			config.SoftFail("invalid header %q, must be 'Name: value'", header)
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if config.SpanName == DefaultConfig().SpanName {
+		config.SpanName = method
+	}
+	span := config.NewProtobufSpan()
+
+	// pass through a traceparent that's already set when not recording, like exec
+	tp := config.LoadTraceparent()
+	if config.GetIsRecording() {
+		tp = otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
+	}
+	if tp.Initialized {
+		req.Header.Set("traceparent", tp.Encode())
+		if span.TraceState != "" {
+			req.Header.Set("tracestate", span.TraceState)
+		}
+	}
+
+	client := &http.Client{}
+	if !httpRequest.location {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+
+	Diag.ExecExitCode = 0
+	resp, err := tracedHttpRequest(client, req, span)
+	if err != nil {
+		Diag.ExecExitCode = 1
+		config.SoftLog("request failed: %s", err)
+	} else {
+		out := io.Writer(os.Stdout)
+		if httpRequest.output != "" {
+			f, err := os.Create(httpRequest.output)
+			config.SoftFailIfErr(err)
+			defer f.Close()
+			out = f
+		}
+
+		n, err := io.Copy(out, resp.Body)
+		resp.Body.Close()
+		span.Attributes = append(span.Attributes, otlpclient.StringMapAttrsToProtobuf(map[string]string{
+			"http.response.body.size": strconv.FormatInt(n, 10),
+		})...)
+		if err != nil {
+			Diag.ExecExitCode = 1
+			otlpclient.SetSpanStatus(span, "error", fmt.Sprintf("reading the response failed: %s", err))
+		} else if resp.StatusCode >= 400 {
+			Diag.ExecExitCode = 22
+		}
+	}
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+
+	ctx, cancelSend := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancelSend()
+
+	ctx, otlpClient := StartClient(ctx, config)
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, span)
+		ctx, err = otlpclient.SendSpan(ctx, otlpClient, config, span)
+		if err != nil {
+			config.SoftFail("unable to send span: %s", err)
+		}
+	}
+
+	_, err = otlpClient.Stop(ctx)
+	if err != nil {
+		config.SoftFail("client.Stop() failed: %s", err)
+	}
+
+	config.PropagateTraceparent(span, os.Stdout)
+}
+
+// requestBody returns the body for --data, reading it from a file or stdin
+// when it starts with @.
+func requestBody(data string) (io.Reader, error) {
+	if data == "" {
+		return nil, nil
+	} else if data == "@-" {
+		return os.Stdin, nil
+	} else if strings.HasPrefix(data, "@") {
+		return os.Open(data[1:])
+	}
+	return strings.NewReader(data), nil
+}
+
+// tracedHttpRequest sends the request and adds the HTTP client semantic
+// convention attributes and an event for each phase of the request to the
+// span, with how long the phase took. The caller reads and closes the body.
+func tracedHttpRequest(client *http.Client, req *http.Request, span *tracepb.Span) (*http.Response, error) {
+	var mu sync.Mutex
+	started := time.Now()
+	var dnsStart, tlsStart time.Time
+	connectStarts := map[string]time.Time{}
+	event := func(name string, since time.Time, attrs map[string]string) {
+		now := time.Now()
+		duration := float64(now.Sub(since).Microseconds()) / 1000
+		mu.Lock()
+		defer mu.Unlock()
+		span.Events = append(span.Events, &tracepb.Span_Event{
+			Name:         name,
+			TimeUnixNano: uint64(now.UnixNano()),
+			Attributes: append(importAttrs(attrs),
+				&commonpb.KeyValue{Key: "duration_ms", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: duration}}},
+			),
+		})
+	}
+
+	// connections to each address can be attempted at the same time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			addrs := []string{}
+			for _, addr := range info.Addrs {
+				addrs = append(addrs, addr.String())
+			}
+			event("dns", dnsStart, map[string]string{"dns.addresses": strings.Join(addrs, ",")})
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			connectStarts[addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			start := connectStarts[addr]
+			mu.Unlock()
+			if err == nil {
+				event("connect", start, map[string]string{"network.peer.address": addr})
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err == nil {
+				event("tls", tlsStart, map[string]string{"tls.protocol.version": strings.TrimPrefix(tls.VersionName(state.Version), "TLS ")})
+			}
+		},
+		GotFirstResponseByte: func() { event("first_byte", started, nil) },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	port := req.URL.Port()
+	if port == "" && req.URL.Scheme == "https" {
+		port = "443"
+	} else if port == "" {
+		port = "80"
+	}
+	span.Attributes = append(span.Attributes, importAttrs(map[string]string{
+		"http.request.method": req.Method,
+		"url.full":            redactedUrl(req.URL),
+		"server.address":      req.URL.Hostname(),
+	})...)
+	span.Attributes = append(span.Attributes, otlpclient.StringMapAttrsToProtobuf(map[string]string{"server.port": port})...)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		errorType := "error"
+		var netErr net.Error
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			errorType = "dns"
+		} else if errors.As(err, &netErr) && netErr.Timeout() {
+			errorType = "timeout"
+		}
+		span.Attributes = append(span.Attributes, importAttrs(map[string]string{"error.type": errorType})...)
+		otlpclient.SetSpanStatus(span, "error", err.Error())
+		return nil, err
+	}
+
+	span.Attributes = append(span.Attributes,
+		&commonpb.KeyValue{Key: "http.response.status_code", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(resp.StatusCode)}}},
+	)
+	version := fmt.Sprintf("%d.%d", resp.ProtoMajor, resp.ProtoMinor)
+	if resp.ProtoMajor > 1 {
+		version = strconv.Itoa(resp.ProtoMajor)
+	}
+	span.Attributes = append(span.Attributes, importAttrs(map[string]string{"network.protocol.version": version})...)
+	// client spans are errors for 4xx and 5xx, with the status code as the type
+	if resp.StatusCode >= 400 {
+		span.Attributes = append(span.Attributes, importAttrs(map[string]string{"error.type": strconv.Itoa(resp.StatusCode)})...)
+		otlpclient.SetSpanStatus(span, "error", "")
+	}
+
+	return resp, nil
+}
+
+// redactedUrl returns the URL with any credentials in it replaced with
+// REDACTED, as the semantic conventions ask for url.full.
+func redactedUrl(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	out := *u
+	out.User = url.UserPassword("REDACTED", "REDACTED")
+	return out.String()
+}
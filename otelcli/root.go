@@ -4,7 +4,9 @@ package otelcli
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -47,12 +49,9 @@ func createRootCmd(config *Config) *cobra.Command {
 		Long:  `A command-line interface for generating OpenTelemetry data on the command line.`,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			config := getConfigRef(cmd.Context())
-			if err := config.LoadFile(); err != nil {
-				config.SoftFail("Error while loading configuration file %s: %s", config.CfgFile, err)
-			}
-			if err := config.LoadEnv(os.Getenv); err != nil {
-				// will need to specify --fail --verbose flags to see these errors
-				config.SoftFail("Error while loading environment variables: %s", err)
+			// will need to specify --fail --verbose flags to see these errors
+			for _, err := range loadConfig(config, os.Getenv) {
+				config.SoftFail("%s", err)
 			}
 		},
 	}
@@ -68,14 +67,64 @@ func createRootCmd(config *Config) *cobra.Command {
 
 	// add all the subcommands to rootCmd
 	rootCmd.AddCommand(spanCmd(config))
+	rootCmd.AddCommand(metricCmd(config))
+	rootCmd.AddCommand(logCmd(config))
+	rootCmd.AddCommand(logInjectCmd(config))
 	rootCmd.AddCommand(execCmd(config))
+	rootCmd.AddCommand(systemdExecCmd(config))
+	rootCmd.AddCommand(cronCmd(config))
+	rootCmd.AddCommand(makeCmd(config))
+	rootCmd.AddCommand(terraformCmd(config))
+	rootCmd.AddCommand(sshCmd(config))
+	rootCmd.AddCommand(requestCmd(config))
+	rootCmd.AddCommand(annotateCmd(config))
+	rootCmd.AddCommand(tpCmd(config))
+	rootCmd.AddCommand(k8sCmd(config))
+	rootCmd.AddCommand(flushCmd(config))
+	rootCmd.AddCommand(replayCmd(config))
+	rootCmd.AddCommand(importCmd(config))
 	rootCmd.AddCommand(statusCmd(config))
 	rootCmd.AddCommand(serverCmd(config))
+	rootCmd.AddCommand(agentCmd(config))
+	rootCmd.AddCommand(asyncCmd(config))
+	rootCmd.AddCommand(configCmd(config))
+	rootCmd.AddCommand(verifyCmd(config))
+	rootCmd.AddCommand(selftestCmd(config))
+	rootCmd.AddCommand(waitForEndpointCmd(config))
+	rootCmd.AddCommand(generateCmd(config))
+	rootCmd.AddCommand(shellInitCmd(config))
 	rootCmd.AddCommand(completionCmd(config))
 
 	return rootCmd
 }
 
+// loadConfig loads the config file and then environment variables into
+// config. Errors are returned instead of failing so that config validate
+// can report them.
+func loadConfig(config *Config, getenv func(string) string) []error {
+	errs := []error{}
+
+	// the profile has to be known before the file is loaded
+	if config.Profile == "" {
+		config.Profile = getenv("OTEL_CLI_PROFILE")
+	}
+	if err := config.LoadFile(); err != nil {
+		errs = append(errs, fmt.Errorf("Error while loading configuration file: %w", err))
+	}
+	if err := config.LoadEnv(getenv); err != nil {
+		errs = append(errs, fmt.Errorf("Error while loading environment variables: %w", err))
+	}
+	if err := config.expandTemplates(getenv, time.Now()); err != nil {
+		errs = append(errs, fmt.Errorf("Error while expanding templates: %w", err))
+	}
+	// after templates so command output is used as-is
+	if err := config.runAttributeCommands(context.Background()); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once.
 func Execute(version string) {
@@ -94,21 +143,41 @@ func addCommonParams(cmd *cobra.Command, config *Config) {
 	defaults := DefaultConfig()
 
 	// --config / -c a JSON configuration file
-	cmd.Flags().StringVarP(&config.CfgFile, "config", "c", defaults.CfgFile, "JSON configuration file")
+	cmd.Flags().StringVarP(&config.CfgFile, "config", "c", defaults.CfgFile, "YAML or JSON configuration file, defaults to ~/.config/otel-cli/config.yaml if it exists")
+	// --profile selects a named profile from the configuration file
+	cmd.Flags().StringVar(&config.Profile, "profile", defaults.Profile, "name of a profile in the configuration file to apply")
 	// --endpoint an endpoint to send otlp output to
-	cmd.Flags().StringVar(&config.Endpoint, "endpoint", defaults.Endpoint, "host and port for the desired OTLP/gRPC or OTLP/HTTP endpoint (use http:// or https:// for OTLP/HTTP)")
+	// can be repeated or comma-separated to send spans to more than one endpoint
+	cmd.Flags().Var(newCommaListValue(&config.Endpoint, defaults.Endpoint), "endpoint", "host and port for the desired OTLP/gRPC or OTLP/HTTP endpoint (use http:// or https:// for OTLP/HTTP), may be repeated")
 	// --traces-endpoint sets the endpoint for the traces signal
-	cmd.Flags().StringVar(&config.TracesEndpoint, "traces-endpoint", defaults.TracesEndpoint, "HTTP(s) URL for traces")
+	cmd.Flags().Var(newCommaListValue(&config.TracesEndpoint, defaults.TracesEndpoint), "traces-endpoint", "HTTP(s) URL for traces, may be repeated")
+	// --metrics-endpoint and --logs-endpoint set the endpoints for the other signals
+	cmd.Flags().StringVar(&config.MetricsEndpoint, "metrics-endpoint", defaults.MetricsEndpoint, "HTTP(s) URL for metrics")
+	cmd.Flags().StringVar(&config.LogsEndpoint, "logs-endpoint", defaults.LogsEndpoint, "HTTP(s) URL for logs")
+	for _, name := range []string{"endpoint", "traces-endpoint", "metrics-endpoint", "logs-endpoint"} {
+		cmd.RegisterFlagCompletionFunc(name, completeEndpoint)
+	}
 	// --protocol allows setting the OTLP protocol instead of relying on auto-detection from URI
-	cmd.Flags().StringVar(&config.Protocol, "protocol", defaults.Protocol, "desired OTLP protocol: grpc or http/protobuf")
+	cmd.Flags().StringVar(&config.Protocol, "protocol", defaults.Protocol, "desired OTLP protocol: grpc, grpc-web, http/protobuf, or http/json")
+	// --exporter console writes OTLP/JSON to stdout instead of sending it
+	cmd.Flags().StringVar(&config.Exporter, "exporter", defaults.Exporter, "set to 'console' to write OTLP/JSON lines to stdout, same as --endpoint stdout://, or 'none' to disable exports")
 	// --timeout a default timeout to use in all otel-cli operations (default 1s)
-	cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for otel-cli operations, all timeouts in otel-cli use this value")
+	cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for otel-cli operations, all timeouts in otel-cli default to this value")
 	// --verbose tells otel-cli to actually log errors to stderr instead of failing silently
 	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(cmd, config)
 	// --fail causes a non-zero exit status on error
 	cmd.Flags().BoolVar(&config.Fail, "fail", defaults.Fail, "on failure, exit with a non-zero status")
 }
 
+// addLogParams adds --log-level and --log-format for otel-cli's own
+// diagnostic logs on stderr, to commands with --verbose.
+func addLogParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.LogLevel, "log-level", defaults.LogLevel, "log diagnostics to stderr at this level: debug, info, warn, or error, debug includes OTLP requests")
+	cmd.Flags().StringVar(&config.LogFormat, "log-format", defaults.LogFormat, "format of diagnostic logs: logfmt or json")
+}
+
 // addClientParams adds the common CLI flags for e.g. span and exec to the command.
 // envvars are named according to the otel specs, others use the OTEL_CLI prefix
 // https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/sdk-environment-variables.md
@@ -119,15 +188,66 @@ func addClientParams(cmd *cobra.Command, config *Config) {
 
 	// OTEL_EXPORTER standard env and variable params
 	cmd.Flags().StringToStringVar(&config.Headers, "otlp-headers", defaults.Headers, "a comma-sparated list of key=value headers to send on OTLP connection")
+	cmd.Flags().StringVar(&config.OtlpHeadersFile, "otlp-headers-from-file", defaults.OtlpHeadersFile, "read key=value OTLP headers, one per line, from this file when sending")
+	cmd.Flags().StringVar(&config.OtlpHeaderCmd, "otlp-header-cmd", defaults.OtlpHeaderCmd, "run this shell command when sending and use its key=value output lines as OTLP headers")
+	cmd.Flags().StringToStringVar(&config.TracesHeaders, "traces-headers", defaults.TracesHeaders, "key=value headers to send only with traces, overriding --otlp-headers")
+	cmd.Flags().StringToStringVar(&config.MetricsHeaders, "metrics-headers", defaults.MetricsHeaders, "key=value headers to send only with metrics, overriding --otlp-headers")
+	cmd.Flags().StringToStringVar(&config.LogsHeaders, "logs-headers", defaults.LogsHeaders, "key=value headers to send only with logs, overriding --otlp-headers")
+	cmd.Flags().StringVar(&config.UserAgent, "user-agent", defaults.UserAgent, "User-Agent to send with exports, defaults to otel-cli/<version>")
+	cmd.Flags().StringToStringVar(&config.GrpcMetadata, "grpc-metadata", defaults.GrpcMetadata, "key=value metadata to send only with gRPC exports, overriding --otlp-headers")
+	cmd.Flags().StringVar(&config.Compression, "compression", defaults.Compression, "compress OTLP exports, gzip or none")
+	// --debug-wire on its own dumps to stderr, --debug-wire=wire.log to a file
+	cmd.Flags().StringVar(&config.DebugWire, "debug-wire", defaults.DebugWire, "dump every OTLP request and response, in hex and decoded, to stderr or to this file")
+	cmd.Flags().Lookup("debug-wire").NoOptDefVal = "stderr"
+	cmd.Flags().StringVar(&config.ConnectTimeout, "connect-timeout", defaults.ConnectTimeout, "give up connecting to the endpoint after this long, defaults to --timeout")
+	cmd.Flags().StringVar(&config.SendTimeout, "send-timeout", defaults.SendTimeout, "timeout for each export request, defaults to --timeout")
+	cmd.Flags().StringVar(&config.Proxy, "proxy", defaults.Proxy, "proxy URL for OTLP exports, http://, https://, or socks5://")
+	cmd.Flags().IntVar(&config.RetryMaxAttempts, "retry-max-attempts", defaults.RetryMaxAttempts, "maximum number of export attempts, 0 retries until --timeout")
+	cmd.Flags().StringVar(&config.RetryInitialInterval, "retry-initial-interval", defaults.RetryInitialInterval, "wait before the first retry, doubled on each retry with jitter")
+	cmd.Flags().StringVar(&config.RetryMaxInterval, "retry-max-interval", defaults.RetryMaxInterval, "maximum wait between retries")
+	cmd.Flags().StringVar(&config.FanoutPolicy, "fanout-policy", defaults.FanoutPolicy, "with multiple endpoints, 'any' succeeds if any endpoint accepts the span, 'all' requires every endpoint to, 'failover' sends to the first endpoint that works, 'round-robin' is failover starting at a different endpoint each run")
+	cmd.Flags().StringVar(&config.Sampler, "sampler", defaults.Sampler, "sampler name and optional argument, e.g. traceidratio=0.05 or parentbased_always_on")
+	cmd.Flags().StringVar(&config.ServiceVersion, "service-version", defaults.ServiceVersion, "set the service.version resource attribute")
+	cmd.Flags().StringVar(&config.Environment, "environment", defaults.Environment, "set the deployment.environment resource attribute, e.g. prod, staging")
+	cmd.Flags().StringToStringVar(&config.ResourceAttributes, "resource-attrs", defaults.ResourceAttributes, "key=value attributes to add to the resource, overriding OTEL_RESOURCE_ATTRIBUTES")
+	cmd.RegisterFlagCompletionFunc("resource-attrs", completeAttrs)
+	cmd.Flags().StringVar(&config.ResourceDetectors, "resource-detectors", defaults.ResourceDetectors, "comma-separated resource detectors to add attributes from: host, os, container, k8s")
+	cmd.Flags().IntVar(&config.SpanAttributeValueLengthLimit, "span-attribute-value-length-limit", defaults.SpanAttributeValueLengthLimit, "truncate string attribute values longer than this many characters, -1 for no limit")
+	cmd.Flags().IntVar(&config.SpanAttributeCountLimit, "span-attribute-count-limit", defaults.SpanAttributeCountLimit, "drop attributes beyond this many on each span, event, and link, -1 for no limit")
+	cmd.Flags().IntVar(&config.SpanEventCountLimit, "span-event-count-limit", defaults.SpanEventCountLimit, "drop the oldest events beyond this many on a span, -1 for no limit")
+	cmd.Flags().IntVar(&config.SpanLinkCountLimit, "span-link-count-limit", defaults.SpanLinkCountLimit, "drop links beyond this many on a span, -1 for no limit")
+	cmd.Flags().StringVar(&config.SpoolDir, "spool-dir", defaults.SpoolDir, "write spans that fail to export to this directory, send them later with 'otel-cli flush'")
+	cmd.Flags().StringVar(&config.SpoolKey, "spool-key", defaults.SpoolKey, "encrypt spooled spans with AES-256-GCM using the secret in this file or env:VARNAME")
+	cmd.Flags().StringVar(&config.OAuth2TokenURL, "oauth2-token-url", defaults.OAuth2TokenURL, "fetch a bearer token from this OAuth2 token endpoint with the client credentials grant")
+	cmd.Flags().StringVar(&config.OAuth2ClientID, "oauth2-client-id", defaults.OAuth2ClientID, "OAuth2 client id")
+	cmd.Flags().StringVar(&config.OAuth2ClientSecret, "oauth2-client-secret", defaults.OAuth2ClientSecret, "OAuth2 client secret")
+	cmd.Flags().StringVar(&config.OAuth2Scopes, "oauth2-scopes", defaults.OAuth2Scopes, "comma-separated list of OAuth2 scopes to request")
+	cmd.Flags().StringVar(&config.OAuth2CacheDir, "oauth2-cache-dir", defaults.OAuth2CacheDir, "directory to cache OAuth2 tokens in between runs, defaults to the user cache directory")
+	// file:// endpoint options, named after the collector's file exporter settings
+	cmd.Flags().StringVar(&config.FileFormat, "file-format", defaults.FileFormat, "format for file:// endpoints, json or proto")
+	cmd.Flags().IntVar(&config.FileMaxMegabytes, "file-max-megabytes", defaults.FileMaxMegabytes, "rotate file:// endpoints at this size, 0 disables rotation")
+	cmd.Flags().IntVar(&config.FileMaxDays, "file-max-days", defaults.FileMaxDays, "delete rotated files older than this many days, 0 keeps them")
+	cmd.Flags().IntVar(&config.FileMaxBackups, "file-max-backups", defaults.FileMaxBackups, "keep at most this many rotated files, 0 keeps all")
+	cmd.Flags().StringVar(&config.FileMaxAge, "file-max-age", defaults.FileMaxAge, "rotate file:// endpoints once the file is this old, e.g. 24h, empty disables it")
+	// kafka:// endpoint options, named after the collector's kafka exporter settings
+	cmd.Flags().StringVar(&config.KafkaBrokers, "kafka-brokers", defaults.KafkaBrokers, "comma-separated list of kafka brokers in addition to the kafka:// endpoint's host")
+	cmd.Flags().StringVar(&config.KafkaEncoding, "kafka-encoding", defaults.KafkaEncoding, "encoding for kafka:// endpoints, otlp_proto or otlp_json")
+	cmd.Flags().StringVar(&config.KafkaSASLMechanism, "kafka-sasl-mechanism", defaults.KafkaSASLMechanism, "kafka SASL mechanism, PLAIN, SCRAM-SHA-256, or SCRAM-SHA-512")
+	cmd.Flags().StringVar(&config.KafkaSASLUsername, "kafka-sasl-username", defaults.KafkaSASLUsername, "kafka SASL username")
+	cmd.Flags().StringVar(&config.KafkaSASLPassword, "kafka-sasl-password", defaults.KafkaSASLPassword, "kafka SASL password")
+	cmd.Flags().BoolVar(&config.Async, "async", defaults.Async, "export in a detached helper process and return immediately, see 'otel-cli async'")
+	cmd.Flags().IntVar(&config.AsyncMaxInflight, "async-max-inflight", defaults.AsyncMaxInflight, "with --async, export synchronously once this many async sends are pending")
+	addAsyncParams(cmd, config)
+	cmd.Flags().StringVar(&config.AgentSocket, "agent-socket", defaults.AgentSocket, "hand spans to the 'otel-cli agent' listening on this unix socket, exports directly if it isn't running")
 
 	// DEPRECATED
 	// TODO: remove before 1.0
 	cmd.Flags().BoolVar(&config.Blocking, "otlp-blocking", defaults.Blocking, "DEPRECATED: does nothing, please file an issue if you need this.")
 
 	cmd.Flags().BoolVar(&config.Insecure, "insecure", defaults.Insecure, "allow connecting to cleartext endpoints")
-	cmd.Flags().StringVar(&config.TlsCACert, "tls-ca-cert", defaults.TlsCACert, "a file containing the certificate authority bundle")
-	cmd.Flags().StringVar(&config.TlsClientCert, "tls-client-cert", defaults.TlsClientCert, "a file containing the client certificate")
-	cmd.Flags().StringVar(&config.TlsClientKey, "tls-client-key", defaults.TlsClientKey, "a file containing the client certificate key")
+	cmd.Flags().StringVar(&config.TlsCACert, "tls-ca-cert", defaults.TlsCACert, "a file, inline PEM, or env:VARNAME containing the certificate authority bundle")
+	cmd.Flags().StringVar(&config.TlsClientCert, "tls-client-cert", defaults.TlsClientCert, "a file, inline PEM, or env:VARNAME containing the client certificate")
+	cmd.Flags().StringVar(&config.TlsClientKey, "tls-client-key", defaults.TlsClientKey, "a file, inline PEM, or env:VARNAME containing the client certificate key")
 	cmd.Flags().BoolVar(&config.TlsNoVerify, "tls-no-verify", defaults.TlsNoVerify, "insecure! disables verification of the server certificate and name, mostly for self-signed CAs")
 	// --no-tls-verify is deprecated, will remove before 1.0
 	cmd.Flags().BoolVar(&config.TlsNoVerify, "no-tls-verify", defaults.TlsNoVerify, "(deprecated) same as --tls-no-verify")
@@ -135,9 +255,11 @@ func addClientParams(cmd *cobra.Command, config *Config) {
 	// OTEL_CLI trace propagation options
 	cmd.Flags().BoolVar(&config.TraceparentRequired, "tp-required", defaults.TraceparentRequired, "when set to true, fail and log if a traceparent can't be picked up from TRACEPARENT ennvar or a carrier file")
 	cmd.Flags().StringVar(&config.TraceparentCarrierFile, "tp-carrier", defaults.TraceparentCarrierFile, "a file for reading and WRITING traceparent across invocations")
+	cmd.Flags().StringVar(&config.TraceparentCarrierFormat, "tp-carrier-format", defaults.TraceparentCarrierFormat, "format used when writing --tp-carrier, text or json (reading detects the format)")
 	cmd.Flags().BoolVar(&config.TraceparentIgnoreEnv, "tp-ignore-env", defaults.TraceparentIgnoreEnv, "ignore the TRACEPARENT envvar even if it's set")
 	cmd.Flags().BoolVar(&config.TraceparentPrint, "tp-print", defaults.TraceparentPrint, "print the trace id, span id, and the w3c-formatted traceparent representation of the new span")
 	cmd.Flags().BoolVarP(&config.TraceparentPrintExport, "tp-export", "p", defaults.TraceparentPrintExport, "same as --tp-print but it puts an 'export ' in front so it's more convinenient to source in scripts")
+	cmd.Flags().StringVar(&config.TraceparentPrintFormat, "tp-print-format", defaults.TraceparentPrintFormat, "output format for --tp-print, one of w3c, hex, trace-id, span-id, xray, b3, or json")
 }
 
 func addSpanParams(cmd *cobra.Command, config *Config) {
@@ -149,6 +271,7 @@ func addSpanParams(cmd *cobra.Command, config *Config) {
 	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the traces")
 	// --kind / -k
 	cmd.Flags().StringVarP(&config.Kind, "kind", "k", defaults.Kind, "set the trace kind, e.g. internal, server, client, producer, consumer")
+	cmd.RegisterFlagCompletionFunc("kind", completeKind)
 
 	// expert options: --force-trace-id, --force-span-id, --force-parent-span-id allow setting custom trace, span and parent span ids
 	cmd.Flags().StringVar(&config.ForceTraceId, "force-trace-id", defaults.ForceTraceId, "expert: force the trace id to be the one provided in hex")
@@ -182,4 +305,61 @@ func addAttrParams(cmd *cobra.Command, config *Config) {
 	// --attrs key=value,foo=bar
 	config.Attributes = make(map[string]string)
 	cmd.Flags().StringToStringVarP(&config.Attributes, "attrs", "a", defaults.Attributes, "a comma-separated list of key=value attributes")
+	cmd.RegisterFlagCompletionFunc("attrs", completeAttrs)
+	// --attr-from-cmd 'git_sha=git rev-parse HEAD'
+	cmd.Flags().StringArrayVar(&config.AttributeCommands, "attr-from-cmd", defaults.AttributeCommands, "key=command, set the attribute to the trimmed output of the shell command, may be repeated")
+}
+
+// addGrafanaParams adds the flags for posting Grafana annotations.
+func addGrafanaParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.GrafanaUrl, "grafana-url", defaults.GrafanaUrl, "Grafana URL to post annotations to, e.g. https://grafana.example.com")
+	cmd.Flags().StringVar(&config.GrafanaToken, "grafana-token", defaults.GrafanaToken, "Grafana service account token")
+	cmd.Flags().StringVar(&config.GrafanaDashboardUid, "grafana-dashboard-uid", defaults.GrafanaDashboardUid, "put the annotation on this dashboard instead of the whole organization")
+	cmd.Flags().StringVar(&config.GrafanaTags, "grafana-tags", defaults.GrafanaTags, "comma-separated tags to add to the annotation")
+	cmd.Flags().StringVar(&config.GrafanaTraceUrl, "grafana-trace-url", defaults.GrafanaTraceUrl, "link the annotation to this URL, with {trace_id} replaced by the trace id")
+}
+
+// addPushgatewayParams adds the flags for pushing job metrics to a Prometheus Pushgateway.
+func addPushgatewayParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.PushgatewayUrl, "pushgateway-url", defaults.PushgatewayUrl, "also push the command's duration and result to this Prometheus Pushgateway")
+	cmd.Flags().StringVar(&config.PushgatewayJob, "pushgateway-job", defaults.PushgatewayJob, "the job label for the Pushgateway, defaults to the span name or the command")
+	cmd.Flags().StringToStringVar(&config.PushgatewayLabels, "pushgateway-labels", defaults.PushgatewayLabels, "key=value grouping labels for the Pushgateway, e.g. instance=web1")
+}
+
+// addJsonParams adds --json for commands that can print a jsonResult.
+func addJsonParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().BoolVar(&config.Json, "json", defaults.Json, "print a JSON result with the trace id, span id, traceparent, endpoint, send status, and exit code")
+}
+
+// addStatsdParams adds the flags for sending span metrics to StatsD.
+func addStatsdParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.Statsd, "statsd", defaults.Statsd, "also send the span's duration and a status counter to this StatsD host:port")
+	cmd.Flags().StringVar(&config.StatsdPrefix, "statsd-prefix", defaults.StatsdPrefix, "the prefix for StatsD metric names")
+	cmd.Flags().BoolVar(&config.StatsdDogstatsd, "statsd-dogstatsd", defaults.StatsdDogstatsd, "send the span name and status as DogStatsD tags instead of in the metric names")
+}
+
+// addClockSkewParams adds the flags for correcting span timestamps for a
+// local clock that's off.
+func addClockSkewParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	// --clock-skew ntp|collector|1.5s
+	cmd.Flags().StringVar(&config.ClockSkew, "clock-skew", defaults.ClockSkew, "correct span timestamps for local clock skew: ntp, collector to read the Date of an OTLP/HTTP response, or a fixed duration to add")
+	cmd.Flags().StringVar(&config.ClockSkewNtpServer, "clock-skew-ntp-server", defaults.ClockSkewNtpServer, "the NTP server to ask for the time with --clock-skew ntp")
+}
+
+// addAttrsFileParams adds --attrs-file, --ci-detect, and --git-attrs to
+// commands that build their own payloads, which leaves out span event and
+// span end since those send --attrs to span background as strings.
+func addAttrsFileParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	// --attrs-file build-meta.json
+	cmd.Flags().StringVar(&config.AttributesFile, "attrs-file", defaults.AttributesFile, "a JSON or YAML file of typed attributes to add, overridden by --attrs")
+	// --ci-detect auto|none|github,gitlab,...
+	cmd.Flags().StringVar(&config.CiDetect, "ci-detect", defaults.CiDetect, "CI systems to add run attributes for: auto, none, or a comma-separated list of github, gitlab, jenkins, circleci, buildkite")
+	// --git-attrs adds the repository, branch, and revision of the working directory
+	cmd.Flags().BoolVar(&config.GitAttrs, "git-attrs", defaults.GitAttrs, "add the git remote URL, branch, revision, and whether the work tree is dirty as attributes")
 }
@@ -0,0 +1,273 @@
+package otelcli
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// selftest holds the command-line configured settings for otel-cli selftest.
+var selftest struct {
+	tls bool
+}
+
+// selftestResult is what a selftest found, with how long each part took.
+type selftestResult struct {
+	protocol string
+	endpoint string
+	tls      bool
+	startup  time.Duration // starting the receiver and the client
+	send     time.Duration // until the client returned
+	receive  time.Duration // until the receiver had the span
+	problems []string
+}
+
+func selftestCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "selftest",
+		Short: "send a span to an in-process receiver and check that it arrives intact",
+		Long: `Starts an OTLP receiver on a random localhost port, sends it a span with
+the same client code, protocol, headers, compression, attributes, and limits
+that span and exec would use, then checks the receiver got the span exactly
+as it was sent. A quick way to prove the binary and its config work on a new
+machine without a collector. With --tls, the receiver uses a throwaway
+self-signed certificate that the client is set up to trust.
+
+Only the endpoint, TLS, and settings that would send somewhere else, like
+--agent, --async, and --spool-dir, are replaced. Exits non-zero if the span
+didn't arrive intact within --timeout.
+
+Example:
+	otel-cli selftest
+	otel-cli selftest --protocol http/json --tls --attrs deploy.env=staging
+`,
+		Args: cobra.NoArgs,
+		Run:  doSelftest,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVar(&selftest.tls, "tls", false, "serve the receiver with a self-signed certificate and send to it over TLS")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSelftest(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+	ctx, cancel := context.WithTimeout(ctx, config.GetTimeout())
+	defer cancel()
+
+	result, err := runSelftest(ctx, config, selftest.tls)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "FAIL: %s\n", err)
+		os.Exit(1)
+	}
+	if !writeSelftestReport(os.Stdout, result) {
+		os.Exit(1)
+	}
+}
+
+// runSelftest sends a span to a receiver of its own over the configured
+// protocol and returns what it found. Errors are for failing to run the
+// test at all, problems with the span are in the result.
+func runSelftest(ctx context.Context, config Config, useTls bool) (selftestResult, error) {
+	result := selftestResult{protocol: config.Protocol, tls: useTls}
+	if result.protocol == "" {
+		result.protocol = "grpc"
+	}
+	serverProtocol := "http"
+	switch result.protocol {
+	case "grpc":
+		serverProtocol = "grpc"
+	case "http/protobuf", "http/json":
+	default:
+		return result, fmt.Errorf("selftest can't receive protocol %q, only grpc, http/protobuf, and http/json", result.protocol)
+	}
+
+	start := time.Now()
+	received := make(chan *tracepb.ResourceSpans, 1)
+	var receivedAt time.Time
+	server := otlpserver.NewServer(serverProtocol, func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+		receivedAt = time.Now()
+		select {
+		case received <- rss:
+		default:
+		}
+		return false
+	}, func(otlpserver.OtlpServer) {})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return result, fmt.Errorf("failed to listen for the receiver: %w", err)
+	}
+
+	// only the settings that point the span somewhere else are replaced
+	config = config.WithAgentSocket("").WithAsync(false).WithSpoolDir("").
+		WithExporter("").WithTracesEndpoint("").WithProxy("").
+		WithTlsNoVerify(false).WithTlsCACert("").WithTlsClientCert("").WithTlsClientKey("").
+		WithProtocol(result.protocol)
+	config.Targets = nil
+
+	scheme := "grpc"
+	if serverProtocol == "http" {
+		scheme = "http"
+	}
+	if useTls {
+		cert, certPEM, err := selftestCert()
+		if err != nil {
+			listener.Close()
+			return result, fmt.Errorf("failed to create a certificate for the receiver: %w", err)
+		}
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		})
+		// the CA cert can be inline PEM, so nothing is written to disk
+		config = config.WithTlsCACert(string(certPEM))
+		scheme = "https"
+	}
+	result.endpoint = fmt.Sprintf("%s://%s", scheme, listener.Addr())
+	config = config.WithEndpoint(result.endpoint)
+
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx, client := StartClient(ctx, config)
+	result.startup = time.Since(start)
+
+	span := config.NewProtobufSpan()
+	start = time.Now()
+	ctx, err = otlpclient.SendSpan(ctx, client, config, span)
+	result.send = time.Since(start)
+	client.Stop(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to send the span to %s: %w", result.endpoint, err)
+	}
+
+	select {
+	case rss := <-received:
+		result.receive = receivedAt.Sub(start)
+		result.problems = selftestProblems(config, span, rss)
+	case <-ctx.Done():
+		return result, fmt.Errorf("the receiver didn't get the span within --timeout")
+	}
+
+	return result, nil
+}
+
+// selftestProblems compares the span that was sent with what the receiver
+// got, returning what didn't survive the trip.
+func selftestProblems(config Config, sent *tracepb.Span, rss *tracepb.ResourceSpans) []string {
+	if len(rss.ScopeSpans) != 1 || len(rss.ScopeSpans[0].Spans) != 1 {
+		return []string{"the receiver didn't get exactly one span"}
+	}
+	got := rss.ScopeSpans[0].Spans[0]
+
+	problems := []string{}
+	check := func(field string, equal bool) {
+		if !equal {
+			problems = append(problems, field+" changed")
+		}
+	}
+	if !proto.Equal(sent, got) {
+		check("trace id", string(sent.TraceId) == string(got.TraceId))
+		check("span id", string(sent.SpanId) == string(got.SpanId))
+		check("parent span id", string(sent.ParentSpanId) == string(got.ParentSpanId))
+		check("name", sent.Name == got.Name)
+		check("kind", sent.Kind == got.Kind)
+		check("start time", sent.StartTimeUnixNano == got.StartTimeUnixNano)
+		check("end time", sent.EndTimeUnixNano == got.EndTimeUnixNano)
+		check("attributes", proto.Equal(&tracepb.Span{Attributes: sent.Attributes}, &tracepb.Span{Attributes: got.Attributes}))
+		check("events", proto.Equal(&tracepb.Span{Events: sent.Events}, &tracepb.Span{Events: got.Events}))
+		check("status", proto.Equal(sent.Status, got.Status))
+		// anything else, e.g. links or dropped counts
+		if len(problems) == 0 {
+			problems = append(problems, "span changed")
+		}
+	}
+
+	serviceName := ""
+	for _, attr := range rss.GetResource().GetAttributes() {
+		if attr.Key == "service.name" {
+			serviceName = attr.Value.GetStringValue()
+		}
+	}
+	check("resource service.name", serviceName == config.GetServiceName())
+
+	return problems
+}
+
+// writeSelftestReport prints the result of a selftest and returns true if
+// the span arrived intact.
+func writeSelftestReport(w io.Writer, result selftestResult) bool {
+	transport := "without TLS"
+	if result.tls {
+		transport = "over TLS"
+	}
+
+	if len(result.problems) > 0 {
+		fmt.Fprintf(w, "FAIL: the span sent with %s %s to %s didn't arrive intact\n", result.protocol, transport, result.endpoint)
+		for _, problem := range result.problems {
+			fmt.Fprintf(w, "  %s\n", problem)
+		}
+		return false
+	}
+
+	fmt.Fprintf(w, "PASS: the span sent with %s %s to %s arrived intact\n", result.protocol, transport, result.endpoint)
+	fmt.Fprintf(w, "  startup: %s\n", result.startup.Round(time.Microsecond))
+	fmt.Fprintf(w, "  send:    %s\n", result.send.Round(time.Microsecond))
+	fmt.Fprintf(w, "  receive: %s\n", result.receive.Round(time.Microsecond))
+	return true
+}
+
+// selftestCert returns a self-signed certificate for 127.0.0.1 that's only
+// good for as long as a selftest, along with its PEM for the client to trust.
+func selftestCert() (tls.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "otel-cli selftest"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certPEM, nil
+}
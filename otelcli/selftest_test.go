@@ -0,0 +1,104 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRunSelftest(t *testing.T) {
+	for _, protocol := range []string{"", "grpc", "http/protobuf", "http/json"} {
+		for _, useTls := range []bool{false, true} {
+			config := DefaultConfig().
+				WithProtocol(protocol).
+				WithAttributes(map[string]string{"deploy.env": "staging"}).
+				WithEndpoint("https://collector.invalid:4317").
+				WithSpoolDir(t.TempDir())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			result, err := runSelftest(ctx, config, useTls)
+			cancel()
+			if err != nil {
+				t.Errorf("[%q tls=%t] selftest failed: %s", protocol, useTls, err)
+				continue
+			}
+			if len(result.problems) > 0 {
+				t.Errorf("[%q tls=%t] expected the span to arrive intact but got %q", protocol, useTls, result.problems)
+			}
+			if useTls != strings.HasPrefix(result.endpoint, "https://") {
+				t.Errorf("[%q tls=%t] got the wrong endpoint %q", protocol, useTls, result.endpoint)
+			}
+		}
+	}
+}
+
+func TestRunSelftestUnsupportedProtocol(t *testing.T) {
+	if _, err := runSelftest(context.Background(), DefaultConfig().WithProtocol("grpc-web"), false); err == nil {
+		t.Error("expected selftest to refuse grpc-web")
+	}
+}
+
+func TestSelftestProblems(t *testing.T) {
+	config := DefaultConfig()
+	sent := otlpclient.NewProtobufSpan()
+	sent.Name = "selftest"
+	sent.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{"a": "b"})
+	resource := &resourcepb.Resource{Attributes: []*commonpb.KeyValue{{
+		Key:   "service.name",
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: config.GetServiceName()}},
+	}}}
+	received := func(span *tracepb.Span) *tracepb.ResourceSpans {
+		return &tracepb.ResourceSpans{
+			Resource:   resource,
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+		}
+	}
+
+	if problems := selftestProblems(config, sent, received(proto.Clone(sent).(*tracepb.Span))); len(problems) != 0 {
+		t.Errorf("expected no problems with an identical span but got %q", problems)
+	}
+
+	got := proto.Clone(sent).(*tracepb.Span)
+	got.Name = "mangled"
+	got.Attributes = nil
+	problems := selftestProblems(config, sent, received(got))
+	if strings.Join(problems, ", ") != "name changed, attributes changed" {
+		t.Errorf("expected the name and attributes to have changed but got %q", problems)
+	}
+
+	got = proto.Clone(sent).(*tracepb.Span)
+	got.DroppedAttributesCount = 1
+	if problems := selftestProblems(config, sent, received(got)); len(problems) != 1 || problems[0] != "span changed" {
+		t.Errorf("expected any other change to be reported but got %q", problems)
+	}
+
+	if problems := selftestProblems(config.WithServiceName("other"), sent, received(sent)); len(problems) != 1 || problems[0] != "resource service.name changed" {
+		t.Errorf("expected the service name to have changed but got %q", problems)
+	}
+}
+
+func TestWriteSelftestReport(t *testing.T) {
+	out := bytes.Buffer{}
+	if !writeSelftestReport(&out, selftestResult{protocol: "grpc", endpoint: "grpc://127.0.0.1:4317"}) {
+		t.Error("expected a result without problems to pass")
+	}
+	if !strings.HasPrefix(out.String(), "PASS: ") {
+		t.Errorf("expected PASS but got %q", out.String())
+	}
+
+	out.Reset()
+	if writeSelftestReport(&out, selftestResult{protocol: "grpc", tls: true, problems: []string{"name changed"}}) {
+		t.Error("expected a result with problems to fail")
+	}
+	if !strings.HasPrefix(out.String(), "FAIL: ") || !strings.Contains(out.String(), "over TLS") || !strings.Contains(out.String(), "  name changed\n") {
+		t.Errorf("expected FAIL with the problem but got %q", out.String())
+	}
+}
@@ -0,0 +1,182 @@
+package otelcli
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed semconv/registry.yaml
+var semconvRegistryYaml []byte
+
+// semconvRegistry is the bundled list of semantic convention attributes.
+type semconvRegistry struct {
+	Version    string              `yaml:"version"`
+	Attributes map[string]string   `yaml:"attributes"`
+	Deprecated map[string]string   `yaml:"deprecated"`
+	Values     map[string][]string `yaml:"values"`
+}
+
+// bundledSemconv is parsed once at startup, the tests make sure it parses.
+var bundledSemconv = mustParseSemconvRegistry(semconvRegistryYaml)
+
+func mustParseSemconvRegistry(data []byte) semconvRegistry {
+	reg := semconvRegistry{}
+	if err := yaml.Unmarshal(data, &reg); err != nil {
+		panic(fmt.Sprintf("BUG: bundled semantic conventions registry is invalid: %s", err))
+	}
+	return reg
+}
+
+// lint checks the attributes against the registry and returns an error for
+// each deprecated key, value of the wrong type, and key that's probably a
+// typo of a registry key. where names the attributes in the errors.
+func (reg semconvRegistry) lint(where string, attrs []*commonpb.KeyValue) []error {
+	sorted := append([]*commonpb.KeyValue{}, attrs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	errs := []error{}
+	for _, attr := range sorted {
+		if replacement, ok := reg.Deprecated[attr.Key]; ok {
+			errs = append(errs, fmt.Errorf("%s %s: deprecated, use %s", where, attr.Key, replacement))
+			continue
+		}
+
+		if want, ok := reg.Attributes[attr.Key]; ok {
+			if got := semconvType(attr.Value); !semconvTypeMatches(want, got) {
+				errs = append(errs, fmt.Errorf("%s %s: should be %s but %q is %s", where, attr.Key, want, otlpclient.AttrValueToString(attr), got))
+			}
+			continue
+		}
+
+		if suggestion := reg.suggest(attr.Key); suggestion != "" {
+			errs = append(errs, fmt.Errorf("%s %s: not a semantic convention, did you mean %s?", where, attr.Key, suggestion))
+		}
+	}
+
+	return errs
+}
+
+// suggest returns the registry key closest to the key if it's within a
+// couple of edits, e.g. a typo, a missing dot, or the wrong case.
+func (reg semconvRegistry) suggest(key string) string {
+	// short keys are too close to everything to guess
+	if len(key) < 6 {
+		return ""
+	}
+
+	best, bestDist := "", 3
+	for known := range reg.Attributes {
+		if dist := editDistance(strings.ToLower(key), known); dist < bestDist || (dist == bestDist && known < best) {
+			best, bestDist = known, dist
+		}
+	}
+	return best
+}
+
+// semconvType returns the registry type name for the value.
+func semconvType(value *commonpb.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return "string"
+	case *commonpb.AnyValue_IntValue:
+		return "int"
+	case *commonpb.AnyValue_DoubleValue:
+		return "double"
+	case *commonpb.AnyValue_BoolValue:
+		return "boolean"
+	case *commonpb.AnyValue_ArrayValue:
+		if values := v.ArrayValue.GetValues(); len(values) > 0 {
+			return semconvType(values[0]) + "[]"
+		}
+		return "[]"
+	default:
+		return "unsupported"
+	}
+}
+
+// semconvTypeMatches returns true if a value of type got is fine for an
+// attribute of type want. ints are fine as doubles, and an empty array is
+// fine as any array.
+func semconvTypeMatches(want, got string) bool {
+	switch {
+	case want == got:
+		return true
+	case want == "double" && got == "int":
+		return true
+	case got == "[]" && strings.HasSuffix(want, "[]"):
+		return true
+	}
+	return false
+}
+
+// editDistance returns the optimal string alignment distance between the
+// strings, the Levenshtein distance but with swapped letters counted as one
+// edit since that's a common typo.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// semconvErrors lints the attributes and resource attributes otel-cli is
+// configured to send.
+func (c Config) semconvErrors() []error {
+	resource := map[string]string{"service.name": c.GetServiceName()}
+	for k, v := range c.GetResourceAttributes() {
+		resource[k] = v
+	}
+
+	errs := bundledSemconv.lint("attribute", c.GetAttributes())
+	return append(errs, bundledSemconv.lint("resource attribute", otlpclient.StringMapAttrsToProtobuf(resource))...)
+}
+
+// checkStrictSemconv prints semantic convention problems and exits non-zero
+// when --strict-semconv is set, before anything is sent or run. Unlike most
+// otel-cli errors it doesn't need --fail since strictness was asked for.
+func (c Config) checkStrictSemconv() {
+	if !c.StrictSemconv {
+		return
+	}
+
+	errs := c.semconvErrors()
+	if len(errs) == 0 {
+		return
+	}
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "semconv: %s\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "--strict-semconv: %d semantic convention problem(s) found, not sending\n", len(errs))
+	os.Exit(1)
+}
+
+// WithStrictSemconv returns the config with StrictSemconv set to the provided value.
+func (c Config) WithStrictSemconv(with bool) Config {
+	c.StrictSemconv = with
+	return c
+}
@@ -0,0 +1,109 @@
+package otelcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+)
+
+func TestBundledSemconv(t *testing.T) {
+	if bundledSemconv.Version == "" || len(bundledSemconv.Attributes) == 0 {
+		t.Fatal("expected the bundled registry to have a version and attributes")
+	}
+	for key, typ := range bundledSemconv.Attributes {
+		switch strings.TrimSuffix(typ, "[]") {
+		case "string", "int", "double", "boolean":
+		default:
+			t.Errorf("%s has unknown type %q", key, typ)
+		}
+	}
+	for old, replacement := range bundledSemconv.Deprecated {
+		if _, ok := bundledSemconv.Attributes[replacement]; !ok {
+			t.Errorf("%s is replaced by %s, which isn't in the registry", old, replacement)
+		}
+	}
+	for key, values := range bundledSemconv.Values {
+		if bundledSemconv.Attributes[key] != "string" || len(values) == 0 {
+			t.Errorf("%s has values but isn't a string attribute in the registry", key)
+		}
+	}
+}
+
+func TestSemconvLint(t *testing.T) {
+	attrs := otlpclient.StringMapAttrsToProtobuf(map[string]string{
+		"http.method":               "GET",
+		"http.response.status_code": "ok",
+		"server.port":               "8080",
+		"htpp.route":                "/api",
+		"Service.Version":           "1.2.3",
+		"team":                      "payments",
+		"ci.pipeline.id":            "42",
+	})
+
+	errs := bundledSemconv.lint("attribute", attrs)
+	want := []string{
+		`attribute Service.Version: not a semantic convention, did you mean service.version?`,
+		`attribute htpp.route: not a semantic convention, did you mean http.route?`,
+		`attribute http.method: deprecated, use http.request.method`,
+		`attribute http.response.status_code: should be int but "ok" is string`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors but got %d: %q", len(want), len(errs), errs)
+	}
+	for i := range want {
+		if errs[i].Error() != want[i] {
+			t.Errorf("expected error %d to be %q but got %q", i, want[i], errs[i])
+		}
+	}
+}
+
+func TestSemconvErrors(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	path := filepath.Join(t.TempDir(), "attrs.json")
+	os.WriteFile(path, []byte(`{"process.command_args": ["make", "test"], "code.lineno": 1.5}`), 0644)
+
+	c := DefaultConfig().
+		WithAttributesFile(path).
+		WithResourceAttributes(map[string]string{"host.nmae": "build-7"}).
+		WithServiceVersion("1.2")
+	errs := c.semconvErrors()
+
+	out := bytes.Buffer{}
+	if writeVerifyReport(&out, errs) {
+		t.Error("expected the report to fail")
+	}
+	want := "FAIL: 3 problem(s) found\n" +
+		"  attribute code.lineno: should be int but \"1.5\" is double\n" +
+		"  resource attribute host.nmae: not a semantic convention, did you mean host.name?\n" +
+		"  resource attribute service.version: should be string but \"1.2\" is double\n"
+	if out.String() != want {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+
+	out.Reset()
+	if !writeVerifyReport(&out, DefaultConfig().semconvErrors()) {
+		t.Errorf("expected the defaults to pass but got:\n%s", out.String())
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"host.name", "host.name", 0},
+		{"host.nmae", "host.name", 1},
+		{"htpp.route", "http.route", 1},
+		{"servicename", "service.name", 1},
+		{"host.name", "host.mac", 3},
+		{"", "abc", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("expected the distance from %q to %q to be %d but got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
@@ -1,10 +1,21 @@
 package otelcli
 
 import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
 	"strings"
 
+	"github.com/equinix-labs/otel-cli/otlpclient"
 	"github.com/equinix-labs/otel-cli/otlpserver"
 	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
 const defaultOtlpEndpoint = "grpc://localhost:4317"
@@ -19,30 +30,254 @@ func serverCmd(config *Config) *cobra.Command {
 
 	cmd.AddCommand(serverJsonCmd(config))
 	cmd.AddCommand(serverTuiCmd(config))
+	cmd.AddCommand(serverForwardCmd(config))
+	cmd.AddCommand(serverAssertCmd(config))
+	cmd.AddCommand(serverWebCmd(config))
+	cmd.AddCommand(serverTreeCmd(config))
 
 	return &cmd
 }
 
+// addServerParams adds the listener flags shared by the server subcommands.
+func addServerParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.ServerHttpEndpoint, "http-endpoint", defaults.ServerHttpEndpoint, "also accept OTLP/HTTP protobuf and JSON on this host:port, e.g. localhost:4318")
+	cmd.Flags().StringVar(&config.ServerTlsCert, "tls-cert", defaults.ServerTlsCert, "a file, inline PEM, or env:VARNAME containing the certificate to serve TLS with")
+	cmd.Flags().StringVar(&config.ServerTlsKey, "tls-key", defaults.ServerTlsKey, "a file, inline PEM, or env:VARNAME containing the key for --tls-cert")
+	cmd.Flags().StringVar(&config.ServerTlsClientCA, "tls-client-ca", defaults.ServerTlsClientCA, "require clients to present a certificate signed by the CA in this file, inline PEM, or env:VARNAME")
+	cmd.Flags().StringVar(&config.ServerAuthToken, "auth-token", defaults.ServerAuthToken, "require clients to send this bearer token in the Authorization header")
+	cmd.Flags().StringVar(&config.ServerAuthBasic, "auth-basic", defaults.ServerAuthBasic, "require clients to send these user:password basic auth credentials")
+	cmd.Flags().StringVar(&config.ServerAllowIps, "allow-ips", defaults.ServerAllowIps, "only accept connections from these comma-separated IPs and CIDRs, e.g. 10.0.0.0/8,192.168.1.5")
+	cmd.Flags().StringArrayVar(&config.ServerKeep, "keep", defaults.ServerKeep, "only keep spans matching one of these rules of key=value and key~regex terms, e.g. 'status=error', repeatable; per span unless --keep-wait is set")
+	cmd.Flags().StringArrayVar(&config.ServerDrop, "drop", defaults.ServerDrop, "drop spans matching any of these rules of key=value and key~regex terms, e.g. 'name~^health', repeatable")
+	cmd.Flags().StringVar(&config.ServerSample, "sample", defaults.ServerSample, "keep this percentage of traces, e.g. 10%")
+	cmd.Flags().StringVar(&config.ServerKeepWait, "keep-wait", defaults.ServerKeepWait, "apply --keep to whole traces, holding spans up to this long, e.g. 30s, for one in their trace to match")
+	cmd.Flags().StringVar(&config.ServerMetricsEndpoint, "prometheus-endpoint", defaults.ServerMetricsEndpoint, "serve Prometheus metrics about received spans on this host:port at /metrics, e.g. localhost:9464")
+}
+
 // runServer runs the server on either grpc or http and blocks until the server
-// stops or is killed.
-func runServer(config Config, cb otlpserver.Callback, stop otlpserver.Stopper) {
+// stops or is killed. With --http-endpoint, an OTLP/HTTP server runs alongside
+// it and either one stopping stops both. Both listen with TLS when --tls-cert
+// is set, and both require --auth-token or --auth-basic credentials when
+// those are set. Spans that --keep, --drop, and --sample filter out never
+// reach cb. With --prometheus-endpoint, received
+// spans are counted and served at /metrics while the server runs. Logs and
+// metrics are accepted when signals has callbacks for them.
+func runServer(config Config, cb otlpserver.Callback, signals otlpserver.SignalCallbacks, stop otlpserver.Stopper) {
 	// unlike the rest of otel-cli, server should default to localhost:4317
 	if config.Endpoint == "" {
 		config.Endpoint = defaultOtlpEndpoint
 	}
 	endpointURL, _ := config.ParseEndpoint()
 
+	cb, stopMetrics := startServerMetrics(config, applyServerRules(config, cb))
+	defer stopMetrics()
+
 	var cs otlpserver.OtlpServer
 	if config.Protocol != "grpc" &&
 		(strings.HasPrefix(config.Protocol, "http/") ||
-			endpointURL.Scheme == "http") {
+			endpointURL.Scheme == "http" || endpointURL.Scheme == "https") {
 		cs = otlpserver.NewServer("http", cb, stop)
-	} else if config.Protocol == "https" || endpointURL.Scheme == "https" {
-		config.SoftFail("https server is not supported yet, please raise an issue")
 	} else {
 		cs = otlpserver.NewServer("grpc", cb, stop)
 	}
+	if endpointURL.Scheme == "https" && config.ServerTlsCert == "" {
+		config.SoftFail("an https endpoint requires --tls-cert and --tls-key")
+	}
+	cs.SetSignalCallbacks(signals)
+	cs.SetAuthenticator(serverAuthenticator(config))
+
+	listener, err := listenServer(config, endpointURL.Host)
+	if err != nil {
+		log.Fatalf("failed to listen on OTLP endpoint %q: %s", endpointURL.Host, err)
+	}
+
+	if hs := startServerHttp(config, cb, signals, stop, cs.Stop); hs != nil {
+		defer hs.Stop()
+	}
 
 	defer cs.Stop()
-	cs.ListenAndServe(endpointURL.Host)
+	cs.Serve(listener)
+}
+
+// listenServer listens on addr, with TLS when --tls-cert is set, only
+// accepting connections from --allow-ips when it's set.
+func listenServer(config Config, addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ServerAllowIps != "" {
+		allowed, err := parseAllowedIps(config.ServerAllowIps)
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("invalid --allow-ips: %w", err)
+		}
+		listener = &allowListener{Listener: listener, allowed: allowed, config: config}
+	}
+
+	if tlsConfig := config.GetServerTlsConfig(); tlsConfig != nil {
+		return tls.NewListener(listener, tlsConfig), nil
+	}
+	return listener, nil
+}
+
+// startServerHttp starts serving OTLP/HTTP on --http-endpoint in the
+// background, calling done when it stops. Returns nil when --http-endpoint
+// isn't set.
+func startServerHttp(config Config, cb otlpserver.Callback, signals otlpserver.SignalCallbacks, stop otlpserver.Stopper, done func()) otlpserver.OtlpServer {
+	if config.ServerHttpEndpoint == "" {
+		return nil
+	}
+
+	addr := strings.TrimPrefix(strings.TrimPrefix(config.ServerHttpEndpoint, "http://"), "https://")
+	listener, err := listenServer(config, addr)
+	if err != nil {
+		config.SoftFail("failed to listen on OTLP/HTTP endpoint %q: %s", addr, err)
+	}
+
+	hs := otlpserver.NewServer("http", cb, stop)
+	hs.SetSignalCallbacks(signals)
+	hs.SetAuthenticator(serverAuthenticator(config))
+	go func() {
+		hs.Serve(listener)
+		done()
+	}()
+
+	return hs
+}
+
+// spanResourceSpans returns a ResourceSpans holding just the span, with the
+// resource and scope it was received with.
+func spanResourceSpans(span *tracepb.Span, rss *tracepb.ResourceSpans) *tracepb.ResourceSpans {
+	ss := &tracepb.ScopeSpans{Spans: []*tracepb.Span{span}}
+	for _, s := range rss.GetScopeSpans() {
+		for _, sp := range s.GetSpans() {
+			if sp == span {
+				ss.Scope = s.Scope
+				ss.SchemaUrl = s.SchemaUrl
+			}
+		}
+	}
+
+	return &tracepb.ResourceSpans{
+		Resource:   rss.GetResource(),
+		ScopeSpans: []*tracepb.ScopeSpans{ss},
+		SchemaUrl:  rss.GetSchemaUrl(),
+	}
+}
+
+// logResourceLogs returns a ResourceLogs holding just the log record, with
+// the resource and scope it was received with.
+func logResourceLogs(record *logspb.LogRecord, rl *logspb.ResourceLogs) *logspb.ResourceLogs {
+	sl := &logspb.ScopeLogs{LogRecords: []*logspb.LogRecord{record}}
+	for _, s := range rl.GetScopeLogs() {
+		for _, lr := range s.GetLogRecords() {
+			if lr == record {
+				sl.Scope = s.Scope
+				sl.SchemaUrl = s.SchemaUrl
+			}
+		}
+	}
+
+	return &logspb.ResourceLogs{
+		Resource:  rl.GetResource(),
+		ScopeLogs: []*logspb.ScopeLogs{sl},
+		SchemaUrl: rl.GetSchemaUrl(),
+	}
+}
+
+// metricResourceMetrics returns a ResourceMetrics holding just the metric,
+// with the resource and scope it was received with.
+func metricResourceMetrics(metric *metricspb.Metric, rm *metricspb.ResourceMetrics) *metricspb.ResourceMetrics {
+	sm := &metricspb.ScopeMetrics{Metrics: []*metricspb.Metric{metric}}
+	for _, s := range rm.GetScopeMetrics() {
+		for _, m := range s.GetMetrics() {
+			if m == metric {
+				sm.Scope = s.Scope
+				sm.SchemaUrl = s.SchemaUrl
+			}
+		}
+	}
+
+	return &metricspb.ResourceMetrics{
+		Resource:     rm.GetResource(),
+		ScopeMetrics: []*metricspb.ScopeMetrics{sm},
+		SchemaUrl:    rm.GetSchemaUrl(),
+	}
+}
+
+// resourceServiceName returns the resource's service.name.
+func resourceServiceName(resource *resourcepb.Resource) string {
+	for _, attr := range resource.GetAttributes() {
+		if attr.Key == "service.name" {
+			return anyValueString(attr.Value)
+		}
+	}
+	return ""
+}
+
+// anyValueString returns a log body or attribute value as text, with arrays,
+// maps, and bytes as OTLP/JSON.
+func anyValueString(v *commonpb.AnyValue) string {
+	switch val := v.GetValue().(type) {
+	case nil:
+		return ""
+	case *commonpb.AnyValue_StringValue:
+		return val.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(val.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(val.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(val.DoubleValue, 'f', -1, 64)
+	default:
+		js, _ := otlpclient.MarshalOtlpJson(v)
+		return string(js)
+	}
+}
+
+// metricSummary returns the metric's type and a summary of its newest data
+// point, e.g. the value of a gauge or the count and sum of a histogram.
+func metricSummary(metric *metricspb.Metric) (string, string) {
+	numberValue := func(dps []*metricspb.NumberDataPoint) string {
+		if len(dps) == 0 {
+			return ""
+		}
+		switch val := dps[len(dps)-1].GetValue().(type) {
+		case *metricspb.NumberDataPoint_AsInt:
+			return strconv.FormatInt(val.AsInt, 10)
+		case *metricspb.NumberDataPoint_AsDouble:
+			return strconv.FormatFloat(val.AsDouble, 'f', -1, 64)
+		}
+		return ""
+	}
+	countSum := func(count uint64, sum float64) string {
+		return fmt.Sprintf("count=%d sum=%s", count, strconv.FormatFloat(sum, 'f', -1, 64))
+	}
+
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		return "gauge", numberValue(data.Gauge.GetDataPoints())
+	case *metricspb.Metric_Sum:
+		return "sum", numberValue(data.Sum.GetDataPoints())
+	case *metricspb.Metric_Histogram:
+		if dps := data.Histogram.GetDataPoints(); len(dps) > 0 {
+			return "histogram", countSum(dps[len(dps)-1].GetCount(), dps[len(dps)-1].GetSum())
+		}
+		return "histogram", ""
+	case *metricspb.Metric_ExponentialHistogram:
+		if dps := data.ExponentialHistogram.GetDataPoints(); len(dps) > 0 {
+			return "exponential_histogram", countSum(dps[len(dps)-1].GetCount(), dps[len(dps)-1].GetSum())
+		}
+		return "exponential_histogram", ""
+	case *metricspb.Metric_Summary:
+		if dps := data.Summary.GetDataPoints(); len(dps) > 0 {
+			return "summary", countSum(dps[len(dps)-1].GetCount(), dps[len(dps)-1].GetSum())
+		}
+		return "summary", ""
+	}
+
+	return "unknown", ""
 }
@@ -0,0 +1,231 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// assertSvr holds the command-line configured settings and the spans seen by
+// otel-cli server assert. seen and matched are guarded by mu.
+var assertSvr struct {
+	match   string
+	count   int
+	matcher spanMatcher
+
+	mu      sync.Mutex
+	seen    []assertSpan
+	matched int
+}
+
+// assertSpan is a received span as it's printed in the report.
+type assertSpan struct {
+	service string
+	span    *tracepb.Span
+	matched bool
+}
+
+// spanMatcher is a parsed --match expression: key=value terms that all have
+// to match a span.
+type spanMatcher map[string]string
+
+func serverAssertCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "assert",
+		Short: "wait for matching spans and exit non-zero if they don't arrive",
+		Long: `Run otel-cli as an OTLP server until --count spans matching --match arrive
+or --timeout expires, then print every span that was received and exit 0 if
+enough matched or 1 if they didn't. Start it in the background before running
+the code under test to check its instrumentation in CI.
+
+--match is a space-separated list of key=value terms that all have to match.
+name, kind, status (ok, error, or unset), trace_id, span_id, and
+parent_span_id match those fields of the span. Any other key matches a span
+attribute, or a resource attribute such as service.name when the span doesn't
+have that attribute. An empty --match matches every span.
+
+Example:
+	otel-cli server assert --timeout 30s --match 'name=checkout service.name=api' --count 2 &
+	OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4317 ./integration-tests.sh
+	wait $!
+`,
+		Run: doServerAssert,
+	}
+
+	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
+	cmd.Flags().StringVar(&assertSvr.match, "match", "", "key=value terms a span must match, see above for the syntax")
+	cmd.Flags().IntVar(&assertSvr.count, "count", 1, "how many matching spans to wait for")
+
+	return &cmd
+}
+
+func doServerAssert(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	// assert is for tests, so bad settings have to fail even without --fail
+	matcher, err := parseSpanMatcher(assertSvr.match)
+	if err != nil {
+		log.Fatalf("invalid --match: %s", err)
+	}
+	if assertSvr.count < 1 {
+		log.Fatalf("--count must be at least 1")
+	}
+	assertSvr.matcher = matcher
+	timeout := config.ParseCliTimeout()
+
+	done := make(chan struct{})
+	go func() {
+		runServer(config, assertCallback, otlpserver.SignalCallbacks{}, func(otlpserver.OtlpServer) {})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+
+	assertSvr.mu.Lock()
+	defer assertSvr.mu.Unlock()
+	ok := writeAssertReport(os.Stdout)
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// assertCallback records every span and stops the server once enough of them
+// have matched.
+func assertCallback(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+	matched := assertSvr.matcher.match(span, rss)
+
+	assertSvr.mu.Lock()
+	defer assertSvr.mu.Unlock()
+
+	assertSvr.seen = append(assertSvr.seen, assertSpan{
+		service: otlpclient.ResourceAttributesToStringMap(rss)["service.name"],
+		span:    span,
+		matched: matched,
+	})
+	if matched {
+		assertSvr.matched++
+	}
+
+	return assertSvr.matched >= assertSvr.count
+}
+
+// writeAssertReport writes a summary line and every span received, marking
+// the ones that matched. Returns true if enough spans matched. Must be called
+// with assertSvr.mu held.
+func writeAssertReport(w io.Writer) bool {
+	ok := assertSvr.matched >= assertSvr.count
+	result := "PASS"
+	if !ok {
+		result = "FAIL"
+	}
+	fmt.Fprintf(w, "%s: %d of %d spans matching %q, %d spans received\n",
+		result, assertSvr.matched, assertSvr.count, assertSvr.match, len(assertSvr.seen))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range assertSvr.seen {
+		mark := ""
+		if s.matched {
+			mark = "MATCH"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", mark, s.service, s.span.Name,
+			hex.EncodeToString(s.span.TraceId), hex.EncodeToString(s.span.SpanId),
+			spanStatusString(s.span))
+	}
+	tw.Flush()
+
+	return ok
+}
+
+// parseSpanMatcher parses a --match expression, checking that status terms
+// have a valid status.
+func parseSpanMatcher(expr string) (spanMatcher, error) {
+	matcher := spanMatcher{}
+	for _, term := range strings.Fields(expr) {
+		key, value, ok := strings.Cut(term, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("term %q must be in key=value format", term)
+		}
+		if key == "status" && value != "ok" && value != "error" && value != "unset" {
+			return nil, fmt.Errorf("invalid status %q, must be ok, error, or unset", value)
+		}
+		matcher[key] = value
+	}
+
+	return matcher, nil
+}
+
+// match returns true if the span matches every term.
+func (m spanMatcher) match(span *tracepb.Span, rss *tracepb.ResourceSpans) bool {
+	for key, want := range m {
+		if value, ok := spanFieldValue(span, rss, key); !ok || value != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+// spanFieldValue returns the value of a --match key for the span: name,
+// kind, status, trace_id, span_id, parent_span_id, or else an attribute of
+// the span or, failing that, of its resource. Returns false when the span
+// has no such attribute.
+func spanFieldValue(span *tracepb.Span, rss *tracepb.ResourceSpans, key string) (string, bool) {
+	switch key {
+	case "name":
+		return span.Name, true
+	case "kind":
+		return otlpclient.SpanKindIntToString(span.GetKind()), true
+	case "status":
+		return spanStatusString(span), true
+	case "trace_id":
+		return hex.EncodeToString(span.TraceId), true
+	case "span_id":
+		return hex.EncodeToString(span.SpanId), true
+	case "parent_span_id":
+		return hex.EncodeToString(span.ParentSpanId), true
+	}
+
+	for _, attrs := range [][]*commonpb.KeyValue{span.Attributes, rss.GetResource().GetAttributes()} {
+		for _, attr := range attrs {
+			if attr.Key != key {
+				continue
+			}
+			if b, ok := attr.GetValue().GetValue().(*commonpb.AnyValue_BoolValue); ok {
+				return strconv.FormatBool(b.BoolValue), true
+			}
+			return otlpclient.AttrValueToString(attr), true
+		}
+	}
+
+	return "", false
+}
+
+// spanStatusString returns the span's status as ok, error, or unset.
+func spanStatusString(span *tracepb.Span) string {
+	switch span.GetStatus().GetCode() {
+	case tracepb.Status_STATUS_CODE_OK:
+		return "ok"
+	case tracepb.Status_STATUS_CODE_ERROR:
+		return "error"
+	default:
+		return "unset"
+	}
+}
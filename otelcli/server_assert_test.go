@@ -0,0 +1,106 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestSpanMatcher(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.Name = "checkout"
+	span.Kind = tracepb.Span_SPAN_KIND_SERVER
+	span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{
+		"http.method": "POST",
+		"retry":       "true",
+		"env":         "span",
+	})
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{
+				"service.name": "api",
+				"env":          "resource",
+			}),
+		},
+	}
+
+	for _, tc := range []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"name=checkout", true},
+		{"name=check", false},
+		{"name=checkout service.name=api", true},
+		{"name=checkout service.name=web", false},
+		{"kind=server status=error", true},
+		{"status=ok", false},
+		{"http.method=POST retry=true", true},
+		{"http.method=GET", false},
+		{"missing=attr", false},
+		// span attributes take precedence over the resource's
+		{"env=span", true},
+		{"env=resource", false},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			matcher, err := parseSpanMatcher(tc.expr)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %s", tc.expr, err)
+			}
+			if got := matcher.match(span, rss); got != tc.want {
+				t.Errorf("expected %q to match %t but got %t", tc.expr, tc.want, got)
+			}
+		})
+	}
+
+	for _, expr := range []string{"checkout", "=api", "status=failed"} {
+		if _, err := parseSpanMatcher(expr); err == nil {
+			t.Errorf("expected an error parsing %q", expr)
+		}
+	}
+}
+
+func TestAssertCallback(t *testing.T) {
+	matcher, err := parseSpanMatcher("name=wanted")
+	if err != nil {
+		t.Fatalf("failed to parse matcher: %s", err)
+	}
+	assertSvr.match = "name=wanted"
+	assertSvr.count = 2
+	assertSvr.matcher = matcher
+	assertSvr.seen = nil
+	assertSvr.matched = 0
+
+	for i, name := range []string{"wanted", "other", "wanted"} {
+		span := otlpclient.NewProtobufSpan()
+		span.Name = name
+		done := assertCallback(context.Background(), span, nil, &tracepb.ResourceSpans{}, nil, nil)
+		if done != (i == 2) {
+			t.Errorf("expected the server to stop only after the second match, got %t for span %d", done, i)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if !writeAssertReport(&buf) {
+		t.Error("expected the assertion to pass")
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, `PASS: 2 of 2 spans matching "name=wanted", 3 spans received`) {
+		t.Errorf("unexpected report summary: %q", out)
+	}
+	if strings.Count(out, "MATCH") != 2 || !strings.Contains(out, "other") {
+		t.Errorf("expected the report to list every span and mark the matches: %q", out)
+	}
+
+	assertSvr.count = 3
+	buf.Reset()
+	if writeAssertReport(&buf) || !strings.HasPrefix(buf.String(), "FAIL: 2 of 3") {
+		t.Errorf("expected the assertion to fail, got %q", buf.String())
+	}
+}
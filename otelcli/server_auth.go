@@ -0,0 +1,139 @@
+package otelcli
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpserver"
+)
+
+// serverAuthenticator returns an authenticator that accepts requests with
+// the --auth-token bearer token or the --auth-basic user:password
+// credentials, either one when both are set. Returns nil when neither is set
+// so every request is accepted.
+func serverAuthenticator(config Config) otlpserver.Authenticator {
+	if config.ServerAuthToken == "" && config.ServerAuthBasic == "" {
+		return nil
+	}
+	if config.ServerAuthBasic != "" && !strings.Contains(config.ServerAuthBasic, ":") {
+		config.SoftFail("--auth-basic must be in user:password form")
+	}
+
+	accepted := []string{}
+	if config.ServerAuthToken != "" {
+		accepted = append(accepted, "Bearer "+config.ServerAuthToken)
+	}
+	if config.ServerAuthBasic != "" {
+		accepted = append(accepted, "Basic "+base64.StdEncoding.EncodeToString([]byte(config.ServerAuthBasic)))
+	}
+
+	return func(authorization string) bool {
+		ok := false
+		for _, want := range accepted {
+			// check them all in constant time so timing doesn't leak which
+			// one came close
+			if subtle.ConstantTimeCompare([]byte(authorization), []byte(want)) == 1 {
+				ok = true
+			}
+		}
+		return ok
+	}
+}
+
+// serverAuthHandler wraps handlers that aren't OTLP, like the web UI and
+// /metrics, with the same --auth-token and --auth-basic checks as OTLP
+// requests. Rejected requests get a 401, which asks browsers for a password
+// when --auth-basic is set. Returns next as-is when neither is set.
+func serverAuthHandler(config Config, next http.Handler) http.Handler {
+	auth := serverAuthenticator(config)
+	if auth == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !auth(req.Header.Get("Authorization")) {
+			if config.ServerAuthBasic != "" {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="otel-cli"`)
+			}
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// parseAllowedIps parses a comma-separated list of IPs and CIDRs, e.g.
+// 10.0.0.0/8,192.168.1.5, into networks. A bare IP is a network of one.
+func parseAllowedIps(in string) ([]*net.IPNet, error) {
+	out := []*net.IPNet{}
+	for _, entry := range strings.Split(in, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			out = append(out, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+		}
+		out = append(out, ipnet)
+	}
+
+	return out, nil
+}
+
+// allowListener is a net.Listener that closes connections from addresses
+// outside of --allow-ips as soon as they're accepted, before any TLS
+// handshake or request.
+type allowListener struct {
+	net.Listener
+	allowed []*net.IPNet
+	config  Config
+}
+
+// Accept returns the next connection from an allowed address.
+func (al *allowListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := al.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if al.allows(conn.RemoteAddr()) {
+			return conn, nil
+		}
+		al.config.SoftLog("rejected connection from %s, which isn't in --allow-ips", conn.RemoteAddr())
+		conn.Close()
+	}
+}
+
+// allows returns true if the address is in one of the allowed networks.
+func (al *allowListener) allows(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, ipnet := range al.allowed {
+		if ipnet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerAuthenticator(t *testing.T) {
+	if serverAuthenticator(DefaultConfig()) != nil {
+		t.Error("expected no authenticator without --auth-token or --auth-basic")
+	}
+
+	auth := serverAuthenticator(DefaultConfig().WithServerAuthToken("s3cret").WithServerAuthBasic("dev:pa55"))
+	for authorization, want := range map[string]bool{
+		"Bearer s3cret":        true,
+		"Basic ZGV2OnBhNTU=":   true, // dev:pa55
+		"":                     false,
+		"Bearer wrong":         false,
+		"s3cret":               false,
+		"Basic ZGV2Ondyb25n":   false, // dev:wrong
+		"Bearer s3cret-suffix": false,
+	} {
+		if got := auth(authorization); got != want {
+			t.Errorf("expected %q to be accepted=%t but got %t", authorization, want, got)
+		}
+	}
+}
+
+func TestServerAuthGrpc(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	received := 0
+	server := otlpserver.NewGrpcServer(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		received++
+		return false
+	}, func(otlpserver.OtlpServer) {})
+	server.SetAuthenticator(serverAuthenticator(DefaultConfig().WithServerAuthToken("s3cret")))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer conn.Close()
+	client := coltracepb.NewTraceServiceClient(conn)
+	req := &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{otlpclient.NewProtobufSpan()}}},
+	}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.Export(ctx, req)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected a request without a token to be unauthenticated but got %v", err)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+	if _, err := client.Export(ctx, req); err != nil {
+		t.Errorf("expected a request with the token to be accepted but got %s", err)
+	}
+	if received != 1 {
+		t.Errorf("expected only the authenticated span to be received but got %d", received)
+	}
+}
+
+func TestServerAuthHttp(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	server := otlpserver.NewHttpServer(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		return false
+	}, func(otlpserver.OtlpServer) {})
+	server.SetAuthenticator(serverAuthenticator(DefaultConfig().WithServerAuthBasic("dev:pa55")))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	send := func(user, password string) int {
+		req, _ := http.NewRequest("POST", "http://"+listener.Addr().String()+"/v1/traces", bytes.NewReader([]byte("{}")))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to post: %s", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := send("", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected a 401 without credentials but got %d", code)
+	}
+	if code := send("dev", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected a 401 with the wrong password but got %d", code)
+	}
+	if code := send("dev", "pa55"); code != http.StatusOK {
+		t.Errorf("expected a 200 with the right credentials but got %d", code)
+	}
+}
+
+func TestServerAuthHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	if serverAuthHandler(DefaultConfig(), ok) == nil {
+		t.Fatal("expected the handler to be returned without auth")
+	}
+
+	server := httptest.NewServer(serverAuthHandler(DefaultConfig().WithServerAuthBasic("dev:pa55").WithServerAuthToken("t0ken"), ok))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		auth func(*http.Request)
+		want int
+	}{
+		{auth: func(*http.Request) {}, want: http.StatusUnauthorized},
+		{auth: func(req *http.Request) { req.SetBasicAuth("dev", "wrong") }, want: http.StatusUnauthorized},
+		{auth: func(req *http.Request) { req.SetBasicAuth("dev", "pa55") }, want: http.StatusOK},
+		{auth: func(req *http.Request) { req.Header.Set("Authorization", "Bearer t0ken") }, want: http.StatusOK},
+	} {
+		req, _ := http.NewRequest("GET", server.URL+"/api/traces", nil)
+		tc.auth(req)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to get: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("expected %d but got %d", tc.want, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Error("expected a WWW-Authenticate header so browsers ask for a password")
+		}
+	}
+}
+
+func TestParseAllowedIps(t *testing.T) {
+	allowed, err := parseAllowedIps("10.0.0.0/8, 192.168.1.5,::1")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	al := allowListener{allowed: allowed}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.1.5": true,
+		"192.168.1.6": false,
+		"::1":         true,
+		"127.0.0.1":   false,
+	} {
+		if got := al.allows(&net.TCPAddr{IP: net.ParseIP(ip)}); got != want {
+			t.Errorf("expected %s to be allowed=%t but got %t", ip, want, got)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := parseAllowedIps(bad); err == nil {
+			t.Errorf("expected %q to fail to parse", bad)
+		}
+	}
+}
+
+func TestAllowListener(t *testing.T) {
+	for _, tc := range []struct {
+		allowIps string
+		accepted bool
+	}{
+		{"127.0.0.0/8", true},
+		{"10.0.0.0/8", false},
+	} {
+		listener, err := listenServer(DefaultConfig().WithServerAllowIps(tc.allowIps), "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %s", err)
+		}
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				conn.Write([]byte("hi"))
+				conn.Close()
+			}
+		}()
+
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to connect: %s", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _ := conn.Read(make([]byte, 2))
+		if accepted := n == 2; accepted != tc.accepted {
+			t.Errorf("expected a connection with --allow-ips %s to be accepted=%t but got %t", tc.allowIps, tc.accepted, accepted)
+		}
+		conn.Close()
+		listener.Close()
+	}
+}
@@ -0,0 +1,150 @@
+package otelcli
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func serverForwardCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "forward",
+		Short: "receive spans and forward them to another endpoint",
+		Long: `Receive OTLP spans and re-export them in batches to --endpoint, with the
+same TLS, header, retry, and spooling settings as any other otel-cli command.
+Attributes set with --attrs are added to the resource of every span, replacing
+any the sender set with the same key, so e.g. CI jobs can tag everything their
+tools send without running a collector.
+
+The server listens for OTLP/gRPC on --listen, and on --http-endpoint for
+OTLP/HTTP when it's set. It flushes on an interval, when a batch fills up, and
+on SIGINT or SIGTERM before exiting. Like the agent, it drops spans over
+--max-rate or while --max-queue are waiting.
+
+Example:
+	otel-cli server forward --endpoint https://otlp.example.com --attrs env=ci,ci.job=$CI_JOB_ID &
+	OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4317 make test
+`,
+		Run: doServerForward,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&config.ServerForwardListen, "listen", defaults.ServerForwardListen, "accept OTLP/gRPC on this host:port, defaults to localhost:4317")
+	cmd.Flags().StringVar(&config.AgentFlushInterval, "flush-interval", defaults.AgentFlushInterval, "how often to export buffered spans")
+	cmd.Flags().IntVar(&config.AgentBatchSize, "batch-size", defaults.AgentBatchSize, "export as soon as this many spans are buffered")
+	cmd.Flags().IntVar(&config.IngestMaxRate, "max-rate", defaults.IngestMaxRate, "drop spans received beyond this many a second, 0 for no limit")
+	cmd.Flags().IntVar(&config.IngestMaxQueue, "max-queue", defaults.IngestMaxQueue, "drop spans received while this many are waiting to be exported, 0 for no limit")
+
+	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doServerForward(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx).WithAgentSocket("")
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to forward spans to")
+	}
+
+	interval, err := parseDuration(config.AgentFlushInterval)
+	config.SoftFailIfErr(err)
+	if interval <= 0 {
+		config.SoftFail("--flush-interval must be greater than zero")
+	}
+
+	addr := strings.TrimPrefix(config.ServerForwardListen, "grpc://")
+	if addr == "" {
+		addr = strings.TrimPrefix(defaultOtlpEndpoint, "grpc://")
+	}
+	listener, err := listenServer(config, addr)
+	if err != nil {
+		config.SoftFail("failed to listen on OTLP/gRPC endpoint %q: %s", addr, err)
+	}
+
+	ctx, client := StartClient(ctx, config)
+	forwarder := newAgentServer(config, client)
+	forwarder.resourceAttrs = sortedAttrs(config.Attributes)
+
+	// either server stopping, e.g. on a listener error, stops the forwarder
+	done := make(chan struct{})
+	var once sync.Once
+	stopped := func() { once.Do(func() { close(done) }) }
+
+	cb, stopMetrics := startServerMetrics(config, applyServerRules(config, forwarder.callback))
+	defer stopMetrics()
+
+	stop := func(otlpserver.OtlpServer) {}
+	gs := otlpserver.NewServer("grpc", cb, stop)
+	gs.SetAuthenticator(serverAuthenticator(config))
+	go func() {
+		gs.Serve(listener)
+		stopped()
+	}()
+	servers := []otlpserver.OtlpServer{gs}
+	if hs := startServerHttp(config, cb, otlpserver.SignalCallbacks{}, stop, stopped); hs != nil {
+		servers = append(servers, hs)
+	}
+	config.SoftLog("forwarding spans received on %s", addr)
+
+	if sig := forwarder.run(ctx, interval, done); sig != nil {
+		config.SoftLog("forwarder shutting down on %s", sig)
+	}
+
+	// stop accepting spans, then send everything that's left
+	for _, server := range servers {
+		server.StopWait()
+	}
+	forwarder.flush(ctx)
+	forwarder.limiter.logDrops(config, "spans")
+
+	_, err = client.Stop(ctx)
+	config.SoftLogIfErr(err)
+}
+
+// sortedAttrs returns the attributes as protobuf, sorted by key so the
+// forwarded resources come out the same every time.
+func sortedAttrs(attrs map[string]string) []*commonpb.KeyValue {
+	out := otlpclient.StringMapAttrsToProtobuf(attrs)
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// mergeResourceAttrs returns a copy of the resource with the attributes
+// added, replacing any existing attributes with the same keys. The original
+// is left alone since it's shared by every span in the request.
+func mergeResourceAttrs(resource *resourcepb.Resource, attrs []*commonpb.KeyValue) *resourcepb.Resource {
+	out := &resourcepb.Resource{}
+	if resource != nil {
+		out = proto.Clone(resource).(*resourcepb.Resource)
+	}
+
+	for _, attr := range attrs {
+		replaced := false
+		for i, existing := range out.Attributes {
+			if existing.Key == attr.Key {
+				out.Attributes[i] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out.Attributes = append(out.Attributes, attr)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,65 @@
+package otelcli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/google/go-cmp/cmp"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestServerForwardResourceAttrs(t *testing.T) {
+	upstream := &agentTestClient{}
+	forwarder := newAgentServer(DefaultConfig(), upstream)
+	forwarder.resourceAttrs = sortedAttrs(map[string]string{
+		"service.name": "ci",
+		"ci.job":       "1234",
+	})
+
+	span := otlpclient.NewProtobufSpan()
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{
+				"service.name": "make",
+				"host.name":    "runner-7",
+			}),
+		},
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+	}
+
+	forwarder.callback(context.Background(), span, nil, rss, nil, nil)
+	forwarder.callback(context.Background(), span, nil, &tracepb.ResourceSpans{}, nil, nil)
+	forwarder.flush(context.Background())
+
+	if len(upstream.uploads) != 1 || len(upstream.uploads[0]) != 2 {
+		t.Fatalf("expected one upload of 2 spans but got %v", upstream.uploads)
+	}
+
+	want := map[string]string{
+		"service.name": "ci",
+		"ci.job":       "1234",
+		"host.name":    "runner-7",
+	}
+	got := otlpclient.ResourceAttributesToStringMap(upstream.uploads[0][0])
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("forwarded resource attributes did not match (-want +got):\n%s", diff)
+	}
+
+	// spans that came in without a resource still get the attributes
+	want = map[string]string{
+		"service.name": "ci",
+		"ci.job":       "1234",
+	}
+	got = otlpclient.ResourceAttributesToStringMap(upstream.uploads[0][1])
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("forwarded resource attributes without a resource did not match (-want +got):\n%s", diff)
+	}
+
+	// the received resource is shared by every span in a request, so it must
+	// not be modified
+	if got := otlpclient.ResourceAttributesToStringMap(rss)["service.name"]; got != "make" {
+		t.Errorf("expected the received resource to be unchanged but service.name is %q", got)
+	}
+}
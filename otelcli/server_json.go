@@ -4,43 +4,131 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/equinix-labs/otel-cli/otlpclient"
 	"github.com/equinix-labs/otel-cli/otlpserver"
 	"github.com/spf13/cobra"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
 )
 
 // jsonSvr holds the command-line configured settings for otel-cli server json
 var jsonSvr struct {
 	outDir    string
 	stdout    bool
+	ndjson    string
+	ndjsonOut io.Writer
+	output    string
+	outputOut io.Writer
 	maxSpans  int
 	spansSeen int
+
+	retainSpans int
+	maxAge      string
+	mu          sync.Mutex // guards retention and the log and metric counts
+	retention   *serverRetention
+	logsSeen    int
+	metricsSeen int
 }
 
 func serverJsonCmd(config *Config) *cobra.Command {
 	cmd := cobra.Command{
 		Use:   "json",
-		Short: "write spans to json or stdout",
-		Long:  "",
-		Run:   doServerJson,
+		Short: "write spans, logs, and metrics to json or stdout",
+		Long: `Write each received span to json files in --dir, and/or to stdout with
+--stdout. Log records and metrics are written the same way, to numbered files
+in the logs and metrics directories under --dir.
+
+With --ndjson, each span, log record, and metric is written as a single line of OTLP/JSON as soon as it
+arrives, including its resource and scope, so the output can be piped to jq for
+live analysis or checked in tests. Use - for stdout.
+
+To run for days without filling the disk, --retain-spans keeps at most that
+many spans in --dir, deleting the oldest traces first, and --max-age deletes
+traces that haven't had a new span in that long.
+
+--output csv writes everything to signals.csv in --dir and/or to stdout as
+flat rows for spreadsheets and pandas, with spans, log records, and metrics
+told apart by the signal column. --output otlp-json-file writes otlp.json
+with one OTLP/JSON export request per line, the framing the collector's file
+exporter uses, so captures can be read by the otlpjsonfile receiver.
+
+Example:
+	otel-cli server json --ndjson - | jq -c '.resourceSpans[].scopeSpans[].spans[] | {name, traceId}'
+	otel-cli server json --ndjson spans.ndjson --max-spans 3 --timeout 60
+	otel-cli server json --dir /var/tmp/spans --retain-spans 100000 --max-age 24h
+	otel-cli server json --output csv --stdout > signals.csv
+`,
+		Run: doServerJson,
 	}
 
 	addCommonParams(&cmd, config)
-	cmd.Flags().StringVar(&jsonSvr.outDir, "dir", "", "write spans to json in the specified directory")
-	cmd.Flags().BoolVar(&jsonSvr.stdout, "stdout", false, "write span jsons to stdout")
+	addServerParams(&cmd, config)
+	cmd.Flags().StringVar(&jsonSvr.outDir, "dir", "", "write spans, logs, and metrics to json in the specified directory")
+	cmd.Flags().BoolVar(&jsonSvr.stdout, "stdout", false, "write span, log, and metric jsons to stdout")
+	cmd.Flags().StringVar(&jsonSvr.ndjson, "ndjson", "", "append each span, log record, and metric as a line of OTLP/JSON to this file as it arrives, - for stdout")
+	cmd.Flags().StringVar(&jsonSvr.output, "output", jsonOutputJson, "format for --dir and --stdout: json, csv, or otlp-json-file")
 	cmd.Flags().IntVar(&jsonSvr.maxSpans, "max-spans", 0, "exit the server after this many spans come in")
+	cmd.Flags().IntVar(&jsonSvr.retainSpans, "retain-spans", 0, "keep at most this many spans in --dir, deleting the oldest traces first")
+	cmd.Flags().StringVar(&jsonSvr.maxAge, "max-age", "", "delete traces from --dir that haven't had a new span in this long, e.g. 24h")
 
 	return &cmd
 }
 
 func doServerJson(cmd *cobra.Command, args []string) {
 	config := getConfig(cmd.Context())
+
+	if jsonSvr.ndjson == "-" {
+		if jsonSvr.stdout {
+			config.SoftFail("--stdout can't be used with --ndjson -")
+		}
+		jsonSvr.ndjsonOut = os.Stdout
+	} else if jsonSvr.ndjson != "" {
+		file, err := os.OpenFile(jsonSvr.ndjson, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			config.SoftFail("could not open --ndjson file %q: %s", jsonSvr.ndjson, err)
+		}
+		defer file.Close()
+		jsonSvr.ndjsonOut = file
+	}
+
+	maxAge, err := parseDuration(jsonSvr.maxAge)
+	if err != nil {
+		config.SoftFail("invalid --max-age: %s", err)
+	}
+
+	switch jsonSvr.output {
+	case jsonOutputJson:
+	case jsonOutputCsv, jsonOutputOtlpFile:
+		if jsonSvr.retainSpans > 0 || maxAge > 0 {
+			config.SoftFail("--retain-spans and --max-age only work with --output json")
+		}
+		file, err := openJsonOutput()
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		if file != nil {
+			defer file.Close()
+		}
+	default:
+		config.SoftFail("invalid --output %q, must be one of json, csv, or otlp-json-file", jsonSvr.output)
+	}
+	if jsonSvr.outDir != "" && (jsonSvr.retainSpans > 0 || maxAge > 0) {
+		jsonSvr.retention = newServerRetention(jsonSvr.retainSpans, maxAge, nil)
+		if maxAge > 0 {
+			go expireJsonTraces(maxAge / 10)
+		}
+	}
+
 	stop := func(otlpserver.OtlpServer) {}
 	cs := otlpserver.NewGrpcServer(renderJson, stop)
 
@@ -53,14 +141,55 @@ func doServerJson(cmd *cobra.Command, args []string) {
 		}()
 	}
 
-	runServer(config, renderJson, stop)
+	signals := otlpserver.SignalCallbacks{Logs: renderJsonLog, Metrics: renderJsonMetric}
+	runServer(config, renderJson, signals, stop)
 }
 
-// writeFile takes the spans and events and writes them out to json files in the
-// tid/sid/span.json and tid/sid/events.json files.
+// renderJson writes the span to --ndjson and to --dir and/or stdout in the
+// --output format.
 func renderJson(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, ss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
 	jsonSvr.spansSeen++ // count spans for exiting on --max-spans
 
+	if jsonSvr.ndjsonOut != nil {
+		if err := writeNdjson(jsonSvr.ndjsonOut, span, ss); err != nil {
+			log.Fatalf("failed to write span as ndjson: %s", err)
+		}
+	}
+
+	switch jsonSvr.output {
+	case jsonOutputCsv:
+		if err := writeJsonOutput(csvLine(spanCsvRow(span, ss))); err != nil {
+			log.Fatalf("failed to write span as csv: %s", err)
+		}
+	case jsonOutputOtlpFile:
+		if err := writeNdjson(jsonSvr.outputOut, span, ss); err != nil {
+			log.Fatalf("failed to write span to --output: %s", err)
+		}
+	default:
+		writeJsonSpan(span, events)
+	}
+
+	if jsonSvr.retention != nil {
+		jsonSvr.mu.Lock()
+		evicted, err := jsonSvr.retention.add(span, ss, time.Now())
+		if err != nil {
+			log.Printf("failed to spill evicted spans: %s", err)
+		}
+		removeJsonTraces(evicted)
+		jsonSvr.mu.Unlock()
+	}
+
+	if jsonSvr.maxSpans > 0 && jsonSvr.spansSeen >= jsonSvr.maxSpans {
+		return true // will cause the server loop to exit
+	}
+
+	return false
+}
+
+// writeJsonSpan writes the span and its events to json files in the
+// tid/sid/span.json and tid/sid/event-N.json files under --dir and/or to
+// stdout.
+func writeJsonSpan(span *tracepb.Span, events []*tracepb.Span_Event) {
 	// TODO: check for existence of outdir and error when it doesn't exist
 	var outpath string
 	if jsonSvr.outDir != "" {
@@ -96,14 +225,119 @@ func renderJson(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_
 		filename := "event-" + strconv.Itoa(i) + ".json"
 		writeJson(outpath, filename, ejs)
 	}
+}
 
-	if jsonSvr.maxSpans > 0 && jsonSvr.spansSeen >= jsonSvr.maxSpans {
-		return true // will cause the server loop to exit
+// expireJsonTraces deletes traces past --max-age from --dir every interval.
+func expireJsonTraces(interval time.Duration) {
+	for now := range time.Tick(interval) {
+		jsonSvr.mu.Lock()
+		evicted, err := jsonSvr.retention.expire(now)
+		if err != nil {
+			log.Printf("failed to spill evicted spans: %s", err)
+		}
+		removeJsonTraces(evicted)
+		jsonSvr.mu.Unlock()
+	}
+}
+
+// removeJsonTraces deletes the directories of the evicted traces from --dir.
+func removeJsonTraces(evicted []string) {
+	for _, tid := range evicted {
+		path := filepath.Join(jsonSvr.outDir, tid)
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("failed to remove evicted trace %q: %s", path, err)
+		}
+	}
+}
+
+// renderJsonLog writes the log record to logs/log-N.json in --dir, to stdout
+// with --stdout, and as OTLP/JSON to --ndjson.
+func renderJsonLog(ctx context.Context, record *logspb.LogRecord, rl *logspb.ResourceLogs, headers map[string]string, meta map[string]string) bool {
+	jsonSvr.mu.Lock()
+	jsonSvr.logsSeen++
+	n := jsonSvr.logsSeen
+	jsonSvr.mu.Unlock()
+
+	if jsonSvr.ndjsonOut != nil {
+		data := &logspb.LogsData{ResourceLogs: []*logspb.ResourceLogs{logResourceLogs(record, rl)}}
+		if err := writeOtlpJsonLine(jsonSvr.ndjsonOut, data); err != nil {
+			log.Fatalf("failed to write log record as ndjson: %s", err)
+		}
 	}
 
+	switch jsonSvr.output {
+	case jsonOutputCsv:
+		if err := writeJsonOutput(csvLine(logCsvRow(record, rl))); err != nil {
+			log.Fatalf("failed to write log record as csv: %s", err)
+		}
+		return false
+	case jsonOutputOtlpFile:
+		data := &logspb.LogsData{ResourceLogs: []*logspb.ResourceLogs{logResourceLogs(record, rl)}}
+		if err := writeOtlpJsonLine(jsonSvr.outputOut, data); err != nil {
+			log.Fatalf("failed to write log record to --output: %s", err)
+		}
+		return false
+	}
+
+	js, err := json.Marshal(record)
+	if err != nil {
+		log.Fatalf("failed to marshal log record to json: %s", err)
+	}
+	writeJson(jsonSignalDir("logs"), "log-"+strconv.Itoa(n)+".json", js)
+
 	return false
 }
 
+// renderJsonMetric writes the metric to metrics/metric-N.json in --dir, to
+// stdout with --stdout, and as OTLP/JSON to --ndjson.
+func renderJsonMetric(ctx context.Context, metric *metricspb.Metric, rm *metricspb.ResourceMetrics, headers map[string]string, meta map[string]string) bool {
+	jsonSvr.mu.Lock()
+	jsonSvr.metricsSeen++
+	n := jsonSvr.metricsSeen
+	jsonSvr.mu.Unlock()
+
+	if jsonSvr.ndjsonOut != nil {
+		data := &metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{metricResourceMetrics(metric, rm)}}
+		if err := writeOtlpJsonLine(jsonSvr.ndjsonOut, data); err != nil {
+			log.Fatalf("failed to write metric as ndjson: %s", err)
+		}
+	}
+
+	switch jsonSvr.output {
+	case jsonOutputCsv:
+		if err := writeJsonOutput(csvLine(metricCsvRow(metric, rm))); err != nil {
+			log.Fatalf("failed to write metric as csv: %s", err)
+		}
+		return false
+	case jsonOutputOtlpFile:
+		data := &metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{metricResourceMetrics(metric, rm)}}
+		if err := writeOtlpJsonLine(jsonSvr.outputOut, data); err != nil {
+			log.Fatalf("failed to write metric to --output: %s", err)
+		}
+		return false
+	}
+
+	js, err := json.Marshal(metric)
+	if err != nil {
+		log.Fatalf("failed to marshal metric to json: %s", err)
+	}
+	writeJson(jsonSignalDir("metrics"), "metric-"+strconv.Itoa(n)+".json", js)
+
+	return false
+}
+
+// jsonSignalDir creates and returns the directory under --dir for a signal,
+// or an empty string when --dir isn't set.
+func jsonSignalDir(signal string) string {
+	if jsonSvr.outDir == "" {
+		return ""
+	}
+
+	outpath := filepath.Join(jsonSvr.outDir, signal)
+	os.Mkdir(outpath, 0755) // ignore errors like the span directories
+	return outpath
+}
+
 // writeJson takes a directory path, a filename, and json. When the path is not empty
 // string the json is written to path/filename. If --stdout was specified the json will
 // be printed as a line to stdout.
@@ -121,3 +355,23 @@ func writeJson(path, filename string, js []byte) {
 		os.Stdout.WriteString("\n")
 	}
 }
+
+// writeNdjson writes the span with its resource and scope as one line of
+// OTLP/JSON, the same format the console exporter uses.
+func writeNdjson(w io.Writer, span *tracepb.Span, rss *tracepb.ResourceSpans) error {
+	return writeOtlpJsonLine(w, &tracepb.TracesData{
+		ResourceSpans: []*tracepb.ResourceSpans{spanResourceSpans(span, rss)},
+	})
+}
+
+// writeOtlpJsonLine writes the message as one line of OTLP/JSON.
+func writeOtlpJsonLine(w io.Writer, msg proto.Message) error {
+	js, err := otlpclient.MarshalOtlpJson(msg)
+	if err != nil {
+		return err
+	}
+
+	// a single write per line, so lines from concurrent requests don't interleave
+	_, err = w.Write(append(js, '\n'))
+	return err
+}
@@ -0,0 +1,183 @@
+package otelcli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// formats for otel-cli server json --output
+const (
+	jsonOutputJson     = "json"
+	jsonOutputCsv      = "csv"
+	jsonOutputOtlpFile = "otlp-json-file"
+)
+
+// jsonOutputFiles are the files written to --dir for each --output format
+// other than json, which writes a tree of files instead.
+var jsonOutputFiles = map[string]string{
+	jsonOutputCsv:      "signals.csv",
+	jsonOutputOtlpFile: "otlp.json",
+}
+
+// jsonCsvHeader is the header of --output csv. Spans, log records, and
+// metrics share the columns so they can be loaded as one table, with the
+// signal column telling them apart.
+var jsonCsvHeader = []string{
+	"signal", "time", "end_time", "duration_ms", "service_name",
+	"trace_id", "span_id", "parent_span_id", "name", "kind",
+	"status", "value", "unit", "attributes",
+}
+
+// openJsonOutput sets up jsonSvr.outputOut for formats other than json,
+// appending to the format's file in --dir and/or writing to stdout. CSV
+// headers are written to stdout and to files that are empty. The returned
+// file is nil without --dir.
+func openJsonOutput() (*os.File, error) {
+	var file *os.File
+	writers := []io.Writer{}
+	header := csvLine(jsonCsvHeader)
+
+	if jsonSvr.outDir != "" {
+		path := filepath.Join(jsonSvr.outDir, jsonOutputFiles[jsonSvr.output])
+		var err error
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not open %q: %w", path, err)
+		}
+
+		if jsonSvr.output == jsonOutputCsv {
+			if fi, err := file.Stat(); err == nil && fi.Size() == 0 {
+				file.Write(header)
+			}
+		}
+		writers = append(writers, file)
+	}
+
+	if jsonSvr.stdout {
+		if jsonSvr.output == jsonOutputCsv {
+			os.Stdout.Write(header)
+		}
+		writers = append(writers, os.Stdout)
+	}
+
+	jsonSvr.outputOut = io.MultiWriter(writers...)
+	return file, nil
+}
+
+// writeJsonOutput writes a line to --output. jsonSvr.mu keeps lines from
+// concurrent requests from interleaving across the file and stdout.
+func writeJsonOutput(line []byte) error {
+	jsonSvr.mu.Lock()
+	defer jsonSvr.mu.Unlock()
+	_, err := jsonSvr.outputOut.Write(line)
+	return err
+}
+
+// csvLine returns the fields as a line of CSV.
+func csvLine(fields []string) []byte {
+	buf := bytes.Buffer{}
+	w := csv.NewWriter(&buf)
+	w.Write(fields) // writes to a bytes.Buffer don't fail
+	w.Flush()
+	return buf.Bytes()
+}
+
+// csvTime returns the unix epoch nanoseconds as RFC3339 in UTC, or an empty
+// string when unset.
+func csvTime(ts uint64) string {
+	if ts == 0 {
+		return ""
+	}
+	return time.Unix(0, int64(ts)).UTC().Format(time.RFC3339Nano)
+}
+
+// spanCsvRow returns the span in the columns of jsonCsvHeader.
+func spanCsvRow(span *tracepb.Span, rss *tracepb.ResourceSpans) []string {
+	var duration string
+	if span.EndTimeUnixNano >= span.StartTimeUnixNano {
+		ms := float64(span.EndTimeUnixNano-span.StartTimeUnixNano) / 1e6
+		duration = strconv.FormatFloat(ms, 'f', -1, 64)
+	}
+
+	return []string{
+		"span",
+		csvTime(span.StartTimeUnixNano),
+		csvTime(span.EndTimeUnixNano),
+		duration,
+		resourceServiceName(rss.GetResource()),
+		hex.EncodeToString(span.TraceId),
+		hex.EncodeToString(span.SpanId),
+		hex.EncodeToString(span.ParentSpanId),
+		span.Name,
+		otlpclient.SpanKindIntToString(span.GetKind()),
+		spanStatusString(span),
+		"",
+		"",
+		flattenStringMap(attrsToStringMap(span.Attributes), ""),
+	}
+}
+
+// logCsvRow returns the log record in the columns of jsonCsvHeader, with
+// its severity as the status and its body as the value.
+func logCsvRow(record *logspb.LogRecord, rl *logspb.ResourceLogs) []string {
+	ts := record.TimeUnixNano
+	if ts == 0 {
+		ts = record.ObservedTimeUnixNano
+	}
+	severity := record.SeverityText
+	if severity == "" {
+		severity = strings.TrimPrefix(record.SeverityNumber.String(), "SEVERITY_NUMBER_")
+	}
+
+	return []string{
+		"log",
+		csvTime(ts),
+		"",
+		"",
+		resourceServiceName(rl.GetResource()),
+		hex.EncodeToString(record.TraceId),
+		hex.EncodeToString(record.SpanId),
+		"",
+		"",
+		"",
+		severity,
+		anyValueString(record.Body),
+		"",
+		flattenStringMap(attrsToStringMap(record.Attributes), ""),
+	}
+}
+
+// metricCsvRow returns the metric in the columns of jsonCsvHeader, with the
+// metric type as the kind and its latest data point as the value.
+func metricCsvRow(metric *metricspb.Metric, rm *metricspb.ResourceMetrics) []string {
+	kind, value := metricSummary(metric)
+	return []string{
+		"metric",
+		"",
+		"",
+		"",
+		resourceServiceName(rm.GetResource()),
+		"",
+		"",
+		"",
+		metric.Name,
+		kind,
+		"",
+		value,
+		metric.Unit,
+		"",
+	}
+}
@@ -0,0 +1,200 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/google/go-cmp/cmp"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWriteNdjson(t *testing.T) {
+	first := otlpclient.NewProtobufSpan()
+	first.Name = "first"
+	second := otlpclient.NewProtobufSpan()
+	second.Name = "second"
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": "test"}),
+		},
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Scope: &commonpb.InstrumentationScope{Name: "test-scope"},
+			Spans: []*tracepb.Span{first, second},
+		}},
+	}
+
+	buf := bytes.Buffer{}
+	for _, span := range []*tracepb.Span{first, second} {
+		if err := writeNdjson(&buf, span, rss); err != nil {
+			t.Fatalf("failed to write ndjson: %s", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines but got %d: %q", len(lines), buf.String())
+	}
+
+	for i, span := range []*tracepb.Span{first, second} {
+		td := tracepb.TracesData{}
+		if err := otlpclient.UnmarshalOtlpJson([]byte(lines[i]), &td); err != nil {
+			t.Fatalf("failed to parse line %d: %s", i, err)
+		}
+
+		rs := td.GetResourceSpans()
+		if len(rs) != 1 || len(rs[0].GetScopeSpans()) != 1 || len(rs[0].ScopeSpans[0].GetSpans()) != 1 {
+			t.Fatalf("expected exactly one span on line %d but got %s", i, lines[i])
+		}
+		if got := rs[0].ScopeSpans[0].Spans[0]; !proto.Equal(got, span) {
+			t.Errorf("span on line %d did not match, got %s", i, lines[i])
+		}
+		if got := rs[0].ScopeSpans[0].GetScope().GetName(); got != "test-scope" {
+			t.Errorf("expected scope test-scope on line %d but got %q", i, got)
+		}
+		if got := otlpclient.ResourceAttributesToStringMap(rs[0])["service.name"]; got != "test" {
+			t.Errorf("expected service.name test on line %d but got %q", i, got)
+		}
+	}
+}
+
+func TestRenderJsonSignals(t *testing.T) {
+	dir := t.TempDir()
+	buf := bytes.Buffer{}
+	jsonSvr.outDir = dir
+	jsonSvr.ndjsonOut = &buf
+	defer func() {
+		jsonSvr.outDir = ""
+		jsonSvr.ndjsonOut = nil
+	}()
+
+	logs, metrics := signalsTestData()
+	rl := logs.ResourceLogs[0]
+	rm := metrics.ResourceMetrics[0]
+	renderJsonLog(context.Background(), rl.ScopeLogs[0].LogRecords[0], rl, nil, nil)
+	renderJsonMetric(context.Background(), rm.ScopeMetrics[0].Metrics[0], rm, nil, nil)
+
+	for _, path := range []string{
+		filepath.Join(dir, "logs", fmt.Sprintf("log-%d.json", jsonSvr.logsSeen)),
+		filepath.Join(dir, "metrics", fmt.Sprintf("metric-%d.json", jsonSvr.metricsSeen)),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be written: %s", path, err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines but got %d: %q", len(lines), buf.String())
+	}
+	ld := logspb.LogsData{}
+	if err := otlpclient.UnmarshalOtlpJson([]byte(lines[0]), &ld); err != nil {
+		t.Fatalf("failed to parse logs line: %s", err)
+	}
+	if got := ld.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Body.GetStringValue(); got != "disk full" {
+		t.Errorf("expected the log record on the first line but got %s", lines[0])
+	}
+	md := metricspb.MetricsData{}
+	if err := otlpclient.UnmarshalOtlpJson([]byte(lines[1]), &md); err != nil {
+		t.Fatalf("failed to parse metrics line: %s", err)
+	}
+	if got := md.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Name; got != "queue.depth" {
+		t.Errorf("expected the metric on the second line but got %s", lines[1])
+	}
+}
+
+func TestRenderJsonOutput(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.Name = "checkout"
+	span.TraceId = otlpclient.GenerateTraceId()
+	span.SpanId = otlpclient.GenerateSpanId()
+	span.StartTimeUnixNano = 1700000000000000000
+	span.EndTimeUnixNano = 1700000000250000000
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{"http.method": "POST"})
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": "api"}),
+		},
+	}
+	logs, metrics := signalsTestData()
+	rl := logs.ResourceLogs[0]
+	rm := metrics.ResourceMetrics[0]
+
+	render := func(output string) string {
+		dir := t.TempDir()
+		jsonSvr.outDir = dir
+		jsonSvr.output = output
+		defer func() {
+			jsonSvr.outDir = ""
+			jsonSvr.output = ""
+			jsonSvr.outputOut = nil
+		}()
+
+		file, err := openJsonOutput()
+		if err != nil {
+			t.Fatalf("failed to open --output %s: %s", output, err)
+		}
+		renderJson(context.Background(), span, nil, rss, nil, nil)
+		renderJsonLog(context.Background(), rl.ScopeLogs[0].LogRecords[0], rl, nil, nil)
+		renderJsonMetric(context.Background(), rm.ScopeMetrics[0].Metrics[0], rm, nil, nil)
+		file.Close()
+
+		if _, err := os.Stat(filepath.Join(dir, hex.EncodeToString(span.TraceId))); err == nil {
+			t.Errorf("expected --output %s not to write the json tree", output)
+		}
+		out, err := os.ReadFile(filepath.Join(dir, jsonOutputFiles[output]))
+		if err != nil {
+			t.Fatalf("failed to read --output %s: %s", output, err)
+		}
+		return string(out)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(render(jsonOutputCsv))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv: %s", err)
+	}
+	wantRows := [][]string{
+		jsonCsvHeader,
+		{
+			"span", "2023-11-14T22:13:20Z", "2023-11-14T22:13:20.25Z", "250", "api",
+			hex.EncodeToString(span.TraceId), hex.EncodeToString(span.SpanId), "", "checkout", "client",
+			"unset", "", "", "http.method=POST",
+		},
+		{"log", rows[2][1], "", "", "api", "", "", "", "", "", "ERROR", "disk full", "", ""},
+		{"metric", "", "", "", "api", "", "", "", "queue.depth", "gauge", "", "42", "{items}", ""},
+	}
+	if diff := cmp.Diff(wantRows, rows); diff != "" {
+		t.Errorf("unexpected csv (-want +got):\n%s", diff)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(render(jsonOutputOtlpFile), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines but got %d: %q", len(lines), lines)
+	}
+	// each line is a complete export request like the collector's file exporter writes
+	for i, msg := range []proto.Message{&coltracepb.ExportTraceServiceRequest{}, &collogspb.ExportLogsServiceRequest{}, &colmetricspb.ExportMetricsServiceRequest{}} {
+		if err := otlpclient.UnmarshalOtlpJson([]byte(lines[i]), msg); err != nil {
+			t.Errorf("failed to parse line %d as %T: %s", i, msg, err)
+		}
+	}
+	req := coltracepb.ExportTraceServiceRequest{}
+	otlpclient.UnmarshalOtlpJson([]byte(lines[0]), &req)
+	if got := req.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0]; !proto.Equal(got, span) {
+		t.Errorf("span did not round trip, got %s", lines[0])
+	}
+}
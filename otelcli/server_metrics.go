@@ -0,0 +1,108 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// serverMetrics counts what the server has received and serves the counts
+// in the Prometheus text format.
+type serverMetrics struct {
+	mu         sync.Mutex
+	spans      uint64
+	bytes      uint64
+	errorSpans uint64
+	services   map[string]uint64
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{services: make(map[string]uint64)}
+}
+
+// startServerMetrics serves /metrics on --prometheus-endpoint in the background
+// and returns a callback that counts every span before passing it on to cb,
+// along with a function to stop the metrics server. When --prometheus-endpoint
+// isn't set, cb is returned as-is.
+func startServerMetrics(config Config, cb otlpserver.Callback) (otlpserver.Callback, func()) {
+	if config.ServerMetricsEndpoint == "" {
+		return cb, func() {}
+	}
+
+	addr := strings.TrimPrefix(config.ServerMetricsEndpoint, "http://")
+	listener, err := listenServer(config, addr)
+	if err != nil {
+		config.SoftFail("failed to listen on metrics endpoint %q: %s", addr, err)
+	}
+
+	sm := newServerMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", sm)
+	hs := &http.Server{Handler: serverAuthHandler(config, mux)}
+	go hs.Serve(listener)
+
+	return sm.wrap(cb), func() { hs.Close() }
+}
+
+// wrap returns a callback that observes each span then calls cb.
+func (sm *serverMetrics) wrap(cb otlpserver.Callback) otlpserver.Callback {
+	return func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+		sm.observe(span, rss)
+		return cb(ctx, span, events, rss, headers, meta)
+	}
+}
+
+// observe counts a received span. Bytes are the span's protobuf-encoded
+// size, which is the same regardless of the protocol it came in on.
+func (sm *serverMetrics) observe(span *tracepb.Span, rss *tracepb.ResourceSpans) {
+	service := otlpclient.ResourceAttributesToStringMap(rss)["service.name"]
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.spans++
+	sm.bytes += uint64(proto.Size(span))
+	if span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+		sm.errorSpans++
+	}
+	sm.services[service]++
+}
+
+// ServeHTTP writes the counters in the Prometheus text exposition format.
+func (sm *serverMetrics) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	counter := func(name, help string, value uint64) {
+		fmt.Fprintf(rw, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+	}
+	counter("otel_cli_server_spans_received_total", "Spans received.", sm.spans)
+	counter("otel_cli_server_span_bytes_received_total", "Protobuf-encoded size of the spans received.", sm.bytes)
+	counter("otel_cli_server_error_spans_received_total", "Spans received with an error status.", sm.errorSpans)
+
+	name := "otel_cli_server_service_spans_received_total"
+	fmt.Fprintf(rw, "# HELP %s Spans received by service.name.\n# TYPE %s counter\n", name, name)
+	services := make([]string, 0, len(sm.services))
+	for service := range sm.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		fmt.Fprintf(rw, "%s{service_name=\"%s\"} %d\n", name, escapePromLabel(service), sm.services[service])
+	}
+}
+
+// escapePromLabel escapes a label value for the Prometheus text format.
+func escapePromLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,64 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServerMetrics(t *testing.T) {
+	resource := func(service string) *tracepb.ResourceSpans {
+		return &tracepb.ResourceSpans{
+			Resource: &resourcepb.Resource{
+				Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": service}),
+			},
+		}
+	}
+
+	sm := newServerMetrics()
+	calls := 0
+	cb := sm.wrap(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		calls++
+		return false
+	})
+
+	var size int
+	for _, service := range []string{"api", "web", "api", `we"ird`} {
+		span := otlpclient.NewProtobufSpan()
+		span.Name = "op"
+		if service == "web" {
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+		}
+		size += proto.Size(span)
+		cb(context.Background(), span, nil, resource(service), nil, nil)
+	}
+	if calls != 4 {
+		t.Errorf("expected the wrapped callback to be called 4 times but got %d", calls)
+	}
+
+	rec := httptest.NewRecorder()
+	sm.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE otel_cli_server_spans_received_total counter\notel_cli_server_spans_received_total 4\n",
+		"otel_cli_server_error_spans_received_total 1\n",
+		"otel_cli_server_service_spans_received_total{service_name=\"api\"} 2\n",
+		"otel_cli_server_service_spans_received_total{service_name=\"we\\\"ird\"} 1\n",
+		"otel_cli_server_service_spans_received_total{service_name=\"web\"} 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, out)
+		}
+	}
+	if want := fmt.Sprintf("otel_cli_server_span_bytes_received_total %d\n", size); !strings.Contains(out, want) {
+		t.Errorf("expected metrics to contain %q, got:\n%s", want, out)
+	}
+}
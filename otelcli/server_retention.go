@@ -0,0 +1,244 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// spillSegments is how many files the spill ring is split into. The oldest
+// file is deleted whenever the ring is full, so it holds between 3/4 of the
+// size limit and the limit.
+const spillSegments = 4
+
+// serverRetention tracks the traces a long-running server holds on to and
+// evicts them oldest first when there are more than maxSpans spans, or when
+// a trace hasn't had a new span in maxAge. Zero disables either limit.
+// Evicted spans are written to the spill ring when there is one. Not safe
+// for concurrent use.
+type serverRetention struct {
+	maxSpans int
+	maxAge   time.Duration
+	spill    *spillRing
+
+	spans  int
+	traces map[string]*retainedTrace
+	order  []*retainedTrace // by first span received, oldest first
+}
+
+// retainedTrace is a trace held by serverRetention.
+type retainedTrace struct {
+	id       string
+	spans    []retainedSpan
+	lastSeen time.Time
+}
+
+// retainedSpan is a span along with the resource it was received with, so
+// it can be spilled to disk as OTLP/JSON.
+type retainedSpan struct {
+	span *tracepb.Span
+	rss  *tracepb.ResourceSpans
+}
+
+func newServerRetention(maxSpans int, maxAge time.Duration, spill *spillRing) *serverRetention {
+	return &serverRetention{
+		maxSpans: maxSpans,
+		maxAge:   maxAge,
+		spill:    spill,
+		traces:   make(map[string]*retainedTrace),
+	}
+}
+
+// add records a span received at now and returns the ids of any traces
+// evicted to stay within the limits.
+func (r *serverRetention) add(span *tracepb.Span, rss *tracepb.ResourceSpans, now time.Time) ([]string, error) {
+	id := hex.EncodeToString(span.TraceId)
+	trace, ok := r.traces[id]
+	if !ok {
+		trace = &retainedTrace{id: id}
+		r.traces[id] = trace
+		r.order = append(r.order, trace)
+	}
+	trace.spans = append(trace.spans, retainedSpan{span: span, rss: rss})
+	trace.lastSeen = now
+	r.spans++
+
+	return r.evict(now)
+}
+
+// expire evicts traces that have been idle for longer than maxAge as of now
+// and returns their ids. Servers call it periodically so traces age out even
+// when no new spans are coming in.
+func (r *serverRetention) expire(now time.Time) ([]string, error) {
+	return r.evict(now)
+}
+
+// evict drops the oldest traces until there are no more than maxSpans
+// spans, along with every trace that's older than maxAge. A spill error
+// doesn't stop the eviction, the first one is returned once it's done.
+func (r *serverRetention) evict(now time.Time) ([]string, error) {
+	var evicted []string
+	var spillErr error
+	kept := r.order[:0]
+	for _, trace := range r.order {
+		tooMany := r.maxSpans > 0 && r.spans > r.maxSpans
+		tooOld := r.maxAge > 0 && now.Sub(trace.lastSeen) > r.maxAge
+		if !tooMany && !tooOld {
+			kept = append(kept, trace)
+			continue
+		}
+
+		r.spans -= len(trace.spans)
+		delete(r.traces, trace.id)
+		evicted = append(evicted, trace.id)
+
+		if r.spill != nil {
+			for _, s := range trace.spans {
+				if err := r.spill.write(s.span, s.rss); err != nil && spillErr == nil {
+					spillErr = err
+				}
+			}
+		}
+	}
+	// clear the tail so evicted traces can be garbage collected
+	for i := len(kept); i < len(r.order); i++ {
+		r.order[i] = nil
+	}
+	r.order = kept
+
+	return evicted, spillErr
+}
+
+// spillRing is an on-disk ring buffer of spans written as OTLP/JSON lines,
+// the same format as server json --ndjson. It's split across numbered
+// segment files in dir and the oldest segment is deleted when the total size
+// would go over maxBytes.
+type spillRing struct {
+	dir      string
+	maxBytes int64
+
+	segments []string // segment paths, oldest first
+	next     int      // number of the next segment file
+	size     int64    // bytes across all segments
+	current  *os.File
+	curSize  int64
+}
+
+// newSpillRing opens a spill ring in dir, creating it if needed and picking
+// up any segments left by a previous run.
+func newSpillRing(dir string, maxBytes int64) (*spillRing, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("spill size must be greater than zero")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory %q: %w", dir, err)
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, "spill-*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(existing) // zero-padded, so this sorts oldest first
+
+	ring := spillRing{dir: dir, maxBytes: maxBytes}
+	for _, path := range existing {
+		var n int
+		if _, err := fmt.Sscanf(filepath.Base(path), "spill-%d.ndjson", &n); err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		ring.segments = append(ring.segments, path)
+		ring.size += info.Size()
+		ring.next = n + 1
+	}
+
+	return &ring, ring.trim(0)
+}
+
+// write appends the span to the current segment, starting a new one when
+// it's full and deleting the oldest ones to make room.
+func (ring *spillRing) write(span *tracepb.Span, rss *tracepb.ResourceSpans) error {
+	if ring.current == nil || ring.curSize >= ring.maxBytes/spillSegments {
+		if err := ring.rotate(); err != nil {
+			return err
+		}
+	}
+
+	cw := countingWriter{w: ring.current}
+	err := writeNdjson(&cw, span, rss)
+	ring.curSize += cw.n
+	ring.size += cw.n
+	if err != nil {
+		return fmt.Errorf("failed to write span to spill file: %w", err)
+	}
+
+	return nil
+}
+
+// rotate closes the current segment and starts the next one.
+func (ring *spillRing) rotate() error {
+	if ring.current != nil {
+		ring.current.Close()
+	}
+	// make room for a full segment before starting it
+	if err := ring.trim(ring.maxBytes / spillSegments); err != nil {
+		return err
+	}
+
+	path := filepath.Join(ring.dir, fmt.Sprintf("spill-%08d.ndjson", ring.next))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create spill file: %w", err)
+	}
+	ring.next++
+	ring.segments = append(ring.segments, path)
+	ring.current = file
+	ring.curSize = 0
+
+	return nil
+}
+
+// trim deletes the oldest segments until there is room for another need bytes.
+func (ring *spillRing) trim(need int64) error {
+	for len(ring.segments) > 0 && ring.size+need > ring.maxBytes {
+		info, err := os.Stat(ring.segments[0])
+		if err == nil {
+			ring.size -= info.Size()
+		}
+		if err := os.Remove(ring.segments[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove spill file: %w", err)
+		}
+		ring.segments = ring.segments[1:]
+	}
+
+	return nil
+}
+
+// Close closes the current segment.
+func (ring *spillRing) Close() error {
+	if ring.current == nil {
+		return nil
+	}
+	return ring.current.Close()
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,155 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/google/go-cmp/cmp"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// retentionSpan returns a span in a trace with an id that's tid repeated.
+func retentionSpan(tid byte) *tracepb.Span {
+	span := otlpclient.NewProtobufSpan()
+	span.Name = "op"
+	for i := range span.TraceId {
+		span.TraceId[i] = tid
+	}
+	return span
+}
+
+func TestServerRetentionMaxSpans(t *testing.T) {
+	r := newServerRetention(3, 0, nil)
+	now := time.Now()
+	rss := &tracepb.ResourceSpans{}
+
+	for _, tid := range []byte{1, 1, 2} {
+		evicted, err := r.add(retentionSpan(tid), rss, now)
+		if err != nil || len(evicted) != 0 {
+			t.Fatalf("expected nothing evicted under the limit, got %v, %v", evicted, err)
+		}
+	}
+
+	// trace 1 is the oldest and has to go as a whole
+	evicted, err := r.add(retentionSpan(3), rss, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{strings.Repeat("01", 16)}, evicted); diff != "" {
+		t.Errorf("unexpected evicted traces (-want +got):\n%s", diff)
+	}
+	if r.spans != 2 || len(r.traces) != 2 || len(r.order) != 2 {
+		t.Errorf("expected 2 spans in 2 traces left, got %d spans in %d traces", r.spans, len(r.traces))
+	}
+}
+
+func TestServerRetentionMaxAge(t *testing.T) {
+	r := newServerRetention(0, time.Minute, nil)
+	start := time.Now()
+	rss := &tracepb.ResourceSpans{}
+
+	r.add(retentionSpan(1), rss, start)
+	r.add(retentionSpan(2), rss, start)
+	// a new span keeps trace 1 alive
+	r.add(retentionSpan(1), rss, start.Add(50*time.Second))
+
+	evicted, err := r.expire(start.Add(90 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{strings.Repeat("02", 16)}, evicted); diff != "" {
+		t.Errorf("unexpected evicted traces (-want +got):\n%s", diff)
+	}
+
+	evicted, _ = r.expire(start.Add(2 * time.Minute))
+	if len(evicted) != 1 || len(r.traces) != 0 || r.spans != 0 {
+		t.Errorf("expected the last trace to expire, got %v", evicted)
+	}
+}
+
+func TestServerRetentionSpillError(t *testing.T) {
+	dir := t.TempDir()
+	ring, err := newSpillRing(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newServerRetention(0, time.Minute, ring)
+	start := time.Now()
+	rss := &tracepb.ResourceSpans{}
+	for _, tid := range []byte{1, 1, 2, 3} {
+		r.add(retentionSpan(tid), rss, start)
+	}
+
+	// with the spill directory gone every write fails, but all of the traces
+	// are still evicted and counted once
+	os.RemoveAll(dir)
+	evicted, err := r.expire(start.Add(2 * time.Minute))
+	if err == nil {
+		t.Error("expected the spill error to be returned")
+	}
+	if len(evicted) != 3 || len(r.traces) != 0 || len(r.order) != 0 || r.spans != 0 {
+		t.Errorf("expected all 3 traces evicted, got %v with %d traces, %d in order, and %d spans left", evicted, len(r.traces), len(r.order), r.spans)
+	}
+
+	r.add(retentionSpan(4), rss, start.Add(2*time.Minute))
+	if len(r.order) != 1 || r.spans != 1 {
+		t.Errorf("expected 1 trace with 1 span after adding another, got %d and %d", len(r.order), r.spans)
+	}
+}
+
+func TestSpillRing(t *testing.T) {
+	dir := t.TempDir()
+	rss := &tracepb.ResourceSpans{}
+
+	line := func() int64 {
+		cw := countingWriter{w: &strings.Builder{}}
+		writeNdjson(&cw, retentionSpan(1), rss)
+		return cw.n
+	}()
+
+	// room for 2 lines per segment, 8 lines in the whole ring
+	ring, err := newSpillRing(dir, 8*line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newServerRetention(1, 0, ring)
+	for i := 0; i < 20; i++ {
+		if _, err := r.add(retentionSpan(byte(i)), rss, time.Now()); err != nil {
+			t.Fatalf("failed to spill: %s", err)
+		}
+	}
+	ring.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "spill-*.ndjson"))
+	var total int64
+	for _, path := range segments {
+		info, _ := os.Stat(path)
+		total += info.Size()
+	}
+	if total > 8*line || total < 6*line {
+		t.Errorf("expected the ring to hold 6 to 8 spans, got %d bytes in %d files", total, len(segments))
+	}
+
+	// the newest evicted span, from trace 18, should be in the last segment
+	last, err := os.ReadFile(segments[len(segments)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(last), strings.Repeat("12", 16)) {
+		t.Errorf("expected the last segment to have the newest span, got %s", last)
+	}
+
+	// a new ring in the same directory carries on after the existing segments
+	ring, err = newSpillRing(dir, 8*line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Close()
+	if ring.next != 10 || len(ring.segments) != len(segments) {
+		t.Errorf("expected to pick up %d segments and continue at 10, got %d and %d", len(segments), len(ring.segments), ring.next)
+	}
+}
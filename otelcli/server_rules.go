@@ -0,0 +1,267 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// spanRule is a parsed --keep or --drop rule: whitespace-separated terms
+// that all have to match a span. key=value terms match exactly, the same as
+// server assert --match, and key~regex terms match a regular expression
+// anywhere in the value.
+type spanRule struct {
+	exact spanMatcher
+	regex map[string]*regexp.Regexp
+}
+
+// serverRules decide which received spans the server keeps, from --drop,
+// --keep, --sample, and --keep-wait.
+type serverRules struct {
+	keep     []spanRule
+	drop     []spanRule
+	ratio    float64
+	keepWait time.Duration
+}
+
+// parseSpanRule parses a --keep or --drop rule.
+func parseSpanRule(expr string) (spanRule, error) {
+	rule := spanRule{exact: spanMatcher{}, regex: map[string]*regexp.Regexp{}}
+	for _, term := range strings.Fields(expr) {
+		eq := strings.Index(term, "=")
+		tilde := strings.Index(term, "~")
+
+		// whichever comes first is the operator, so regexes can contain =
+		// and values can contain ~
+		if tilde > 0 && (eq < 0 || tilde < eq) {
+			re, err := regexp.Compile(term[tilde+1:])
+			if err != nil {
+				return rule, fmt.Errorf("invalid regex in %q: %w", term, err)
+			}
+			rule.regex[term[:tilde]] = re
+			continue
+		}
+
+		matcher, err := parseSpanMatcher(term)
+		if err != nil {
+			return rule, fmt.Errorf("term %q must be in key=value or key~regex format", term)
+		}
+		for key, value := range matcher {
+			rule.exact[key] = value
+		}
+	}
+	if len(rule.exact) == 0 && len(rule.regex) == 0 {
+		return rule, fmt.Errorf("rule %q has no terms", expr)
+	}
+
+	return rule, nil
+}
+
+// match returns true if the span matches every term of the rule.
+func (r spanRule) match(span *tracepb.Span, rss *tracepb.ResourceSpans) bool {
+	if !r.exact.match(span, rss) {
+		return false
+	}
+	for key, re := range r.regex {
+		if value, ok := spanFieldValue(span, rss, key); !ok || !re.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// newServerRules parses --keep, --drop, --sample, and --keep-wait. --sample
+// is a percentage of traces, e.g. 10 or 10%.
+func newServerRules(config Config) (serverRules, error) {
+	rules := serverRules{ratio: 1}
+
+	for _, expr := range config.ServerKeep {
+		rule, err := parseSpanRule(expr)
+		if err != nil {
+			return rules, fmt.Errorf("invalid --keep: %w", err)
+		}
+		rules.keep = append(rules.keep, rule)
+	}
+	for _, expr := range config.ServerDrop {
+		rule, err := parseSpanRule(expr)
+		if err != nil {
+			return rules, fmt.Errorf("invalid --drop: %w", err)
+		}
+		rules.drop = append(rules.drop, rule)
+	}
+
+	if config.ServerSample != "" {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(config.ServerSample, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return rules, fmt.Errorf("invalid --sample %q, must be a percentage from 0 to 100", config.ServerSample)
+		}
+		rules.ratio = percent / 100
+	}
+
+	wait, err := parseDuration(config.ServerKeepWait)
+	if err != nil || wait < 0 {
+		return rules, fmt.Errorf("invalid --keep-wait %q, must be a duration like 30s", config.ServerKeepWait)
+	}
+	rules.keepWait = wait
+
+	return rules, nil
+}
+
+// allow returns true if the span should be kept: it matches no --drop
+// rule, matches a --keep rule if there are any, and its trace is in the
+// --sample. Sampling is decided from the trace id like the SDKs' ratio
+// sampler, so a trace's spans are all kept or all dropped together. --keep
+// and --drop only see the span itself, so e.g. --keep status=error keeps the
+// error spans and drops their parents and siblings. --keep-wait applies
+// --keep to whole traces instead, see traceKeeper.
+func (r serverRules) allow(span *tracepb.Span, rss *tracepb.ResourceSpans) bool {
+	return !r.dropped(span, rss) && r.kept(span, rss)
+}
+
+// dropped returns true if the span matches a --drop rule or its trace isn't
+// in the --sample.
+func (r serverRules) dropped(span *tracepb.Span, rss *tracepb.ResourceSpans) bool {
+	for _, rule := range r.drop {
+		if rule.match(span, rss) {
+			return true
+		}
+	}
+	return !traceIdRatioSampled(span.TraceId, r.ratio)
+}
+
+// kept returns true if the span matches a --keep rule or there are none.
+func (r serverRules) kept(span *tracepb.Span, rss *tracepb.ResourceSpans) bool {
+	if len(r.keep) == 0 {
+		return true
+	}
+	for _, rule := range r.keep {
+		if rule.match(span, rss) {
+			return true
+		}
+	}
+	return false
+}
+
+// heldSpan is a span traceKeeper holds with the rest of its callback's args.
+type heldSpan struct {
+	span    *tracepb.Span
+	events  []*tracepb.Span_Event
+	rss     *tracepb.ResourceSpans
+	headers map[string]string
+	meta    map[string]string
+}
+
+// traceKeeper applies --keep to whole traces for --keep-wait. Spans that
+// don't match are held by trace id until a span in the same trace does,
+// then they're released along with it and the trace's later spans pass
+// straight through. A held trace is dropped once --keep-wait has passed
+// since its first span without a match, and a kept trace is forgotten once
+// it has gone that long without a span.
+type traceKeeper struct {
+	wait time.Duration
+
+	mu      sync.Mutex
+	held    map[string][]heldSpan
+	started map[string]time.Time // held trace id to its first span
+	kept    map[string]time.Time // kept trace id to its last span
+	expired time.Time
+}
+
+// newTraceKeeper returns a traceKeeper that holds traces for wait.
+func newTraceKeeper(wait time.Duration) *traceKeeper {
+	return &traceKeeper{
+		wait:    wait,
+		held:    map[string][]heldSpan{},
+		started: map[string]time.Time{},
+		kept:    map[string]time.Time{},
+	}
+}
+
+// add takes a span that arrived at now and passed --drop and --sample, with
+// whether it matched --keep. It returns the spans to pass on, oldest first:
+// none while the span's trace is held, or its held spans and the span once
+// the trace is kept.
+func (tk *traceKeeper) add(now time.Time, hs heldSpan, matched bool) []heldSpan {
+	tk.mu.Lock()
+	defer tk.mu.Unlock()
+
+	// expiring walks every trace, so it's done at most once a second
+	if now.Sub(tk.expired) >= time.Second {
+		tk.expire(now)
+	}
+
+	id := string(hs.span.TraceId)
+	if _, ok := tk.kept[id]; !ok && !matched {
+		if _, ok := tk.started[id]; !ok {
+			tk.started[id] = now
+		}
+		tk.held[id] = append(tk.held[id], hs)
+		return nil
+	}
+
+	tk.kept[id] = now
+	out := append(tk.held[id], hs)
+	delete(tk.held, id)
+	delete(tk.started, id)
+	return out
+}
+
+// expire drops held traces and forgets kept traces older than the wait.
+func (tk *traceKeeper) expire(now time.Time) {
+	for id, started := range tk.started {
+		if now.Sub(started) > tk.wait {
+			delete(tk.held, id)
+			delete(tk.started, id)
+		}
+	}
+	for id, last := range tk.kept {
+		if now.Sub(last) > tk.wait {
+			delete(tk.kept, id)
+		}
+	}
+	tk.expired = now
+}
+
+// applyServerRules returns a callback that only calls cb for the spans the
+// rules allow. Returns cb unchanged when there are no rules.
+func applyServerRules(config Config, cb otlpserver.Callback) otlpserver.Callback {
+	rules, err := newServerRules(config)
+	if err != nil {
+		config.SoftFail("%s", err)
+	}
+	if len(rules.keep) == 0 && len(rules.drop) == 0 && rules.ratio >= 1 {
+		return cb
+	}
+
+	if rules.keepWait > 0 && len(rules.keep) > 0 {
+		keeper := newTraceKeeper(rules.keepWait)
+		return func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+			if rules.dropped(span, rss) {
+				return false
+			}
+			done := false
+			for _, hs := range keeper.add(time.Now(), heldSpan{span, events, rss, headers, meta}, rules.kept(span, rss)) {
+				// held spans' requests are over, so they go out with the
+				// context of the one that released them
+				if cb(ctx, hs.span, hs.events, hs.rss, hs.headers, hs.meta) {
+					done = true
+				}
+			}
+			return done
+		}
+	}
+
+	return func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+		if !rules.allow(span, rss) {
+			return false
+		}
+		return cb(ctx, span, events, rss, headers, meta)
+	}
+}
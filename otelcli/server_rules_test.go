@@ -0,0 +1,216 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestServerRules(t *testing.T) {
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": "api"}),
+		},
+	}
+	newSpan := func(name, path string, isError bool) *tracepb.Span {
+		span := otlpclient.NewProtobufSpan()
+		span.TraceId = otlpclient.GenerateTraceId()
+		span.Name = name
+		span.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{"http.route": path})
+		if isError {
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+		}
+		return span
+	}
+	health := newSpan("GET /healthz", "/healthz", false)
+	checkout := newSpan("POST /checkout", "/checkout", false)
+	failed := newSpan("POST /checkout", "/checkout", true)
+	metrics := newSpan("GET /metrics", "/metrics", true)
+
+	for _, tc := range []struct {
+		name   string
+		config Config
+		want   map[*tracepb.Span]bool
+	}{
+		{
+			name:   "no rules keeps everything",
+			config: DefaultConfig(),
+			want:   map[*tracepb.Span]bool{health: true, checkout: true, failed: true, metrics: true},
+		},
+		{
+			name:   "drop by name regex",
+			config: DefaultConfig().WithServerDrop([]string{`name~^GET\s/(healthz|metrics)$`}),
+			want:   map[*tracepb.Span]bool{health: false, checkout: true, failed: true, metrics: false},
+		},
+		{
+			name:   "keep only errors",
+			config: DefaultConfig().WithServerKeep([]string{"status=error"}),
+			want:   map[*tracepb.Span]bool{health: false, checkout: false, failed: true, metrics: true},
+		},
+		{
+			name:   "drop wins over keep",
+			config: DefaultConfig().WithServerKeep([]string{"status=error"}).WithServerDrop([]string{"http.route=/metrics"}),
+			want:   map[*tracepb.Span]bool{health: false, checkout: false, failed: true, metrics: false},
+		},
+		{
+			name:   "keep matches any rule, with all terms of a rule",
+			config: DefaultConfig().WithServerKeep([]string{"http.route~check service.name=api status=unset", "http.route=/healthz"}),
+			want:   map[*tracepb.Span]bool{health: true, checkout: true, failed: false, metrics: false},
+		},
+		{
+			name:   "sample nothing",
+			config: DefaultConfig().WithServerSample("0%"),
+			want:   map[*tracepb.Span]bool{health: false, checkout: false, failed: false, metrics: false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := newServerRules(tc.config)
+			if err != nil {
+				t.Fatalf("failed to parse rules: %s", err)
+			}
+			for span, want := range tc.want {
+				if got := rules.allow(span, rss); got != want {
+					t.Errorf("expected %q %s to be kept=%t but got %t", span.Name, spanStatusString(span), want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestServerRulesSampleTraces(t *testing.T) {
+	rules, err := newServerRules(DefaultConfig().WithServerSample("50"))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %s", err)
+	}
+
+	// the low 63 bits of the second half of the trace id decide, so these
+	// are just under and just over half
+	in, _ := hex.DecodeString("000000000000000070000000000000ff")
+	out, _ := hex.DecodeString("ffffffffffffffff90000000000000ff")
+	for _, tc := range []struct {
+		traceId []byte
+		want    bool
+	}{{in, true}, {out, false}} {
+		// every span of a trace gets the same decision
+		for _, name := range []string{"parent", "child"} {
+			span := otlpclient.NewProtobufSpan()
+			span.TraceId = tc.traceId
+			span.Name = name
+			if got := rules.allow(span, nil); got != tc.want {
+				t.Errorf("expected %s span in trace %x to be kept=%t but got %t", name, tc.traceId, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestServerRulesErrors(t *testing.T) {
+	for _, config := range []Config{
+		DefaultConfig().WithServerKeep([]string{"name~("}),
+		DefaultConfig().WithServerDrop([]string{"justaword"}),
+		DefaultConfig().WithServerDrop([]string{"  "}),
+		DefaultConfig().WithServerKeep([]string{"status=broken"}),
+		DefaultConfig().WithServerSample("150%"),
+		DefaultConfig().WithServerSample("lots"),
+		DefaultConfig().WithServerKeepWait("soon"),
+		DefaultConfig().WithServerKeepWait("-5s"),
+	} {
+		if _, err := newServerRules(config); err == nil {
+			t.Errorf("expected keep %q, drop %q, sample %q, keep wait %q to fail", config.ServerKeep, config.ServerDrop, config.ServerSample, config.ServerKeepWait)
+		}
+	}
+}
+
+func TestApplyServerRules(t *testing.T) {
+	received := []string{}
+	cb := applyServerRules(DefaultConfig().WithServerDrop([]string{"name~^health"}), func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+		received = append(received, span.Name)
+		return true
+	})
+
+	for _, name := range []string{"healthcheck", "checkout"} {
+		span := otlpclient.NewProtobufSpan()
+		span.Name = name
+		done := cb(context.Background(), span, nil, nil, nil, nil)
+		if done != (name == "checkout") {
+			t.Errorf("expected only the kept span to be able to stop the server, got %t for %s", done, name)
+		}
+	}
+	if len(received) != 1 || received[0] != "checkout" {
+		t.Errorf("expected only checkout to be received but got %q", received)
+	}
+}
+
+func TestTraceKeeper(t *testing.T) {
+	newSpan := func(traceId []byte, name string) heldSpan {
+		span := otlpclient.NewProtobufSpan()
+		span.TraceId = traceId
+		span.Name = name
+		return heldSpan{span: span}
+	}
+	names := func(spans []heldSpan) []string {
+		out := []string{}
+		for _, hs := range spans {
+			out = append(out, hs.span.Name)
+		}
+		return out
+	}
+	traceA := otlpclient.GenerateTraceId()
+	traceB := otlpclient.GenerateTraceId()
+	start := time.Unix(1700000000, 0)
+	tk := newTraceKeeper(30 * time.Second)
+
+	if out := tk.add(start, newSpan(traceA, "a-parent"), false); len(out) != 0 {
+		t.Errorf("expected a-parent to be held but got %q", names(out))
+	}
+	if out := tk.add(start, newSpan(traceB, "b-parent"), false); len(out) != 0 {
+		t.Errorf("expected b-parent to be held but got %q", names(out))
+	}
+
+	out := names(tk.add(start.Add(time.Second), newSpan(traceA, "a-error"), true))
+	if len(out) != 2 || out[0] != "a-parent" || out[1] != "a-error" {
+		t.Errorf("expected a-parent then a-error when trace A matched but got %q", out)
+	}
+
+	out = names(tk.add(start.Add(2*time.Second), newSpan(traceA, "a-sibling"), false))
+	if len(out) != 1 || out[0] != "a-sibling" {
+		t.Errorf("expected a-sibling to pass through once trace A is kept but got %q", out)
+	}
+
+	if out := tk.add(start.Add(time.Minute), newSpan(traceA, "a-late"), false); len(out) != 0 {
+		t.Errorf("expected trace A to be forgotten after the wait but got %q", names(out))
+	}
+	if _, ok := tk.held[string(traceB)]; ok {
+		t.Error("expected trace B to be dropped after the wait")
+	}
+}
+
+func TestApplyServerRulesKeepWait(t *testing.T) {
+	received := []string{}
+	config := DefaultConfig().WithServerKeep([]string{"status=error"}).WithServerKeepWait("30s")
+	cb := applyServerRules(config, func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+		received = append(received, span.Name)
+		return span.Name == "error"
+	})
+
+	traceId := otlpclient.GenerateTraceId()
+	for _, name := range []string{"parent", "error", "sibling"} {
+		span := otlpclient.NewProtobufSpan()
+		span.TraceId = traceId
+		span.Name = name
+		if name == "error" {
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+		}
+		done := cb(context.Background(), span, nil, nil, nil, nil)
+		if done != (name == "error") {
+			t.Errorf("expected only the error span's batch to stop the server, got %t for %s", done, name)
+		}
+	}
+	if len(received) != 3 || received[0] != "parent" || received[1] != "error" || received[2] != "sibling" {
+		t.Errorf("expected the whole error trace to be received but got %q", received)
+	}
+}
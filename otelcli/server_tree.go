@@ -0,0 +1,275 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// treeSvr holds the command-line configured settings and the traces
+// buffered by otel-cli server tree. traces is guarded by mu.
+var treeSvr struct {
+	wait    string
+	maxWait string
+
+	mu     sync.Mutex
+	traces map[string]*treeTrace
+	out    io.Writer
+}
+
+// treeTrace is a trace waiting to be printed.
+type treeTrace struct {
+	id      string
+	spans   []retainedSpan
+	firstAt time.Time // when the first span arrived
+	rootAt  time.Time // when the root span arrived, zero until then
+}
+
+// treeSpan is a span in a trace's tree, depth levels below its root.
+type treeSpan struct {
+	retainedSpan
+	depth int
+	last  bool // the last of its parent's children
+}
+
+func serverTreeCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "tree",
+		Short: "print each trace as a tree of spans",
+		Long: `Run otel-cli as an OTLP server that groups received spans by trace and
+prints each trace as an indented tree with durations once its root span has
+arrived. Spans are sent when they end, so the root is usually last, but
+children that end after their parent still show up if they arrive within
+--wait of the root. Traces whose root never arrives are printed after
+--max-wait.
+
+Example:
+	otel-cli server tree
+	otel-cli server tree --wait 5s --timeout 60
+`,
+		Run: doServerTree,
+	}
+
+	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
+	cmd.Flags().StringVar(&treeSvr.wait, "wait", "1s", "how long after the root span arrives to wait for late children")
+	cmd.Flags().StringVar(&treeSvr.maxWait, "max-wait", "30s", "print traces whose root span hasn't arrived after this long")
+
+	return &cmd
+}
+
+func doServerTree(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	wait, err := parseDuration(treeSvr.wait)
+	if err != nil {
+		config.SoftFail("invalid --wait: %s", err)
+	}
+	maxWait, err := parseDuration(treeSvr.maxWait)
+	if err != nil {
+		config.SoftFail("invalid --max-wait: %s", err)
+	}
+	treeSvr.traces = make(map[string]*treeTrace)
+	treeSvr.out = os.Stdout
+
+	go func() {
+		for now := range time.Tick(max(wait/4, 10*time.Millisecond)) {
+			flushTreeTraces(now, wait, maxWait)
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		runServer(config, addTreeSpan, otlpserver.SignalCallbacks{}, func(otlpserver.OtlpServer) {})
+		close(done)
+	}()
+
+	if timeout := config.ParseCliTimeout(); timeout > 0 {
+		select {
+		case <-done:
+		case <-time.After(timeout):
+		}
+	} else {
+		<-done
+	}
+
+	// print whatever is left, complete or not
+	flushTreeTraces(time.Now(), 0, 0)
+}
+
+// addTreeSpan buffers the span until its trace is printed.
+func addTreeSpan(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+	tid := hex.EncodeToString(span.TraceId)
+	now := time.Now()
+
+	treeSvr.mu.Lock()
+	defer treeSvr.mu.Unlock()
+
+	trace, ok := treeSvr.traces[tid]
+	if !ok {
+		trace = &treeTrace{id: tid, firstAt: now}
+		treeSvr.traces[tid] = trace
+	}
+	trace.spans = append(trace.spans, retainedSpan{span: span, rss: rss})
+	if len(span.ParentSpanId) == 0 && trace.rootAt.IsZero() {
+		trace.rootAt = now
+	}
+
+	return false
+}
+
+// flushTreeTraces prints and forgets the traces whose root arrived at least
+// wait ago or whose first span arrived at least maxWait ago, oldest first.
+func flushTreeTraces(now time.Time, wait, maxWait time.Duration) {
+	treeSvr.mu.Lock()
+	defer treeSvr.mu.Unlock()
+
+	ready := []*treeTrace{}
+	for tid, trace := range treeSvr.traces {
+		if (!trace.rootAt.IsZero() && now.Sub(trace.rootAt) >= wait) || now.Sub(trace.firstAt) >= maxWait {
+			ready = append(ready, trace)
+			delete(treeSvr.traces, tid)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		return ready[i].firstAt.Before(ready[j].firstAt)
+	})
+
+	for _, trace := range ready {
+		writeTraceTree(treeSvr.out, trace.id, trace.spans)
+	}
+}
+
+// writeTraceTree writes a header line for the trace followed by its spans as
+// a tree, e.g.
+//
+//	trace 4bf92f3577b34da6a3ce929d0e0e4736 3 spans 250ms
+//	└─ checkout 250ms (api)
+//	   ├─ db.query 12.3ms (postgres)
+//	   └─ render 40ms
+func writeTraceTree(w io.Writer, tid string, spans []retainedSpan) {
+	tree := traceTree(spans)
+	start, end := traceBounds(spans)
+	fmt.Fprintf(w, "trace %s %d spans %s\n", tid, len(spans), treeDuration(start, end))
+
+	// ancestors[d] is true when the span's ancestor at depth d has siblings
+	// below it, so the tree needs a line down through that level
+	ancestors := []bool{}
+	services := []string{}
+	for _, s := range tree {
+		ancestors = append(ancestors[:s.depth], !s.last)
+		service := resourceServiceName(s.rss.GetResource())
+		services = append(services[:s.depth], service)
+
+		var line strings.Builder
+		for _, more := range ancestors[:s.depth] {
+			if more {
+				line.WriteString("│  ")
+			} else {
+				line.WriteString("   ")
+			}
+		}
+		if s.last {
+			line.WriteString("└─ ")
+		} else {
+			line.WriteString("├─ ")
+		}
+		line.WriteString(s.span.Name + " " + treeDuration(s.span.StartTimeUnixNano, s.span.EndTimeUnixNano))
+
+		// only show the service where it changes, which is where the
+		// interesting hops are
+		if s.depth == 0 || service != services[s.depth-1] {
+			line.WriteString(" (" + service + ")")
+		}
+		if s.span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+			line.WriteString(" ERROR")
+			if msg := s.span.GetStatus().GetMessage(); msg != "" {
+				line.WriteString(": " + msg)
+			}
+		}
+
+		fmt.Fprintln(w, line.String())
+	}
+}
+
+// treeDuration returns the time between the unix epoch nanoseconds in
+// milliseconds, rounded to 0.1ms or to 1µs under a millisecond, e.g. 12.3ms.
+func treeDuration(start, end uint64) string {
+	if end < start {
+		return "0ms"
+	}
+	ms := float64(end-start) / 1e6
+	if ms >= 1 {
+		ms = math.Round(ms*10) / 10
+	} else {
+		ms = math.Round(ms*1000) / 1000
+	}
+	return strconv.FormatFloat(ms, 'f', -1, 64) + "ms"
+}
+
+// traceBounds returns the earliest start and latest end of the spans in unix
+// epoch nanoseconds.
+func traceBounds(spans []retainedSpan) (uint64, uint64) {
+	var start, end uint64
+	for _, s := range spans {
+		if start == 0 || s.span.StartTimeUnixNano < start {
+			start = s.span.StartTimeUnixNano
+		}
+		if s.span.EndTimeUnixNano > end {
+			end = s.span.EndTimeUnixNano
+		}
+	}
+	return start, max(start, end)
+}
+
+// traceTree returns the spans of a trace in tree order: each span followed
+// by its children, ordered by start time. Spans whose parent hasn't been
+// received are shown as roots.
+func traceTree(spans []retainedSpan) []treeSpan {
+	ids := make(map[string]bool)
+	for _, s := range spans {
+		ids[hex.EncodeToString(s.span.SpanId)] = true
+	}
+	children := make(map[string][]retainedSpan)
+	for _, s := range spans {
+		parent := hex.EncodeToString(s.span.ParentSpanId)
+		if !ids[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], s)
+	}
+
+	out := []treeSpan{}
+	visited := make(map[*tracepb.Span]bool)
+	var walk func(spans []retainedSpan, depth int)
+	walk = func(spans []retainedSpan, depth int) {
+		sort.SliceStable(spans, func(i, j int) bool {
+			return spans[i].span.StartTimeUnixNano < spans[j].span.StartTimeUnixNano
+		})
+		for i, s := range spans {
+			if visited[s.span] {
+				continue
+			}
+			visited[s.span] = true
+			out = append(out, treeSpan{retainedSpan: s, depth: depth, last: i == len(spans)-1})
+			walk(children[hex.EncodeToString(s.span.SpanId)], depth+1)
+		}
+	}
+	walk(children[""], 0)
+	// spans in a parent loop never hang off a root, show them at the top
+	walk(append([]retainedSpan{}, spans...), 0)
+
+	return out
+}
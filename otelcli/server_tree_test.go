@@ -0,0 +1,115 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/google/go-cmp/cmp"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// treeTestTrace returns a trace of a checkout calling the db and rendering,
+// with the db call making two queries, in the order they'd arrive.
+func treeTestTrace() []retainedSpan {
+	traceId := otlpclient.GenerateTraceId()
+	rss := func(service string) *tracepb.ResourceSpans {
+		return &tracepb.ResourceSpans{
+			Resource: &resourcepb.Resource{
+				Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": service}),
+			},
+		}
+	}
+	newSpan := func(name string, parent *tracepb.Span, startMs, endMs uint64) *tracepb.Span {
+		span := otlpclient.NewProtobufSpan()
+		span.TraceId = traceId
+		span.SpanId = otlpclient.GenerateSpanId()
+		span.Name = name
+		if parent != nil {
+			span.ParentSpanId = parent.SpanId
+		}
+		span.StartTimeUnixNano = 1700000000000000000 + startMs*1e6
+		span.EndTimeUnixNano = 1700000000000000000 + endMs*1e6
+		return span
+	}
+
+	root := newSpan("checkout", nil, 0, 250)
+	db := newSpan("db", root, 10, 30)
+	first := newSpan("select", db, 11, 20)
+	second := newSpan("update", db, 20, 29)
+	second.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "deadlock"}
+	render := newSpan("render", root, 200, 240)
+
+	return []retainedSpan{
+		{span: first, rss: rss("postgres")},
+		{span: second, rss: rss("postgres")},
+		{span: db, rss: rss("api")},
+		{span: render, rss: rss("api")},
+		{span: root, rss: rss("api")},
+	}
+}
+
+func TestWriteTraceTree(t *testing.T) {
+	spans := treeTestTrace()
+	buf := bytes.Buffer{}
+	writeTraceTree(&buf, "abc", spans)
+
+	want := []string{
+		"trace abc 5 spans 250ms",
+		"└─ checkout 250ms (api)",
+		"   ├─ db 20ms",
+		"   │  ├─ select 9ms (postgres)",
+		"   │  └─ update 9ms (postgres) ERROR: deadlock",
+		"   └─ render 40ms",
+	}
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected tree (-want +got):\n%s", diff)
+	}
+}
+
+func TestFlushTreeTraces(t *testing.T) {
+	buf := bytes.Buffer{}
+	treeSvr.traces = make(map[string]*treeTrace)
+	treeSvr.out = &buf
+
+	spans := treeTestTrace()
+	for _, s := range spans[:len(spans)-1] {
+		addTreeSpan(context.Background(), s.span, nil, s.rss, nil, nil)
+	}
+
+	// without the root, the trace waits for max-wait
+	now := time.Now()
+	flushTreeTraces(now, time.Second, time.Minute)
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing to be printed before the root arrived but got:\n%s", buf.String())
+	}
+
+	// with the root, it waits for late children for wait
+	root := spans[len(spans)-1]
+	addTreeSpan(context.Background(), root.span, nil, root.rss, nil, nil)
+	flushTreeTraces(time.Now(), time.Second, time.Minute)
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing to be printed within --wait of the root but got:\n%s", buf.String())
+	}
+	flushTreeTraces(time.Now().Add(time.Second), time.Second, time.Minute)
+	if !strings.Contains(buf.String(), "5 spans") {
+		t.Errorf("expected the trace to be printed after --wait but got:\n%s", buf.String())
+	}
+	if len(treeSvr.traces) != 0 {
+		t.Errorf("expected the printed trace to be forgotten")
+	}
+
+	// a trace whose root never arrives is printed after max-wait
+	buf.Reset()
+	orphan := treeTestTrace()[0]
+	addTreeSpan(context.Background(), orphan.span, nil, orphan.rss, nil, nil)
+	flushTreeTraces(time.Now().Add(time.Minute), time.Second, time.Minute)
+	if !strings.Contains(buf.String(), "└─ select 9ms (postgres)") {
+		t.Errorf("expected the rootless trace to be printed after --max-wait but got:\n%s", buf.String())
+	}
+}
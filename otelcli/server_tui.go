@@ -3,42 +3,122 @@ package otelcli
 import (
 	"context"
 	"encoding/hex"
+	"fmt"
 	"log"
 	"math"
+	"os"
 	"sort"
 	"strconv"
+	"sync"
+	"time"
 
+	"atomicgo.dev/keyboard"
+	"atomicgo.dev/keyboard/keys"
 	"github.com/equinix-labs/otel-cli/otlpclient"
 	"github.com/equinix-labs/otel-cli/otlpserver"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"golang.org/x/term"
 )
 
+// tuiDefaultMaxSpans is how many spans the tui keeps around for scrolling
+// back and filtering by default, the oldest traces are dropped first.
+const tuiDefaultMaxSpans = 10000
+
+// tuiRenderInterval is how often the tui redraws at most, so a busy
+// application flooding it with spans doesn't keep the terminal redrawing.
+const tuiRenderInterval = 100 * time.Millisecond
+
+// tuiServer is updated by the OTLP server, the keyboard, and the render loop
+// so everything but area is guarded by mu.
 var tuiServer struct {
+	mu     sync.Mutex
 	lines  SpanEventUnionList
 	traces map[string]*tracepb.Span // for looking up top span of trace by trace id
 	area   *pterm.AreaPrinter
+	dirty  bool // redraw on the next tick
+
+	query     string // the current filter query, from --filter or typed after /
+	prevQuery string // the query before editing started, restored on esc
+	filter    tuiFilter
+	editing   bool
+	input     string // the query being typed
+	inputErr  string // why input isn't a valid query
+
+	paused bool
+	frozen SpanEventUnionList // the lines when the tui was paused
+	missed int                // spans and events that came in while paused
+	scroll int                // rows scrolled back from the newest
+
+	maxSpans   int
+	maxAge     string
+	spillDir   string
+	spillMaxMb int
+	retention  *serverRetention
+
+	view    tuiView
+	logs    []tuiLog
+	metrics []tuiMetric
 }
 
 func serverTuiCmd(config *Config) *cobra.Command {
 	cmd := cobra.Command{
 		Use:   "tui",
-		Short: "display spans in a terminal UI",
-		Long: `Run otel-cli as an OTLP server with a terminal UI that displays traces.
-	
+		Short: "display spans, logs, and metrics in a terminal UI",
+		Long: `Run otel-cli as an OTLP server with a terminal UI that displays traces,
+and tables of the log records and metrics it receives. Press t, l, and m to
+switch between traces, logs, and metrics.
+
+Press / to type a filter, which is applied as you type. A filter is a list of
+terms that all have to match: service:NAME matches the service name,
+status:ok|error|unset the span status, key=value a span attribute, and any
+other word is a case-insensitive substring of the span name. Filters, pausing,
+and scrolling only apply to traces, the logs and metrics views show the newest
+entries that fit on the screen. Press p or space
+to pause and scroll back with the arrow keys, PgUp, PgDn, and Home. End or p
+goes back to live. c clears the filter and q quits.
+
+The tui keeps the newest --max-spans spans, evicting the oldest traces first,
+and with --max-age drops traces that haven't had a new span in that long, so it
+can run for days. Evicted spans are lost unless --spill-dir is set, in which
+case they are written there as OTLP/JSON lines, in a ring of files that is kept
+under --spill-max-mb by deleting the oldest.
+
 	# run otel-cli as a local server and print spans to the console as a table
-	otel-cli server tui`,
+	otel-cli server tui
+
+	# only show failed spans from the deploy service
+	otel-cli server tui --filter "service:deploy status:error"
+
+	# keep an hour of traces on screen and the last 1GB of older ones on disk
+	otel-cli server tui --max-age 1h --spill-dir /var/tmp/otel-cli-spill --spill-max-mb 1024`,
 		Run: doServerTui,
 	}
 
 	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
+	cmd.Flags().StringVar(&tuiServer.query, "filter", "", "only show spans matching this filter, see above for the syntax")
+	cmd.Flags().IntVar(&tuiServer.maxSpans, "max-spans", tuiDefaultMaxSpans, "keep at most this many spans, evicting the oldest traces first")
+	cmd.Flags().StringVar(&tuiServer.maxAge, "max-age", "", "evict traces that haven't had a new span in this long, e.g. 1h")
+	cmd.Flags().StringVar(&tuiServer.spillDir, "spill-dir", "", "write evicted spans to OTLP/JSON files in this directory instead of dropping them")
+	cmd.Flags().IntVar(&tuiServer.spillMaxMb, "spill-max-mb", 100, "the most disk space --spill-dir may use, the oldest spans are deleted first")
 	return &cmd
 }
 
 // doServerTui implements the 'otel-cli server tui' subcommand.
 func doServerTui(cmd *cobra.Command, args []string) {
 	config := getConfig(cmd.Context())
+	filter, err := parseTuiFilter(tuiServer.query)
+	if err != nil {
+		config.SoftFail("invalid --filter: %s", err)
+	}
+	tuiServer.filter = filter
+	tuiServer.retention = newTuiRetention(config)
+	if tuiServer.retention.spill != nil {
+		defer tuiServer.retention.spill.Close()
+	}
+
 	area, err := pterm.DefaultArea.Start()
 	if err != nil {
 		log.Fatalf("failed to set up terminal for rendering: %s", err)
@@ -47,130 +127,375 @@ func doServerTui(cmd *cobra.Command, args []string) {
 
 	tuiServer.lines = []SpanEventUnion{}
 	tuiServer.traces = make(map[string]*tracepb.Span)
+	tuiServer.view = tuiViewTraces
+	tuiServer.dirty = true
 
 	stop := func(otlpserver.OtlpServer) {
 		tuiServer.area.Stop()
 	}
 
-	runServer(config, renderTui, stop)
+	go renderTuiLoop()
+	// keys only work on a terminal, otherwise the tui just displays spans
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		go listenTuiKeys()
+	}
+
+	signals := otlpserver.SignalCallbacks{Logs: addTuiLog, Metrics: addTuiMetric}
+	runServer(config, addTuiSpan, signals, stop)
+}
+
+// newTuiRetention returns the retention configured by --max-spans,
+// --max-age, and the --spill flags.
+func newTuiRetention(config Config) *serverRetention {
+	maxAge, err := parseDuration(tuiServer.maxAge)
+	if err != nil {
+		config.SoftFail("invalid --max-age: %s", err)
+	}
+
+	var spill *spillRing
+	if tuiServer.spillDir != "" {
+		spill, err = newSpillRing(tuiServer.spillDir, int64(tuiServer.spillMaxMb)<<20)
+		if err != nil {
+			config.SoftFail("invalid --spill-dir: %s", err)
+		}
+	}
+
+	return newServerRetention(tuiServer.maxSpans, maxAge, spill)
 }
 
-// renderTui takes the given span and events, appends them to the in-memory
-// event list, sorts that, then prints it as a pterm table.
-func renderTui(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+// addTuiSpan takes the given span and events and adds them to the sorted
+// list of lines, which is redrawn by renderTuiLoop.
+func addTuiSpan(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+	service := otlpclient.ResourceAttributesToStringMap(rss)["service.name"]
+
+	tuiServer.mu.Lock()
+	defer tuiServer.mu.Unlock()
+
 	spanTraceId := hex.EncodeToString(span.TraceId)
 	if _, ok := tuiServer.traces[spanTraceId]; !ok {
 		tuiServer.traces[spanTraceId] = span
 	}
 
-	tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Span: span})
+	tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Span: span, Service: service})
 	for _, e := range events {
-		tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Span: span, Event: e})
+		tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Span: span, Event: e, Service: service})
 	}
 	sort.Sort(tuiServer.lines)
-	trimTuiEvents()
+
+	evicted, err := tuiServer.retention.add(span, rss, time.Now())
+	if err != nil {
+		log.Fatalf("failed to spill evicted spans: %s", err)
+	}
+	removeTuiTraces(evicted)
+
+	if tuiServer.paused {
+		tuiServer.missed += 1 + len(events)
+	}
+	tuiServer.dirty = true
+
+	return false // keep running until user hits ctrl-c
+}
+
+// removeTuiTraces drops the lines and top spans of the evicted traces.
+// Must be called with tuiServer.mu held.
+func removeTuiTraces(evicted []string) {
+	if len(evicted) == 0 {
+		return
+	}
+
+	gone := make(map[string]bool, len(evicted))
+	for _, tid := range evicted {
+		gone[tid] = true
+		delete(tuiServer.traces, tid)
+	}
+
+	kept := SpanEventUnionList{}
+	for _, line := range tuiServer.lines {
+		if !gone[line.TraceIdString()] {
+			kept = append(kept, line)
+		}
+	}
+	tuiServer.lines = kept
+	tuiServer.dirty = true
+}
+
+// renderTuiLoop redraws the screen when anything changed, at most once per
+// tuiRenderInterval, after evicting traces past --max-age.
+func renderTuiLoop() {
+	for now := range time.Tick(tuiRenderInterval) {
+		tuiServer.mu.Lock()
+		evicted, err := tuiServer.retention.expire(now)
+		if err != nil {
+			log.Fatalf("failed to spill evicted spans: %s", err)
+		}
+		removeTuiTraces(evicted)
+		if tuiServer.dirty {
+			tuiServer.area.Update(renderTuiScreen(pterm.GetTerminalHeight()))
+			tuiServer.dirty = false
+		}
+		tuiServer.mu.Unlock()
+	}
+}
+
+// renderTuiScreen returns the table of the lines matching the filter that
+// fit on a screen of the given height, followed by the status and prompt
+// lines. When paused, the lines from when it was paused are shown, scrolled
+// back by tuiServer.scroll rows. Must be called with tuiServer.mu held.
+func renderTuiScreen(height int) string {
+	if tuiServer.view == tuiViewLogs || tuiServer.view == tuiViewMetrics {
+		return renderTuiSignalScreen(height)
+	}
+
+	lines := tuiServer.lines
+	if tuiServer.paused {
+		lines = tuiServer.frozen
+	}
+
+	shown := SpanEventUnionList{}
+	for _, line := range lines {
+		if tuiServer.filter.match(line) {
+			shown = append(shown, line)
+		}
+	}
+
+	// leave room for the table header, the status line, and the prompt
+	rows := max(height-3, 1)
+	tuiServer.scroll = min(tuiServer.scroll, max(len(shown)-rows, 0))
+	end := len(shown) - tuiServer.scroll
+	start := max(end-rows, 0)
 
 	td := pterm.TableData{
 		{"Trace ID", "Span ID", "Parent", "Name", "Kind", "Start", "End", "Elapsed"},
 	}
+	for _, line := range shown[start:end] {
+		td = append(td, tuiRow(line))
+	}
+	table, err := pterm.DefaultTable.WithHasHeader().WithData(td).Srender()
+	if err != nil {
+		table = err.Error()
+	}
 
-	for _, line := range tuiServer.lines {
-		var traceId, spanId, parent, name, kind string
-		var startOffset, endOffset, elapsed int64
-		if line.IsSpan() {
-			name = line.Span.Name
-			kind = otlpclient.SpanKindIntToString(line.Span.GetKind())
-			traceId = line.TraceIdString()
-			spanId = line.SpanIdString()
-
-			if tspan, ok := tuiServer.traces[traceId]; ok {
-				startOffset = roundedDelta(line.Span.StartTimeUnixNano, tspan.StartTimeUnixNano)
-				endOffset = roundedDelta(line.Span.EndTimeUnixNano, tspan.StartTimeUnixNano)
-			} else {
-				endOffset = roundedDelta(line.Span.EndTimeUnixNano, line.Span.StartTimeUnixNano)
-			}
-
-			if len(line.Span.ParentSpanId) > 0 {
-				traceId = "" // hide it after printing the first trace id
-				parent = hex.EncodeToString(line.Span.ParentSpanId)
-			}
-
-			elapsed = endOffset - startOffset
-		} else { // span events
-			name = line.Event.Name
-			kind = "event"
-			traceId = "" // hide ids on events to make screen less busy
-			parent = line.SpanIdString()
-			if tspan, ok := tuiServer.traces[traceId]; ok {
-				startOffset = roundedDelta(line.Event.TimeUnixNano, tspan.StartTimeUnixNano)
-			} else {
-				startOffset = roundedDelta(line.Event.TimeUnixNano, line.Span.StartTimeUnixNano)
-			}
-			endOffset = startOffset
-			elapsed = 0
+	status := "LIVE"
+	if tuiServer.paused {
+		status = fmt.Sprintf("PAUSED, %d new", tuiServer.missed)
+	}
+	status += fmt.Sprintf(" | showing %d of %d spans and events", len(shown), len(lines))
+	if tuiServer.query != "" {
+		status += " | filter: " + tuiServer.query
+	}
+
+	prompt := "/ filter  p pause  arrows/PgUp/PgDn scroll  c clear filter  l logs  m metrics  q quit"
+	if tuiServer.editing {
+		prompt = "/" + tuiServer.input
+		if tuiServer.inputErr != "" {
+			prompt += "  (" + tuiServer.inputErr + ")"
 		}
+	}
 
-		td = append(td, []string{
-			traceId,
-			spanId,
-			parent,
-			name,
-			kind,
-			strconv.FormatInt(startOffset, 10),
-			strconv.FormatInt(endOffset, 10),
-			strconv.FormatInt(elapsed, 10),
-		})
+	return table + "\n" + status + "\n" + prompt
+}
+
+// tuiRow returns the table columns for a span or event.
+func tuiRow(line SpanEventUnion) []string {
+	var traceId, spanId, parent, name, kind string
+	var startOffset, endOffset, elapsed int64
+	if line.IsSpan() {
+		name = line.Span.Name
+		kind = otlpclient.SpanKindIntToString(line.Span.GetKind())
+		traceId = line.TraceIdString()
+		spanId = line.SpanIdString()
+
+		if tspan, ok := tuiServer.traces[traceId]; ok {
+			startOffset = roundedDelta(line.Span.StartTimeUnixNano, tspan.StartTimeUnixNano)
+			endOffset = roundedDelta(line.Span.EndTimeUnixNano, tspan.StartTimeUnixNano)
+		} else {
+			endOffset = roundedDelta(line.Span.EndTimeUnixNano, line.Span.StartTimeUnixNano)
+		}
+
+		if len(line.Span.ParentSpanId) > 0 {
+			traceId = "" // hide it after printing the first trace id
+			parent = hex.EncodeToString(line.Span.ParentSpanId)
+		}
+
+		elapsed = endOffset - startOffset
+	} else { // span events
+		name = line.Event.Name
+		kind = "event"
+		traceId = "" // hide ids on events to make screen less busy
+		parent = line.SpanIdString()
+		if tspan, ok := tuiServer.traces[traceId]; ok {
+			startOffset = roundedDelta(line.Event.TimeUnixNano, tspan.StartTimeUnixNano)
+		} else {
+			startOffset = roundedDelta(line.Event.TimeUnixNano, line.Span.StartTimeUnixNano)
+		}
+		endOffset = startOffset
+		elapsed = 0
 	}
 
-	tuiServer.area.Update(pterm.DefaultTable.WithHasHeader().WithData(td).Srender())
-	return false // keep running until user hits ctrl-c
+	return []string{
+		traceId,
+		spanId,
+		parent,
+		name,
+		kind,
+		strconv.FormatInt(startOffset, 10),
+		strconv.FormatInt(endOffset, 10),
+		strconv.FormatInt(elapsed, 10),
+	}
 }
 
-// roundedDelta takes to uint64 nanos values, cuts them down to milliseconds,
-// takes the delta (absolute value, so any order is fine), and returns an int64
-// of ms between the values.
-func roundedDelta(ts1, ts2 uint64) int64 {
-	deltaMs := math.Abs(float64(ts1/1000000) - float64(ts2/1000000))
-	rounded := math.Round(deltaMs)
-	return int64(rounded)
+// listenTuiKeys handles key presses until q or ctrl-c, then exits since the
+// terminal is in raw mode and ctrl-c doesn't send a signal.
+func listenTuiKeys() {
+	err := keyboard.Listen(func(key keys.Key) (bool, error) {
+		tuiServer.mu.Lock()
+		defer tuiServer.mu.Unlock()
+		tuiServer.dirty = true
+
+		if key.Code == keys.CtrlC {
+			return true, nil
+		} else if tuiServer.editing {
+			handleTuiEditKey(key)
+			return false, nil
+		}
+		return handleTuiKey(key), nil
+	})
+	if err != nil {
+		return // no keyboard, keep displaying spans
+	}
+
+	tuiServer.mu.Lock()
+	tuiServer.area.Stop()
+	os.Exit(0)
 }
 
-// trimEvents looks to see if there's room on the screen for the number of incoming
-// events and removes the oldest traces until there's room
-// TODO: how to hand really huge traces that would scroll off the screen entirely?
-func trimTuiEvents() {
-	maxRows := pterm.GetTerminalHeight() // TODO: allow override of this?
+// handleTuiKey handles a key press while not typing a filter, returning true
+// to quit. Must be called with tuiServer.mu held.
+func handleTuiKey(key keys.Key) bool {
+	page := max(pterm.GetTerminalHeight()-3, 1)
 
-	if len(tuiServer.lines) == 0 || len(tuiServer.lines) < maxRows {
-		return // plenty of room, nothing to do
+	switch key.Code {
+	case keys.Space:
+		setTuiPaused(!tuiServer.paused)
+	case keys.Up:
+		scrollTui(1)
+	case keys.Down:
+		scrollTui(-1)
+	case keys.PgUp:
+		scrollTui(page)
+	case keys.PgDown:
+		scrollTui(-page)
+	case keys.Home:
+		scrollTui(len(tuiServer.lines)) // clamped when rendering
+	case keys.End:
+		setTuiPaused(false)
+	case keys.RuneKey:
+		switch string(key.Runes) {
+		case "q":
+			return true
+		case "/":
+			tuiServer.editing = true
+			tuiServer.prevQuery = tuiServer.query
+			tuiServer.input = tuiServer.query
+			tuiServer.inputErr = ""
+		case "p", " ":
+			setTuiPaused(!tuiServer.paused)
+		case "c":
+			setTuiQuery("")
+		case "k":
+			scrollTui(1)
+		case "j":
+			scrollTui(-1)
+		case "t":
+			tuiServer.view = tuiViewTraces
+		case "l":
+			tuiServer.view = tuiViewLogs
+		case "m":
+			tuiServer.view = tuiViewMetrics
+		}
 	}
 
-	end := len(tuiServer.lines) - 1              // should never happen but default to all
-	need := (len(tuiServer.lines) - maxRows) + 2 // trim at least this many
-	tid := tuiServer.lines[0].TraceIdString()    // we always remove the whole trace
-	for i, v := range tuiServer.lines {
-		if v.TraceIdString() == tid {
-			end = i
-		} else {
-			if end+1 < need {
-				// trace id changed, advance the trim point, and change trace ids
-				tid = v.TraceIdString()
-				end = i
-			} else {
-				break // made enough room, we can quit early
-			}
+	return false
+}
+
+// handleTuiEditKey handles a key press while typing a filter, which is
+// applied as soon as it parses. Must be called with tuiServer.mu held.
+func handleTuiEditKey(key keys.Key) {
+	switch key.Code {
+	case keys.Enter:
+		tuiServer.editing = false
+	case keys.Esc:
+		tuiServer.editing = false
+		setTuiQuery(tuiServer.prevQuery)
+	case keys.Backspace, keys.CtrlH:
+		if runes := []rune(tuiServer.input); len(runes) > 0 {
+			setTuiQuery(string(runes[:len(runes)-1]))
+		}
+	case keys.Space:
+		setTuiQuery(tuiServer.input + " ")
+	case keys.RuneKey:
+		setTuiQuery(tuiServer.input + string(key.Runes))
+	}
+}
+
+// setTuiQuery sets the typed input and applies it as the filter if it's
+// valid, jumping back to the newest lines. Must be called with
+// tuiServer.mu held.
+func setTuiQuery(query string) {
+	tuiServer.input = query
+	filter, err := parseTuiFilter(query)
+	if err != nil {
+		tuiServer.inputErr = err.Error()
+		return
+	}
+
+	tuiServer.inputErr = ""
+	tuiServer.query = query
+	tuiServer.filter = filter
+	tuiServer.scroll = 0
+}
+
+// setTuiPaused pauses or resumes the display. While paused, the lines from
+// when it was paused are shown so they hold still for scrolling. Must be
+// called with tuiServer.mu held.
+func setTuiPaused(paused bool) {
+	tuiServer.paused = paused
+	tuiServer.scroll = 0
+	tuiServer.missed = 0
+	if paused {
+		tuiServer.frozen = append(SpanEventUnionList{}, tuiServer.lines...)
+	} else {
+		tuiServer.frozen = nil
+	}
+}
+
+// scrollTui scrolls back by rows, or forward when negative, pausing the
+// display if it's live. Must be called with tuiServer.mu held.
+func scrollTui(rows int) {
+	if !tuiServer.paused {
+		if rows < 0 {
+			return // already at the newest
 		}
+		setTuiPaused(true)
 	}
+	tuiServer.scroll = max(tuiServer.scroll+rows, 0)
+}
 
-	// might need to realloc to not leak memory here?
-	tuiServer.lines = tuiServer.lines[end:]
+// roundedDelta takes to uint64 nanos values, cuts them down to milliseconds,
+// takes the delta (absolute value, so any order is fine), and returns an int64
+// of ms between the values.
+func roundedDelta(ts1, ts2 uint64) int64 {
+	deltaMs := math.Abs(float64(ts1/1000000) - float64(ts2/1000000))
+	rounded := math.Round(deltaMs)
+	return int64(rounded)
 }
 
 // SpanEventUnion is for server_tui so it can sort spans and events together
 // by timestamp.
 type SpanEventUnion struct {
-	Span  *tracepb.Span
-	Event *tracepb.Span_Event
+	Span    *tracepb.Span
+	Event   *tracepb.Span_Event
+	Service string // service.name from the span's resource, for filtering
 }
 
 func (seu *SpanEventUnion) TraceIdString() string { return hex.EncodeToString(seu.Span.TraceId) }
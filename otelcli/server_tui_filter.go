@@ -0,0 +1,91 @@
+package otelcli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// tuiFilter is a parsed server tui filter query. A span is shown when it
+// matches every term, and events are shown when their span is.
+type tuiFilter struct {
+	service string
+	names   []string // lowercased span name substrings
+	attrs   map[string]string
+	status  string
+}
+
+// parseTuiFilter parses a filter query of whitespace-separated terms:
+// service:NAME matches the service name, status:ok|error|unset matches the
+// span status, key=value matches a span attribute, and anything else,
+// optionally prefixed with name:, is a case-insensitive span name substring.
+func parseTuiFilter(query string) (tuiFilter, error) {
+	filter := tuiFilter{attrs: map[string]string{}}
+
+	for _, term := range strings.Fields(query) {
+		if service, ok := strings.CutPrefix(term, "service:"); ok {
+			filter.service = service
+		} else if status, ok := strings.CutPrefix(term, "status:"); ok {
+			status = strings.ToLower(status)
+			if status != "ok" && status != "error" && status != "unset" {
+				return filter, fmt.Errorf("invalid status %q, must be ok, error, or unset", status)
+			}
+			filter.status = status
+		} else if name, ok := strings.CutPrefix(term, "name:"); ok {
+			filter.names = append(filter.names, strings.ToLower(name))
+		} else if key, value, ok := strings.Cut(term, "="); ok && key != "" {
+			filter.attrs[key] = value
+		} else {
+			filter.names = append(filter.names, strings.ToLower(term))
+		}
+	}
+
+	return filter, nil
+}
+
+// match returns true if the line's span matches all of the filter's terms.
+// Spans without a status count as unset.
+func (f tuiFilter) match(line SpanEventUnion) bool {
+	span := line.Span
+
+	if f.service != "" && line.Service != f.service {
+		return false
+	}
+
+	if f.status != "" && span.GetStatus().GetCode() != otlpclient.SpanStatusStringToInt(f.status) {
+		return false
+	}
+
+	name := strings.ToLower(span.Name)
+	for _, want := range f.names {
+		if !strings.Contains(name, want) {
+			return false
+		}
+	}
+
+	for key, want := range f.attrs {
+		if !hasAttrValue(span.Attributes, key, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasAttrValue returns true if the attributes have the key with a value that
+// prints as want.
+func hasAttrValue(attrs []*commonpb.KeyValue, key, want string) bool {
+	for _, attr := range attrs {
+		if attr.Key != key {
+			continue
+		}
+		if b, ok := attr.GetValue().GetValue().(*commonpb.AnyValue_BoolValue); ok {
+			return strconv.FormatBool(b.BoolValue) == want
+		}
+		return otlpclient.AttrValueToString(attr) == want
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package otelcli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"atomicgo.dev/keyboard/keys"
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestTuiFilter(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.Name = "Deploy Frontend"
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{
+		"env":    "prod",
+		"canary": "true",
+		"shard":  "3",
+	})
+	span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+	line := SpanEventUnion{Span: span, Service: "deployer"}
+
+	for _, tc := range []struct {
+		query string
+		match bool
+	}{
+		{"", true},
+		{"deploy", true},
+		{"name:FRONT deploy", true},
+		{"backend", false},
+		{"service:deployer", true},
+		{"service:other", false},
+		{"status:error", true},
+		{"status:OK", false},
+		{"env=prod canary=true shard=3", true},
+		{"env=dev", false},
+		{"region=us", false},
+		{"service:deployer status:error env=prod front", true},
+	} {
+		filter, err := parseTuiFilter(tc.query)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", tc.query, err)
+		}
+		if got := filter.match(line); got != tc.match {
+			t.Errorf("expected match to be %t for %q but got %t", tc.match, tc.query, got)
+		}
+	}
+
+	// spans without a status are unset
+	unset := SpanEventUnion{Span: &tracepb.Span{Name: "x"}}
+	filter, _ := parseTuiFilter("status:unset")
+	if !filter.match(unset) {
+		t.Errorf("expected a span without a status to match status:unset")
+	}
+
+	if _, err := parseTuiFilter("status:bogus"); err == nil {
+		t.Errorf("expected an error for an invalid status")
+	}
+}
+
+func TestRenderTuiScreen(t *testing.T) {
+	tuiServer.lines = SpanEventUnionList{}
+	tuiServer.traces = map[string]*tracepb.Span{}
+	tuiServer.retention = newServerRetention(tuiDefaultMaxSpans, 0, nil)
+	tuiServer.paused = false
+	setTuiQuery("")
+	defer setTuiQuery("")
+
+	addSpan := func(name string, start uint64) {
+		span := otlpclient.NewProtobufSpan()
+		span.Name = name
+		span.StartTimeUnixNano = start
+		span.EndTimeUnixNano = start
+		addTuiSpan(context.Background(), span, nil, nil, nil, nil)
+	}
+	for i, name := range []string{"one", "two", "three", "four", "five"} {
+		addSpan(name, uint64(i+1)*1000000)
+	}
+
+	// 3 rows of the table fit on a screen of 6 lines
+	screen := renderTuiScreen(6)
+	if !strings.Contains(screen, "five") || strings.Contains(screen, "two") {
+		t.Errorf("expected the newest spans to be shown but got:\n%s", screen)
+	}
+
+	handleTuiKey(keys.Key{Code: keys.RuneKey, Runes: []rune{'p'}})
+	addSpan("six", 6000000)
+	scrollTui(2)
+	screen = renderTuiScreen(6)
+	if !strings.Contains(screen, "one") || strings.Contains(screen, "six") || !strings.Contains(screen, "PAUSED, 1 new") {
+		t.Errorf("expected the paused lines scrolled back but got:\n%s", screen)
+	}
+
+	setTuiPaused(false)
+	setTuiQuery("s")
+	screen = renderTuiScreen(10)
+	if !strings.Contains(screen, "six") || strings.Contains(screen, "five") || !strings.Contains(screen, "showing 1 of 6") {
+		t.Errorf("expected only the spans matching the filter but got:\n%s", screen)
+	}
+}
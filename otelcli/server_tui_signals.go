@@ -0,0 +1,153 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// tuiView is which signal the tui is showing.
+type tuiView string
+
+const (
+	tuiViewTraces  tuiView = "traces"
+	tuiViewLogs    tuiView = "logs"
+	tuiViewMetrics tuiView = "metrics"
+)
+
+// tuiLog is a received log record and the service it came from.
+type tuiLog struct {
+	record  *logspb.LogRecord
+	service string
+}
+
+// tuiMetric is a received metric and the service it came from.
+type tuiMetric struct {
+	metric  *metricspb.Metric
+	service string
+}
+
+// addTuiLog adds the log record to the logs view, dropping the oldest past
+// --max-spans.
+func addTuiLog(ctx context.Context, record *logspb.LogRecord, rl *logspb.ResourceLogs, headers map[string]string, meta map[string]string) bool {
+	service := resourceServiceName(rl.GetResource())
+
+	tuiServer.mu.Lock()
+	defer tuiServer.mu.Unlock()
+
+	tuiServer.logs = append(tuiServer.logs, tuiLog{record: record, service: service})
+	if limit := tuiServer.maxSpans; limit > 0 && len(tuiServer.logs) > limit {
+		tuiServer.logs = append([]tuiLog{}, tuiServer.logs[len(tuiServer.logs)-limit:]...)
+	}
+	tuiServer.dirty = true
+
+	return false
+}
+
+// addTuiMetric adds the metric to the metrics view, dropping the oldest past
+// --max-spans.
+func addTuiMetric(ctx context.Context, metric *metricspb.Metric, rm *metricspb.ResourceMetrics, headers map[string]string, meta map[string]string) bool {
+	service := resourceServiceName(rm.GetResource())
+
+	tuiServer.mu.Lock()
+	defer tuiServer.mu.Unlock()
+
+	tuiServer.metrics = append(tuiServer.metrics, tuiMetric{metric: metric, service: service})
+	if limit := tuiServer.maxSpans; limit > 0 && len(tuiServer.metrics) > limit {
+		tuiServer.metrics = append([]tuiMetric{}, tuiServer.metrics[len(tuiServer.metrics)-limit:]...)
+	}
+	tuiServer.dirty = true
+
+	return false
+}
+
+// renderTuiSignalScreen returns the table of the newest log records or
+// metrics that fit on a screen of the given height, followed by the status
+// and prompt lines. Must be called with tuiServer.mu held.
+func renderTuiSignalScreen(height int) string {
+	// leave room for the table header, the status line, and the prompt
+	rows := max(height-3, 1)
+
+	var td pterm.TableData
+	var count int
+	if tuiServer.view == tuiViewLogs {
+		count = len(tuiServer.logs)
+		td = pterm.TableData{{"Time", "Service", "Severity", "Body", "Trace ID", "Span ID"}}
+		for _, l := range tuiServer.logs[max(count-rows, 0):] {
+			td = append(td, tuiLogRow(l))
+		}
+	} else {
+		count = len(tuiServer.metrics)
+		td = pterm.TableData{{"Name", "Service", "Type", "Unit", "Points", "Value"}}
+		for _, m := range tuiServer.metrics[max(count-rows, 0):] {
+			td = append(td, tuiMetricRow(m))
+		}
+	}
+
+	table, err := pterm.DefaultTable.WithHasHeader().WithData(td).Srender()
+	if err != nil {
+		table = err.Error()
+	}
+	status := fmt.Sprintf("%s | %d received", strings.ToUpper(string(tuiServer.view)), count)
+	prompt := "t traces  l logs  m metrics  q quit"
+
+	return table + "\n" + status + "\n" + prompt
+}
+
+// tuiLogRow returns the table columns for a log record.
+func tuiLogRow(l tuiLog) []string {
+	ts := l.record.TimeUnixNano
+	if ts == 0 {
+		ts = l.record.ObservedTimeUnixNano
+	}
+	severity := l.record.SeverityText
+	if severity == "" {
+		severity = strings.TrimPrefix(l.record.SeverityNumber.String(), "SEVERITY_NUMBER_")
+	}
+
+	return []string{
+		time.Unix(0, int64(ts)).Format("15:04:05.000"),
+		l.service,
+		severity,
+		anyValueString(l.record.Body),
+		hex.EncodeToString(l.record.TraceId),
+		hex.EncodeToString(l.record.SpanId),
+	}
+}
+
+// tuiMetricRow returns the table columns for a metric.
+func tuiMetricRow(m tuiMetric) []string {
+	kind, value := metricSummary(m.metric)
+	return []string{
+		m.metric.Name,
+		m.service,
+		kind,
+		m.metric.Unit,
+		strconv.Itoa(metricDataPointCount(m.metric)),
+		value,
+	}
+}
+
+// metricDataPointCount returns how many data points the metric has.
+func metricDataPointCount(metric *metricspb.Metric) int {
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		return len(data.Gauge.GetDataPoints())
+	case *metricspb.Metric_Sum:
+		return len(data.Sum.GetDataPoints())
+	case *metricspb.Metric_Histogram:
+		return len(data.Histogram.GetDataPoints())
+	case *metricspb.Metric_ExponentialHistogram:
+		return len(data.ExponentialHistogram.GetDataPoints())
+	case *metricspb.Metric_Summary:
+		return len(data.Summary.GetDataPoints())
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// signalsTestData returns a logs and a metrics request from the api service.
+func signalsTestData() (*collogspb.ExportLogsServiceRequest, *colmetricspb.ExportMetricsServiceRequest) {
+	resource := &resourcepb.Resource{
+		Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": "api"}),
+	}
+	logs := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				LogRecords: []*logspb.LogRecord{{
+					TimeUnixNano:   uint64(time.Now().UnixNano()),
+					SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+					Body:           &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "disk full"}},
+				}},
+			}},
+		}},
+	}
+	metrics := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: resource,
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{{
+					Name: "queue.depth",
+					Unit: "{items}",
+					Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+						DataPoints: []*metricspb.NumberDataPoint{
+							{Value: &metricspb.NumberDataPoint_AsInt{AsInt: 41}},
+							{Value: &metricspb.NumberDataPoint_AsInt{AsInt: 42}},
+						},
+					}},
+				}},
+			}},
+		}},
+	}
+	return logs, metrics
+}
+
+// resetTuiSignals clears the tui's logs and metrics.
+func resetTuiSignals() {
+	tuiServer.mu.Lock()
+	defer tuiServer.mu.Unlock()
+	tuiServer.logs = nil
+	tuiServer.metrics = nil
+	tuiServer.maxSpans = tuiDefaultMaxSpans
+}
+
+// checkTuiSignals checks that the log record and metric from
+// signalsTestData show up in the tui's logs and metrics views.
+func checkTuiSignals(t *testing.T) {
+	tuiServer.mu.Lock()
+	defer tuiServer.mu.Unlock()
+
+	tuiServer.view = tuiViewLogs
+	screen := renderTuiScreen(10)
+	for _, want := range []string{"api", "ERROR", "disk full", "LOGS | 1 received"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected the logs view to contain %q but got:\n%s", want, screen)
+		}
+	}
+
+	tuiServer.view = tuiViewMetrics
+	screen = renderTuiScreen(10)
+	for _, want := range []string{"queue.depth", "gauge", "{items}", "42", "METRICS | 1 received"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected the metrics view to contain %q but got:\n%s", want, screen)
+		}
+	}
+	tuiServer.view = tuiViewTraces
+}
+
+func TestServerSignalsGrpc(t *testing.T) {
+	resetTuiSignals()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	server := otlpserver.NewGrpcServer(addTuiSpan, func(otlpserver.OtlpServer) {})
+	server.SetSignalCallbacks(otlpserver.SignalCallbacks{Logs: addTuiLog, Metrics: addTuiMetric})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	logs, metrics := signalsTestData()
+	if _, err := collogspb.NewLogsServiceClient(conn).Export(ctx, logs); err != nil {
+		t.Fatalf("failed to export logs: %s", err)
+	}
+	if _, err := colmetricspb.NewMetricsServiceClient(conn).Export(ctx, metrics); err != nil {
+		t.Fatalf("failed to export metrics: %s", err)
+	}
+
+	checkTuiSignals(t)
+}
+
+func TestServerSignalsHttp(t *testing.T) {
+	resetTuiSignals()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	server := otlpserver.NewHttpServer(addTuiSpan, func(otlpserver.OtlpServer) {})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	logs, metrics := signalsTestData()
+	send := func(path string, js []byte) int {
+		resp, err := http.Post("http://"+listener.Addr().String()+path, "application/json", bytes.NewReader(js))
+		if err != nil {
+			t.Fatalf("failed to post to %s: %s", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	logsJs, _ := otlpclient.MarshalOtlpJson(logs)
+	metricsJs, _ := otlpclient.MarshalOtlpJson(metrics)
+
+	// without callbacks, logs and metrics are rejected like a collector would
+	if code := send("/v1/logs", logsJs); code != http.StatusNotFound {
+		t.Errorf("expected a 404 for logs without a callback but got %d", code)
+	}
+
+	server.SetSignalCallbacks(otlpserver.SignalCallbacks{Logs: addTuiLog, Metrics: addTuiMetric})
+	if code := send("/v1/logs", logsJs); code != http.StatusOK {
+		t.Errorf("expected logs to be accepted but got %d", code)
+	}
+	if code := send("/v1/metrics", metricsJs); code != http.StatusOK {
+		t.Errorf("expected metrics to be accepted but got %d", code)
+	}
+
+	checkTuiSignals(t)
+}
@@ -0,0 +1,278 @@
+package otelcli
+
+import (
+	"context"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	"github.com/spf13/cobra"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+//go:embed web/index.html
+var webIndexHtml []byte
+
+// webSvr holds the command-line configured settings and the traces shown by
+// otel-cli server web. retention is guarded by mu.
+var webSvr struct {
+	listen   string
+	maxSpans int
+	maxAge   string
+
+	mu        sync.Mutex
+	retention *serverRetention
+}
+
+// webTraceSummary is a trace in the list of traces.
+type webTraceSummary struct {
+	TraceId  string  `json:"trace_id"`
+	Name     string  `json:"name"`
+	Service  string  `json:"service"`
+	Spans    int     `json:"spans"`
+	Errors   int     `json:"errors"`
+	Start    float64 `json:"start"`    // unix epoch milliseconds
+	Duration float64 `json:"duration"` // milliseconds
+}
+
+// webSpan is a span in the waterfall, with times in milliseconds from the
+// start of the trace.
+type webSpan struct {
+	SpanId        string            `json:"span_id"`
+	ParentSpanId  string            `json:"parent_span_id"`
+	Name          string            `json:"name"`
+	Service       string            `json:"service"`
+	Kind          string            `json:"kind"`
+	Status        string            `json:"status"`
+	StatusMessage string            `json:"status_message"`
+	Offset        float64           `json:"offset"`
+	Duration      float64           `json:"duration"`
+	Depth         int               `json:"depth"`
+	Attributes    map[string]string `json:"attributes"`
+	Resource      map[string]string `json:"resource"`
+	Events        []webSpanEvent    `json:"events"`
+}
+
+// webSpanEvent is a span event, offset in milliseconds from the start of
+// the trace.
+type webSpanEvent struct {
+	Name       string            `json:"name"`
+	Offset     float64           `json:"offset"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func serverWebCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "web",
+		Short: "display traces in a web browser",
+		Long: `Run otel-cli as an OTLP server with a web UI on --listen that groups the
+received spans into traces and shows each one as a waterfall. Click a span to
+inspect its attributes, resource, and events.
+
+The newest --max-spans spans are kept, evicting the oldest traces first, and
+with --max-age traces that haven't had a new span in that long are dropped.
+
+Example:
+	otel-cli server web --listen localhost:8080
+`,
+		Run: doServerWeb,
+	}
+
+	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
+	cmd.Flags().StringVar(&webSvr.listen, "listen", "localhost:8080", "serve the web UI on this host:port")
+	cmd.Flags().IntVar(&webSvr.maxSpans, "max-spans", tuiDefaultMaxSpans, "keep at most this many spans, evicting the oldest traces first")
+	cmd.Flags().StringVar(&webSvr.maxAge, "max-age", "", "evict traces that haven't had a new span in this long, e.g. 1h")
+
+	return &cmd
+}
+
+func doServerWeb(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	maxAge, err := parseDuration(webSvr.maxAge)
+	if err != nil {
+		config.SoftFail("invalid --max-age: %s", err)
+	}
+	webSvr.retention = newServerRetention(webSvr.maxSpans, maxAge, nil)
+	if maxAge > 0 {
+		go expireWebTraces(maxAge / 10)
+	}
+
+	// the UI shows everything that's received, so it gets the same
+	// --allow-ips, TLS, and auth as the OTLP listeners
+	listener, err := listenServer(config, webSvr.listen)
+	if err != nil {
+		config.SoftFail("failed to listen on %q: %s", webSvr.listen, err)
+	}
+	hs := &http.Server{Handler: serverAuthHandler(config, webHandler())}
+	go hs.Serve(listener)
+	defer hs.Close()
+	scheme := "http"
+	if config.ServerTlsCert != "" {
+		scheme = "https"
+	}
+	config.SoftLog("serving the web UI on %s://%s", scheme, listener.Addr())
+
+	runServer(config, addWebSpan, otlpserver.SignalCallbacks{}, func(otlpserver.OtlpServer) {})
+}
+
+// addWebSpan keeps the span for the web UI.
+func addWebSpan(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+	webSvr.mu.Lock()
+	defer webSvr.mu.Unlock()
+	webSvr.retention.add(span, rss, time.Now()) // only spilling fails
+	return false
+}
+
+// expireWebTraces evicts traces past --max-age every interval.
+func expireWebTraces(interval time.Duration) {
+	for now := range time.Tick(interval) {
+		webSvr.mu.Lock()
+		webSvr.retention.expire(now) // only spilling fails
+		webSvr.mu.Unlock()
+	}
+}
+
+// webHandler returns the handler for the web UI and its JSON API.
+func webHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(rw, req)
+			return
+		}
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write(webIndexHtml)
+	})
+	mux.HandleFunc("/api/traces", func(rw http.ResponseWriter, req *http.Request) {
+		webSvr.mu.Lock()
+		defer webSvr.mu.Unlock()
+		writeWebJson(rw, webTraceSummaries())
+	})
+	mux.HandleFunc("/api/traces/", func(rw http.ResponseWriter, req *http.Request) {
+		webSvr.mu.Lock()
+		defer webSvr.mu.Unlock()
+		trace, ok := webSvr.retention.traces[strings.TrimPrefix(req.URL.Path, "/api/traces/")]
+		if !ok {
+			http.NotFound(rw, req)
+			return
+		}
+		writeWebJson(rw, webTraceSpans(trace))
+	})
+
+	return mux
+}
+
+// writeWebJson writes the value as a JSON response.
+func writeWebJson(rw http.ResponseWriter, value interface{}) {
+	js, err := json.Marshal(value)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(js)
+}
+
+// webTraceSummaries returns every trace, newest first. Must be called with
+// webSvr.mu held.
+func webTraceSummaries() []webTraceSummary {
+	out := []webTraceSummary{}
+	for i := len(webSvr.retention.order) - 1; i >= 0; i-- {
+		trace := webSvr.retention.order[i]
+		start, end := traceBounds(trace.spans)
+		summary := webTraceSummary{
+			TraceId:  trace.id,
+			Spans:    len(trace.spans),
+			Start:    float64(start) / 1e6,
+			Duration: float64(end-start) / 1e6,
+		}
+
+		// name the trace after its root span, or the earliest span until
+		// the root arrives
+		var top *retainedSpan
+		for j, s := range trace.spans {
+			if s.span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+				summary.Errors++
+			}
+			if top == nil || len(s.span.ParentSpanId) == 0 ||
+				(len(top.span.ParentSpanId) > 0 && s.span.StartTimeUnixNano < top.span.StartTimeUnixNano) {
+				top = &trace.spans[j]
+			}
+		}
+		if top != nil {
+			summary.Name = top.span.Name
+			summary.Service = otlpclient.ResourceAttributesToStringMap(top.rss)["service.name"]
+		}
+
+		out = append(out, summary)
+	}
+
+	return out
+}
+
+// webTraceSpans returns the trace's spans in waterfall order: each span
+// followed by its children, ordered by start time. Spans whose parent
+// hasn't been received are shown at the top level.
+func webTraceSpans(trace *retainedTrace) []webSpan {
+	start, _ := traceBounds(trace.spans)
+	offset := func(ts uint64) float64 {
+		if ts < start {
+			return 0
+		}
+		return float64(ts-start) / 1e6
+	}
+
+	out := []webSpan{}
+	for _, s := range traceTree(trace.spans) {
+		out = append(out, newWebSpan(s.retainedSpan, s.depth, offset))
+	}
+
+	return out
+}
+
+// newWebSpan returns the span for the waterfall, using offset to convert
+// timestamps to milliseconds from the start of the trace.
+func newWebSpan(s retainedSpan, depth int, offset func(uint64) float64) webSpan {
+	ws := webSpan{
+		SpanId:        hex.EncodeToString(s.span.SpanId),
+		ParentSpanId:  hex.EncodeToString(s.span.ParentSpanId),
+		Name:          s.span.Name,
+		Service:       otlpclient.ResourceAttributesToStringMap(s.rss)["service.name"],
+		Kind:          otlpclient.SpanKindIntToString(s.span.GetKind()),
+		Status:        spanStatusString(s.span),
+		StatusMessage: s.span.GetStatus().GetMessage(),
+		Offset:        offset(s.span.StartTimeUnixNano),
+		Duration:      offset(max(s.span.EndTimeUnixNano, s.span.StartTimeUnixNano)) - offset(s.span.StartTimeUnixNano),
+		Depth:         depth,
+		Attributes:    attrsToStringMap(s.span.Attributes),
+		Resource:      attrsToStringMap(s.rss.GetResource().GetAttributes()),
+		Events:        []webSpanEvent{},
+	}
+	for _, e := range s.span.Events {
+		ws.Events = append(ws.Events, webSpanEvent{
+			Name:       e.Name,
+			Offset:     offset(e.TimeUnixNano),
+			Attributes: attrsToStringMap(e.Attributes),
+		})
+	}
+
+	return ws
+}
+
+// attrsToStringMap returns the attributes as a map of key to value as text.
+func attrsToStringMap(attrs []*commonpb.KeyValue) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		out[attr.Key] = anyValueString(attr.Value)
+	}
+	return out
+}
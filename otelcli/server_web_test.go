@@ -0,0 +1,92 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/google/go-cmp/cmp"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestServerWeb(t *testing.T) {
+	webSvr.retention = newServerRetention(tuiDefaultMaxSpans, 0, nil)
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": "api"}),
+		},
+	}
+
+	// a root with two children, received out of order, and a span whose
+	// parent never arrived
+	traceId := otlpclient.GenerateTraceId()
+	newSpan := func(name string, parent []byte, start, end uint64) *tracepb.Span {
+		span := otlpclient.NewProtobufSpan()
+		span.TraceId = traceId
+		span.SpanId = otlpclient.GenerateSpanId()
+		span.Name = name
+		span.ParentSpanId = parent
+		span.StartTimeUnixNano = start
+		span.EndTimeUnixNano = end
+		return span
+	}
+	root := newSpan("root", nil, 1000000000, 1010000000)
+	second := newSpan("second", root.SpanId, 1005000000, 1010000000)
+	second.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+	first := newSpan("first", root.SpanId, 1001000000, 1004000000)
+	orphan := newSpan("orphan", otlpclient.GenerateSpanId(), 1002000000, 1003000000)
+	for _, span := range []*tracepb.Span{second, root, orphan, first} {
+		addWebSpan(context.Background(), span, nil, rss, nil, nil)
+	}
+
+	handler := webHandler()
+	get := func(path string, into interface{}) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if into != nil && rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), into); err != nil {
+				t.Fatalf("failed to parse %s: %s", path, err)
+			}
+		}
+		return rec.Code
+	}
+
+	summaries := []webTraceSummary{}
+	get("/api/traces", &summaries)
+	if len(summaries) != 1 {
+		t.Fatalf("expected 1 trace but got %d", len(summaries))
+	}
+	if s := summaries[0]; s.Name != "root" || s.Service != "api" || s.Spans != 4 || s.Errors != 1 || s.Duration != 10 {
+		t.Errorf("unexpected trace summary: %+v", s)
+	}
+
+	spans := []webSpan{}
+	if code := get("/api/traces/"+hex.EncodeToString(traceId), &spans); code != http.StatusOK {
+		t.Fatalf("expected the trace to be found but got %d", code)
+	}
+	got := []string{}
+	for _, s := range spans {
+		got = append(got, strings.Repeat("  ", s.Depth)+s.Name)
+	}
+	if diff := cmp.Diff([]string{"root", "  first", "  second", "orphan"}, got); diff != "" {
+		t.Errorf("unexpected waterfall (-want +got):\n%s", diff)
+	}
+	if spans[1].Offset != 1 || spans[1].Duration != 3 || spans[2].Status != "error" {
+		t.Errorf("unexpected span timing or status: %+v", spans[1:3])
+	}
+
+	if code := get("/api/traces/nope", nil); code != http.StatusNotFound {
+		t.Errorf("expected a 404 for an unknown trace but got %d", code)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), "<title>otel-cli server web</title>") {
+		t.Error("expected the index page to be served")
+	}
+}
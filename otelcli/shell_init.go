@@ -0,0 +1,343 @@
+package otelcli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// shellInit holds the command-line configured settings for otel-cli shell-init.
+var shellInit struct {
+	commands    bool
+	session     bool
+	sessionName string
+}
+
+// shellInitFlags are shell-init's own flags, which aren't passed on to the
+// otel-cli commands in the script.
+var shellInitFlags = map[string]bool{
+	"commands":     true,
+	"session":      true,
+	"session-name": true,
+}
+
+// shellSession is the span for a whole shell session, the parent of every
+// span sent from it, which is sent when the shell exits.
+type shellSession struct {
+	name        string
+	start       time.Time
+	traceId     string
+	spanId      string
+	traceparent string // exported as TRACEPARENT for the session's spans
+	parent      string // the TRACEPARENT to send the session span with, if any
+}
+
+func shellInitCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "shell-init [bash|zsh|fish]",
+		Short: "print shell functions and hooks for tracing shell commands",
+		Long: `Print shell functions and hooks for tracing from an interactive shell, to be
+loaded by eval-ing the output in the shell's rc file. The flags given to
+shell-init, e.g. --endpoint or --service, are passed on to every otel-cli
+command the script runs.
+
+The script always defines otel_run, which runs a command as a span named
+after it, like otel-cli exec:
+
+	otel_run make test
+
+--commands adds preexec and precmd hooks that send a span for every command
+run at the prompt, with its exit code as process.exit.code and an error
+status when it fails. Spans are sent in the background so the prompt isn't
+held up.
+
+--session starts a span for the whole shell session when the script is
+loaded, exporting TRACEPARENT so every span from the shell is its child, and
+sends it when the shell exits. When TRACEPARENT is already set, the session
+is a child of it.
+
+Example:
+	# ~/.bashrc
+	eval "$(otel-cli shell-init bash --commands --session --endpoint localhost:4317 --service shell)"
+
+	# ~/.zshrc
+	eval "$(otel-cli shell-init zsh --commands --endpoint localhost:4317)"
+
+	# ~/.config/fish/config.fish
+	otel-cli shell-init fish --commands --endpoint localhost:4317 | source
+`,
+		DisableFlagsInUseLine: true,
+		ValidArgs:             []string{"bash", "zsh", "fish"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		Run:                   doShellInit,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	defaults := DefaultConfig()
+	cmd.Flags().BoolVar(&shellInit.commands, "commands", false, "send a span for every command run at the prompt")
+	cmd.Flags().BoolVar(&shellInit.session, "session", false, "send a span for the whole shell session, the parent of the other spans")
+	cmd.Flags().StringVar(&shellInit.sessionName, "session-name", "shell session", "the name of the session span")
+	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the traces")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addAttrParams(&cmd, config)
+
+	return &cmd
+}
+
+func doShellInit(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	var session *shellSession
+	if shellInit.session {
+		session = config.newShellSession(shellInit.sessionName, time.Now())
+	}
+
+	writeShellInit(os.Stdout, args[0], shellInitArgs(cmd.Flags()), shellInit.commands, session)
+}
+
+// newShellSession returns the session span's ids and traceparents, under
+// TRACEPARENT or --tp-carrier when there's one.
+func (c Config) newShellSession(name string, start time.Time) *shellSession {
+	tp := traceparent.Traceparent{
+		TraceId:     otlpclient.GenerateTraceId(),
+		SpanId:      otlpclient.GenerateSpanId(),
+		Sampling:    true,
+		Initialized: true,
+	}
+	session := shellSession{name: name, start: start}
+	if parent := c.LoadTraceparent(); parent.Initialized && !bytes.Equal(parent.TraceId, otlpclient.GetEmptyTraceId()) {
+		tp.TraceId = parent.TraceId
+		tp.Sampling = parent.Sampling
+		session.parent = parent.Encode()
+	}
+	session.traceId = tp.TraceIdString()
+	session.spanId = tp.SpanIdString()
+	session.traceparent = tp.Encode()
+
+	return &session
+}
+
+// shellInitArgs returns the flags that were set on shell-init, as arguments
+// for the otel-cli commands in the script.
+func shellInitArgs(flags *pflag.FlagSet) []string {
+	args := []string{}
+	flags.Visit(func(f *pflag.Flag) {
+		if shellInitFlags[f.Name] {
+			return
+		}
+		switch v := f.Value.(type) {
+		case pflag.SliceValue:
+			for _, value := range v.GetSlice() {
+				args = append(args, "--"+f.Name+"="+value)
+			}
+		default:
+			value := v.String()
+			// maps print as [k=v,a=b] but are set with k=v,a=b
+			if v.Type() == "stringToString" {
+				value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			}
+			args = append(args, "--"+f.Name+"="+value)
+		}
+	})
+	return args
+}
+
+// writeShellInit writes the script for the shell to target.
+func writeShellInit(target io.Writer, shell string, args []string, commands bool, session *shellSession) {
+	quote := posixQuote
+	if shell == "fish" {
+		quote = fishQuote
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+
+	fmt.Fprintf(target, "# otel-cli shell-init %s\n", shell)
+	if shell == "fish" {
+		fmt.Fprintf(target, "set -g _otel_cli_args %s\n", strings.Join(quoted, " "))
+		io.WriteString(target, fishRun)
+	} else {
+		fmt.Fprintf(target, "_otel_cli_args=(%s)\n", strings.Join(quoted, " "))
+		io.WriteString(target, posixRun)
+	}
+
+	if session != nil {
+		start := fmt.Sprintf("%d.%09d", session.start.Unix(), session.start.Nanosecond())
+		sessionArgs := strings.Join([]string{
+			"--name", quote(session.name),
+			"--start", start,
+			"--end", "now",
+			"--force-trace-id", session.traceId,
+			"--force-span-id", session.spanId,
+		}, " ")
+		switch shell {
+		case "bash":
+			fmt.Fprintf(target, bashSession, quote(session.traceparent), quote(session.parent), sessionArgs)
+		case "zsh":
+			fmt.Fprintf(target, zshSession, quote(session.traceparent), quote(session.parent), sessionArgs)
+		case "fish":
+			fmt.Fprintf(target, fishSession, quote(session.traceparent), quote(session.parent), sessionArgs)
+		}
+	}
+
+	if commands {
+		switch shell {
+		case "bash":
+			io.WriteString(target, bashCommands)
+		case "zsh":
+			io.WriteString(target, zshCommands)
+		case "fish":
+			io.WriteString(target, fishCommands)
+		}
+	}
+}
+
+// posixQuote single-quotes the string for bash and zsh.
+func posixQuote(in string) string {
+	return "'" + strings.ReplaceAll(in, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes the string for fish, which escapes quotes and
+// backslashes inside single quotes instead.
+func fishQuote(in string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(in) + "'"
+}
+
+const posixRun = `
+# otel_run runs a command as a span named after it, e.g. otel_run make test
+otel_run() {
+	otel-cli exec "${_otel_cli_args[@]}" --name "$*" -- "$@"
+}
+`
+
+const fishRun = `
+function otel_run --description 'run a command as a span named after it'
+	otel-cli exec $_otel_cli_args --name "$argv" -- $argv
+end
+`
+
+// the session span is sent with the TRACEPARENT from before the session,
+// with its ids forced to the ones its children were given
+
+const bashSession = `
+export TRACEPARENT=%s
+_otel_cli_session_parent=%s
+_otel_cli_session_end() {
+	TRACEPARENT=$_otel_cli_session_parent otel-cli span "${_otel_cli_args[@]}" %s >/dev/null 2>&1
+}
+trap _otel_cli_session_end EXIT
+`
+
+const zshSession = `
+export TRACEPARENT=%s
+_otel_cli_session_parent=%s
+_otel_cli_session_end() {
+	TRACEPARENT=$_otel_cli_session_parent otel-cli span "${_otel_cli_args[@]}" %s >/dev/null 2>&1
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook zshexit _otel_cli_session_end
+`
+
+const fishSession = `
+set -gx TRACEPARENT %s
+set -g _otel_cli_session_parent %s
+function _otel_cli_session_end --on-event fish_exit
+	env TRACEPARENT=$_otel_cli_session_parent otel-cli span $_otel_cli_args %s >/dev/null 2>&1
+end
+`
+
+// bash doesn't have preexec, so the DEBUG trap stands in for it. It runs
+// before every simple command, including the ones in PROMPT_COMMAND, so only
+// the first one after the prompt is drawn counts as the start of a command,
+// and an empty command line only runs PROMPT_COMMAND.
+// Times are epoch.nanoseconds, which EPOCHREALTIME has in microseconds.
+const bashCommands = `
+_otel_cli_now() {
+	if [ -n "$EPOCHREALTIME" ]; then
+		_otel_cli_time="${EPOCHREALTIME/,/.}000"
+	else
+		_otel_cli_time=$(date +%s)
+	fi
+}
+_otel_cli_preexec() {
+	# a non-zero return skips the command with extdebug
+	[ -n "$_otel_cli_at_prompt" ] || return 0
+	[ -n "$COMP_LINE" ] && return 0
+	[ "$BASH_COMMAND" = _otel_cli_precmd ] && return 0
+	_otel_cli_at_prompt=
+	local line
+	line=$(HISTTIMEFORMAT= builtin history 1)
+	if [[ $line =~ ^[[:space:]]*[0-9]+[*]?[[:space:]]+(.*)$ ]]; then
+		_otel_cli_cmd=${BASH_REMATCH[1]}
+	else
+		_otel_cli_cmd=$BASH_COMMAND
+	fi
+	_otel_cli_now
+	_otel_cli_cmd_start=$_otel_cli_time
+}
+_otel_cli_precmd() {
+	local exit_code=$?
+	_otel_cli_at_prompt=
+	if [ -n "$_otel_cli_cmd_start" ]; then
+		local status_args=()
+		[ "$exit_code" -ne 0 ] && status_args=(--status-code error --status-description "exit code $exit_code")
+		(otel-cli span "${_otel_cli_args[@]}" --name "$_otel_cli_cmd" --start "$_otel_cli_cmd_start" --end now \
+			--attrs "process.exit.code=$exit_code" "${status_args[@]}" >/dev/null 2>&1 &)
+	fi
+	_otel_cli_cmd_start=
+}
+trap _otel_cli_preexec DEBUG
+PROMPT_COMMAND="_otel_cli_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND};_otel_cli_at_prompt=1"
+`
+
+const zshCommands = `
+zmodload zsh/datetime
+autoload -Uz add-zsh-hook
+_otel_cli_preexec() {
+	_otel_cli_cmd=$1
+	_otel_cli_cmd_start="${epochtime[1]}.${(l:9::0:)epochtime[2]}"
+}
+_otel_cli_precmd() {
+	local exit_code=$?
+	if [[ -n $_otel_cli_cmd_start ]]; then
+		local -a status_args
+		(( exit_code != 0 )) && status_args=(--status-code error --status-description "exit code $exit_code")
+		(otel-cli span "${_otel_cli_args[@]}" --name "$_otel_cli_cmd" --start "$_otel_cli_cmd_start" --end now \
+			--attrs "process.exit.code=$exit_code" "${status_args[@]}" >/dev/null 2>&1 &)
+	fi
+	_otel_cli_cmd_start=
+}
+add-zsh-hook preexec _otel_cli_preexec
+add-zsh-hook precmd _otel_cli_precmd
+`
+
+// fish doesn't have a builtin for the time, so it's from date, which only
+// has nanoseconds with GNU date
+const fishCommands = `
+function _otel_cli_preexec --on-event fish_preexec
+	set -g _otel_cli_cmd_start (date +%s.%N)
+	string match -q '*N' -- $_otel_cli_cmd_start; and set -g _otel_cli_cmd_start (date +%s)
+end
+function _otel_cli_postexec --on-event fish_postexec
+	set -l exit_code $status
+	set -q _otel_cli_cmd_start[1]; or return
+	set -l status_args
+	test $exit_code -ne 0; and set status_args --status-code error --status-description "exit code $exit_code"
+	otel-cli span $_otel_cli_args --name "$argv" --start $_otel_cli_cmd_start --end now \
+		--attrs "process.exit.code=$exit_code" $status_args >/dev/null 2>&1 &
+	disown $last_pid 2>/dev/null
+	set -e _otel_cli_cmd_start
+end
+`
@@ -0,0 +1,134 @@
+package otelcli
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+)
+
+func TestShellInitArgs(t *testing.T) {
+	flags := pflag.NewFlagSet("shell-init", pflag.ContinueOnError)
+	flags.Bool("commands", false, "")
+	flags.String("endpoint", "", "")
+	flags.Bool("fail", false, "")
+	flags.StringToString("attrs", nil, "")
+	flags.StringArray("attr-from-cmd", nil, "")
+	flags.String("service", "otel-cli", "")
+	err := flags.Parse([]string{"--commands", "--endpoint", "localhost:4317", "--fail",
+		"--attrs", "team=infra", "--attr-from-cmd", "a=uname", "--attr-from-cmd", "b=hostname"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only flags that were set, without shell-init's own
+	want := []string{"--attr-from-cmd=a=uname", "--attr-from-cmd=b=hostname", "--attrs=team=infra", "--endpoint=localhost:4317", "--fail=true"}
+	if diff := cmp.Diff(want, shellInitArgs(flags)); diff != "" {
+		t.Errorf("args mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, tc := range []struct{ in, posix, fish string }{
+		{in: "plain", posix: `'plain'`, fish: `'plain'`},
+		{in: "it's $HOME", posix: `'it'\''s $HOME'`, fish: `'it\'s $HOME'`},
+		{in: `back\slash`, posix: `'back\slash'`, fish: `'back\\slash'`},
+	} {
+		if got := posixQuote(tc.in); got != tc.posix {
+			t.Errorf("expected %q to be quoted for bash as %s but got %s", tc.in, tc.posix, got)
+		}
+		if got := fishQuote(tc.in); got != tc.fish {
+			t.Errorf("expected %q to be quoted for fish as %s but got %s", tc.in, tc.fish, got)
+		}
+	}
+}
+
+func TestNewShellSession(t *testing.T) {
+	start := time.Unix(1700000000, 5)
+
+	t.Setenv("TRACEPARENT", "")
+	session := DefaultConfig().newShellSession("shell session", start)
+	if session.parent != "" || !strings.HasSuffix(session.traceparent, "-01") || !strings.Contains(session.traceparent, session.traceId+"-"+session.spanId) {
+		t.Errorf("expected a sampled root session but got %+v", session)
+	}
+
+	// sessions started under a trace are part of it
+	parent := "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00"
+	t.Setenv("TRACEPARENT", parent)
+	session = DefaultConfig().newShellSession("shell session", start)
+	if session.parent != parent || session.traceId != "f6c109f48195b451c4def6ab32f47b61" ||
+		session.spanId == "a5d2a35f2483004e" || !strings.HasSuffix(session.traceparent, "-00") {
+		t.Errorf("expected a session under %s but got %+v", parent, session)
+	}
+}
+
+func TestWriteShellInit(t *testing.T) {
+	session := &shellSession{
+		name:        "my session",
+		start:       time.Unix(1700000000, 5),
+		traceId:     "f6c109f48195b451c4def6ab32f47b61",
+		spanId:      "a5d2a35f2483004e",
+		traceparent: "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01",
+	}
+	args := []string{"--endpoint=localhost:4317", "--attrs=owner=it's me"}
+
+	for shell, wants := range map[string][]string{
+		"bash": {
+			`_otel_cli_args=('--endpoint=localhost:4317' '--attrs=owner=it'\''s me')`,
+			"otel_run() {",
+			"export TRACEPARENT='00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01'",
+			"--name 'my session' --start 1700000000.000000005 --end now --force-trace-id f6c109f48195b451c4def6ab32f47b61 --force-span-id a5d2a35f2483004e",
+			"trap _otel_cli_session_end EXIT",
+			"trap _otel_cli_preexec DEBUG",
+		},
+		"zsh": {
+			`_otel_cli_args=('--endpoint=localhost:4317' '--attrs=owner=it'\''s me')`,
+			"add-zsh-hook zshexit _otel_cli_session_end",
+			"add-zsh-hook preexec _otel_cli_preexec",
+		},
+		"fish": {
+			`set -g _otel_cli_args '--endpoint=localhost:4317' '--attrs=owner=it\'s me'`,
+			"function otel_run",
+			"set -gx TRACEPARENT '00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01'",
+			"--on-event fish_exit",
+			"--on-event fish_postexec",
+		},
+	} {
+		buf := bytes.Buffer{}
+		writeShellInit(&buf, shell, args, true, session)
+		for _, want := range wants {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("expected the %s script to contain %q but got:\n%s", shell, want, buf.String())
+			}
+		}
+
+		// without --commands and --session there's only otel_run
+		buf.Reset()
+		writeShellInit(&buf, shell, args, false, nil)
+		if strings.Contains(buf.String(), "TRACEPARENT") || strings.Contains(buf.String(), "_otel_cli_preexec") {
+			t.Errorf("expected only otel_run in the %s script but got:\n%s", shell, buf.String())
+		}
+	}
+}
+
+func TestWriteShellInitSyntax(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			t.Logf("skipping %s syntax check, it isn't installed", shell)
+			continue
+		}
+
+		buf := bytes.Buffer{}
+		writeShellInit(&buf, shell, []string{"--endpoint=localhost:4317"}, true, &shellSession{name: "shell session", start: time.Now()})
+		cmd := exec.Command(path, "-n")
+		cmd.Stdin = &buf
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("%s script has syntax errors: %s\n%s", shell, err, out)
+		}
+	}
+}
@@ -36,12 +36,20 @@ Example:
 	addSpanParams(&cmd, config)
 	addSpanStartEndParams(&cmd, config)
 	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
 	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
+	addStatsdParams(&cmd, config)
+	addJsonParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().BoolVar(&config.StrictSemconv, "strict-semconv", defaults.StrictSemconv, "check attributes against the semantic conventions and exit 1 without sending if there are problems")
 
 	// subcommands
 	cmd.AddCommand(spanBgCmd(config))
 	cmd.AddCommand(spanEventCmd(config))
 	cmd.AddCommand(spanEndCmd(config))
+	cmd.AddCommand(spanBackfillCmd(config))
 
 	return &cmd
 }
@@ -51,11 +59,27 @@ func doSpan(cmd *cobra.Command, args []string) {
 	config := getConfig(ctx)
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
 	defer cancel()
+	config.checkStrictSemconv()
 	ctx, client := StartClient(ctx, config)
 	span := config.NewProtobufSpan()
-	ctx, err := otlpclient.SendSpan(ctx, client, config, span)
-	config.SoftFailIfErr(err)
-	_, err = client.Stop(ctx)
+	var sendErr error
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, span)
+		ctx, sendErr = otlpclient.SendSpan(ctx, client, config, span)
+		Diag.SetSendResult(ctx)
+		// --json reports the error in its result before failing
+		if !config.Json {
+			config.SoftFailIfErr(sendErr)
+		}
+	}
+	_, err := client.Stop(ctx)
 	config.SoftFailIfErr(err)
+	if config.Statsd != "" {
+		config.SoftLogIfErr(config.SendStatsd(span))
+	}
 	config.PropagateTraceparent(span, os.Stdout)
+	if config.Json {
+		config.PrintJsonResult(ctx, span, sendErr, os.Stdout)
+		config.SoftFailIfErr(sendErr)
+	}
 }
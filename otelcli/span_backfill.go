@@ -0,0 +1,324 @@
+package otelcli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// spanBackfill holds the command-line configured settings for otel-cli span backfill.
+var spanBackfill struct {
+	csv       string
+	columns   backfillColumns
+	batchSize int
+}
+
+// backfillColumns are the names of the CSV columns for each part of a span.
+type backfillColumns struct {
+	name       string
+	start      string
+	end        string
+	id         string
+	parent     string
+	kind       string
+	status     string
+	statusDesc string
+}
+
+// defaultBackfillColumns returns the column names used without --*-column.
+func defaultBackfillColumns() backfillColumns {
+	return backfillColumns{
+		name:       "name",
+		start:      "start",
+		end:        "end",
+		id:         "id",
+		parent:     "parent",
+		kind:       "kind",
+		status:     "status",
+		statusDesc: "status_description",
+	}
+}
+
+// backfillRow is a CSV row that passed validation, with the values of the
+// columns that aren't mapped to anything else as its attributes.
+type backfillRow struct {
+	line   int
+	id     string
+	parent string
+	span   *tracepb.Span
+}
+
+func spanBackfillCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "backfill --csv spans.csv",
+		Short: "send historical spans from a CSV file",
+		Long: `Send a span for each row of a CSV file, e.g. runs exported from a job
+runner's database, in batches of --batch-size spans per request.
+
+The first row names the columns. Each span's name, start, and end come from
+the name, start, and end columns, with times as Unix epochs or RFC3339 like
+--start and --end. A row's parent column refers to another row's id
+column, which can come before or after it in the file, and rows without a
+parent start their own trace, or are children of TRACEPARENT or
+--tp-carrier when set. The kind, status, and status_description columns
+set the span's kind and status when they're there. Every other column
+becomes an attribute, leaving out empty values. The --*-column flags map
+different column names.
+
+All rows are checked before anything is sent: each needs a name and a
+start, can't end before it starts or start before its parent, and parents
+have to exist without any loops.
+
+Example:
+	psql -c "\copy (select id, parent_id, job as name, started_at as start, finished_at as end, exit_code from runs) to 'runs.csv' csv header"
+	otel-cli span backfill --csv runs.csv --parent-column parent_id --service job-runner
+`,
+		Args: cobra.NoArgs,
+		Run:  doSpanBackfill,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	defaults := DefaultConfig()
+	columns := defaultBackfillColumns()
+	cmd.Flags().StringVar(&spanBackfill.csv, "csv", "", "the CSV file to read spans from, - for stdin")
+	cmd.MarkFlagRequired("csv")
+	cmd.Flags().StringVar(&spanBackfill.columns.name, "name-column", columns.name, "the column with the span name")
+	cmd.Flags().StringVar(&spanBackfill.columns.start, "start-column", columns.start, "the column with the span start time")
+	cmd.Flags().StringVar(&spanBackfill.columns.end, "end-column", columns.end, "the column with the span end time, spans without one end when they start")
+	cmd.Flags().StringVar(&spanBackfill.columns.id, "id-column", columns.id, "the column with the row's id, for other rows to use as their parent")
+	cmd.Flags().StringVar(&spanBackfill.columns.parent, "parent-column", columns.parent, "the column with the id of the row's parent")
+	cmd.Flags().StringVar(&spanBackfill.columns.kind, "kind-column", columns.kind, "the column with the span kind, defaults to --kind")
+	cmd.Flags().StringVar(&spanBackfill.columns.status, "status-column", columns.status, "the column with the span status code, e.g. ok or error")
+	cmd.Flags().StringVar(&spanBackfill.columns.statusDesc, "status-description-column", columns.statusDesc, "the column with the span status description")
+	cmd.Flags().IntVar(&spanBackfill.batchSize, "batch-size", 500, "how many spans to send in each request")
+	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the traces")
+	cmd.Flags().StringVarP(&config.Kind, "kind", "k", defaults.Kind, "set the kind of spans without a kind column")
+	cmd.RegisterFlagCompletionFunc("kind", completeKind)
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSpanBackfill(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if !config.GetIsRecording() {
+		config.SoftFail("an endpoint is required to backfill spans to")
+	}
+	if spanBackfill.batchSize < 1 {
+		config.SoftFail("--batch-size must be at least 1")
+	}
+
+	in := io.Reader(os.Stdin)
+	if spanBackfill.csv != "-" {
+		f, err := os.Open(spanBackfill.csv)
+		if err != nil {
+			config.SoftFail("%s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// LoadTraceparent returns an all-zero traceparent when there is none
+	var traceId, parentId []byte
+	if tp := config.LoadTraceparent(); tp.Initialized && !bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		traceId, parentId = tp.TraceId, tp.SpanId
+	}
+
+	spans, err := config.readBackfillCsv(in, spanBackfill.columns, traceId, parentId)
+	if err != nil {
+		config.SoftFail("%s", err)
+	}
+
+	addImportParentAttrs(config, spans)
+	for start := 0; start < len(spans); start += spanBackfill.batchSize {
+		end := start + spanBackfill.batchSize
+		if end > len(spans) {
+			end = len(spans)
+		}
+		sendImportSpans(ctx, config, spans[start:end])
+	}
+
+	config.SoftLog("backfilled %d spans", len(spans))
+}
+
+// readBackfillCsv reads and validates the spans in the CSV, returning an
+// error listing every row with a problem. Root rows are parented to
+// traceId and parentId when there's a traceId.
+func (c Config) readBackfillCsv(in io.Reader, columns backfillColumns, traceId, parentId []byte) ([]*tracepb.Span, error) {
+	r := csv.NewReader(in)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+
+	indexes := map[string]int{}
+	for i, name := range header {
+		indexes[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{columns.name, columns.start} {
+		if _, ok := indexes[name]; !ok {
+			return nil, fmt.Errorf("the CSV doesn't have a %q column", name)
+		}
+	}
+	mapped := map[string]bool{}
+	for _, name := range []string{columns.name, columns.start, columns.end,
+		columns.id, columns.parent, columns.kind,
+		columns.status, columns.statusDesc} {
+		mapped[name] = true
+	}
+
+	// rows under a missing parent or in a loop would report it again
+	problems := []string{}
+	reported := map[string]bool{}
+	report := func(format string, a ...interface{}) {
+		if msg := fmt.Sprintf(format, a...); !reported[msg] {
+			reported[msg] = true
+			problems = append(problems, msg)
+		}
+	}
+	rows := []*backfillRow{}
+	byId := map[string]*backfillRow{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read the CSV: %w", err)
+		}
+		// quoted values can span lines, so rows aren't always one line each
+		line, _ := r.FieldPos(0)
+		get := func(column string) string {
+			if i, ok := indexes[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row, err := c.backfillRow(line, get, columns, header, mapped)
+		if err != nil {
+			report("%s", err)
+			continue
+		}
+		if row.id != "" {
+			if _, ok := byId[row.id]; ok {
+				report("line %d: id %q is used by an earlier row", line, row.id)
+				continue
+			}
+			byId[row.id] = row
+		}
+		rows = append(rows, row)
+	}
+
+	// parents can come after their children, so traces are worked out once
+	// all the rows are read, walking up to the root of each
+	spans := []*tracepb.Span{}
+	for _, row := range rows {
+		span := row.span
+		seen := map[*backfillRow]bool{row: true}
+		root := row
+		for root.parent != "" {
+			parent, ok := byId[root.parent]
+			if !ok {
+				report("line %d: parent %q isn't the id of any row", root.line, root.parent)
+				break
+			} else if seen[parent] {
+				report("line %d: parent %q is part of a loop", root.line, root.parent)
+				break
+			}
+			seen[parent] = true
+			root = parent
+		}
+
+		if row.parent != "" {
+			if parent, ok := byId[row.parent]; ok {
+				span.ParentSpanId = parent.span.SpanId
+				if span.StartTimeUnixNano < parent.span.StartTimeUnixNano {
+					report("line %d: starts before its parent on line %d", row.line, parent.line)
+				}
+			}
+		} else if len(traceId) > 0 {
+			span.ParentSpanId = parentId
+		}
+		span.TraceId = root.span.TraceId
+		if len(traceId) > 0 {
+			span.TraceId = traceId
+		}
+		spans = append(spans, span)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%d problems in the CSV:\n%s", len(problems), strings.Join(problems, "\n"))
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("the CSV doesn't have any rows")
+	}
+	return spans, nil
+}
+
+// backfillRow builds the span for a row without its trace or parent, which
+// readBackfillCsv fills in once it has every row.
+func (c Config) backfillRow(line int, get func(string) string, columns backfillColumns, header []string, mapped map[string]bool) (*backfillRow, error) {
+	name := get(columns.name)
+	if name == "" {
+		return nil, fmt.Errorf("line %d: the name is empty", line)
+	}
+	start, err := c.parseTime(get(columns.start), "start")
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	end := start
+	if value := get(columns.end); value != "" {
+		if end, err = c.parseTime(value, "end"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("line %d: ends at %s, before it starts at %s", line, end, start)
+		}
+	}
+
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = otlpclient.GenerateTraceId()
+	span.SpanId = otlpclient.GenerateSpanId()
+	span.Name = name
+	span.StartTimeUnixNano = uint64(start.UnixNano())
+	span.EndTimeUnixNano = uint64(end.UnixNano())
+
+	kind := get(columns.kind)
+	if kind == "" {
+		kind = c.Kind
+	}
+	span.Kind = otlpclient.SpanKindStringToInt(strings.ToLower(kind))
+	otlpclient.SetSpanStatus(span, strings.ToLower(get(columns.status)), get(columns.statusDesc))
+
+	attrs := map[string]string{}
+	for _, column := range header {
+		column = strings.TrimSpace(column)
+		if value := get(column); !mapped[column] && value != "" {
+			attrs[column] = value
+		}
+	}
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+
+	return &backfillRow{
+		line:   line,
+		id:     get(columns.id),
+		parent: get(columns.parent),
+		span:   span,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package otelcli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestReadBackfillCsv(t *testing.T) {
+	csv := `id,parent,name,start,end,status,exit_code,host
+2,1,"build
+step",2024-03-09T14:30:05Z,2024-03-09T14:31:00Z,error,2,
+1,,nightly,1709994600,1709994700,ok,0,web1
+3,,cleanup,1709994800,,,,web1
+`
+	config := DefaultConfig().WithKind("internal")
+	spans, err := config.readBackfillCsv(strings.NewReader(csv), defaultBackfillColumns(), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to read CSV: %s", err)
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans but got %d", len(spans))
+	}
+
+	step, nightly, cleanup := spans[0], spans[1], spans[2]
+	if step.Name != "build\nstep" || string(step.ParentSpanId) != string(nightly.SpanId) || string(step.TraceId) != string(nightly.TraceId) {
+		t.Errorf("expected the step to be a child of the nightly run, which comes after it")
+	}
+	if len(nightly.ParentSpanId) != 0 || string(cleanup.TraceId) == string(nightly.TraceId) {
+		t.Errorf("expected rows without a parent to start their own traces")
+	}
+	if step.StartTimeUnixNano != uint64(time.Date(2024, 3, 9, 14, 30, 5, 0, time.UTC).UnixNano()) || nightly.EndTimeUnixNano != uint64(time.Unix(1709994700, 0).UnixNano()) {
+		t.Errorf("unexpected times")
+	}
+	if cleanup.EndTimeUnixNano != cleanup.StartTimeUnixNano {
+		t.Errorf("expected a span without an end to end when it starts")
+	}
+	if step.Status.Code != tracepb.Status_STATUS_CODE_ERROR || nightly.Status.Code != tracepb.Status_STATUS_CODE_OK || step.Kind != tracepb.Span_SPAN_KIND_INTERNAL {
+		t.Errorf("unexpected status or kind")
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range step.Attributes {
+		attrs[attr.Key] = attr.Value.String()
+	}
+	if len(attrs) != 1 || attrs["exit_code"] != "int_value:2" {
+		t.Errorf("expected only exit_code as an attribute but got %v", attrs)
+	}
+
+	// root rows are children of the traceparent when there is one
+	traceId, parentId := otlpclient.GenerateTraceId(), otlpclient.GenerateSpanId()
+	spans, err = config.readBackfillCsv(strings.NewReader(csv), defaultBackfillColumns(), traceId, parentId)
+	if err != nil {
+		t.Fatalf("failed to read CSV: %s", err)
+	}
+	for _, span := range spans {
+		if string(span.TraceId) != string(traceId) {
+			t.Errorf("expected %q to be in the traceparent's trace", span.Name)
+		}
+	}
+	if string(spans[1].ParentSpanId) != string(parentId) {
+		t.Errorf("expected the root row to be a child of the traceparent")
+	}
+}
+
+func TestReadBackfillCsvProblems(t *testing.T) {
+	csv := `id,parent,name,start,end
+1,,a,1709994600,1709994500
+2,9,b,1709994600,
+3,4,c,1709994600,
+4,3,d,1709994600,
+5,6,e,1709994600,
+6,,f,1709994700,
+,,,1709994600,
+6,,g,1709994600,
+`
+	_, err := DefaultConfig().readBackfillCsv(strings.NewReader(csv), defaultBackfillColumns(), nil, nil)
+	if err == nil {
+		t.Fatal("expected problems with the CSV")
+	}
+	for _, want := range []string{
+		"line 2: ends at",
+		`line 3: parent "9" isn't the id of any row`,
+		"is part of a loop",
+		"line 6: starts before its parent on line 7",
+		"line 8: the name is empty",
+		`line 9: id "6" is used by an earlier row`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the problems but got:\n%s", want, err)
+		}
+	}
+
+	if _, err := DefaultConfig().readBackfillCsv(strings.NewReader("id,start\n1,1709994600\n"), defaultBackfillColumns(), nil, nil); err == nil || !strings.Contains(err.Error(), `"name" column`) {
+		t.Errorf("expected an error about the missing name column but got %v", err)
+	}
+}
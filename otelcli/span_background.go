@@ -51,11 +51,15 @@ timeout, (catchable) signals, or deliberate exit.
 	cmd.Flags().IntVar(&config.BackgroundParentPollMs, "parent-poll", defaults.BackgroundParentPollMs, "number of milliseconds to wait between checking for whether the parent process exited")
 	cmd.Flags().BoolVar(&config.BackgroundWait, "wait", defaults.BackgroundWait, "wait for background to be fully started and then return")
 	cmd.Flags().BoolVar(&config.BackgroundSkipParentPidCheck, "skip-pid-check", defaults.BackgroundSkipParentPidCheck, "disable checking parent pid")
+	cmd.Flags().IntVar(&config.IngestMaxRate, "max-rate", defaults.IngestMaxRate, "drop span events received beyond this many a second, 0 for no limit")
+	cmd.Flags().IntVar(&config.IngestMaxQueue, "max-queue", defaults.IngestMaxQueue, "drop span events received once the span has this many, 0 for no limit")
 
 	addCommonParams(&cmd, config)
 	addSpanParams(&cmd, config)
 	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
 	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
 
 	return &cmd
 }
@@ -131,15 +135,19 @@ func doSpanBackground(cmd *cobra.Command, args []string) {
 
 	// will block until bgs.Shutdown()
 	bgs.Run()
+	bgs.limiter.logDrops(config, "events")
 
 	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
 
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
 	defer cancel()
 
-	_, err := otlpclient.SendSpan(ctx, client, config, span)
-	if err != nil {
-		config.SoftFail("Sending span failed: %s", err)
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, span)
+		_, err := otlpclient.SendSpan(ctx, client, config, span)
+		if err != nil {
+			config.SoftFail("Sending span failed: %s", err)
+		}
 	}
 }
 
@@ -3,6 +3,7 @@ package otelcli
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
 	"net/rpc"
@@ -24,6 +25,7 @@ type BgSpan struct {
 	Error       string `json:"error"`
 	config      Config
 	span        *tracepb.Span
+	limiter     *ingestLimiter
 	shutdown    func()
 }
 
@@ -42,10 +44,18 @@ type BgEnd struct {
 }
 
 // AddEvent takes a BgSpanEvent from the client and attaches an event to the span.
+// Events over --max-rate, or once the span has --max-queue events, are dropped
+// and counted in the span's dropped events.
 func (bs BgSpan) AddEvent(bse *BgSpanEvent, reply *BgSpan) error {
 	reply.TraceID = hex.EncodeToString(bs.span.TraceId)
 	reply.SpanID = hex.EncodeToString(bs.span.SpanId)
-	reply.Traceparent = otlpclient.TraceparentFromProtobufSpan(bs.span, bs.config.GetIsRecording()).Encode()
+	reply.Traceparent = otlpclient.TraceparentFromProtobufSpan(bs.span, bs.config.IsSampled(bs.span)).Encode()
+
+	if err := bs.limiter.allow(time.Now(), len(bs.span.Events)); err != nil {
+		bs.span.DroppedEventsCount++
+		reply.Error = fmt.Sprintf("event %q %s", bse.Name, err)
+		return errors.New(reply.Error)
+	}
 
 	ts, err := time.Parse(time.RFC3339Nano, bse.Timestamp)
 	if err != nil {
@@ -71,18 +81,12 @@ func (bs BgSpan) Wait(in, reply *struct{}) error {
 // End takes a BgEnd (empty) struct, replies with the usual trace info, then
 // ends the span end exits the background process.
 func (bs BgSpan) End(in *BgEnd, reply *BgSpan) error {
-	// handle --attrs arg to span end by retrieving and merging with/overwriting existing attribtues
-	attrs := make(map[string]string)
-	for k, v := range otlpclient.SpanAttributesToStringMap(bs.span) {
-		attrs[k] = v
-	}
-	for key, value := range in.Attributes {
-		attrs[key] = value
-	}
+	// handle --attrs arg to span end by merging with/overwriting existing attributes,
+	// which keeps the types of any that came from --attrs-file
+	bs.span.Attributes = mergeAttrs(bs.span.Attributes, in.Attributes)
 	// handle --status-code and --status-description args to span end
-	c := bs.config.WithStatusCode(in.StatusCode).WithStatusDescription(in.StatusDesc).WithAttributes(attrs)
+	c := bs.config.WithStatusCode(in.StatusCode).WithStatusDescription(in.StatusDesc)
 	otlpclient.SetSpanStatus(bs.span, c.StatusCode, c.StatusDescription)
-	bs.span.Attributes = otlpclient.StringMapAttrsToProtobuf(c.Attributes)
 
 	// running the shutdown as a goroutine prevents the client from getting an
 	// error here when the server gets closed. defer didn't do the trick.
@@ -97,6 +101,7 @@ type bgServer struct {
 	quit     chan struct{}
 	wg       sync.WaitGroup
 	config   Config
+	limiter  *ingestLimiter
 }
 
 // createBgServer opens a new span background server on a unix socket and
@@ -109,6 +114,7 @@ func createBgServer(ctx context.Context, sockfile string, span *tracepb.Span) *b
 		sockfile: sockfile,
 		quit:     make(chan struct{}),
 		config:   config,
+		limiter:  newIngestLimiter(config),
 	}
 
 	// TODO: be safer?
@@ -121,6 +127,7 @@ func createBgServer(ctx context.Context, sockfile string, span *tracepb.Span) *b
 		SpanID:   hex.EncodeToString(span.SpanId),
 		config:   config,
 		span:     span,
+		limiter:  bgs.limiter,
 		shutdown: func() { bgs.Shutdown() },
 	}
 	// makes methods on BgSpan available over RPC
@@ -25,6 +25,7 @@ See: otel-cli span background
 	defaults := DefaultConfig()
 
 	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(&cmd, config)
 	// TODO
 	//cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for otel-cli operations, all timeouts in otel-cli use this value")
 	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
@@ -57,6 +58,6 @@ func doSpanEnd(cmd *cobra.Command, args []string) {
 
 	tp, _ := traceparent.Parse(res.Traceparent)
 	if config.TraceparentPrint {
-		tp.Fprint(os.Stdout, config.TraceparentPrintExport)
+		config.PrintTraceparent(tp, os.Stdout)
 	}
 }
@@ -33,6 +33,7 @@ See: otel-cli span background
 	cmd.Flags().SortFlags = false
 
 	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(&cmd, config)
 	// TODO
 	//spanEventCmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for otel-cli operations, all timeouts in otel-cli use this value")
 	cmd.Flags().StringVarP(&config.EventName, "name", "e", defaults.EventName, "set the name of the event")
@@ -67,6 +68,6 @@ func doSpanEvent(cmd *cobra.Command, args []string) {
 		if err != nil {
 			config.SoftFail("Could not parse traceparent: %s", err)
 		}
-		tp.Fprint(os.Stdout, config.TraceparentPrintExport)
+		config.PrintTraceparent(tp, os.Stdout)
 	}
 }
@@ -0,0 +1,192 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+)
+
+// sshWrapper holds the command-line configured settings for otel-cli ssh.
+var sshWrapper struct {
+	bin       string
+	args      []string
+	propagate string
+}
+
+func sshCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "ssh destination [-- remote command...]",
+		Short: "run ssh in a span and pass the traceparent to the remote host",
+		Long: `Run ssh inside a span like exec, passing the span's traceparent to the remote
+host as TRACEPARENT, so otel-cli and other instrumented programs run there
+join the same trace.
+
+--propagate chooses how TRACEPARENT gets there:
+	prefix   put "export TRACEPARENT=...;" in front of the remote command,
+	         which works with any sshd but needs a remote command and a
+	         POSIX-like login shell
+	setenv   pass it with ssh -o SetEnv, needs OpenSSH 7.8 or later
+	sendenv  pass it with ssh -o SendEnv, for older clients
+	none     don't pass it
+	auto     prefix when there's a remote command, setenv otherwise
+setenv and sendenv need "AcceptEnv TRACEPARENT" in the remote sshd_config.
+
+ssh's own options can be passed with --ssh-arg. Use -- before the remote
+command.
+
+Examples:
+	otel-cli ssh deploy@web1 -- 'otel-cli exec --name migrate -- ./migrate.sh'
+	otel-cli ssh --ssh-arg=-p2222 --propagate setenv web1 -- ./deploy.sh
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doSsh,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&sshWrapper.bin, "ssh", "ssh", "the ssh program to run")
+	cmd.Flags().StringArrayVar(&sshWrapper.args, "ssh-arg", []string{}, "an argument to pass to ssh before the destination, may be repeated")
+	cmd.Flags().StringVar(&sshWrapper.propagate, "propagate", "auto", "how to pass TRACEPARENT to the remote host, one of auto, prefix, setenv, sendenv, or none")
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSsh(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	destination, command := args[0], strings.Join(args[1:], " ")
+	if config.SpanName == DefaultConfig().SpanName {
+		config.SpanName = "ssh " + destination
+	}
+	config.Attributes["server.address"] = sshHost(destination)
+	if command != "" {
+		config.Attributes["ssh.command"] = command
+	}
+	span := config.NewProtobufSpan()
+
+	// pass through a traceparent that's already set when not recording, like exec
+	tp := config.LoadTraceparent()
+	if config.GetIsRecording() {
+		tp = otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
+	}
+	traceparent := ""
+	if tp.Initialized {
+		traceparent = tp.Encode()
+	}
+
+	sshArgs, err := sshCommandArgs(sshWrapper.args, destination, command, sshWrapper.propagate, traceparent)
+	config.SoftFailIfErr(err)
+
+	child := exec.Command(sshWrapper.bin, sshArgs...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = []string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "TRACEPARENT=") {
+			child.Env = append(child.Env, env)
+		}
+	}
+	if traceparent != "" {
+		child.Env = append(child.Env, "TRACEPARENT="+traceparent)
+	}
+
+	// ssh puts the terminal in raw mode and passes ctrl-c to the remote
+	// side itself, otherwise it gets the signal too and exits on its own
+	signal.Ignore(os.Interrupt)
+
+	if err := child.Run(); err != nil {
+		otlpclient.SetSpanStatus(span, "error", fmt.Sprintf("ssh failed: %s", err))
+	}
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	ctx, client := StartClient(ctx, config)
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, span)
+		ctx, err = otlpclient.SendSpan(ctx, client, config, span)
+		if err != nil {
+			config.SoftFail("unable to send span: %s", err)
+		}
+	}
+
+	_, err = client.Stop(ctx)
+	if err != nil {
+		config.SoftFail("client.Stop() failed: %s", err)
+	}
+
+	// ssh exits with the remote command's exit code, or 255 on its own errors
+	Diag.ExecExitCode = child.ProcessState.ExitCode()
+
+	config.PropagateTraceparent(span, os.Stdout)
+}
+
+// sshCommandArgs returns the arguments for ssh, passing the traceparent the
+// way propagate says to when there is one.
+func sshCommandArgs(extra []string, destination, command, propagate, traceparent string) ([]string, error) {
+	if propagate == "auto" {
+		propagate = "setenv"
+		if command != "" {
+			propagate = "prefix"
+		}
+	}
+
+	out := append([]string{}, extra...)
+	switch propagate {
+	case "prefix":
+		if command == "" {
+			return nil, fmt.Errorf("--propagate prefix needs a remote command")
+		}
+		if traceparent != "" {
+			command = "export TRACEPARENT=" + traceparent + "; " + command
+		}
+	case "setenv":
+		if traceparent != "" {
+			out = append(out, "-o", "SetEnv=TRACEPARENT="+traceparent)
+		}
+	case "sendenv":
+		if traceparent != "" {
+			out = append(out, "-o", "SendEnv=TRACEPARENT")
+		}
+	case "none":
+	default:
+		return nil, fmt.Errorf("invalid --propagate %q, must be one of auto, prefix, setenv, sendenv, or none", propagate)
+	}
+
+	// -- stops ssh from reading a destination or command starting with - as options
+	out = append(out, "--", destination)
+	if command != "" {
+		out = append(out, command)
+	}
+	return out, nil
+}
+
+// sshHost returns the host from a destination like user@host or
+// ssh://user@host:port.
+func sshHost(destination string) string {
+	if u, err := url.Parse(destination); err == nil && u.Scheme == "ssh" {
+		return u.Hostname()
+	}
+	if i := strings.LastIndex(destination, "@"); i >= 0 {
+		return destination[i+1:]
+	}
+	return destination
+}
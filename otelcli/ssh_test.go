@@ -0,0 +1,88 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSshCommandArgs(t *testing.T) {
+	tp := "00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01"
+
+	for _, tc := range []struct {
+		name        string
+		extra       []string
+		command     string
+		propagate   string
+		traceparent string
+		want        []string
+		fail        bool
+	}{
+		{
+			name:        "auto with a command prefixes it",
+			command:     "./deploy.sh --fast",
+			propagate:   "auto",
+			traceparent: tp,
+			want:        []string{"--", "web1", "export TRACEPARENT=" + tp + "; ./deploy.sh --fast"},
+		},
+		{
+			name:        "auto without a command uses SetEnv",
+			propagate:   "auto",
+			traceparent: tp,
+			want:        []string{"-o", "SetEnv=TRACEPARENT=" + tp, "--", "web1"},
+		},
+		{
+			name:        "sendenv after the extra args",
+			extra:       []string{"-p2222"},
+			command:     "uptime",
+			propagate:   "sendenv",
+			traceparent: tp,
+			want:        []string{"-p2222", "-o", "SendEnv=TRACEPARENT", "--", "web1", "uptime"},
+		},
+		{
+			name:      "nothing to pass",
+			command:   "uptime",
+			propagate: "auto",
+			want:      []string{"--", "web1", "uptime"},
+		},
+		{
+			name:        "none",
+			command:     "uptime",
+			propagate:   "none",
+			traceparent: tp,
+			want:        []string{"--", "web1", "uptime"},
+		},
+		{name: "prefix needs a command", propagate: "prefix", traceparent: tp, fail: true},
+		{name: "invalid", command: "uptime", propagate: "env", fail: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sshCommandArgs(tc.extra, "web1", tc.command, tc.propagate, tc.traceparent)
+			if tc.fail {
+				if err == nil {
+					t.Errorf("expected an error but got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ssh args did not match (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSshHost(t *testing.T) {
+	for in, want := range map[string]string{
+		"web1":                          "web1",
+		"deploy@web1.example.com":       "web1.example.com",
+		"ssh://deploy@web1:2222":        "web1",
+		"ssh://[2001:db8::1]:22":        "2001:db8::1",
+		"user@name@bastion.example.com": "bastion.example.com",
+	} {
+		if got := sshHost(in); got != want {
+			t.Errorf("sshHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
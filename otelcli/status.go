@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -23,6 +24,7 @@ type StatusOutput struct {
 	SpanData    map[string]string    `json:"span_data"`
 	Env         map[string]string    `json:"env"`
 	Diagnostics Diagnostics          `json:"diagnostics"`
+	Probes      []StatusProbe        `json:"probes,omitempty"`
 	Errors      otlpclient.ErrorList `json:"errors"`
 }
 
@@ -36,9 +38,31 @@ By default just one canary is sent. When --canary-count is set, that number of c
 are sent. If --canary-interval is set, status will sleep the specified duration
 between canaries, up to --timeout (default 1s).
 
+With --probe, each endpoint is also probed before the canaries go out, and
+the DNS lookup, TCP connection, TLS handshake (version, cipher suite, and
+certificate chain with expiry), and the response to an empty OTLP export
+request are reported separately under "probes", each with how long it
+took. The probe connects directly, without --proxy, and stops at the
+first stage that fails.
+
+With --check, status is a health check for container readiness probes and
+CI: it probes each endpoint, sends one canary, and exits 0 only if the
+canary was accepted within --timeout, printing nothing. Otherwise it prints
+why to stderr and exits with:
+
+	1  any other failure, e.g. the canary was rejected
+	2  the endpoint's host didn't resolve
+	3  the connection failed, e.g. it was refused
+	4  the TLS handshake failed, e.g. the certificate isn't trusted
+	5  the endpoint refused the credentials (HTTP 401 or 403, gRPC
+	   Unauthenticated or PermissionDenied)
+	6  --timeout or --connect-timeout ran out
+
 Example:
 	otel-cli status
 	otel-cli status --canary-count 10 --canary-interval 10 --timeout 10s
+	otel-cli status --probe --endpoint https://otel.example.com --timeout 5s
+	otel-cli status --check --endpoint https://otel.example.com --timeout 30s
 `,
 		Run: doStatus,
 	}
@@ -46,10 +70,13 @@ Example:
 	defaults := DefaultConfig()
 	cmd.Flags().IntVar(&config.StatusCanaryCount, "canary-count", defaults.StatusCanaryCount, "number of canaries to send")
 	cmd.Flags().StringVar(&config.StatusCanaryInterval, "canary-interval", defaults.StatusCanaryInterval, "number of milliseconds to wait between canaries")
+	cmd.Flags().BoolVar(&config.StatusProbe, "probe", defaults.StatusProbe, "probe the DNS, TCP, TLS, and OTLP stages of reaching each endpoint and report them separately")
+	cmd.Flags().BoolVar(&config.StatusCheck, "check", defaults.StatusCheck, "exit 0 only if a canary is accepted within --timeout, or with an exit code for the stage that failed")
 
 	addCommonParams(&cmd, config)
 	addClientParams(&cmd, config)
 	addSpanParams(&cmd, config)
+	addJsonParams(&cmd, config)
 
 	return &cmd
 }
@@ -63,7 +90,10 @@ func doStatus(cmd *cobra.Command, args []string) {
 	config := getConfig(ctx)
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
 	defer cancel()
-	ctx, client := StartClient(ctx, config)
+
+	if config.StatusCheck {
+		doStatusCheck(ctx, config)
+	}
 
 	env := make(map[string]string)
 	for _, e := range os.Environ() {
@@ -73,7 +103,7 @@ func doStatus(cmd *cobra.Command, args []string) {
 			// should be a list at top of file and needs a flag to turn it off
 			// TODO: for sure need to mask OTEL_EXPORTER_OTLP_HEADERS
 			if strings.Contains(strings.ToLower(parts[0]), "token") || parts[0] == "OTEL_EXPORTER_OTLP_HEADERS" {
-				env[parts[0]] = "--- redacted ---"
+				env[parts[0]] = redacted
 			} else {
 				env[parts[0]] = parts[1]
 			}
@@ -82,6 +112,34 @@ func doStatus(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// probe before starting the client, which can fail on some of the
+	// same problems before the probe would get to say which stage it was.
+	// --json prints only the result, which has nowhere to put probes.
+	var probes []StatusProbe
+	if config.StatusProbe && config.GetIsRecording() && !config.Json {
+		probes = config.probeEndpoints(ctx)
+	}
+
+	// the client can't start without the endpoint's address, so report the
+	// probes without any canaries instead of failing with less to go on
+	for _, probe := range probes {
+		if probe.Dns != nil && probe.Dns.Error != "" {
+			Diag.Error = probe.Error
+			printStatus(config, StatusOutput{
+				Config:      config,
+				Env:         env,
+				Spans:       allSpans,
+				SpanData:    map[string]string{},
+				Diagnostics: Diag,
+				Probes:      probes,
+				Errors:      otlpclient.ErrorList{},
+			})
+			os.Exit(exitCode)
+		}
+	}
+
+	ctx, client := StartClient(ctx, config)
+
 	var canaryCount int
 	var lastSpan *tracepb.Span
 	deadline := time.Now().Add(config.GetTimeout())
@@ -133,6 +191,17 @@ func doStatus(cmd *cobra.Command, args []string) {
 	// otlpclient saves all errors to a key in context so they can be used
 	// to validate assumptions here & in tests
 	errorList := otlpclient.GetErrorList(ctx)
+	Diag.SetSendResult(ctx)
+
+	// --json prints the same result as span and exec instead of everything
+	if config.Json {
+		var sendErr error
+		if len(errorList) > 0 {
+			sendErr = errors.New(errorList[len(errorList)-1].Error)
+		}
+		config.PrintJsonResult(ctx, lastSpan, sendErr, os.Stdout)
+		os.Exit(exitCode)
+	}
 
 	// TODO: does it make sense to turn SpanData into a list of spans?
 	outData := StatusOutput{
@@ -150,14 +219,22 @@ func doStatus(cmd *cobra.Command, args []string) {
 		// Diagnostics is deprecated, being replaced by Errors below and eventually
 		// another stringmap of stuff that was tunneled through context.Context
 		Diagnostics: Diag,
+		Probes:      probes,
 		Errors:      errorList,
 	}
+	printStatus(config, outData)
+
+	os.Exit(exitCode)
+}
+
+// printStatus writes the status output to stdout as indented JSON, with
+// the secrets in its config redacted.
+func printStatus(config Config, outData StatusOutput) {
+	outData.Config = outData.Config.redactSecrets()
 
 	js, err := json.MarshalIndent(outData, "", "    ")
 	config.SoftFailIfErr(err)
 
 	os.Stdout.Write(js)
 	os.Stdout.WriteString("\n")
-
-	os.Exit(exitCode)
 }
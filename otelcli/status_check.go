@@ -0,0 +1,110 @@
+package otelcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exit codes for status --check, so readiness checks can tell what's wrong
+// without parsing any output
+const (
+	checkExitFailed  = 1 // anything not covered below, e.g. the span was rejected
+	checkExitDns     = 2
+	checkExitConnect = 3
+	checkExitTls     = 4
+	checkExitAuth    = 5
+	checkExitTimeout = 6
+)
+
+// doStatusCheck probes each endpoint and then sends one canary span, exiting
+// 0 only when it was accepted within --timeout, or with the exit code for
+// the stage that failed. It prints nothing but the failure, to stderr.
+func doStatusCheck(ctx context.Context, config Config) {
+	// the span has to reach the endpoint for the check to mean anything
+	config = config.WithAgentSocket("").WithAsync(false).WithSpoolDir("")
+	if !config.GetIsRecording() || config.Exporter == "console" {
+		statusCheckFailed(checkExitFailed, "an endpoint is required to check")
+	}
+
+	for _, probe := range config.probeEndpoints(ctx) {
+		if code, stage := probe.checkExitCode(); code != 0 {
+			statusCheckFailed(code, "%s failed at %s: %s", probe.Endpoint, stage, probe.Error)
+		}
+	}
+
+	start := time.Now()
+	ctx, client := StartClient(ctx, config)
+	span := config.NewProtobufSpan()
+	span.Name = "otel-cli status check"
+	span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+	ctx, err := otlpclient.SendSpan(ctx, client, config, span)
+	if _, stopErr := client.Stop(ctx); err == nil {
+		err = stopErr
+	}
+	if err != nil {
+		code := checkExitFailed
+		if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+			code = checkExitTimeout
+		}
+		statusCheckFailed(code, "the span was not accepted: %s", err)
+	}
+
+	config.SoftLog("the span was accepted in %s", time.Since(start))
+	os.Exit(0)
+}
+
+// statusCheckFailed prints why the check failed to stderr and exits with code.
+func statusCheckFailed(code int, format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "otel-cli status --check: "+format+"\n", a...)
+	os.Exit(code)
+}
+
+// checkExitCode returns the status --check exit code and name of the stage
+// the probe failed at, or 0 when it didn't fail. Running out of time at
+// any stage is a timeout.
+func (sp StatusProbe) checkExitCode() (int, string) {
+	switch {
+	case sp.Error == "":
+		return 0, ""
+	case sp.Dns != nil && sp.Dns.Error != "":
+		return sp.Dns.checkExitCode(checkExitDns), "dns"
+	case sp.Connect != nil && sp.Connect.Error != "":
+		return sp.Connect.checkExitCode(checkExitConnect), "connect"
+	case sp.Tls != nil && sp.Tls.Error != "":
+		return sp.Tls.checkExitCode(checkExitTls), "tls"
+	case sp.Response != nil && sp.Response.Error != "":
+		if sp.Response.unauthorized() {
+			return checkExitAuth, "response"
+		}
+		return sp.Response.checkExitCode(checkExitFailed), "response"
+	}
+	return checkExitFailed, "probe"
+}
+
+// checkExitCode returns code, or the timeout exit code if the stage timed out.
+func (ps ProbeStage) checkExitCode(code int) int {
+	if ps.Timeout {
+		return checkExitTimeout
+	}
+	return code
+}
+
+// unauthorized returns true when the server refused the request's
+// credentials, over either HTTP or gRPC.
+func (pr ProbeResponse) unauthorized() bool {
+	for _, s := range []string{"401", "403", codes.Unauthenticated.String(), codes.PermissionDenied.String()} {
+		if pr.Status == s || strings.HasPrefix(pr.Status, s+" ") {
+			return true
+		}
+	}
+	return false
+}
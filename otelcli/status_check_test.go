@@ -0,0 +1,116 @@
+package otelcli
+
+import (
+	"testing"
+)
+
+func TestStatusProbeCheckExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		probe StatusProbe
+		code  int
+		stage string
+	}{
+		{
+			name: "ok",
+			probe: StatusProbe{
+				Dns:      &ProbeDns{},
+				Connect:  &ProbeConnect{},
+				Response: &ProbeResponse{Status: "200 OK"},
+			},
+		},
+		{
+			name: "dns",
+			probe: StatusProbe{
+				Dns:   &ProbeDns{ProbeStage: ProbeStage{Error: "no such host"}},
+				Error: "no such host",
+			},
+			code:  checkExitDns,
+			stage: "dns",
+		},
+		{
+			name: "connect",
+			probe: StatusProbe{
+				Dns:     &ProbeDns{},
+				Connect: &ProbeConnect{ProbeStage: ProbeStage{Error: "connection refused"}},
+				Error:   "connection refused",
+			},
+			code:  checkExitConnect,
+			stage: "connect",
+		},
+		{
+			name: "connect timeout",
+			probe: StatusProbe{
+				Dns:     &ProbeDns{},
+				Connect: &ProbeConnect{ProbeStage: ProbeStage{Error: "i/o timeout", Timeout: true}},
+				Error:   "i/o timeout",
+			},
+			code:  checkExitTimeout,
+			stage: "connect",
+		},
+		{
+			name: "tls",
+			probe: StatusProbe{
+				Dns:     &ProbeDns{},
+				Connect: &ProbeConnect{},
+				Tls:     &ProbeTls{ProbeStage: ProbeStage{Error: "certificate signed by unknown authority"}},
+				Error:   "certificate signed by unknown authority",
+			},
+			code:  checkExitTls,
+			stage: "tls",
+		},
+		{
+			name: "http auth",
+			probe: StatusProbe{
+				Connect:  &ProbeConnect{},
+				Response: &ProbeResponse{ProbeStage: ProbeStage{Error: "server responded with 401 Unauthorized"}, Status: "401 Unauthorized"},
+				Error:    "server responded with 401 Unauthorized",
+			},
+			code:  checkExitAuth,
+			stage: "response",
+		},
+		{
+			name: "grpc auth",
+			probe: StatusProbe{
+				Connect:  &ProbeConnect{},
+				Response: &ProbeResponse{ProbeStage: ProbeStage{Error: "rpc error"}, Status: "PermissionDenied"},
+				Error:    "rpc error",
+			},
+			code:  checkExitAuth,
+			stage: "response",
+		},
+		{
+			name: "rejected",
+			probe: StatusProbe{
+				Connect:  &ProbeConnect{},
+				Response: &ProbeResponse{ProbeStage: ProbeStage{Error: "server responded with 4010 Weird"}, Status: "4010 Weird"},
+				Error:    "server responded with 4010 Weird",
+			},
+			code:  checkExitFailed,
+			stage: "response",
+		},
+		{
+			name: "response timeout",
+			probe: StatusProbe{
+				Connect:  &ProbeConnect{},
+				Response: &ProbeResponse{ProbeStage: ProbeStage{Error: "deadline exceeded", Timeout: true}, Status: "DeadlineExceeded"},
+				Error:    "deadline exceeded",
+			},
+			code:  checkExitTimeout,
+			stage: "response",
+		},
+		{
+			name:  "nothing to probe",
+			probe: StatusProbe{Error: "stdout endpoints are written locally"},
+			code:  checkExitFailed,
+			stage: "probe",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code, stage := tc.probe.checkExitCode()
+			if code != tc.code || stage != tc.stage {
+				t.Errorf("expected exit code %d at %q but got %d at %q", tc.code, tc.stage, code, stage)
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package otelcli
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// StatusProbe is what status --probe found out about an endpoint, one stage
+// of connecting at a time, so a failure can be pinned on DNS, the network,
+// TLS, or the server. Stages that weren't reached or don't apply are nil.
+type StatusProbe struct {
+	Endpoint string         `json:"endpoint"`
+	Protocol string         `json:"protocol"`
+	Dns      *ProbeDns      `json:"dns,omitempty"`
+	Connect  *ProbeConnect  `json:"connect,omitempty"`
+	Tls      *ProbeTls      `json:"tls,omitempty"`
+	Response *ProbeResponse `json:"response,omitempty"`
+	// Error is the error of the first stage that failed
+	Error string `json:"error,omitempty"`
+}
+
+// ProbeStage is how long a stage of the probe took and how it failed.
+// Timeout is set when it failed by running out of time.
+type ProbeStage struct {
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+	Timeout    bool    `json:"timeout,omitempty"`
+}
+
+// ProbeDns is the lookup of the endpoint's host, or its SRV record.
+type ProbeDns struct {
+	ProbeStage
+	Addresses []string `json:"addresses"`
+}
+
+// ProbeConnect is the TCP or unix socket connection to the endpoint.
+type ProbeConnect struct {
+	ProbeStage
+	Address string `json:"address"`
+}
+
+// ProbeTls is the TLS handshake. Certificates are listed even when they
+// fail verification, since that's usually when they're interesting.
+type ProbeTls struct {
+	ProbeStage
+	Version      string      `json:"version,omitempty"`
+	CipherSuite  string      `json:"cipher_suite,omitempty"`
+	Alpn         string      `json:"alpn,omitempty"`
+	ServerName   string      `json:"server_name"`
+	Verified     bool        `json:"verified"`
+	Certificates []ProbeCert `json:"certificates"`
+}
+
+// ProbeCert is one certificate of the chain the server presented.
+type ProbeCert struct {
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	DnsNames      []string  `json:"dns_names,omitempty"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	ExpiresInDays int       `json:"expires_in_days"`
+}
+
+// ProbeResponse is the server's answer to an empty OTLP export request.
+// Status is the HTTP status line or the gRPC status code. RoundTripMs
+// leaves out connecting, so it's close to the network latency plus however
+// long the server took.
+type ProbeResponse struct {
+	ProbeStage
+	Status      string  `json:"status"`
+	ContentType string  `json:"content_type,omitempty"`
+	RoundTripMs float64 `json:"round_trip_ms"`
+}
+
+// probeEndpoints probes each of the endpoints spans would be sent to, with
+// the same dynamic headers and OAuth2 token as the client.
+func (c Config) probeEndpoints(ctx context.Context) []StatusProbe {
+	// the console exporter and an agent without an endpoint of its own
+	// don't connect to anything to probe
+	if c.Exporter == "console" || (c.Endpoint == "" && c.getSignalEndpoint(c.getSignal()) == "" && len(c.Targets) == 0) {
+		return []StatusProbe{}
+	}
+
+	if extraHeaders := getExtraHeaders(ctx, c); len(extraHeaders) > 0 {
+		c = c.withExtraHeaders(extraHeaders)
+	}
+
+	if !c.IsFanout() {
+		return []StatusProbe{c.probeEndpoint(ctx)}
+	}
+
+	probes := []StatusProbe{}
+	for _, target := range c.GetTargetConfigs() {
+		probes = append(probes, target.probeEndpoint(ctx))
+	}
+	return probes
+}
+
+// probeEndpoint connects to the endpoint a stage at a time, stopping at the
+// first one that fails. The probe connects directly, without --proxy, and
+// the export request is sent on a connection of its own so the earlier
+// stages are timed without any of grpc-go's or net/http's retries.
+func (c Config) probeEndpoint(ctx context.Context) StatusProbe {
+	probe := StatusProbe{}
+
+	// srv:// endpoints are looked up here too, counting toward the DNS time
+	start := time.Now()
+	config, srvErr := c.resolveSRV(ctx)
+	endpointURL := config.GetEndpoint()
+	probe.Endpoint = endpointURL.String()
+	probe.Protocol = config.probeProtocol(endpointURL)
+	if srvErr != nil {
+		probe.Dns = &ProbeDns{ProbeStage: probeStage(start, srvErr), Addresses: []string{}}
+		return probe.failed(srvErr)
+	}
+
+	switch endpointURL.Scheme {
+	case "stdout", "stderr", "file":
+		return probe.failed(fmt.Errorf("%s endpoints are written locally, there's no connection to probe", endpointURL.Scheme))
+	}
+
+	network, address := "unix", otlpclient.UnixSocketPath(endpointURL)
+	if endpointURL.Scheme != "unix" {
+		network = "tcp"
+		host, port := endpointURL.Hostname(), probePort(endpointURL)
+		addrs, err := []string{host}, error(nil)
+		if net.ParseIP(host) == nil {
+			addrs, err = net.DefaultResolver.LookupHost(ctx, host)
+		}
+		probe.Dns = &ProbeDns{ProbeStage: probeStage(start, err), Addresses: addrs}
+		if err != nil {
+			probe.Dns.Addresses = []string{}
+			return probe.failed(err)
+		}
+		// connect to the first address so it's clear which one was tried
+		address = net.JoinHostPort(addrs[0], port)
+	}
+
+	start = time.Now()
+	dialer := net.Dialer{Timeout: config.GetConnectTimeout()}
+	conn, err := dialer.DialContext(ctx, network, address)
+	probe.Connect = &ProbeConnect{ProbeStage: probeStage(start, err), Address: address}
+	if err != nil {
+		return probe.failed(err)
+	}
+	defer conn.Close()
+
+	var tlsConfig *tls.Config
+	if network == "tcp" && !config.GetInsecure() {
+		tlsConfig = config.GetTlsConfig()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = endpointURL.Hostname()
+		}
+		probe.Tls = probeTls(ctx, conn, tlsConfig.Clone(), probe.Protocol)
+		if probe.Tls.Error != "" {
+			return probe.failed(errors.New(probe.Tls.Error))
+		}
+	}
+
+	switch probe.Protocol {
+	case "grpc":
+		probe.Response = config.probeGrpc(ctx, network, address, tlsConfig)
+	case "http":
+		probe.Response = config.probeHttp(ctx, endpointURL, network, address, tlsConfig)
+	default:
+		// zipkin, kafka, and grpc-web endpoints aren't OTLP over HTTP or
+		// gRPC, so the probe stops at the connection
+		return probe
+	}
+	if probe.Response.Error != "" {
+		return probe.failed(errors.New(probe.Response.Error))
+	}
+
+	return probe
+}
+
+// failed returns the probe with its error set to err.
+func (sp StatusProbe) failed(err error) StatusProbe {
+	sp.Error = err.Error()
+	return sp
+}
+
+// probeProtocol returns grpc or http for OTLP endpoints, deciding the same
+// way as newClient, or the scheme or protocol of anything else.
+func (c Config) probeProtocol(endpointURL *url.URL) string {
+	switch {
+	case endpointURL.Scheme == "zipkin" || endpointURL.Scheme == "zipkins":
+		return "zipkin"
+	case endpointURL.Scheme == "kafka" || endpointURL.Scheme == "kafkas":
+		return "kafka"
+	case c.Protocol == "grpc-web":
+		return "grpc-web"
+	case c.Protocol != "grpc" && (strings.HasPrefix(c.Protocol, "http/") ||
+		endpointURL.Scheme == "http" || endpointURL.Scheme == "https"):
+		return "http"
+	}
+	return "grpc"
+}
+
+// probePort returns the endpoint's port, or the usual one for its scheme.
+func probePort(endpointURL *url.URL) string {
+	if port := endpointURL.Port(); port != "" {
+		return port
+	}
+	switch endpointURL.Scheme {
+	case "https", "zipkins":
+		return "443"
+	case "http", "zipkin":
+		return "80"
+	case "kafka", "kafkas":
+		return "9092"
+	}
+	return "4317"
+}
+
+// probeStage returns the stage that started at start and ended with err.
+func probeStage(start time.Time, err error) ProbeStage {
+	stage := ProbeStage{DurationMs: probeMs(time.Since(start))}
+	if err != nil {
+		var netErr net.Error
+		stage.Error = err.Error()
+		stage.Timeout = errors.Is(err, context.DeadlineExceeded) ||
+			(errors.As(err, &netErr) && netErr.Timeout()) ||
+			status.Code(err) == codes.DeadlineExceeded
+	}
+	return stage
+}
+
+// probeMs returns the duration in milliseconds, to the microsecond.
+func probeMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// probeTls does a TLS handshake on conn, offering the ALPN protocols the
+// client would for the OTLP protocol.
+func probeTls(ctx context.Context, conn net.Conn, tlsConfig *tls.Config, protocol string) *ProbeTls {
+	if protocol == "grpc" {
+		tlsConfig.NextProtos = []string{"h2"}
+	} else {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	start := time.Now()
+	tlsConn := tls.Client(conn, tlsConfig)
+	err := tlsConn.HandshakeContext(ctx)
+	pt := ProbeTls{
+		ProbeStage:   probeStage(start, err),
+		ServerName:   tlsConfig.ServerName,
+		Certificates: []ProbeCert{},
+	}
+
+	certs := []*x509.Certificate{}
+	verifyErr := &tls.CertificateVerificationError{}
+	if err == nil {
+		state := tlsConn.ConnectionState()
+		pt.Version = tls.VersionName(state.Version)
+		pt.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+		pt.Alpn = state.NegotiatedProtocol
+		pt.Verified = !tlsConfig.InsecureSkipVerify
+		certs = state.PeerCertificates
+	} else if errors.As(err, &verifyErr) {
+		certs = verifyErr.UnverifiedCertificates
+	}
+
+	for _, cert := range certs {
+		pt.Certificates = append(pt.Certificates, ProbeCert{
+			Subject:       cert.Subject.String(),
+			Issuer:        cert.Issuer.String(),
+			DnsNames:      cert.DNSNames,
+			NotBefore:     cert.NotBefore,
+			NotAfter:      cert.NotAfter,
+			ExpiresInDays: int(time.Until(cert.NotAfter).Hours() / 24),
+		})
+	}
+
+	return &pt
+}
+
+// probeGrpc connects to the gRPC server and sends it an empty trace export
+// request with the configured headers, timing the call on its own.
+func (c Config) probeGrpc(ctx context.Context, network, address string, tlsConfig *tls.Config) *ProbeResponse {
+	target := address
+	if network == "unix" {
+		target = "unix://" + address
+	}
+
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	start := time.Now()
+	conn, err := grpc.DialContext(ctx, target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(c.GetUserAgent()),
+		grpc.WithBlock(),
+		grpc.WithReturnConnectionError(),
+	)
+	if err != nil {
+		return &ProbeResponse{ProbeStage: probeStage(start, err), Status: status.Code(err).String()}
+	}
+	defer conn.Close()
+
+	md := metadata.New(c.GetHeaders())
+	for k, v := range c.GetGrpcMetadata() {
+		md.Set(k, v)
+	}
+
+	sent := time.Now()
+	_, err = coltracepb.NewTraceServiceClient(conn).Export(metadata.NewOutgoingContext(ctx, md), &coltracepb.ExportTraceServiceRequest{})
+	return &ProbeResponse{
+		ProbeStage:  probeStage(start, err),
+		Status:      status.Code(err).String(),
+		RoundTripMs: probeMs(time.Since(sent)),
+	}
+}
+
+// probeHttp POSTs an empty trace export request with the configured
+// headers to the endpoint, timing from having a connection to the first
+// byte of the response.
+func (c Config) probeHttp(ctx context.Context, endpointURL *url.URL, network, address string, tlsConfig *tls.Config) *ProbeResponse {
+	dialer := net.Dialer{Timeout: c.GetConnectTimeout()}
+	client := http.Client{Transport: &http.Transport{
+		// always dial the address the connect stage did
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		},
+		TLSClientConfig:   tlsConfig,
+		ForceAttemptHTTP2: true,
+	}}
+
+	reqURL := endpointURL.String()
+	if network == "unix" {
+		reqURL = "http://localhost/v1/traces"
+	}
+
+	contentType, body := "application/x-protobuf", ""
+	if c.GetProtocol() == "http/json" {
+		contentType, body = "application/json", "{}"
+	}
+
+	var gotConn, gotByte time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn:              func(httptrace.GotConnInfo) { gotConn = time.Now() },
+		GotFirstResponseByte: func() { gotByte = time.Now() },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "POST", reqURL, strings.NewReader(body))
+	if err != nil {
+		return &ProbeResponse{ProbeStage: probeStage(time.Now(), err)}
+	}
+	for k, v := range c.GetHeaders() {
+		req.Header.Add(k, v)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.GetUserAgent())
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return &ProbeResponse{ProbeStage: probeStage(start, err)}
+	}
+	resp.Body.Close()
+
+	pr := ProbeResponse{
+		ProbeStage:  probeStage(start, nil),
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if !gotByte.IsZero() {
+		pr.RoundTripMs = probeMs(gotByte.Sub(gotConn))
+	}
+	if resp.StatusCode >= 300 {
+		pr.Error = fmt.Sprintf("server responded with %s", resp.Status)
+	}
+
+	return &pr
+}
@@ -0,0 +1,138 @@
+package otelcli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestProbeEndpointHttps(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotAuth = req.Header.Get("Authorization")
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	config := DefaultConfig().
+		WithEndpoint(server.URL).
+		WithTlsNoVerify(true).
+		WithHeaders(map[string]string{"Authorization": "Bearer s3cret"})
+	probe := config.probeEndpoint(ctx)
+
+	if probe.Error != "" {
+		t.Fatalf("expected the probe to succeed but got %q", probe.Error)
+	}
+	if probe.Protocol != "http" {
+		t.Errorf("expected protocol http but got %q", probe.Protocol)
+	}
+	if probe.Dns == nil || len(probe.Dns.Addresses) != 1 || probe.Dns.Addresses[0] != "127.0.0.1" {
+		t.Errorf("expected DNS to pass the IP through but got %+v", probe.Dns)
+	}
+	if probe.Connect == nil || probe.Connect.Address != server.Listener.Addr().String() {
+		t.Errorf("expected a connection to %s but got %+v", server.Listener.Addr(), probe.Connect)
+	}
+	if probe.Tls == nil {
+		t.Fatal("expected a TLS handshake")
+	}
+	if probe.Tls.Version == "" || probe.Tls.CipherSuite == "" {
+		t.Errorf("expected the TLS version and cipher suite but got %+v", probe.Tls)
+	}
+	if probe.Tls.Verified {
+		t.Error("expected the certificate to be unverified with --tls-no-verify")
+	}
+	if len(probe.Tls.Certificates) != 1 || probe.Tls.Certificates[0].ExpiresInDays <= 0 {
+		t.Errorf("expected httptest's certificate but got %+v", probe.Tls.Certificates)
+	}
+	if probe.Response == nil || probe.Response.Status != "200 OK" || probe.Response.ContentType != "application/x-protobuf" {
+		t.Errorf("expected a 200 protobuf response but got %+v", probe.Response)
+	}
+	if gotAuth != "Bearer s3cret" {
+		t.Errorf("expected the probe to send the configured headers but got %q", gotAuth)
+	}
+}
+
+func TestProbeEndpointUntrustedCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	probe := DefaultConfig().WithEndpoint(server.URL).probeEndpoint(ctx)
+
+	if probe.Tls == nil || probe.Tls.Error == "" || probe.Error != probe.Tls.Error {
+		t.Fatalf("expected the TLS handshake to fail but got %+v", probe)
+	}
+	if len(probe.Tls.Certificates) != 1 {
+		t.Errorf("expected the unverified certificate to be listed but got %+v", probe.Tls.Certificates)
+	}
+	if probe.Response != nil {
+		t.Errorf("expected the probe to stop at TLS but got a response %+v", probe.Response)
+	}
+}
+
+func TestProbeEndpointRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	endpoint := "http://" + listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	probe := DefaultConfig().WithEndpoint(endpoint).probeEndpoint(ctx)
+
+	if probe.Connect == nil || !strings.Contains(probe.Connect.Error, "refused") {
+		t.Fatalf("expected the connection to be refused but got %+v", probe.Connect)
+	}
+	if probe.Tls != nil || probe.Response != nil {
+		t.Errorf("expected the probe to stop at connect but got %+v", probe)
+	}
+}
+
+func TestProbeEndpointGrpc(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	server := otlpserver.NewGrpcServer(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		return false
+	}, func(otlpserver.OtlpServer) {})
+	server.SetAuthenticator(serverAuthenticator(DefaultConfig().WithServerAuthToken("s3cret")))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	config := DefaultConfig().WithEndpoint(listener.Addr().String())
+
+	probe := config.probeEndpoint(ctx)
+	if probe.Protocol != "grpc" || probe.Tls != nil {
+		t.Errorf("expected insecure gRPC to localhost but got %+v", probe)
+	}
+	if probe.Response == nil || probe.Response.Status != "Unauthenticated" || probe.Error == "" {
+		t.Errorf("expected the probe without a token to be unauthenticated but got %+v", probe.Response)
+	}
+
+	probe = config.WithHeaders(map[string]string{"authorization": "Bearer s3cret"}).probeEndpoint(ctx)
+	if probe.Error != "" || probe.Response == nil || probe.Response.Status != "OK" {
+		t.Errorf("expected the probe with a token to be accepted but got %+v", probe)
+	}
+}
+
+func TestProbeEndpointLocal(t *testing.T) {
+	probe := DefaultConfig().WithEndpoint("stdout://").probeEndpoint(context.Background())
+	if probe.Error == "" || probe.Dns != nil || probe.Connect != nil {
+		t.Errorf("expected nothing to probe for stdout but got %+v", probe)
+	}
+}
@@ -0,0 +1,82 @@
+package otelcli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// systemdExecCmd sets up `otel-cli systemd-exec`, which is exec with
+// notifications to systemd.
+func systemdExecCmd(config *Config) *cobra.Command {
+	cmd := execCmd(config)
+	cmd.Use = "systemd-exec"
+	cmd.Short = "execute the command provided in a span for a systemd unit"
+	cmd.Long = `Execute the command provided inside a span like exec, for use in ExecStart= of
+services and timers. Spans from any otel-cli command run in a systemd service
+get systemd.unit, systemd.invocation_id, and systemd.slice attributes, which
+match the _SYSTEMD_UNIT, _SYSTEMD_INVOCATION_ID, and _SYSTEMD_SLICE fields of
+the unit's journal entries.
+
+systemd-exec also tells systemd about the run when NOTIFY_SOCKET is set: it
+sends READY=1 once the command has started, so it works in Type=notify units,
+and sets the unit's status to the trace id, so it shows up in systemctl status,
+until the command exits.
+
+Example:
+	[Service]
+	Type=notify
+	Environment=OTEL_EXPORTER_OTLP_ENDPOINT=localhost:4317
+	ExecStart=/usr/local/bin/otel-cli systemd-exec --name backup -- /usr/local/bin/backup.sh
+`
+	cmd.Run = doSystemdExec
+
+	return cmd
+}
+
+func doSystemdExec(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	if os.Getenv("INVOCATION_ID") == "" {
+		config.SoftLog("INVOCATION_ID isn't set, otel-cli doesn't seem to be running in a systemd unit")
+	}
+
+	span := runExec(cmd, args, func(span *tracev1.Span) {
+		tp := otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
+		err := sdNotify(os.Getenv("NOTIFY_SOCKET"), fmt.Sprintf("READY=1\nSTATUS=running %s in trace %s", args[0], tp.TraceIdString()))
+		config.SoftLogIfErr(err)
+	})
+
+	tp := otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
+	err := sdNotify(os.Getenv("NOTIFY_SOCKET"), fmt.Sprintf("STOPPING=1\nSTATUS=%s exited with %d in trace %s", args[0], Diag.ExecExitCode, tp.TraceIdString()))
+	config.SoftLogIfErr(err)
+}
+
+// sdNotify sends the state to systemd's notification socket, like
+// sd_notify(3). It does nothing when socket is empty, i.e. NOTIFY_SOCKET
+// isn't set because systemd isn't listening.
+func sdNotify(socket, state string) error {
+	if socket == "" {
+		return nil
+	}
+	// sockets starting with @ are in the abstract namespace
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("unable to notify systemd: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("unable to notify systemd: %w", err)
+	}
+	return nil
+}
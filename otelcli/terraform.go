@@ -0,0 +1,297 @@
+package otelcli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// terraformWrapper holds the command-line configured settings for otel-cli terraform.
+var terraformWrapper struct {
+	bin string
+}
+
+// terraformJsonCommands are the terraform commands with -json output that
+// reports resource changes as they happen.
+var terraformJsonCommands = map[string]bool{"apply": true, "destroy": true, "refresh": true}
+
+// terraformMessage is a line of terraform's machine-readable UI output.
+// https://developer.hashicorp.com/terraform/internals/machine-readable-ui
+type terraformMessage struct {
+	Level     string    `json:"@level"`
+	Message   string    `json:"@message"`
+	Timestamp time.Time `json:"@timestamp"`
+	Type      string    `json:"type"`
+	Hook      struct {
+		Resource struct {
+			Addr            string `json:"addr"`
+			ResourceType    string `json:"resource_type"`
+			ImpliedProvider string `json:"implied_provider"`
+		} `json:"resource"`
+		Action  string `json:"action"`
+		IdValue string `json:"id_value"`
+	} `json:"hook"`
+	Changes struct {
+		Add       int    `json:"add"`
+		Change    int    `json:"change"`
+		Remove    int    `json:"remove"`
+		Operation string `json:"operation"`
+	} `json:"changes"`
+	Diagnostic struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+		Address  string `json:"address"`
+	} `json:"diagnostic"`
+}
+
+func terraformCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "terraform [-- terraform args...]",
+		Short: "run terraform with a span for each resource change",
+		Long: `Run terraform inside a span like exec. For apply, destroy, and refresh,
+otel-cli adds -json and turns terraform's machine-readable output into a
+child span for each resource it creates, updates, deletes, or reads, with
+how long it took and any error, while printing the usual messages.
+terraform requires -auto-approve with -json for apply and destroy. Use --
+before terraform's own arguments.
+
+Examples:
+	otel-cli terraform -- apply -auto-approve
+	otel-cli terraform --name "deploy prod" -- -chdir=envs/prod apply -auto-approve
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  doTerraform,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&terraformWrapper.bin, "terraform", "terraform", "the terraform program to run")
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+	addClientParams(&cmd, config)
+	addClockSkewParams(&cmd, config)
+
+	return &cmd
+}
+
+func doTerraform(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	if config.SpanName == DefaultConfig().SpanName {
+		config.SpanName = "terraform " + strings.Join(args, " ")
+	}
+	span := config.NewProtobufSpan()
+
+	// only ask for -json when there's somewhere to send the resource spans
+	jsonOutput := false
+	if config.GetIsRecording() {
+		args, jsonOutput = terraformJsonArgs(args)
+	}
+	child := exec.Command(terraformWrapper.bin, args...)
+	child.Stdin = os.Stdin
+	child.Stderr = os.Stderr
+	child.Env = []string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "TRACEPARENT=") {
+			child.Env = append(child.Env, env)
+		}
+	}
+	if config.GetIsRecording() {
+		tp := otlpclient.TraceparentFromProtobufSpan(span, config.IsSampled(span))
+		child.Env = append(child.Env, "TRACEPARENT="+tp.Encode())
+	}
+
+	// anything else goes straight through so prompts show up
+	var stdout io.Reader
+	if jsonOutput {
+		pipe, err := child.StdoutPipe()
+		if err != nil {
+			config.SoftFail("unable to read terraform's output: %s", err)
+		}
+		stdout = pipe
+	} else {
+		child.Stdout = os.Stdout
+	}
+
+	// ctrl-c goes to terraform too, since it's in the same process group,
+	// and terraform stops gracefully on the first one
+	signal.Ignore(os.Interrupt)
+
+	var spans []*tracepb.Span
+	if err := child.Start(); err != nil {
+		otlpclient.SetSpanStatus(span, "error", fmt.Sprintf("terraform failed: %s", err))
+	} else {
+		if jsonOutput {
+			spans = parseTerraformJson(stdout, os.Stdout, span)
+		}
+		if err := child.Wait(); err != nil {
+			otlpclient.SetSpanStatus(span, "error", fmt.Sprintf("terraform failed: %s", err))
+		}
+	}
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+	spans = append([]*tracepb.Span{span}, spans...)
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+
+	ctx, client := StartClient(ctx, config)
+	if config.IsSampled(span) {
+		config.correctClockSkew(ctx, spans...)
+		var err error
+		ctx, err = otlpclient.SendSpans(ctx, client, config, spans)
+		if err != nil {
+			config.SoftFail("unable to send spans: %s", err)
+		}
+	}
+
+	_, err := client.Stop(ctx)
+	if err != nil {
+		config.SoftFail("client.Stop() failed: %s", err)
+	}
+
+	if child.ProcessState != nil {
+		Diag.ExecExitCode = child.ProcessState.ExitCode()
+	} else {
+		Diag.ExecExitCode = 1
+	}
+
+	config.PropagateTraceparent(span, os.Stdout)
+}
+
+// terraformJsonArgs adds -json after the terraform command when it's one
+// that reports resource changes, and returns whether the output is JSON.
+// Global options like -chdir come before the command.
+func terraformJsonArgs(args []string) ([]string, bool) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !terraformJsonCommands[arg] {
+			return args, false
+		}
+		for _, rest := range args[i+1:] {
+			if rest == "-json" || rest == "--json" {
+				return args, true
+			}
+		}
+		out := append([]string{}, args[:i+1]...)
+		out = append(out, "-json")
+		return append(out, args[i+1:]...), true
+	}
+	return args, false
+}
+
+// parseTerraformJson reads terraform's output, printing the human-readable
+// message for each JSON line to w and anything else as-is, and returns a
+// span for each resource change, children of parent. The change summary
+// is added to parent's attributes.
+func parseTerraformJson(r io.Reader, w io.Writer, parent *tracepb.Span) []*tracepb.Span {
+	spans := []*tracepb.Span{}
+	running := map[string]*tracepb.Span{}
+	byAddr := map[string]*tracepb.Span{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		msg := terraformMessage{}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.Type == "" {
+			fmt.Fprintln(w, scanner.Text())
+			continue
+		}
+		if msg.Message != "" {
+			fmt.Fprintln(w, msg.Message)
+		}
+
+		addr, action := msg.Hook.Resource.Addr, msg.Hook.Action
+		key := addr + " " + action
+		switch msg.Type {
+		case "apply_start", "refresh_start":
+			if msg.Type == "refresh_start" {
+				action, key = "read", addr+" read"
+			}
+			span := otlpclient.NewProtobufSpan()
+			span.TraceId = parent.TraceId
+			span.SpanId = otlpclient.GenerateSpanId()
+			span.ParentSpanId = parent.SpanId
+			span.TraceState = parent.TraceState
+			span.Name = action + " " + addr
+			span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+			span.StartTimeUnixNano = uint64(msg.Timestamp.UnixNano())
+			span.EndTimeUnixNano = span.StartTimeUnixNano
+			span.Attributes = importAttrs(map[string]string{
+				"terraform.resource.address": addr,
+				"terraform.resource.type":    msg.Hook.Resource.ResourceType,
+				"terraform.provider":         msg.Hook.Resource.ImpliedProvider,
+				"terraform.action":           action,
+			})
+			running[key] = span
+			byAddr[addr] = span
+			spans = append(spans, span)
+		case "apply_complete", "apply_errored", "refresh_complete":
+			if msg.Type == "refresh_complete" {
+				key = addr + " read"
+			}
+			span, ok := running[key]
+			if !ok {
+				continue
+			}
+			delete(running, key)
+			span.EndTimeUnixNano = uint64(msg.Timestamp.UnixNano())
+			if msg.Hook.IdValue != "" {
+				span.Attributes = append(span.Attributes, importAttrs(map[string]string{"terraform.resource.id": msg.Hook.IdValue})...)
+			}
+			if msg.Type == "apply_errored" {
+				otlpclient.SetSpanStatus(span, "error", "apply failed")
+			} else {
+				otlpclient.SetSpanStatus(span, "ok", "")
+			}
+		case "diagnostic":
+			// errors about a resource come after apply_errored, so put the
+			// details on its span, and the rest on the run's span
+			if msg.Diagnostic.Severity != "error" {
+				continue
+			}
+			message := msg.Diagnostic.Summary
+			if msg.Diagnostic.Detail != "" {
+				message += ": " + msg.Diagnostic.Detail
+			}
+			if span, ok := byAddr[msg.Diagnostic.Address]; ok && msg.Diagnostic.Address != "" {
+				otlpclient.SetSpanStatus(span, "error", message)
+			} else {
+				otlpclient.SetSpanStatus(parent, "error", message)
+			}
+		case "change_summary":
+			parent.Attributes = append(parent.Attributes, otlpclient.StringMapAttrsToProtobuf(map[string]string{
+				"terraform.changes.add":    strconv.Itoa(msg.Changes.Add),
+				"terraform.changes.change": strconv.Itoa(msg.Changes.Change),
+				"terraform.changes.remove": strconv.Itoa(msg.Changes.Remove),
+			})...)
+		}
+	}
+
+	// anything still running was interrupted, end it with the run
+	now := uint64(time.Now().UnixNano())
+	for _, span := range running {
+		span.EndTimeUnixNano = now
+		otlpclient.SetSpanStatus(span, "error", "did not finish")
+	}
+
+	return spans
+}
@@ -0,0 +1,86 @@
+package otelcli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// terraformTestOutput is trimmed down from terraform apply -json.
+const terraformTestOutput = `{"@level":"info","@message":"Terraform 1.7.4","@module":"terraform.ui","@timestamp":"2024-03-09T14:30:00.000000Z","terraform":"1.7.4","type":"version","ui":"1.2"}
+{"@level":"info","@message":"null_resource.db: Refreshing state... [id=42]","@timestamp":"2024-03-09T14:30:00.100000Z","hook":{"resource":{"addr":"null_resource.db","resource_type":"null_resource","implied_provider":"null"},"id_key":"id","id_value":"42"},"type":"refresh_start"}
+{"@level":"info","@message":"null_resource.db: Refresh complete [id=42]","@timestamp":"2024-03-09T14:30:00.600000Z","hook":{"resource":{"addr":"null_resource.db","resource_type":"null_resource","implied_provider":"null"},"id_key":"id","id_value":"42"},"type":"refresh_complete"}
+{"@level":"info","@message":"aws_instance.web: Creating...","@timestamp":"2024-03-09T14:30:01.000000Z","hook":{"resource":{"addr":"aws_instance.web","resource_type":"aws_instance","implied_provider":"aws"},"action":"create"},"type":"apply_start"}
+{"@level":"info","@message":"aws_s3_bucket.logs: Destroying... [id=logs]","@timestamp":"2024-03-09T14:30:01.000000Z","hook":{"resource":{"addr":"aws_s3_bucket.logs","resource_type":"aws_s3_bucket","implied_provider":"aws"},"action":"delete","id_key":"id","id_value":"logs"},"type":"apply_start"}
+{"@level":"info","@message":"aws_instance.web: Still creating... [10s elapsed]","@timestamp":"2024-03-09T14:30:11.000000Z","hook":{"resource":{"addr":"aws_instance.web","resource_type":"aws_instance","implied_provider":"aws"},"action":"create","elapsed_seconds":10},"type":"apply_progress"}
+{"@level":"info","@message":"aws_instance.web: Creation complete after 15s [id=i-0abc]","@timestamp":"2024-03-09T14:30:16.000000Z","hook":{"resource":{"addr":"aws_instance.web","resource_type":"aws_instance","implied_provider":"aws"},"action":"create","id_key":"id","id_value":"i-0abc","elapsed_seconds":15},"type":"apply_complete"}
+{"@level":"info","@message":"aws_s3_bucket.logs: Destruction errored after 3s","@timestamp":"2024-03-09T14:30:04.000000Z","hook":{"resource":{"addr":"aws_s3_bucket.logs","resource_type":"aws_s3_bucket","implied_provider":"aws"},"action":"delete","elapsed_seconds":3},"type":"apply_errored"}
+{"@level":"error","@message":"Error: deleting S3 Bucket (logs): BucketNotEmpty","@timestamp":"2024-03-09T14:30:16.100000Z","diagnostic":{"severity":"error","summary":"deleting S3 Bucket (logs): BucketNotEmpty","detail":"","address":"aws_s3_bucket.logs"},"type":"diagnostic"}
+{"@level":"info","@message":"Apply complete! Resources: 1 added, 0 changed, 1 destroyed.","@timestamp":"2024-03-09T14:30:16.200000Z","changes":{"add":1,"change":0,"remove":1,"operation":"apply"},"type":"change_summary"}
+not json, passed through
+`
+
+func TestParseTerraformJson(t *testing.T) {
+	parent := otlpclient.NewProtobufSpan()
+	parent.Name = "terraform apply"
+	parent.TraceId = otlpclient.GenerateTraceId()
+	parent.SpanId = otlpclient.GenerateSpanId()
+
+	out := bytes.Buffer{}
+	spans := parseTerraformJson(strings.NewReader(terraformTestOutput), &out, parent)
+
+	got := []string{}
+	for _, span := range spans {
+		if string(span.ParentSpanId) != string(parent.SpanId) {
+			t.Errorf("expected %q to be a child of the terraform span", span.Name)
+		}
+		attrs := otlpclient.SpanAttributesToStringMap(span)
+		got = append(got, fmt.Sprintf("%s %s %s %q id=%s", span.Name,
+			time.Duration(span.EndTimeUnixNano-span.StartTimeUnixNano), span.Status.Code, span.Status.Message, attrs["terraform.resource.id"]))
+	}
+	want := []string{
+		`read null_resource.db 500ms STATUS_CODE_OK "" id=42`,
+		`create aws_instance.web 15s STATUS_CODE_OK "" id=i-0abc`,
+		`delete aws_s3_bucket.logs 3s STATUS_CODE_ERROR "deleting S3 Bucket (logs): BucketNotEmpty" id=`,
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("unexpected spans\nwant: %q\n got: %q", want, got)
+	}
+
+	attrs := otlpclient.SpanAttributesToStringMap(parent)
+	if attrs["terraform.changes.add"] != "1" || attrs["terraform.changes.remove"] != "1" {
+		t.Errorf("unexpected change summary attributes %q", attrs)
+	}
+	if parent.Status.Code != tracepb.Status_STATUS_CODE_UNSET {
+		t.Errorf("resource errors should stay on their spans but the terraform span got %q", parent.Status.Message)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[0] != "Terraform 1.7.4" || lines[len(lines)-1] != "not json, passed through" {
+		t.Errorf("expected the human-readable messages to be printed but got %q", lines)
+	}
+}
+
+func TestTerraformJsonArgs(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+		json bool
+	}{
+		{"apply -auto-approve", "apply -json -auto-approve", true},
+		{"-chdir=prod destroy -auto-approve", "-chdir=prod destroy -json -auto-approve", true},
+		{"apply -json -auto-approve", "apply -json -auto-approve", true},
+		{"plan -out=plan.tfplan", "plan -out=plan.tfplan", false},
+		{"init", "init", false},
+	} {
+		got, json := terraformJsonArgs(strings.Fields(tc.in))
+		if strings.Join(got, " ") != tc.want || json != tc.json {
+			t.Errorf("terraformJsonArgs(%q) = %q, %t, want %q, %t", tc.in, got, json, tc.want, tc.json)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package otelcli
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	"github.com/spf13/cobra"
+)
+
+// tpCmd represents the tp command, for working with traceparents
+func tpCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "tp",
+		Short: "traceparent helpers",
+		Long:  "Helpers for sharing traceparents between otel-cli invocations. See subcommands.",
+	}
+
+	cmd.AddCommand(tpRegisterCmd(config))
+	cmd.AddCommand(tpLookupCmd(config))
+
+	return &cmd
+}
+
+// tpRegisterCmd represents the tp register command
+func tpRegisterCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "register",
+		Short: "publish the current traceparent under a name",
+		Long: `Publish the current traceparent, from the TRACEPARENT envvar or --tp-carrier,
+into a registry directory under the provided name so that other jobs can find it
+with 'otel-cli tp lookup'. The directory is lock-protected so it is safe to use
+from parallel make or CI jobs.
+
+Example:
+	export OTEL_CLI_TP_REGISTRY_DIR=$(mktemp -d)
+	otel-cli exec --name build-step-1 -- \
+		otel-cli tp register --name build-step-1
+	otel-cli tp lookup --name build-step-1 --tp-export
+`,
+		Run: doTpRegister,
+	}
+
+	cmd.Flags().SortFlags = false
+	addTpRegistryParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.TraceparentCarrierFile, "tp-carrier", defaults.TraceparentCarrierFile, "a file to read the traceparent from")
+	cmd.Flags().BoolVar(&config.TraceparentIgnoreEnv, "tp-ignore-env", defaults.TraceparentIgnoreEnv, "ignore the TRACEPARENT envvar even if it's set")
+
+	return &cmd
+}
+
+// tpLookupCmd represents the tp lookup command
+func tpLookupCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "lookup",
+		Short: "print a traceparent that was published with tp register",
+		Long: `Look up a traceparent that was published with 'otel-cli tp register' and
+print it. The output format follows --tp-print-format and --tp-export. With
+--wait, lookup polls until the name is registered or --timeout is reached.
+
+Example:
+	eval $(otel-cli tp lookup --name build-step-1 --wait --timeout 30s --tp-export)
+`,
+		Run: doTpLookup,
+	}
+
+	cmd.Flags().SortFlags = false
+	addTpRegistryParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().BoolVar(&config.TraceparentRegistryWait, "wait", defaults.TraceparentRegistryWait, "wait until the name is registered, up to --timeout")
+	cmd.Flags().BoolVarP(&config.TraceparentPrintExport, "tp-export", "p", defaults.TraceparentPrintExport, "put an 'export ' in front of the traceparent so it's more convinenient to source in scripts")
+	cmd.Flags().StringVar(&config.TraceparentPrintFormat, "tp-print-format", defaults.TraceparentPrintFormat, "output format, one of w3c, hex, trace-id, span-id, xray, b3, or json")
+
+	return &cmd
+}
+
+// addTpRegistryParams adds the flags shared by the tp registry subcommands.
+func addTpRegistryParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+
+	cmd.Flags().StringVar(&config.TraceparentRegistryDir, "dir", defaults.TraceparentRegistryDir, "the registry directory, shared by all participating jobs")
+	cmd.Flags().StringVar(&config.TraceparentRegistryName, "name", defaults.TraceparentRegistryName, "the name to register or look up")
+	cmd.MarkFlagRequired("name")
+	cmd.Flags().StringVarP(&config.CfgFile, "config", "c", defaults.CfgFile, "YAML or JSON configuration file, defaults to ~/.config/otel-cli/config.yaml if it exists")
+	cmd.Flags().StringVar(&config.Profile, "profile", defaults.Profile, "name of a profile in the configuration file to apply")
+	cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for acquiring the registry lock and for --wait")
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	addLogParams(cmd, config)
+	cmd.Flags().BoolVar(&config.Fail, "fail", defaults.Fail, "on failure, exit with a non-zero status")
+}
+
+func doTpRegister(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	registry, err := newTpRegistry(config.TraceparentRegistryDir, config.ParseCliTimeout())
+	config.SoftFailIfErr(err)
+
+	// LoadTraceparent returns an all-zero traceparent when there is none
+	tp := config.LoadTraceparent()
+	if !tp.Initialized || bytes.Equal(tp.TraceId, otlpclient.GetEmptyTraceId()) {
+		config.SoftFail("no traceparent found in TRACEPARENT or --tp-carrier to register as %q", config.TraceparentRegistryName)
+	}
+
+	carrier := config.LoadCarrier()
+	carrier.Traceparent = tp.Encode()
+
+	err = registry.Register(config.TraceparentRegistryName, carrier)
+	config.SoftFailIfErr(err)
+}
+
+func doTpLookup(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	registry, err := newTpRegistry(config.TraceparentRegistryDir, config.ParseCliTimeout())
+	config.SoftFailIfErr(err)
+
+	carrier, err := registry.Lookup(config.TraceparentRegistryName, config.TraceparentRegistryWait)
+	config.SoftFailIfErr(err)
+
+	tp, err := carrier.GetTraceparent()
+	config.SoftFailIfErr(err)
+
+	config.PrintTraceparent(tp, os.Stdout)
+}
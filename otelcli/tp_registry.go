@@ -0,0 +1,195 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+)
+
+const tpRegistryLockfile = ".otel-cli-registry.lock"
+
+// tpRegistryStaleLock is how old a lockfile has to be to be broken when the
+// registry has no timeout. The lock is only held long enough to read or write
+// one small file.
+const tpRegistryStaleLock = 10 * time.Second
+
+var tpRegistryNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// tpRegistry is a directory of named carrier files that concurrent otel-cli
+// invocations use to publish and discover each other's traceparents. All
+// reads and writes happen while holding a lockfile in the directory.
+type tpRegistry struct {
+	dir     string
+	timeout time.Duration
+}
+
+// newTpRegistry returns a registry handle for the directory, creating the
+// directory if it doesn't exist yet.
+func newTpRegistry(dir string, timeout time.Duration) (*tpRegistry, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("a registry directory is required, set --dir or OTEL_CLI_TP_REGISTRY_DIR")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create registry directory '%s': %w", dir, err)
+	}
+
+	return &tpRegistry{dir: dir, timeout: timeout}, nil
+}
+
+// Register stores the carrier under the provided name, replacing any
+// previous entry with the same name.
+func (r *tpRegistry) Register(name string, carrier traceparent.Carrier) error {
+	entry, err := r.entryPath(name)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// write to a tempfile then rename so readers never see a partial file
+	tmp := entry + ".tmp"
+	if err := carrier.SaveToFile(tmp); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, entry)
+}
+
+// Lookup returns the carrier registered under name. When wait is true it
+// polls until the entry shows up or the registry timeout is reached.
+func (r *tpRegistry) Lookup(name string, wait bool) (traceparent.Carrier, error) {
+	entry, err := r.entryPath(name)
+	if err != nil {
+		return traceparent.Carrier{}, err
+	}
+
+	started := time.Now()
+	for {
+		carrier, err := r.read(entry)
+		if err == nil {
+			return carrier, nil
+		} else if !os.IsNotExist(err) {
+			return traceparent.Carrier{}, err
+		}
+
+		if !wait {
+			return traceparent.Carrier{}, fmt.Errorf("no traceparent registered with name %q", name)
+		} else if r.timeout > 0 && time.Since(started) > r.timeout {
+			return traceparent.Carrier{}, fmt.Errorf("timeout after %s waiting for traceparent %q to be registered", r.timeout, name)
+		}
+
+		time.Sleep(time.Millisecond * 25)
+	}
+}
+
+// read loads a registry entry while holding the lock. Errors from a missing
+// file are returned unwrapped so os.IsNotExist works on them.
+func (r *tpRegistry) read(entry string) (traceparent.Carrier, error) {
+	unlock, err := r.lock()
+	if err != nil {
+		return traceparent.Carrier{}, err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(entry); err != nil {
+		return traceparent.Carrier{}, err
+	}
+
+	return traceparent.LoadCarrierFromFile(entry)
+}
+
+// entryPath validates the name and returns the path to its carrier file.
+func (r *tpRegistry) entryPath(name string) (string, error) {
+	if !tpRegistryNameRe.MatchString(name) {
+		return "", fmt.Errorf("invalid registry name %q, only letters, numbers, '.', '_', and '-' are allowed", name)
+	}
+
+	return filepath.Join(r.dir, name+".json"), nil
+}
+
+// lock creates the lockfile exclusively, polling until it succeeds or the
+// registry timeout is reached. A lockfile left behind by a process that was
+// killed while holding it is broken. Returns a function that releases the lock.
+func (r *tpRegistry) lock() (func(), error) {
+	lockfile := filepath.Join(r.dir, tpRegistryLockfile)
+	started := time.Now()
+	for {
+		file, err := os.OpenFile(lockfile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(lockfile) }, nil
+		} else if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create registry lockfile '%s': %w", lockfile, err)
+		}
+
+		if stale, ok := r.staleLock(lockfile); ok {
+			breakLock(lockfile, stale)
+			continue
+		}
+
+		if r.timeout > 0 && time.Since(started) > r.timeout {
+			return nil, fmt.Errorf("timeout after %s waiting for registry lock '%s'", r.timeout, lockfile)
+		}
+
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+// staleLock returns the lockfile's info and true if its holder is gone,
+// either because the pid in it isn't running, or because it's older than the
+// registry timeout, or tpRegistryStaleLock without one.
+func (r *tpRegistry) staleLock(lockfile string) (os.FileInfo, bool) {
+	info, err := os.Stat(lockfile)
+	if err != nil {
+		return nil, false
+	}
+
+	staleAfter := r.timeout
+	if staleAfter <= 0 {
+		staleAfter = tpRegistryStaleLock
+	}
+	if time.Since(info.ModTime()) > staleAfter {
+		return info, true
+	}
+
+	// the holder might not have written its pid yet, then only age counts
+	data, err := os.ReadFile(lockfile)
+	if err != nil {
+		return nil, false
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return info, pid > 0 && pid != os.Getpid() && !processRunning(pid)
+}
+
+// breakLock removes the lockfile that stale was read from. Several waiters
+// can find the same lock stale, and by the time a slower one gets here
+// another may have broken it and taken a fresh lock. So the lockfile is
+// moved to a unique name first, which only one of them can do, and when
+// what was moved isn't the stale lock it's put back.
+func breakLock(lockfile string, stale os.FileInfo) {
+	tombstone := fmt.Sprintf("%s.stale-%d-%d", lockfile, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockfile, tombstone); err != nil {
+		// released or broken by someone else already
+		return
+	}
+	defer os.Remove(tombstone)
+
+	// inodes get reused, so the mtime and size have to match too
+	moved, err := os.Stat(tombstone)
+	if err == nil && !(os.SameFile(stale, moved) && moved.ModTime().Equal(stale.ModTime()) && moved.Size() == stale.Size()) {
+		// a link fails instead of replacing a lock taken in the meantime
+		os.Link(tombstone, lockfile)
+	}
+}
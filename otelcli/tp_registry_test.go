@@ -0,0 +1,144 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/w3c/traceparent"
+)
+
+func TestTpRegistry(t *testing.T) {
+	registry, err := newTpRegistry(filepath.Join(t.TempDir(), "registry"), time.Second)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+
+	testTp := "00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01"
+	tp, _ := traceparent.Parse(testTp)
+
+	// many concurrent registrations should all land without clobbering each other
+	names := []string{"build-step-1", "build-step-2", "test.unit", "test_e2e"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := registry.Register(name, traceparent.NewCarrier(tp)); err != nil {
+				t.Errorf("failed to register %q: %s", name, err)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	for _, name := range names {
+		carrier, err := registry.Lookup(name, false)
+		if err != nil {
+			t.Errorf("failed to look up %q: %s", name, err)
+		} else if carrier.Traceparent != testTp {
+			t.Errorf("expected traceparent %q for %q but got %q", testTp, name, carrier.Traceparent)
+		}
+	}
+
+	if _, err := registry.Lookup("not-registered", false); err == nil {
+		t.Error("expected an error looking up a name that was never registered")
+	}
+
+	for _, name := range []string{"../escape", "a/b", "", ".hidden"} {
+		if err := registry.Register(name, traceparent.NewCarrier(tp)); err == nil {
+			t.Errorf("expected an error registering invalid name %q", name)
+		}
+	}
+}
+
+func TestTpRegistryLookupWait(t *testing.T) {
+	registry, err := newTpRegistry(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+
+	tp, _ := traceparent.Parse("00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01")
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		registry.Register("late", traceparent.NewCarrier(tp))
+	}()
+
+	carrier, err := registry.Lookup("late", true)
+	if err != nil {
+		t.Fatalf("lookup with wait failed: %s", err)
+	}
+	if carrier.Traceparent != tp.Encode() {
+		t.Errorf("expected traceparent %q but got %q", tp.Encode(), carrier.Traceparent)
+	}
+}
+
+func TestTpRegistryStaleLock(t *testing.T) {
+	tp, _ := traceparent.Parse("00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01")
+	lockfile := func(dir string) string { return filepath.Join(dir, tpRegistryLockfile) }
+
+	// a lock held by a process that's gone is broken right away
+	registry, err := newTpRegistry(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	os.WriteFile(lockfile(registry.dir), []byte(fmt.Sprintf("%d\n", 1<<22+1)), 0600)
+	started := time.Now()
+	if err := registry.Register("dead-holder", traceparent.NewCarrier(tp)); err != nil {
+		t.Fatalf("expected the dead holder's lock to be broken but got %s", err)
+	}
+	if time.Since(started) > 500*time.Millisecond {
+		t.Errorf("expected the lock to be broken without waiting but it took %s", time.Since(started))
+	}
+
+	// a lock older than the timeout is broken even when its holder is running
+	registry, err = newTpRegistry(t.TempDir(), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	os.WriteFile(lockfile(registry.dir), []byte(fmt.Sprintf("%d\n", os.Getppid())), 0600)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(lockfile(registry.dir), old, old)
+	if err := registry.Register("old-lock", traceparent.NewCarrier(tp)); err != nil {
+		t.Fatalf("expected the old lock to be broken but got %s", err)
+	}
+
+	// a fresh lock held by a running process is waited on until it's stale
+	os.WriteFile(lockfile(registry.dir), []byte(fmt.Sprintf("%d\n", os.Getppid())), 0600)
+	started = time.Now()
+	if err := registry.Register("held", traceparent.NewCarrier(tp)); err != nil {
+		t.Fatalf("expected the lock to be broken once stale but got %s", err)
+	}
+	if time.Since(started) < 50*time.Millisecond {
+		t.Errorf("expected to wait for the held lock but it took %s", time.Since(started))
+	}
+}
+
+func TestTpRegistryBreakLock(t *testing.T) {
+	lockfile := filepath.Join(t.TempDir(), tpRegistryLockfile)
+
+	os.WriteFile(lockfile, []byte("1\n"), 0600)
+	stale, _ := os.Stat(lockfile)
+	breakLock(lockfile, stale)
+	if _, err := os.Stat(lockfile); !os.IsNotExist(err) {
+		t.Errorf("expected the stale lock to be removed but got %v", err)
+	}
+
+	// another waiter broke the stale lock and took a fresh one before this
+	// one got to it, so the fresh lock has to survive
+	os.WriteFile(lockfile, []byte("1\n"), 0600)
+	stale, _ = os.Stat(lockfile)
+	os.Remove(lockfile)
+	os.WriteFile(lockfile, []byte("12\n"), 0600)
+	breakLock(lockfile, stale)
+	if data, err := os.ReadFile(lockfile); err != nil || string(data) != "12\n" {
+		t.Errorf("expected the fresh lock to be kept but got %q, %v", data, err)
+	}
+
+	matches, _ := filepath.Glob(lockfile + ".stale-*")
+	if len(matches) != 0 {
+		t.Errorf("expected no tombstones to be left behind but found %v", matches)
+	}
+}
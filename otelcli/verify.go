@@ -0,0 +1,60 @@
+package otelcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// verifyCmd represents the verify command
+func verifyCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "verify",
+		Short: "check attributes against the OpenTelemetry semantic conventions",
+		Long: `Checks the attributes and resource attributes otel-cli would send
+against a bundled copy of the semantic conventions registry, reporting
+deprecated names, values of the wrong type, and keys that look like typos of
+registry keys. Attributes that aren't in the registry and aren't close to a
+registry key are assumed to be custom and left alone. Nothing is sent. Exits
+non-zero if there are problems. Takes the same flags as span and exec, so a
+command line can be checked by swapping the subcommand.
+
+Example:
+	otel-cli verify --attrs http.method=GET,http.response.status_code=ok
+`,
+		Run: doVerify,
+	}
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addAttrsFileParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doVerify(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+
+	if !writeVerifyReport(os.Stdout, config.semconvErrors()) {
+		os.Exit(1)
+	}
+}
+
+// writeVerifyReport prints the problems found by verify and returns true if
+// there weren't any.
+func writeVerifyReport(w io.Writer, errs []error) bool {
+	if len(errs) > 0 {
+		fmt.Fprintf(w, "FAIL: %d problem(s) found\n", len(errs))
+		for _, err := range errs {
+			fmt.Fprintf(w, "  %s\n", err)
+		}
+		return false
+	}
+
+	fmt.Fprintf(w, "PASS: attributes follow semantic conventions v%s\n", bundledSemconv.Version)
+	return true
+}
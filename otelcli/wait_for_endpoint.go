@@ -0,0 +1,128 @@
+package otelcli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// waitForEndpoint holds the command-line configured settings for otel-cli
+// wait-for-endpoint.
+var waitForEndpoint struct {
+	wait        string
+	interval    string
+	maxInterval string
+}
+
+func waitForEndpointCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "wait-for-endpoint",
+		Short: "block until the endpoint accepts OTLP",
+		Long: `Probes the endpoint the same way as status --probe until it accepts an
+empty OTLP export request, or --wait runs out, for init containers and boot
+scripts that shouldn't start sending spans before the collector is up.
+Attempts start --interval apart, doubling up to --max-interval, for as long
+as they'd start within --wait, and each one can take up to --timeout. With
+more than one endpoint, all of them have to accept.
+
+Exits 0 as soon as the endpoint accepts, printing nothing. When --wait runs
+out, prints the last failure to stderr and exits with the same code that
+status --check would have for it.
+
+Example:
+	otel-cli wait-for-endpoint --endpoint otel-collector:4317 --wait 2m
+	otel-cli wait-for-endpoint && exec my-app
+`,
+		Args: cobra.NoArgs,
+		Run:  doWaitForEndpoint,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&waitForEndpoint.wait, "wait", "60s", "how long to keep trying before giving up")
+	cmd.Flags().StringVar(&waitForEndpoint.interval, "interval", "250ms", "how long to wait after the first failed attempt")
+	cmd.Flags().StringVar(&waitForEndpoint.maxInterval, "max-interval", "5s", "the most time to wait between attempts")
+
+	addCommonParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doWaitForEndpoint(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+
+	wait, err := parseDuration(waitForEndpoint.wait)
+	if err != nil {
+		config.SoftFail("invalid --wait: %s", err)
+	}
+	interval, err := parseDuration(waitForEndpoint.interval)
+	if err != nil {
+		config.SoftFail("invalid --interval: %s", err)
+	}
+	maxInterval, err := parseDuration(waitForEndpoint.maxInterval)
+	if err != nil {
+		config.SoftFail("invalid --max-interval: %s", err)
+	}
+
+	// an agent isn't the collector, so it's skipped like status --check does
+	config = config.WithAgentSocket("")
+	if !config.GetIsRecording() || config.Exporter == "console" {
+		fmt.Fprintln(os.Stderr, "otel-cli wait-for-endpoint: an endpoint is required to wait for")
+		os.Exit(checkExitFailed)
+	}
+
+	probe, attempts := config.waitForEndpoint(ctx, wait, interval, maxInterval)
+	if code, stage := probe.checkExitCode(); code != 0 {
+		fmt.Fprintf(os.Stderr, "otel-cli wait-for-endpoint: %s still failed at %s after %d attempts: %s\n", probe.Endpoint, stage, attempts, probe.Error)
+		os.Exit(code)
+	}
+}
+
+// waitForEndpoint probes the endpoints until they all pass or the next
+// attempt would start after wait runs out, returning the last failed probe,
+// or an empty one when they passed, and the number of attempts. Each
+// attempt gets all of --timeout, even the last, so its failure is the
+// endpoint's and not from being cut short. The time between attempts
+// starts at interval and doubles up to maxInterval.
+func (c Config) waitForEndpoint(ctx context.Context, wait, interval, maxInterval time.Duration) (StatusProbe, int) {
+	deadline := time.Now().Add(wait)
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout := c.GetTimeout(); timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		failed := StatusProbe{}
+		for _, probe := range c.probeEndpoints(attemptCtx) {
+			if probe.Error != "" {
+				failed = probe
+				break
+			}
+		}
+		cancel()
+
+		if failed.Error == "" {
+			c.SoftLog("endpoint accepted OTLP after %d attempts", attempt)
+			return failed, attempt
+		}
+		c.SoftLog("attempt %d: %s failed: %s", attempt, failed.Endpoint, failed.Error)
+
+		if time.Now().Add(interval).After(deadline) {
+			return failed, attempt
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return failed, attempt
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
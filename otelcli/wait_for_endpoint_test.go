@@ -0,0 +1,67 @@
+package otelcli
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/equinix-labs/otel-cli/otlpserver"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestWaitForEndpoint(t *testing.T) {
+	// find a free port, then start the server on it a little later
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	server := otlpserver.NewGrpcServer(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		return false
+	}, func(otlpserver.OtlpServer) {})
+	defer server.Stop()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("failed to listen on %s: %s", addr, err)
+			return
+		}
+		server.Serve(listener)
+	}()
+
+	config := DefaultConfig().WithEndpoint(addr)
+	probe, attempts := config.waitForEndpoint(context.Background(), 5*time.Second, 20*time.Millisecond, 50*time.Millisecond)
+	if probe.Error != "" {
+		t.Fatalf("expected the endpoint to come up but got %q", probe.Error)
+	}
+	if attempts < 2 {
+		t.Errorf("expected more than one attempt before the server started but got %d", attempts)
+	}
+}
+
+func TestWaitForEndpointGivesUp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	config := DefaultConfig().WithEndpoint(addr)
+	start := time.Now()
+	probe, attempts := config.waitForEndpoint(context.Background(), 300*time.Millisecond, 50*time.Millisecond, 100*time.Millisecond)
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("expected to give up soon after --wait but took %s", time.Since(start))
+	}
+	if code, stage := probe.checkExitCode(); code != checkExitConnect || stage != "connect" {
+		t.Errorf("expected the last failure to be at connect but got %d at %q: %s", code, stage, probe.Error)
+	}
+	// 0, 50, 150, then 250ms would be the last to start within 300ms
+	if attempts < 3 || attempts > 4 {
+		t.Errorf("expected 3 or 4 attempts but got %d", attempts)
+	}
+}
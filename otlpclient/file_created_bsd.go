@@ -0,0 +1,18 @@
+//go:build darwin || freebsd || netbsd
+
+package otlpclient
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileCreated returns when the file was created, from its birth time.
+func fileCreated(_ string, fi os.FileInfo) (created time.Time, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Birthtimespec.Unix()), true
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !windows && !darwin && !freebsd && !netbsd
+
+package otlpclient
+
+import (
+	"os"
+	"time"
+)
+
+// fileCreated reports that file creation times aren't available here.
+func fileCreated(_ string, _ os.FileInfo) (created time.Time, ok bool) {
+	return time.Time{}, false
+}
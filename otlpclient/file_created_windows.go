@@ -0,0 +1,16 @@
+package otlpclient
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileCreated returns when the file was created, from its creation time.
+func fileCreated(_ string, fi os.FileInfo) (created time.Time, ok bool) {
+	attrs, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, attrs.CreationTime.Nanoseconds()), true
+}
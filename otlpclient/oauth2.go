@@ -0,0 +1,188 @@
+package otlpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// oauth2ExpiryMargin is how long before expiry a cached token is refreshed,
+// so a token doesn't expire while a span is in flight.
+const oauth2ExpiryMargin = 30 * time.Second
+
+// OAuth2Config holds the settings for fetching a bearer token with the
+// OAuth2 client credentials grant.
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// CacheDir is where tokens are cached between otel-cli runs. Caching is
+	// disabled when empty.
+	CacheDir string
+	// Transport sends the token request, http.DefaultTransport when nil.
+	Transport http.RoundTripper
+}
+
+// oauth2Token is a token as cached on disk.
+type oauth2Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+// oauth2TokenResponse is the token endpoint's response, RFC 6749 section 5.1.
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// GetOAuth2Token returns a bearer token for the config, from the on-disk
+// cache if a valid one is there, otherwise from the token endpoint. Fresh
+// tokens are written back to the cache, a failure to is logged but the
+// token is still returned.
+func GetOAuth2Token(ctx context.Context, config OAuth2Config) (string, error) {
+	cacheFile := config.cacheFile()
+	if token, ok := readOAuth2Cache(cacheFile); ok {
+		return token.AccessToken, nil
+	}
+
+	token, err := fetchOAuth2Token(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	if cacheFile != "" {
+		if err := writeOAuth2Cache(cacheFile, token); err != nil {
+			GetLogger(ctx).Warn("failed to cache OAuth2 token", "error", err)
+		}
+	}
+
+	return token.AccessToken, nil
+}
+
+// cacheFile returns the path of the cache file for these settings, or an empty
+// string when caching is disabled. The name is a hash of the token URL,
+// credentials, and scopes so a token is never reused across credentials.
+func (c OAuth2Config) cacheFile() string {
+	if c.CacheDir == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.TokenURL, c.ClientID, c.ClientSecret, strings.Join(c.Scopes, " ")}, "\n")))
+	return filepath.Join(c.CacheDir, "oauth2-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// fetchOAuth2Token requests a new token from the token endpoint.
+func fetchOAuth2Token(ctx context.Context, config OAuth2Config) (oauth2Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(config.Scopes) > 0 {
+		form.Set("scope", strings.Join(config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to create OAuth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+
+	client := http.Client{Transport: config.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("OAuth2 token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to read OAuth2 token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("OAuth2 token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	tr := oauth2TokenResponse{}
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to parse OAuth2 token response: %w", err)
+	} else if tr.AccessToken == "" {
+		return oauth2Token{}, fmt.Errorf("OAuth2 token response did not include an access_token")
+	}
+
+	token := oauth2Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+// readOAuth2Cache returns the cached token if the file exists and the token
+// is not about to expire. Tokens without an expiry are never cached.
+func readOAuth2Cache(cacheFile string) (oauth2Token, bool) {
+	if cacheFile == "" {
+		return oauth2Token{}, false
+	}
+
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return oauth2Token{}, false
+	}
+
+	token := oauth2Token{}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return oauth2Token{}, false
+	}
+
+	if token.Expiry.IsZero() || time.Now().Add(oauth2ExpiryMargin).After(token.Expiry) {
+		return oauth2Token{}, false
+	}
+
+	return token, true
+}
+
+// writeOAuth2Cache saves the token to the cache file, readable only by the
+// current user. Tokens without an expiry are not cached.
+func writeOAuth2Cache(cacheFile string, token oauth2Token) error {
+	if token.Expiry.IsZero() {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err != nil {
+		return fmt.Errorf("failed to create OAuth2 token cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal OAuth2 token for cache: %w", err)
+	}
+
+	// write to a tempfile then rename so concurrent runs never read a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(cacheFile), ".oauth2-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth2 token cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write OAuth2 token cache '%s': %w", tmp.Name(), err)
+	}
+
+	return os.Rename(tmp.Name(), cacheFile)
+}
@@ -0,0 +1,129 @@
+package otlpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetOAuth2Token(t *testing.T) {
+	var requests int
+	var expiresIn int64 = 3600
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		if err := req.ParseForm(); err != nil {
+			t.Errorf("failed to parse token request form: %s", err)
+		}
+		if gt := req.PostForm.Get("grant_type"); gt != "client_credentials" {
+			t.Errorf("expected grant_type client_credentials but got %q", gt)
+		}
+		if scope := req.PostForm.Get("scope"); scope != "traces.write metrics.write" {
+			t.Errorf("expected scopes in request but got %q", scope)
+		}
+		if id, secret, ok := req.BasicAuth(); !ok || id != "otel-cli" || secret != "s3cr3t" {
+			t.Errorf("expected client credentials in basic auth but got %q/%q", id, secret)
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", requests),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	defer server.Close()
+
+	config := OAuth2Config{
+		TokenURL:     server.URL,
+		ClientID:     "otel-cli",
+		ClientSecret: "s3cr3t",
+		Scopes:       []string{"traces.write", "metrics.write"},
+		CacheDir:     t.TempDir(),
+	}
+	ctx := context.Background()
+
+	token, err := GetOAuth2Token(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to get token: %s", err)
+	} else if token != "token-1" {
+		t.Errorf("expected token-1 but got %q", token)
+	}
+
+	// second call should come from the cache
+	token, err = GetOAuth2Token(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to get cached token: %s", err)
+	} else if token != "token-1" || requests != 1 {
+		t.Errorf("expected cached token-1 after 1 request but got %q after %d requests", token, requests)
+	}
+
+	if fi, err := os.Stat(config.cacheFile()); err != nil {
+		t.Errorf("failed to stat cache file: %s", err)
+	} else if fi.Mode().Perm() != 0600 {
+		t.Errorf("expected cache file mode 0600 but got %o", fi.Mode().Perm())
+	}
+
+	// a token about to expire is refreshed
+	data, _ := json.Marshal(oauth2Token{AccessToken: "token-1", Expiry: time.Now().Add(time.Second)})
+	if err := os.WriteFile(config.cacheFile(), data, 0600); err != nil {
+		t.Fatalf("failed to write cache file: %s", err)
+	}
+	token, err = GetOAuth2Token(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to refresh token: %s", err)
+	} else if token != "token-2" {
+		t.Errorf("expected refreshed token-2 but got %q", token)
+	}
+
+	// different credentials never reuse the cached token
+	other := config
+	other.ClientSecret = "other"
+	if config.cacheFile() == other.cacheFile() {
+		t.Errorf("expected different cache files for different credentials")
+	}
+}
+
+func TestGetOAuth2TokenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := GetOAuth2Token(context.Background(), OAuth2Config{TokenURL: server.URL, ClientID: "nope"})
+	if err == nil {
+		t.Fatal("expected an error from a failing token endpoint")
+	}
+}
+
+func TestGetOAuth2TokenTransportAndCacheError(t *testing.T) {
+	// a TLS server the default transport doesn't trust, so the token can
+	// only be fetched through the configured transport
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write([]byte(`{"access_token":"token-1","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	// the cache directory can't be created under a file
+	notADir := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(notADir, []byte{}, 0600)
+
+	config := OAuth2Config{TokenURL: server.URL, ClientID: "otel-cli", CacheDir: notADir}
+	if _, err := GetOAuth2Token(context.Background(), config); err == nil {
+		t.Error("expected the default transport to reject the test server's certificate")
+	}
+
+	config.Transport = server.Client().Transport
+	token, err := GetOAuth2Token(context.Background(), config)
+	if err != nil {
+		t.Fatalf("expected the token despite the cache failing but got %s", err)
+	} else if token != "token-1" {
+		t.Errorf("expected token-1 but got %q", token)
+	}
+}
@@ -5,7 +5,9 @@ package otlpclient
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/url"
 	"time"
 
@@ -30,20 +32,54 @@ type OTLPConfig interface {
 	GetTlsConfig() *tls.Config
 	GetIsRecording() bool
 	GetEndpoint() *url.URL
+	GetProtocol() string
 	GetInsecure() bool
 	GetTimeout() time.Duration
+	GetConnectTimeout() time.Duration
+	GetSendTimeout() time.Duration
 	GetHeaders() map[string]string
+	GetUserAgent() string
+	GetGrpcMetadata() map[string]string
+	GetCompression() string
+	GetProxy() string
+	GetRetryMaxAttempts() int
+	GetRetryInitialInterval() time.Duration
+	GetRetryMaxInterval() time.Duration
+	GetSpoolDir() string
+	GetSpoolKey() []byte
+	GetSpanLimits() SpanLimits
+	GetFileFormat() string
+	GetFileMaxMegabytes() int
+	GetFileMaxDays() int
+	GetFileMaxBackups() int
+	GetFileMaxAge() time.Duration
+	GetKafkaConfig() KafkaConfig
 	GetVersion() string
 	GetServiceName() string
+	GetResourceDetectors() []string
+	GetResourceAttributes() map[string]string
 }
 
 // SendSpan connects to the OTLP server, sends the span, and disconnects.
+// The span limits are applied to the span first. If the upload fails and a
+// spool directory is configured, the span is written to the spool so it can
+// be sent later with otel-cli flush.
 func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *tracepb.Span) (context.Context, error) {
+	return SendSpans(ctx, client, config, []*tracepb.Span{span})
+}
+
+// SendSpans is SendSpan for several spans, e.g. a trace built by an
+// importer, which are sent together in one request with otel-cli's resource.
+func SendSpans(ctx context.Context, client OTLPClient, config OTLPConfig, spans []*tracepb.Span) (context.Context, error) {
 	if !config.GetIsRecording() {
 		return ctx, nil
 	}
 
-	resourceAttrs, err := resourceAttributes(ctx, config.GetServiceName())
+	for _, span := range spans {
+		LimitSpan(span, config.GetSpanLimits())
+	}
+
+	resourceAttrs, err := resourceAttributes(ctx, config)
 	if err != nil {
 		return ctx, err
 	}
@@ -60,7 +96,7 @@ func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *t
 					Attributes:             []*commonpb.KeyValue{},
 					DroppedAttributesCount: 0,
 				},
-				Spans:     []*tracepb.Span{span},
+				Spans:     spans,
 				SchemaUrl: semconv.SchemaURL,
 			}},
 			SchemaUrl: semconv.SchemaURL,
@@ -69,7 +105,18 @@ func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *t
 
 	ctx, err = client.UploadTraces(ctx, rsps)
 	if err != nil {
-		return SaveError(ctx, time.Now(), err)
+		ctx, err = SaveError(ctx, time.Now(), err)
+
+		// when spooling is enabled the span isn't lost, so only fail if
+		// spooling fails too; the upload error stays in the error list
+		if spoolDir := config.GetSpoolDir(); spoolDir != "" {
+			if _, serr := SpoolTraces(spoolDir, config.GetSpoolKey(), rsps); serr != nil {
+				return SaveError(ctx, time.Now(), fmt.Errorf("%w, and spooling failed: %s", err, serr))
+			}
+			return ctx, nil
+		}
+
+		return ctx, err
 	}
 
 	return ctx, nil
@@ -77,20 +124,27 @@ func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *t
 
 // resourceAttributes calls the OTel SDK to get automatic resource attrs and
 // returns them converted to []*commonpb.KeyValue for use with protobuf.
-func resourceAttributes(ctx context.Context, serviceName string) ([]*commonpb.KeyValue, error) {
-	// set the service name that will show up in tracing UIs
-	resOpts := []resource.Option{
-		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
-		resource.WithFromEnv(), // maybe switch to manually loading this envvar?
-		// TODO: make these autodetectors configurable
-		//resource.WithHost(),
-		//resource.WithOS(),
-		//resource.WithProcess(),
-		//resource.WithContainer(),
+func resourceAttributes(ctx context.Context, config OTLPConfig) ([]*commonpb.KeyValue, error) {
+	// detectors go first so anything set explicitly wins over them
+	resOpts, err := resourceDetectorOptions(config.GetResourceDetectors())
+	if err != nil {
+		return nil, err
 	}
+	// config has already merged OTEL_RESOURCE_ATTRIBUTES under the flags
+	resAttrs := []attribute.KeyValue{}
+	for k, v := range config.GetResourceAttributes() {
+		resAttrs = append(resAttrs, attribute.String(k, v))
+	}
+	resOpts = append(resOpts,
+		resource.WithAttributes(resAttrs...),
+		// set the service name that will show up in tracing UIs
+		resource.WithAttributes(semconv.ServiceNameKey.String(config.GetServiceName())),
+	)
 
+	// detectors that can't find anything, e.g. container outside of one,
+	// return a partial resource, which is fine
 	res, err := resource.New(ctx, resOpts...)
-	if err != nil {
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
 		return nil, fmt.Errorf("failed to create OpenTelemetry service name resource: %s", err)
 	}
 
@@ -123,6 +177,12 @@ func resourceAttributes(ctx context.Context, serviceName string) ([]*commonpb.Ke
 	return attrs, nil
 }
 
+// UnixSocketPath returns the socket path from a unix:// endpoint URL. Both
+// unix:///abs/path.sock and unix://relative/path.sock are supported.
+func UnixSocketPath(u *url.URL) string {
+	return u.Host + u.Path
+}
+
 // otlpClientCtxKey is a type for storing otlp client information in context.Context safely.
 type otlpClientCtxKey string
 
@@ -175,19 +235,34 @@ func SaveError(ctx context.Context, t time.Time, err error) (context.Context, er
 	return ctx, err
 }
 
+// retryCountKey() returns the typed key used to store the retry count in context.
+func retryCountKey() otlpClientCtxKey {
+	return otlpClientCtxKey("otlp_retries")
+}
+
+// GetRetryCount returns the number of retries done so far, as recorded in ctx
+// by retry().
+func GetRetryCount(ctx context.Context) int {
+	if cv := ctx.Value(retryCountKey()); cv != nil {
+		if n, ok := cv.(int); ok {
+			return n
+		} else {
+			panic("BUG: failed to unwrap retry count, please report an issue")
+		}
+	}
+	return 0
+}
+
 // retry calls the provided function and expects it to return (true, wait, err)
 // to keep retrying, and (false, wait, err) to stop retrying and return.
 // The wait value is a time.Duration so the server can recommend a backoff
 // and it will be followed.
 //
-// This is a minimal retry mechanism that backs off linearly, 100ms at a time,
-// up to a maximum of 5 seconds.
-// While there are many robust implementations of retries out there, this one
-// is just ~20 LoC and seems to work fine for otel-cli's modest needs. It should
-// be rare for otel-cli to have a long timeout in the first place, and when it
-// does, maybe it's ok to wait a few seconds.
-// TODO: provide --otlp-retries (or something like that) option on CLI
-// TODO: --otlp-retry-sleep? --otlp-retry-timeout?
+// Between attempts, retry backs off exponentially with jitter, starting at
+// --retry-initial-interval and capped at --retry-max-interval. It gives up
+// after --retry-max-attempts attempts (when > 0) or when the deadline is hit,
+// whichever comes first. The number of retries is recorded in ctx and can be
+// retrieved with GetRetryCount.
 // TODO: span events? hmm... feels weird to plumb spans this deep into the client
 // but it's probably fine?
 func retry(ctx context.Context, config OTLPConfig, fun retryFun) (context.Context, error) {
@@ -195,40 +270,71 @@ func retry(ctx context.Context, config OTLPConfig, fun retryFun) (context.Contex
 	if !haveDL {
 		return ctx, fmt.Errorf("BUG in otel-cli: no deadline set before retry()")
 	}
-	sleep := time.Duration(0)
-	for {
-		if ctx, keepGoing, wait, err := fun(ctx); err != nil {
-			if err != nil {
-				ctx, _ = SaveError(ctx, time.Now(), err)
-			}
-			//config.SoftLog("error on retry %d: %s", Diag.Retries, err)
-
-			if keepGoing {
-				if wait > 0 {
-					if time.Now().Add(wait).After(deadline) {
-						// wait will be after deadline, give up now
-						return SaveError(ctx, time.Now(), err)
-					}
-					time.Sleep(wait)
-				} else {
-					time.Sleep(sleep)
-				}
-
-				if time.Now().After(deadline) {
-					return SaveError(ctx, time.Now(), err)
-				}
-
-				// linearly increase sleep time up to 5 seconds
-				if sleep < time.Second*5 {
-					sleep = sleep + time.Millisecond*100
-				}
-			} else {
+
+	maxAttempts := config.GetRetryMaxAttempts()
+	initial := config.GetRetryInitialInterval()
+	max := config.GetRetryMaxInterval()
+	logger := GetLogger(ctx)
+
+	for attempt := 1; ; attempt++ {
+		var keepGoing bool
+		var wait time.Duration
+		var err error
+		ctx, keepGoing, wait, err = fun(ctx)
+		if err == nil {
+			logger.Debug("export succeeded", "attempt", attempt)
+			return ctx, nil
+		}
+
+		ctx, _ = SaveError(ctx, time.Now(), err)
+		logger.Debug("export failed", "attempt", attempt, "retriable", keepGoing, "error", err)
+
+		if !keepGoing || (maxAttempts > 0 && attempt >= maxAttempts) {
+			return SaveError(ctx, time.Now(), err)
+		}
+
+		// a server-provided wait (RetryInfo, Retry-After) takes precedence
+		// and if it would land after the deadline, give up now
+		if wait > 0 {
+			if time.Now().Add(wait).After(deadline) {
 				return SaveError(ctx, time.Now(), err)
 			}
 		} else {
-			return ctx, nil
+			// otherwise back off but don't sleep past the deadline
+			wait = backoff(attempt, initial, max)
+			if remaining := time.Until(deadline); wait > remaining {
+				wait = remaining
+			}
 		}
+		logger.Debug("retrying export", "wait", wait)
+		time.Sleep(wait)
+
+		if time.Now().After(deadline) {
+			return SaveError(ctx, time.Now(), err)
+		}
+
+		ctx = context.WithValue(ctx, retryCountKey(), GetRetryCount(ctx)+1)
+	}
+}
+
+// backoff returns how long to wait after the given attempt. The wait doubles
+// with each attempt up to max, and the second half of it is randomized so
+// parallel otel-cli invocations don't retry in lockstep.
+func backoff(attempt int, initial, max time.Duration) time.Duration {
+	if initial <= 0 {
+		return 0
+	}
+
+	wait := initial
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
 	}
+	if max > 0 && wait > max {
+		wait = max
+	}
+
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
 }
 
 // retryFun is the function signature for functions passed to retry().
@@ -0,0 +1,103 @@
+package otlpclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// loggerKey is the context key for the logger the clients use for debug logs.
+func loggerKey() otlpClientCtxKey {
+	return otlpClientCtxKey("logger")
+}
+
+// WithLogger returns a context carrying the logger the clients write debug
+// logs of their requests to.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey(), logger)
+}
+
+// GetLogger returns the logger in ctx, or one that discards everything when
+// there isn't one, so the clients stay silent unless asked otherwise.
+func GetLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey()).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// grpcDebugInterceptor logs each gRPC call at debug level with its method,
+// size, result, and how long it took. Only the names of metadata are logged
+// since the values are often credentials.
+func grpcDebugInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	logger := GetLogger(ctx)
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	attrs := []any{"target", cc.Target(), "method", method}
+	if msg, ok := req.(proto.Message); ok {
+		attrs = append(attrs, "bytes", proto.Size(msg))
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		attrs = append(attrs, "metadata", sortedKeys(md))
+	}
+	logger.Debug("gRPC request", attrs...)
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	attrs = []any{"method", method, "code", status.Code(err).String(), "duration", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	logger.Debug("gRPC response", attrs...)
+
+	return err
+}
+
+// debugTransport logs each HTTP request at debug level with its URL, size,
+// status, and how long it took. Like grpcDebugInterceptor, only the names
+// of headers are logged.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (dt debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := GetLogger(req.Context())
+	if !logger.Enabled(req.Context(), slog.LevelDebug) {
+		return dt.next.RoundTrip(req)
+	}
+
+	logger.Debug("HTTP request", "method", req.Method, "url", req.URL.String(),
+		"bytes", req.ContentLength, "headers", sortedKeys(req.Header))
+
+	start := time.Now()
+	resp, err := dt.next.RoundTrip(req)
+	if err != nil {
+		logger.Debug("HTTP response", "url", req.URL.String(), "duration", time.Since(start), "error", err)
+		return resp, err
+	}
+	logger.Debug("HTTP response", "url", req.URL.String(), "status", resp.StatusCode,
+		"content_type", resp.Header.Get("Content-Type"), "duration", time.Since(start))
+
+	return resp, nil
+}
+
+// sortedKeys returns the keys of headers or gRPC metadata, sorted.
+func sortedKeys(in map[string][]string) []string {
+	keys := []string{}
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	buf := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := WithLogger(context.Background(), logger)
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL+"/v1/traces", strings.NewReader("spans"))
+	req.Header.Set("Authorization", "Bearer secret")
+	client := http.Client{Transport: debugTransport{next: http.DefaultTransport}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	resp.Body.Close()
+
+	got := buf.String()
+	for _, want := range []string{
+		`msg="HTTP request" method=POST url=` + srv.URL + `/v1/traces bytes=5 headers=[Authorization]`,
+		`msg="HTTP response" url=` + srv.URL + `/v1/traces status=503 content_type=application/x-protobuf`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the log to contain %q but got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("header values should never be logged but got %q", got)
+	}
+
+	// without a logger nothing is logged and requests still work
+	buf.Reset()
+	req, _ = http.NewRequest("POST", srv.URL, nil)
+	if resp, err = client.Do(req); err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	resp.Body.Close()
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing logged without a logger in the context but got %q", buf.String())
+	}
+}
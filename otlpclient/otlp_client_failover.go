@@ -0,0 +1,153 @@
+package otlpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FailoverClient sends spans to one of several OTLP clients, trying them in
+// order starting at first until one accepts the spans. This is for HA
+// collector pairs that don't have a load balancer in front of them.
+type FailoverClient struct {
+	clients []OTLPClient
+	names   []string
+	first   int
+}
+
+// NewFailoverClient returns a FailoverClient for the provided clients. names
+// identify the targets in errors and diagnostics and should be the same
+// length as clients. first is the index of the client to try first, which
+// allows spreading load across the clients.
+func NewFailoverClient(clients []OTLPClient, names []string, first int) *FailoverClient {
+	return &FailoverClient{clients: clients, names: names, first: first % len(clients)}
+}
+
+// Start starts all of the clients, failing if any of them fail to start.
+// Starting doesn't connect, so a dead endpoint doesn't fail here.
+func (fc *FailoverClient) Start(ctx context.Context) (context.Context, error) {
+	for i, client := range fc.clients {
+		var err error
+		ctx, err = client.Start(ctx)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to start client for %s: %w", fc.names[i], err)
+		}
+	}
+
+	return ctx, nil
+}
+
+// UploadTraces tries each client in turn until one succeeds. Each client
+// gets an equal share of the time left before the deadline so a dead
+// endpoint can't use it all up. The next client is only tried when the
+// error says the endpoint is down, see shouldFailover; an endpoint that
+// rejected the spans fails the export. The name of the client that
+// succeeded is recorded in the returned context, see GetFailoverTarget.
+func (fc *FailoverClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	deadline, haveDL := ctx.Deadline()
+	if !haveDL {
+		return ctx, fmt.Errorf("BUG in otel-cli: no deadline set before failover")
+	}
+
+	failed := []string{}
+	for n := 0; n < len(fc.clients); n++ {
+		i := (fc.first + n) % len(fc.clients)
+
+		share := time.Until(deadline) / time.Duration(len(fc.clients)-n)
+		targetCtx, cancel := context.WithTimeout(ctx, share)
+		resultCtx, err := fc.clients[i].UploadTraces(targetCtx, rsps)
+		cancel()
+
+		// keep the errors and retries but not the shortened deadline
+		ctx = context.WithValue(ctx, errorListKey(), GetErrorList(resultCtx))
+		ctx = context.WithValue(ctx, retryCountKey(), GetRetryCount(resultCtx))
+
+		if err == nil {
+			return context.WithValue(ctx, failoverTargetKey(), fc.names[i]), nil
+		}
+
+		failed = append(failed, fmt.Sprintf("%s: %s", fc.names[i], err))
+		ctx, _ = SaveError(ctx, time.Now(), fmt.Errorf("%s: %w", fc.names[i], err))
+
+		if !shouldFailover(err) {
+			return ctx, fmt.Errorf("export failed, not failing over: %s", strings.Join(failed, "; "))
+		}
+	}
+
+	return ctx, fmt.Errorf("export failed for all %d endpoints: %s", len(fc.clients), strings.Join(failed, "; "))
+}
+
+// shouldFailover returns true if err means the endpoint couldn't take the
+// spans right now: gRPC Unavailable or DeadlineExceeded, HTTP 502, 503, or
+// 504, or a network error or timeout. Other errors, e.g. bad auth or an
+// invalid request, would be the same on every endpoint.
+func shouldFailover(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var httpErr httpStatusError
+	if errors.As(err, &httpErr) {
+		return httpErr.code == 502 || httpErr.code == 503 || httpErr.code == 504
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return st.Code() == codes.Unavailable || st.Code() == codes.DeadlineExceeded
+	}
+
+	return false
+}
+
+// httpStatusError is the error for an HTTP response with a retriable status
+// code, so failover can tell an unavailable server from a rate limited one.
+type httpStatusError struct {
+	server string
+	code   int
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("%s responded with retriable code %d", e.server, e.code)
+}
+
+// Stop stops all of the clients, returning the first error encountered.
+func (fc *FailoverClient) Stop(ctx context.Context) (context.Context, error) {
+	var firstErr error
+	for i, client := range fc.clients {
+		var err error
+		ctx, err = client.Stop(ctx)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to stop client for %s: %w", fc.names[i], err)
+		}
+	}
+
+	return ctx, firstErr
+}
+
+func failoverTargetKey() otlpClientCtxKey {
+	return otlpClientCtxKey("otlp_failover_target")
+}
+
+// GetFailoverTarget returns the name of the target that accepted the spans,
+// as recorded in ctx by FailoverClient, or an empty string.
+func GetFailoverTarget(ctx context.Context) string {
+	if cv := ctx.Value(failoverTargetKey()); cv != nil {
+		if name, ok := cv.(string); ok {
+			return name
+		} else {
+			panic("BUG: failed to unwrap failover target, please report an issue")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package otlpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// downClient is an OTLPClient whose endpoint is always unavailable.
+type downClient struct{ NullClient }
+
+func (dc *downClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	return ctx, status.Error(codes.Unavailable, "connection refused")
+}
+
+func TestFailoverClient(t *testing.T) {
+	for _, tc := range []struct {
+		clients    []OTLPClient
+		first      int
+		wantErr    bool
+		wantTarget string
+		wantErrors int
+	}{
+		{clients: []OTLPClient{&NullClient{}, &NullClient{}}, first: 0, wantTarget: "a"},
+		{clients: []OTLPClient{&NullClient{}, &NullClient{}}, first: 1, wantTarget: "b"},
+		{clients: []OTLPClient{&downClient{}, &NullClient{}}, first: 0, wantTarget: "b", wantErrors: 1},
+		// wraps around to the start of the list
+		{clients: []OTLPClient{&NullClient{}, &downClient{}}, first: 3, wantTarget: "a", wantErrors: 1},
+		{clients: []OTLPClient{&downClient{}, &downClient{}}, first: 0, wantErr: true, wantErrors: 2},
+		// an endpoint that rejects the spans doesn't fail over
+		{clients: []OTLPClient{&failingClient{}, &NullClient{}}, first: 0, wantErr: true, wantErrors: 1},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		fc := NewFailoverClient(tc.clients, []string{"a", "b"}, tc.first)
+		ctx, err := fc.UploadTraces(ctx, fileTestSpans("failover"))
+
+		if tc.wantErr != (err != nil) {
+			t.Errorf("expected error: %t but got %v", tc.wantErr, err)
+		}
+		if got := GetFailoverTarget(ctx); got != tc.wantTarget {
+			t.Errorf("expected target %q but got %q", tc.wantTarget, got)
+		}
+		if got := len(GetErrorList(ctx)); got != tc.wantErrors {
+			t.Errorf("expected %d errors in the error list but got %d", tc.wantErrors, got)
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected the caller's deadline to be kept")
+		}
+	}
+}
+
+func TestShouldFailover(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: status.Error(codes.Unavailable, "down"), want: true},
+		{err: status.Error(codes.DeadlineExceeded, "slow"), want: true},
+		{err: status.Error(codes.Unauthenticated, "bad token"), want: false},
+		{err: status.Error(codes.ResourceExhausted, "rate limited"), want: false},
+		{err: httpStatusError{server: "server", code: 503}, want: true},
+		{err: httpStatusError{server: "server", code: 429}, want: false},
+		{err: fmt.Errorf("wrapped: %w", context.DeadlineExceeded), want: true},
+		{err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, want: true},
+		{err: fmt.Errorf("server returned unretriable code 400 with status: bad"), want: false},
+	} {
+		if got := shouldFailover(tc.err); got != tc.want {
+			t.Errorf("expected shouldFailover(%q) to be %t but got %t", tc.err, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package otlpclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// FanoutClient sends the same spans to multiple OTLP clients in parallel.
+// When requireAll is false, an upload succeeds as long as at least one
+// target accepted the spans. When true, any failed target fails the upload.
+type FanoutClient struct {
+	clients    []OTLPClient
+	names      []string
+	requireAll bool
+}
+
+// NewFanoutClient returns a FanoutClient for the provided clients. names are
+// used to identify the targets in error messages and should be the same
+// length as clients.
+func NewFanoutClient(clients []OTLPClient, names []string, requireAll bool) *FanoutClient {
+	return &FanoutClient{clients: clients, names: names, requireAll: requireAll}
+}
+
+// Start starts all of the clients, failing if any of them fail to start.
+func (fc *FanoutClient) Start(ctx context.Context) (context.Context, error) {
+	for i, client := range fc.clients {
+		var err error
+		ctx, err = client.Start(ctx)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to start client for %s: %w", fc.names[i], err)
+		}
+	}
+
+	return ctx, nil
+}
+
+// UploadTraces sends the spans to every client in parallel. Errors from each
+// target and the sum of their retries are recorded in the returned context.
+func (fc *FanoutClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	ctxs := make([]context.Context, len(fc.clients))
+	errs := make([]error, len(fc.clients))
+
+	wg := sync.WaitGroup{}
+	for i, client := range fc.clients {
+		wg.Add(1)
+		go func(i int, client OTLPClient) {
+			defer wg.Done()
+			ctxs[i], errs[i] = client.UploadTraces(ctx, rsps)
+		}(i, client)
+	}
+	wg.Wait()
+
+	retries := GetRetryCount(ctx)
+	failed := []string{}
+	for i, err := range errs {
+		retries += GetRetryCount(ctxs[i]) - GetRetryCount(ctx)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", fc.names[i], err))
+			ctx, _ = SaveError(ctx, time.Now(), fmt.Errorf("%s: %w", fc.names[i], err))
+		}
+	}
+	ctx = context.WithValue(ctx, retryCountKey(), retries)
+
+	if len(failed) == 0 {
+		return ctx, nil
+	} else if fc.requireAll || len(failed) == len(fc.clients) {
+		return ctx, fmt.Errorf("export failed for %d of %d targets: %s", len(failed), len(fc.clients), strings.Join(failed, "; "))
+	}
+
+	return ctx, nil
+}
+
+// Stop stops all of the clients, returning the first error encountered.
+func (fc *FanoutClient) Stop(ctx context.Context) (context.Context, error) {
+	var firstErr error
+	for i, client := range fc.clients {
+		var err error
+		ctx, err = client.Stop(ctx)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to stop client for %s: %w", fc.names[i], err)
+		}
+	}
+
+	return ctx, firstErr
+}
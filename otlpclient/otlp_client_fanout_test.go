@@ -0,0 +1,40 @@
+package otlpclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFanoutClient(t *testing.T) {
+	for _, tc := range []struct {
+		clients    []OTLPClient
+		requireAll bool
+		wantErr    bool
+		wantErrors int
+	}{
+		{clients: []OTLPClient{&NullClient{}, &NullClient{}}, requireAll: true, wantErr: false, wantErrors: 0},
+		{clients: []OTLPClient{&NullClient{}, &failingClient{}}, requireAll: false, wantErr: false, wantErrors: 1},
+		{clients: []OTLPClient{&NullClient{}, &failingClient{}}, requireAll: true, wantErr: true, wantErrors: 1},
+		{clients: []OTLPClient{&failingClient{}, &failingClient{}}, requireAll: false, wantErr: true, wantErrors: 2},
+	} {
+		names := make([]string, len(tc.clients))
+		for i := range names {
+			names[i] = "target"
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		fc := NewFanoutClient(tc.clients, names, tc.requireAll)
+		ctx, err := fc.UploadTraces(ctx, fileTestSpans("fanout"))
+
+		if tc.wantErr != (err != nil) {
+			t.Errorf("expected error: %t but got %v", tc.wantErr, err)
+		}
+
+		if got := len(GetErrorList(ctx)); got != tc.wantErrors {
+			t.Errorf("expected %d errors in the error list but got %d", tc.wantErrors, got)
+		}
+	}
+}
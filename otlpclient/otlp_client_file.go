@@ -0,0 +1,213 @@
+package otlpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fileBackupTimeFormat is the timestamp format used in rotated file names,
+// the same as the collector's file exporter (via lumberjack).
+const fileBackupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileClient is an OTLP client backend that appends spans to a file in the
+// same formats as the collector's file exporter, so files can be replayed
+// later. The json format writes one OTLP/JSON TracesData per line and the
+// proto format writes each TracesData prefixed with its length as a 4 byte
+// big-endian integer.
+type FileClient struct {
+	path   string
+	config OTLPConfig
+}
+
+// NewFileClient returns a FileClient that writes to the path in the endpoint.
+func NewFileClient(config OTLPConfig) *FileClient {
+	return &FileClient{config: config}
+}
+
+// Start resolves the file path from the endpoint URL and creates the
+// directory holding it if needed.
+func (fc *FileClient) Start(ctx context.Context) (context.Context, error) {
+	fc.path = FilePathFromURL(fc.config.GetEndpoint())
+	if fc.path == "" {
+		return ctx, fmt.Errorf("file endpoint requires a path, e.g. file:///var/log/otel-cli/spans.json")
+	}
+
+	format := fc.config.GetFileFormat()
+	if format != "json" && format != "proto" {
+		return ctx, fmt.Errorf("invalid file format %q, must be json or proto", format)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fc.path), 0755); err != nil {
+		return ctx, fmt.Errorf("failed to create directory for '%s': %w", fc.path, err)
+	}
+
+	return ctx, nil
+}
+
+// FilePathFromURL returns the path from a file:// URL. Both file:///abs/path
+// and file://relative/path are supported. The path comes from the parsed
+// fields since String() would percent-escape spaces and the like.
+func FilePathFromURL(fileURL *url.URL) string {
+	return fileURL.Host + fileURL.Path
+}
+
+// UploadTraces appends the spans to the file, rotating it first if it would
+// grow past the configured size or is past the configured age.
+func (fc *FileClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	td := tracepb.TracesData{ResourceSpans: rsps}
+
+	var data []byte
+	var err error
+	if fc.config.GetFileFormat() == "proto" {
+		var pb []byte
+		pb, err = proto.Marshal(&td)
+		if err == nil {
+			data = binary.BigEndian.AppendUint32(nil, uint32(len(pb)))
+			data = append(data, pb...)
+		}
+	} else {
+		data, err = MarshalOtlpJson(&td)
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return ctx, fmt.Errorf("failed to marshal spans for file export: %w", err)
+	}
+
+	if err := fc.rotate(int64(len(data))); err != nil {
+		return ctx, err
+	}
+
+	file, err := os.OpenFile(fc.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to open '%s' for append: %w", fc.path, err)
+	}
+	defer file.Close()
+
+	// a single write with O_APPEND keeps records from concurrent otel-cli
+	// processes from interleaving
+	if _, err := file.Write(data); err != nil {
+		return ctx, fmt.Errorf("failed to write spans to '%s': %w", fc.path, err)
+	}
+
+	return ctx, nil
+}
+
+// rotate moves the current file to a timestamped backup when writing
+// another incoming bytes would take it past the size limit, or when it's older
+// than the age limit, then removes old backups past the configured count and
+// age.
+func (fc *FileClient) rotate(incoming int64) error {
+	maxBytes := int64(fc.config.GetFileMaxMegabytes()) * 1024 * 1024
+	maxAge := fc.config.GetFileMaxAge()
+	if maxBytes <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	fi, err := os.Stat(fc.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to stat '%s': %w", fc.path, err)
+	}
+
+	if fi.Size() == 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(fc.path)
+	prefix := strings.TrimSuffix(fc.path, ext)
+
+	tooBig := maxBytes > 0 && fi.Size()+incoming > maxBytes
+	if !tooBig && (maxAge <= 0 || fc.fileAge(fi, prefix, ext) <= maxAge) {
+		return nil
+	}
+
+	backup := prefix + "-" + time.Now().UTC().Format(fileBackupTimeFormat) + ext
+	// another otel-cli may have rotated the file already, which is fine
+	if err := os.Rename(fc.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate '%s': %w", fc.path, err)
+	}
+
+	return fc.removeOldBackups(prefix, ext)
+}
+
+// fileAge returns how long ago the current file was started. That's its
+// creation time where the filesystem records one, but no earlier than the
+// last rotation, since Windows can hand a new file the creation time of the
+// one just renamed away. It returns 0 when neither is known, so the file is
+// only rotated on size.
+func (fc *FileClient) fileAge(fi os.FileInfo, prefix, ext string) time.Duration {
+	started, _ := fileCreated(fc.path, fi)
+	if backups, _ := fc.listBackups(prefix, ext); len(backups) > 0 && backups[0].ts.After(started) {
+		started = backups[0].ts
+	}
+	if started.IsZero() {
+		return 0
+	}
+	return time.Since(started)
+}
+
+// fileBackup is a rotated file and the time it was rotated.
+type fileBackup struct {
+	path string
+	ts   time.Time
+}
+
+// listBackups returns the rotated files for the prefix and extension, newest
+// first. Files that match the glob without a valid timestamp are left out.
+func (fc *FileClient) listBackups(prefix, ext string) ([]fileBackup, error) {
+	matches, err := filepath.Glob(prefix + "-*" + ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups of '%s': %w", fc.path, err)
+	}
+
+	backups := []fileBackup{}
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, prefix+"-"), ext)
+		if ts, err := time.Parse(fileBackupTimeFormat, stamp); err == nil {
+			backups = append(backups, fileBackup{path: match, ts: ts})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ts.After(backups[j].ts) })
+	return backups, nil
+}
+
+// removeOldBackups deletes rotated files beyond --file-max-backups and older
+// than --file-max-days. Zero disables either check.
+func (fc *FileClient) removeOldBackups(prefix, ext string) error {
+	maxBackups := fc.config.GetFileMaxBackups()
+	maxDays := fc.config.GetFileMaxDays()
+	if maxBackups <= 0 && maxDays <= 0 {
+		return nil
+	}
+
+	backups, err := fc.listBackups(prefix, ext)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-time.Duration(maxDays) * 24 * time.Hour)
+	for i, b := range backups {
+		if (maxBackups > 0 && i >= maxBackups) || (maxDays > 0 && b.ts.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+
+	return nil
+}
+
+// Stop fulfills the interface and does nothing.
+func (fc *FileClient) Stop(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
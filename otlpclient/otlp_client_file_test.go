@@ -0,0 +1,203 @@
+package otlpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fileTestConfig provides the settings FileClient uses and leaves the rest
+// of the interface unimplemented.
+type fileTestConfig struct {
+	OTLPConfig
+	endpoint   string
+	format     string
+	maxMB      int
+	maxBackups int
+	maxAge     time.Duration
+}
+
+func (c fileTestConfig) GetEndpoint() *url.URL {
+	u, _ := url.Parse(c.endpoint)
+	return u
+}
+func (c fileTestConfig) GetFileFormat() string    { return c.format }
+func (c fileTestConfig) GetFileMaxMegabytes() int { return c.maxMB }
+func (c fileTestConfig) GetFileMaxDays() int      { return 0 }
+func (c fileTestConfig) GetFileMaxBackups() int   { return c.maxBackups }
+func (c fileTestConfig) GetFileMaxAge() time.Duration {
+	return c.maxAge
+}
+
+func fileTestSpans(name string) []*tracepb.ResourceSpans {
+	span := NewProtobufSpan()
+	span.Name = name
+	return []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+	}}
+}
+
+func startFileClient(t *testing.T, config fileTestConfig) *FileClient {
+	client := NewFileClient(config)
+	if _, err := client.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start file client: %s", err)
+	}
+	return client
+}
+
+func TestFileClientJson(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "spans.json")
+	client := startFileClient(t, fileTestConfig{endpoint: "file://" + path, format: "json"})
+
+	for _, name := range []string{"first", "second"} {
+		if _, err := client.UploadTraces(context.Background(), fileTestSpans(name)); err != nil {
+			t.Fatalf("failed to write spans: %s", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open output: %s", err)
+	}
+	defer file.Close()
+
+	names := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		td := tracepb.TracesData{}
+		if err := UnmarshalOtlpJson(scanner.Bytes(), &td); err != nil {
+			t.Fatalf("output line is not valid OTLP/JSON: %s", err)
+		}
+		names = append(names, td.ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+	}
+
+	if strings.Join(names, ",") != "first,second" {
+		t.Errorf("expected spans first,second in file but got %v", names)
+	}
+}
+
+func TestFilePathFromURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"file:///var/log/spans.json":    "/var/log/spans.json",
+		"file://relative/spans.json":    "relative/spans.json",
+		"file:///tmp/my spans.json":     "/tmp/my spans.json",
+		"file:///tmp/escaped%20it.json": "/tmp/escaped it.json",
+	} {
+		u, _ := url.Parse(in)
+		if got := FilePathFromURL(u); got != want {
+			t.Errorf("expected path %q for %q but got %q", want, in, got)
+		}
+	}
+}
+
+func TestFileClientPathWithSpace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "my spans.json")
+	client := startFileClient(t, fileTestConfig{endpoint: "file://" + path, format: "json"})
+
+	if _, err := client.UploadTraces(context.Background(), fileTestSpans("spaced")); err != nil {
+		t.Fatalf("failed to write spans: %s", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected spans to be written to %q: %s", path, err)
+	}
+	if escaped, _ := filepath.Glob(filepath.Join(dir, "*%20*")); len(escaped) != 0 {
+		t.Errorf("expected no percent-escaped file names but found %v", escaped)
+	}
+}
+
+func TestFileClientProto(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.pb")
+	client := startFileClient(t, fileTestConfig{endpoint: "file://" + path, format: "proto"})
+
+	if _, err := client.UploadTraces(context.Background(), fileTestSpans("proto")); err != nil {
+		t.Fatalf("failed to write spans: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output: %s", err)
+	}
+
+	size := binary.BigEndian.Uint32(data[:4])
+	if int(size) != len(data)-4 {
+		t.Fatalf("length prefix %d does not match payload size %d", size, len(data)-4)
+	}
+
+	td := tracepb.TracesData{}
+	if err := proto.Unmarshal(data[4:], &td); err != nil {
+		t.Fatalf("payload is not valid protobuf: %s", err)
+	} else if got := td.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; got != "proto" {
+		t.Errorf("expected span name 'proto' but got %q", got)
+	}
+}
+
+func TestFileClientRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spans.json")
+	client := startFileClient(t, fileTestConfig{endpoint: "file://" + path, format: "json", maxMB: 1, maxBackups: 1})
+
+	// ~600KB per write, so every second write has to rotate
+	big := strings.Repeat("x", 600*1024)
+	for i := 0; i < 5; i++ {
+		if _, err := client.UploadTraces(context.Background(), fileTestSpans(big)); err != nil {
+			t.Fatalf("failed to write spans: %s", err)
+		}
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "spans-*.json"))
+	if len(backups) != 1 {
+		t.Errorf("expected exactly 1 backup to be kept but found %v", backups)
+	}
+
+	file, _ := os.Open(path)
+	defer file.Close()
+	data, _ := io.ReadAll(file)
+	if len(data) > 1024*1024 {
+		t.Errorf("active file should have been rotated at 1MB but is %d bytes", len(data))
+	}
+}
+
+func TestFileClientRotationByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spans.json")
+	client := startFileClient(t, fileTestConfig{endpoint: "file://" + path, format: "json", maxAge: 200 * time.Millisecond})
+
+	write := func() {
+		if _, err := client.UploadTraces(context.Background(), fileTestSpans("small")); err != nil {
+			t.Fatalf("failed to write spans: %s", err)
+		}
+	}
+
+	write()
+	fi, _ := os.Stat(path)
+	if _, ok := fileCreated(path, fi); !ok {
+		t.Skip("the filesystem doesn't record file creation times")
+	}
+	write()
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "spans-*.json"))
+	if len(backups) != 0 {
+		t.Fatalf("expected no rotation before the file is 200ms old but found %v", backups)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	write()
+	// the new file is younger than the last rotation, so it isn't rotated again
+	write()
+
+	backups, _ = filepath.Glob(filepath.Join(dir, "spans-*.json"))
+	if len(backups) != 1 {
+		t.Errorf("expected exactly 1 backup after the file aged out but found %v", backups)
+	}
+}
@@ -5,22 +5,31 @@ import (
 	"fmt"
 	"time"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	grpcbackoff "google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // GrpcClient holds the state for gRPC connections.
 type GrpcClient struct {
-	conn   *grpc.ClientConn
-	client coltracepb.TraceServiceClient
-	config OTLPConfig
+	conn    *grpc.ClientConn
+	client  coltracepb.TraceServiceClient
+	metrics colmetricspb.MetricsServiceClient
+	logs    collogspb.LogsServiceClient
+	config  OTLPConfig
 }
 
 // NewGrpcClient returns a fresh GrpcClient ready to Start.
@@ -31,14 +40,34 @@ func NewGrpcClient(config OTLPConfig) *GrpcClient {
 
 // Start configures and starts the connection to the gRPC server in the background.
 func (gc *GrpcClient) Start(ctx context.Context) (context.Context, error) {
-	var err error
 	endpointURL := gc.config.GetEndpoint()
 	host := endpointURL.Hostname()
 	if endpointURL.Port() != "" {
 		host = host + ":" + endpointURL.Port()
 	}
 
-	grpcOpts := []grpc.DialOption{}
+	// grpc-go has a built-in resolver for unix:///path/to/socket targets
+	if endpointURL.Scheme == "unix" {
+		host = "unix://" + UnixSocketPath(endpointURL)
+	}
+
+	// grpc-go appends its own name and version to the user agent
+	grpcOpts := []grpc.DialOption{
+		grpc.WithUserAgent(gc.config.GetUserAgent()),
+		grpc.WithChainUnaryInterceptor(grpcDebugInterceptor, grpcWireInterceptor),
+	}
+
+	if gc.config.GetCompression() == "gzip" {
+		grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	}
+
+	// without --proxy, grpc-go already honors HTTPS_PROXY and NO_PROXY
+	proxyURL, err := parseProxyURL(gc.config.GetProxy())
+	if err != nil {
+		return ctx, err
+	} else if proxyURL != nil {
+		grpcOpts = append(grpcOpts, grpc.WithContextDialer(grpcProxyDialer(proxyURL, gc.config.GetTlsConfig())))
+	}
 
 	if gc.config.GetInsecure() {
 		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -46,12 +75,21 @@ func (gc *GrpcClient) Start(ctx context.Context) (context.Context, error) {
 		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(gc.config.GetTlsConfig())))
 	}
 
+	if connectTimeout := gc.config.GetConnectTimeout(); connectTimeout > 0 {
+		grpcOpts = append(grpcOpts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           grpcbackoff.DefaultConfig,
+			MinConnectTimeout: connectTimeout,
+		}))
+	}
+
 	gc.conn, err = grpc.DialContext(ctx, host, grpcOpts...)
 	if err != nil {
 		return ctx, fmt.Errorf("could not connect to gRPC/OTLP: %w", err)
 	}
 
 	gc.client = coltracepb.NewTraceServiceClient(gc.conn)
+	gc.metrics = colmetricspb.NewMetricsServiceClient(gc.conn)
+	gc.logs = collogspb.NewLogsServiceClient(gc.conn)
 
 	return ctx, nil
 }
@@ -60,21 +98,81 @@ func (gc *GrpcClient) Start(ctx context.Context) (context.Context, error) {
 // on some errors as needed.
 // TODO: look into grpc.WaitForReady(), esp for status use cases
 func (gc *GrpcClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
-	// add headers onto the request
+	req := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
+	return gc.export(ctx, func(sendCtx context.Context) error {
+		_, err := gc.client.Export(sendCtx, &req)
+		return err
+	})
+}
+
+// UploadMetrics takes a list of protobuf metrics and sends them out, with
+// the same retries as UploadTraces.
+func (gc *GrpcClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	req := colmetricspb.ExportMetricsServiceRequest{ResourceMetrics: rms}
+	return gc.export(ctx, func(sendCtx context.Context) error {
+		_, err := gc.metrics.Export(sendCtx, &req)
+		return err
+	})
+}
+
+// UploadLogs takes a list of protobuf log records and sends them out, with
+// the same retries as UploadTraces.
+func (gc *GrpcClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	req := collogspb.ExportLogsServiceRequest{ResourceLogs: rls}
+	return gc.export(ctx, func(sendCtx context.Context) error {
+		_, err := gc.logs.Export(sendCtx, &req)
+		return err
+	})
+}
+
+// export adds the headers to the context and calls the export function for
+// a signal until it succeeds or fails with an error that isn't retriable.
+func (gc *GrpcClient) export(ctx context.Context, export func(context.Context) error) (context.Context, error) {
+	// add headers and gRPC-only metadata onto the request
 	headers := gc.config.GetHeaders()
-	if len(headers) > 0 {
+	grpcMetadata := gc.config.GetGrpcMetadata()
+	if len(headers) > 0 || len(grpcMetadata) > 0 {
 		md := metadata.New(headers)
+		for k, v := range grpcMetadata {
+			md.Set(k, v)
+		}
 		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
-	req := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
+	// with a connect timeout, a dead collector fails here instead of being
+	// retried as unavailable until --timeout
+	if connectTimeout := gc.config.GetConnectTimeout(); connectTimeout > 0 {
+		if err := gc.waitForConnect(ctx, connectTimeout); err != nil {
+			return SaveError(ctx, time.Now(), err)
+		}
+	}
+
+	sendTimeout := gc.config.GetSendTimeout()
 
 	return retry(ctx, gc.config, func(innerCtx context.Context) (context.Context, bool, time.Duration, error) {
-		etsr, err := gc.client.Export(innerCtx, &req)
-		return processGrpcStatus(innerCtx, etsr, err)
+		sendCtx, cancel := context.WithTimeout(innerCtx, sendTimeout)
+		defer cancel()
+		return processGrpcStatus(innerCtx, nil, export(sendCtx))
 	})
 }
 
+// waitForConnect waits up to timeout for the connection to be ready.
+func (gc *GrpcClient) waitForConnect(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	gc.conn.Connect()
+	for {
+		state := gc.conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !gc.conn.WaitForStateChange(ctx, state) {
+			return status.Errorf(codes.Unavailable, "could not connect to gRPC/OTLP within %s, connection is %s", timeout, state)
+		}
+	}
+}
+
 // Stop closes the connection to the gRPC server.
 func (gc *GrpcClient) Stop(ctx context.Context) (context.Context, error) {
 	return ctx, gc.conn.Close()
@@ -2,12 +2,15 @@ package otlpclient
 
 import (
 	"context"
+	"net"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/google/go-cmp/cmp"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -94,3 +97,57 @@ func retryWithInfo(wait int64) error {
 
 	return st.Err()
 }
+
+// grpcTestConfig provides the settings GrpcClient uses and leaves the rest
+// of the interface unimplemented.
+type grpcTestConfig struct {
+	OTLPConfig
+	endpoint       string
+	connectTimeout time.Duration
+}
+
+func (c grpcTestConfig) GetEndpoint() *url.URL {
+	u, _ := url.Parse(c.endpoint)
+	return u
+}
+func (c grpcTestConfig) GetInsecure() bool                      { return true }
+func (c grpcTestConfig) GetProxy() string                       { return "" }
+func (c grpcTestConfig) GetUserAgent() string                   { return "otel-cli/test" }
+func (c grpcTestConfig) GetGrpcMetadata() map[string]string     { return map[string]string{} }
+func (c grpcTestConfig) GetCompression() string                 { return "" }
+func (c grpcTestConfig) GetHeaders() map[string]string          { return map[string]string{} }
+func (c grpcTestConfig) GetConnectTimeout() time.Duration       { return c.connectTimeout }
+func (c grpcTestConfig) GetSendTimeout() time.Duration          { return time.Second }
+func (c grpcTestConfig) GetRetryMaxAttempts() int               { return 0 }
+func (c grpcTestConfig) GetRetryInitialInterval() time.Duration { return 10 * time.Millisecond }
+func (c grpcTestConfig) GetRetryMaxInterval() time.Duration     { return 100 * time.Millisecond }
+
+func TestGrpcConnectTimeout(t *testing.T) {
+	// grab a free port then close it so nothing is listening
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	endpoint := "grpc://" + listener.Addr().String()
+	listener.Close()
+
+	config := grpcTestConfig{endpoint: endpoint, connectTimeout: 200 * time.Millisecond}
+	client := NewGrpcClient(config)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ctx, err = client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	defer client.Stop(ctx)
+
+	started := time.Now()
+	_, err = client.UploadTraces(ctx, []*tracepb.ResourceSpans{})
+	if err == nil {
+		t.Fatal("expected an error uploading to a dead endpoint")
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("expected to give up after the connect timeout but took %s", elapsed)
+	}
+}
@@ -0,0 +1,297 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebExportPath, grpcWebMetricsExportPath, and grpcWebLogsExportPath are
+// the gRPC methods for exports, which gRPC-Web sends as the URL path.
+const (
+	grpcWebExportPath        = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+	grpcWebMetricsExportPath = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+	grpcWebLogsExportPath    = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+)
+
+// gRPC-Web frame flags, the first byte of each length-prefixed frame
+const (
+	grpcWebFlagCompressed = 0x01
+	grpcWebFlagTrailer    = 0x80
+)
+
+// GrpcWebClient sends OTLP gRPC calls with gRPC-Web framing over HTTP/1.1,
+// for proxies and ingress controllers that don't pass HTTP/2 through.
+type GrpcWebClient struct {
+	client *http.Client
+	config OTLPConfig
+}
+
+// NewGrpcWebClient returns an initialized GrpcWebClient.
+func NewGrpcWebClient(config OTLPConfig) *GrpcWebClient {
+	c := GrpcWebClient{config: config}
+	return &c
+}
+
+// Start sets up the HTTP client. Like gRPC, nothing connects until the
+// first export.
+func (gwc *GrpcWebClient) Start(ctx context.Context) (context.Context, error) {
+	transport, err := NewHttpTransport(gwc.config)
+	if err != nil {
+		return ctx, err
+	}
+
+	// unix sockets ignore the address and always dial the socket
+	if endpointURL := gwc.config.GetEndpoint(); endpointURL.Scheme == "unix" {
+		socketPath := UnixSocketPath(endpointURL)
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: gwc.config.GetConnectTimeout()}
+			return d.DialContext(ctx, "unix", socketPath)
+		}
+	}
+
+	gwc.client = &http.Client{
+		Timeout:   gwc.config.GetSendTimeout(),
+		Transport: transport,
+	}
+	return ctx, nil
+}
+
+// exportURL returns the URL of the gRPC method. gRPC endpoints and bare
+// host:port become https://, or http:// when insecure. Any path on the
+// endpoint other than the default /v1/traces, /v1/metrics, or /v1/logs is
+// kept as a prefix for ingresses that route gRPC-Web by path.
+func (gwc *GrpcWebClient) exportURL(method string) string {
+	endpointURL := gwc.config.GetEndpoint()
+
+	u := url.URL{Scheme: endpointURL.Scheme, Host: endpointURL.Host}
+	if endpointURL.Scheme == "unix" {
+		// the socket is dialed by the transport, the host is only for the Host header
+		return "http://localhost" + method
+	} else if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" {
+		if gwc.config.GetInsecure() {
+			u.Scheme = "http"
+		} else {
+			u.Scheme = "https"
+		}
+	}
+	prefix := endpointURL.Path
+	for _, signalPath := range []string{"/v1/traces", "/v1/metrics", "/v1/logs"} {
+		prefix = strings.TrimSuffix(prefix, signalPath)
+	}
+	u.Path = strings.TrimSuffix(prefix, "/") + method
+
+	return u.String()
+}
+
+// UploadTraces sends the protobuf spans in a gRPC-Web request, doing retries
+// on the same status codes as the gRPC client.
+func (gwc *GrpcWebClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	msg := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
+	return gwc.export(ctx, grpcWebExportPath, &msg)
+}
+
+// UploadMetrics sends the protobuf metrics in a gRPC-Web request, with the
+// same retries as UploadTraces.
+func (gwc *GrpcWebClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	msg := colmetricspb.ExportMetricsServiceRequest{ResourceMetrics: rms}
+	return gwc.export(ctx, grpcWebMetricsExportPath, &msg)
+}
+
+// UploadLogs sends the protobuf log records in a gRPC-Web request, with the
+// same retries as UploadTraces.
+func (gwc *GrpcWebClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	msg := collogspb.ExportLogsServiceRequest{ResourceLogs: rls}
+	return gwc.export(ctx, grpcWebLogsExportPath, &msg)
+}
+
+// export sends the request to the gRPC method and checks the status. The
+// response body is only checked for being well formed, as the gRPC client
+// doesn't look at partial success either.
+func (gwc *GrpcWebClient) export(ctx context.Context, method string, msg proto.Message) (context.Context, error) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to marshal export request: %w", err)
+	}
+
+	var flags byte
+	if gwc.config.GetCompression() == "gzip" {
+		payload, err = gzipBytes(payload)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to gzip trace service request: %w", err)
+		}
+		flags |= grpcWebFlagCompressed
+	}
+	frame := grpcWebFrame(flags, payload)
+
+	header := http.Header{}
+	for k, v := range gwc.config.GetHeaders() {
+		header.Add(k, v)
+	}
+	for k, v := range gwc.config.GetGrpcMetadata() {
+		header.Set(k, v)
+	}
+	// a User-Agent in --otlp-headers wins
+	if header.Get("User-Agent") == "" {
+		header.Set("User-Agent", gwc.config.GetUserAgent())
+	}
+	header.Set("Content-Type", "application/grpc-web+proto")
+	header.Set("Accept", "application/grpc-web+proto")
+	header.Set("X-Grpc-Web", "1")
+	if gwc.config.GetCompression() == "gzip" {
+		header.Set("Grpc-Encoding", "gzip")
+	}
+
+	exportURL := gwc.exportURL(method)
+	sendTimeout := gwc.config.GetSendTimeout()
+
+	return retry(ctx, gwc.config, func(innerCtx context.Context) (context.Context, bool, time.Duration, error) {
+		sendCtx, cancel := context.WithTimeout(innerCtx, sendTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(sendCtx, "POST", exportURL, bytes.NewReader(frame))
+		if err != nil {
+			return innerCtx, false, 0, fmt.Errorf("failed to create gRPC-Web request: %w", err)
+		}
+		req.Header = header.Clone()
+		req.Header.Set("Grpc-Timeout", strconv.FormatInt(sendTimeout.Milliseconds(), 10)+"m")
+
+		resp, err := gwc.client.Do(req)
+		if err != nil {
+			// connection failures are unavailable, same as gRPC
+			return processGrpcStatus(innerCtx, nil, status.Error(codes.Unavailable, err.Error()))
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return innerCtx, true, 0, fmt.Errorf("io.Readall of response body failed: %w", err)
+		}
+
+		return processGrpcStatus(innerCtx, nil, parseGrpcWebResponse(resp, body))
+	})
+}
+
+// Stop does nothing for gRPC-Web. It exists to fulfill the interface.
+func (gwc *GrpcWebClient) Stop(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+// grpcWebFrame returns the payload with the gRPC-Web frame header: one
+// byte of flags and the big-endian length.
+func grpcWebFrame(flags byte, payload []byte) []byte {
+	frame := make([]byte, 5+len(payload))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	copy(frame[5:], payload)
+	return frame
+}
+
+// parseGrpcWebResponse returns the gRPC status of a gRPC-Web response as an
+// error, nil for OK, so it can be handed to processGrpcStatus. The status is
+// in the trailer frame at the end of the body, or in the headers when the
+// server responds with trailers only.
+func parseGrpcWebResponse(resp *http.Response, body []byte) error {
+	if resp.StatusCode != http.StatusOK {
+		return status.Errorf(httpStatusToGrpcCode(resp.StatusCode), "gRPC-Web server returned HTTP status %d", resp.StatusCode)
+	}
+
+	trailer := textproto.MIMEHeader{}
+	for k, v := range resp.Header {
+		trailer[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return status.Error(codes.Internal, "gRPC-Web response has a truncated frame header")
+		}
+		flags := body[0]
+		length := binary.BigEndian.Uint32(body[1:5])
+		if uint32(len(body)-5) < length {
+			return status.Error(codes.Internal, "gRPC-Web response has a truncated frame")
+		}
+		data := body[5 : 5+length]
+		body = body[5+length:]
+
+		if flags&grpcWebFlagTrailer != 0 {
+			// the trailers are HTTP/1 style header lines
+			for _, line := range strings.Split(string(data), "\r\n") {
+				if k, v, ok := strings.Cut(line, ":"); ok {
+					trailer.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+				}
+			}
+		}
+		// the message frame is the export response, which only carries
+		// partial success details that the gRPC client ignores as well
+	}
+
+	return grpcWebStatus(trailer)
+}
+
+// grpcWebStatus returns the status in the trailers as an error, including
+// the details such as RetryInfo when the server sent them.
+func grpcWebStatus(trailer textproto.MIMEHeader) error {
+	code := trailer.Get("Grpc-Status")
+	if code == "" {
+		return status.Error(codes.Internal, "gRPC-Web response is missing grpc-status")
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return status.Errorf(codes.Internal, "gRPC-Web response has invalid grpc-status %q", code)
+	}
+
+	if details := trailer.Get("Grpc-Status-Details-Bin"); details != "" {
+		if raw, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(details, "=")); err == nil {
+			st := spb.Status{}
+			if err := proto.Unmarshal(raw, &st); err == nil {
+				return status.FromProto(&st).Err()
+			}
+		}
+	}
+
+	msg, err := url.PathUnescape(trailer.Get("Grpc-Message"))
+	if err != nil {
+		msg = trailer.Get("Grpc-Message")
+	}
+	return status.Error(codes.Code(n), msg)
+}
+
+// httpStatusToGrpcCode maps an HTTP status to a gRPC code the same way
+// grpc-go does when a proxy answers instead of the server.
+func httpStatusToGrpcCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
@@ -0,0 +1,189 @@
+package otlpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebTestConfig is unixTestConfig with gRPC metadata, headers, and
+// --insecure set per test.
+type grpcWebTestConfig struct {
+	unixTestConfig
+	insecure bool
+}
+
+func (c grpcWebTestConfig) GetInsecure() bool { return c.insecure }
+func (c grpcWebTestConfig) GetGrpcMetadata() map[string]string {
+	return map[string]string{"x-md": "grpc"}
+}
+func (c grpcWebTestConfig) GetHeaders() map[string]string {
+	return map[string]string{"x-test": "grpc-web"}
+}
+
+func TestGrpcWebExportURL(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		insecure bool
+		want     string
+	}{
+		{
+			endpoint: "grpc://collector.example.com:4317",
+			want:     "https://collector.example.com:4317" + grpcWebExportPath,
+		},
+		{
+			endpoint: "grpc://localhost:4317",
+			insecure: true,
+			want:     "http://localhost:4317" + grpcWebExportPath,
+		},
+		{
+			endpoint: "https://ingress.example.com/otlp/v1/traces",
+			want:     "https://ingress.example.com/otlp" + grpcWebExportPath,
+		},
+		{
+			endpoint: "https://ingress.example.com/otlp/v1/metrics",
+			want:     "https://ingress.example.com/otlp" + grpcWebExportPath,
+		},
+		{
+			endpoint: "https://ingress.example.com/otlp/v1/logs",
+			want:     "https://ingress.example.com/otlp" + grpcWebExportPath,
+		},
+		{
+			endpoint: "unix:///var/run/otelcol.sock",
+			want:     "http://localhost" + grpcWebExportPath,
+		},
+	} {
+		gwc := NewGrpcWebClient(grpcWebTestConfig{unixTestConfig{endpoint: tc.endpoint}, tc.insecure})
+		if got := gwc.exportURL(grpcWebExportPath); got != tc.want {
+			t.Errorf("expected %q for %q but got %q", tc.want, tc.endpoint, got)
+		}
+	}
+}
+
+func TestParseGrpcWebResponse(t *testing.T) {
+	etsr, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	ok := append(grpcWebFrame(0, etsr), grpcWebFrame(grpcWebFlagTrailer, []byte("grpc-status: 0\r\ngrpc-message: \r\n"))...)
+
+	for _, tc := range []struct {
+		name   string
+		status int
+		header http.Header
+		body   []byte
+		code   codes.Code
+		msg    string
+	}{
+		{
+			name:   "ok in trailer frame",
+			status: 200,
+			body:   ok,
+			code:   codes.OK,
+		},
+		{
+			name:   "trailers only",
+			status: 200,
+			header: http.Header{"Grpc-Status": []string{"14"}, "Grpc-Message": []string{"collector%20is%20busy"}},
+			code:   codes.Unavailable,
+			msg:    "collector is busy",
+		},
+		{
+			name:   "error in trailer frame",
+			status: 200,
+			body:   grpcWebFrame(grpcWebFlagTrailer, []byte("grpc-status:3\r\ngrpc-message:bad span")),
+			code:   codes.InvalidArgument,
+			msg:    "bad span",
+		},
+		{
+			name:   "missing status",
+			status: 200,
+			body:   grpcWebFrame(0, etsr),
+			code:   codes.Internal,
+			msg:    "gRPC-Web response is missing grpc-status",
+		},
+		{
+			name:   "truncated frame",
+			status: 200,
+			body:   ok[:len(ok)-3],
+			code:   codes.Internal,
+			msg:    "gRPC-Web response has a truncated frame",
+		},
+		{
+			name:   "proxy returned 503",
+			status: 503,
+			code:   codes.Unavailable,
+			msg:    "gRPC-Web server returned HTTP status 503",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			header := tc.header
+			if header == nil {
+				header = http.Header{}
+			}
+			err := parseGrpcWebResponse(&http.Response{StatusCode: tc.status, Header: header}, tc.body)
+			st := status.Convert(err)
+			if st.Code() != tc.code {
+				t.Errorf("expected code %s but got %s: %s", tc.code, st.Code(), st.Message())
+			}
+			if st.Message() != tc.msg {
+				t.Errorf("expected message %q but got %q", tc.msg, st.Message())
+			}
+		})
+	}
+}
+
+func TestGrpcWebClient(t *testing.T) {
+	var gotPath, gotType, gotHeader, gotMD string
+	var gotReq coltracepb.ExportTraceServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		gotType = req.Header.Get("Content-Type")
+		gotHeader = req.Header.Get("x-test")
+		gotMD = req.Header.Get("x-md")
+
+		body, _ := io.ReadAll(req.Body)
+		if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Errorf("request is not a single gRPC-Web frame")
+		} else if err := proto.Unmarshal(body[5:], &gotReq); err != nil {
+			t.Errorf("failed to unmarshal request: %s", err)
+		}
+
+		etsr, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+		rw.Header().Set("Content-Type", "application/grpc-web+proto")
+		rw.Write(grpcWebFrame(0, etsr))
+		rw.Write(grpcWebFrame(grpcWebFlagTrailer, []byte("grpc-status: 0\r\n")))
+	}))
+	defer server.Close()
+
+	client := NewGrpcWebClient(grpcWebTestConfig{unixTestConfig{endpoint: server.URL}, true})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	if _, err := client.UploadTraces(ctx, zipkinTestSpans()); err != nil {
+		t.Fatalf("failed to upload spans: %s", err)
+	}
+
+	if gotPath != grpcWebExportPath {
+		t.Errorf("expected path %q but got %q", grpcWebExportPath, gotPath)
+	}
+	if gotType != "application/grpc-web+proto" {
+		t.Errorf("expected gRPC-Web content type but got %q", gotType)
+	}
+	if gotHeader != "grpc-web" || gotMD != "grpc" {
+		t.Errorf("expected headers and gRPC metadata to be sent but got %q and %q", gotHeader, gotMD)
+	}
+	if got := gotReq.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; got != "zipkin test" {
+		t.Errorf("expected the span to be sent but got %q", got)
+	}
+}
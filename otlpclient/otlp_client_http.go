@@ -2,16 +2,21 @@ package otlpclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/protobuf/proto"
@@ -32,32 +37,98 @@ func NewHttpClient(config OTLPConfig) *HttpClient {
 // Start sets up the client configuration.
 // TODO: see if there's a way to background start http2 connections?
 func (hc *HttpClient) Start(ctx context.Context) (context.Context, error) {
-	if hc.config.GetInsecure() {
-		hc.client = &http.Client{Timeout: hc.config.GetTimeout()}
-	} else {
-		hc.client = &http.Client{
-			Timeout: hc.config.GetTimeout(),
-			Transport: &http.Transport{
-				DialTLS: func(network, addr string) (net.Conn, error) {
-					return tls.Dial(network, addr, hc.config.GetTlsConfig())
-				},
-			},
+	transport, err := NewHttpTransport(hc.config)
+	if err != nil {
+		return ctx, err
+	}
+
+	// unix sockets ignore the address and always dial the socket
+	if endpointURL := hc.config.GetEndpoint(); endpointURL.Scheme == "unix" {
+		socketPath := UnixSocketPath(endpointURL)
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: hc.config.GetConnectTimeout()}
+			return d.DialContext(ctx, "unix", socketPath)
 		}
 	}
+
+	hc.client = &http.Client{
+		Timeout:   hc.config.GetSendTimeout(),
+		Transport: debugTransport{next: transport},
+	}
 	return ctx, nil
 }
 
+// NewHttpTransport returns an http.Transport with the proxy, TLS, and connect
+// timeout settings from the config, for the HTTP-based clients and other
+// requests that should go out the same way, like fetching OAuth2 tokens.
+func NewHttpTransport(config OTLPConfig) (*http.Transport, error) {
+	proxyURL, err := parseProxyURL(config.GetProxy())
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: config.GetConnectTimeout()}
+	transport := &http.Transport{
+		Proxy:               httpProxyFunc(proxyURL),
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: config.GetConnectTimeout(),
+	}
+	if !config.GetInsecure() {
+		transport.TLSClientConfig = config.GetTlsConfig()
+	}
+
+	return transport, nil
+}
+
 // UploadTraces sends the protobuf spans up to the HTTP server.
 func (hc *HttpClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
 	msg := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
-	protoMsg, err := proto.Marshal(&msg)
+	return hc.export(ctx, &msg, &coltracepb.ExportTraceServiceResponse{}, "/v1/traces")
+}
+
+// UploadMetrics sends the protobuf metrics up to the HTTP server.
+func (hc *HttpClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	msg := colmetricspb.ExportMetricsServiceRequest{ResourceMetrics: rms}
+	return hc.export(ctx, &msg, &colmetricspb.ExportMetricsServiceResponse{}, "/v1/metrics")
+}
+
+// UploadLogs sends the protobuf log records up to the HTTP server.
+func (hc *HttpClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	msg := collogspb.ExportLogsServiceRequest{ResourceLogs: rls}
+	return hc.export(ctx, &msg, &collogspb.ExportLogsServiceResponse{}, "/v1/logs")
+}
+
+// export POSTs the export request for any signal and checks the response,
+// which is unmarshaled into resp. unixPath is the URL path to use when the
+// endpoint is a unix socket, since those don't have one.
+func (hc *HttpClient) export(ctx context.Context, msg proto.Message, resp proto.Message, unixPath string) (context.Context, error) {
+	// http/protobuf is the default, http/json has to be asked for
+	var payload []byte
+	var err error
+	contentType := "application/x-protobuf"
+	if hc.config.GetProtocol() == "http/json" {
+		contentType = "application/json"
+		payload, err = MarshalOtlpJson(msg)
+	} else {
+		payload, err = proto.Marshal(msg)
+	}
 	if err != nil {
-		return ctx, fmt.Errorf("failed to marshal trace service request: %w", err)
+		return ctx, fmt.Errorf("failed to marshal export request: %w", err)
 	}
-	body := bytes.NewBuffer(protoMsg)
 
+	if hc.config.GetCompression() == "gzip" {
+		payload, err = gzipBytes(payload)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to gzip export request: %w", err)
+		}
+	}
 	endpointURL := hc.config.GetEndpoint()
-	req, err := http.NewRequest("POST", endpointURL.String(), body)
+	if endpointURL.Scheme == "unix" {
+		// the socket is dialed by the transport, the host is only for the Host header
+		endpointURL = &url.URL{Scheme: "http", Host: "localhost", Path: unixPath}
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpointURL.String(), bytes.NewReader(payload))
 	if err != nil {
 		return ctx, fmt.Errorf("failed to create HTTP POST request: %w", err)
 	}
@@ -65,50 +136,102 @@ func (hc *HttpClient) UploadTraces(ctx context.Context, rsps []*tracepb.Resource
 	for k, v := range hc.config.GetHeaders() {
 		req.Header.Add(k, v)
 	}
-	req.Header.Set("Content-Type", "application/x-protobuf")
+	// a User-Agent in --otlp-headers wins
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", hc.config.GetUserAgent())
+	}
+	req.Header.Set("Content-Type", contentType)
+	if hc.config.GetCompression() == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	wd := getWireDump(ctx)
+	if wd != nil {
+		wd.dumpHttpRequest(req, payload, msg)
+	}
+
+	return retry(ctx, hc.config, func(innerCtx context.Context) (context.Context, bool, time.Duration, error) {
+		// the body is consumed on each attempt so every attempt gets a copy of
+		// the request with the body rewound
+		attempt := req.Clone(ctx)
+		var err error
+		attempt.Body, err = req.GetBody()
+		if err != nil {
+			return innerCtx, false, 0, fmt.Errorf("failed to rewind HTTP request body: %w", err)
+		}
 
-	return retry(ctx, hc.config, func(context.Context) (context.Context, bool, time.Duration, error) {
 		var body []byte
-		resp, err := hc.client.Do(req)
+		httpResp, err := hc.client.Do(attempt)
 		if uerr, ok := err.(*url.Error); ok {
+			if wd != nil {
+				wd.write("OTLP response: none, "+uerr.Error(), nil, nil, "")
+			}
 			// e.g. http on https, un-retriable error, quit now
-			return ctx, false, 0, uerr
+			return innerCtx, false, 0, uerr
 		} else {
-			body, err = io.ReadAll(resp.Body)
+			body, err = io.ReadAll(httpResp.Body)
 			if err != nil {
-				return ctx, true, 0, fmt.Errorf("io.Readall of response body failed: %w", err)
+				return innerCtx, true, 0, fmt.Errorf("io.Readall of response body failed: %w", err)
+			}
+			httpResp.Body.Close()
+			if wd != nil {
+				wd.dumpHttpResponse(httpResp, body, contentType, resp)
 			}
-			resp.Body.Close()
 
-			return processHTTPStatus(ctx, resp, body)
+			return processHTTPResponse(innerCtx, httpResp, body, contentType, resp)
 		}
 	})
 }
 
-// processHTTPStatus takes the http.Response and body, returning the same bool, error
-// as retryFunc. Mostly it's broken out so it can be unit tested.
-func processHTTPStatus(ctx context.Context, resp *http.Response, body []byte) (context.Context, bool, time.Duration, error) {
+// gzipBytes compresses the data with gzip at the default compression level.
+func gzipBytes(data []byte) ([]byte, error) {
+	buf := bytes.Buffer{}
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// processHTTPStatus takes the http.Response, body, and the content type of a
+// trace export request, returning the same bool, error as retryFunc. Mostly
+// it's broken out so it can be unit tested.
+func processHTTPStatus(ctx context.Context, resp *http.Response, body []byte, contentType string) (context.Context, bool, time.Duration, error) {
+	return processHTTPResponse(ctx, resp, body, contentType, &coltracepb.ExportTraceServiceResponse{})
+}
+
+// processHTTPResponse is processHTTPStatus for any signal, unmarshaling a
+// successful response into exportResp to check for partial success.
+func processHTTPResponse(ctx context.Context, resp *http.Response, body []byte, contentType string, exportResp proto.Message) (context.Context, bool, time.Duration, error) {
 	// #262 a vendor OTLP server is out of spec and returns JSON instead of protobuf
+	// the spec says servers respond with the same content type as the request
 	ctype := resp.Header.Get("Content-Type")
 	if ctype == "" {
 		return ctx, false, 0, fmt.Errorf("server is out of specification: Content-Type header is missing or mangled")
-	} else if ctype != "application/x-protobuf" {
-		return ctx, false, 0, fmt.Errorf("server is out of specification: expected content type application/x-protobuf but got %q", ctype)
+	} else if ctype != contentType {
+		return ctx, false, 0, fmt.Errorf("server is out of specification: expected content type %s but got %q", contentType, ctype)
+	}
+
+	unmarshal := proto.Unmarshal
+	if contentType == "application/json" {
+		unmarshal = UnmarshalOtlpJson
 	}
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		// success & partial success
 		// spec says server MUST send 200 OK, we'll be generous and accept any 200
-		etsr := coltracepb.ExportTraceServiceResponse{}
-		err := proto.Unmarshal(body, &etsr)
+		err := unmarshal(body, exportResp)
 		if err != nil {
 			// if the server's sending garbage, no point in retrying
 			return ctx, false, 0, fmt.Errorf("unmarshal of server response failed: %w", err)
 		}
 
-		if partial := etsr.GetPartialSuccess(); partial != nil && partial.RejectedSpans > 0 {
-			// spec says to stop retrying and drop rejected spans
-			return ctx, false, 0, fmt.Errorf("partial success. %d spans were rejected", partial.GetRejectedSpans())
+		if rejected, what := rejectedCount(exportResp); rejected > 0 {
+			// spec says to stop retrying and drop rejected data
+			return ctx, false, 0, fmt.Errorf("partial success. %d %s were rejected", rejected, what)
 
 		} else {
 			// full success!
@@ -116,14 +239,16 @@ func processHTTPStatus(ctx context.Context, resp *http.Response, body []byte) (c
 		}
 	} else if resp.StatusCode == 429 || resp.StatusCode == 502 || resp.StatusCode == 503 || resp.StatusCode == 504 {
 		// 429, 502, 503, and 504 must be retried according to spec
-		return ctx, true, 0, fmt.Errorf("server responded with retriable code %d", resp.StatusCode)
+		// and the server may tell us how long to wait with Retry-After
+		wait := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return ctx, true, wait, httpStatusError{server: "server", code: resp.StatusCode}
 	} else if resp.StatusCode >= 300 && resp.StatusCode < 400 {
 		// spec doesn't say anything about 300's, ignore body and assume they're errors and unretriable
 		return ctx, false, 0, fmt.Errorf("server returned unsupported code %d", resp.StatusCode)
 	} else if resp.StatusCode >= 400 {
 		// https://github.com/open-telemetry/opentelemetry-proto/blob/main/docs/specification.md#failures-1
 		st := status.Status{}
-		err := proto.Unmarshal(body, &st)
+		err := unmarshal(body, &st)
 		if err != nil {
 			return ctx, false, 0, fmt.Errorf("unmarshal of server status failed: %w", err)
 		} else {
@@ -135,6 +260,28 @@ func processHTTPStatus(ctx context.Context, resp *http.Response, body []byte) (c
 	return ctx, false, 0, fmt.Errorf("BUG: fell through error checking with status code %d", resp.StatusCode)
 }
 
+// parseRetryAfter parses a Retry-After header value, which is either a number
+// of seconds or an HTTP date, into a wait duration. Returns 0 when the header
+// is missing or invalid so the default backoff is used.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		return 0
+	}
+
+	if when, err := http.ParseTime(value); err == nil && when.After(now) {
+		return when.Sub(now)
+	}
+
+	return 0
+}
+
 // Stop does nothing for HTTP, for now. It exists to fulfill the interface.
 func (hc *HttpClient) Stop(ctx context.Context) (context.Context, error) {
 	return ctx, nil
@@ -3,8 +3,11 @@ package otlpclient
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
@@ -21,6 +24,7 @@ func TestProcessHTTPStatus(t *testing.T) {
 	for _, tc := range []struct {
 		resp      *http.Response
 		body      []byte
+		reqType   string // defaults to application/x-protobuf
 		keepgoing bool
 		err       error
 	}{
@@ -118,6 +122,27 @@ func TestProcessHTTPStatus(t *testing.T) {
 			keepgoing: false,
 			err:       fmt.Errorf(`server is out of specification: expected content type application/x-protobuf but got "application/json"`),
 		},
+		// http/json requests get json responses
+		{
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+			},
+			body:      []byte(`{}`),
+			reqType:   "application/json",
+			keepgoing: false,
+			err:       nil,
+		},
+		{
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+			},
+			body:      []byte(`{"partialSuccess": {"rejectedSpans": "2"}}`),
+			reqType:   "application/json",
+			keepgoing: false,
+			err:       fmt.Errorf("partial success. 2 spans were rejected"),
+		},
 		// spec requires headers so report that as a server problem too
 		{
 			resp: &http.Response{
@@ -129,8 +154,11 @@ func TestProcessHTTPStatus(t *testing.T) {
 			err:       fmt.Errorf("server is out of specification: Content-Type header is missing or mangled"),
 		},
 	} {
+		if tc.reqType == "" {
+			tc.reqType = "application/x-protobuf"
+		}
 		ctx := context.Background()
-		_, kg, _, err := processHTTPStatus(ctx, tc.resp, tc.body)
+		_, kg, _, err := processHTTPStatus(ctx, tc.resp, tc.body, tc.reqType)
 
 		if kg != tc.keepgoing {
 			t.Errorf("keepgoing value returned %t but expected %t", kg, tc.keepgoing)
@@ -148,6 +176,81 @@ func TestProcessHTTPStatus(t *testing.T) {
 	}
 }
 
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "3", want: time.Second * 3},
+		{value: "-1", want: 0},
+		{value: "Mon, 01 Jan 2024 00:00:10 GMT", want: time.Second * 10},
+		{value: "Sun, 31 Dec 2023 23:59:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	} {
+		if got := parseRetryAfter(tc.value, now); got != tc.want {
+			t.Errorf("Retry-After %q parsed to %s but expected %s", tc.value, got, tc.want)
+		}
+	}
+}
+
+// httpRetryTestConfig is unixTestConfig with retries turned on.
+type httpRetryTestConfig struct {
+	unixTestConfig
+}
+
+func (c httpRetryTestConfig) GetRetryMaxAttempts() int { return 3 }
+
+func TestHttpClientRetry(t *testing.T) {
+	attempts := 0
+	received := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(req.Body)
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+		if attempts == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		msg := coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, &msg); err != nil {
+			t.Errorf("failed to unmarshal request body on attempt %d: %s", attempts, err)
+		}
+		for _, rs := range msg.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					received = append(received, span.Name)
+				}
+			}
+		}
+		rw.Write(etsrSuccessBody())
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	client := NewHttpClient(httpRetryTestConfig{unixTestConfig{endpoint: srv.URL + "/v1/traces"}})
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	ctx, err = client.UploadTraces(ctx, fileTestSpans("retried"))
+	if err != nil {
+		t.Fatalf("expected the upload to succeed on retry but got: %s", err)
+	}
+
+	if len(received) != 1 || received[0] != "retried" {
+		t.Errorf("expected the span to arrive on the second attempt but got %v", received)
+	}
+	if got := GetRetryCount(ctx); got != 1 {
+		t.Errorf("expected 1 retry to be recorded but got %d", got)
+	}
+}
+
 func etsrSuccessBody() []byte {
 	etsr := coltracepb.ExportTraceServiceResponse{
 		PartialSuccess: nil,
@@ -0,0 +1,155 @@
+package otlpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// KafkaConfig holds the settings for publishing spans to Kafka.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	// Encoding is otlp_proto or otlp_json, the same names the collector's
+	// kafka receiver uses.
+	Encoding      string
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+// KafkaClient publishes spans to a Kafka topic as serialized OTLP export
+// requests, one message per upload, that the collector's kafka receiver can
+// consume. kafka:// endpoints are plaintext and kafkas:// use TLS.
+type KafkaClient struct {
+	writer *kafka.Writer
+	config OTLPConfig
+}
+
+// NewKafkaClient returns an initialized KafkaClient.
+func NewKafkaClient(config OTLPConfig) *KafkaClient {
+	return &KafkaClient{config: config}
+}
+
+// Start sets up the Kafka writer. Connections to the brokers are made on
+// the first upload.
+func (kc *KafkaClient) Start(ctx context.Context) (context.Context, error) {
+	kconf := kc.config.GetKafkaConfig()
+	if len(kconf.Brokers) == 0 {
+		return ctx, fmt.Errorf("no kafka brokers configured")
+	}
+	if kconf.Encoding != "otlp_proto" && kconf.Encoding != "otlp_json" {
+		return ctx, fmt.Errorf("invalid kafka encoding %q, must be otlp_proto or otlp_json", kconf.Encoding)
+	}
+
+	mechanism, err := kafkaSASLMechanism(kconf)
+	if err != nil {
+		return ctx, err
+	}
+
+	dialTimeout := kc.config.GetConnectTimeout()
+	if dialTimeout == 0 {
+		dialTimeout = kc.config.GetTimeout()
+	}
+	transport := &kafka.Transport{
+		DialTimeout: dialTimeout,
+		SASL:        mechanism,
+	}
+	if !kc.config.GetInsecure() {
+		transport.TLS = kc.config.GetTlsConfig()
+	}
+
+	kc.writer = &kafka.Writer{
+		Addr:         kafka.TCP(kconf.Brokers...),
+		Topic:        kconf.Topic,
+		Transport:    transport,
+		RequiredAcks: kafka.RequireOne,
+		// otel-cli sends one message per upload, so there's nothing to wait for
+		BatchSize: 1,
+		// retries are done by retry() so they follow the otel-cli settings
+		MaxAttempts:  1,
+		WriteTimeout: kc.config.GetSendTimeout(),
+	}
+	if kc.config.GetCompression() == "gzip" {
+		kc.writer.Compression = kafka.Gzip
+	}
+
+	return ctx, nil
+}
+
+// UploadTraces publishes the spans as a single message.
+func (kc *KafkaClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	payload, err := kafkaEncode(kc.config.GetKafkaConfig().Encoding, rsps)
+	if err != nil {
+		return ctx, err
+	}
+
+	return retry(ctx, kc.config, func(context.Context) (context.Context, bool, time.Duration, error) {
+		err := kc.writer.WriteMessages(ctx, kafka.Message{Value: payload})
+		if err == nil {
+			return ctx, false, 0, nil
+		}
+
+		// broker errors say whether they're worth retrying, e.g. leader
+		// elections are but authorization failures are not
+		var kerr kafka.Error
+		if errors.As(err, &kerr) && !kerr.Temporary() {
+			return ctx, false, 0, fmt.Errorf("kafka publish failed: %w", err)
+		}
+		return ctx, true, 0, fmt.Errorf("kafka publish failed: %w", err)
+	})
+}
+
+// Stop closes the Kafka writer and its connections.
+func (kc *KafkaClient) Stop(ctx context.Context) (context.Context, error) {
+	if kc.writer == nil {
+		return ctx, nil
+	}
+	return ctx, kc.writer.Close()
+}
+
+// kafkaEncode serializes the spans as an ExportTraceServiceRequest, which is
+// what the collector's kafka receiver expects for otlp_proto and otlp_json.
+func kafkaEncode(encoding string, rsps []*tracepb.ResourceSpans) ([]byte, error) {
+	msg := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
+
+	var payload []byte
+	var err error
+	if encoding == "otlp_json" {
+		payload, err = MarshalOtlpJson(&msg)
+	} else {
+		payload, err = proto.Marshal(&msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trace service request: %w", err)
+	}
+
+	return payload, nil
+}
+
+// kafkaSASLMechanism returns the SASL mechanism for the config, or nil when
+// SASL is not configured.
+func kafkaSASLMechanism(kconf KafkaConfig) (sasl.Mechanism, error) {
+	switch strings.ToUpper(kconf.SASLMechanism) {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: kconf.SASLUsername, Password: kconf.SASLPassword}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, kconf.SASLUsername, kconf.SASLPassword)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, kconf.SASLUsername, kconf.SASLPassword)
+	default:
+		return nil, fmt.Errorf("invalid kafka SASL mechanism %q, must be PLAIN, SCRAM-SHA-256, or SCRAM-SHA-512", kconf.SASLMechanism)
+	}
+}
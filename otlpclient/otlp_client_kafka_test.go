@@ -0,0 +1,91 @@
+package otlpclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// kafkaTestConfig provides the settings KafkaClient uses and leaves the rest
+// of the interface unimplemented.
+type kafkaTestConfig struct {
+	OTLPConfig
+	kafka KafkaConfig
+}
+
+func (c kafkaTestConfig) GetKafkaConfig() KafkaConfig      { return c.kafka }
+func (c kafkaTestConfig) GetInsecure() bool                { return true }
+func (c kafkaTestConfig) GetCompression() string           { return "" }
+func (c kafkaTestConfig) GetTimeout() time.Duration        { return time.Second }
+func (c kafkaTestConfig) GetConnectTimeout() time.Duration { return 0 }
+func (c kafkaTestConfig) GetSendTimeout() time.Duration    { return time.Second }
+
+func TestKafkaEncode(t *testing.T) {
+	span := NewProtobufSpan()
+	span.Name = "kafka test"
+	rsps := []*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}}}}
+
+	for encoding, unmarshal := range map[string]func([]byte, proto.Message) error{
+		"otlp_proto": proto.Unmarshal,
+		"otlp_json":  UnmarshalOtlpJson,
+	} {
+		payload, err := kafkaEncode(encoding, rsps)
+		if err != nil {
+			t.Fatalf("failed to encode %s: %s", encoding, err)
+		}
+
+		msg := coltracepb.ExportTraceServiceRequest{}
+		if err := unmarshal(payload, &msg); err != nil {
+			t.Fatalf("failed to decode %s: %s", encoding, err)
+		}
+		if got := msg.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; got != "kafka test" {
+			t.Errorf("expected span name 'kafka test' in %s but got %q", encoding, got)
+		}
+	}
+}
+
+func TestKafkaSASLMechanism(t *testing.T) {
+	for mechanism, want := range map[string]string{
+		"":              "",
+		"plain":         "PLAIN",
+		"SCRAM-SHA-256": "SCRAM-SHA-256",
+		"SCRAM-SHA-512": "SCRAM-SHA-512",
+	} {
+		got, err := kafkaSASLMechanism(KafkaConfig{SASLMechanism: mechanism, SASLUsername: "u", SASLPassword: "p"})
+		if err != nil {
+			t.Errorf("unexpected error for mechanism %q: %s", mechanism, err)
+		} else if want == "" && got != nil {
+			t.Errorf("expected no mechanism but got %s", got.Name())
+		} else if want != "" && got.Name() != want {
+			t.Errorf("expected mechanism %s but got %s", want, got.Name())
+		}
+	}
+
+	if _, err := kafkaSASLMechanism(KafkaConfig{SASLMechanism: "GSSAPI"}); err == nil {
+		t.Errorf("expected an error for an unsupported mechanism")
+	}
+}
+
+func TestKafkaClientStart(t *testing.T) {
+	for _, tc := range []struct {
+		kafka  KafkaConfig
+		wantOk bool
+	}{
+		{kafka: KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "otlp_spans", Encoding: "otlp_proto"}, wantOk: true},
+		{kafka: KafkaConfig{Topic: "otlp_spans", Encoding: "otlp_proto"}, wantOk: false},
+		{kafka: KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "otlp_spans", Encoding: "avro"}, wantOk: false},
+	} {
+		client := NewKafkaClient(kafkaTestConfig{kafka: tc.kafka})
+		_, err := client.Start(context.Background())
+		if tc.wantOk && err != nil {
+			t.Errorf("unexpected error starting client for %+v: %s", tc.kafka, err)
+		} else if !tc.wantOk && err == nil {
+			t.Errorf("expected an error starting client for %+v", tc.kafka)
+		}
+		client.Stop(context.Background())
+	}
+}
@@ -3,6 +3,8 @@ package otlpclient
 import (
 	"context"
 
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
@@ -25,6 +27,16 @@ func (nc *NullClient) UploadTraces(ctx context.Context, rsps []*tracepb.Resource
 	return ctx, nil
 }
 
+// UploadMetrics fulfills the interface and does nothing.
+func (nc *NullClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	return ctx, nil
+}
+
+// UploadLogs fulfills the interface and does nothing.
+func (nc *NullClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	return ctx, nil
+}
+
 // Stop fulfills the interface and does nothing.
 func (gc *NullClient) Stop(ctx context.Context) (context.Context, error) {
 	return ctx, nil
@@ -42,3 +42,67 @@ func TestErrorLists(t *testing.T) {
 
 	}
 }
+
+// retryTestConfig overrides the retry settings and leaves the rest of the
+// interface unimplemented, since retry() doesn't use it.
+type retryTestConfig struct {
+	OTLPConfig
+	maxAttempts int
+}
+
+func (c retryTestConfig) GetRetryMaxAttempts() int               { return c.maxAttempts }
+func (c retryTestConfig) GetRetryInitialInterval() time.Duration { return time.Millisecond }
+func (c retryTestConfig) GetRetryMaxInterval() time.Duration     { return time.Millisecond * 4 }
+
+func TestRetry(t *testing.T) {
+	for _, tc := range []struct {
+		maxAttempts int
+		failures    int
+		wantErr     bool
+		wantRetries int
+	}{
+		{maxAttempts: 0, failures: 0, wantErr: false, wantRetries: 0},
+		{maxAttempts: 0, failures: 3, wantErr: false, wantRetries: 3},
+		{maxAttempts: 2, failures: 3, wantErr: true, wantRetries: 1},
+		{maxAttempts: 5, failures: 3, wantErr: false, wantRetries: 3},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		calls := 0
+		ctx, err := retry(ctx, retryTestConfig{maxAttempts: tc.maxAttempts}, func(ctx context.Context) (context.Context, bool, time.Duration, error) {
+			calls++
+			if calls <= tc.failures {
+				return ctx, true, 0, fmt.Errorf("failure %d", calls)
+			}
+			return ctx, false, 0, nil
+		})
+
+		if tc.wantErr != (err != nil) {
+			t.Errorf("expected error: %t but got %v", tc.wantErr, err)
+		}
+
+		if got := GetRetryCount(ctx); got != tc.wantRetries {
+			t.Errorf("expected %d retries but got %d", tc.wantRetries, got)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	initial := time.Millisecond * 100
+	max := time.Second
+
+	for attempt, want := range map[int]time.Duration{
+		1: initial,
+		2: initial * 2,
+		3: initial * 4,
+		5: max,
+		9: max,
+	} {
+		got := backoff(attempt, initial, max)
+		// jitter randomizes the second half of the wait
+		if got < want/2 || got > want {
+			t.Errorf("backoff for attempt %d should be between %s and %s but got %s", attempt, want/2, want, got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package otlpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// unixTestConfig provides the settings HttpClient uses for a unix socket
+// endpoint and leaves the rest of the interface unimplemented.
+type unixTestConfig struct {
+	OTLPConfig
+	endpoint string
+}
+
+func (c unixTestConfig) GetEndpoint() *url.URL {
+	u, _ := url.Parse(c.endpoint)
+	return u
+}
+func (c unixTestConfig) GetInsecure() bool                      { return true }
+func (c unixTestConfig) GetProxy() string                       { return "" }
+func (c unixTestConfig) GetUserAgent() string                   { return "otel-cli/test" }
+func (c unixTestConfig) GetGrpcMetadata() map[string]string     { return map[string]string{} }
+func (c unixTestConfig) GetProtocol() string                    { return "http/protobuf" }
+func (c unixTestConfig) GetCompression() string                 { return "" }
+func (c unixTestConfig) GetHeaders() map[string]string          { return map[string]string{} }
+func (c unixTestConfig) GetTimeout() time.Duration              { return time.Second }
+func (c unixTestConfig) GetConnectTimeout() time.Duration       { return 0 }
+func (c unixTestConfig) GetSendTimeout() time.Duration          { return time.Second }
+func (c unixTestConfig) GetRetryMaxAttempts() int               { return 1 }
+func (c unixTestConfig) GetRetryInitialInterval() time.Duration { return 0 }
+func (c unixTestConfig) GetRetryMaxInterval() time.Duration     { return 0 }
+
+func TestUnixSocketPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"unix:///var/run/otelcol.sock": "/var/run/otelcol.sock",
+		"unix://run/otelcol.sock":      "run/otelcol.sock",
+	} {
+		u, _ := url.Parse(in)
+		if got := UnixSocketPath(u); got != want {
+			t.Errorf("expected socket path %q for %q but got %q", want, in, got)
+		}
+	}
+}
+
+func TestHttpClientUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "otlp.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on unix socket: %s", err)
+	}
+
+	var gotPath string
+	var gotSpans int
+	srv := http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		body, _ := io.ReadAll(req.Body)
+		msg := coltracepb.ExportTraceServiceRequest{}
+		proto.Unmarshal(body, &msg)
+		gotSpans = len(msg.ResourceSpans)
+
+		resp, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+		rw.Write(resp)
+	})}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	client := NewHttpClient(unixTestConfig{endpoint: "unix://" + socket})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx, err = client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+
+	if _, err = client.UploadTraces(ctx, fileTestSpans("unix")); err != nil {
+		t.Fatalf("upload over unix socket failed: %s", err)
+	}
+
+	if gotPath != "/v1/traces" || gotSpans != 1 {
+		t.Errorf("expected 1 resource span at /v1/traces but got %d at %q", gotSpans, gotPath)
+	}
+}
+
+// unixTraceServer counts the spans it receives over gRPC.
+type unixTraceServer struct {
+	coltracepb.UnimplementedTraceServiceServer
+	spans chan int
+}
+
+func (s *unixTraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	s.spans <- len(req.ResourceSpans)
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestGrpcClientUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "otlp.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on unix socket: %s", err)
+	}
+
+	ts := unixTraceServer{spans: make(chan int, 1)}
+	srv := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(srv, &ts)
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	client := NewGrpcClient(unixTestConfig{endpoint: "unix://" + socket})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx, err = client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	defer client.Stop(ctx)
+
+	if _, err = client.UploadTraces(ctx, fileTestSpans("unix")); err != nil {
+		t.Fatalf("upload over unix socket failed: %s", err)
+	}
+
+	if got := <-ts.spans; got != 1 {
+		t.Errorf("expected 1 resource span but got %d", got)
+	}
+}
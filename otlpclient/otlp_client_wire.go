@@ -0,0 +1,176 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// wireSafeHeaders are the request headers otel-cli sets itself, which are
+// the only ones whose values are dumped. The rest come from --otlp-headers
+// and friends and are often credentials.
+var wireSafeHeaders = map[string]bool{
+	"content-type":     true,
+	"content-encoding": true,
+	"user-agent":       true,
+}
+
+// wireDump writes requests and responses as they go over the wire. Fanout
+// clients share it, so each dump is written whole under the lock.
+type wireDump struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// wireDumpKey is the context key for the wireDump.
+func wireDumpKey() otlpClientCtxKey {
+	return otlpClientCtxKey("wire_dump")
+}
+
+// WithWireDump returns a context that makes the OTLP/HTTP and OTLP/gRPC
+// clients write every request they send and response they get to w, as a
+// hex dump of the payload followed by it decoded as OTLP/JSON.
+func WithWireDump(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, wireDumpKey(), &wireDump{w: w})
+}
+
+// getWireDump returns the wireDump in ctx or nil when there isn't one.
+func getWireDump(ctx context.Context) *wireDump {
+	if wd, ok := ctx.Value(wireDumpKey()).(*wireDump); ok {
+		return wd
+	}
+	return nil
+}
+
+// write dumps one request or response: the heading, the headers, which are
+// already formatted, the payload in hex, and then decoded. A nil payload is
+// for when there was no response at all.
+func (wd *wireDump) write(heading string, headers []string, payload []byte, decoded string) {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "--- %s\n", heading)
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s\n", header)
+	}
+	if payload != nil {
+		fmt.Fprintf(&buf, "payload: %d bytes\n", len(payload))
+		buf.WriteString(hex.Dump(payload))
+	}
+	if decoded != "" {
+		fmt.Fprintf(&buf, "decoded:\n%s\n", decoded)
+	}
+	buf.WriteString("\n")
+
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	wd.w.Write(buf.Bytes())
+}
+
+// wireJson returns the message as indented OTLP/JSON for a dump.
+func wireJson(msg proto.Message) string {
+	js, err := MarshalOtlpJson(msg)
+	if err != nil {
+		return fmt.Sprintf("unable to encode as OTLP/JSON: %s", err)
+	}
+	out := bytes.Buffer{}
+	if err := json.Indent(&out, js, "", "  "); err != nil {
+		return string(js)
+	}
+	return out.String()
+}
+
+// wireDecode returns the body unmarshaled into msg as OTLP/JSON, or why it
+// couldn't be, which is often the interesting part of a dump.
+func wireDecode(body []byte, unmarshal func([]byte, proto.Message) error, msg proto.Message) string {
+	if err := unmarshal(body, msg); err != nil {
+		return fmt.Sprintf("unable to decode as %s: %s", msg.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return wireJson(msg)
+}
+
+// wireHeaders returns the headers or gRPC metadata as sorted name: value
+// lines, with the values hidden unless showAll is set or otel-cli set them.
+func wireHeaders(in map[string][]string, showAll bool) []string {
+	out := []string{}
+	for name, values := range in {
+		value := "(hidden)"
+		if showAll || wireSafeHeaders[strings.ToLower(name)] {
+			value = strings.Join(values, ", ")
+		}
+		out = append(out, name+": "+value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// dumpHttpRequest dumps the exact body of an OTLP/HTTP request, which is
+// compressed when the Content-Encoding says so, with msg decoded after it.
+func (wd *wireDump) dumpHttpRequest(req *http.Request, payload []byte, msg proto.Message) {
+	wd.write(fmt.Sprintf("OTLP request: %s %s", req.Method, req.URL), wireHeaders(req.Header, false), payload, wireJson(msg))
+}
+
+// dumpHttpResponse dumps the raw body of an OTLP/HTTP response, decoded
+// as exportResp for success and as a Status for failures.
+func (wd *wireDump) dumpHttpResponse(resp *http.Response, body []byte, contentType string, exportResp proto.Message) {
+	unmarshal := proto.Unmarshal
+	if contentType == "application/json" {
+		unmarshal = UnmarshalOtlpJson
+	}
+
+	var msg proto.Message = exportResp.ProtoReflect().New().Interface()
+	if resp.StatusCode >= 400 {
+		msg = &spb.Status{}
+	}
+	decoded := ""
+	if len(body) > 0 {
+		decoded = wireDecode(body, unmarshal, msg)
+	}
+
+	wd.write("OTLP response: "+resp.Status, wireHeaders(resp.Header, true), body, decoded)
+}
+
+// grpcWireInterceptor dumps each gRPC request and response when there's a
+// wireDump in the context. gRPC frames and compresses messages itself, so
+// the payloads dumped are the serialized messages before that.
+func grpcWireInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	wd := getWireDump(ctx)
+	if wd == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if msg, ok := req.(proto.Message); ok {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		payload, _ := proto.Marshal(msg)
+		wd.write(fmt.Sprintf("OTLP request: gRPC %s%s", cc.Target(), method), wireHeaders(md, false), payload, wireJson(msg))
+	}
+
+	var header, trailer metadata.MD
+	opts = append(opts, grpc.Header(&header), grpc.Trailer(&trailer))
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	st := status.Convert(err)
+	var msg proto.Message = st.Proto()
+	if err == nil {
+		if replyMsg, ok := reply.(proto.Message); ok {
+			msg = replyMsg
+		}
+	}
+	payload, _ := proto.Marshal(msg)
+	headers := append(wireHeaders(header, true), wireHeaders(trailer, true)...)
+	heading := strings.TrimSpace(fmt.Sprintf("OTLP response: gRPC %s %s", st.Code(), st.Message()))
+	wd.write(heading, headers, payload, wireJson(msg))
+
+	return err
+}
@@ -0,0 +1,108 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// wireTestConfig is unixTestConfig with a credential in the headers.
+type wireTestConfig struct {
+	unixTestConfig
+}
+
+func (c wireTestConfig) GetHeaders() map[string]string {
+	return map[string]string{"Authorization": "Bearer secret"}
+}
+
+func TestHttpWireDump(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		resp, _ := proto.Marshal(&spb.Status{Message: "malformed payload"})
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write(resp)
+	}))
+	defer srv.Close()
+
+	out := bytes.Buffer{}
+	ctx, cancel := context.WithTimeout(WithWireDump(context.Background(), &out), time.Second)
+	defer cancel()
+
+	client := NewHttpClient(wireTestConfig{unixTestConfig{endpoint: srv.URL + "/v1/traces"}})
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	if _, err = client.UploadTraces(ctx, fileTestSpans("wire")); err == nil {
+		t.Fatal("expected the upload to fail with a 400")
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"--- OTLP request: POST " + srv.URL + "/v1/traces\n",
+		"Authorization: (hidden)\n",
+		"Content-Type: application/x-protobuf\n",
+		"00000000  0a ",
+		`"name": "wire"`,
+		"--- OTLP response: 400 Bad Request\n",
+		`"message": "malformed payload"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the dump to contain %q but got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("header values otel-cli didn't set should never be dumped but got %q", got)
+	}
+}
+
+func TestGrpcWireDump(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "otlp.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on unix socket: %s", err)
+	}
+
+	ts := unixTraceServer{spans: make(chan int, 1)}
+	srv := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(srv, &ts)
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	out := bytes.Buffer{}
+	ctx, cancel := context.WithTimeout(WithWireDump(context.Background(), &out), time.Second)
+	defer cancel()
+
+	client := NewGrpcClient(unixTestConfig{endpoint: "unix://" + socket})
+	ctx, err = client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	defer client.Stop(ctx)
+	if _, err = client.UploadTraces(ctx, fileTestSpans("wire")); err != nil {
+		t.Fatalf("upload failed: %s", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"/opentelemetry.proto.collector.trace.v1.TraceService/Export\n",
+		"00000000  0a ",
+		`"name": "wire"`,
+		"--- OTLP response: gRPC OK\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the dump to contain %q but got %q", want, got)
+		}
+	}
+}
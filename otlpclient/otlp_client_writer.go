@@ -0,0 +1,83 @@
+package otlpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// WriterClient is an OTLP client backend that writes spans to an io.Writer
+// as OTLP/JSON, one ResourceSpans per line, instead of making a network call.
+// The output is compatible with the collector's otlpjsonfile receiver.
+type WriterClient struct {
+	writer io.Writer
+	config OTLPConfig
+}
+
+// NewWriterClient returns a WriterClient that writes to the provided writer,
+// usually os.Stdout or os.Stderr.
+func NewWriterClient(config OTLPConfig, writer io.Writer) *WriterClient {
+	return &WriterClient{writer: writer, config: config}
+}
+
+// Start fulfills the interface and does nothing.
+func (wc *WriterClient) Start(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+// UploadTraces writes each ResourceSpans as a single line of OTLP/JSON.
+func (wc *WriterClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	for _, rs := range rsps {
+		js, err := MarshalOtlpJson(&tracepb.TracesData{ResourceSpans: []*tracepb.ResourceSpans{rs}})
+		if err != nil {
+			return ctx, err
+		}
+
+		if _, err := fmt.Fprintf(wc.writer, "%s\n", js); err != nil {
+			return ctx, fmt.Errorf("failed to write spans: %w", err)
+		}
+	}
+
+	return ctx, nil
+}
+
+// UploadMetrics writes each ResourceMetrics as a single line of OTLP/JSON.
+func (wc *WriterClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	for _, rm := range rms {
+		js, err := MarshalOtlpJson(&metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{rm}})
+		if err != nil {
+			return ctx, err
+		}
+
+		if _, err := fmt.Fprintf(wc.writer, "%s\n", js); err != nil {
+			return ctx, fmt.Errorf("failed to write metrics: %w", err)
+		}
+	}
+
+	return ctx, nil
+}
+
+// UploadLogs writes each ResourceLogs as a single line of OTLP/JSON.
+func (wc *WriterClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	for _, rl := range rls {
+		js, err := MarshalOtlpJson(&logspb.LogsData{ResourceLogs: []*logspb.ResourceLogs{rl}})
+		if err != nil {
+			return ctx, err
+		}
+
+		if _, err := fmt.Fprintf(wc.writer, "%s\n", js); err != nil {
+			return ctx, fmt.Errorf("failed to write log records: %w", err)
+		}
+	}
+
+	return ctx, nil
+}
+
+// Stop fulfills the interface and does nothing.
+func (wc *WriterClient) Stop(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
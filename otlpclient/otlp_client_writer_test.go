@@ -0,0 +1,44 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestWriterClient(t *testing.T) {
+	span := NewProtobufSpan()
+	span.TraceId = []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	span.Name = "writer test"
+	rs := &tracepb.ResourceSpans{
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+	}
+
+	buf := bytes.Buffer{}
+	client := NewWriterClient(nil, &buf)
+	_, err := client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{rs, rs})
+	if err != nil {
+		t.Fatalf("unexpected error writing spans: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines of output but got %d: %q", len(lines), buf.String())
+	}
+
+	for _, line := range lines {
+		td := tracepb.TracesData{}
+		if err := UnmarshalOtlpJson([]byte(line), &td); err != nil {
+			t.Fatalf("output line is not valid OTLP/JSON: %s", err)
+		}
+		if got := td.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; got != "writer test" {
+			t.Errorf("expected span name 'writer test' but got %q", got)
+		}
+		if !strings.Contains(line, `"traceId":"00112233445566778899aabbccddeeff"`) {
+			t.Errorf("expected hex trace id in output: %s", line)
+		}
+	}
+}
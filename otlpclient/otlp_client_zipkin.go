@@ -0,0 +1,271 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// ZipkinClient sends spans to a Zipkin server's v2 JSON API, for users
+// running Zipkin without an OTLP-capable collector in front of it.
+// zipkin:// endpoints use plain HTTP and zipkins:// use HTTPS.
+type ZipkinClient struct {
+	client *http.Client
+	config OTLPConfig
+}
+
+// zipkinSpan is a span in the Zipkin v2 JSON model.
+// https://zipkin.io/zipkin-api/#/default/post_spans
+type zipkinSpan struct {
+	TraceId       string             `json:"traceId"`
+	Id            string             `json:"id"`
+	ParentId      string             `json:"parentId,omitempty"`
+	Name          string             `json:"name,omitempty"`
+	Kind          string             `json:"kind,omitempty"`
+	Timestamp     uint64             `json:"timestamp,omitempty"`
+	Duration      uint64             `json:"duration,omitempty"`
+	LocalEndpoint *zipkinEndpoint    `json:"localEndpoint,omitempty"`
+	Annotations   []zipkinAnnotation `json:"annotations,omitempty"`
+	Tags          map[string]string  `json:"tags,omitempty"`
+}
+
+// zipkinEndpoint is the Zipkin v2 endpoint model, only the service name is used.
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// zipkinAnnotation is a timestamped event on a Zipkin span.
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// NewZipkinClient returns an initialized ZipkinClient.
+func NewZipkinClient(config OTLPConfig) *ZipkinClient {
+	return &ZipkinClient{config: config}
+}
+
+// Start sets up the HTTP client with the proxy, TLS, and timeout settings.
+func (zc *ZipkinClient) Start(ctx context.Context) (context.Context, error) {
+	transport, err := NewHttpTransport(zc.config)
+	if err != nil {
+		return ctx, err
+	}
+
+	zc.client = &http.Client{
+		Timeout:   zc.config.GetSendTimeout(),
+		Transport: transport,
+	}
+	return ctx, nil
+}
+
+// UploadTraces converts the spans to Zipkin v2 JSON and POSTs them to the
+// server, /api/v2/spans unless the endpoint has a path.
+func (zc *ZipkinClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	payload, err := json.Marshal(ResourceSpansToZipkin(rsps))
+	if err != nil {
+		return ctx, fmt.Errorf("failed to marshal zipkin spans: %w", err)
+	}
+
+	if zc.config.GetCompression() == "gzip" {
+		payload, err = gzipBytes(payload)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to gzip zipkin spans: %w", err)
+		}
+	}
+
+	endpointURL := ZipkinURL(zc.config.GetEndpoint())
+	headers := zc.config.GetHeaders()
+	userAgent := zc.config.GetUserAgent()
+	compression := zc.config.GetCompression()
+
+	return retry(ctx, zc.config, func(context.Context) (context.Context, bool, time.Duration, error) {
+		// the body is consumed on each attempt so the request is built every time
+		req, err := http.NewRequestWithContext(ctx, "POST", endpointURL.String(), bytes.NewReader(payload))
+		if err != nil {
+			return ctx, false, 0, fmt.Errorf("failed to create HTTP POST request: %w", err)
+		}
+		for k, v := range headers {
+			req.Header.Add(k, v)
+		}
+		if req.Header.Get("User-Agent") == "" {
+			req.Header.Set("User-Agent", userAgent)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if compression == "gzip" {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		resp, err := zc.client.Do(req)
+		if err != nil {
+			return ctx, false, 0, err
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return ctx, false, 0, nil
+		} else if resp.StatusCode == 429 || resp.StatusCode == 502 || resp.StatusCode == 503 || resp.StatusCode == 504 {
+			wait := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			return ctx, true, wait, httpStatusError{server: "zipkin server", code: resp.StatusCode}
+		}
+
+		return ctx, false, 0, fmt.Errorf("zipkin server returned unretriable code %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	})
+}
+
+// Stop does nothing for Zipkin. It exists to fulfill the interface.
+func (zc *ZipkinClient) Stop(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+// ZipkinURL converts a zipkin:// or zipkins:// endpoint into the http:// or
+// https:// URL spans are POSTed to.
+func ZipkinURL(endpoint *url.URL) *url.URL {
+	out := *endpoint
+	out.Scheme = "http"
+	if endpoint.Scheme == "zipkins" {
+		out.Scheme = "https"
+	}
+	if out.Path == "" || out.Path == "/" {
+		out.Path = "/api/v2/spans"
+	}
+	return &out
+}
+
+// ResourceSpansToZipkin converts OTLP spans to the Zipkin v2 model following
+// the OTel spec's Zipkin mapping: service.name becomes the local endpoint,
+// other resource and span attributes become tags, events become annotations,
+// and error status is reported with the otel.status_code and error tags.
+// https://opentelemetry.io/docs/specs/otel/trace/sdk_exporters/zipkin/
+func ResourceSpansToZipkin(rsps []*tracepb.ResourceSpans) []zipkinSpan {
+	out := []zipkinSpan{}
+	for _, rs := range rsps {
+		var serviceName string
+		resourceTags := map[string]string{}
+		for _, attr := range rs.GetResource().GetAttributes() {
+			if attr.Key == "service.name" {
+				serviceName = anyValueToString(attr.Value)
+			} else {
+				resourceTags[attr.Key] = anyValueToString(attr.Value)
+			}
+		}
+
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				zs := zipkinSpan{
+					TraceId:       hex.EncodeToString(span.TraceId),
+					Id:            hex.EncodeToString(span.SpanId),
+					Name:          span.Name,
+					Kind:          zipkinKind(span.Kind),
+					Timestamp:     span.StartTimeUnixNano / 1000,
+					Duration:      zipkinDuration(span),
+					LocalEndpoint: &zipkinEndpoint{ServiceName: serviceName},
+					Tags:          map[string]string{},
+				}
+				if len(span.ParentSpanId) > 0 && !bytes.Equal(span.ParentSpanId, GetEmptySpanId()) {
+					zs.ParentId = hex.EncodeToString(span.ParentSpanId)
+				}
+
+				for k, v := range resourceTags {
+					zs.Tags[k] = v
+				}
+				for _, attr := range span.Attributes {
+					zs.Tags[attr.Key] = anyValueToString(attr.Value)
+				}
+				if ss.Scope != nil && ss.Scope.Name != "" {
+					zs.Tags["otel.scope.name"] = ss.Scope.Name
+					zs.Tags["otel.scope.version"] = ss.Scope.Version
+				}
+
+				switch span.Status.GetCode() {
+				case tracepb.Status_STATUS_CODE_OK:
+					zs.Tags["otel.status_code"] = "OK"
+				case tracepb.Status_STATUS_CODE_ERROR:
+					zs.Tags["otel.status_code"] = "ERROR"
+					zs.Tags["error"] = span.Status.GetMessage()
+				}
+
+				for _, event := range span.Events {
+					zs.Annotations = append(zs.Annotations, zipkinAnnotation{
+						Timestamp: event.TimeUnixNano / 1000,
+						Value:     zipkinEventValue(event),
+					})
+				}
+
+				out = append(out, zs)
+			}
+		}
+	}
+
+	return out
+}
+
+// zipkinDuration returns the span's duration in microseconds, or 0 when it
+// has no end time or ends before it starts, which would otherwise underflow.
+func zipkinDuration(span *tracepb.Span) uint64 {
+	if span.EndTimeUnixNano <= span.StartTimeUnixNano {
+		return 0
+	}
+	return (span.EndTimeUnixNano - span.StartTimeUnixNano) / 1000
+}
+
+// zipkinKind maps OTel span kinds to Zipkin's. Internal and unspecified spans
+// have no kind in Zipkin.
+func zipkinKind(kind tracepb.Span_SpanKind) string {
+	switch kind {
+	case tracepb.Span_SPAN_KIND_CLIENT:
+		return "CLIENT"
+	case tracepb.Span_SPAN_KIND_SERVER:
+		return "SERVER"
+	case tracepb.Span_SPAN_KIND_PRODUCER:
+		return "PRODUCER"
+	case tracepb.Span_SPAN_KIND_CONSUMER:
+		return "CONSUMER"
+	default:
+		return ""
+	}
+}
+
+// zipkinEventValue returns the annotation value for an event, which is the
+// event name, followed by its attributes as JSON when it has any.
+func zipkinEventValue(event *tracepb.Span_Event) string {
+	if len(event.Attributes) == 0 {
+		return event.Name
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range event.Attributes {
+		attrs[attr.Key] = anyValueToString(attr.Value)
+	}
+	js, _ := json.Marshal(attrs) // a map[string]string always marshals
+
+	return fmt.Sprintf("%s %s", event.Name, js)
+}
+
+// anyValueToString converts a scalar attribute value to a string, for
+// formats like Zipkin that only support string tags.
+func anyValueToString(v *commonpb.AnyValue) string {
+	switch val := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return val.StringValue
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(val.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(val.DoubleValue, 'f', -1, 64)
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(val.BoolValue)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,151 @@
+package otlpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// zipkinTestConfig is unixTestConfig with a header to check for.
+type zipkinTestConfig struct {
+	unixTestConfig
+}
+
+func (c zipkinTestConfig) GetHeaders() map[string]string {
+	return map[string]string{"x-test": "zipkin"}
+}
+
+func TestZipkinURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"zipkin://localhost:9411":              "http://localhost:9411/api/v2/spans",
+		"zipkins://zipkin.example.com":         "https://zipkin.example.com/api/v2/spans",
+		"zipkin://localhost:9411/custom/spans": "http://localhost:9411/custom/spans",
+	} {
+		u, _ := url.Parse(in)
+		if got := ZipkinURL(u).String(); got != want {
+			t.Errorf("expected %q for %q but got %q", want, in, got)
+		}
+	}
+}
+
+func zipkinTestSpans() []*tracepb.ResourceSpans {
+	span := NewProtobufSpan()
+	span.TraceId = []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	span.SpanId = []byte{0xbe, 0xef, 0xca, 0xfe, 0xfa, 0xce, 0xde, 0xad}
+	span.ParentSpanId = []byte{0xee, 0xee, 0xee, 0xb3, 0x3f, 0xc4, 0xf3, 0xd3}
+	span.Name = "zipkin test"
+	span.Kind = tracepb.Span_SPAN_KIND_SERVER
+	span.StartTimeUnixNano = 1700000000000000000
+	span.EndTimeUnixNano = 1700000000250000000
+	span.Attributes = StringMapAttrsToProtobuf(map[string]string{"retries": "3"})
+	span.Events = []*tracepb.Span_Event{
+		{Name: "started", TimeUnixNano: 1700000000100000000},
+		{Name: "done", TimeUnixNano: 1700000000200000000, Attributes: StringMapAttrsToProtobuf(map[string]string{"ok": "true"})},
+	}
+	SetSpanStatus(span, "error", "it broke")
+
+	return []*tracepb.ResourceSpans{{
+		Resource: &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{
+				{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "cron"}}},
+				{Key: "host.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "box"}}},
+			},
+		},
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Scope: &commonpb.InstrumentationScope{Name: "github.com/equinix-labs/otel-cli", Version: "1.0"},
+			Spans: []*tracepb.Span{span},
+		}},
+	}}
+}
+
+func TestResourceSpansToZipkin(t *testing.T) {
+	want := []zipkinSpan{{
+		TraceId:       "00112233445566778899aabbccddeeff",
+		Id:            "beefcafefacedead",
+		ParentId:      "eeeeeeb33fc4f3d3",
+		Name:          "zipkin test",
+		Kind:          "SERVER",
+		Timestamp:     1700000000000000,
+		Duration:      250000,
+		LocalEndpoint: &zipkinEndpoint{ServiceName: "cron"},
+		Annotations: []zipkinAnnotation{
+			{Timestamp: 1700000000100000, Value: "started"},
+			{Timestamp: 1700000000200000, Value: `done {"ok":"true"}`},
+		},
+		Tags: map[string]string{
+			"host.name":          "box",
+			"retries":            "3",
+			"otel.scope.name":    "github.com/equinix-labs/otel-cli",
+			"otel.scope.version": "1.0",
+			"otel.status_code":   "ERROR",
+			"error":              "it broke",
+		},
+	}}
+
+	if diff := cmp.Diff(want, ResourceSpansToZipkin(zipkinTestSpans())); diff != "" {
+		t.Errorf("zipkin spans did not match (-want +got):\n%s", diff)
+	}
+}
+
+func TestZipkinDuration(t *testing.T) {
+	for _, tc := range []struct {
+		start, end uint64
+		want       uint64
+	}{
+		{start: 1700000000000000000, end: 1700000000250000000, want: 250000},
+		{start: 1700000000250000000, end: 1700000000000000000, want: 0},
+		{start: 1700000000000000000, end: 0, want: 0},
+	} {
+		span := &tracepb.Span{StartTimeUnixNano: tc.start, EndTimeUnixNano: tc.end}
+		if got := zipkinDuration(span); got != tc.want {
+			t.Errorf("expected duration %d for start %d and end %d but got %d", tc.want, tc.start, tc.end, got)
+		}
+	}
+}
+
+func TestZipkinClient(t *testing.T) {
+	var gotPath, gotHeader string
+	var gotSpans []zipkinSpan
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		gotHeader = req.Header.Get("x-test")
+		if err := json.NewDecoder(req.Body).Decode(&gotSpans); err != nil {
+			t.Errorf("failed to decode zipkin spans: %s", err)
+		}
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	config := zipkinTestConfig{unixTestConfig{endpoint: strings.Replace(server.URL, "http://", "zipkin://", 1)}}
+	client := NewZipkinClient(config)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+	if _, err := client.UploadTraces(ctx, zipkinTestSpans()); err != nil {
+		t.Fatalf("failed to upload spans: %s", err)
+	}
+
+	if gotPath != "/api/v2/spans" {
+		t.Errorf("expected spans to be posted to /api/v2/spans but got %q", gotPath)
+	}
+	if gotHeader != "zipkin" {
+		t.Errorf("expected headers to be sent but got %q", gotHeader)
+	}
+	if len(gotSpans) != 1 || gotSpans[0].Name != "zipkin test" {
+		t.Errorf("expected the span to be received but got %+v", gotSpans)
+	}
+}
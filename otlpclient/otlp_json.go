@@ -0,0 +1,106 @@
+package otlpclient
+
+// Implements the OTLP/JSON encoding. protojson gets most of the way there,
+// but the OTLP spec requires trace and span ids to be hex-encoded instead of
+// protojson's base64 for bytes fields, and enums must be integers.
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/docs/specification.md#json-protobuf-encoding
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpJsonIdKeys are the JSON keys holding ids that are hex in OTLP/JSON.
+var otlpJsonIdKeys = map[string]bool{
+	"traceId":      true,
+	"spanId":       true,
+	"parentSpanId": true,
+}
+
+// MarshalOtlpJson encodes the protobuf message to OTLP/JSON.
+func MarshalOtlpJson(msg proto.Message) ([]byte, error) {
+	opts := protojson.MarshalOptions{UseEnumNumbers: true}
+	js, err := opts.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf to json: %w", err)
+	}
+
+	return convertOtlpJsonIds(js, base64ToHex)
+}
+
+// UnmarshalOtlpJson decodes OTLP/JSON into the protobuf message.
+func UnmarshalOtlpJson(js []byte, msg proto.Message) error {
+	js, err := convertOtlpJsonIds(js, hexToBase64)
+	if err != nil {
+		return err
+	}
+
+	opts := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err := opts.Unmarshal(js, msg); err != nil {
+		return fmt.Errorf("failed to unmarshal json to protobuf: %w", err)
+	}
+
+	return nil
+}
+
+// convertOtlpJsonIds walks the json document and rewrites the values of
+// all id fields with the provided conversion function.
+func convertOtlpJsonIds(js []byte, convert func(string) (string, error)) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(js, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+
+	if err := walkOtlpJsonIds(doc, convert); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(doc)
+}
+
+// walkOtlpJsonIds recursively converts id fields in place.
+func walkOtlpJsonIds(node interface{}, convert func(string) (string, error)) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			if s, ok := v.(string); ok && otlpJsonIdKeys[k] {
+				converted, err := convert(s)
+				if err != nil {
+					return fmt.Errorf("invalid value %q for %s: %w", s, k, err)
+				}
+				n[k] = converted
+			} else if err := walkOtlpJsonIds(v, convert); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if err := walkOtlpJsonIds(v, convert); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func base64ToHex(in string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(in)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+func hexToBase64(in string) (string, error) {
+	data, err := hex.DecodeString(in)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,58 @@
+package otlpclient
+
+import (
+	"strings"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOtlpJsonRoundTrip(t *testing.T) {
+	span := NewProtobufSpan()
+	span.TraceId = []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	span.SpanId = []byte{0xbe, 0xef, 0xca, 0xfe, 0xfa, 0xce, 0xde, 0xad}
+	span.ParentSpanId = []byte{0xe4, 0xe3, 0xee, 0xb3, 0x3f, 0xc4, 0xf3, 0xd3}
+	span.Name = "json test"
+
+	req := coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{{
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+		}},
+	}
+
+	js, err := MarshalOtlpJson(&req)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+
+	// OTLP/JSON requires hex ids and integer enums
+	for _, want := range []string{
+		`"traceId":"00112233445566778899aabbccddeeff"`,
+		`"spanId":"beefcafefacedead"`,
+		`"parentSpanId":"e4e3eeb33fc4f3d3"`,
+		`"kind":3`,
+	} {
+		if !strings.Contains(string(js), want) {
+			t.Errorf("expected %s in OTLP/JSON output: %s", want, js)
+		}
+	}
+
+	got := coltracepb.ExportTraceServiceRequest{}
+	if err := UnmarshalOtlpJson(js, &got); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+
+	if !proto.Equal(&req, &got) {
+		t.Errorf("round trip through OTLP/JSON did not match, got: %s", got.String())
+	}
+}
+
+func TestUnmarshalOtlpJsonBadId(t *testing.T) {
+	js := []byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"not hex"}]}]}]}`)
+	err := UnmarshalOtlpJson(js, &coltracepb.ExportTraceServiceRequest{})
+	if err == nil {
+		t.Error("expected an error for an invalid hex trace id")
+	}
+}
@@ -0,0 +1,42 @@
+package otlpclient
+
+import (
+	"context"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// OTLPLogsClient is implemented by the clients that can also send log
+// records, which are the same ones that can send metrics.
+type OTLPLogsClient interface {
+	OTLPClient
+	UploadLogs(context.Context, []*logspb.ResourceLogs) (context.Context, error)
+}
+
+// SendLogs sends the log records with the same resource and scope that
+// SendSpan puts on spans.
+func SendLogs(ctx context.Context, client OTLPLogsClient, config OTLPConfig, records []*logspb.LogRecord) (context.Context, error) {
+	if !config.GetIsRecording() || len(records) == 0 {
+		return ctx, nil
+	}
+
+	resourceAttrs, err := resourceAttributes(ctx, config)
+	if err != nil {
+		return ctx, err
+	}
+
+	rls := []*logspb.ResourceLogs{{
+		Resource: &resourcepb.Resource{Attributes: resourceAttrs},
+		ScopeLogs: []*logspb.ScopeLogs{{
+			Scope: &commonpb.InstrumentationScope{
+				Name:    "github.com/equinix-labs/otel-cli",
+				Version: config.GetVersion(),
+			},
+			LogRecords: records,
+		}},
+	}}
+
+	return client.UploadLogs(ctx, rls)
+}
@@ -0,0 +1,70 @@
+package otlpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHttpClientUploadLogs(t *testing.T) {
+	var gotPath string
+	var gotReq collogspb.ExportLogsServiceRequest
+	rejected := int64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		body, _ := io.ReadAll(req.Body)
+		if err := proto.Unmarshal(body, &gotReq); err != nil {
+			t.Errorf("failed to unmarshal logs request: %s", err)
+		}
+
+		resp := collogspb.ExportLogsServiceResponse{}
+		if rejected > 0 {
+			resp.PartialSuccess = &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: rejected}
+		}
+		js, _ := proto.Marshal(&resp)
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+		rw.Write(js)
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(unixTestConfig{endpoint: server.URL + "/v1/logs"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+
+	rls := []*logspb.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{
+			LogRecords: []*logspb.LogRecord{{
+				SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+				Body:           &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "disk full"}},
+			}},
+		}},
+	}}
+
+	if _, err := client.UploadLogs(ctx, rls); err != nil {
+		t.Fatalf("failed to upload logs: %s", err)
+	}
+	if gotPath != "/v1/logs" {
+		t.Errorf("expected path /v1/logs but got %q", gotPath)
+	}
+	if got := gotReq.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Body.GetStringValue(); got != "disk full" {
+		t.Errorf("expected the log record to be sent but got %q", got)
+	}
+
+	rejected = 2
+	_, err = client.UploadLogs(ctx, rls)
+	if err == nil || err.Error() != "partial success. 2 log records were rejected" {
+		t.Errorf("expected a partial success error but got %v", err)
+	}
+}
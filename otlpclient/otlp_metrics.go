@@ -0,0 +1,63 @@
+package otlpclient
+
+import (
+	"context"
+	"fmt"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// OTLPMetricsClient is implemented by the clients that can also send
+// metrics. Clients for endpoints that only make sense for spans, like
+// Zipkin, don't implement it.
+type OTLPMetricsClient interface {
+	OTLPClient
+	UploadMetrics(context.Context, []*metricspb.ResourceMetrics) (context.Context, error)
+}
+
+// SendMetric sends a single metric with the same resource and scope that
+// SendSpan puts on spans.
+func SendMetric(ctx context.Context, client OTLPMetricsClient, config OTLPConfig, metric *metricspb.Metric) (context.Context, error) {
+	if !config.GetIsRecording() {
+		return ctx, nil
+	}
+
+	resourceAttrs, err := resourceAttributes(ctx, config)
+	if err != nil {
+		return ctx, err
+	}
+
+	rms := []*metricspb.ResourceMetrics{{
+		Resource: &resourcepb.Resource{Attributes: resourceAttrs},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope: &commonpb.InstrumentationScope{
+				Name:    "github.com/equinix-labs/otel-cli",
+				Version: config.GetVersion(),
+			},
+			Metrics: []*metricspb.Metric{metric},
+		}},
+	}}
+
+	return client.UploadMetrics(ctx, rms)
+}
+
+// rejectedCount returns the number of items the server rejected in a
+// partial success export response and what they are, e.g. "spans".
+func rejectedCount(resp proto.Message) (int64, string) {
+	switch r := resp.(type) {
+	case *coltracepb.ExportTraceServiceResponse:
+		return r.GetPartialSuccess().GetRejectedSpans(), "spans"
+	case *colmetricspb.ExportMetricsServiceResponse:
+		return r.GetPartialSuccess().GetRejectedDataPoints(), "data points"
+	case *collogspb.ExportLogsServiceResponse:
+		return r.GetPartialSuccess().GetRejectedLogRecords(), "log records"
+	default:
+		panic(fmt.Sprintf("BUG: unknown export response type %T, please report an issue", resp))
+	}
+}
@@ -0,0 +1,71 @@
+package otlpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHttpClientUploadMetrics(t *testing.T) {
+	var gotPath string
+	var gotReq colmetricspb.ExportMetricsServiceRequest
+	rejected := int64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		body, _ := io.ReadAll(req.Body)
+		if err := proto.Unmarshal(body, &gotReq); err != nil {
+			t.Errorf("failed to unmarshal metrics request: %s", err)
+		}
+
+		resp := colmetricspb.ExportMetricsServiceResponse{}
+		if rejected > 0 {
+			resp.PartialSuccess = &colmetricspb.ExportMetricsPartialSuccess{RejectedDataPoints: rejected}
+		}
+		js, _ := proto.Marshal(&resp)
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+		rw.Write(js)
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(unixTestConfig{endpoint: server.URL + "/v1/metrics"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to start client: %s", err)
+	}
+
+	rms := []*metricspb.ResourceMetrics{{
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Metrics: []*metricspb.Metric{{
+				Name: "queue.depth",
+				Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+					DataPoints: []*metricspb.NumberDataPoint{{Value: &metricspb.NumberDataPoint_AsInt{AsInt: 42}}},
+				}},
+			}},
+		}},
+	}}
+
+	if _, err := client.UploadMetrics(ctx, rms); err != nil {
+		t.Fatalf("failed to upload metrics: %s", err)
+	}
+	if gotPath != "/v1/metrics" {
+		t.Errorf("expected path /v1/metrics but got %q", gotPath)
+	}
+	if got := gotReq.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Name; got != "queue.depth" {
+		t.Errorf("expected the metric to be sent but got %q", got)
+	}
+
+	rejected = 1
+	_, err = client.UploadMetrics(ctx, rms)
+	if err == nil || err.Error() != "partial success. 1 data points were rejected" {
+		t.Errorf("expected a partial success error but got %v", err)
+	}
+}
@@ -202,7 +202,7 @@ func ResourceAttributesToStringMap(rss *tracepb.ResourceSpans) map[string]string
 	}
 
 	out := make(map[string]string)
-	for _, attr := range rss.Resource.Attributes {
+	for _, attr := range rss.GetResource().GetAttributes() {
 		out[attr.Key] = AttrValueToString(attr)
 	}
 	return out
@@ -219,6 +219,8 @@ func AttrValueToString(attr *commonpb.KeyValue) string {
 		return strconv.FormatInt(v.GetIntValue(), 10)
 	} else if _, ok := v.Value.(*commonpb.AnyValue_DoubleValue); ok {
 		return strconv.FormatFloat(v.GetDoubleValue(), byte('f'), -1, 64)
+	} else if _, ok := v.Value.(*commonpb.AnyValue_BoolValue); ok {
+		return strconv.FormatBool(v.GetBoolValue())
 	}
 
 	return ""
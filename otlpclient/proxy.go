@@ -0,0 +1,136 @@
+package otlpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// parseProxyURL parses and validates a --proxy value. Supported schemes are
+// http, https, and socks5. Returns nil without error when no proxy is set.
+func parseProxyURL(proxyStr string) (*url.URL, error) {
+	if proxyStr == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxyStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy URL %q: %w", proxyStr, err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return proxyURL, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, must be one of http, https, or socks5", proxyURL.Scheme)
+	}
+}
+
+// httpProxyFunc returns the Proxy function for an http.Transport. When no
+// proxy is configured, the standard HTTPS_PROXY/HTTP_PROXY/NO_PROXY
+// environment variables are honored.
+func httpProxyFunc(proxyURL *url.URL) func(*http.Request) (*url.URL, error) {
+	if proxyURL == nil {
+		return http.ProxyFromEnvironment
+	}
+
+	// net/http supports http, https, and socks5 proxy URLs natively
+	return http.ProxyURL(proxyURL)
+}
+
+// grpcProxyDialer returns a dialer for grpc.WithContextDialer that connects
+// to addr through the proxy. SOCKS5 uses x/net/proxy and http(s) proxies use
+// an HTTP CONNECT tunnel. tlsConfig is used to connect to https proxies.
+func grpcProxyDialer(proxyURL *url.URL, tlsConfig *tls.Config) func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		if proxyURL.Scheme == "socks5" {
+			dialer, err := proxy.FromURL(proxyURL, &net.Dialer{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up socks5 proxy: %w", err)
+			}
+			if cd, ok := dialer.(proxy.ContextDialer); ok {
+				return cd.DialContext(ctx, "tcp", addr)
+			}
+			return dialer.Dial("tcp", addr)
+		}
+
+		return dialHttpConnect(ctx, proxyURL, tlsConfig, addr)
+	}
+}
+
+// dialHttpConnect opens a tunnel to addr through an HTTP proxy using the
+// CONNECT method and returns the raw connection. https proxies are connected
+// to with TLS using tlsConfig, so the CONNECT and its Proxy-Authorization
+// aren't sent in the clear.
+func dialHttpConnect(ctx context.Context, proxyURL *url.URL, tlsConfig *tls.Config, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		if proxyURL.Scheme == "https" {
+			proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "443")
+		} else {
+			proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+		}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyAddr, err)
+	}
+
+	if proxyURL.Scheme == "https" {
+		// the proxy's certificate is for its own name, and the tunnel inside
+		// carries the endpoint's own TLS and ALPN
+		proxyTls := &tls.Config{}
+		if tlsConfig != nil {
+			proxyTls = tlsConfig.Clone()
+		}
+		proxyTls.ServerName = proxyURL.Hostname()
+		proxyTls.NextProtos = nil
+
+		tlsConn := tls.Client(conn, proxyTls)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed TLS handshake with proxy %s: %w", proxyAddr, err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		pass, _ := proxyURL.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy %s: %w", proxyAddr, err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response from proxy %s: %w", proxyAddr, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", proxyAddr, addr, resp.Status)
+	}
+
+	return conn, nil
+}
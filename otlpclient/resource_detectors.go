@@ -0,0 +1,91 @@
+package otlpclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// ResourceDetectors are the names accepted by --resource-detectors.
+var ResourceDetectors = []string{"host", "os", "container", "k8s"}
+
+// k8sNamespaceFile is where Kubernetes mounts the pod's namespace along
+// with the service account token.
+const k8sNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// resourceDetectorOptions returns the SDK resource options for the named
+// detectors.
+func resourceDetectorOptions(names []string) ([]resource.Option, error) {
+	opts := []resource.Option{}
+	for _, name := range names {
+		switch name {
+		case "host":
+			opts = append(opts, resource.WithHost())
+		case "os":
+			opts = append(opts, resource.WithOS())
+		case "container":
+			opts = append(opts, resource.WithContainer())
+		case "k8s":
+			opts = append(opts, resource.WithDetectors(k8sDetector{getenv: os.Getenv, namespaceFile: k8sNamespaceFile}))
+		default:
+			return nil, fmt.Errorf("unknown resource detector %q, must be one of %s", name, strings.Join(ResourceDetectors, ", "))
+		}
+	}
+	return opts, nil
+}
+
+// k8sDetector detects the pod otel-cli is running in. The SDKs leave this
+// to the downward API, so the pod name, namespace, node, and uid are read
+// from the environment variables it's usually mapped to, falling back to
+// HOSTNAME for the pod name and the service account mount for the
+// namespace.
+type k8sDetector struct {
+	getenv        func(string) string
+	namespaceFile string
+}
+
+// Detect implements resource.Detector.
+func (d k8sDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	// set in every container Kubernetes runs
+	if d.getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return resource.Empty(), nil
+	}
+
+	first := func(names ...string) string {
+		for _, name := range names {
+			if value := d.getenv(name); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	namespace := first("K8S_NAMESPACE_NAME", "POD_NAMESPACE")
+	if namespace == "" {
+		if data, err := os.ReadFile(d.namespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	attrs := []attribute.KeyValue{}
+	for _, kv := range []struct {
+		key   attribute.Key
+		value string
+	}{
+		{semconv.K8SPodNameKey, first("K8S_POD_NAME", "POD_NAME", "HOSTNAME")},
+		{semconv.K8SPodUIDKey, first("K8S_POD_UID", "POD_UID")},
+		{semconv.K8SNamespaceNameKey, namespace},
+		{semconv.K8SNodeNameKey, first("K8S_NODE_NAME", "NODE_NAME")},
+	} {
+		if kv.value != "" {
+			attrs = append(attrs, kv.key.String(kv.value))
+		}
+	}
+
+	return resource.NewSchemaless(attrs...), nil
+}
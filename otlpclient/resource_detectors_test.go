@@ -0,0 +1,118 @@
+package otlpclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// resourceTestConfig overrides the settings resourceAttributes uses and
+// leaves the rest of the interface unimplemented.
+type resourceTestConfig struct {
+	OTLPConfig
+	detectors []string
+	attrs     map[string]string
+}
+
+func (c resourceTestConfig) GetServiceName() string                   { return "test-service" }
+func (c resourceTestConfig) GetResourceDetectors() []string           { return c.detectors }
+func (c resourceTestConfig) GetResourceAttributes() map[string]string { return c.attrs }
+
+func TestResourceAttributesDetectors(t *testing.T) {
+	attrs, err := resourceAttributes(context.Background(), resourceTestConfig{detectors: []string{"host", "os", "container"}})
+	if err != nil {
+		t.Fatalf("failed to get resource attributes: %s", err)
+	}
+	got := ResourceAttributesToStringMap(&tracepb.ResourceSpans{Resource: &resourcepb.Resource{Attributes: attrs}})
+
+	hostname, _ := os.Hostname()
+	if got["host.name"] != hostname || got["os.type"] == "" || got["service.name"] != "test-service" {
+		t.Errorf("expected host.name, os.type, and service.name but got %q", got)
+	}
+
+	if _, err := resourceAttributes(context.Background(), resourceTestConfig{detectors: []string{"aws"}}); err == nil {
+		t.Error("expected an unknown detector to fail")
+	}
+}
+
+func TestResourceAttributesPrecedence(t *testing.T) {
+	config := resourceTestConfig{
+		detectors: []string{"host"},
+		attrs:     map[string]string{"host.name": "build-7", "service.name": "ignored", "team": "infra"},
+	}
+	attrs, err := resourceAttributes(context.Background(), config)
+	if err != nil {
+		t.Fatalf("failed to get resource attributes: %s", err)
+	}
+	got := ResourceAttributesToStringMap(&tracepb.ResourceSpans{Resource: &resourcepb.Resource{Attributes: attrs}})
+
+	want := map[string]string{"host.name": "build-7", "service.name": "test-service", "team": "infra"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("resource attributes did not match (-want +got):\n%s", diff)
+	}
+}
+
+func TestK8sDetector(t *testing.T) {
+	namespaceFile := filepath.Join(t.TempDir(), "namespace")
+	os.WriteFile(namespaceFile, []byte("payments\n"), 0644)
+
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "not in kubernetes",
+			env:  map[string]string{"HOSTNAME": "laptop", "POD_NAME": "nope"},
+			want: map[string]string{},
+		},
+		{
+			name: "bare pod",
+			env:  map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "HOSTNAME": "checkout-7d9f-x2x"},
+			want: map[string]string{"k8s.pod.name": "checkout-7d9f-x2x", "k8s.namespace.name": "payments"},
+		},
+		{
+			name: "downward api",
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+				"HOSTNAME":                "ignored",
+				"K8S_POD_NAME":            "checkout-7d9f-x2x",
+				"POD_UID":                 "0b9c-44",
+				"POD_NAMESPACE":           "shop",
+				"NODE_NAME":               "node-3",
+			},
+			want: map[string]string{
+				"k8s.pod.name":       "checkout-7d9f-x2x",
+				"k8s.pod.uid":        "0b9c-44",
+				"k8s.namespace.name": "shop",
+				"k8s.node.name":      "node-3",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			detector := k8sDetector{
+				getenv:        func(name string) string { return tc.env[name] },
+				namespaceFile: namespaceFile,
+			}
+			res, err := detector.Detect(context.Background())
+			if err != nil {
+				t.Fatalf("detect failed: %s", err)
+			}
+			got := map[string]string{}
+			for _, kv := range res.Attributes() {
+				if kv.Value.Type() == attribute.STRING {
+					got[string(kv.Key)] = kv.Value.AsString()
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("k8s attributes did not match (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package otlpclient
+
+import (
+	"unicode/utf8"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// truncationMarker replaces the end of attribute values that are cut to fit
+// the length limit, so it's obvious in a backend that the value is partial.
+const truncationMarker = "..."
+
+// SpanLimits holds the OTel SDK-style span limits. A negative limit means
+// no limit.
+type SpanLimits struct {
+	AttributeValueLengthLimit int // in characters, for string values
+	AttributeCountLimit       int // per span, event, and link
+	EventCountLimit           int
+	LinkCountLimit            int
+}
+
+// LimitSpan applies the limits to the span in place before export, so spans
+// with huge or numerous attributes and events don't get rejected by the
+// collector. Like the SDKs, the first attributes and links are kept and the
+// oldest events are dropped, and the Dropped*Count fields say how many went.
+func LimitSpan(span *tracepb.Span, limits SpanLimits) {
+	var dropped int
+	span.Attributes, dropped = limitAttributes(span.Attributes, limits)
+	span.DroppedAttributesCount += uint32(dropped)
+
+	if limits.EventCountLimit >= 0 && len(span.Events) > limits.EventCountLimit {
+		dropped := len(span.Events) - limits.EventCountLimit
+		span.Events = span.Events[dropped:]
+		span.DroppedEventsCount += uint32(dropped)
+	}
+	for _, event := range span.Events {
+		event.Attributes, dropped = limitAttributes(event.Attributes, limits)
+		event.DroppedAttributesCount += uint32(dropped)
+	}
+
+	if limits.LinkCountLimit >= 0 && len(span.Links) > limits.LinkCountLimit {
+		span.DroppedLinksCount += uint32(len(span.Links) - limits.LinkCountLimit)
+		span.Links = span.Links[:limits.LinkCountLimit]
+	}
+	for _, link := range span.Links {
+		link.Attributes, dropped = limitAttributes(link.Attributes, limits)
+		link.DroppedAttributesCount += uint32(dropped)
+	}
+}
+
+// limitAttributes returns the attributes cut down to the count limit with
+// string values truncated, and how many attributes were dropped.
+func limitAttributes(attrs []*commonpb.KeyValue, limits SpanLimits) ([]*commonpb.KeyValue, int) {
+	var dropped int
+	if limits.AttributeCountLimit >= 0 && len(attrs) > limits.AttributeCountLimit {
+		dropped = len(attrs) - limits.AttributeCountLimit
+		attrs = attrs[:limits.AttributeCountLimit]
+	}
+
+	if limits.AttributeValueLengthLimit >= 0 {
+		for _, attr := range attrs {
+			truncateAnyValue(attr.Value, limits.AttributeValueLengthLimit)
+		}
+	}
+
+	return attrs, dropped
+}
+
+// truncateAnyValue truncates string values and the strings in array values
+// in place to at most limit characters.
+func truncateAnyValue(value *commonpb.AnyValue, limit int) {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		v.StringValue = truncateString(v.StringValue, limit)
+	case *commonpb.AnyValue_ArrayValue:
+		for _, elem := range v.ArrayValue.GetValues() {
+			truncateAnyValue(elem, limit)
+		}
+	}
+}
+
+// truncateString returns the string cut to at most limit characters, ending
+// in the truncation marker when there's room for it. Strings that aren't
+// valid UTF-8 are cut by bytes.
+func truncateString(s string, limit int) string {
+	if !utf8.ValidString(s) {
+		if len(s) <= limit {
+			return s
+		}
+		return s[:limit]
+	}
+
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	keep := limit
+	marker := ""
+	if limit > len(truncationMarker) {
+		keep = limit - len(truncationMarker)
+		marker = truncationMarker
+	}
+
+	// find the byte offset of the keep'th character
+	var n, offset int
+	for offset = range s {
+		if n == keep {
+			break
+		}
+		n++
+	}
+	return s[:offset] + marker
+}
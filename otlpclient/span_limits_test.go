@@ -0,0 +1,82 @@
+package otlpclient
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestTruncateString(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 10, "this is..."},
+		{"abcdef", 3, "abc"},
+		{"abcdef", 0, ""},
+		{"ééééé", 4, "é..."},
+		{"ééééé", 5, "ééééé"},
+		{"\xff\xfe\xfd\xfc", 2, "\xff\xfe"},
+	} {
+		if got := truncateString(tc.in, tc.limit); got != tc.want {
+			t.Errorf("expected %q for %q with limit %d but got %q", tc.want, tc.in, tc.limit, got)
+		}
+	}
+}
+
+func TestLimitSpan(t *testing.T) {
+	attrs := func(n int) []*commonpb.KeyValue {
+		out := []*commonpb.KeyValue{}
+		for i := 0; i < n; i++ {
+			out = append(out, &commonpb.KeyValue{
+				Key:   "key" + strconv.Itoa(i),
+				Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: strings.Repeat("x", 20)}},
+			})
+		}
+		return out
+	}
+
+	span := NewProtobufSpan()
+	span.Attributes = attrs(5)
+	for i := 0; i < 4; i++ {
+		span.Events = append(span.Events, &tracepb.Span_Event{Name: "event" + strconv.Itoa(i), Attributes: attrs(3)})
+		span.Links = append(span.Links, &tracepb.Span_Link{Attributes: attrs(1)})
+	}
+
+	LimitSpan(span, SpanLimits{
+		AttributeValueLengthLimit: 10,
+		AttributeCountLimit:       2,
+		EventCountLimit:           3,
+		LinkCountLimit:            1,
+	})
+
+	if len(span.Attributes) != 2 || span.DroppedAttributesCount != 3 {
+		t.Errorf("expected 2 attributes and 3 dropped but got %d and %d", len(span.Attributes), span.DroppedAttributesCount)
+	}
+	if got := span.Attributes[0].Value.GetStringValue(); got != "xxxxxxx..." {
+		t.Errorf("expected a truncated attribute value but got %q", got)
+	}
+	if len(span.Events) != 3 || span.DroppedEventsCount != 1 || span.Events[0].Name != "event1" {
+		t.Errorf("expected the oldest event to be dropped but got %d events, %d dropped", len(span.Events), span.DroppedEventsCount)
+	}
+	if len(span.Events[2].Attributes) != 2 || span.Events[2].DroppedAttributesCount != 1 {
+		t.Errorf("expected event attributes to be limited but got %d", len(span.Events[2].Attributes))
+	}
+	if len(span.Links) != 1 || span.DroppedLinksCount != 3 {
+		t.Errorf("expected 1 link and 3 dropped but got %d and %d", len(span.Links), span.DroppedLinksCount)
+	}
+
+	// negative limits are no limit
+	span = NewProtobufSpan()
+	span.Attributes = attrs(200)
+	LimitSpan(span, SpanLimits{-1, -1, -1, -1})
+	if len(span.Attributes) != 200 || span.Attributes[0].Value.GetStringValue() != strings.Repeat("x", 20) {
+		t.Errorf("expected no limits to be applied")
+	}
+}
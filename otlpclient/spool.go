@@ -0,0 +1,231 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// spoolExt is the file extension for spooled requests. Files are named with
+// a nanosecond timestamp prefix so a sorted directory listing replays them
+// in the order they were spooled.
+const spoolExt = ".otlp"
+
+// spoolInflightExt is added to a spool file while it is being flushed so
+// concurrent flushes don't send the same request twice.
+const spoolInflightExt = ".inflight"
+
+// spoolInflightStale is how long a file can be in flight before ListSpool
+// takes it to be left behind by a flush that crashed or was killed and puts
+// it back to be flushed again. It's well past any sensible --timeout.
+const spoolInflightStale = 10 * time.Minute
+
+// spoolEncryptedMagic starts every encrypted spool file, followed by the
+// AES-GCM nonce and the sealed request. An unencrypted request is protobuf,
+// which can't start with these bytes.
+var spoolEncryptedMagic = []byte("OTELCLI-AES256GCM\n")
+
+// SpoolTraces serializes the resource spans as an OTLP ExportTraceServiceRequest
+// and writes it to a new file in the spool directory. When key is not nil,
+// the request is encrypted with AES-256-GCM. Returns the path of the file
+// written.
+func SpoolTraces(dir string, key []byte, rsps []*tracepb.ResourceSpans) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create spool directory '%s': %w", dir, err)
+	}
+
+	msg := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal spans for spooling: %w", err)
+	}
+	if key != nil {
+		data, err = encryptSpool(key, data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate spool file name: %w", err)
+	}
+	name := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), hex.EncodeToString(suffix), spoolExt)
+	file := filepath.Join(dir, name)
+
+	// write to a tempfile then rename so flush never sees a partial file
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write spool file '%s': %w", tmp, err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return "", fmt.Errorf("failed to rename spool file '%s': %w", tmp, err)
+	}
+
+	return file, nil
+}
+
+// ListSpool returns the paths of all spooled requests in dir, oldest first.
+// Files left in flight for longer than spoolInflightStale are renamed back
+// and included. A missing directory is not an error and returns an empty
+// list.
+func ListSpool(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory '%s': %w", dir, err)
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if strings.HasSuffix(entry.Name(), spoolExt) {
+			files = append(files, path)
+		} else if strings.HasSuffix(entry.Name(), spoolExt+spoolInflightExt) {
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < spoolInflightStale {
+				continue
+			}
+			// another flush may have recovered it already, which is fine
+			file := strings.TrimSuffix(path, spoolInflightExt)
+			if err := os.Rename(path, file); err == nil {
+				files = append(files, file)
+			}
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// LoadSpooled reads a spooled request from file and returns its resource spans.
+// Encrypted files are decrypted with key, and unencrypted files are read as-is
+// so turning on encryption doesn't strand spans that were already spooled.
+func LoadSpooled(file string, key []byte) ([]*tracepb.ResourceSpans, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool file '%s': %w", file, err)
+	}
+
+	if bytes.HasPrefix(data, spoolEncryptedMagic) {
+		if key == nil {
+			return nil, fmt.Errorf("spool file '%s' is encrypted and no spool key is set", file)
+		}
+		data, err = decryptSpool(key, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt spool file '%s': %w", file, err)
+		}
+	}
+
+	msg := coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spool file '%s': %w", file, err)
+	}
+
+	return msg.ResourceSpans, nil
+}
+
+// FlushSpoolFile uploads a single spooled request with the client and removes
+// it on success. While the upload is in progress the file is renamed so a
+// concurrent flush skips it, and it is put back if the upload fails. key
+// decrypts encrypted files, see LoadSpooled.
+func FlushSpoolFile(ctx context.Context, client OTLPClient, file string, key []byte) (context.Context, error) {
+	inflight := file + spoolInflightExt
+	if err := os.Rename(file, inflight); err != nil {
+		if os.IsNotExist(err) {
+			// another flush got to it first
+			return ctx, nil
+		}
+		return ctx, fmt.Errorf("failed to claim spool file '%s': %w", file, err)
+	}
+	// renaming keeps the mtime from when the file was spooled, so set it to
+	// now for ListSpool to tell a running flush from one that died
+	now := time.Now()
+	os.Chtimes(inflight, now, now)
+
+	rsps, err := LoadSpooled(inflight, key)
+	if err != nil {
+		os.Rename(inflight, file)
+		return ctx, err
+	}
+
+	ctx, err = client.UploadTraces(ctx, rsps)
+	if err != nil {
+		os.Rename(inflight, file)
+		return ctx, err
+	}
+
+	if err := os.Remove(inflight); err != nil {
+		return ctx, fmt.Errorf("failed to remove flushed spool file '%s': %w", inflight, err)
+	}
+
+	return ctx, nil
+}
+
+// encryptSpool seals data with AES-256-GCM and a random nonce. key must be
+// 32 bytes.
+func encryptSpool(key, data []byte) ([]byte, error) {
+	gcm, err := spoolCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate spool nonce: %w", err)
+	}
+
+	out := append([]byte{}, spoolEncryptedMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, spoolEncryptedMagic), nil
+}
+
+// decryptSpool opens data sealed by encryptSpool. A wrong key or a modified
+// file fails authentication.
+func decryptSpool(key, data []byte) ([]byte, error) {
+	gcm, err := spoolCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(spoolEncryptedMagic):]
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted spool data is truncated")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	out, err := gcm.Open(nil, nonce, sealed, spoolEncryptedMagic)
+	if err != nil {
+		return nil, fmt.Errorf("wrong spool key or corrupted file: %w", err)
+	}
+	return out, nil
+}
+
+// spoolCipher returns the AES-256-GCM AEAD for key.
+func spoolCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("spool key must be 32 bytes but got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
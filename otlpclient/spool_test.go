@@ -0,0 +1,151 @@
+package otlpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// failingClient is an OTLPClient whose uploads always fail.
+type failingClient struct{ NullClient }
+
+func (fc *failingClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
+	return ctx, fmt.Errorf("upload failed")
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+
+	span := NewProtobufSpan()
+	span.Name = "spooled"
+	rsps := []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+	}}
+
+	first, err := SpoolTraces(dir, nil, rsps)
+	if err != nil {
+		t.Fatalf("failed to spool spans: %s", err)
+	}
+	second, err := SpoolTraces(dir, nil, rsps)
+	if err != nil {
+		t.Fatalf("failed to spool spans: %s", err)
+	}
+
+	files, err := ListSpool(dir)
+	if err != nil {
+		t.Fatalf("failed to list spool: %s", err)
+	} else if len(files) != 2 || files[0] != first || files[1] != second {
+		t.Fatalf("expected spool files in order [%s %s] but got %v", first, second, files)
+	}
+
+	loaded, err := LoadSpooled(first, nil)
+	if err != nil {
+		t.Fatalf("failed to load spool file: %s", err)
+	} else if got := loaded[0].ScopeSpans[0].Spans[0].Name; got != "spooled" {
+		t.Errorf("expected span name 'spooled' but got %q", got)
+	}
+
+	// a failed upload must leave the file in place
+	ctx := context.Background()
+	if _, err := FlushSpoolFile(ctx, &failingClient{}, first, nil); err == nil {
+		t.Errorf("expected an error flushing with a failing client")
+	} else if _, err := os.Stat(first); err != nil {
+		t.Errorf("spool file should still exist after a failed flush: %s", err)
+	}
+
+	// a successful upload removes it
+	if _, err := FlushSpoolFile(ctx, &NullClient{}, first, nil); err != nil {
+		t.Errorf("unexpected error flushing: %s", err)
+	}
+	files, _ = ListSpool(dir)
+	if len(files) != 1 || files[0] != second {
+		t.Errorf("expected only %s to remain in the spool but got %v", second, files)
+	}
+}
+
+func TestListSpoolInflight(t *testing.T) {
+	dir := t.TempDir()
+	rsps := []*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{NewProtobufSpan()}}}}}
+
+	stuck, _ := SpoolTraces(dir, nil, rsps)
+	running, _ := SpoolTraces(dir, nil, rsps)
+	for _, file := range []string{stuck, running} {
+		if err := os.Rename(file, file+spoolInflightExt); err != nil {
+			t.Fatalf("failed to mark spool file in flight: %s", err)
+		}
+	}
+	// the stuck flush claimed its file long ago, the running one just now
+	old := time.Now().Add(-2 * spoolInflightStale)
+	os.Chtimes(stuck+spoolInflightExt, old, old)
+
+	files, err := ListSpool(dir)
+	if err != nil {
+		t.Fatalf("failed to list spool: %s", err)
+	} else if len(files) != 1 || files[0] != stuck {
+		t.Fatalf("expected only the stuck file %s to be recovered but got %v", stuck, files)
+	}
+	if _, err := os.Stat(stuck); err != nil {
+		t.Errorf("expected the stuck file to be renamed back: %s", err)
+	}
+	if _, err := os.Stat(running + spoolInflightExt); err != nil {
+		t.Errorf("expected the running flush's file to be left alone: %s", err)
+	}
+}
+
+func TestListSpoolMissingDir(t *testing.T) {
+	files, err := ListSpool(t.TempDir() + "/nope")
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected an empty list and no error for a missing spool dir, got %v, %v", files, err)
+	}
+}
+
+func TestSpoolEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	span := NewProtobufSpan()
+	span.Name = "secret command line"
+	rsps := []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}},
+	}}
+
+	file, err := SpoolTraces(dir, key, rsps)
+	if err != nil {
+		t.Fatalf("failed to spool spans: %s", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read spool file: %s", err)
+	} else if bytes.Contains(data, []byte(span.Name)) {
+		t.Error("expected the spool file to be encrypted but found the span name in it")
+	}
+
+	loaded, err := LoadSpooled(file, key)
+	if err != nil {
+		t.Fatalf("failed to load encrypted spool file: %s", err)
+	} else if got := loaded[0].ScopeSpans[0].Spans[0].Name; got != span.Name {
+		t.Errorf("expected span name %q but got %q", span.Name, got)
+	}
+
+	if _, err := LoadSpooled(file, nil); err == nil {
+		t.Error("expected an error loading an encrypted spool file without a key")
+	}
+	if _, err := LoadSpooled(file, bytes.Repeat([]byte{0x24}, 32)); err == nil {
+		t.Error("expected an error loading an encrypted spool file with the wrong key")
+	}
+
+	// files spooled before encryption was turned on still load
+	plain, err := SpoolTraces(dir, nil, rsps)
+	if err != nil {
+		t.Fatalf("failed to spool spans: %s", err)
+	}
+	if _, err := LoadSpooled(plain, key); err != nil {
+		t.Errorf("expected an unencrypted spool file to load with a key set: %s", err)
+	}
+}
@@ -8,16 +8,23 @@ import (
 	"net"
 	"sync"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // enables gzip decompression
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // GrpcServer is a gRPC/OTLP server handle.
 type GrpcServer struct {
 	server   *grpc.Server
 	callback Callback
+	signals  SignalCallbacks
+	auth     Authenticator
 	stoponce sync.Once
 	stopper  chan struct{}
 	stopdone chan struct{}
@@ -29,11 +36,11 @@ type GrpcServer struct {
 // to run with .Serve().
 func NewGrpcServer(cb Callback, stop Stopper) *GrpcServer {
 	s := GrpcServer{
-		server:   grpc.NewServer(),
 		callback: cb,
 		stopper:  make(chan struct{}),
 		stopdone: make(chan struct{}, 1),
 	}
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(s.authenticate))
 
 	coltracepb.RegisterTraceServiceServer(s.server, &s)
 
@@ -83,10 +90,85 @@ func (gs *GrpcServer) StopWait() {
 	})
 }
 
+// SetSignalCallbacks registers the logs and metrics services for the
+// signals that have a callback. Must be called before Serve.
+func (gs *GrpcServer) SetSignalCallbacks(signals SignalCallbacks) {
+	gs.signals = signals
+	if signals.Logs != nil {
+		collogspb.RegisterLogsServiceServer(gs.server, &grpcLogsService{gs: gs})
+	}
+	if signals.Metrics != nil {
+		colmetricspb.RegisterMetricsServiceServer(gs.server, &grpcMetricsService{gs: gs})
+	}
+}
+
+// SetAuthenticator sets the authenticator that every request must pass.
+// Requests it rejects get an Unauthenticated status.
+func (gs *GrpcServer) SetAuthenticator(auth Authenticator) {
+	gs.auth = auth
+}
+
+// authenticate is a unary interceptor that rejects requests the
+// authenticator doesn't accept before they reach any of the services.
+func (gs *GrpcServer) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if gs.auth != nil {
+		var authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vals := md.Get("authorization"); len(vals) > 0 {
+				authorization = vals[0]
+			}
+		}
+		if !gs.auth(authorization) {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid credentials")
+		}
+	}
+	return handler(ctx, req)
+}
+
 // Export implements the gRPC server interface for exporting messages.
 func (gs *GrpcServer) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
-	// OTLP/gRPC headers are passed in metadata, copy them to serverMeta
-	// for now. This isn't ideal but gets them exposed to the test suite.
+	done := doCallback(ctx, gs.callback, req, grpcHeaders(ctx), map[string]string{"proto": "grpc"})
+	if done {
+		go gs.StopWait()
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// grpcLogsService implements the OTLP logs service for GrpcServer, which
+// can't implement it directly since every service has an Export method.
+type grpcLogsService struct {
+	gs *GrpcServer
+	collogspb.UnimplementedLogsServiceServer
+}
+
+// Export implements the gRPC server interface for exporting logs.
+func (ls *grpcLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	done := doLogsCallback(ctx, ls.gs.signals.Logs, req, grpcHeaders(ctx), map[string]string{"proto": "grpc"})
+	if done {
+		go ls.gs.StopWait()
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// grpcMetricsService implements the OTLP metrics service for GrpcServer.
+type grpcMetricsService struct {
+	gs *GrpcServer
+	colmetricspb.UnimplementedMetricsServiceServer
+}
+
+// Export implements the gRPC server interface for exporting metrics.
+func (ms *grpcMetricsService) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	done := doMetricsCallback(ctx, ms.gs.signals.Metrics, req, grpcHeaders(ctx), map[string]string{"proto": "grpc"})
+	if done {
+		go ms.gs.StopWait()
+	}
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+// grpcHeaders returns the OTLP/gRPC headers, which are passed in metadata,
+// as a map of header name to CSV values. This isn't ideal but gets them
+// exposed to the test suite.
+func grpcHeaders(ctx context.Context) map[string]string {
 	headers := make(map[string]string)
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		for mdk := range md {
@@ -96,10 +178,5 @@ func (gs *GrpcServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 			headers[mdk] = buf.String()
 		}
 	}
-
-	done := doCallback(ctx, gs.callback, req, headers, map[string]string{"proto": "grpc"})
-	if done {
-		go gs.StopWait()
-	}
-	return &coltracepb.ExportTraceServiceResponse{}, nil
+	return headers
 }
@@ -1,13 +1,17 @@
 package otlpserver
 
 import (
+	"compress/gzip"
 	"context"
-	"encoding/json"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"strings"
 
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"google.golang.org/protobuf/proto"
 )
@@ -16,6 +20,8 @@ import (
 type HttpServer struct {
 	server   *http.Server
 	callback Callback
+	signals  SignalCallbacks
+	auth     Authenticator
 }
 
 // NewServer takes a callback and stop function and returns a Server ready
@@ -31,22 +37,93 @@ func NewHttpServer(cb Callback, stop Stopper) *HttpServer {
 	return &s
 }
 
+// SetSignalCallbacks sets the callbacks for logs and metrics. Requests for
+// signals without a callback get a 404.
+func (hs *HttpServer) SetSignalCallbacks(signals SignalCallbacks) {
+	hs.signals = signals
+}
+
+// SetAuthenticator sets the authenticator that every request must pass.
+// Requests it rejects get a 401.
+func (hs *HttpServer) SetAuthenticator(auth Authenticator) {
+	hs.auth = auth
+}
+
 // ServeHTTP processes every request as if it is a trace regardless of
-// method and path or anything else.
+// method and path or anything else, except requests to /v1/logs and
+// /v1/metrics. Those go to the logs and metrics callbacks, or get a 404 like
+// a collector without those receivers would send when there isn't one.
 func (hs *HttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	data, err := io.ReadAll(req.Body)
+	if hs.auth != nil && !hs.auth(req.Header.Get("Authorization")) {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var msg, resp proto.Message
+	var callback func(headers, meta map[string]string) bool
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/v1/logs"):
+		if hs.signals.Logs == nil {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logsReq := collogspb.ExportLogsServiceRequest{}
+		msg, resp = &logsReq, &collogspb.ExportLogsServiceResponse{}
+		callback = func(headers, meta map[string]string) bool {
+			return doLogsCallback(req.Context(), hs.signals.Logs, &logsReq, headers, meta)
+		}
+	case strings.HasSuffix(req.URL.Path, "/v1/metrics"):
+		if hs.signals.Metrics == nil {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		metricsReq := colmetricspb.ExportMetricsServiceRequest{}
+		msg, resp = &metricsReq, &colmetricspb.ExportMetricsServiceResponse{}
+		callback = func(headers, meta map[string]string) bool {
+			return doMetricsCallback(req.Context(), hs.signals.Metrics, &metricsReq, headers, meta)
+		}
+	default:
+		traceReq := coltracepb.ExportTraceServiceRequest{}
+		msg, resp = &traceReq, &coltracepb.ExportTraceServiceResponse{}
+		callback = func(headers, meta map[string]string) bool {
+			return doCallback(req.Context(), hs.callback, &traceReq, headers, meta)
+		}
+	}
+
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			log.Printf("failed to decompress request body: %s", err)
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	// a truncated or corrupt body, gzipped or not, is the client's problem
+	// and mustn't take the server down
+	data, err := io.ReadAll(body)
 	if err != nil {
-		log.Fatalf("Error while reading request body: %s", err)
+		log.Printf("failed to read request body: %s", err)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	msg := coltracepb.ExportTraceServiceRequest{}
-	switch req.Header.Get("Content-Type") {
+	ctype := req.Header.Get("Content-Type")
+	switch ctype {
 	case "application/x-protobuf":
-		proto.Unmarshal(data, &msg)
+		err = proto.Unmarshal(data, msg)
 	case "application/json":
-		json.Unmarshal(data, &msg)
+		err = otlpclient.UnmarshalOtlpJson(data, msg)
 	default:
-		rw.WriteHeader(http.StatusNotAcceptable)
+		rw.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
 	meta := map[string]string{
@@ -62,10 +139,24 @@ func (hs *HttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		headers[k] = req.Header.Get(k)
 	}
 
-	done := doCallback(req.Context(), hs.callback, &msg, headers, meta)
+	done := callback(headers, meta)
 	if done {
 		go hs.StopWait()
 	}
+
+	// respond with an empty success in the same encoding as the request
+	var out []byte
+	if ctype == "application/json" {
+		out, err = otlpclient.MarshalOtlpJson(resp)
+	} else {
+		out, err = proto.Marshal(resp)
+	}
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", ctype)
+	rw.Write(out)
 }
 
 // ServeHttp takes a listener and starts the HTTP server on that listener.
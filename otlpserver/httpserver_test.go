@@ -0,0 +1,68 @@
+package otlpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/equinix-labs/otel-cli/otlpclient"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHttpServerBadGzip(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	received := 0
+	server := NewHttpServer(func(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_Event, rss *tracepb.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+		received++
+		return false
+	}, func(OtlpServer) {})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = otlpclient.GenerateTraceId()
+	span.SpanId = otlpclient.GenerateSpanId()
+	payload, _ := proto.Marshal(&coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span}}}}},
+	})
+	gzipped := bytes.Buffer{}
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write(payload)
+	zw.Close()
+
+	post := func(body []byte) int {
+		req, _ := http.NewRequest("POST", "http://"+listener.Addr().String()+"/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Content-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to post to the server: %s", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// a valid gzip header with the rest cut off fails while reading the body
+	if code := post(gzipped.Bytes()[:gzipped.Len()/2]); code != http.StatusBadRequest {
+		t.Errorf("expected a 400 for a truncated gzip body but got %d", code)
+	}
+	if code := post([]byte("not gzip")); code != http.StatusBadRequest {
+		t.Errorf("expected a 400 for a body that isn't gzip but got %d", code)
+	}
+
+	// the server is still up and takes a good request
+	if code := post(gzipped.Bytes()); code != http.StatusOK {
+		t.Errorf("expected the server to accept a valid gzip body but got %d", code)
+	}
+	if received != 1 {
+		t.Errorf("expected 1 span to be received but got %d", received)
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"context"
 	"net"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	colv1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
@@ -16,6 +20,26 @@ import (
 // called for each incoming span.
 type Callback func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool
 
+// LogsCallback is called for each incoming log record when the server
+// accepts logs.
+type LogsCallback func(context.Context, *logspb.LogRecord, *logspb.ResourceLogs, map[string]string, map[string]string) bool
+
+// MetricsCallback is called for each incoming metric when the server accepts
+// metrics.
+type MetricsCallback func(context.Context, *metricspb.Metric, *metricspb.ResourceMetrics, map[string]string, map[string]string) bool
+
+// SignalCallbacks are the callbacks for the signals besides traces. A server
+// rejects the signals without a callback, like a collector without those
+// receivers configured.
+type SignalCallbacks struct {
+	Logs    LogsCallback
+	Metrics MetricsCallback
+}
+
+// Authenticator is called with the Authorization header of each request and
+// returns false to reject the request as unauthenticated.
+type Authenticator func(authorization string) bool
+
 // Stopper is the function passed to newServer to be called when the
 // server is shut down.
 type Stopper func(OtlpServer)
@@ -27,6 +51,8 @@ type OtlpServer interface {
 	Serve(listener net.Listener) error
 	Stop()
 	StopWait()
+	SetSignalCallbacks(SignalCallbacks)
+	SetAuthenticator(Authenticator)
 }
 
 // NewServer will start the requested server protocol, one of grpc, http/protobuf,
@@ -65,3 +91,35 @@ func doCallback(ctx context.Context, cb Callback, req *colv1.ExportTraceServiceR
 
 	return false
 }
+
+// doLogsCallback unwraps the OTLP logs service request and calls the callback
+// for each log record in the request.
+func doLogsCallback(ctx context.Context, cb LogsCallback, req *collogspb.ExportLogsServiceRequest, headers map[string]string, serverMeta map[string]string) bool {
+	for _, resource := range req.GetResourceLogs() {
+		for _, sl := range resource.GetScopeLogs() {
+			for _, record := range sl.GetLogRecords() {
+				if cb(ctx, record, resource, headers, serverMeta) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// doMetricsCallback unwraps the OTLP metrics service request and calls the
+// callback for each metric in the request.
+func doMetricsCallback(ctx context.Context, cb MetricsCallback, req *colmetricspb.ExportMetricsServiceRequest, headers map[string]string, serverMeta map[string]string) bool {
+	for _, resource := range req.GetResourceMetrics() {
+		for _, sm := range resource.GetScopeMetrics() {
+			for _, metric := range sm.GetMetrics() {
+				if cb(ctx, metric, resource, headers, serverMeta) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
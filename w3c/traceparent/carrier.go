@@ -0,0 +1,113 @@
+package traceparent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// CarrierVersion is the version of the JSON carrier file format written by
+// this package. Readers accept any version up to and including this one.
+const CarrierVersion = 1
+
+// Carrier is the structured, JSON-serialized carrier file format. Where the
+// plain text format only holds a traceparent, Carrier also holds the W3C
+// tracestate, W3C baggage, and some metadata about the span that wrote it.
+type Carrier struct {
+	Version     int          `json:"version"`
+	Traceparent string       `json:"traceparent"`
+	Tracestate  string       `json:"tracestate,omitempty"`
+	Baggage     string       `json:"baggage,omitempty"`
+	Span        *CarrierSpan `json:"span,omitempty"`
+}
+
+// CarrierSpan is the metadata about the originating span stored in a Carrier.
+type CarrierSpan struct {
+	TraceId     string `json:"trace_id"`
+	SpanId      string `json:"span_id"`
+	Name        string `json:"name,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Start       uint64 `json:"start_time_unix_nano,omitempty"`
+	End         uint64 `json:"end_time_unix_nano,omitempty"`
+}
+
+// NewCarrier returns a Carrier at the current version holding the provided
+// traceparent.
+func NewCarrier(tp Traceparent) Carrier {
+	return Carrier{
+		Version:     CarrierVersion,
+		Traceparent: tp.Encode(),
+	}
+}
+
+// GetTraceparent parses the carrier's traceparent and returns it.
+func (c Carrier) GetTraceparent() (Traceparent, error) {
+	if c.Traceparent == "" {
+		return Traceparent{}, nil
+	}
+
+	return Parse(c.Traceparent)
+}
+
+// IsCarrierJson returns true if the data looks like a JSON carrier, i.e. its
+// first non-whitespace character is an opening brace.
+func IsCarrierJson(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// ParseCarrier parses a JSON carrier and validates its version.
+func ParseCarrier(data []byte) (Carrier, error) {
+	c := Carrier{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Carrier{}, fmt.Errorf("could not parse carrier json: %w", err)
+	}
+
+	if c.Version < 1 || c.Version > CarrierVersion {
+		return Carrier{}, fmt.Errorf("unsupported carrier version %d, expected 1-%d", c.Version, CarrierVersion)
+	}
+
+	return c, nil
+}
+
+// LoadCarrierFromFile reads a carrier file and returns it as a Carrier. Both
+// formats are supported: JSON carriers are parsed in full, while plain text
+// carriers are converted to a Carrier with only the traceparent set.
+func LoadCarrierFromFile(filename string) (Carrier, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Carrier{}, fmt.Errorf("could not open file '%s' for read: %s", filename, err)
+	}
+
+	if IsCarrierJson(data) {
+		c, err := ParseCarrier(data)
+		if err != nil {
+			return Carrier{}, fmt.Errorf("file '%s' was read but is not a valid carrier: %w", filename, err)
+		}
+		return c, nil
+	}
+
+	tp, err := LoadFromFile(filename)
+	if err != nil || !tp.Initialized {
+		return Carrier{}, err
+	}
+
+	return NewCarrier(tp), nil
+}
+
+// SaveToFile writes the carrier to the file as indented JSON.
+func (c Carrier) SaveToFile(carrierFile string) error {
+	js, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal carrier to json: %w", err)
+	}
+	js = append(js, '\n')
+
+	err = os.WriteFile(carrierFile, js, 0600)
+	if err != nil {
+		return fmt.Errorf("failure writing carrier file '%s': %w", carrierFile, err)
+	}
+
+	return nil
+}
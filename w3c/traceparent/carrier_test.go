@@ -0,0 +1,82 @@
+package traceparent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCarrierRoundTrip(t *testing.T) {
+	testTp := "00-ce1c6ae29edafc52eb6dd223da7d20b4-1c617f036253531c-01"
+	tp, err := Parse(testTp)
+	if err != nil {
+		t.Fatalf("failed while parsing test TP %q: %s", testTp, err)
+	}
+
+	want := NewCarrier(tp)
+	want.Tracestate = "vendor=abc123"
+	want.Baggage = "userId=alice"
+	want.Span = &CarrierSpan{
+		TraceId: "ce1c6ae29edafc52eb6dd223da7d20b4",
+		SpanId:  "1c617f036253531c",
+		Name:    "build",
+	}
+
+	carrierFile := filepath.Join(t.TempDir(), "carrier.json")
+	if err := want.SaveToFile(carrierFile); err != nil {
+		t.Fatalf("SaveToFile returned an unexpected error: %s", err)
+	}
+
+	got, err := LoadCarrierFromFile(carrierFile)
+	if err != nil {
+		t.Fatalf("LoadCarrierFromFile returned an unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("carrier didn't match expected: (-want +got):\n%s", diff)
+	}
+
+	// LoadFromFile should detect the json carrier and return its traceparent
+	gotTp, err := LoadFromFile(carrierFile)
+	if err != nil {
+		t.Fatalf("LoadFromFile returned an unexpected error: %s", err)
+	}
+	if gotTp.Encode() != testTp {
+		t.Errorf("LoadFromFile failed, expected '%s', got '%s'", testTp, gotTp.Encode())
+	}
+}
+
+func TestLoadCarrierFromTextFile(t *testing.T) {
+	testTp := "00-f61fc53f926e07a9c3893b1a722e1b65-7a2d6a804f3de137-01"
+	carrierFile := filepath.Join(t.TempDir(), "carrier.txt")
+	os.WriteFile(carrierFile, []byte("export TRACEPARENT="+testTp+"\n"), 0600)
+
+	got, err := LoadCarrierFromFile(carrierFile)
+	if err != nil {
+		t.Fatalf("LoadCarrierFromFile returned an unexpected error: %s", err)
+	}
+	if got.Traceparent != testTp {
+		t.Errorf("expected traceparent %q but got %q", testTp, got.Traceparent)
+	}
+	if got.Tracestate != "" || got.Baggage != "" || got.Span != nil {
+		t.Errorf("text carriers should only have a traceparent, got %+v", got)
+	}
+}
+
+func TestParseCarrierVersion(t *testing.T) {
+	for _, tc := range []struct {
+		data    string
+		wantErr bool
+	}{
+		{data: `{"version": 1, "traceparent": ""}`, wantErr: false},
+		{data: `{"traceparent": ""}`, wantErr: true},
+		{data: `{"version": 99, "traceparent": ""}`, wantErr: true},
+		{data: `{"version": `, wantErr: true},
+	} {
+		_, err := ParseCarrier([]byte(tc.data))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseCarrier(%q) error = %v, expected error: %t", tc.data, err, tc.wantErr)
+		}
+	}
+}
@@ -4,7 +4,9 @@ package traceparent
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -77,19 +79,27 @@ func (tp Traceparent) SpanIdString() string {
 // context with the traceparent set. The format for the file as written is
 // just a bare traceparent string. Whitespace, "export " and "TRACEPARENT=" are
 // stripped automatically so the file can also be a valid shell snippet.
+// JSON carrier files (see Carrier) are detected and handled automatically.
 func LoadFromFile(filename string) (Traceparent, error) {
-	file, err := os.Open(filename)
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		errOut := fmt.Errorf("could not open file '%s' for read: %s", filename, err)
 		// only fatal when the tp carrier file is required explicitly, otherwise
 		// just silently return the unmodified context
 		return Traceparent{}, errOut
 	}
-	defer file.Close()
+
+	if IsCarrierJson(data) {
+		c, err := ParseCarrier(data)
+		if err != nil {
+			return Traceparent{}, fmt.Errorf("file '%s' was read but is not a valid carrier: %w", filename, err)
+		}
+		return c.GetTraceparent()
+	}
 
 	// only use the line that contains TRACEPARENT
 	var tp string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		// printSpanData emits comments with trace id and span id, ignore those
@@ -146,6 +156,74 @@ func (tp Traceparent) Fprint(target io.Writer, export bool) error {
 	return err
 }
 
+// FprintFormat writes the traceparent to target in the requested format.
+//
+//	w3c:      the same shell-compatible output as Fprint
+//	hex:      the trace id and span id in hex, separated by a space
+//	trace-id: only the trace id in hex
+//	span-id:  only the span id in hex
+//	xray:     an AWS X-Ray X-Amzn-Trace-Id header value
+//	b3:       a Zipkin B3 single header value
+//	json:     a JSON object with the ids, traceparent, and sampling flag
+//
+// The export flag only applies to the w3c format.
+func (tp Traceparent) FprintFormat(target io.Writer, format string, export bool) error {
+	var err error
+	switch format {
+	case "", "w3c":
+		return tp.Fprint(target, export)
+	case "hex":
+		_, err = fmt.Fprintf(target, "%s %s\n", tp.TraceIdString(), tp.SpanIdString())
+	case "trace-id":
+		_, err = fmt.Fprintln(target, tp.TraceIdString())
+	case "span-id":
+		_, err = fmt.Fprintln(target, tp.SpanIdString())
+	case "xray":
+		_, err = fmt.Fprintln(target, tp.EncodeXray())
+	case "b3":
+		_, err = fmt.Fprintln(target, tp.EncodeB3())
+	case "json":
+		js, jerr := json.Marshal(map[string]interface{}{
+			"trace_id":    tp.TraceIdString(),
+			"span_id":     tp.SpanIdString(),
+			"traceparent": tp.Encode(),
+			"sampled":     tp.Sampling,
+		})
+		if jerr != nil {
+			return fmt.Errorf("failed to marshal traceparent to json: %w", jerr)
+		}
+		_, err = fmt.Fprintln(target, string(js))
+	default:
+		return fmt.Errorf("unsupported traceparent print format %q, must be one of w3c, hex, trace-id, span-id, xray, b3, or json", format)
+	}
+
+	return err
+}
+
+// EncodeXray returns the traceparent as an AWS X-Ray trace header value, e.g.
+// Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+// X-Ray embeds a timestamp in the first 8 hex digits of the trace id, which
+// is carried over as-is, so only W3C ids generated by X-Ray-aware systems
+// will have a meaningful time.
+func (tp Traceparent) EncodeXray() string {
+	traceId := tp.TraceIdString()
+	return fmt.Sprintf("Root=1-%s-%s;Parent=%s;Sampled=%s", traceId[:8], traceId[8:], tp.SpanIdString(), tp.samplingFlag())
+}
+
+// EncodeB3 returns the traceparent as a Zipkin B3 single header value, e.g.
+// 80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1
+func (tp Traceparent) EncodeB3() string {
+	return fmt.Sprintf("%s-%s-%s", tp.TraceIdString(), tp.SpanIdString(), tp.samplingFlag())
+}
+
+// samplingFlag returns "1" when sampling is on and "0" when it is off.
+func (tp Traceparent) samplingFlag() string {
+	if tp.Sampling {
+		return "1"
+	}
+	return "0"
+}
+
 // LoadFromEnv loads the traceparent from the environment variable
 // TRACEPARENT and sets it in the returned Go context.
 func LoadFromEnv() (Traceparent, error) {
@@ -152,3 +152,65 @@ func TestWriteTraceparentToFile(t *testing.T) {
 		t.Errorf("invalid data in traceparent file, expected '%s', got '%s'", testTp, data)
 	}
 }
+
+func TestFprintFormat(t *testing.T) {
+	tp := Traceparent{
+		Version:     0,
+		TraceId:     []byte{0xfe, 0xdc, 0xcb, 0xa9, 0x87, 0x65, 0x43, 0x21, 0xfe, 0xdc, 0xcb, 0xa9, 0x87, 0x65, 0x43, 0x21},
+		SpanId:      []byte{0xde, 0xea, 0xd6, 0xbb, 0xaa, 0xbb, 0xcc, 0xdd},
+		Sampling:    true,
+		Initialized: true,
+	}
+
+	for _, tc := range []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{
+			format: "w3c",
+			want: "# trace id: fedccba987654321fedccba987654321\n" +
+				"#  span id: deead6bbaabbccdd\n" +
+				"TRACEPARENT=00-fedccba987654321fedccba987654321-deead6bbaabbccdd-01\n",
+		},
+		{
+			format: "hex",
+			want:   "fedccba987654321fedccba987654321 deead6bbaabbccdd\n",
+		},
+		{
+			format: "trace-id",
+			want:   "fedccba987654321fedccba987654321\n",
+		},
+		{
+			format: "span-id",
+			want:   "deead6bbaabbccdd\n",
+		},
+		{
+			format: "xray",
+			want:   "Root=1-fedccba9-87654321fedccba987654321;Parent=deead6bbaabbccdd;Sampled=1\n",
+		},
+		{
+			format: "b3",
+			want:   "fedccba987654321fedccba987654321-deead6bbaabbccdd-1\n",
+		},
+		{
+			format: "json",
+			want:   `{"sampled":true,"span_id":"deead6bbaabbccdd","trace_id":"fedccba987654321fedccba987654321","traceparent":"00-fedccba987654321fedccba987654321-deead6bbaabbccdd-01"}` + "\n",
+		},
+		{
+			format:  "bogus",
+			want:    "",
+			wantErr: true,
+		},
+	} {
+		buf := bytes.NewBuffer([]byte{})
+		err := tp.FprintFormat(buf, tc.format, false)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("format %q got error %v, expected error: %t", tc.format, err, tc.wantErr)
+		}
+
+		if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+			t.Errorf("format %q printed tp didn't match expected: (-want +got):\n%s", tc.format, diff)
+		}
+	}
+}